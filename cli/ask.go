@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"prompt-mcp/server"
+)
+
+// Exit codes for the ask command.
+const (
+	exitAnswered = 0
+	exitError    = 1
+	exitDeclined = 2
+	exitTimeout  = 3
+)
+
+var (
+	askMethod  string
+	askTimeout int
+	askRaw     bool
+	askChoices []string
+	askSecret  bool
+	askMulti   bool
+	askDefault string
+)
+
+// askResult is printed to stdout as JSON unless --raw is given.
+type askResult struct {
+	Outcome  string `json:"outcome"`
+	Response string `json:"response,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Method   string `json:"method"`
+}
+
+var askCmd = &cobra.Command{
+	Use:   "ask PROMPT",
+	Short: "Ask the user a single question and print the answer",
+	Long: `Present a prompt exactly as the MCP server would, print the result, and exit.
+
+The result is printed as JSON unless --raw is given. The exit code reflects the
+outcome: 0 when the user answered, 2 when they declined to answer (the Decline
+button, or Esc on the terminal), 3 when the prompt timed out, 1 on error. With
+--raw only an answer is printed.`,
+	Example: `  prompt-mcp ask --method auto --choices yes,no --timeout 60 "Deploy to prod?"
+  BRANCH=$(prompt-mcp ask --raw "Branch name?")`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if askMethod != server.MethodTTY && askMethod != server.MethodWeb && askMethod != server.MethodAuto && askMethod != server.MethodBoth {
+			fmt.Fprintf(os.Stderr, "Error: --method must be tty, web, auto or both (got %q)\n", askMethod)
+			os.Exit(exitError)
+		}
+		if askTimeout < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --timeout must not be negative (got %d)\n", askTimeout)
+			os.Exit(exitError)
+		}
+
+		if askDefault != "" && len(askChoices) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --default cannot be used with --choices\n")
+			os.Exit(exitError)
+		}
+		if askSecret && askMulti {
+			fmt.Fprintf(os.Stderr, "Error: --secret and --multiline cannot be combined\n")
+			os.Exit(exitError)
+		}
+
+		prompt := server.NewPromptRequest(args[0], askMethod)
+		if len(askChoices) > 0 {
+			prompt = server.NewChoicePrompt(args[0], askMethod, askChoices)
+		}
+		prompt.Secret = askSecret
+		prompt.Default = askDefault
+		if askMulti {
+			prompt.Multiline = true
+			prompt.Trim = server.TrimNone
+		}
+		prompt.ID = 1
+		if cmd.Flags().Changed("timeout") {
+			prompt.Timeout = time.Duration(askTimeout) * time.Second
+		}
+
+		// The prompt is asked as the server would, under the same config
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := server.LoadWebAssets(cfg.WebTemplateDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := server.SetWebTheme(cfg.Theme); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		listener, err := cfg.WebListener()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		server.SetWebListener(listener)
+		asked := prompt.Method
+		substituted, err := cfg.PreparePrompt(prompt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		if substituted {
+			fmt.Fprintf(os.Stderr, "Warning: the %s method is not allowed by the config; asking via %s\n", asked, prompt.Method)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		response, err := server.Ask(ctx, server.DefaultProvider(prompt.Method), prompt)
+		server.CloseTunnels()
+		result := askResult{Outcome: "answered", Response: response, Method: prompt.Method}
+		code := exitAnswered
+
+		var declined *server.DeclinedError
+		switch {
+		case errors.Is(err, server.ErrTimeout):
+			result = askResult{Outcome: "timeout", Method: prompt.Method}
+			code = exitTimeout
+		case errors.As(err, &declined):
+			result = askResult{Outcome: "declined", Reason: declined.Reason, Method: prompt.Method}
+			code = exitDeclined
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+
+		if askRaw {
+			if code == exitAnswered {
+				fmt.Println(result.Response)
+			}
+		} else {
+			data, _ := json.Marshal(result)
+			fmt.Println(string(data))
+		}
+		os.Exit(code)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(askCmd)
+
+	askCmd.Flags().StringVarP(&askMethod, "method", "m", server.MethodAuto, "Input method: tty, web, auto or both")
+	askCmd.Flags().IntVarP(&askTimeout, "timeout", "t", 0, "Seconds to wait for an answer (0 waits forever; web defaults to 300)")
+	askCmd.Flags().StringSliceVarP(&askChoices, "choices", "c", nil, "Ask the user to pick one of these options (comma-separated)")
+	askCmd.Flags().StringVarP(&askDefault, "default", "d", "", "Answer used when the user submits an empty response")
+	askCmd.Flags().BoolVarP(&askSecret, "secret", "s", false, "Don't echo the answer while it is typed")
+	askCmd.Flags().BoolVarP(&askMulti, "multiline", "M", false, "Accept several lines, finished with a line containing only '.' or Ctrl+D")
+	askCmd.Flags().BoolVarP(&askRaw, "raw", "r", false, "Print only the answer text")
+	askCmd.Flags().StringVarP(&configPath, "config", "C", "", "Path to a JSON config file, as for serve: allowed methods, web listener, locale")
+}
@@ -8,12 +8,42 @@ import (
 	"syscall"
 
 	"github.com/spf13/cobra"
+	"prompt-mcp/i18n"
 	"prompt-mcp/server"
 )
 
 var (
-	port    int
-	verbose bool
+	port           int
+	verbose        bool
+	configPath     string
+	warnAt         float64
+	inlineLimit    int
+	delivery       string
+	chunkSize      int
+	maxAttempts    int
+	maxUploadBytes int64
+	maxAnswerBytes int64
+	methods        []string
+	toolPrefix     string
+	locale         string
+	pinLocale      bool
+	enableTools    []string
+	disableTools   []string
+
+	observerSocket string
+	webPersistent  bool
+	webHost        string
+	webPortRange   string
+	webExternalURL string
+	webBasePath    string
+	webAuth        string
+	webTunnel      string
+	webPush        bool
+	webQR          bool
+	noBrowser      bool
+	webTemplateDir string
+	historySize    int
+	cacheTTL       int
 )
 
 var rootCmd = &cobra.Command{
@@ -34,8 +64,80 @@ var serveCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "Starting MCP server...\n")
 		}
 
+		cfg, err := buildConfig(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
+		defer server.CloseTunnels()
+
+		srv := server.NewMCPServer()
+		srv.SetConfig(cfg)
+		listener, _ := cfg.WebListener()
+		server.SetWebListener(listener)
+		if listener.QR && listener.ExternalURL == "" && listener.Private() {
+			fmt.Fprintf(os.Stderr, "Warning: --web-qr has no effect while pages are only served on %s; phones can't reach them\n", listener.Host)
+		}
+		if err := server.LoadWebAssets(cfg.WebTemplateDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := server.SetWebTheme(cfg.Theme); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		srv.SetVerbose(verbose)
+
+		if cfg.ObserverSocket != "" {
+			hub, err := server.ListenObservers(cfg.ObserverSocket, cfg.ObserverIncludeAnswers)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: failed to listen on observer socket %s: %v\n", cfg.ObserverSocket, err)
+				os.Exit(1)
+			}
+			defer os.Remove(cfg.ObserverSocket)
+			defer hub.Close()
+			srv.SetObserverHub(hub)
+			if verbose {
+				fmt.Fprintf(os.Stderr, "Streaming prompt events to %s\n", cfg.ObserverSocket)
+			}
+		}
+
+		if cfg.Dashboard() {
+			dashboard, err := server.NewDashboard(cfg.WebPort, cfg.Locale)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if cfg.PinLocale {
+				dashboard.PinLocale()
+			}
+			url, err := dashboard.Start()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			defer dashboard.Close()
+			dashboard.SetHistory(srv.History())
+			dashboard.SetAnswerCache(srv.AnswerCache())
+			dashboard.SetHealth(server.NewHealthHandler(srv))
+			if cfg.WebPush {
+				keyFile, err := cfg.PushKeyFile()
+				if err == nil {
+					var push *server.Pusher
+					if push, err = server.LoadPusher(keyFile); err == nil {
+						dashboard.SetPush(push)
+					}
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: push notifications are off: %v\n", err)
+				}
+			}
+			srv.SetInputProvider(server.MethodWeb, dashboard)
+			fmt.Fprintf(os.Stderr, "Web prompts are served at %s\n", url)
+		}
 
 		// Handle shutdown signals
 		sigChan := make(chan os.Signal, 1)
@@ -45,22 +147,189 @@ var serveCmd = &cobra.Command{
 			if verbose {
 				fmt.Fprintf(os.Stderr, "Shutting down server...\n")
 			}
+			server.CloseTunnels()
 			cancel()
 		}()
 
-		srv := server.NewMCPServer()
+		// Reload the config file on SIGHUP
+		if configPath != "" {
+			hupChan := make(chan os.Signal, 1)
+			signal.Notify(hupChan, syscall.SIGHUP)
+			go func() {
+				for range hupChan {
+					cfg, err := buildConfig(cmd)
+					if err == nil {
+						err = server.SetWebTheme(cfg.Theme)
+					}
+					if err == nil {
+						err = srv.ReloadConfig(cfg)
+					}
+					if err == nil {
+						listener, _ := cfg.WebListener()
+						server.SetWebListener(listener)
+					}
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Config reload failed, keeping previous config: %v\n", err)
+					} else if verbose {
+						fmt.Fprintf(os.Stderr, "Reloaded config from %s\n", configPath)
+					}
+				}
+			}()
+		}
+
 		if err := srv.Start(ctx); err != nil {
 			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+			server.CloseTunnels()
 			os.Exit(1)
 		}
 	},
 }
 
+// buildConfig loads the config file, if any, and applies the flags that were
+// set explicitly on top of it.
+func buildConfig(cmd *cobra.Command) (server.Config, error) {
+	cfg := server.DefaultConfig()
+	if configPath != "" {
+		var err error
+		if cfg, err = server.LoadConfig(configPath); err != nil {
+			return cfg, err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("warn-at") {
+		cfg.WarnFraction = warnAt
+	}
+	if flags.Changed("inline-limit") {
+		cfg.InlineLimit = inlineLimit
+	}
+	if flags.Changed("delivery") {
+		cfg.Delivery = delivery
+	}
+	if flags.Changed("chunk-size") {
+		if chunkSize <= 0 {
+			return cfg, fmt.Errorf("--chunk-size must be positive (got %d)", chunkSize)
+		}
+		cfg.ChunkSize = chunkSize
+	}
+	if flags.Changed("max-attempts") {
+		cfg.MaxAttempts = maxAttempts
+	}
+	if flags.Changed("max-upload-bytes") {
+		if maxUploadBytes <= 0 {
+			return cfg, fmt.Errorf("--max-upload-bytes must be positive (got %d)", maxUploadBytes)
+		}
+		cfg.MaxUploadBytes = maxUploadBytes
+	}
+	if flags.Changed("max-answer-bytes") {
+		if maxAnswerBytes <= 0 {
+			return cfg, fmt.Errorf("--max-answer-bytes must be positive (got %d)", maxAnswerBytes)
+		}
+		cfg.MaxAnswerBytes = maxAnswerBytes
+	}
+	if flags.Changed("observer-socket") {
+		cfg.ObserverSocket = observerSocket
+	}
+	if flags.Changed("web-persistent") {
+		cfg.WebPersistent = webPersistent
+	}
+	if flags.Changed("port") {
+		cfg.WebPort = port
+	}
+	if flags.Changed("web-host") {
+		cfg.WebHost = webHost
+	}
+	if flags.Changed("web-port-range") {
+		cfg.WebPortRange = webPortRange
+	}
+	if flags.Changed("web-external-url") {
+		cfg.WebExternalURL = webExternalURL
+	}
+	if flags.Changed("web-base-path") {
+		cfg.WebBasePath = webBasePath
+	}
+	if flags.Changed("web-auth") {
+		cfg.WebAuth = webAuth
+	}
+	if flags.Changed("web-tunnel") {
+		cfg.WebTunnel = webTunnel
+	}
+	if flags.Changed("web-push") {
+		cfg.WebPush = webPush
+	}
+	if flags.Changed("web-qr") {
+		cfg.WebQR = webQR
+	}
+	if flags.Changed("no-browser") {
+		cfg.NoBrowser = noBrowser
+	}
+	if flags.Changed("history-size") {
+		cfg.HistorySize = historySize
+	}
+	if flags.Changed("cache-ttl") {
+		cfg.CacheTTL = cacheTTL
+	}
+	if flags.Changed("web-template-dir") {
+		cfg.WebTemplateDir = webTemplateDir
+	}
+	if flags.Changed("methods") {
+		cfg.Methods = methods
+	}
+	if flags.Changed("tool-prefix") {
+		cfg.ToolPrefix = toolPrefix
+	}
+	if flags.Changed("locale") {
+		cfg.Locale = locale
+	}
+	if cfg.Locale == "" {
+		cfg.Locale = i18n.FromEnv()
+	}
+	if flags.Changed("pin-locale") {
+		cfg.PinLocale = pinLocale
+	}
+	if flags.Changed("enable-tools") {
+		cfg.Tools.Enable = enableTools
+	}
+	if flags.Changed("disable-tools") {
+		cfg.Tools.Disable = disableTools
+	}
+
+	return cfg, cfg.Validate()
+}
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
-	serveCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on (future use)")
+	serveCmd.Flags().IntVarP(&port, "port", "p", 0, "Port of the web dashboard; setting it implies --web-persistent")
+	serveCmd.Flags().BoolVar(&webPersistent, "web-persistent", false, "Serve web prompts from one dashboard for the whole session instead of a page per prompt")
+	serveCmd.Flags().StringVar(&webHost, "web-host", "127.0.0.1", "Interface web prompt pages are served on")
+	serveCmd.Flags().StringVar(&webPortRange, "web-port-range", "", "Ports web prompt pages may be served on, such as 8400-8500 (default any free port)")
+	serveCmd.Flags().StringVar(&webExternalURL, "web-external-url", "", "URL web prompt pages are reached at through NAT or a proxy, such as https://prompts.example.com")
+	serveCmd.Flags().StringVar(&webBasePath, "web-base-path", "", "Path all web pages are served under, such as /prompt-mcp, for a reverse proxy routing by path")
+	serveCmd.Flags().StringVar(&webAuth, "web-auth", "", "Credentials every web page asks for, as user:password; the password may be a bcrypt hash")
+	serveCmd.Flags().StringVar(&webTunnel, "web-tunnel", "", "Serve web pages at a public URL through a tunnel: cloudflared, ngrok, localhost.run, or a command with {host}, {port} or {url}")
+	serveCmd.Flags().BoolVar(&webPush, "web-push", false, "Offer push notifications of new prompts on the web dashboard; implies --web-persistent")
+	serveCmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Never open a browser for web prompts; print their URL on the terminal instead (the default without a display)")
+	serveCmd.Flags().BoolVar(&webQR, "web-qr", false, "Print a QR code of each web prompt on the terminal, to answer from a phone (needs a LAN-reachable --web-host or --web-external-url)")
+	serveCmd.Flags().IntVar(&historySize, "history-size", 100, "Past prompts listed on the dashboard's history page (negative keeps none)")
+	serveCmd.Flags().IntVar(&cacheTTL, "cache-ttl", 3600, "Seconds an answer the user asked to remember is reused for the same question (negative never remembers)")
+	serveCmd.Flags().StringVar(&webTemplateDir, "web-template-dir", "", "Directory of templates/ and static/ files replacing the built-in web pages' (missing files keep the built-in ones)")
 	serveCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	serveCmd.Flags().StringVarP(&configPath, "config", "C", "", "Path to a JSON config file (reloaded on SIGHUP)")
+	serveCmd.Flags().Float64VarP(&warnAt, "warn-at", "w", 0.8, "Fraction of a prompt's timeout after which the client is warned (negative disables)")
+	serveCmd.Flags().IntVarP(&inlineLimit, "inline-limit", "l", 64*1024, "Largest answer in bytes returned inline; larger answers become resource links (negative always inlines)")
+	serveCmd.Flags().StringVarP(&delivery, "delivery", "d", server.DeliveryInline, "Default answer delivery: inline or chunked")
+	serveCmd.Flags().IntVarP(&chunkSize, "chunk-size", "c", 16*1024, "Largest chunk in bytes when answers are delivered chunked")
+	serveCmd.Flags().IntVarP(&maxAttempts, "max-attempts", "a", 3, "Invalid answers allowed before a validated prompt fails (negative never gives up)")
+	serveCmd.Flags().Int64Var(&maxUploadBytes, "max-upload-bytes", 5*1024*1024, "Largest file in bytes a user may send to a prompt with accept_file")
+	serveCmd.Flags().Int64Var(&maxAnswerBytes, "max-answer-bytes", 1024*1024, "Largest text answer in bytes the web form accepts, such as a pasted log")
+	serveCmd.Flags().StringVarP(&observerSocket, "observer-socket", "o", "", "Unix socket path streaming prompt lifecycle events as JSON lines")
+	serveCmd.Flags().StringSliceVar(&methods, "methods", nil, "Input methods agents may use, in order of preference (tty, web); others fall back to the first")
+	serveCmd.Flags().StringVar(&toolPrefix, "tool-prefix", "", "Prefix for every tool name, such as prompt_ (the tools config keeps the plain names)")
+	serveCmd.Flags().StringVar(&locale, "locale", "", "Language of prompt labels and buttons (en, de, es, fr, ja); defaults to LANG")
+	serveCmd.Flags().BoolVar(&pinLocale, "pin-locale", false, "Keep web pages in --locale instead of the browser's language")
+	serveCmd.Flags().StringSliceVarP(&enableTools, "enable-tools", "E", nil, "Only expose these tools (comma-separated)")
+	serveCmd.Flags().StringSliceVarP(&disableTools, "disable-tools", "D", nil, "Hide and refuse calls to these tools (comma-separated)")
 }
 
 func main() {
@@ -0,0 +1,102 @@
+package i18n
+
+var de = map[string]string{
+	PageTitle:                 "Eingabe erforderlich",
+	Submit:                    "Absenden",
+	Submitting:                "Wird gesendet...",
+	Placeholder:               "Antwort eingeben...",
+	NumberPlaceholder:         "Zahl eingeben...",
+	PathPlaceholder:           "Pfad eingeben...",
+	RepeatPlaceholder:         "Zur Bestätigung wiederholen...",
+	ShowContext:               "Kontext anzeigen",
+	Details:                   "Details",
+	CriticalBanner:            "Wichtig: vor dem Antworten sorgfältig lesen",
+	TimeLeft:                  "Diese Anfrage läuft ab in",
+	ExpiresAt:                 "Diese Anfrage läuft ab um",
+	AskedAgo:                  "Gefragt vor %s",
+	DeadlineBanner:            "Der Agent wartet nur noch kurz auf diese Antwort.",
+	AutoDeny:                  "Automatische Ablehnung in",
+	AutoApprove:               "Automatische Genehmigung in",
+	PageTimedOut:              "Zeit abgelaufen. Sie können diesen Tab schließen.",
+	PageCancelled:             "Vom Agenten abgebrochen. Sie können diesen Tab schließen.",
+	PageWithdrawn:             "Der Agent hat diese Anfrage zurückgezogen. Sie können diesen Tab schließen.",
+	PageAnsweredElsewhere:     "Bereits anderswo beantwortet. Sie können diesen Tab schließen.",
+	PageAnsweredInTTY:         "Bereits im Terminal beantwortet. Sie können diesen Tab schließen.",
+	PageFailed:                "Zu viele ungültige Versuche. Sie können diesen Tab schließen.",
+	PageCompleted:             "Bereits beantwortet",
+	PageClosed:                "Nicht mehr offen",
+	Approve:                   "Zustimmen",
+	Deny:                      "Ablehnen",
+	ApproveWithComment:        "Mit Kommentar zustimmen",
+	Reject:                    "Zurückweisen",
+	Revise:                    "Überarbeiten",
+	RevisePlaceholder:         "Was soll sich ändern? (nötig für Überarbeiten)",
+	ReadConfirm:               "Ich habe es gelesen",
+	ReadHint:                  "Bis zum Ende scrollen, um zu bestätigen",
+	Confirm:                   "Bestätigen",
+	Cancel:                    "Abbrechen",
+	Continue:                  "Weiter",
+	Select:                    "Auswählen",
+	ThankYou:                  "Vielen Dank!",
+	Submitted:                 "Ihre Antwort wurde übermittelt. Sie können diesen Tab schließen.",
+	RememberAnswer:            "Diese Antwort merken",
+	RememberHint:              "Antwort mit !remember beenden, um sie für diese Frage wiederzuverwenden",
+	ResponseLabel:             "Antwort",
+	NumberLabel:               "Zahl",
+	DurationLabel:             "Dauer",
+	PathLabel:                 "Pfad",
+	SecretLabel:               "Geheimnis (verborgen)",
+	RepeatLabel:               "Zur Bestätigung wiederholen",
+	PressEnter:                "Weiter mit Enter...",
+	AnsweredInBrowser:         "Im Browser beantwortet",
+	Withdrawn:                 "Der Agent hat diese Anfrage zurückgezogen",
+	DashboardTitle:            "Offene Fragen",
+	DashboardEmpty:            "Keine Frage wartet auf eine Antwort. Neue Fragen erscheinen hier, sobald sie eintreffen.",
+	DashboardBack:             "Zurück zu den offenen Fragen",
+	DashboardAsked:            "Gestellt um",
+	DashboardOpen:             "In eigenem Tab öffnen",
+	DashboardForget:           "Gemerkte Antworten vergessen",
+	DashboardNotify:           "Bei neuen Fragen benachrichtigen",
+	DashboardOffline:          "Verbindung getrennt",
+	DashboardReconnect:        "Der Server ist nicht erreichbar. Die Seite verbindet sich von selbst neu, sobald er wieder da ist.",
+	DashboardResolved:         "Erledigt",
+	PushTitle:                 "Agent braucht eine Eingabe",
+	AboutToRespond:            "Sie sind dabei zu antworten:",
+	EditAnswer:                "Bearbeiten",
+	SendThis:                  "Das senden? [y/n]",
+	Decline:                   "Antwort ablehnen",
+	DeclineReason:             "Grund (optional)",
+	DeclineBack:               "Zurück zur Frage",
+	PageDeclined:              "Sie haben die Antwort abgelehnt. Sie können diesen Tab schließen.",
+	AnswerLabel:               "Ihre Antwort",
+	QuestionLabel:             "Frage",
+	RequestedBy:               "Angefragt von",
+	UnknownClient:             "unbekannter Client",
+	SessionQuestion:           "Frage",
+	HistoryTitle:              "Verlauf der Fragen",
+	HistoryEmpty:              "Bisher wurden keine Fragen gestellt.",
+	HistoryNoMatch:            "Keine Frage passt zum Filter.",
+	HistoryFilter:             "Filtern",
+	HistoryTook:               "Beantwortet in",
+	HistoryTimedOut:           "Abgelaufen nach",
+	HistoryFailed:             "Fehlgeschlagen nach",
+	HistoryDeclined:           "Abgelehnt nach",
+	HistoryCached:             "Aus dem Gedächtnis beantwortet",
+	LateTimedOut:              "Zeit abgelaufen",
+	LateTimedOutText:          "Die Zeit für diese Eingabe ist abgelaufen, bevor Ihre Antwort ankam, daher wurde sie nicht verwendet.",
+	LateDefaultUsedText:       "Die Zeit für diese Eingabe ist abgelaufen, bevor Ihre Antwort ankam, daher wurde stattdessen die Standardantwort %q verwendet.",
+	LateCancelled:             "Abgebrochen",
+	LateCancelledText:         "Diese Eingabe wurde abgebrochen, bevor Ihre Antwort ankam, daher wurde sie nicht verwendet.",
+	LateWithdrawn:             "Zurückgezogen",
+	LateWithdrawnText:         "Der Agent hat diese Anfrage zurückgezogen, bevor Ihre Antwort ankam, daher wurde sie nicht verwendet.",
+	LateAnsweredInTTY:         "Im Terminal beantwortet",
+	LateAnsweredInTTYText:     "Diese Eingabe wurde im Terminal beantwortet, bevor Ihre Antwort ankam, daher wurde sie nicht verwendet.",
+	LateAnsweredElsewhere:     "Anderswo beantwortet",
+	LateAnsweredElsewhereText: "Diese Eingabe wurde anderswo beantwortet, bevor Ihre Antwort ankam, daher wurde sie nicht verwendet.",
+	CloseTab:                  "Sie können diesen Tab schließen.",
+	EntriesMismatch:           "Die Eingaben stimmen nicht überein, bitte erneut versuchen",
+	ResponseEmpty:             "Die Antwort darf nicht leer sein",
+	AttemptCount:              "Versuch %d von %d",
+	ReviewStale:               "Diese Antwort wurde durch eine neuere ersetzt; bitte prüfen und erneut senden",
+	AlreadySubmitted:          "Antwort bereits gesendet",
+}
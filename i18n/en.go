@@ -0,0 +1,102 @@
+package i18n
+
+var en = map[string]string{
+	PageTitle:                 "User Input Required",
+	Submit:                    "Submit",
+	Submitting:                "Submitting...",
+	Placeholder:               "Enter your response...",
+	NumberPlaceholder:         "Enter a number...",
+	PathPlaceholder:           "Enter a path...",
+	RepeatPlaceholder:         "Repeat to confirm...",
+	ShowContext:               "Show context",
+	Details:                   "Details",
+	CriticalBanner:            "Critical: read carefully before answering",
+	TimeLeft:                  "This request expires in",
+	ExpiresAt:                 "This request expires at",
+	AskedAgo:                  "Asked %s ago",
+	DeadlineBanner:            "The agent will stop waiting for this answer very soon.",
+	AutoDeny:                  "Auto-denying in",
+	AutoApprove:               "Auto-approving in",
+	PageTimedOut:              "Timed out. You can close this tab.",
+	PageCancelled:             "Cancelled by the agent. You can close this tab.",
+	PageWithdrawn:             "The agent withdrew this request. You can close this tab.",
+	PageAnsweredElsewhere:     "Answered elsewhere. You can close this tab.",
+	PageAnsweredInTTY:         "Answered in the terminal. You can close this tab.",
+	PageFailed:                "Too many invalid attempts. You can close this tab.",
+	PageCompleted:             "Already answered",
+	PageClosed:                "No longer open",
+	Approve:                   "Approve",
+	Deny:                      "Deny",
+	ApproveWithComment:        "Approve with comment",
+	Reject:                    "Reject",
+	Revise:                    "Revise",
+	RevisePlaceholder:         "What should change? (needed for Revise)",
+	ReadConfirm:               "I have read this",
+	ReadHint:                  "Scroll to the end to confirm",
+	Confirm:                   "Confirm",
+	Cancel:                    "Cancel",
+	Continue:                  "Continue",
+	Select:                    "Select",
+	ThankYou:                  "Thank you!",
+	Submitted:                 "Your response has been submitted. You can close this tab.",
+	RememberAnswer:            "Remember this answer",
+	RememberHint:              "End the answer with !remember to reuse it for this question",
+	ResponseLabel:             "Response",
+	NumberLabel:               "Number",
+	DurationLabel:             "Duration",
+	PathLabel:                 "Path",
+	SecretLabel:               "Secret (hidden)",
+	RepeatLabel:               "Repeat to confirm",
+	PressEnter:                "Press Enter to continue...",
+	AnsweredInBrowser:         "Answered in the browser",
+	Withdrawn:                 "The agent withdrew this request",
+	DashboardTitle:            "Pending prompts",
+	DashboardEmpty:            "Nothing is waiting for an answer. New prompts appear here as they arrive.",
+	DashboardBack:             "Back to pending prompts",
+	DashboardAsked:            "Asked at",
+	DashboardOpen:             "Open in its own tab",
+	DashboardForget:           "Forget remembered answers",
+	DashboardNotify:           "Notify me of new prompts",
+	DashboardOffline:          "Disconnected",
+	DashboardReconnect:        "The prompt server can't be reached. This page reconnects by itself once it is back.",
+	DashboardResolved:         "Resolved",
+	PushTitle:                 "Agent needs input",
+	AboutToRespond:            "You are about to respond:",
+	EditAnswer:                "Edit",
+	SendThis:                  "Send this? [y/n]",
+	Decline:                   "Decline to answer",
+	DeclineReason:             "Reason (optional)",
+	DeclineBack:               "Back to the question",
+	PageDeclined:              "You declined to answer. You can close this tab.",
+	AnswerLabel:               "Your answer",
+	QuestionLabel:             "Question",
+	RequestedBy:               "Requested by",
+	UnknownClient:             "unknown client",
+	SessionQuestion:           "question",
+	HistoryTitle:              "Prompt history",
+	HistoryEmpty:              "No prompts have been asked yet.",
+	HistoryNoMatch:            "No prompts match the filter.",
+	HistoryFilter:             "Filter",
+	HistoryTook:               "Answered in",
+	HistoryTimedOut:           "Timed out after",
+	HistoryFailed:             "Failed after",
+	HistoryDeclined:           "Declined after",
+	HistoryCached:             "Answered from memory",
+	LateTimedOut:              "Timed out",
+	LateTimedOutText:          "This prompt timed out before your response arrived, so it was not used.",
+	LateDefaultUsedText:       "This prompt timed out before your response arrived, so the default answer %q was used instead.",
+	LateCancelled:             "Cancelled",
+	LateCancelledText:         "This prompt was cancelled before your response arrived, so it was not used.",
+	LateWithdrawn:             "Withdrawn",
+	LateWithdrawnText:         "The agent withdrew this request before your response arrived, so it was not used.",
+	LateAnsweredInTTY:         "Answered in the terminal",
+	LateAnsweredInTTYText:     "This prompt was answered in the terminal before your response arrived, so it was not used.",
+	LateAnsweredElsewhere:     "Answered elsewhere",
+	LateAnsweredElsewhereText: "This prompt was answered elsewhere before your response arrived, so it was not used.",
+	CloseTab:                  "You can close this tab.",
+	EntriesMismatch:           "Entries did not match, try again",
+	ResponseEmpty:             "Response cannot be empty",
+	AttemptCount:              "Attempt %d of %d",
+	ReviewStale:               "This answer was replaced by a newer one; check it and submit again",
+	AlreadySubmitted:          "Response already submitted",
+}
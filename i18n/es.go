@@ -0,0 +1,102 @@
+package i18n
+
+var es = map[string]string{
+	PageTitle:                 "Se requiere una respuesta",
+	Submit:                    "Enviar",
+	Submitting:                "Enviando...",
+	Placeholder:               "Escriba su respuesta...",
+	NumberPlaceholder:         "Escriba un número...",
+	PathPlaceholder:           "Escriba una ruta...",
+	RepeatPlaceholder:         "Repita para confirmar...",
+	ShowContext:               "Mostrar contexto",
+	Details:                   "Detalles",
+	CriticalBanner:            "Importante: lea con atención antes de responder",
+	TimeLeft:                  "Esta solicitud caduca en",
+	ExpiresAt:                 "Esta solicitud caduca a las",
+	AskedAgo:                  "Preguntada hace %s",
+	DeadlineBanner:            "El agente dejará de esperar esta respuesta muy pronto.",
+	AutoDeny:                  "Denegación automática en",
+	AutoApprove:               "Aprobación automática en",
+	PageTimedOut:              "Tiempo agotado. Puede cerrar esta pestaña.",
+	PageCancelled:             "Cancelado por el agente. Puede cerrar esta pestaña.",
+	PageWithdrawn:             "El agente retiró esta solicitud. Puede cerrar esta pestaña.",
+	PageAnsweredElsewhere:     "Ya se respondió en otro lugar. Puede cerrar esta pestaña.",
+	PageAnsweredInTTY:         "Ya se respondió en la terminal. Puede cerrar esta pestaña.",
+	PageFailed:                "Demasiados intentos no válidos. Puede cerrar esta pestaña.",
+	PageCompleted:             "Ya respondido",
+	PageClosed:                "Ya no está abierto",
+	Approve:                   "Aprobar",
+	Deny:                      "Denegar",
+	ApproveWithComment:        "Aprobar con comentario",
+	Reject:                    "Rechazar",
+	Revise:                    "Revisar",
+	RevisePlaceholder:         "¿Qué debería cambiar? (necesario para Revisar)",
+	ReadConfirm:               "Lo he leído",
+	ReadHint:                  "Desplázate hasta el final para confirmar",
+	Confirm:                   "Confirmar",
+	Cancel:                    "Cancelar",
+	Continue:                  "Continuar",
+	Select:                    "Elegir",
+	ThankYou:                  "¡Gracias!",
+	Submitted:                 "Su respuesta se ha enviado. Puede cerrar esta pestaña.",
+	RememberAnswer:            "Recordar esta respuesta",
+	RememberHint:              "Termine la respuesta con !remember para reutilizarla en esta pregunta",
+	ResponseLabel:             "Respuesta",
+	NumberLabel:               "Número",
+	DurationLabel:             "Duración",
+	PathLabel:                 "Ruta",
+	SecretLabel:               "Secreto (oculto)",
+	RepeatLabel:               "Repita para confirmar",
+	PressEnter:                "Pulse Intro para continuar...",
+	AnsweredInBrowser:         "Respondido en el navegador",
+	Withdrawn:                 "El agente retiró esta solicitud",
+	DashboardTitle:            "Preguntas pendientes",
+	DashboardEmpty:            "No hay ninguna pregunta esperando respuesta. Las nuevas aparecen aquí en cuanto llegan.",
+	DashboardBack:             "Volver a las preguntas pendientes",
+	DashboardAsked:            "Preguntada a las",
+	DashboardOpen:             "Abrir en su propia pestaña",
+	DashboardForget:           "Olvidar las respuestas recordadas",
+	DashboardNotify:           "Avisarme de nuevas preguntas",
+	DashboardOffline:          "Desconectado",
+	DashboardReconnect:        "No se puede acceder al servidor. Esta página se reconecta sola en cuanto vuelva.",
+	DashboardResolved:         "Resueltas",
+	PushTitle:                 "El agente necesita una respuesta",
+	AboutToRespond:            "Está a punto de responder:",
+	EditAnswer:                "Editar",
+	SendThis:                  "¿Enviar esto? [y/n]",
+	Decline:                   "No responder",
+	DeclineReason:             "Motivo (opcional)",
+	DeclineBack:               "Volver a la pregunta",
+	PageDeclined:              "Ha rechazado responder. Puede cerrar esta pestaña.",
+	AnswerLabel:               "Su respuesta",
+	QuestionLabel:             "Pregunta",
+	RequestedBy:               "Solicitado por",
+	UnknownClient:             "cliente desconocido",
+	SessionQuestion:           "pregunta",
+	HistoryTitle:              "Historial de preguntas",
+	HistoryEmpty:              "Todavía no se ha hecho ninguna pregunta.",
+	HistoryNoMatch:            "Ninguna pregunta coincide con el filtro.",
+	HistoryFilter:             "Filtrar",
+	HistoryTook:               "Respondida en",
+	HistoryTimedOut:           "Caducada tras",
+	HistoryFailed:             "Fallida tras",
+	HistoryDeclined:           "Rechazado tras",
+	HistoryCached:             "Respondido de memoria",
+	LateTimedOut:              "Tiempo agotado",
+	LateTimedOutText:          "El tiempo de esta solicitud se agotó antes de que llegara su respuesta, así que no se usó.",
+	LateDefaultUsedText:       "El tiempo de esta solicitud se agotó antes de que llegara su respuesta, así que se usó la respuesta predeterminada %q.",
+	LateCancelled:             "Cancelada",
+	LateCancelledText:         "Esta solicitud se canceló antes de que llegara su respuesta, así que no se usó.",
+	LateWithdrawn:             "Retirada",
+	LateWithdrawnText:         "El agente retiró esta solicitud antes de que llegara su respuesta, así que no se usó.",
+	LateAnsweredInTTY:         "Respondida en la terminal",
+	LateAnsweredInTTYText:     "Esta solicitud se respondió en la terminal antes de que llegara su respuesta, así que no se usó.",
+	LateAnsweredElsewhere:     "Respondida en otro lugar",
+	LateAnsweredElsewhereText: "Esta solicitud se respondió en otro lugar antes de que llegara su respuesta, así que no se usó.",
+	CloseTab:                  "Puede cerrar esta pestaña.",
+	EntriesMismatch:           "Las entradas no coinciden, inténtelo de nuevo",
+	ResponseEmpty:             "La respuesta no puede estar vacía",
+	AttemptCount:              "Intento %d de %d",
+	ReviewStale:               "Esta respuesta fue sustituida por una más reciente; revísela y envíela de nuevo",
+	AlreadySubmitted:          "Respuesta ya enviada",
+}
@@ -0,0 +1,102 @@
+package i18n
+
+var fr = map[string]string{
+	PageTitle:                 "Saisie requise",
+	Submit:                    "Envoyer",
+	Submitting:                "Envoi...",
+	Placeholder:               "Saisissez votre réponse...",
+	NumberPlaceholder:         "Saisissez un nombre...",
+	PathPlaceholder:           "Saisissez un chemin...",
+	RepeatPlaceholder:         "Répétez pour confirmer...",
+	ShowContext:               "Afficher le contexte",
+	Details:                   "Détails",
+	CriticalBanner:            "Important : lisez attentivement avant de répondre",
+	TimeLeft:                  "Cette demande expire dans",
+	ExpiresAt:                 "Cette demande expire à",
+	AskedAgo:                  "Posée il y a %s",
+	DeadlineBanner:            "L’agent va très bientôt cesser d’attendre cette réponse.",
+	AutoDeny:                  "Refus automatique dans",
+	AutoApprove:               "Approbation automatique dans",
+	PageTimedOut:              "Délai dépassé. Vous pouvez fermer cet onglet.",
+	PageCancelled:             "Annulé par l'agent. Vous pouvez fermer cet onglet.",
+	PageWithdrawn:             "L’agent a retiré cette demande. Vous pouvez fermer cet onglet.",
+	PageAnsweredElsewhere:     "Déjà répondu ailleurs. Vous pouvez fermer cet onglet.",
+	PageAnsweredInTTY:         "Déjà répondu dans le terminal. Vous pouvez fermer cet onglet.",
+	PageFailed:                "Trop de tentatives invalides. Vous pouvez fermer cet onglet.",
+	PageCompleted:             "Déjà répondu",
+	PageClosed:                "Plus ouvert",
+	Approve:                   "Approuver",
+	Deny:                      "Refuser",
+	ApproveWithComment:        "Approuver avec un commentaire",
+	Reject:                    "Rejeter",
+	Revise:                    "Réviser",
+	RevisePlaceholder:         "Que faut-il changer ? (requis pour Réviser)",
+	ReadConfirm:               "J'ai lu ce texte",
+	ReadHint:                  "Faites défiler jusqu'à la fin pour confirmer",
+	Confirm:                   "Confirmer",
+	Cancel:                    "Annuler",
+	Continue:                  "Continuer",
+	Select:                    "Choisir",
+	ThankYou:                  "Merci !",
+	Submitted:                 "Votre réponse a été envoyée. Vous pouvez fermer cet onglet.",
+	RememberAnswer:            "Se souvenir de cette réponse",
+	RememberHint:              "Terminez la réponse par !remember pour la réutiliser pour cette question",
+	ResponseLabel:             "Réponse",
+	NumberLabel:               "Nombre",
+	DurationLabel:             "Durée",
+	PathLabel:                 "Chemin",
+	SecretLabel:               "Secret (masqué)",
+	RepeatLabel:               "Répétez pour confirmer",
+	PressEnter:                "Appuyez sur Entrée pour continuer...",
+	AnsweredInBrowser:         "Répondu dans le navigateur",
+	Withdrawn:                 "L’agent a retiré cette demande",
+	DashboardTitle:            "Questions en attente",
+	DashboardEmpty:            "Aucune question n'attend de réponse. Les nouvelles questions apparaissent ici dès leur arrivée.",
+	DashboardBack:             "Retour aux questions en attente",
+	DashboardAsked:            "Posée à",
+	DashboardOpen:             "Ouvrir dans son propre onglet",
+	DashboardForget:           "Oublier les réponses mémorisées",
+	DashboardNotify:           "Me notifier des nouvelles questions",
+	DashboardOffline:          "Déconnecté",
+	DashboardReconnect:        "Le serveur est injoignable. Cette page se reconnecte d’elle-même dès son retour.",
+	DashboardResolved:         "Résolues",
+	PushTitle:                 "L'agent attend une réponse",
+	AboutToRespond:            "Vous allez répondre :",
+	EditAnswer:                "Modifier",
+	SendThis:                  "Envoyer ceci ? [y/n]",
+	Decline:                   "Refuser de répondre",
+	DeclineReason:             "Raison (facultatif)",
+	DeclineBack:               "Retour à la question",
+	PageDeclined:              "Vous avez refusé de répondre. Vous pouvez fermer cet onglet.",
+	AnswerLabel:               "Votre réponse",
+	QuestionLabel:             "Question",
+	RequestedBy:               "Demandé par",
+	UnknownClient:             "client inconnu",
+	SessionQuestion:           "question",
+	HistoryTitle:              "Historique des questions",
+	HistoryEmpty:              "Aucune question n'a encore été posée.",
+	HistoryNoMatch:            "Aucune question ne correspond au filtre.",
+	HistoryFilter:             "Filtrer",
+	HistoryTook:               "Répondue en",
+	HistoryTimedOut:           "Expirée après",
+	HistoryFailed:             "Échouée après",
+	HistoryDeclined:           "Refusé après",
+	HistoryCached:             "Répondu de mémoire",
+	LateTimedOut:              "Délai dépassé",
+	LateTimedOutText:          "Le délai de cette demande a expiré avant l'arrivée de votre réponse, elle n'a donc pas été utilisée.",
+	LateDefaultUsedText:       "Le délai de cette demande a expiré avant l'arrivée de votre réponse, la réponse par défaut %q a donc été utilisée à la place.",
+	LateCancelled:             "Annulée",
+	LateCancelledText:         "Cette demande a été annulée avant l'arrivée de votre réponse, elle n'a donc pas été utilisée.",
+	LateWithdrawn:             "Retirée",
+	LateWithdrawnText:         "L'agent a retiré cette demande avant l'arrivée de votre réponse, elle n'a donc pas été utilisée.",
+	LateAnsweredInTTY:         "Répondu dans le terminal",
+	LateAnsweredInTTYText:     "Cette demande a reçu une réponse dans le terminal avant l'arrivée de la vôtre, qui n'a donc pas été utilisée.",
+	LateAnsweredElsewhere:     "Répondu ailleurs",
+	LateAnsweredElsewhereText: "Cette demande a reçu une réponse ailleurs avant l'arrivée de la vôtre, qui n'a donc pas été utilisée.",
+	CloseTab:                  "Vous pouvez fermer cet onglet.",
+	EntriesMismatch:           "Les saisies ne correspondent pas, réessayez",
+	ResponseEmpty:             "La réponse ne peut pas être vide",
+	AttemptCount:              "Tentative %d sur %d",
+	ReviewStale:               "Cette réponse a été remplacée par une plus récente ; vérifiez-la et envoyez-la à nouveau",
+	AlreadySubmitted:          "Réponse déjà envoyée",
+}
@@ -0,0 +1,189 @@
+// Package i18n holds the translations of the text the server puts around a
+// prompt: page titles, buttons, field labels and placeholders. The prompt
+// itself comes from the agent and is never translated.
+//
+// Adding a language means adding its table to catalog; nothing outside this
+// package needs to change.
+package i18n
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// Fallback is the locale used for unknown locales and missing keys.
+const Fallback = "en"
+
+// Message keys.
+const (
+	PageTitle                 = "page_title"
+	Submit                    = "submit"
+	Submitting                = "submitting"
+	Placeholder               = "placeholder"
+	NumberPlaceholder         = "number_placeholder"
+	PathPlaceholder           = "path_placeholder"
+	RepeatPlaceholder         = "repeat_placeholder"
+	ShowContext               = "show_context"
+	Details                   = "details"
+	CriticalBanner            = "critical_banner"
+	TimeLeft                  = "time_left"
+	ExpiresAt                 = "expires_at"
+	AskedAgo                  = "asked_ago"
+	DeadlineBanner            = "deadline_banner"
+	AutoDeny                  = "auto_deny"
+	AutoApprove               = "auto_approve"
+	PageTimedOut              = "page_timed_out"
+	PageCancelled             = "page_cancelled"
+	PageWithdrawn             = "page_withdrawn"
+	PageAnsweredElsewhere     = "page_answered_elsewhere"
+	PageAnsweredInTTY         = "page_answered_in_tty"
+	PageFailed                = "page_failed"
+	PageCompleted             = "page_completed"
+	PageClosed                = "page_closed"
+	Approve                   = "approve"
+	Deny                      = "deny"
+	ApproveWithComment        = "approve_with_comment"
+	Reject                    = "reject"
+	Revise                    = "revise"
+	RevisePlaceholder         = "revise_placeholder"
+	ReadConfirm               = "read_confirm"
+	ReadHint                  = "read_hint"
+	Confirm                   = "confirm"
+	Cancel                    = "cancel"
+	Continue                  = "continue"
+	Select                    = "select"
+	ThankYou                  = "thank_you"
+	Submitted                 = "submitted"
+	RememberAnswer            = "remember_answer"
+	RememberHint              = "remember_hint"
+	ResponseLabel             = "response_label"
+	NumberLabel               = "number_label"
+	DurationLabel             = "duration_label"
+	PathLabel                 = "path_label"
+	SecretLabel               = "secret_label"
+	RepeatLabel               = "repeat_label"
+	PressEnter                = "press_enter"
+	AnsweredInBrowser         = "answered_in_browser"
+	Withdrawn                 = "withdrawn"
+	DashboardTitle            = "dashboard_title"
+	DashboardEmpty            = "dashboard_empty"
+	DashboardBack             = "dashboard_back"
+	DashboardAsked            = "dashboard_asked"
+	DashboardOpen             = "dashboard_open"
+	DashboardForget           = "dashboard_forget"
+	DashboardNotify           = "dashboard_notify"
+	DashboardOffline          = "dashboard_offline"
+	DashboardReconnect        = "dashboard_reconnect"
+	DashboardResolved         = "dashboard_resolved"
+	PushTitle                 = "push_title"
+	AboutToRespond            = "about_to_respond"
+	EditAnswer                = "edit_answer"
+	SendThis                  = "send_this"
+	Decline                   = "decline"
+	DeclineReason             = "decline_reason"
+	DeclineBack               = "decline_back"
+	PageDeclined              = "page_declined"
+	AnswerLabel               = "answer_label"
+	QuestionLabel             = "question_label"
+	RequestedBy               = "requested_by"
+	UnknownClient             = "unknown_client"
+	SessionQuestion           = "session_question"
+	HistoryTitle              = "history_title"
+	HistoryEmpty              = "history_empty"
+	HistoryNoMatch            = "history_no_match"
+	HistoryFilter             = "history_filter"
+	HistoryTook               = "history_took"
+	HistoryTimedOut           = "history_timed_out"
+	HistoryFailed             = "history_failed"
+	HistoryDeclined           = "history_declined"
+	HistoryCached             = "history_cached"
+	LateTimedOut              = "late_timed_out"
+	LateTimedOutText          = "late_timed_out_text"
+	LateDefaultUsedText       = "late_default_used_text"
+	LateCancelled             = "late_cancelled"
+	LateCancelledText         = "late_cancelled_text"
+	LateWithdrawn             = "late_withdrawn"
+	LateWithdrawnText         = "late_withdrawn_text"
+	LateAnsweredInTTY         = "late_answered_in_tty"
+	LateAnsweredInTTYText     = "late_answered_in_tty_text"
+	LateAnsweredElsewhere     = "late_answered_elsewhere"
+	LateAnsweredElsewhereText = "late_answered_elsewhere_text"
+	CloseTab                  = "close_tab"
+	EntriesMismatch           = "entries_mismatch"
+	ResponseEmpty             = "response_empty"
+	AttemptCount              = "attempt_count"
+	ReviewStale               = "review_stale"
+	AlreadySubmitted          = "already_submitted"
+)
+
+// catalog maps a language to its messages. Every table should have the
+// keys of en; missing ones fall back to English.
+var catalog = map[string]map[string]string{
+	"en": en,
+	"de": de,
+	"fr": fr,
+	"es": es,
+	"ja": ja,
+}
+
+// T returns the message for key in locale, falling back to English when the
+// locale is unknown or lacks the message.
+func T(locale, key string) string {
+	if msg, ok := catalog[Normalize(locale)][key]; ok {
+		return msg
+	}
+	if msg, ok := en[key]; ok {
+		return msg
+	}
+	return key
+}
+
+// Normalize maps a locale such as "de_DE.UTF-8", "fr-CA" or "ja" to the
+// language of the catalog serving it, or Fallback when there is none.
+func Normalize(locale string) string {
+	if lang := language(locale); Supported(lang) {
+		return lang
+	}
+	return Fallback
+}
+
+// Supported reports whether locale has a catalog of its own rather than
+// falling back to English.
+func Supported(locale string) bool {
+	_, ok := catalog[language(locale)]
+	return ok
+}
+
+// language strips the region, encoding and modifier from a locale.
+func language(locale string) string {
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if i := strings.IndexAny(lang, "_-"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// Languages lists the languages in the catalog.
+func Languages() []string {
+	langs := make([]string, 0, len(catalog))
+	for lang := range catalog {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// FromEnv returns the language of the POSIX locale variables, checked in
+// their usual order of precedence: LC_ALL, LC_MESSAGES, then LANG.
+func FromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return Normalize(value)
+		}
+	}
+	return Fallback
+}
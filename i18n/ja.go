@@ -0,0 +1,102 @@
+package i18n
+
+var ja = map[string]string{
+	PageTitle:                 "入力が必要です",
+	Submit:                    "送信",
+	Submitting:                "送信中...",
+	Placeholder:               "回答を入力してください...",
+	NumberPlaceholder:         "数値を入力してください...",
+	PathPlaceholder:           "パスを入力してください...",
+	RepeatPlaceholder:         "確認のためもう一度入力...",
+	ShowContext:               "コンテキストを表示",
+	Details:                   "詳細",
+	CriticalBanner:            "重要: 回答する前によく読んでください",
+	TimeLeft:                  "このリクエストの期限まで残り",
+	ExpiresAt:                 "このリクエストの期限:",
+	AskedAgo:                  "%s前に質問",
+	DeadlineBanner:            "エージェントはまもなくこの回答の待機をやめます。",
+	AutoDeny:                  "自動拒否まで",
+	AutoApprove:               "自動承認まで",
+	PageTimedOut:              "時間切れです。このタブは閉じてかまいません。",
+	PageCancelled:             "エージェントによりキャンセルされました。このタブは閉じてかまいません。",
+	PageWithdrawn:             "エージェントがこのリクエストを取り下げました。このタブは閉じてかまいません。",
+	PageAnsweredElsewhere:     "別の場所で回答済みです。このタブは閉じてかまいません。",
+	PageAnsweredInTTY:         "ターミナルで回答済みです。このタブは閉じてかまいません。",
+	PageFailed:                "無効な入力が多すぎます。このタブは閉じてかまいません。",
+	PageCompleted:             "回答済み",
+	PageClosed:                "受付終了",
+	Approve:                   "承認",
+	Deny:                      "拒否",
+	ApproveWithComment:        "コメント付きで承認",
+	Reject:                    "却下",
+	Revise:                    "修正を依頼",
+	RevisePlaceholder:         "何を変更しますか？（修正を依頼する場合は必須）",
+	ReadConfirm:               "読みました",
+	ReadHint:                  "確認するには最後までスクロールしてください",
+	Confirm:                   "確認",
+	Cancel:                    "キャンセル",
+	Continue:                  "続行",
+	Select:                    "選択",
+	ThankYou:                  "ありがとうございました！",
+	Submitted:                 "回答を送信しました。このタブは閉じてかまいません。",
+	RememberAnswer:            "この回答を記憶する",
+	RememberHint:              "回答の末尾に !remember を付けると、この質問に再利用されます",
+	ResponseLabel:             "回答",
+	NumberLabel:               "数値",
+	DurationLabel:             "期間",
+	PathLabel:                 "パス",
+	SecretLabel:               "シークレット (非表示)",
+	RepeatLabel:               "確認のためもう一度入力",
+	PressEnter:                "Enter キーで続行...",
+	AnsweredInBrowser:         "ブラウザで回答済みです",
+	Withdrawn:                 "エージェントがこのリクエストを取り下げました",
+	DashboardTitle:            "未回答の質問",
+	DashboardEmpty:            "回答待ちの質問はありません。新しい質問は届きしだいここに表示されます。",
+	DashboardBack:             "未回答の質問に戻る",
+	DashboardAsked:            "質問時刻",
+	DashboardOpen:             "別のタブで開く",
+	DashboardForget:           "記憶した回答を消去",
+	DashboardNotify:           "新しい質問を通知する",
+	DashboardOffline:          "接続が切れました",
+	DashboardReconnect:        "サーバーに接続できません。サーバーが戻ると、このページは自動的に再接続します。",
+	DashboardResolved:         "解決済み",
+	PushTitle:                 "エージェントが入力を待っています",
+	AboutToRespond:            "次の内容で回答します:",
+	EditAnswer:                "編集",
+	SendThis:                  "送信しますか? [y/n]",
+	Decline:                   "回答を辞退",
+	DeclineReason:             "理由 (任意)",
+	DeclineBack:               "質問に戻る",
+	PageDeclined:              "回答を辞退しました。このタブは閉じてかまいません。",
+	AnswerLabel:               "回答",
+	QuestionLabel:             "質問",
+	RequestedBy:               "依頼元",
+	UnknownClient:             "不明なクライアント",
+	SessionQuestion:           "質問",
+	HistoryTitle:              "質問の履歴",
+	HistoryEmpty:              "まだ質問はありません。",
+	HistoryNoMatch:            "フィルターに一致する質問はありません。",
+	HistoryFilter:             "絞り込む",
+	HistoryTook:               "回答までの時間",
+	HistoryTimedOut:           "タイムアウトまでの時間",
+	HistoryFailed:             "失敗までの時間",
+	HistoryDeclined:           "辞退までの時間",
+	HistoryCached:             "記憶から回答",
+	LateTimedOut:              "タイムアウト",
+	LateTimedOutText:          "回答が届く前にこの質問はタイムアウトしたため、回答は使われませんでした。",
+	LateDefaultUsedText:       "回答が届く前にこの質問はタイムアウトしたため、代わりに既定の回答 %q が使われました。",
+	LateCancelled:             "キャンセル済み",
+	LateCancelledText:         "回答が届く前にこの質問はキャンセルされたため、回答は使われませんでした。",
+	LateWithdrawn:             "取り下げ済み",
+	LateWithdrawnText:         "回答が届く前にエージェントがこのリクエストを取り下げたため、回答は使われませんでした。",
+	LateAnsweredInTTY:         "ターミナルで回答済み",
+	LateAnsweredInTTYText:     "回答が届く前にこの質問はターミナルで回答されたため、回答は使われませんでした。",
+	LateAnsweredElsewhere:     "別の場所で回答済み",
+	LateAnsweredElsewhereText: "回答が届く前にこの質問は別の場所で回答されたため、回答は使われませんでした。",
+	CloseTab:                  "このタブは閉じてかまいません。",
+	EntriesMismatch:           "入力が一致しません。もう一度入力してください",
+	ResponseEmpty:             "回答を空にすることはできません",
+	AttemptCount:              "%d 回目 / 全 %d 回",
+	ReviewStale:               "この回答は新しい回答に置き換えられました。確認してもう一度送信してください",
+	AlreadySubmitted:          "回答は送信済みです",
+}
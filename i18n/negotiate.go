@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxAcceptLanguages caps how many entries of an Accept-Language header are
+// looked at.
+const maxAcceptLanguages = 32
+
+// Negotiate picks the catalog language for an Accept-Language header such
+// as "de-CH, fr;q=0.8, *;q=0.1". Tags are tried by descending q-value, in
+// header order on ties; each matches a catalog with exactly its name, else
+// the catalog of its base language ("de" for "de-CH"). Tags with q=0 are
+// refused. It returns "" when nothing matches, so the caller picks the
+// default; a wildcard matches nothing on its own.
+func Negotiate(header string) string {
+	for _, tag := range acceptedTags(header) {
+		tag = strings.ToLower(tag)
+		if _, ok := catalog[tag]; ok {
+			return tag
+		}
+		if lang := language(tag); Supported(lang) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// acceptedTags returns the language tags of an Accept-Language header that
+// have a positive q-value, best first. Malformed entries are skipped.
+func acceptedTags(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for i, entry := range strings.Split(header, ",") {
+		if i == maxAcceptLanguages {
+			break
+		}
+		tag, params, _ := strings.Cut(entry, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			value, ok := strings.CutPrefix(params, "q=")
+			if !ok {
+				continue
+			}
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.tag
+	}
+	return names
+}
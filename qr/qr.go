@@ -0,0 +1,458 @@
+// Package qr encodes short texts, such as URLs, as QR codes: byte mode,
+// error correction level M, versions 1 to 10 (up to 213 bytes). It follows
+// ISO/IEC 18004 and needs nothing outside the standard library.
+package qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code is an encoded QR symbol, without its quiet zone.
+type Code struct {
+	Version int
+	Size    int
+
+	modules  [][]bool
+	function [][]bool
+}
+
+// Black reports whether the module at column x, row y is dark. Modules
+// outside the symbol are light.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y][x]
+}
+
+// version describes the level M codewords of a version.
+type version struct {
+	total  int // codewords, data and error correction
+	blocks int // error correction blocks
+	ecc    int // error correction codewords per block
+	align  []int
+}
+
+var versions = []version{
+	1:  {26, 1, 10, nil},
+	2:  {44, 1, 16, []int{6, 18}},
+	3:  {70, 1, 26, []int{6, 22}},
+	4:  {100, 2, 18, []int{6, 26}},
+	5:  {134, 2, 24, []int{6, 30}},
+	6:  {172, 4, 16, []int{6, 34}},
+	7:  {196, 4, 18, []int{6, 22, 38}},
+	8:  {242, 4, 22, []int{6, 24, 42}},
+	9:  {292, 5, 22, []int{6, 26, 46}},
+	10: {346, 5, 26, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() int {
+	return v.total - v.blocks*v.ecc
+}
+
+// countBits is the width of the byte mode character count.
+func countBits(ver int) int {
+	if ver < 10 {
+		return 8
+	}
+	return 16
+}
+
+// MaxLength is the longest text Encode takes, in bytes.
+const MaxLength = 213
+
+// Encode returns the smallest code holding text.
+func Encode(text string) (*Code, error) {
+	ver := 1
+	for ; ver < len(versions); ver++ {
+		if 4+countBits(ver)+8*len(text) <= 8*versions[ver].dataCodewords() {
+			break
+		}
+	}
+	if ver == len(versions) {
+		return nil, fmt.Errorf("text of %d bytes is too long for a QR code; at most %d fit", len(text), MaxLength)
+	}
+
+	c := &Code{Version: ver, Size: 17 + 4*ver}
+	c.modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+	for y := range c.modules {
+		c.modules[y] = make([]bool, c.Size)
+		c.function[y] = make([]bool, c.Size)
+	}
+
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords(ver, text))
+
+	// The mask leaving the fewest look-alike patterns wins
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+	return c, nil
+}
+
+// codewords returns text's data codewords, padded, with error correction,
+// interleaved in the order they are placed.
+func codewords(ver int, text string) []byte {
+	v := versions[ver]
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(text), countBits(ver))
+	for i := 0; i < len(text); i++ {
+		bits.append(int(text[i]), 8)
+	}
+	capacity := 8 * v.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	data := bits.bytes()
+
+	// Later blocks take one data codeword more when they don't divide evenly
+	short := v.blocks - v.total%v.blocks
+	shortData := v.total/v.blocks - v.ecc
+	divisor := rsDivisor(v.ecc)
+	dataBlocks := make([][]byte, v.blocks)
+	eccBlocks := make([][]byte, v.blocks)
+	for i, k := 0, 0; i < v.blocks; i++ {
+		n := shortData
+		if i >= short {
+			n++
+		}
+		dataBlocks[i] = data[k : k+n]
+		eccBlocks[i] = rsRemainder(dataBlocks[i], divisor)
+		k += n
+	}
+
+	result := make([]byte, 0, v.total)
+	for i := 0; i <= shortData; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecc; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// bitBuffer collects bits, most significant first.
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// rsDivisor is the Reed-Solomon generator polynomial of degree n, highest
+// coefficient first and the leading 1 left out.
+func rsDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return result
+}
+
+// rsRemainder is the error correction of data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (c *Code) set(x, y int, black bool) {
+	c.modules[y][x] = black
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	align := versions[c.Version].align
+	for i, y := range align {
+		for j, x := range align {
+			// The corners with finders have none
+			last := len(align) - 1
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; drawFormat fills them in
+	c.drawFormat(0)
+
+	if c.Version >= 7 {
+		rem := c.Version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := c.Version<<12 | rem
+		for i := 0; i < 18; i++ {
+			black := bits>>i&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.set(a, b, black)
+			c.set(b, a, black)
+		}
+	}
+}
+
+// drawFinder draws a finder pattern centred on x, y, with its separator.
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < c.Size && yy >= 0 && yy < c.Size {
+				dist := max(abs(dx), abs(dy))
+				c.set(xx, yy, dist != 2 && dist != 4)
+			}
+		}
+	}
+}
+
+// drawFormat writes both copies of the format information for mask.
+func (c *Code) drawFormat(mask int) {
+	data := 0b00<<3 | mask // level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// drawCodewords places data in the zigzag of two-module columns, right to
+// left, skipping the vertical timing pattern.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.function[y][x] && i < len(data)*8 {
+					c.modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the data modules selected by mask; applying it twice
+// undoes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.function[y][x] && masked(mask, x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	}
+	return ((x+y)%2+x*y%3)%2 == 0
+}
+
+// penalty scores how hard the symbol is to read: long runs, 2x2 blocks,
+// finder look-alikes and an uneven balance of dark and light.
+func (c *Code) penalty() int {
+	total := 0
+	line := make([]bool, c.Size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < c.Size; a++ {
+			for b := 0; b < c.Size; b++ {
+				if vertical {
+					line[b] = c.modules[b][a]
+				} else {
+					line[b] = c.modules[a][b]
+				}
+			}
+			total += linePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				m := c.modules[y][x]
+				if m == c.modules[y][x+1] && m == c.modules[y+1][x] && m == c.modules[y+1][x+1] {
+					total += 3
+				}
+			}
+		}
+	}
+	modules := c.Size * c.Size
+	total += (abs(dark*20-modules*10)+modules-1)/modules*10 - 10
+	return total
+}
+
+// finderLike is the 1:1:3:1:1 pattern of a finder with light on one side.
+var finderLike = []bool{true, false, true, true, true, false, true, false, false, false, false}
+
+func linePenalty(line []bool) int {
+	total := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			total += run - 2
+		}
+		run = 1
+	}
+
+	for i := 0; i+len(finderLike) <= len(line); i++ {
+		forward, backward := true, true
+		for j, black := range finderLike {
+			forward = forward && line[i+j] == black
+			backward = backward && line[i+len(finderLike)-1-j] == black
+		}
+		if forward {
+			total += 40
+		}
+		if backward {
+			total += 40
+		}
+	}
+	return total
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ANSI draws the code for a terminal, two rows of modules per line, with a
+// quiet zone of quiet modules around it. Colours are explicit, so it reads
+// the same on dark and light terminals.
+func (c *Code) ANSI(quiet int) string {
+	var b strings.Builder
+	for y := -quiet; y < c.Size+quiet; y += 2 {
+		for x := -quiet; x < c.Size+quiet; x++ {
+			fg, bg := 37, 47
+			if c.Black(x, y) {
+				fg = 30
+			}
+			switch {
+			case y+1 >= c.Size+quiet:
+				// An odd number of rows leaves the bottom half unused
+				bg = 49
+			case c.Black(x, y+1):
+				bg = 40
+			}
+			fmt.Fprintf(&b, "\x1b[%d;%dm▀", fg, bg)
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// NewAckPrompt returns a prompt that only waits for the user to acknowledge
+// a message. Any response, including an empty one, acknowledges it.
+func NewAckPrompt(prompt, method string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindAck
+	req.Trim = TrimNone
+	req.Validate = func(string) (string, error) {
+		return "", nil
+	}
+	return req
+}
+
+func (s *MCPServer) handleUserAckTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	promptReq := NewAckPrompt(prompt, promptMethod(args))
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+
+	timeout, err := optionalTimeout(args)
+	if err == nil {
+		err = parsePromptMeta(args, promptReq)
+	}
+	var ackOnTimeout bool
+	if err == nil {
+		ackOnTimeout, err = optionalBool(args, "acknowledge_on_timeout", false)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+	if ackOnTimeout {
+		// Shown to the user as what happens when time runs out
+		acknowledged := "Continue"
+		promptReq.TimeoutResponse = &acknowledged
+	}
+
+	start := time.Now()
+	_, err = s.collectInput(req, promptReq, progressToken)
+	elapsed := time.Since(start)
+	timedOut := false
+	if errors.Is(err, ErrTimeout) && ackOnTimeout {
+		err, timedOut = nil, true
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	structured := map[string]interface{}{
+		"acknowledged":   true,
+		"elapsedSeconds": elapsed.Seconds(),
+	}
+	text := fmt.Sprintf("Acknowledged after %s", formatSeconds(elapsed.Round(time.Millisecond)))
+	if timedOut {
+		structured["timedOut"] = true
+		text = fmt.Sprintf("Acknowledged automatically after %s without a response", formatSeconds(elapsed.Round(time.Millisecond)))
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
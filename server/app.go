@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+
+	"prompt-mcp/i18n"
+)
+
+// dashboardWorker is the dashboard's service worker, a static file served
+// at the dashboard's base path so its scope covers every page.
+const dashboardWorker = "sw.js"
+
+// defaultAppName names the installed dashboard unless the theme has a
+// title.
+const defaultAppName = "prompt-mcp"
+
+// appIcons are the static icons of the installed dashboard. They keep
+// their glyph within the middle 80%, so platforms may mask them.
+var appIcons = []struct {
+	name  string
+	sizes string
+}{
+	{"icon-192.png", "192x192"},
+	{"icon-512.png", "512x512"},
+}
+
+// WebAppIcon is an icon in the web app manifest.
+type WebAppIcon struct {
+	Src     string `json:"src"`
+	Sizes   string `json:"sizes"`
+	Type    string `json:"type"`
+	Purpose string `json:"purpose"`
+}
+
+// WebAppManifest is the dashboard's web app manifest, which lets browsers
+// install it as an app of its own opening the index.
+type WebAppManifest struct {
+	ID              string       `json:"id"`
+	Name            string       `json:"name"`
+	ShortName       string       `json:"short_name"`
+	StartURL        string       `json:"start_url"`
+	Scope           string       `json:"scope"`
+	Display         string       `json:"display"`
+	BackgroundColor string       `json:"background_color"`
+	ThemeColor      string       `json:"theme_color"`
+	Lang            string       `json:"lang"`
+	Icons           []WebAppIcon `json:"icons"`
+}
+
+// OfflinePageData is passed to the offline.html template, the page the
+// installed dashboard shows while the server can't be reached.
+type OfflinePageData struct {
+	Lang string
+}
+
+// appThemeColor is the colour of the installed dashboard's title bar: the
+// theme's accent, or the built-in one.
+func appThemeColor() string {
+	if accent := currentWebTheme().theme.AccentColor; accent != "" {
+		return expandHexColor(accent)
+	}
+	return defaultAccent
+}
+
+// handleManifest serves the web app manifest. It names the index, token
+// included, so it is served under the token too.
+func (d *Dashboard) handleManifest(w http.ResponseWriter, r *http.Request) {
+	name := currentWebTheme().theme.PageTitle
+	if name == "" {
+		name = defaultAppName
+	}
+	locale := pageLocale(r, d.locale, !d.pinned)
+	manifest := WebAppManifest{
+		ID:              d.base + "/",
+		Name:            name,
+		ShortName:       name,
+		StartURL:        d.Path(),
+		Scope:           d.base + "/",
+		Display:         "standalone",
+		BackgroundColor: "#ffffff",
+		ThemeColor:      appThemeColor(),
+		Lang:            i18n.Normalize(locale),
+	}
+	for _, icon := range appIcons {
+		for _, purpose := range []string{"any", "maskable"} {
+			manifest.Icons = append(manifest.Icons, WebAppIcon{Src: d.base + "/static/" + icon.name, Sizes: icon.sizes, Type: "image/png", Purpose: purpose})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// handleWorker serves the service worker. It is the same for every run, so
+// an installed dashboard keeps its registration, and with it any push
+// subscription, across restarts.
+func (d *Dashboard) handleWorker(w http.ResponseWriter, r *http.Request) {
+	script, err := fs.ReadFile(currentWebAssets().static, dashboardWorker)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(script)
+}
+
+// handleOffline serves the page the service worker keeps for when the
+// server can't be reached. It holds nothing about the prompts.
+func (d *Dashboard) handleOffline(w http.ResponseWriter, r *http.Request) {
+	locale := pageLocale(r, d.locale, !d.pinned)
+	w.Header().Set("Cache-Control", "no-cache")
+	renderTemplate(w, http.StatusOK, "offline.html", translate(locale), OfflinePageData{Lang: i18n.Normalize(locale)})
+}
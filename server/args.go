@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// optionalString returns a string argument, whether it was present, and an
+// error when it has the wrong type.
+func optionalString(args map[string]interface{}, name string) (string, bool, error) {
+	value, exists := args[name]
+	if !exists || value == nil {
+		return "", false, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", true, fmt.Errorf("Invalid %s parameter: must be a string", name)
+	}
+	return str, true, nil
+}
+
+// optionalBool returns a boolean argument, or def when it is absent.
+func optionalBool(args map[string]interface{}, name string, def bool) (bool, error) {
+	value, exists := args[name]
+	if !exists || value == nil {
+		return def, nil
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return def, fmt.Errorf("Invalid %s parameter: must be a boolean", name)
+	}
+	return b, nil
+}
+
+// optionalStringList returns an array-of-strings argument.
+func optionalStringList(args map[string]interface{}, name string) ([]string, bool, error) {
+	value, exists := args[name]
+	if !exists || value == nil {
+		return nil, false, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, true, fmt.Errorf("Invalid %s parameter: must be an array of strings", name)
+	}
+	list := make([]string, len(items))
+	for i, item := range items {
+		str, ok := item.(string)
+		if !ok {
+			return nil, true, fmt.Errorf("Invalid %s parameter: element %d is not a string", name, i)
+		}
+		list[i] = str
+	}
+	return list, true, nil
+}
+
+// promptMethod returns the input method requested by a tool call, defaulting
+// to tty.
+func promptMethod(args map[string]interface{}) string {
+	if method, ok := args["method"].(string); ok {
+		return method
+	}
+	return MethodTTY
+}
+
+// maxAttemptsLimit is the most attempts a tool call may allow.
+const maxAttemptsLimit = 100
+
+// optionalMaxAttempts reads max_attempts, how many invalid answers the user
+// may give before the tool call fails. Zero means the server's setting
+// applies.
+func optionalMaxAttempts(args map[string]interface{}) (int, error) {
+	n, err := optionalNumber(args, "max_attempts")
+	if err != nil || n == nil {
+		return 0, err
+	}
+	if *n != float64(int(*n)) || *n < 1 || *n > maxAttemptsLimit {
+		return 0, fmt.Errorf("Invalid max_attempts parameter: must be a whole number from 1 to %d", maxAttemptsLimit)
+	}
+	return int(*n), nil
+}
+
+// optionalTimeout reads the timeout argument, in seconds. Zero waits
+// forever; nil means the method's default applies.
+func optionalTimeout(args map[string]interface{}) (*time.Duration, error) {
+	seconds, err := optionalNumber(args, "timeout")
+	if err != nil || seconds == nil {
+		return nil, err
+	}
+	if *seconds < 0 || *seconds > math.MaxInt64/float64(time.Second) {
+		return nil, fmt.Errorf("Invalid timeout parameter: must be a number of seconds, 0 to wait forever")
+	}
+	timeout := time.Duration(*seconds * float64(time.Second))
+	return &timeout, nil
+}
+
+// parsePromptMeta reads the arguments describing how a prompt is presented,
+// title, detail, urgency and format, into req.
+func parsePromptMeta(args map[string]interface{}, req *PromptRequest) error {
+	var err error
+	if req.Format, _, err = optionalString(args, "format"); err != nil {
+		return err
+	}
+	if req.Format != "" && req.Format != FormatText && req.Format != FormatMarkdown {
+		return fmt.Errorf("Invalid format parameter: must be 'text' or 'markdown'")
+	}
+	if req.Title, _, err = optionalString(args, "title"); err != nil {
+		return err
+	}
+	if req.Detail, _, err = optionalString(args, "detail"); err != nil {
+		return err
+	}
+	if req.Priority, err = parsePriority(args); err != nil {
+		return err
+	}
+	if req.Locale, _, err = optionalString(args, "locale"); err != nil {
+		return err
+	}
+	if req.Urgency, _, err = optionalString(args, "urgency"); err != nil {
+		return err
+	}
+	switch req.Urgency {
+	case "", UrgencyLow, UrgencyNormal, UrgencyCritical:
+		return nil
+	default:
+		return fmt.Errorf("Invalid urgency parameter: must be 'low', 'normal' or 'critical'")
+	}
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"prompt-mcp/i18n"
+)
+
+// assets holds the web pages' templates and the static files they load.
+// Everything is compiled in, so pages work offline and need no CDN.
+//
+//go:embed assets
+var assets embed.FS
+
+// templateData is what each page template is executed with. LoadWebAssets
+// runs custom templates on the zero value, so mistakes such as a misspelt
+// field show at startup.
+var templateData = map[string]interface{}{
+	"input.html":     PageData{},
+	"submit.html":    MessagePageData{},
+	"review.html":    ReviewPageData{},
+	"decline.html":   DeclinePageData{},
+	"dashboard.html": DashboardPageData{},
+	"history.html":   HistoryPageData{},
+	"offline.html":   OfflinePageData{},
+	"notify.html":    &PromptRequest{},
+}
+
+// webAssets are the templates and static files pages are served from.
+type webAssets struct {
+	templates *template.Template
+	static    fs.FS
+}
+
+var (
+	webAssetsMu   sync.Mutex
+	defaultAssets = mustLoadAssets(assets)
+	currentAssets = defaultAssets
+)
+
+// LoadWebAssets serves web pages from the templates and static files in
+// dir, laid out like server/assets: templates/input.html, submit.html,
+// review.html, decline.html, dashboard.html, history.html, offline.html and
+// notify.html, and static/input.css, input.js, sw.js and the app icons.
+// Any file missing from dir comes from the embedded set, so a directory
+// holding only a stylesheet is fine. The templates are parsed and tried on
+// empty data right away; a mistake is returned naming the file, line and
+// column, and the pages in use are kept. An empty dir restores the
+// embedded files.
+func LoadWebAssets(dir string) error {
+	loaded := defaultAssets
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("web template directory: %w", err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("web template directory %s is not a directory", dir)
+		}
+		var err error
+		if loaded, err = loadAssets(overlayFS{os.DirFS(dir), assets}); err != nil {
+			return fmt.Errorf("web template in %s: %w", dir, err)
+		}
+	}
+
+	webAssetsMu.Lock()
+	defer webAssetsMu.Unlock()
+	currentAssets = loaded
+	return nil
+}
+
+func currentWebAssets() webAssets {
+	webAssetsMu.Lock()
+	defer webAssetsMu.Unlock()
+	return currentAssets
+}
+
+// loadAssets parses the page templates of files. The functions are
+// placeholders: "t" depends on the page's locale, so renderTemplate binds
+// them on a clone.
+func loadAssets(files fs.FS) (webAssets, error) {
+	static, err := fs.Sub(files, "assets/static")
+	if err != nil {
+		return webAssets{}, err
+	}
+	t := template.New("pages").Funcs(placeholderFuncs())
+	if _, err := t.ParseFS(files, "assets/templates/*.html"); err != nil {
+		return webAssets{}, withColumn(files, err)
+	}
+	for name, data := range templateData {
+		if t.Lookup(name) == nil {
+			return webAssets{}, fmt.Errorf("template %s is missing", name)
+		}
+		if err := executeTemplate(t, io.Discard, name, inputFuncs(""), data); err != nil {
+			return webAssets{}, err
+		}
+	}
+	return webAssets{templates: t, static: static}, nil
+}
+
+// placeholderFuncs are the template functions as parsing sees them.
+func placeholderFuncs() template.FuncMap {
+	return template.FuncMap{
+		"inc":    func(int) int { return 0 },
+		"t":      func(string) string { return "" },
+		"prompt": promptHTML,
+		"picked": func(string, int) bool { return false },
+	}
+}
+
+// parseErrorLine matches the file and line a template parse error names.
+var parseErrorLine = regexp.MustCompile(`^template: ([\w.-]+):(\d+): `)
+
+// withColumn adds the column to err, a parse error in one of the templates
+// of files, which only names the line. The column is that of the action
+// the error is about, found by parsing the file up to the end of each
+// action on the line in turn until one fails the same way; failing that,
+// the line's first action, or its start.
+func withColumn(files fs.FS, err error) error {
+	m := parseErrorLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	src, readErr := fs.ReadFile(files, "assets/templates/"+m[1])
+	if readErr != nil {
+		return err
+	}
+	n, _ := strconv.Atoi(m[2])
+	lines := strings.SplitAfter(string(src), "\n")
+	if n < 1 || n > len(lines) {
+		return err
+	}
+	start := len(strings.Join(lines[:n-1], ""))
+	line := lines[n-1]
+
+	col := 1
+	for i, first := 0, true; ; first = false {
+		open := strings.Index(line[i:], "{{")
+		if open < 0 {
+			break
+		}
+		open += i
+		if first {
+			col = utf8.RuneCountInString(line[:open]) + 1
+		}
+		end := strings.Index(line[open:], "}}")
+		if end < 0 {
+			break
+		}
+		i = open + end + 2
+		_, prefixErr := template.New(m[1]).Funcs(placeholderFuncs()).Parse(string(src[:start+i]))
+		if prefixErr != nil && prefixErr.Error() == err.Error() {
+			col = utf8.RuneCountInString(line[:open]) + 1
+			break
+		}
+	}
+	return errors.New(strings.Replace(err.Error(), m[0], fmt.Sprintf("template: %s:%d:%d: ", m[1], n, col), 1))
+}
+
+func mustLoadAssets(files fs.FS) webAssets {
+	loaded, err := loadAssets(files)
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+// overlayFS opens files from custom, falling back to embedded for those it
+// lacks. Directories list the files of both.
+type overlayFS struct {
+	custom   fs.FS
+	embedded fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.custom.Open(customName(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.embedded.Open(name)
+	}
+	return f, err
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.embedded, name)
+	if err != nil {
+		return nil, err
+	}
+	custom, err := fs.ReadDir(o.custom, customName(name))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.Name()] = true
+	}
+	for _, e := range custom {
+		if !seen[e.Name()] {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// customName maps a path in the embedded set to the custom directory, which
+// has no leading "assets".
+func customName(name string) string {
+	if name == "assets" {
+		return "."
+	}
+	return strings.TrimPrefix(name, "assets/")
+}
+
+// renderTemplate writes the page template name with funcs bound, as HTML
+// with the given status.
+func renderTemplate(w http.ResponseWriter, status int, name string, funcs template.FuncMap, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := executeTemplate(currentWebAssets().templates, w, name, funcs, data); err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// executeTemplate runs the template name of t with funcs bound on a clone.
+func executeTemplate(t *template.Template, w io.Writer, name string, funcs template.FuncMap, data interface{}) error {
+	page, err := t.Lookup(name).Clone()
+	if err != nil {
+		return err
+	}
+	return page.Funcs(funcs).Execute(w, data)
+}
+
+// translate is the "t" template function for locale.
+func translate(locale string) template.FuncMap {
+	return template.FuncMap{"t": func(key string) string { return i18n.T(locale, key) }}
+}
+
+// pageLocale is the language of a page served for r. With negotiate set it
+// is the best language of the browser's Accept-Language header that has a
+// catalog; without, or when none has one, it is locale.
+func pageLocale(r *http.Request, locale string, negotiate bool) string {
+	if negotiate {
+		if lang := i18n.Negotiate(r.Header.Get("Accept-Language")); lang != "" {
+			return lang
+		}
+	}
+	return locale
+}
+
+// staticHandler serves the static assets, and the theme's logo file, from a
+// handler's /static/ path. Directories aren't listed. Files are served with their own type only and
+// may be cached, since they change only with the binary or a restart.
+func staticHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		if r.URL.Path == "/static/"+themeLogoPath {
+			currentWebTheme().serveLogo(w, r)
+			return
+		}
+		files := http.FileServer(http.FS(currentWebAssets().static))
+		http.StripPrefix("/static/", files).ServeHTTP(w, r)
+	})
+}
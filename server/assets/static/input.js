@@ -0,0 +1,449 @@
+// The page passes what it needs on the script element
+var script = document.currentScript;
+var base = script.dataset.base;
+
+// In a card on the dashboard the page drops its outer margins
+if (window.top !== window) document.documentElement.classList.add('embedded');
+
+var phrase = document.getElementById('phrase');
+if (phrase) {
+    // The server checks the phrase again; this only saves a round trip
+    var confirmButton = document.getElementById('phrase-confirm');
+    var normalize = function(s) {
+        s = s.trim();
+        return phrase.hasAttribute('data-ignore-case') ? s.toLowerCase() : s;
+    };
+    phrase.addEventListener('input', function() {
+        confirmButton.disabled = normalize(phrase.value) !== normalize(phrase.dataset.phrase);
+    });
+}
+var otherText = document.getElementById('other-text');
+if (otherText) {
+    // The text box only matters once Other is picked
+    document.querySelectorAll('input[name="response"]').forEach(function(radio) {
+        radio.addEventListener('change', function() {
+            var picked = document.getElementById('other-pick').checked;
+            otherText.hidden = !picked;
+            otherText.required = picked;
+            if (picked) otherText.focus();
+        });
+    });
+}
+var counter = document.getElementById('length-counter');
+if (counter) {
+    // Counted in code points like the server, which refuses answers out
+    // of bounds whatever minlength and maxlength let through
+    var answer = document.querySelector('[data-max-length]');
+    var min = Number(answer.dataset.minLength), max = Number(answer.dataset.maxLength);
+    var count = function() {
+        // A paste may have swapped the input for a textarea
+        answer = document.querySelector('[data-max-length]');
+        var n = Array.from(answer.value).length;
+        if (max && n > max) {
+            counter.textContent = (n - max) + ' characters too many';
+        } else if (n < min) {
+            counter.textContent = (min - n) + ' more characters needed';
+        } else if (max) {
+            counter.textContent = (max - n) + ' characters remaining';
+        } else {
+            counter.textContent = n + ' characters';
+        }
+        counter.style.color = (max && n > max) || n < min ? '#a4262c' : '';
+    };
+    answer.form.addEventListener('input', count);
+    count();
+}
+var steps = document.querySelectorAll('[data-when]');
+if (steps.length) {
+    // Conditional fields show once the answer they depend on is given;
+    // hidden fields are disabled so they aren't submitted
+    var answerOf = function(name) {
+        var inputs = document.getElementsByName('field.' + name);
+        for (var i = 0; i < inputs.length; i++) {
+            var el = inputs[i];
+            if (el.disabled) return '';
+            if (el.type === 'radio') {
+                if (el.checked) return el.value;
+                continue;
+            }
+            if (el.type === 'number' && el.value !== '') return String(Number(el.value));
+            return el.value.trim();
+        }
+        return '';
+    };
+    var disclose = function() {
+        // Fields come in order, so the ones depended on are settled first
+        steps.forEach(function(step) {
+            var shown = answerOf(step.dataset.when) === step.dataset.equals;
+            step.hidden = !shown;
+            step.querySelectorAll('input, select').forEach(function(el) {
+                el.disabled = !shown;
+            });
+        });
+    };
+    document.querySelector('form').addEventListener('input', disclose);
+    document.querySelector('form').addEventListener('change', disclose);
+    disclose();
+}
+var instructions = document.getElementById('instructions');
+if (instructions) {
+    // Instructions are only needed to ask for a revision
+    document.querySelectorAll('.plan-decision').forEach(function(button) {
+        button.addEventListener('click', function() {
+            instructions.required = button.value === 'revise';
+        });
+    });
+}
+var rankList = document.getElementById('rank-list');
+if (rankList) {
+    // The hidden field carries the order; unticked options are left out
+    var rankOrder = function() {
+        var numbers = [];
+        rankList.querySelectorAll('li').forEach(function(li) {
+            var pick = li.querySelector('.rank-pick');
+            if (!pick || pick.checked) numbers.push(li.dataset.number);
+        });
+        document.getElementById('rank-order').value = numbers.join(',');
+        if (rankList.dataset.max) {
+            var full = numbers.length >= Number(rankList.dataset.max);
+            rankList.querySelectorAll('.rank-pick').forEach(function(pick) {
+                pick.disabled = full && !pick.checked;
+            });
+        }
+    };
+    var dragged = null;
+    rankList.addEventListener('dragstart', function(e) {
+        dragged = e.target.closest('li');
+        dragged.classList.add('dragging');
+        e.dataTransfer.effectAllowed = 'move';
+    });
+    rankList.addEventListener('dragover', function(e) {
+        e.preventDefault();
+        var over = e.target.closest('li');
+        if (!dragged || !over || over === dragged) return;
+        var below = e.clientY > over.getBoundingClientRect().top + over.offsetHeight / 2;
+        rankList.insertBefore(dragged, below ? over.nextSibling : over);
+    });
+    rankList.addEventListener('drop', function(e) { e.preventDefault(); });
+    rankList.addEventListener('dragend', function() {
+        dragged.classList.remove('dragging');
+        dragged = null;
+        rankOrder();
+    });
+    // The arrow buttons do the same without a mouse
+    rankList.addEventListener('click', function(e) {
+        var move = e.target.closest('.rank-move');
+        if (!move) return;
+        var li = move.closest('li');
+        if (move.dataset.move === 'up' && li.previousElementSibling) {
+            rankList.insertBefore(li, li.previousElementSibling);
+        } else if (move.dataset.move === 'down' && li.nextElementSibling) {
+            rankList.insertBefore(li.nextElementSibling, li);
+        }
+        move.focus();
+        rankOrder();
+        // Screen readers hear where the option went
+        var items = Array.from(rankList.querySelectorAll('li'));
+        document.getElementById('rank-status').textContent =
+            li.querySelector('span').textContent + ': ' + (items.indexOf(li) + 1) + ' / ' + items.length;
+    });
+    rankList.addEventListener('change', rankOrder);
+    rankOrder();
+}
+var draftForm = document.querySelector('form[data-drafts]');
+if (draftForm) {
+    // Answers given so far are kept in case time runs out
+    draftForm.addEventListener('change', function() {
+        fetch(base + '/draft', {method: 'POST', body: new URLSearchParams(new FormData(draftForm))});
+    });
+}
+// After a rejected answer focus goes to the first field in error, which
+// is described by the message, or else to the message itself
+var firstInvalid = document.querySelector('[aria-invalid="true"]');
+var errors = document.getElementById('errors');
+if (firstInvalid) {
+    firstInvalid.focus();
+} else if (errors && errors.children.length) {
+    errors.focus();
+}
+// Fields the browser refuses are flagged the same way until corrected
+document.querySelector('form').addEventListener('invalid', function(e) {
+    e.target.setAttribute('aria-invalid', 'true');
+}, true);
+document.querySelector('form').addEventListener('input', function(e) {
+    if (e.target.validity && e.target.validity.valid) e.target.removeAttribute('aria-invalid');
+});
+document.querySelector('form').addEventListener('submit', function(e) {
+    // The clicked button stays enabled so its value is submitted
+    var clicked = e.submitter || document.querySelector('form button');
+    clicked.textContent = script.dataset.submitting;
+    document.querySelectorAll('button').forEach(function(b) {
+        if (b !== clicked) b.disabled = true;
+    });
+});
+
+var listItems = document.getElementById('list-items');
+if (listItems) {
+    // There is always a row to type in; Enter adds one below
+    var addRow = function(after) {
+        var row = listItems.querySelector('.list-row').cloneNode(true);
+        row.querySelector('input').value = '';
+        listItems.insertBefore(row, after ? after.nextSibling : null);
+        row.querySelector('input').focus();
+    };
+    document.getElementById('list-add').addEventListener('click', function() { addRow(null); });
+    listItems.addEventListener('click', function(e) {
+        if (!e.target.classList.contains('list-remove')) return;
+        var row = e.target.closest('.list-row');
+        if (listItems.querySelectorAll('.list-row').length > 1) {
+            row.remove();
+        } else {
+            row.querySelector('input').value = '';
+        }
+    });
+    listItems.addEventListener('keydown', function(e) {
+        if (e.key === 'Enter' && e.target.tagName === 'INPUT') {
+            e.preventDefault();
+            addRow(e.target.closest('.list-row'));
+        }
+    });
+}
+
+// In a long answer Enter starts a new line; Ctrl+Enter (Cmd+Enter on a
+// Mac) submits
+var submitOnCtrlEnter = function(area) {
+    area.addEventListener('keydown', function(e) {
+        if (e.key === 'Enter' && (e.ctrlKey || e.metaKey)) {
+            e.preventDefault();
+            area.form.requestSubmit();
+        }
+    });
+};
+var longAnswer = document.getElementById('long-answer');
+if (longAnswer) submitOnCtrlEnter(longAnswer);
+
+var pasteTarget = document.querySelector('[data-paste-max]');
+if (pasteTarget) {
+    // Logs and stack traces pasted or dropped in keep their line breaks:
+    // the one-line input becomes a textarea. Their size is counted in
+    // bytes against the server's cap before they are sent
+    var pasteForm = pasteTarget.form;
+    var pasteCounter = document.getElementById('paste-counter');
+    var pasteMax = Number(pasteTarget.dataset.pasteMax);
+    var pasteLong = Number(pasteTarget.dataset.pasteLong);
+    var measure = function() {
+        var n = new TextEncoder().encode(pasteTarget.value).length;
+        var over = n > pasteMax;
+        pasteCounter.hidden = !over && pasteTarget.tagName === 'INPUT';
+        pasteCounter.textContent = over ? pasteTarget.dataset.tooLarge : n.toLocaleString() + ' bytes of ' + pasteTarget.dataset.pasteLimit;
+        pasteCounter.style.color = over ? '#a4262c' : '';
+        pasteTarget.setCustomValidity(over ? pasteTarget.dataset.tooLarge : '');
+    };
+    var refusePaste = function(message) {
+        pasteCounter.hidden = false;
+        pasteCounter.textContent = message;
+        pasteCounter.style.color = '#a4262c';
+    };
+    var expand = function() {
+        if (pasteTarget.tagName === 'TEXTAREA') return;
+        var area = document.createElement('textarea');
+        Array.from(pasteTarget.attributes).forEach(function(a) {
+            if (a.name !== 'type' && a.name !== 'value') area.setAttribute(a.name, a.value);
+        });
+        area.rows = 12;
+        area.value = pasteTarget.value;
+        pasteTarget.replaceWith(area);
+        pasteTarget = area;
+        submitOnCtrlEnter(area);
+        area.focus();
+    };
+    var insert = function(text) {
+        var start = pasteTarget.selectionStart, end = pasteTarget.selectionEnd;
+        if (text.indexOf('\n') >= 0 || text.length >= pasteLong) expand();
+        pasteTarget.setRangeText(text, start, end, 'end');
+        pasteTarget.dispatchEvent(new Event('input', {bubbles: true}));
+    };
+    pasteForm.addEventListener('input', function(e) {
+        if (e.target === pasteTarget) measure();
+    });
+    pasteForm.addEventListener('paste', function(e) {
+        if (e.target !== pasteTarget || pasteTarget.tagName !== 'INPUT') return;
+        var text = e.clipboardData.getData('text/plain');
+        if (text.indexOf('\n') < 0 && text.length < pasteLong) return;
+        e.preventDefault();
+        insert(text);
+    });
+    pasteForm.addEventListener('dragover', function(e) {
+        if (e.target === pasteTarget && Array.from(e.dataTransfer.types).indexOf('Files') >= 0) e.preventDefault();
+    });
+    pasteForm.addEventListener('drop', function(e) {
+        if (e.target !== pasteTarget) return;
+        var file = e.dataTransfer.files[0];
+        if (!file) {
+            // Dropped text is taken like pasted text
+            if (pasteTarget.tagName !== 'INPUT') return;
+            e.preventDefault();
+            insert(e.dataTransfer.getData('text/plain'));
+            return;
+        }
+        e.preventDefault();
+        if (file.size > pasteMax) {
+            refusePaste(pasteTarget.dataset.tooLarge);
+            return;
+        }
+        file.arrayBuffer().then(function(data) {
+            // A NUL byte or invalid UTF-8 means a binary file, which is
+            // refused rather than sent in some encoding. The text is kept
+            // as it is, byte order mark included
+            var bytes = new Uint8Array(data), text;
+            try {
+                if (bytes.indexOf(0) >= 0) throw new Error('binary');
+                text = new TextDecoder('utf-8', {fatal: true, ignoreBOM: true}).decode(bytes);
+            } catch (err) {
+                refusePaste(pasteTarget.dataset.binary.replace('%s', file.name));
+                return;
+            }
+            insert(text);
+        });
+    });
+    measure();
+}
+
+var upload = document.getElementById('upload');
+if (upload) {
+    // Oversized files are refused before they are sent
+    upload.addEventListener('change', function() {
+        var file = upload.files[0];
+        upload.setCustomValidity(file && file.size > Number(upload.dataset.max) ? upload.dataset.message : '');
+        upload.reportValidity();
+    });
+}
+
+var readBody = document.getElementById('read-body');
+if (readBody) {
+    // Confirming means having seen the end; the server can't tell
+    var readConfirm = document.getElementById('read-confirm');
+    var checkRead = function() {
+        if (readBody.scrollTop + readBody.clientHeight >= readBody.scrollHeight - 2) {
+            readConfirm.disabled = false;
+            document.getElementById('read-hint').hidden = true;
+            readBody.removeEventListener('scroll', checkRead);
+        }
+    };
+    readBody.addEventListener('scroll', checkRead);
+    checkRead();
+}
+var tabs = Array.from(document.querySelectorAll('button.attachment-tab'));
+tabs.forEach(function(tab, i) {
+    // Only the selected tab is in the tab order; the arrow keys move
+    // between tabs
+    tab.tabIndex = i === 0 ? 0 : -1;
+    tab.addEventListener('click', function() {
+        tabs.forEach(function(other) {
+            var selected = other === tab;
+            other.setAttribute('aria-selected', selected);
+            other.tabIndex = selected ? 0 : -1;
+            document.getElementById(other.getAttribute('aria-controls')).hidden = !selected;
+        });
+    });
+    tab.addEventListener('keydown', function(e) {
+        var step = {ArrowRight: 1, ArrowLeft: -1}[e.key];
+        if (!step) return;
+        e.preventDefault();
+        var next = tabs[(i + step + tabs.length) % tabs.length];
+        next.click();
+        next.focus();
+    });
+});
+// Long code and diffs scroll in their own boxes, which keyboard users
+// reach with Tab
+document.querySelectorAll('pre.review, pre.attachment').forEach(function(pre) {
+    pre.tabIndex = 0;
+});
+
+// How long the question has waited, written like the server's
+// units.FormatElapsed: the two largest units
+var elapsed = function(s) {
+    if (s < 60) return s + 's';
+    if (s < 3600) return Math.floor(s / 60) + 'm ' + s % 60 + 's';
+    if (s < 86400) return Math.floor(s / 3600) + 'h ' + Math.floor(s % 3600 / 60) + 'm';
+    return Math.floor(s / 86400) + 'd ' + Math.floor(s % 86400 / 3600) + 'h';
+};
+var waiting = document.getElementById('waiting');
+var asked = waiting ? Number(waiting.dataset.asked) : Date.now();
+if (waiting) {
+    var showWaiting = function() {
+        var s = Math.max(0, Math.floor((Date.now() - asked) / 1000));
+        waiting.textContent = waiting.dataset.format.replace('%s', elapsed(s));
+    };
+    setInterval(showWaiting, 1000);
+    showWaiting();
+}
+
+var countdown = document.querySelector('.countdown');
+if (countdown) {
+    // The deadline is absolute, so a slow load or a tab left in the
+    // background doesn't put the countdown behind the server's
+    var deadline = Number(countdown.dataset.deadline);
+    var banner = document.getElementById('deadline-banner');
+    var expired = false;
+    var pad = function(n) { return String(n).padStart(2, '0'); };
+    // The countdown itself is silent; screen readers hear the time left
+    // only as it passes a few marks, and when it runs out
+    var announcer = document.getElementById('countdown-announce');
+    var marks = [300, 60, 30, 10], mark = -1;
+    var tick = function() {
+        var left = Math.max(0, Math.ceil((deadline - Date.now()) / 1000));
+        var minutes = Math.floor(left / 60) % 60, hours = Math.floor(left / 3600);
+        document.getElementById('remaining').textContent =
+            (hours ? hours + ':' + pad(minutes) : minutes) + ':' + pad(left % 60);
+        var passed = marks.filter(function(m) { return left <= m; }).pop() || 0;
+        if (mark >= 0 && passed !== mark && left > 0) announcer.textContent = countdown.textContent;
+        mark = passed;
+        // As the deadline nears the countdown turns to a warning, and
+        // near the end a banner says the agent is about to give up
+        var used = (Date.now() - asked) / (deadline - asked);
+        countdown.classList.toggle('warning', used >= 0.75);
+        if (banner) banner.hidden = !(used >= 0.9 && left > 0);
+        if (left === 0) {
+            // A half-typed answer is not submitted; the server has moved on
+            expired = true;
+            countdown.textContent = script.dataset.timedOut;
+            announcer.textContent = script.dataset.timedOut;
+            countdown.classList.add('expired');
+            document.querySelector('form').classList.add('expired');
+            document.querySelectorAll('button, input, textarea, select').forEach(function(el) {
+                el.disabled = true;
+            });
+            clearInterval(timer);
+        }
+    };
+    document.querySelector('form').addEventListener('submit', function(e) {
+        if (expired) {
+            e.preventDefault();
+            e.stopImmediatePropagation();
+        }
+    }, true);
+    var timer = setInterval(tick, 250);
+    tick();
+}
+
+if (window.EventSource) {
+    // The server says when the prompt stops waiting for this page:
+    // answered, here or in another tab, timed out or cancelled
+    var events = new EventSource(base + '/events');
+    events.addEventListener('state', function(e) {
+        var state = JSON.parse(e.data);
+        events.close();
+        if (countdown) countdown.hidden = true;
+        var notice = document.getElementById('state-notice');
+        notice.textContent = state.message;
+        notice.hidden = false;
+        document.querySelectorAll('button, input, textarea, select').forEach(function(el) {
+            el.disabled = true;
+        });
+        // A withdrawn question has nothing left to answer
+        if (state.state === 'withdrawn') document.querySelector('form').hidden = true;
+        if (state.state === 'answered') window.close();
+    });
+}
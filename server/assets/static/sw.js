@@ -0,0 +1,66 @@
+// The dashboard's service worker. It keeps the app shell, the offline page
+// and icons, so an installed dashboard opens without the server and says
+// it is disconnected; everything else, prompts included, always comes from
+// the network and is never cached. It also shows the dashboard's push
+// notifications and opens the prompt's page when one is clicked.
+var SHELL_CACHE = 'prompt-mcp-shell-v1';
+var SHELL = ['offline', 'static/icon-192.png', 'static/icon-512.png'].map(function(path) {
+    return new URL(path, self.registration.scope).href;
+});
+
+self.addEventListener('install', function(event) {
+    event.waitUntil(caches.open(SHELL_CACHE).then(function(cache) {
+        return cache.addAll(SHELL);
+    }).then(function() {
+        return self.skipWaiting();
+    }));
+});
+
+self.addEventListener('activate', function(event) {
+    event.waitUntil(caches.keys().then(function(names) {
+        return Promise.all(names.filter(function(name) {
+            return name !== SHELL_CACHE;
+        }).map(function(name) {
+            return caches.delete(name);
+        }));
+    }).then(function() {
+        return self.clients.claim();
+    }));
+});
+
+self.addEventListener('fetch', function(event) {
+    var request = event.request;
+    if (request.mode === 'navigate') {
+        // Pages are fetched as usual; only a server that can't be reached
+        // gets the offline page, which reloads once it is back
+        event.respondWith(fetch(request).catch(function() {
+            return caches.match(SHELL[0]);
+        }));
+    } else if (request.method === 'GET' && SHELL.indexOf(request.url) >= 0) {
+        event.respondWith(caches.match(request).then(function(cached) {
+            return cached || fetch(request);
+        }));
+    }
+});
+
+self.addEventListener('push', function(event) {
+    var msg = event.data ? event.data.json() : {};
+    event.waitUntil(self.registration.showNotification(msg.title || '', {
+        body: msg.body,
+        tag: msg.tag,
+        icon: SHELL[1],
+        data: {url: msg.url},
+        requireInteraction: true
+    }));
+});
+
+self.addEventListener('notificationclick', function(event) {
+    event.notification.close();
+    var url = new URL(event.notification.data.url || '.', self.registration.scope).href;
+    event.waitUntil(self.clients.matchAll({type: 'window'}).then(function(windows) {
+        for (var i = 0; i < windows.length; i++) {
+            if (windows[i].url === url && 'focus' in windows[i]) return windows[i].focus();
+        }
+        return self.clients.openWindow(url);
+    }));
+});
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Attachment limits. Together they stay far below maxMessageSize.
+const (
+	maxAttachments          = 20
+	maxAttachmentBytes      = 256 * 1024
+	maxTotalAttachmentBytes = 1024 * 1024
+)
+
+// Attachment is a text file shown, read-only, as context for a prompt.
+type Attachment struct {
+	Name     string
+	Content  string
+	Language string
+}
+
+// size describes the length of the attachment's content in lines.
+func (a Attachment) size() string {
+	if n := strings.Count(strings.TrimSuffix(a.Content, "\n"), "\n") + 1; n != 1 {
+		return fmt.Sprintf("%d lines", n)
+	}
+	return "1 line"
+}
+
+// languageExtensions guesses an attachment's language from its name when
+// none is given.
+var languageExtensions = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".mjs":  "javascript",
+	".ts":   "typescript",
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+	".ini":  "ini",
+	".conf": "ini",
+	".sh":   "shell",
+	".bash": "shell",
+	".sql":  "sql",
+	".rs":   "rust",
+	".java": "java",
+	".c":    "c",
+	".h":    "c",
+	".cpp":  "cpp",
+	".rb":   "ruby",
+}
+
+// parseAttachments reads the attachments argument: an array of {name,
+// content, language} objects holding text. Binary content is refused.
+func parseAttachments(args map[string]interface{}) ([]Attachment, error) {
+	value, exists := args["attachments"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid attachments parameter: must be an array of {name, content, language} objects")
+	}
+	if len(items) > maxAttachments {
+		return nil, fmt.Errorf("Invalid attachments parameter: at most %d attachments are allowed", maxAttachments)
+	}
+
+	attachments := make([]Attachment, len(items))
+	total := 0
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid attachments parameter: attachment %d is not an object", i)
+		}
+		name, _, err := optionalString(obj, "name")
+		if err == nil && (strings.TrimSpace(name) == "" || strings.ContainsAny(name, "\r\n")) {
+			err = fmt.Errorf("name must be a non-empty single line")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid attachment %d: %v", i, err)
+		}
+
+		content, present, err := optionalString(obj, "content")
+		switch {
+		case err != nil:
+		case !present:
+			err = fmt.Errorf("content is required")
+		case len(content) > maxAttachmentBytes:
+			err = fmt.Errorf("content is larger than %d bytes", maxAttachmentBytes)
+		case !utf8.ValidString(content) || strings.ContainsRune(content, 0):
+			err = fmt.Errorf("content must be text, not binary data")
+		}
+		language, _, langErr := optionalString(obj, "language")
+		if err == nil {
+			err = langErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid attachment %q: %v", name, err)
+		}
+
+		total += len(content)
+		if total > maxTotalAttachmentBytes {
+			return nil, fmt.Errorf("Invalid attachments parameter: together they are larger than %d bytes", maxTotalAttachmentBytes)
+		}
+		if language == "" {
+			language = languageExtensions[strings.ToLower(filepath.Ext(name))]
+		}
+		attachments[i] = Attachment{Name: name, Content: content, Language: strings.ToLower(language)}
+	}
+	return attachments, nil
+}
+
+// runTTYAttachments lists the attachments and pages through any the user
+// picks before the question is asked.
+func runTTYAttachments(tty io.ReadWriter, scanner *bufio.Scanner, attachments []Attachment) error {
+	fmt.Fprintf(tty, "Attachments:\n")
+	for i, a := range attachments {
+		fmt.Fprintf(tty, "  %d) %s (%s)\n", i+1, a.Name, a.size())
+	}
+
+	for {
+		line, err := readTTYLine(tty, scanner, fmt.Sprintf("View attachment [1-%d], or press Enter to answer: ", len(attachments)), func(line string) (string, error) {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				return line, nil
+			}
+			n, err := strconv.Atoi(line)
+			if err != nil || n < 1 || n > len(attachments) {
+				return "", fmt.Errorf("Please enter a number from 1 to %d", len(attachments))
+			}
+			return line, nil
+		})
+		if err != nil || line == "" {
+			return err
+		}
+
+		n, _ := strconv.Atoi(line)
+		fmt.Fprintf(tty, "--- %s ---\n", attachments[n-1].Name)
+		if err := pageTTYContent(tty, scanner, attachments[n-1].Content); err != nil {
+			return err
+		}
+	}
+}
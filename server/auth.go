@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicAuth is the user and password every web page asks for, on top of
+// the page tokens, for listeners reachable beyond this machine. A nil
+// BasicAuth lets everyone through.
+type BasicAuth struct {
+	user     string
+	password string
+	hashed   bool
+
+	// verified, once a password matched the bcrypt hash, is its SHA-256,
+	// so a page's further requests don't each pay for bcrypt
+	mu       sync.Mutex
+	verified []byte
+}
+
+// ParseBasicAuth reads credentials written as "user:password". The password
+// may be a bcrypt hash ("$2a$…", "$2b$…" or "$2y$…"), as made by
+// htpasswd -nB, so the config file needn't hold it in clear.
+func ParseBasicAuth(s string) (*BasicAuth, error) {
+	user, password, ok := strings.Cut(s, ":")
+	if !ok || user == "" || password == "" {
+		return nil, fmt.Errorf("web auth must be user:password, with a password or its bcrypt hash")
+	}
+	a := &BasicAuth{user: user, password: password}
+	if strings.HasPrefix(password, "$2") {
+		if _, err := bcrypt.Cost([]byte(password)); err != nil {
+			return nil, fmt.Errorf("web auth password looks like a bcrypt hash but isn't one: %w", err)
+		}
+		a.hashed = true
+	}
+	return a, nil
+}
+
+// Allows reports whether r carries the credentials. Both are compared in
+// constant time, and both always, so the time taken tells nothing about
+// which was wrong.
+func (a *BasicAuth) Allows(r *http.Request) bool {
+	if a == nil {
+		return true
+	}
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.user))
+	return a.passwordOK(password)&userOK == 1
+}
+
+// passwordOK returns 1 when password is the configured one, else 0.
+func (a *BasicAuth) passwordOK(password string) int {
+	if !a.hashed {
+		return subtle.ConstantTimeCompare([]byte(password), []byte(a.password))
+	}
+	sum := sha256.Sum256([]byte(password))
+	a.mu.Lock()
+	verified := a.verified
+	a.mu.Unlock()
+	if verified != nil {
+		return subtle.ConstantTimeCompare(sum[:], verified)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(a.password), []byte(password)) != nil {
+		return 0
+	}
+	a.mu.Lock()
+	a.verified = sum[:]
+	a.mu.Unlock()
+	return 1
+}
+
+// challenge asks the browser for the credentials.
+func challenge(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="prompt-mcp", charset="UTF-8"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxBatchQuestions is the most questions one user_input_batch call may ask.
+const maxBatchQuestions = 50
+
+// Question types of user_input_batch, mapped onto form field types.
+var batchQuestionTypes = map[string]string{
+	"text":    FieldText,
+	"number":  FieldNumber,
+	"boolean": FieldBoolean,
+	"choice":  FieldSelect,
+}
+
+// PartialAnswers collects the answers to a form as the user gives them, so
+// what was answered survives a timeout. Providers fill it as they go.
+type PartialAnswers struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// Set records the typed answer to the named field; nil removes it.
+func (p *PartialAnswers) Set(name string, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.values == nil {
+		p.values = make(map[string]interface{})
+	}
+	if value == nil {
+		delete(p.values, name)
+		return
+	}
+	p.values[name] = value
+}
+
+// Values returns a copy of the answers collected so far.
+func (p *PartialAnswers) Values() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	values := make(map[string]interface{}, len(p.values))
+	for name, value := range p.values {
+		values[name] = value
+	}
+	return values
+}
+
+// NewBatchPrompt returns a form prompt asking every question in one session
+// and keeping track of the answers given so far.
+func NewBatchPrompt(prompt, method string, questions []FormField) *PromptRequest {
+	req := NewFormPrompt(prompt, method, questions)
+	req.Partial = &PartialAnswers{}
+	return req
+}
+
+// parseBatchQuestions reads the questions argument of user_input_batch.
+func parseBatchQuestions(args map[string]interface{}) ([]FormField, error) {
+	items, ok := args["questions"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("Invalid questions parameter: must be a non-empty array of questions")
+	}
+	if len(items) > maxBatchQuestions {
+		return nil, fmt.Errorf("Invalid questions parameter: at most %d questions are allowed", maxBatchQuestions)
+	}
+
+	questions := make([]FormField, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid questions parameter: question %d is not an object", i)
+		}
+
+		id, _, err := optionalString(obj, "id")
+		if err == nil && id == "" {
+			err = fmt.Errorf("Invalid questions parameter: question %d has no id", i)
+		}
+		if err == nil && seen[id] {
+			err = fmt.Errorf("Invalid questions parameter: duplicate id %q", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[id] = true
+
+		question, err := parseBatchQuestion(id, obj)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid question %q: %v", id, err)
+		}
+		questions[i] = question
+	}
+	return questions, nil
+}
+
+func parseBatchQuestion(id string, obj map[string]interface{}) (FormField, error) {
+	question := FormField{Name: id, Type: FieldText}
+
+	prompt, _, err := optionalString(obj, "prompt")
+	if err == nil && strings.TrimSpace(prompt) == "" {
+		err = fmt.Errorf("prompt is required")
+	}
+	if err != nil {
+		return question, err
+	}
+	question.Label = prompt
+
+	typ, present, err := optionalString(obj, "type")
+	if err != nil {
+		return question, err
+	}
+	if present {
+		fieldType, ok := batchQuestionTypes[typ]
+		if !ok {
+			return question, fmt.Errorf("type must be one of text, number, boolean, choice")
+		}
+		question.Type = fieldType
+	}
+
+	options, hasOptions, err := optionalStringList(obj, "options")
+	if err != nil {
+		return question, err
+	}
+	switch {
+	case question.Type == FieldSelect:
+		if err := validateOptions(options); err != nil {
+			return question, err
+		}
+		question.Options = options
+	case hasOptions:
+		return question, fmt.Errorf("options are only allowed for choice questions")
+	}
+	return question, nil
+}
+
+// batchAnswers lists the answers in question order, with the ids of the
+// questions that have none.
+func batchAnswers(questions []FormField, values map[string]interface{}) ([]map[string]interface{}, []string) {
+	answers := []map[string]interface{}{}
+	missing := []string{}
+	for _, question := range questions {
+		value, answered := values[question.Name]
+		if !answered {
+			missing = append(missing, question.Name)
+			continue
+		}
+		answers = append(answers, map[string]interface{}{
+			"id":       question.Name,
+			"response": value,
+		})
+	}
+	return answers, missing
+}
+
+func (s *MCPServer) handleUserInputBatchTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please answer the following questions"
+	}
+
+	questions, err := parseBatchQuestions(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	timeout, err := optionalTimeout(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewBatchPrompt(prompt, promptMethod(args), questions)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	var terr *TimeoutError
+	if errors.As(err, &terr) {
+		answers, missing := batchAnswers(questions, promptReq.Partial.Values())
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent(fmt.Sprintf("%s with %d of %d questions answered; missing: %s",
+					terr.Error(), len(answers), len(questions), strings.Join(missing, ", "))),
+			},
+			"structuredContent": map[string]interface{}{
+				"answers":  answers,
+				"missing":  missing,
+				"timedOut": true,
+				"timeout":  terr.Timeout.Seconds(),
+			},
+			"isError": true,
+		})
+		return
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(answer), &values); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode answers: %v", err))
+		return
+	}
+	answers, missing := batchAnswers(questions, values)
+
+	data, err := json.Marshal(answers)
+	if err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to encode answers: %v", err))
+		return
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(string(data)),
+		},
+		"structuredContent": map[string]interface{}{
+			"answers": answers,
+			"missing": missing,
+		},
+		"isError": false,
+	})
+}
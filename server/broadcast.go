@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// dashboardBuffer is how many events may queue for an index page before it
+// is considered too slow and dropped. The page catches up when it
+// reconnects.
+const dashboardBuffer = 64
+
+// dashboardBacklog is how many past events the hub keeps for index pages
+// catching up after a reconnection.
+const dashboardBacklog = 256
+
+// DashboardEvent is a change to the dashboard's list as streamed to its
+// index pages: Type is EventPromptCreated or EventPromptResolved, Entry the
+// prompt as listed, with its outcome once resolved, and Pending how many
+// prompts are left waiting. ID numbers the hub's events from 1.
+type DashboardEvent struct {
+	ID      int64          `json:"id"`
+	Type    string         `json:"type"`
+	Entry   DashboardEntry `json:"entry"`
+	Pending int            `json:"pending"`
+}
+
+// DashboardHub fans the dashboard's events out to the index pages
+// listening. Publishing never waits for a page: one whose queue is full is
+// dropped, and catches up from the hub's backlog when it subscribes again.
+type DashboardHub struct {
+	mu          sync.Mutex
+	seq         int64
+	backlog     []DashboardEvent
+	subscribers map[*DashboardSubscriber]struct{}
+}
+
+// DashboardSubscriber receives a hub's events until it unsubscribes or is
+// dropped, which closes Events.
+type DashboardSubscriber struct {
+	events chan DashboardEvent
+	once   sync.Once
+}
+
+func NewDashboardHub() *DashboardHub {
+	return &DashboardHub{subscribers: make(map[*DashboardSubscriber]struct{})}
+}
+
+// Events is the subscriber's queue, closed when it is dropped.
+func (s *DashboardSubscriber) Events() <-chan DashboardEvent {
+	return s.events
+}
+
+// send queues ev without blocking, reporting whether there was room.
+func (s *DashboardSubscriber) send(ev DashboardEvent) bool {
+	select {
+	case s.events <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *DashboardSubscriber) close() {
+	s.once.Do(func() { close(s.events) })
+}
+
+// LastID is the ID of the latest event, 0 before the first.
+func (h *DashboardHub) LastID() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+// Publish numbers ev, keeps it for pages catching up and queues it for
+// every subscriber. Subscribers whose queue is full are dropped. It returns
+// ev with its ID.
+func (h *DashboardHub) Publish(ev DashboardEvent) DashboardEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	ev.ID = h.seq
+	h.backlog = append(h.backlog, ev)
+	if len(h.backlog) > dashboardBacklog {
+		h.backlog = h.backlog[len(h.backlog)-dashboardBacklog:]
+	}
+	for s := range h.subscribers {
+		if !s.send(ev) {
+			delete(h.subscribers, s)
+			s.close()
+		}
+	}
+	return ev
+}
+
+// Subscribe starts queueing events for a page that has seen those up to
+// lastID, beginning with the ones it missed. It reports false when the
+// missed events are no longer all kept, or lastID is from before a
+// restart, and the page must load the list afresh instead.
+func (h *DashboardHub) Subscribe(lastID int64) (*DashboardSubscriber, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var missed []DashboardEvent
+	complete := lastID <= h.seq
+	if complete && lastID < h.seq {
+		if len(h.backlog) == 0 || h.backlog[0].ID > lastID+1 {
+			complete = false
+		} else {
+			missed = h.backlog[lastID+1-h.backlog[0].ID:]
+		}
+	}
+	// The queue has room for the whole replay on top of the usual buffer
+	s := &DashboardSubscriber{events: make(chan DashboardEvent, len(missed)+dashboardBuffer)}
+	for _, ev := range missed {
+		s.send(ev)
+	}
+	h.subscribers[s] = struct{}{}
+	return s, complete
+}
+
+// Unsubscribe stops queueing events for s and closes its queue.
+func (h *DashboardHub) Unsubscribe(s *DashboardSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, s)
+	s.close()
+}
+
+// dashboardOutcome is how a dashboard prompt ended, given what its handler
+// returned.
+func dashboardOutcome(err error) string {
+	var declined *DeclinedError
+	switch {
+	case err == nil:
+		return OutcomeAnswered
+	case errors.As(err, &declined):
+		return OutcomeDeclined
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return OutcomeTimeout
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL is how long a remembered answer is reused unless
+// configured otherwise.
+const defaultCacheTTL = time.Hour
+
+// Remember records that the user asked for their answer to be reused. It
+// is set on prompts the cache may answer; nil offers no choice.
+type Remember struct {
+	mu     sync.Mutex
+	wanted bool
+}
+
+// Set records that the answer should be remembered. A nil Remember ignores
+// it.
+func (r *Remember) Set() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wanted = true
+}
+
+// Wanted reports whether the user asked to remember the answer.
+func (r *Remember) Wanted() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wanted
+}
+
+// AnswerCache keeps the answers the user asked to remember, so the same
+// question is answered without asking again until the answer expires.
+// Questions match on their kind and exact text, with runs of whitespace
+// counted as one space. A nil AnswerCache remembers nothing.
+type AnswerCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedAnswer
+}
+
+type cachedAnswer struct {
+	response string
+	expires  time.Time
+}
+
+// NewAnswerCache returns an empty cache.
+func NewAnswerCache() *AnswerCache {
+	return &AnswerCache{entries: make(map[string]cachedAnswer)}
+}
+
+// Store remembers response to req for ttl.
+func (c *AnswerCache) Store(req *PromptRequest, response string, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cacheKey(req)] = cachedAnswer{response: response, expires: time.Now().Add(ttl)}
+}
+
+// Lookup returns the remembered answer to req. An answer the prompt's
+// validation no longer accepts, such as an option that was dropped, is not
+// returned.
+func (c *AnswerCache) Lookup(req *PromptRequest) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	key := cacheKey(req)
+	entry, ok := c.entries[key]
+	if ok && !time.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	if req.Validate != nil {
+		valid, err := req.Validate(entry.response)
+		if err != nil {
+			return "", false
+		}
+		return valid, true
+	}
+	return entry.response, true
+}
+
+// Len returns how many answers are remembered.
+func (c *AnswerCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireLocked()
+	return len(c.entries)
+}
+
+// Clear forgets every answer and returns how many there were.
+func (c *AnswerCache) Clear() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireLocked()
+	n := len(c.entries)
+	c.entries = make(map[string]cachedAnswer)
+	return n
+}
+
+func (c *AnswerCache) expireLocked() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheKey is what a remembered answer is found by: the prompt's kind and
+// its text with whitespace normalized.
+func cacheKey(req *PromptRequest) string {
+	return req.Kind + "\x00" + strings.Join(strings.Fields(req.Prompt), " ")
+}
+
+// cacheable reports whether an answer to req may be remembered: plain text,
+// number, confirm and choice prompts. Sensitive and secret answers never
+// are, nor confirmations that must be typed out or answers whose raw input
+// is reported.
+func cacheable(req *PromptRequest) bool {
+	switch req.Kind {
+	case KindText, KindNumber, KindConfirm, KindChoice:
+	default:
+		return false
+	}
+	return !req.Sensitive && !req.Secret && req.Phrase == nil && req.Raw == nil
+}
+
+// AnswerCache returns the answers the user asked to remember. It is nil
+// when the config disables the cache.
+func (s *MCPServer) AnswerCache() *AnswerCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cache == nil {
+		if s.config.cacheTTL() <= 0 {
+			return nil
+		}
+		s.cache = NewAnswerCache()
+	}
+	return s.cache
+}
@@ -0,0 +1,468 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// NewChoicePrompt returns a prompt asking the user to pick one of options,
+// either by number or by typing the option. The answer is always the exact
+// option string.
+func NewChoicePrompt(prompt, method string, options []string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindChoice
+	req.Options = options
+	req.Trim = TrimNone
+	req.Validate = choiceValidator(options)
+	return req
+}
+
+func choiceValidator(options []string) func(string) (string, error) {
+	return func(response string) (string, error) {
+		response = strings.TrimSpace(response)
+
+		if n, err := strconv.Atoi(response); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], nil
+		}
+		for _, option := range options {
+			if option == response {
+				return option, nil
+			}
+		}
+		for _, option := range options {
+			if strings.EqualFold(option, response) {
+				return option, nil
+			}
+		}
+
+		return "", fmt.Errorf("Invalid selection %q: enter a number from 1 to %d or one of the options", response, len(options))
+	}
+}
+
+// OtherLabel is the entry a choice prompt with AllowOther adds after its
+// options.
+const OtherLabel = "Other…"
+
+// ChoiceAnswer is the answer to a choice prompt with AllowOther: the option
+// picked, or the text typed for Other.
+type ChoiceAnswer struct {
+	Option string  `json:"option,omitempty"`
+	Other  *string `json:"other,omitempty"`
+}
+
+// OtherResponse is the response a provider gives when the user picks Other
+// and types text.
+func OtherResponse(text string) string {
+	data, _ := json.Marshal(ChoiceAnswer{Other: &text})
+	return string(data)
+}
+
+// isOtherPick reports whether response picks the Other entry of req, by its
+// number or its name.
+func isOtherPick(req *PromptRequest, response string) bool {
+	response = strings.TrimSpace(response)
+	if n, err := strconv.Atoi(response); err == nil {
+		return n == len(req.Options)+1
+	}
+	if containsString(req.Options, response) {
+		return false
+	}
+	return strings.EqualFold(response, "other") || response == OtherLabel
+}
+
+// NewOtherChoicePrompt returns a choice prompt that also offers Other, for
+// an answer of the user's own. Responses are an option, as for
+// NewChoicePrompt, or an OtherResponse whose text must pass validateText.
+// The answer is a ChoiceAnswer JSON object.
+func NewOtherChoicePrompt(prompt, method string, options []string, validateText func(string) error) *PromptRequest {
+	req := NewChoicePrompt(prompt, method, options)
+	req.AllowOther = true
+
+	pick := choiceValidator(options)
+	req.Validate = func(response string) (string, error) {
+		var answer ChoiceAnswer
+		if strings.HasPrefix(strings.TrimSpace(response), "{") && json.Unmarshal([]byte(response), &answer) == nil && answer.Other != nil {
+			text := strings.TrimSpace(*answer.Other)
+			if text == "" {
+				return "", fmt.Errorf("Please type your answer for Other")
+			}
+			if validateText != nil {
+				if err := validateText(text); err != nil {
+					return "", err
+				}
+			}
+			answer = ChoiceAnswer{Other: &text}
+		} else {
+			option, err := pick(response)
+			if err != nil {
+				return "", fmt.Errorf("Invalid selection %q: enter a number from 1 to %d or one of the options", strings.TrimSpace(response), len(options)+1)
+			}
+			answer = ChoiceAnswer{Option: option}
+		}
+		data, err := json.Marshal(answer)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return req
+}
+
+// otherTextValidator checks the text typed for Other against the pattern and
+// max_length of user_choice.
+func otherTextValidator(args map[string]interface{}) (func(string) error, error) {
+	pattern, hasPattern, err := optionalString(args, "pattern")
+	if err != nil {
+		return nil, err
+	}
+	var re *regexp.Regexp
+	if hasPattern {
+		if re, err = regexp.Compile(`^(?:` + pattern + `)$`); err != nil {
+			return nil, fmt.Errorf("Invalid pattern parameter: %v", err)
+		}
+	}
+	maxLength := 0
+	n, err := optionalNumber(args, "max_length")
+	if err != nil {
+		return nil, err
+	}
+	if n != nil {
+		if *n != float64(int(*n)) || *n < 1 {
+			return nil, fmt.Errorf("Invalid max_length parameter: must be a whole number of at least 1")
+		}
+		maxLength = int(*n)
+	}
+
+	return func(text string) error {
+		if maxLength > 0 && utf8.RuneCountInString(text) > maxLength {
+			return fmt.Errorf("Your answer must be at most %d characters", maxLength)
+		}
+		if re != nil && !re.MatchString(text) {
+			return fmt.Errorf("Your answer must match the pattern %s", pattern)
+		}
+		return nil
+	}, nil
+}
+
+// NewMultiChoicePrompt returns a prompt asking the user to pick between min
+// and max of options, as a comma-separated list of numbers or options. The
+// answer is a JSON array of the picked options in their original order.
+func NewMultiChoicePrompt(prompt, method string, options []string, min, max int) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindChoice
+	req.Options = options
+	req.MultiSelect = true
+	req.Trim = TrimNone
+	req.Validate = multiChoiceValidator(options, min, max)
+	return req
+}
+
+func multiChoiceValidator(options []string, min, max int) func(string) (string, error) {
+	single := choiceValidator(options)
+	return func(response string) (string, error) {
+		picked := make(map[string]bool)
+		for _, item := range strings.Split(response, ",") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			option, err := single(item)
+			if err != nil {
+				return "", err
+			}
+			picked[option] = true
+		}
+
+		if len(picked) < min || len(picked) > max {
+			return "", fmt.Errorf("Please select %s", selectionRange(min, max))
+		}
+
+		// Keep the order the options were offered in
+		selected := []string{}
+		for _, option := range options {
+			if picked[option] {
+				selected = append(selected, option)
+			}
+		}
+		data, err := json.Marshal(selected)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// selectionRange describes how many options must be picked.
+func selectionRange(min, max int) string {
+	switch {
+	case min == max:
+		return fmt.Sprintf("exactly %d", min)
+	case min == 0:
+		return fmt.Sprintf("at most %d", max)
+	default:
+		return fmt.Sprintf("between %d and %d", min, max)
+	}
+}
+
+// parseSelectionBounds reads min_selections and max_selections, which default
+// to one and to every option.
+func parseSelectionBounds(args map[string]interface{}, count int) (int, int, error) {
+	min, max := 1, count
+	for _, bound := range []struct {
+		name string
+		dest *int
+	}{{"min_selections", &min}, {"max_selections", &max}} {
+		n, err := optionalNumber(args, bound.name)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n == nil {
+			continue
+		}
+		if *n != float64(int(*n)) {
+			return 0, 0, fmt.Errorf("Invalid %s parameter: must be a whole number", bound.name)
+		}
+		*bound.dest = int(*n)
+	}
+
+	if min < 0 || max < 1 || min > max || max > count {
+		return 0, 0, fmt.Errorf("Invalid selection bounds: need 0 <= min_selections <= max_selections <= %d (the number of options) and max_selections >= 1", count)
+	}
+	return min, max, nil
+}
+
+// validateOptions checks the options array passed to a choice tool.
+func validateOptions(options []string) error {
+	if len(options) == 0 {
+		return fmt.Errorf("Invalid options parameter: at least one option is required")
+	}
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if strings.TrimSpace(option) == "" {
+			return fmt.Errorf("Invalid options parameter: options must not be empty")
+		}
+		if seen[option] {
+			return fmt.Errorf("Invalid options parameter: duplicate option %q", option)
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+func (s *MCPServer) handleUserChoiceTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	options, present, err := optionalStringList(args, "options")
+	if err == nil && !present {
+		err = fmt.Errorf("Missing options parameter")
+	}
+	if err == nil {
+		err = validateOptions(options)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	multi, err := optionalBool(args, "multi_select", false)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	allowOther, err := optionalBool(args, "allow_other", false)
+	if err == nil && multi && allowOther {
+		err = fmt.Errorf("Invalid allow_other parameter: not supported with multi_select")
+	}
+	if err == nil && !allowOther && (args["pattern"] != nil || args["max_length"] != nil) {
+		err = fmt.Errorf("pattern and max_length only apply to the text typed for Other, which needs allow_other")
+	}
+	if err == nil {
+		_, err = optionalMaxAttempts(args)
+	}
+	if err == nil {
+		_, err = optionalBool(args, "cache_bypass", false)
+	}
+	if err == nil {
+		_, err = optionalBool(args, "require_confirmation", false)
+	}
+	var singleKey bool
+	if err == nil {
+		singleKey, err = optionalBool(args, "single_key", false)
+	}
+	var conv *Conversation
+	if err == nil {
+		conv, err = parseContext(args)
+	}
+	if err == nil && singleKey {
+		switch {
+		case multi || allowOther || conv != nil:
+			err = fmt.Errorf("Invalid single_key parameter: not supported with multi_select, allow_other or context")
+		case len(options) > maxSingleKeyOptions:
+			err = fmt.Errorf("Invalid single_key parameter: at most %d options can be picked with one key", maxSingleKeyOptions)
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if multi {
+		s.handleMultiSelect(req, args, prompt, options, progressToken)
+		return
+	}
+	if allowOther {
+		s.handleOtherChoice(req, args, prompt, options, progressToken)
+		return
+	}
+
+	promptReq := NewChoicePrompt(prompt, promptMethod(args), options)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts, _ = optionalMaxAttempts(args)
+	promptReq.Context, _ = parseContext(args)
+	promptReq.CacheBypass, _ = optionalBool(args, "cache_bypass", false)
+	promptReq.RequireConfirmation, _ = optionalBool(args, "require_confirmation", false)
+	promptReq.SingleKey = singleKey
+
+	choice, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	index := -1
+	for i, option := range options {
+		if option == choice {
+			index = i
+			break
+		}
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(choice),
+		},
+		"structuredContent": map[string]interface{}{
+			"choice": choice,
+			"index":  index,
+		},
+		"isError": false,
+	})
+}
+
+// handleOtherChoice asks for one of options or an answer of the user's own,
+// telling the agent which it got.
+func (s *MCPServer) handleOtherChoice(req MCPRequest, args map[string]interface{}, prompt string, options []string, progressToken interface{}) {
+	validateText, err := otherTextValidator(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewOtherChoicePrompt(prompt, promptMethod(args), options, validateText)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts, _ = optionalMaxAttempts(args)
+	promptReq.Context, _ = parseContext(args)
+	promptReq.CacheBypass, _ = optionalBool(args, "cache_bypass", false)
+	promptReq.RequireConfirmation, _ = optionalBool(args, "require_confirmation", false)
+
+	response, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var answer ChoiceAnswer
+	if err := json.Unmarshal([]byte(response), &answer); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode choice: %v", err))
+		return
+	}
+
+	if answer.Other != nil {
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent("Other: " + *answer.Other),
+			},
+			"structuredContent": map[string]interface{}{
+				"choice":   *answer.Other,
+				"index":    -1,
+				"selected": "other",
+				"text":     *answer.Other,
+				"custom":   true,
+			},
+			"isError": false,
+		})
+		return
+	}
+
+	index := -1
+	for i, option := range options {
+		if option == answer.Option {
+			index = i
+			break
+		}
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer.Option),
+		},
+		"structuredContent": map[string]interface{}{
+			"choice":   answer.Option,
+			"index":    index,
+			"selected": answer.Option,
+			"custom":   false,
+		},
+		"isError": false,
+	})
+}
+
+func (s *MCPServer) handleMultiSelect(req MCPRequest, args map[string]interface{}, prompt string, options []string, progressToken interface{}) {
+	min, max, err := parseSelectionBounds(args, len(options))
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewMultiChoicePrompt(prompt, promptMethod(args), options, min, max)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts, _ = optionalMaxAttempts(args)
+	promptReq.Context, _ = parseContext(args)
+	promptReq.CacheBypass, _ = optionalBool(args, "cache_bypass", false)
+	promptReq.RequireConfirmation, _ = optionalBool(args, "require_confirmation", false)
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var selected []string
+	if err := json.Unmarshal([]byte(answer), &selected); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode selection: %v", err))
+		return
+	}
+
+	indices := []int{}
+	for i, option := range options {
+		if containsString(selected, option) {
+			indices = append(indices, i)
+		}
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer),
+		},
+		"structuredContent": map[string]interface{}{
+			"choices": selected,
+			"indices": indices,
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"prompt-mcp/i18n"
+)
+
+// maxClientField caps the client name and version shown to the user, in
+// runes.
+const maxClientField = 64
+
+// ClientInfo is the name and version the MCP client gave in initialize.
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// String writes the client as "Claude Desktop 0.9.2", or "unknown client"
+// when it didn't say.
+func (c *ClientInfo) String() string {
+	if c == nil || c.Name == "" {
+		return "unknown client"
+	}
+	if c.Version == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Version
+}
+
+// parseClientInfo reads clientInfo from the params of initialize. Anything
+// but a named client is nil. Control characters are dropped and long
+// values cut, as the name ends up on the terminal.
+func parseClientInfo(params interface{}) *ClientInfo {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil
+	}
+	var p struct {
+		ClientInfo struct {
+			Name    interface{} `json:"name"`
+			Version interface{} `json:"version"`
+		} `json:"clientInfo"`
+	}
+	if json.Unmarshal(raw, &p) != nil {
+		return nil
+	}
+	name, _ := p.ClientInfo.Name.(string)
+	version, _ := p.ClientInfo.Version.(string)
+	info := &ClientInfo{Name: cleanClientField(name), Version: cleanClientField(version)}
+	if info.Name == "" {
+		return nil
+	}
+	return info
+}
+
+func cleanClientField(s string) string {
+	s = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s))
+	if runes := []rune(s); len(runes) > maxClientField {
+		s = string(runes[:maxClientField]) + "…"
+	}
+	return s
+}
+
+// ClientInfo returns the client of the session, nil before initialize or
+// when it didn't say.
+func (s *MCPServer) ClientInfo() *ClientInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// requester says who asked the prompt in locale, "Requested by: Claude
+// Desktop 0.9.2 · question 4", or "" for a prompt asked outside an MCP
+// session.
+func (r *PromptRequest) requester(locale string) string {
+	if r.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s · %s %d", i18n.T(locale, i18n.RequestedBy), r.clientName(locale), i18n.T(locale, i18n.SessionQuestion), r.ID)
+}
+
+// clientName is the client as shown to the user in locale.
+func (r *PromptRequest) clientName(locale string) string {
+	if r.Client == nil {
+		return i18n.T(locale, i18n.UnknownClient)
+	}
+	return r.Client.String()
+}
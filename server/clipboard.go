@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// maxClipboardBytes caps the clipboard text returned to the agent.
+const maxClipboardBytes = 1024 * 1024
+
+// clipboardReadTimeout bounds a clipboard command, which can hang when no
+// display is reachable.
+const clipboardReadTimeout = 5 * time.Second
+
+// ClipboardReader returns the text on the system clipboard.
+type ClipboardReader func(ctx context.Context) (string, error)
+
+// SetClipboardReader replaces how user_clipboard reads the clipboard.
+func (s *MCPServer) SetClipboardReader(read ClipboardReader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clipboard = read
+}
+
+func (s *MCPServer) clipboardReader() ClipboardReader {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clipboard != nil {
+		return s.clipboard
+	}
+	return ReadSystemClipboard
+}
+
+// clipboardCommands lists the commands that print the clipboard on this
+// platform, in order of preference.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+	}
+	commands := [][]string{{"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append([][]string{{"wl-paste", "--no-newline"}}, commands...)
+	}
+	return commands
+}
+
+// ReadSystemClipboard runs the first clipboard command available.
+func ReadSystemClipboard(ctx context.Context) (string, error) {
+	for _, command := range clipboardCommands() {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, clipboardReadTimeout)
+		defer cancel()
+
+		out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("%s failed: %w", command[0], err)
+		}
+		return string(out), nil
+	}
+	return "", fmt.Errorf("no clipboard command found (install wl-clipboard, xclip or xsel)")
+}
+
+// handleUserClipboardTool reads the clipboard only after the user agrees.
+// The contents go to the agent alone: they are never stored as a resource
+// or published to observers, and a refusal returns nothing of them.
+func (s *MCPServer) handleUserClipboardTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	reason, _, err := optionalString(args, "reason")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewConfirmPrompt("The agent wants to read your clipboard. Allow?", promptMethod(args), AnswerNo)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.Title = "Clipboard access"
+	promptReq.Detail = reason
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+	if answer != AnswerYes {
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent("The user refused consent to read the clipboard"),
+			},
+			"structuredContent": map[string]interface{}{
+				"consented": false,
+			},
+			"isError": true,
+		})
+		return
+	}
+
+	text, err := s.clipboardReader()(context.Background())
+	if err == nil && !utf8.ValidString(text) {
+		err = fmt.Errorf("the clipboard does not hold text")
+	}
+	if err != nil {
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent(fmt.Sprintf("Failed to read the clipboard: %v", err)),
+			},
+			"structuredContent": map[string]interface{}{
+				"consented": true,
+			},
+			"isError": true,
+		})
+		return
+	}
+
+	size := len(text)
+	truncated := size > maxClipboardBytes
+	if truncated {
+		text = truncateUTF8(text, maxClipboardBytes)
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": map[string]interface{}{
+			"consented": true,
+			"size":      size,
+			"truncated": truncated,
+		},
+		"isError": false,
+	})
+}
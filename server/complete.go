@@ -0,0 +1,219 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Keys the path line editor handles.
+const (
+	keyCtrlC     = 0x03
+	keyCtrlD     = 0x04
+	keyBackspace = 0x08
+	keyTab       = '\t'
+	keyNewline   = '\n'
+	keyReturn    = '\r'
+	keyCtrlU     = 0x15
+	keyCtrlW     = 0x17
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// lineEditor is implemented by terminals that switch to character-at-a-time
+// input without echo themselves. The returned function restores them.
+type lineEditor interface {
+	RawMode() (func(), error)
+}
+
+// rawMode makes tty deliver each key as it is typed, without echoing it,
+// until the returned function is called. Signals still interrupt.
+func rawMode(tty io.ReadWriteCloser) (func(), error) {
+	if t, ok := tty.(lineEditor); ok {
+		return t.RawMode()
+	}
+
+	f, ok := tty.(*os.File)
+	if !ok {
+		return nil, fmt.Errorf("cannot read keys from this terminal")
+	}
+	return setTTYMode(f, "switch the terminal to raw input", "-icanon", "-echo", "min", "1", "time", "0")
+}
+
+// Complete extends a partly typed path as far as the entries on disk
+// allow. It returns the extended input and, when several entries still
+// match, their names, with a trailing slash for directories. Hidden entries
+// are only offered once a "." is typed, and with Restrict nothing outside
+// StartDir is offered.
+func (o *FileOptions) Complete(input string) (string, []string) {
+	typedDir, prefix := "", input
+	if i := strings.LastIndex(input, string(filepath.Separator)); i >= 0 {
+		typedDir, prefix = input[:i+1], input[i+1:]
+	}
+
+	dir := expandHome(typedDir)
+	if dir == "" {
+		dir = "."
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(o.StartDir, dir)
+	}
+	dir = filepath.Clean(dir)
+	if o.Restrict && !o.contains(dir) {
+		return input, nil
+	}
+
+	entries, err := o.listDir(dir)
+	if err != nil {
+		return input, nil
+	}
+	var matches []string
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name, prefix) || (strings.HasPrefix(entry.Name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if o.Restrict && !o.contains(entry.Path) {
+			continue
+		}
+		name := entry.Name
+		if entry.IsDir {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, name)
+	}
+
+	switch len(matches) {
+	case 0:
+		return input, nil
+	case 1:
+		return typedDir + matches[0], nil
+	}
+	return typedDir + sharedPrefix(matches), matches
+}
+
+// sharedPrefix returns the longest prefix, in whole characters, shared by
+// every name.
+func sharedPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+// readTTYPath reads a path with Tab completion from a terminal in raw mode,
+// asking again while it fails validate. Tab completes as far as the matches
+// agree and lists them when several remain.
+func readTTYPath(tty io.ReadWriter, label string, opts *FileOptions, validate func(string) (string, error)) (string, error) {
+	for {
+		fmt.Fprint(tty, label)
+		line, err := editTTYPath(tty, label, opts)
+		if err != nil {
+			return "", err
+		}
+
+		valid, err := validate(line)
+		if err == nil {
+			return valid, nil
+		}
+		if isAttemptsExhausted(err) {
+			fmt.Fprintf(tty, "Too many invalid attempts\n")
+			return "", err
+		}
+		writeTTYError(tty, err)
+	}
+}
+
+// editTTYPath reads keys until Enter, echoing the line as it is edited.
+func editTTYPath(tty io.ReadWriter, label string, opts *FileOptions) (string, error) {
+	var line []rune
+	key := make([]byte, 1)
+	var pending []byte // bytes of a character not complete yet
+
+	for {
+		if _, err := tty.Read(key); err != nil {
+			fmt.Fprint(tty, "\n")
+			if err == io.EOF {
+				return "", fmt.Errorf("terminal closed before a valid response was entered")
+			}
+			return "", fmt.Errorf("failed to read from terminal: %w", err)
+		}
+
+		switch b := key[0]; {
+		case len(pending) > 0 || b >= utf8.RuneSelf:
+			pending = append(pending, b)
+			if utf8.FullRune(pending) {
+				r, _ := utf8.DecodeRune(pending)
+				pending = nil
+				line = append(line, r)
+				fmt.Fprint(tty, string(r))
+			}
+		case b == keyReturn || b == keyNewline:
+			fmt.Fprint(tty, "\n")
+			return string(line), nil
+		case b == keyCtrlC:
+			fmt.Fprint(tty, "\n")
+			return "", fmt.Errorf("input cancelled")
+		case b == keyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprint(tty, "\n")
+				return "", &DeclinedError{}
+			}
+		case b == keyBackspace || b == keyDelete:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				fmt.Fprint(tty, "\b \b")
+			}
+		case b == keyCtrlU:
+			fmt.Fprint(tty, strings.Repeat("\b \b", len(line)))
+			line = line[:0]
+		case b == keyCtrlW:
+			// Delete back to the previous path separator
+			n := len(line)
+			for n > 0 && line[n-1] == filepath.Separator {
+				n--
+			}
+			for n > 0 && line[n-1] != filepath.Separator {
+				n--
+			}
+			fmt.Fprint(tty, strings.Repeat("\b \b", len(line)-n))
+			line = line[:n]
+		case b == keyTab:
+			completed, matches := opts.Complete(string(line))
+			if extra := strings.TrimPrefix(completed, string(line)); extra != "" {
+				line = []rune(completed)
+				fmt.Fprint(tty, extra)
+			} else if len(matches) > 0 {
+				fmt.Fprintf(tty, "\n%s\n%s%s", strings.Join(matches, "  "), label, string(line))
+			} else {
+				fmt.Fprint(tty, "\a")
+			}
+		case b == keyEscape:
+			// Arrow and function keys send sequences the editor ignores
+			skipEscapeSequence(tty)
+		case b >= ' ':
+			line = append(line, rune(b))
+			tty.Write(key)
+		}
+	}
+}
+
+// skipEscapeSequence consumes the rest of a CSI or SS3 key sequence, such as
+// an arrow key.
+func skipEscapeSequence(tty io.Reader) {
+	key := make([]byte, 1)
+	if _, err := tty.Read(key); err != nil || (key[0] != '[' && key[0] != 'O') {
+		return
+	}
+	for {
+		if _, err := tty.Read(key); err != nil || (key[0] >= 0x40 && key[0] <= 0x7e) {
+			return
+		}
+	}
+}
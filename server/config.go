@@ -0,0 +1,447 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"prompt-mcp/i18n"
+)
+
+const (
+	defaultWarnFraction = 0.8
+	defaultWebTimeout   = 5 * time.Minute
+	defaultInlineLimit  = 64 * 1024
+	defaultChunkSize    = 16 * 1024
+	defaultMaxAttempts  = 3
+
+	defaultMaxUploadBytes = 5 * 1024 * 1024
+	defaultMaxAnswerBytes = 1024 * 1024
+)
+
+// validToolPrefix keeps prefixed names within the characters MCP allows in
+// tool names.
+var validToolPrefix = regexp.MustCompile(`^[A-Za-z0-9_.-]*$`)
+
+// Answer delivery modes.
+const (
+	DeliveryInline  = "inline"
+	DeliveryChunked = "chunked"
+)
+
+// Answer encodings.
+const (
+	EncodingText   = "text"
+	EncodingBase64 = "base64"
+)
+
+// Config holds operator-controlled server settings. The zero value is usable
+// and selects the defaults for every field.
+type Config struct {
+	// WarnFraction is the fraction of a prompt's timeout after which the client
+	// is warned that the prompt is about to expire. Zero selects the default,
+	// a negative value disables the warning.
+	WarnFraction float64 `json:"warn_fraction"`
+
+	// InlineLimit is the largest answer, in bytes, returned inline in a tool
+	// result. Larger answers are returned as a resource link. Zero selects the
+	// default, a negative value always inlines.
+	InlineLimit int `json:"inline_limit"`
+
+	// Delivery is the default answer delivery mode, DeliveryInline or
+	// DeliveryChunked. Tool calls may override it with the delivery argument.
+	Delivery string `json:"delivery"`
+
+	// ChunkSize is the largest chunk, in bytes, of a chunked answer.
+	ChunkSize int `json:"chunk_size"`
+
+	// MaxAttempts is how many invalid answers a user may give to a prompt
+	// with validation before the tool call fails. Zero selects the default,
+	// a negative value asks until the answer is valid.
+	MaxAttempts int `json:"max_attempts"`
+
+	// MaxUploadBytes is the largest file a user may send to a prompt with
+	// accept_file. Zero selects the default.
+	MaxUploadBytes int64 `json:"max_upload_bytes"`
+
+	// MaxAnswerBytes is the largest text answer the web form accepts, such
+	// as a pasted log. Zero selects the default.
+	MaxAnswerBytes int64 `json:"max_answer_bytes"`
+
+	// ObserverSocket is the path of a unix socket streaming prompt lifecycle
+	// events to observers. Empty disables it.
+	ObserverSocket string `json:"observer_socket"`
+
+	// ObserverIncludeAnswers adds the user's answers to resolved events.
+	ObserverIncludeAnswers bool `json:"observer_include_answers"`
+
+	// WebPersistent serves web prompts from one dashboard that lives as
+	// long as the server, instead of a server and tab per prompt.
+	WebPersistent bool `json:"web_persistent"`
+
+	// WebPort is the dashboard's port; setting it turns the dashboard on.
+	// Zero picks a free port.
+	WebPort int `json:"web_port"`
+
+	// WebHost is the interface web prompt pages are served on. Empty means
+	// 127.0.0.1; anything else may let other machines reach the pages.
+	WebHost string `json:"web_host"`
+
+	// WebPortRange, such as "8400-8500", limits the ports web prompt pages
+	// are served on to the first free one in it. Empty picks any free port.
+	WebPortRange string `json:"web_port_range"`
+
+	// WebExternalURL replaces scheme, host and port in the URLs of web
+	// prompt pages, for pages reached through NAT or a proxy.
+	WebExternalURL string `json:"web_external_url"`
+
+	// WebBasePath, such as "/prompt-mcp", serves every web page under that
+	// path, for a reverse proxy routing by path. Empty serves from the root.
+	WebBasePath string `json:"web_base_path"`
+
+	// WebAuth, as "user:password", makes every web page ask for those
+	// credentials. The password may be a bcrypt hash.
+	WebAuth string `json:"web_auth"`
+
+	// WebTunnel serves web pages at a public URL through an outbound
+	// tunnel: "cloudflared", "ngrok", "localhost.run" or a command with
+	// {host}, {port} or {url}. WebTunnelURLPattern, a regular expression,
+	// overrides how its public URL is found in the command's output.
+	WebTunnel           string `json:"web_tunnel"`
+	WebTunnelURLPattern string `json:"web_tunnel_url_pattern"`
+
+	// WebPush lets the dashboard's visitors subscribe to a push
+	// notification of each new prompt; it turns the dashboard on.
+	// WebPushKeyFile is where the VAPID key is kept, made on first use;
+	// empty means vapid.pem in the user's config directory.
+	WebPush        bool   `json:"web_push"`
+	WebPushKeyFile string `json:"web_push_key_file"`
+
+	// WebQR prints a QR code of each web prompt's page on the controlling
+	// terminal, for answering from a phone.
+	WebQR bool `json:"web_qr"`
+
+	// NoBrowser never opens a browser for web prompts but prints their URL
+	// on the controlling terminal. Without a display it is the default.
+	NoBrowser bool `json:"no_browser"`
+
+	// HistorySize is how many past prompts the dashboard's history page
+	// lists. Zero selects the default, a negative value keeps none.
+	HistorySize int `json:"history_size"`
+
+	// CacheTTL is how many seconds an answer the user asked to remember
+	// answers the same question again. Zero selects the default, an hour;
+	// a negative value never remembers answers.
+	CacheTTL int `json:"cache_ttl"`
+
+	// Theme brands the web prompt pages with a title, logo, accent colour
+	// and footer. The zero value keeps the built-in look.
+	Theme WebTheme `json:"theme"`
+
+	// WebTemplateDir holds templates and static files replacing the built-in
+	// ones of web pages, laid out like server/assets. Files it lacks keep
+	// the built-in version. It is read at startup only.
+	WebTemplateDir string `json:"web_template_dir"`
+
+	// Methods lists the input methods agents may use, "tty" and "web", in
+	// order of preference. A disallowed method falls back to the first one.
+	// Nil allows every method; an empty list allows none.
+	Methods []string `json:"methods"`
+
+	// ToolPrefix is put before every tool name in tools/list, and calls must
+	// use the prefixed names. It keeps the tools apart from those of other
+	// servers. The tools config still uses the plain names.
+	ToolPrefix string `json:"tool_prefix"`
+
+	// Tools selects which registered tools are listed and callable.
+	Tools ToolsConfig `json:"tools"`
+
+	// Locale is the language of the labels and buttons around prompts that
+	// don't set one, such as "de". Empty means English.
+	Locale string `json:"locale"`
+
+	// PinLocale keeps web pages in Locale. Otherwise pages of prompts that
+	// don't set a language follow the browser's Accept-Language header,
+	// falling back to Locale for languages without a catalog.
+	PinLocale bool `json:"pin_locale"`
+
+	// Path is the file the config was loaded from, if any. A server with a
+	// config file may reload it, so it advertises tool list changes.
+	Path string `json:"-"`
+}
+
+// ToolsConfig enables a subset of the registered tools. An empty Enable list
+// means every tool; Disable is applied afterwards.
+type ToolsConfig struct {
+	Enable  []string `json:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		WarnFraction:   defaultWarnFraction,
+		InlineLimit:    defaultInlineLimit,
+		Delivery:       DeliveryInline,
+		ChunkSize:      defaultChunkSize,
+		MaxAttempts:    defaultMaxAttempts,
+		MaxUploadBytes: defaultMaxUploadBytes,
+		MaxAnswerBytes: defaultMaxAnswerBytes,
+	}
+}
+
+// LoadConfig reads a JSON config file. Keys missing from the file keep their
+// default values.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	cfg.Path = path
+
+	return cfg, nil
+}
+
+// Validate reports settings the server cannot run with.
+func (c Config) Validate() error {
+	if c.WarnFraction >= 1 {
+		return fmt.Errorf("warn fraction must be below 1 (got %v); use a negative value to disable warnings", c.WarnFraction)
+	}
+	if c.Delivery != "" && c.Delivery != DeliveryInline && c.Delivery != DeliveryChunked {
+		return fmt.Errorf("delivery must be %q or %q (got %q)", DeliveryInline, DeliveryChunked, c.Delivery)
+	}
+	if c.ChunkSize < 0 {
+		return fmt.Errorf("chunk size must be positive (got %d)", c.ChunkSize)
+	}
+	if c.MaxUploadBytes < 0 {
+		return fmt.Errorf("max upload size must be positive (got %d)", c.MaxUploadBytes)
+	}
+	if c.MaxAnswerBytes < 0 {
+		return fmt.Errorf("max answer size must be positive (got %d)", c.MaxAnswerBytes)
+	}
+	if c.WebPort < 0 || c.WebPort > 65535 {
+		return fmt.Errorf("web port must be between 1 and 65535, or 0 for a free port (got %d)", c.WebPort)
+	}
+
+	if _, err := c.WebListener(); err != nil {
+		return err
+	}
+
+	for _, method := range c.Methods {
+		if method != MethodTTY && method != MethodWeb {
+			return fmt.Errorf("allowed methods must be %q or %q (got %q)", MethodTTY, MethodWeb, method)
+		}
+	}
+
+	if !validToolPrefix.MatchString(c.ToolPrefix) {
+		return fmt.Errorf("tool prefix may only contain letters, digits, '_', '-' and '.' (got %q)", c.ToolPrefix)
+	}
+
+	if err := c.Theme.Validate(); err != nil {
+		return err
+	}
+
+	if c.Locale != "" && !i18n.Supported(c.Locale) {
+		return fmt.Errorf("locale must be one of %s (got %q)", strings.Join(i18n.Languages(), ", "), c.Locale)
+	}
+
+	for _, name := range append(append([]string{}, c.Tools.Enable...), c.Tools.Disable...) {
+		if _, ok := lookupTool(name); !ok {
+			return fmt.Errorf("unknown tool %q (available: %s)", name, toolNameList())
+		}
+	}
+	if len(c.enabledTools()) == 0 {
+		return fmt.Errorf("every tool is disabled; enable at least one of: %s", toolNameList())
+	}
+
+	return nil
+}
+
+func (s *MCPServer) SetConfig(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = cfg
+}
+
+// ReloadConfig swaps in a new config while the server is running, notifying
+// the client if the set of enabled tools changed.
+func (s *MCPServer) ReloadConfig(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	before := s.config.ToolPrefix + ":" + toolNames(s.config.enabledTools())
+	methodsBefore := fmt.Sprint(s.config.Methods == nil, s.config.Methods)
+	s.config = cfg
+	after := cfg.ToolPrefix + ":" + toolNames(cfg.enabledTools())
+	methodsAfter := fmt.Sprint(cfg.Methods == nil, cfg.Methods)
+	history := s.history
+	s.mu.Unlock()
+
+	if history != nil {
+		history.resize(cfg.historySize())
+	}
+
+	// Tool schemas list the allowed methods
+	if before != after || methodsBefore != methodsAfter {
+		s.sendNotification("notifications/tools/list_changed", nil)
+	}
+	return nil
+}
+
+// Dashboard reports whether web prompts are served from a persistent
+// dashboard.
+func (c Config) Dashboard() bool {
+	return c.WebPersistent || c.WebPort != 0 || c.WebPush
+}
+
+// PushKeyFile is where the dashboard's VAPID key is kept.
+func (c Config) PushKeyFile() (string, error) {
+	if c.WebPushKeyFile != "" {
+		return c.WebPushKeyFile, nil
+	}
+	return DefaultPushKeyFile()
+}
+
+// WebListener describes where web prompt pages are served.
+func (c Config) WebListener() (WebListener, error) {
+	l := WebListener{Host: c.WebHost, ExternalURL: c.WebExternalURL, QR: c.WebQR, NoBrowser: c.NoBrowser}
+	if l.Host == "" {
+		l.Host = defaultWebHost
+	}
+	if c.WebPortRange != "" {
+		var err error
+		if l.MinPort, l.MaxPort, err = ParsePortRange(c.WebPortRange); err != nil {
+			return l, err
+		}
+	}
+	if c.WebExternalURL != "" {
+		if err := validExternalURL(c.WebExternalURL); err != nil {
+			return l, err
+		}
+	}
+	var err error
+	if l.BasePath, err = ParseBasePath(c.WebBasePath); err != nil {
+		return l, err
+	}
+	if c.WebAuth != "" {
+		if l.Auth, err = ParseBasicAuth(c.WebAuth); err != nil {
+			return l, err
+		}
+	}
+	if c.WebTunnel != "" {
+		if c.WebExternalURL != "" {
+			return l, fmt.Errorf("web tunnel and web external URL can't both be set: the tunnel picks the URL")
+		}
+		if l.Tunnel, err = ParseTunnel(c.WebTunnel, c.WebTunnelURLPattern); err != nil {
+			return l, err
+		}
+	}
+	return l, nil
+}
+
+func (s *MCPServer) currentConfig() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+func (c Config) warnFraction() float64 {
+	if c.WarnFraction == 0 {
+		return defaultWarnFraction
+	}
+	return c.WarnFraction
+}
+
+func (c Config) inlineLimit() int {
+	if c.InlineLimit == 0 {
+		return defaultInlineLimit
+	}
+	return c.InlineLimit
+}
+
+func (c Config) delivery() string {
+	if c.Delivery == "" {
+		return DeliveryInline
+	}
+	return c.Delivery
+}
+
+func (c Config) chunkSize() int {
+	if c.ChunkSize <= 0 {
+		return defaultChunkSize
+	}
+	return c.ChunkSize
+}
+
+func (c Config) maxAttempts() int {
+	if c.MaxAttempts == 0 {
+		return defaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+func (c Config) historySize() int {
+	if c.HistorySize == 0 {
+		return defaultHistorySize
+	}
+	return max(c.HistorySize, 0)
+}
+
+func (c Config) cacheTTL() time.Duration {
+	if c.CacheTTL == 0 {
+		return defaultCacheTTL
+	}
+	return time.Duration(max(c.CacheTTL, 0)) * time.Second
+}
+
+func (c Config) maxUploadBytes() int64 {
+	if c.MaxUploadBytes <= 0 {
+		return defaultMaxUploadBytes
+	}
+	return c.MaxUploadBytes
+}
+
+func (c Config) maxAnswerBytes() int64 {
+	if c.MaxAnswerBytes <= 0 {
+		return defaultMaxAnswerBytes
+	}
+	return c.MaxAnswerBytes
+}
+
+// applyPromptDefaults gives prompt the limits and language of c where it
+// doesn't set its own.
+func (c Config) applyPromptDefaults(prompt *PromptRequest) {
+	if prompt.MaxAttempts == 0 {
+		prompt.MaxAttempts = c.maxAttempts()
+	}
+	if prompt.MaxAnswerBytes == 0 {
+		prompt.MaxAnswerBytes = c.maxAnswerBytes()
+	}
+	if prompt.Locale == "" {
+		// Only the server's default gives way to the browser's language
+		prompt.Locale = c.Locale
+		prompt.NegotiateLocale = !c.PinLocale
+	}
+}
+
+// PreparePrompt applies c to a prompt asked outside a server, as by the
+// ask command: the method it may use, reported as substituted when the one
+// asked for isn't allowed, and its limits and language.
+func (c Config) PreparePrompt(prompt *PromptRequest) (substituted bool, err error) {
+	used, substituted, err := c.resolveMethod(prompt.Method)
+	if err != nil {
+		return false, err
+	}
+	prompt.Method = used
+	c.applyPromptDefaults(prompt)
+	return substituted, nil
+}
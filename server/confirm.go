@@ -0,0 +1,232 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Normalized answers to a confirm prompt.
+const (
+	AnswerYes = "yes"
+	AnswerNo  = "no"
+)
+
+// NewConfirmPrompt returns a yes/no prompt. def is the answer given by an
+// empty response: "yes", "no", or "" for no default.
+func NewConfirmPrompt(prompt, method, def string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindConfirm
+	req.Default = def
+	req.Trim = TrimNone
+	req.Validate = confirmValidator(def)
+	return req
+}
+
+func confirmValidator(def string) func(string) (string, error) {
+	return func(response string) (string, error) {
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "y", "yes":
+			return AnswerYes, nil
+		case "n", "no":
+			return AnswerNo, nil
+		case "":
+			if def != "" {
+				return def, nil
+			}
+		}
+		return "", fmt.Errorf("Please answer yes or no")
+	}
+}
+
+// on_timeout values of user_confirm: what a prompt left unanswered decides.
+const (
+	OnTimeoutError   = "error"
+	OnTimeoutDeny    = "deny"
+	OnTimeoutApprove = "approve"
+)
+
+// parseOnTimeout reads the on_timeout argument into the answer a timeout
+// stands for, or "" when it stays an error.
+func parseOnTimeout(args map[string]interface{}) (string, error) {
+	onTimeout, _, err := optionalString(args, "on_timeout")
+	if err != nil {
+		return "", err
+	}
+	switch onTimeout {
+	case "", OnTimeoutError:
+		return "", nil
+	case OnTimeoutDeny:
+		return AnswerNo, nil
+	case OnTimeoutApprove:
+		return AnswerYes, nil
+	}
+	return "", fmt.Errorf("Invalid on_timeout parameter: must be 'deny', 'approve' or 'error'")
+}
+
+// timedOutText describes an answer given by on_timeout.
+func timedOutText(answer string, timeout time.Duration) string {
+	decision := "denied"
+	if answer == AnswerYes {
+		decision = "approved"
+	}
+	return fmt.Sprintf("%s (%s automatically: no answer within %s)", answer, decision, formatSeconds(timeout))
+}
+
+// confirmHint is the [Y/n] marker shown after a confirm prompt on the terminal.
+func confirmHint(def string) string {
+	switch def {
+	case AnswerYes:
+		return "[Y/n]"
+	case AnswerNo:
+		return "[y/N]"
+	default:
+		return "[y/n]"
+	}
+}
+
+func (s *MCPServer) handleUserConfirmTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	def, _, err := optionalString(args, "default")
+	if err == nil && def != "" && def != AnswerYes && def != AnswerNo {
+		err = fmt.Errorf("Invalid default parameter: must be 'yes' or 'no'")
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	phrase, err := parseConfirmationPhrase(args)
+	if err == nil && phrase != nil && def != "" {
+		err = fmt.Errorf("Invalid default parameter: can't be combined with confirmation_phrase")
+	}
+	var maxAttempts int
+	if err == nil {
+		maxAttempts, err = optionalMaxAttempts(args)
+	}
+	var attachments []Attachment
+	if err == nil {
+		attachments, err = parseAttachments(args)
+	}
+	var followUp *FollowUp
+	if err == nil {
+		followUp, err = parseFollowUp(args)
+	}
+	if err == nil && followUp != nil && phrase != nil {
+		err = fmt.Errorf("Invalid follow_up parameter: can't be combined with confirmation_phrase")
+	}
+	var conv *Conversation
+	if err == nil {
+		conv, err = parseContext(args)
+	}
+	var singleKey bool
+	if err == nil {
+		singleKey, err = optionalBool(args, "single_key", false)
+	}
+	var timeout *time.Duration
+	if err == nil {
+		timeout, err = optionalTimeout(args)
+	}
+	var onTimeout string
+	if err == nil {
+		onTimeout, err = parseOnTimeout(args)
+	}
+	var cacheBypass bool
+	if err == nil {
+		cacheBypass, err = optionalBool(args, "cache_bypass", false)
+	}
+	var requireConfirmation bool
+	if err == nil {
+		requireConfirmation, err = optionalBool(args, "require_confirmation", false)
+	}
+	switch {
+	case err != nil:
+	case onTimeout != "" && followUp != nil:
+		err = fmt.Errorf("Invalid on_timeout parameter: can't be combined with follow_up")
+	case onTimeout == AnswerYes && phrase != nil:
+		// Typing the phrase is the point; a timeout must not stand in for it
+		err = fmt.Errorf("Invalid on_timeout parameter: 'approve' can't be combined with confirmation_phrase")
+	}
+	if err == nil && singleKey && (phrase != nil || followUp != nil || len(attachments) > 0 || conv != nil) {
+		err = fmt.Errorf("Invalid single_key parameter: not supported with confirmation_phrase, follow_up, attachments or context")
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	if followUp != nil {
+		promptReq := NewFollowUpConfirmPrompt(prompt, promptMethod(args), def, *followUp)
+		promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+		promptReq.MaxAttempts = maxAttempts
+		promptReq.Attachments = attachments
+		promptReq.Context = conv
+		if timeout != nil {
+			promptReq.Timeout = *timeout
+		}
+		s.handleFollowUpPrompt(req, promptReq, progressToken)
+		return
+	}
+
+	promptReq := NewConfirmPrompt(prompt, promptMethod(args), def)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+	promptReq.Attachments = attachments
+	promptReq.Context = conv
+	promptReq.SingleKey = singleKey
+	promptReq.CacheBypass = cacheBypass
+	promptReq.RequireConfirmation = requireConfirmation
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+	if onTimeout != "" {
+		if promptReq.Timeout <= 0 {
+			s.sendError(req.ID, -32602, "Invalid on_timeout parameter: the prompt has no timeout")
+			return
+		}
+		promptReq.TimeoutResponse = &onTimeout
+	}
+
+	if phrase != nil {
+		if maxAttempts == 0 {
+			promptReq.MaxAttempts = s.currentConfig().maxAttempts()
+		}
+		promptReq.RequirePhrase(*phrase)
+		s.handlePhrasePrompt(req, promptReq, progressToken)
+		return
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	timedOut := false
+	if errors.Is(err, ErrTimeout) && promptReq.TimeoutResponse != nil {
+		answer, err, timedOut = *promptReq.TimeoutResponse, nil, true
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	structured := map[string]interface{}{
+		"answer":    answer,
+		"confirmed": answer == AnswerYes,
+	}
+	text := answer
+	if timedOut {
+		structured["timedOut"] = true
+		text = timedOutText(answer, promptReq.Timeout)
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
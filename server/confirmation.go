@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"prompt-mcp/i18n"
+)
+
+// reviewMask stands in for a secret under review. Its length is fixed, so
+// the secret's length isn't shown either.
+const reviewMask = "••••••••"
+
+// The buttons of the web review page post the staged answer's nonce in one
+// of these fields.
+const (
+	reviewConfirmField = "confirm"
+	reviewEditField    = "edit"
+)
+
+// reviewText is the answer response is returned as, the way the user
+// reviews it before sending it: secrets and passwords are masked.
+func reviewText(req *PromptRequest, response string) string {
+	if req.Secret {
+		return reviewMask
+	}
+	if req.Kind == KindCredentials {
+		var creds Credentials
+		if err := json.Unmarshal([]byte(response), &creds); err == nil {
+			creds.Password = reviewMask
+			data, _ := json.Marshal(creds)
+			return string(data)
+		}
+	}
+	return returnedAnswer(req, response)
+}
+
+// confirmOnTTY shows the answer line is returned as and asks whether to
+// send it, reporting whether the user said yes. editing says the terminal
+// is in raw mode, where y or n is one keypress.
+func confirmOnTTY(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest, line string, editing bool) (bool, error) {
+	if req.Remember != nil {
+		line, _ = cutRemember(line)
+	}
+	fmt.Fprintf(tty, "%s\n%s\n", i18n.T(req.Locale, i18n.AboutToRespond), indent(reviewText(req, line), "  "))
+
+	label := i18n.T(req.Locale, i18n.SendThis) + " "
+	var answer string
+	var err error
+	if editing {
+		answer, err = readTTYSingleKey(tty, label, &PromptRequest{Kind: KindConfirm, Validate: confirmValidator("")})
+	} else {
+		answer, err = readTTYLine(tty, scanner, label, confirmValidator(""))
+	}
+	return answer == AnswerYes, err
+}
+
+// stagedAnswer is a valid answer to a prompt requiring confirmation, held
+// until the user confirms it. nonce names it on the review page's buttons;
+// shown is what the form shows again when the user edits it.
+type stagedAnswer struct {
+	nonce    string
+	response string
+	shown    string
+	remember bool
+}
+
+// ReviewPageData is passed to the review.html template, which shows the
+// answer to a prompt requiring confirmation as it will be returned, with
+// buttons posting Nonce in the confirm or edit field. The heading takes
+// the focus, so a screen reader reads the answer before the buttons.
+type ReviewPageData struct {
+	Lang   string
+	Answer string
+	Nonce  string
+
+	// Base prefixes the page's own links, for a prompt on the dashboard
+	Base string
+
+	// CSRF is the form token submissions must carry
+	CSRF string
+
+	// Theme is the operator's branding, nil for the built-in look
+	Theme *PageTheme
+}
+
+// stage holds response for review and shows it with Confirm and Edit
+// buttons. A new submission replaces the answer staged before it.
+func (h *WebInputHandler) stage(w http.ResponseWriter, r *http.Request, response, shown string) {
+	staged := &stagedAnswer{
+		nonce:    rand.Text(),
+		response: response,
+		shown:    shown,
+		remember: r.FormValue("remember") != "",
+	}
+	h.mu.Lock()
+	h.staged = staged
+	h.mu.Unlock()
+
+	locale := h.locale(r)
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, http.StatusOK, "review.html", translate(locale), ReviewPageData{
+		Lang:   i18n.Normalize(locale),
+		Answer: reviewText(h.req, response),
+		Nonce:  staged.nonce,
+		Base:   h.base,
+		CSRF:   h.csrf,
+		Theme:  currentWebTheme().page(h.base),
+	})
+}
+
+// takeStagedLocked removes and returns the staged answer nonce names, or
+// nil when another one has replaced it. h.mu must be held.
+func (h *WebInputHandler) takeStagedLocked(nonce string) *stagedAnswer {
+	staged := h.staged
+	if staged == nil || subtle.ConstantTimeCompare([]byte(staged.nonce), []byte(nonce)) != 1 {
+		return nil
+	}
+	h.staged = nil
+	return staged
+}
+
+// confirmStaged answers the prompt with the staged answer the user
+// confirmed.
+func (h *WebInputHandler) confirmStaged(w http.ResponseWriter, r *http.Request, nonce string) {
+	h.mu.Lock()
+	if h.state != webPending {
+		h.mu.Unlock()
+		h.accepting(w, r)
+		return
+	}
+	staged := h.takeStagedLocked(nonce)
+	if staged == nil {
+		h.mu.Unlock()
+		// The review page was used after a newer submission replaced it
+		h.renderForm(w, r, http.StatusConflict, i18n.T(h.locale(r), i18n.ReviewStale), "")
+		return
+	}
+	h.inflight.Add(1)
+	h.mu.Unlock()
+	defer h.inflight.Done()
+
+	if h.finish(staged.response, nil) {
+		if staged.remember {
+			h.req.Remember.Set()
+		}
+		h.renderThanks(w, r)
+	} else {
+		h.refuse(w, r)
+	}
+}
+
+// editStaged drops the staged answer and shows the form again, filled in
+// with it.
+func (h *WebInputHandler) editStaged(w http.ResponseWriter, r *http.Request, nonce string) {
+	h.mu.Lock()
+	staged := h.takeStagedLocked(nonce)
+	h.mu.Unlock()
+	if staged == nil {
+		h.renderForm(w, r, http.StatusConflict, i18n.T(h.locale(r), i18n.ReviewStale), "")
+		return
+	}
+	h.renderForm(w, r, http.StatusOK, "", staged.shown)
+}
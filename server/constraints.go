@@ -0,0 +1,77 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// re2Only matches the syntax of Go regular expressions that JavaScript
+// reads differently or not at all: flag groups, \A and \z, one-letter
+// Unicode classes, POSIX classes and \Q…\E quoting.
+var re2Only = regexp.MustCompile(`\(\?[a-zA-Z-]+[:)]|\\[AzCQE]|\\[pP][^{]|\[\[:`)
+
+// webConstraints are a text answer's checks as the form's attributes make
+// them, so the browser flags a bad answer before sending it. The server
+// checks every answer again; these only save the round trip.
+type webConstraints struct {
+	// Pattern is the pattern attribute, empty when the browser can't check
+	// the pattern the way the server does
+	Pattern string
+	Message string
+
+	// Allowed is the datalist of allowed_values
+	Allowed []string
+}
+
+// htmlPattern is pattern as the pattern attribute takes it, or "" when a
+// browser would read it differently from the server. Browsers anchor the
+// attribute and compile it as JavaScript, which names groups without the P;
+// a pattern they fail to compile is ignored.
+func htmlPattern(pattern string) string {
+	pattern = strings.ReplaceAll(pattern, "(?P<", "(?<")
+	if re2Only.MatchString(pattern) {
+		return ""
+	}
+	return pattern
+}
+
+// constraints returns the checks the form mirrors, or nil when the prompt
+// has none.
+func (r *PromptRequest) constraints() *webConstraints {
+	if r.Pattern == "" && len(r.AllowedValues) == 0 {
+		return nil
+	}
+	c := &webConstraints{Allowed: r.AllowedValues}
+	// The server matches the answer after trimming and dedenting it, which
+	// the browser doesn't
+	if r.Pattern != "" && r.Trim == TrimNone && !r.Dedent {
+		c.Pattern = htmlPattern(r.Pattern)
+		c.Message = fmt.Sprintf("Must match the pattern %s", r.Pattern)
+	}
+	return c
+}
+
+// failedConstraint describes the first of the prompt's checks that response
+// fails, or "" when it passes them all.
+func (r *PromptRequest) failedConstraint(response string) string {
+	answer := normalizeAnswer(response, r.Trim, r.Dedent)
+	if r.Length != nil && r.Length.check(answer) != nil {
+		return r.Length.describe()
+	}
+	if r.Pattern != "" {
+		if re, err := regexp.Compile(`^(?:` + r.Pattern + `)$`); err == nil && !re.MatchString(answer) {
+			return "the pattern " + r.Pattern
+		}
+	}
+	if len(r.AllowedValues) > 0 {
+		trimmed := strings.TrimSpace(answer)
+		for _, value := range r.AllowedValues {
+			if strings.EqualFold(strings.TrimSpace(value), trimmed) {
+				return ""
+			}
+		}
+		return "one of: " + strings.Join(r.AllowedValues, ", ")
+	}
+	return ""
+}
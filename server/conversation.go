@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Conversation context limits. Older messages are dropped first to keep
+// the text within maxContextBytes; roles are short labels.
+const (
+	maxContextBytes = 64 * 1024
+	maxContextRole  = 64
+)
+
+// ContextMessage is one entry of the conversation leading up to a prompt.
+type ContextMessage struct {
+	Role string
+	Text string
+}
+
+// Conversation is the recent exchange an agent shows with a prompt, so the
+// user knows what is being asked about. It is only displayed, never part of
+// the answer.
+type Conversation struct {
+	Messages []ContextMessage
+
+	// Omitted counts the older messages dropped to fit maxContextBytes
+	Omitted int
+}
+
+// omittedText is the marker shown in place of dropped messages.
+func (c *Conversation) omittedText() string {
+	if c.Omitted == 1 {
+		return "… 1 earlier message omitted"
+	}
+	return fmt.Sprintf("… %d earlier messages omitted", c.Omitted)
+}
+
+// parseContext reads the context argument: an array of {role, text}
+// objects, oldest first. It returns nil when no context was given.
+func parseContext(args map[string]interface{}) (*Conversation, error) {
+	value, exists := args["context"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid context parameter: must be an array of {role, text} objects")
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	messages := make([]ContextMessage, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid context parameter: entry %d is not an object", i)
+		}
+		role, _, err := optionalString(obj, "role")
+		if err == nil && (strings.TrimSpace(role) == "" || strings.ContainsAny(role, "\r\n")) {
+			err = fmt.Errorf("role must be a non-empty single line")
+		}
+		if err == nil && len(role) > maxContextRole {
+			err = fmt.Errorf("role must be at most %d bytes", maxContextRole)
+		}
+		text, present, textErr := optionalString(obj, "text")
+		switch {
+		case err != nil:
+		case textErr != nil:
+			err = textErr
+		case !present:
+			err = fmt.Errorf("text is required")
+		case !utf8.ValidString(text):
+			err = fmt.Errorf("text must be valid UTF-8")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid context entry %d: %v", i, err)
+		}
+		messages[i] = ContextMessage{Role: strings.TrimSpace(role), Text: text}
+	}
+	return truncateContext(messages, maxContextBytes), nil
+}
+
+// truncateContext keeps the newest messages that fit in limit bytes. When
+// even the newest doesn't fit, the start of its text is cut.
+func truncateContext(messages []ContextMessage, limit int) *Conversation {
+	total := 0
+	first := len(messages)
+	for first > 0 {
+		size := len(messages[first-1].Role) + len(messages[first-1].Text)
+		if total+size > limit {
+			break
+		}
+		total += size
+		first--
+	}
+
+	conv := &Conversation{Messages: messages[first:], Omitted: first}
+	if first == len(messages) {
+		// Keep the end of the newest message, on a rune boundary
+		last := messages[len(messages)-1]
+		text := last.Text[len(last.Text)-(limit-len(last.Role)):]
+		for len(text) > 0 && !utf8.RuneStart(text[0]) {
+			text = text[1:]
+		}
+		conv.Messages = []ContextMessage{{Role: last.Role, Text: "…" + text}}
+		conv.Omitted = len(messages) - 1
+	}
+	return conv
+}
+
+// runTTYContext offers to page through the conversation context before the
+// question is asked.
+func runTTYContext(tty io.ReadWriter, scanner *bufio.Scanner, conv *Conversation) error {
+	count := len(conv.Messages) + conv.Omitted
+	noun := "messages"
+	if count == 1 {
+		noun = "message"
+	}
+	line, err := readTTYLine(tty, scanner, fmt.Sprintf("(Context: %d %s) Press c to show it, or Enter to answer: ", count, noun), func(line string) (string, error) {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line != "" && line != "c" {
+			return "", fmt.Errorf("Please press c or Enter")
+		}
+		return line, nil
+	})
+	if err != nil || line == "" {
+		return err
+	}
+
+	var b strings.Builder
+	if conv.Omitted > 0 {
+		fmt.Fprintf(&b, "%s\n\n", conv.omittedText())
+	}
+	for _, m := range conv.Messages {
+		fmt.Fprintf(&b, "[%s]\n%s\n\n", m.Role, indent(strings.TrimRight(m.Text, "\n"), "  "))
+	}
+	fmt.Fprintf(tty, "--- Context ---\n")
+	return pageTTYContent(tty, scanner, b.String())
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Credentials are a username and password given together. Providers answer
+// credential prompts with them encoded as JSON.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// NewCredentialsPrompt returns a prompt asking for a username and password
+// for service, which may be empty. The answer is sensitive: it never
+// appears in logs or observer events.
+func NewCredentialsPrompt(prompt, method, service string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindCredentials
+	req.Service = service
+	req.Sensitive = true
+	req.Trim = TrimNone
+	req.Validate = validateCredentials
+	return req
+}
+
+// validateCredentials requires both parts. The username is trimmed; the
+// password is kept exactly as typed.
+func validateCredentials(response string) (string, error) {
+	var creds Credentials
+	if err := json.Unmarshal([]byte(response), &creds); err != nil {
+		return "", fmt.Errorf("Invalid credentials response: %v", err)
+	}
+	creds.Username = strings.TrimSpace(creds.Username)
+	switch {
+	case creds.Username == "":
+		return "", fmt.Errorf("Please enter a username")
+	case creds.Password == "":
+		return "", fmt.Errorf("Please enter a password")
+	}
+
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runTTYCredentials reads the username with echo on, then the password with
+// echo off.
+func runTTYCredentials(tty io.Writer, scanner *bufio.Scanner, req *PromptRequest, echo *echoSwitch) (string, error) {
+	if req.Service != "" {
+		fmt.Fprintf(tty, "Service: %s\n", req.Service)
+	}
+	username, err := readTTYLine(tty, scanner, "Username: ", func(line string) (string, error) {
+		if strings.TrimSpace(line) == "" {
+			return "", fmt.Errorf("Please enter a username")
+		}
+		return line, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := echo.off(); err != nil {
+		return "", err
+	}
+	password, err := readTTYLine(tty, scanner, "Password: ", func(line string) (string, error) {
+		if line == "" {
+			// The Enter keypress isn't echoed
+			fmt.Fprintf(tty, "\n")
+			return "", fmt.Errorf("Please enter a password")
+		}
+		return line, nil
+	})
+	echo.on()
+	fmt.Fprintf(tty, "\n")
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(Credentials{Username: username, Password: password})
+	if err != nil {
+		return "", err
+	}
+	return req.Validate(string(data))
+}
+
+func (s *MCPServer) handleUserCredentialsTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	service, _, err := optionalString(args, "service")
+	if err == nil && strings.ContainsAny(service, "\r\n") {
+		err = fmt.Errorf("Invalid service parameter: must be a single line")
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please enter your credentials"
+		if service != "" {
+			prompt = fmt.Sprintf("Please sign in to %s", service)
+		}
+	}
+	timeout, err := optionalTimeout(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewCredentialsPrompt(prompt, promptMethod(args), service)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	// Passwords only travel over a page no one else can reach or read
+	if err := s.usePromptMethod(req, promptReq); err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+	if method := ResolveMethod(promptReq.Method); (method == MethodWeb || method == MethodBoth) && !currentWebListener().Private() {
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent("Refusing to ask for credentials in the browser: the web listener is reachable beyond localhost and not served over TLS. Use method tty instead"),
+			},
+			"isError": true,
+		})
+		return
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal([]byte(answer), &creds); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode credentials: %v", err))
+		return
+	}
+
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer),
+		},
+		"structuredContent": map[string]interface{}{
+			"username": creds.Username,
+			"password": creds.Password,
+		},
+		"isError": false,
+	}
+	markSensitive(result)
+	s.sendResponse(req.ID, result)
+}
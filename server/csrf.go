@@ -0,0 +1,64 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// csrfField is the hidden form field carrying a page's CSRF token.
+const csrfField = "csrf"
+
+// sameOrigin reports whether a submission comes from the page's own site:
+// its Origin, or failing that its Referer, names the host it was sent to,
+// the configured external URL or a running tunnel. Requests with neither,
+// such as those of scripts, are let through; the form token still has to
+// match.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+		if origin == "" {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		// Includes "null", sent by sandboxed frames and data: URLs
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) || tunnelHost(u.Host) {
+		return true
+	}
+	if external := currentWebListener().ExternalURL; external != "" {
+		if e, err := url.Parse(external); err == nil && strings.EqualFold(u.Host, e.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// validCSRF compares a submitted form token with the page's in constant
+// time.
+func (h *WebInputHandler) validCSRF(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.csrf)) == 1
+}
+
+// checkCSRF turns away submissions from other sites and those without the
+// page's form token. An upload's token is left to the upload reader, which
+// meets it on its way to the file.
+func (h *WebInputHandler) checkCSRF(w http.ResponseWriter, r *http.Request) bool {
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin submission refused", http.StatusForbidden)
+		return false
+	}
+	if h.req.Kind == KindUpload {
+		return true
+	}
+	if !h.validCSRF(r.PostFormValue(csrfField)) {
+		http.Error(w, "Invalid or missing form token; reload the page and try again", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,624 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prompt-mcp/i18n"
+	"prompt-mcp/units"
+)
+
+// dashboardPromptPreview is how much of a prompt the dashboard lists.
+const dashboardPromptPreview = 200
+
+// dashboardResolved is how many resolved prompts the index keeps listing.
+const dashboardResolved = 20
+
+// Dashboard serves every web prompt from one HTTP server that lives as long
+// as the MCP server. Its index lists the pending prompts, and each prompt's
+// page is served under /p/<n>/<token>/. Open index pages are told about new
+// and resolved prompts over server-sent events from its DashboardHub, so
+// they stay current without a refresh. It is an InputProvider for the web
+// method.
+//
+// The index is served under a token of its own, made once per dashboard, as
+// it links to every prompt's page. So is the history page, which lists the
+// prompts of the session with their answers, and the form forgetting the
+// answers the user asked to remember, the endpoint browsers subscribe to
+// push notifications at and the web app manifest, which names the index.
+// Only the health probes and the app shell (service worker, offline page
+// and static files), which tell nothing about the prompts, are served
+// without a token.
+type Dashboard struct {
+	port    int
+	base    string
+	auth    *BasicAuth
+	locale  string
+	pinned  bool
+	token   string
+	mux     *http.ServeMux
+	history *History
+	cache   *AnswerCache
+	health  http.Handler
+	push    *Pusher
+
+	mu       sync.Mutex
+	seq      int
+	pending  []*dashboardPrompt
+	resolved []DashboardEntry
+	// finished are the prompts of resolved, whose pages still say how they
+	// ended
+	finished []*dashboardPrompt
+	server   *http.Server
+	tunnel   *Tunnel
+	url      string
+
+	// hub streams the prompts arriving and going to the index pages;
+	// watchers counts the pages listening
+	hub      *DashboardHub
+	watchers int
+}
+
+type dashboardPrompt struct {
+	id      int
+	req     *PromptRequest
+	handler *WebInputHandler
+	page    string
+	asked   time.Time
+}
+
+// preview is as much of the prompt as the dashboard lists.
+func (p *dashboardPrompt) preview() string {
+	prompt := []rune(p.req.Prompt)
+	if len(prompt) > dashboardPromptPreview {
+		prompt = append(prompt[:dashboardPromptPreview], '…')
+	}
+	return string(prompt)
+}
+
+// entry is the prompt as the index lists it.
+func (p *dashboardPrompt) entry() DashboardEntry {
+	e := DashboardEntry{
+		Path:    p.page,
+		Title:   p.req.Title,
+		Prompt:  p.preview(),
+		Urgency: p.req.Urgency,
+		Asked:   p.asked.Format("15:04:05"),
+	}
+	if !p.handler.deadline.IsZero() {
+		e.Deadline = p.handler.deadline.UnixMilli()
+	}
+	return e
+}
+
+// path is the prompt's page below the dashboard's base path, without
+// the page's token.
+func (p *dashboardPrompt) path(base string) string {
+	return fmt.Sprintf("%s/p/%d", base, p.id)
+}
+
+// NewDashboard returns a dashboard for port, 0 picking a free one, with its
+// index in locale. Every page is served under the configured listener's
+// base path, and paths outside it are not found; with credentials on the
+// listener, only to those who give them. It serves nothing until Start is
+// called.
+func NewDashboard(port int, locale string) (*Dashboard, error) {
+	token, err := newWebToken()
+	if err != nil {
+		return nil, err
+	}
+	l := currentWebListener()
+	d := &Dashboard{port: port, base: l.BasePath, auth: l.Auth, locale: locale, token: token, hub: NewDashboardHub()}
+	d.mux = http.NewServeMux()
+	d.mux.HandleFunc(d.Path(), d.handleIndex)
+	d.mux.HandleFunc(d.Path()+"events", d.handleEvents)
+	d.mux.HandleFunc(d.Path()+"history", d.handleHistory)
+	d.mux.HandleFunc(d.Path()+"forget", d.handleForget)
+	d.mux.HandleFunc(d.Path()+"push", d.handlePush)
+	d.mux.HandleFunc(d.Path()+"manifest.webmanifest", d.handleManifest)
+	d.mux.HandleFunc(d.base+"/"+dashboardWorker, d.handleWorker)
+	d.mux.HandleFunc(d.base+"/offline", d.handleOffline)
+	d.mux.Handle(d.base+"/static/", http.StripPrefix(d.base, staticHandler()))
+	d.mux.HandleFunc(d.base+"/p/", d.handlePrompt)
+	d.mux.HandleFunc(d.base+"/healthz", d.handleHealth)
+	d.mux.HandleFunc(d.base+"/readyz", d.handleHealth)
+	return d, nil
+}
+
+// Path returns the path of the index, base path and token included.
+func (d *Dashboard) Path() string {
+	return d.base + "/" + d.token + "/"
+}
+
+// PinLocale keeps the index and history pages in the dashboard's locale
+// rather than the browser's language. Call it before Start.
+func (d *Dashboard) PinLocale() {
+	d.pinned = true
+}
+
+// SetHistory lists history's prompts on the history page. Without one the
+// page is not found.
+func (d *Dashboard) SetHistory(history *History) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.history = history
+}
+
+// SetAnswerCache offers to forget cache's answers on the index. Without one
+// there is nothing to forget.
+func (d *Dashboard) SetAnswerCache(cache *AnswerCache) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.cache = cache
+}
+
+// SetHealth answers /healthz and /readyz with health, such as
+// NewHealthHandler's, without a token. Without one they are not found.
+func (d *Dashboard) SetHealth(health http.Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.health = health
+}
+
+// SetPush offers the index's visitors notifications of new prompts through
+// push, even with the tab in the background. Without one there are none.
+func (d *Dashboard) SetPush(push *Pusher) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.push = push
+}
+
+// Start listens on the dashboard's port and serves it in the background,
+// through the listener's tunnel if it has one. It returns the URL of the
+// index, token included.
+func (d *Dashboard) Start() (string, error) {
+	l := currentWebListener()
+	listener, err := l.listen(d.port)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for the dashboard: %w", err)
+	}
+
+	server := &http.Server{Handler: d}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "Dashboard server error: %v\n", err)
+		}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	tunnel := l.startTunnel(port)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.server = server
+	d.url = l.url(port)
+	if tunnel != nil {
+		d.tunnel = tunnel
+		d.url = tunnel.URL
+	}
+	return d.url + d.Path(), nil
+}
+
+// Close stops the server and its tunnel. Prompts still pending wait for
+// their timeout.
+func (d *Dashboard) Close() error {
+	d.mu.Lock()
+	server, tunnel := d.server, d.tunnel
+	d.mu.Unlock()
+	if tunnel != nil {
+		tunnel.Close()
+	}
+	if server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
+
+// ServeHTTP asks for the listener's credentials, if it has any, on every
+// path but the health probes.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if probe := r.URL.Path == d.base+"/healthz" || r.URL.Path == d.base+"/readyz"; !probe && !d.auth.Allows(r) {
+		challenge(w)
+		return
+	}
+	d.mux.ServeHTTP(w, r)
+}
+
+// GetInput lists req on the dashboard until it is answered or ctx is done.
+// The browser is only opened when no index page is watching; otherwise the
+// prompt just appears there.
+func (d *Dashboard) GetInput(ctx context.Context, req *PromptRequest) (string, error) {
+	handler := NewWebInputHandler(req)
+	handler.back = d.Path()
+
+	d.mu.Lock()
+	d.seq++
+	p := &dashboardPrompt{id: d.seq, req: req, handler: handler, asked: handler.asked}
+	page, err := handler.Protect(p.path(d.base))
+	if err != nil {
+		d.mu.Unlock()
+		return "", err
+	}
+	p.page = page
+	d.pending = append(d.pending, p)
+	d.hub.Publish(DashboardEvent{Type: EventPromptCreated, Entry: p.entry(), Pending: len(d.pending)})
+	url, watched, push := d.url, d.watchers > 0, d.push
+	d.mu.Unlock()
+
+	if push != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			push.Send(ctx, d.pushMessage(p))
+		}()
+	}
+
+	if url != "" {
+		alert := req.alert()
+		if watched {
+			// The watching index links to the page already
+			alert.Focus = false
+			page = d.Path()
+		}
+		showInBrowser(url+page, "input", alert)
+		showQR(url + p.page)
+	}
+
+	response, err := handler.Wait(ctx)
+
+	resolved := p.entry()
+	resolved.Deadline = 0
+	resolved.Outcome = dashboardOutcome(err)
+	resolved.Took = units.FormatDuration(math.Round(time.Since(p.asked).Seconds()))
+
+	d.mu.Lock()
+	for i, pending := range d.pending {
+		if pending == p {
+			d.pending = append(d.pending[:i], d.pending[i+1:]...)
+			break
+		}
+	}
+	d.resolved = append([]DashboardEntry{resolved}, d.resolved...)
+	d.finished = append([]*dashboardPrompt{p}, d.finished...)
+	if len(d.resolved) > dashboardResolved {
+		d.resolved = d.resolved[:dashboardResolved]
+		d.finished = d.finished[:dashboardResolved]
+	}
+	d.hub.Publish(DashboardEvent{Type: EventPromptResolved, Entry: resolved, Pending: len(d.pending)})
+	d.mu.Unlock()
+	return response, err
+}
+
+func (d *Dashboard) WarnTimeout(req *PromptRequest, remaining time.Duration) {
+	webProvider{}.WarnTimeout(req, remaining)
+}
+
+// Notify shows notifications on a page of their own, as without a
+// dashboard.
+func (d *Dashboard) Notify(ctx context.Context, req *PromptRequest) error {
+	return webProvider{}.Notify(ctx, req)
+}
+
+// Hub returns the hub streaming the dashboard's events to its index pages.
+func (d *Dashboard) Hub() *DashboardHub {
+	return d.hub
+}
+
+// DashboardEntry is a prompt as listed on the index. Path is the prompt's
+// page, Asked the time it was asked, such as "14:05:09", and Deadline when
+// it times out, in Unix milliseconds, zero without a timeout. Resolved
+// prompts have an Outcome, as in the history, and Took how long they were
+// open, such as "12s".
+type DashboardEntry struct {
+	Path     string `json:"path"`
+	Title    string `json:"title,omitempty"`
+	Prompt   string `json:"prompt"`
+	Urgency  string `json:"urgency,omitempty"`
+	Asked    string `json:"asked"`
+	Deadline int64  `json:"deadline,omitempty"`
+	Outcome  string `json:"outcome,omitempty"`
+	Took     string `json:"took,omitempty"`
+}
+
+// DashboardPageData is passed to the dashboard.html template. Like PageData
+// it is the interface custom templates rely on. Events is the URL of the
+// stream announcing changes to the list, LastEvent the ID of the last
+// change Entries and Resolved show, to pass as its since parameter.
+// History is the URL of the history page, empty when there is none.
+// Remembered is how many answers the user asked
+// to remember, and Forget the URL to post to for forgetting them. With push
+// notifications on, Push is the URL to post a subscription to and PushKey
+// the VAPID key to subscribe with; both are empty otherwise. Manifest,
+// Worker and Icon are the web app manifest, service worker and icon that
+// make the dashboard installable, ThemeColor the colour of its title bar.
+type DashboardPageData struct {
+	Lang       string
+	Events     string
+	LastEvent  int64
+	History    string
+	Remembered int
+	Forget     string
+	Push       string
+	PushKey    string
+	Manifest   string
+	Worker     string
+	Icon       string
+	ThemeColor string
+	Entries    []DashboardEntry
+
+	// Resolved lists the latest prompts answered or given up on, newest
+	// first
+	Resolved []DashboardEntry
+}
+
+// HistoryPageEntry is a past prompt as listed on the history page. Asked is
+// the date and time it was asked, Took how long it was open, such as "12s",
+// and Client the MCP client that asked.
+// Outcome is OutcomeAnswered, OutcomeTimeout, OutcomeError,
+// OutcomeDeclined or OutcomeCached; Response is "[redacted]" for sensitive prompts and empty
+// unless answered.
+type HistoryPageEntry struct {
+	Title    string
+	Prompt   string
+	Method   string
+	Client   string
+	Outcome  string
+	Response string
+	Asked    string
+	Took     string
+}
+
+// HistoryPageData is passed to the history.html template. Query is the
+// filter the entries were picked with, Back the path of the index.
+type HistoryPageData struct {
+	Lang    string
+	Query   string
+	Back    string
+	Entries []HistoryPageEntry
+}
+
+func (d *Dashboard) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != d.Path() {
+		http.NotFound(w, r)
+		return
+	}
+
+	locale := pageLocale(r, d.locale, !d.pinned)
+	data := DashboardPageData{
+		Lang:       i18n.Normalize(locale),
+		Events:     d.Path() + "events",
+		Manifest:   d.Path() + "manifest.webmanifest",
+		Worker:     d.base + "/" + dashboardWorker,
+		Icon:       d.base + "/static/" + appIcons[0].name,
+		ThemeColor: appThemeColor(),
+	}
+	d.mu.Lock()
+	// Events are published under d.mu, so the stream picks up right after
+	// the list rendered
+	data.LastEvent = d.hub.LastID()
+	if d.history != nil {
+		data.History = d.Path() + "history"
+	}
+	if data.Remembered = d.cache.Len(); data.Remembered > 0 {
+		data.Forget = d.Path() + "forget"
+	}
+	if d.push != nil {
+		data.Push = d.Path() + "push"
+		data.PushKey = d.push.PublicKey()
+	}
+	for _, p := range d.pending {
+		data.Entries = append(data.Entries, p.entry())
+	}
+	data.Resolved = d.resolved
+	d.mu.Unlock()
+
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, http.StatusOK, "dashboard.html", translate(locale), data)
+}
+
+// handleHistory lists the prompts kept in the history, newest first, or
+// those matching the q parameter.
+func (d *Dashboard) handleHistory(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	history := d.history
+	d.mu.Unlock()
+	if history == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	locale := pageLocale(r, d.locale, !d.pinned)
+	data := HistoryPageData{Lang: i18n.Normalize(locale), Query: query, Back: d.Path()}
+	for _, e := range history.Entries() {
+		if query != "" && !e.matches(query) {
+			continue
+		}
+		data.Entries = append(data.Entries, HistoryPageEntry{
+			Title:    e.Title,
+			Prompt:   e.Prompt,
+			Method:   e.Method,
+			Client:   e.Client,
+			Outcome:  e.Outcome,
+			Response: e.Response,
+			Asked:    e.Asked.Format("2006-01-02 15:04:05"),
+			Took:     units.FormatDuration(math.Round(e.Took.Seconds())),
+		})
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, http.StatusOK, "history.html", translate(locale), data)
+}
+
+// handleForget forgets the remembered answers, so every question is asked
+// again, and goes back to the index.
+func (d *Dashboard) handleForget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d.mu.Lock()
+	cache := d.cache
+	d.mu.Unlock()
+	cache.Clear()
+	http.Redirect(w, r, d.Path(), http.StatusSeeOther)
+}
+
+// handlePush subscribes the browser posting its push subscription as
+// JSON, or unsubscribes the one whose endpoint is deleted. Renewing a
+// subscription just replaces it.
+func (d *Dashboard) handlePush(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	push := d.push
+	d.mu.Unlock()
+	if push == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+		return
+	}
+
+	var sub PushSubscription
+	if err := json.NewDecoder(io.LimitReader(r.Body, 8192)).Decode(&sub); err != nil {
+		http.Error(w, "Invalid subscription", http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		push.Unsubscribe(sub.Endpoint)
+	} else if err := push.Subscribe(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pushMessage is the notification of p's arrival, opening its page.
+func (d *Dashboard) pushMessage(p *dashboardPrompt) PushMessage {
+	title := i18n.T(d.locale, i18n.PushTitle)
+	if p.req.Title != "" {
+		title += ": " + p.req.Title
+	}
+	return PushMessage{Title: title, Body: p.preview(), URL: p.page, Tag: p.page}
+}
+
+// handleHealth hands the health probes, with the base path stripped, to the
+// health handler.
+func (d *Dashboard) handleHealth(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	health := d.health
+	d.mu.Unlock()
+	if health == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.StripPrefix(d.base, health).ServeHTTP(w, r)
+}
+
+// handleEvents streams the hub's events to an index page until it is
+// closed or too slow to keep up. Each event carries its ID, so the browser
+// reconnects with the last one it saw in Last-Event-ID and gets the events
+// it missed; the page's first connection starts from the since parameter.
+// When the missed events are gone, a "reset" event tells the page to load
+// the list afresh.
+func (d *Dashboard) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+
+	lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		lastID, err = strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	}
+	if err != nil {
+		lastID = d.hub.LastID()
+	}
+
+	d.mu.Lock()
+	d.watchers++
+	sub, complete := d.hub.Subscribe(lastID)
+	d.mu.Unlock()
+	defer func() {
+		d.hub.Unsubscribe(sub)
+		d.mu.Lock()
+		d.watchers--
+		d.mu.Unlock()
+	}()
+
+	fmt.Fprint(w, "retry: 1000\n: watching\n\n")
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the browser reconnects and
+				// catches up
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handlePrompt hands <base>/p/<n>/... to prompt n's handler, with the prefix
+// stripped; the handler checks the token. Prompts the index still lists as
+// resolved keep their handler, so a reload or a late submission is told how
+// the prompt ended; older ones, like wrong tokens, are not found, so the
+// paths don't tell the two apart.
+func (d *Dashboard) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	idText, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, d.base+"/p/"), "/")
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var p *dashboardPrompt
+	d.mu.Lock()
+	for _, prompts := range [][]*dashboardPrompt{d.pending, d.finished} {
+		for _, candidate := range prompts {
+			if candidate.id == id {
+				p = candidate
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.StripPrefix(p.path(d.base), p.handler).ServeHTTP(w, r)
+}
@@ -0,0 +1,408 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Date and time modes.
+const (
+	ModeDate     = "date"
+	ModeTime     = "time"
+	ModeDateTime = "datetime"
+)
+
+// Local layouts accepted for a date with a time, besides RFC 3339.
+var dateTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// clockLayouts are the accepted times of day, matched with spaces removed
+// and in lower case.
+var clockLayouts = []string{"15:04:05", "15:04", "3pm", "3:04pm"}
+
+// DateTimeOptions describes a date or time prompt. Answers are read in
+// Location, and Min and Max, when set, bound them inclusively.
+type DateTimeOptions struct {
+	Mode     string
+	Location *time.Location
+	Min      *time.Time
+	Max      *time.Time
+}
+
+// DateTimeAnswer is the answer to a date or time prompt: Value in RFC 3339
+// form (a full-date, partial-time or date-time, by mode) and the text the
+// user entered.
+type DateTimeAnswer struct {
+	Value string `json:"value"`
+	Raw   string `json:"raw"`
+}
+
+// NewDateTimePrompt returns a prompt asking for a date, a time or both.
+// Providers pass the user's text; the validated answer is a DateTimeAnswer
+// encoded as JSON.
+func NewDateTimePrompt(prompt, method string, opts DateTimeOptions) *PromptRequest {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindDateTime
+	req.DateTime = &opts
+	req.Trim = TrimNone
+	req.Validate = opts.validate
+	return req
+}
+
+func (o *DateTimeOptions) validate(response string) (string, error) {
+	t, err := o.Parse(response, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if !o.inRange(t) {
+		return "", fmt.Errorf("Please enter %s", o.window())
+	}
+
+	data, err := json.Marshal(DateTimeAnswer{Value: o.format(t), Raw: strings.TrimSpace(response)})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Parse reads a date or time as the user typed it, relative to now for
+// words like "tomorrow" and for times without a date.
+func (o *DateTimeOptions) Parse(raw string, now time.Time) (time.Time, error) {
+	text := strings.TrimSpace(raw)
+	now = now.In(o.Location)
+
+	t, hasDate, hasClock, ok := o.parse(text, now)
+	switch {
+	case !ok:
+		return time.Time{}, fmt.Errorf("Could not read %q: use %s", text, o.examples())
+	case o.Mode == ModeDate && !hasDate:
+		return time.Time{}, fmt.Errorf("Please enter a date: use %s", o.examples())
+	case o.Mode != ModeDate && !hasClock:
+		return time.Time{}, fmt.Errorf("Please include a time: use %s", o.examples())
+	}
+	return t, nil
+}
+
+// parse tries the accepted forms in turn, reporting which parts the text
+// gave.
+func (o *DateTimeOptions) parse(text string, now time.Time) (t time.Time, hasDate, hasClock, ok bool) {
+	if strings.EqualFold(text, "now") {
+		return now, true, true, true
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t.In(o.Location), true, true, true
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, o.Location); err == nil {
+			return t, true, true, true
+		}
+	}
+
+	// A day, optionally followed by a time: "2026-10-17", "tomorrow 3pm"
+	words := strings.SplitN(strings.ToLower(text), " ", 2)
+	if day, found := o.day(words[0], now); found {
+		if len(words) == 1 {
+			return day, true, false, true
+		}
+		if clock, found := o.clock(words[1], day); found {
+			return clock, true, true, true
+		}
+		return time.Time{}, false, false, false
+	}
+
+	// A time on its own is today's
+	if clock, found := o.clock(text, now); found {
+		return clock, false, true, true
+	}
+	return time.Time{}, false, false, false
+}
+
+// day reads a date or a relative day, as midnight in the location.
+func (o *DateTimeOptions) day(word string, now time.Time) (time.Time, bool) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, o.Location)
+	switch word {
+	case "today":
+		return midnight, true
+	case "tomorrow":
+		return midnight.AddDate(0, 0, 1), true
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), true
+	}
+	t, err := time.ParseInLocation("2006-01-02", word, o.Location)
+	return t, err == nil
+}
+
+// clock reads a time of day and sets it on day's date.
+func (o *DateTimeOptions) clock(text string, day time.Time) (time.Time, bool) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), ""))
+	for _, layout := range clockLayouts {
+		if c, err := time.Parse(layout, text); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), c.Second(), 0, o.Location), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// examples lists the forms the mode accepts.
+func (o *DateTimeOptions) examples() string {
+	switch o.Mode {
+	case ModeDate:
+		return "YYYY-MM-DD, today or tomorrow"
+	case ModeTime:
+		return "HH:MM, HH:MM:SS or 3pm"
+	default:
+		return "YYYY-MM-DD HH:MM, RFC 3339 or e.g. tomorrow 3pm"
+	}
+}
+
+// key maps t onto the part the mode compares: the date, the time of day or
+// the instant.
+func (o *DateTimeOptions) key(t time.Time) time.Time {
+	t = t.In(o.Location)
+	switch o.Mode {
+	case ModeDate:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case ModeTime:
+		return time.Date(2000, 1, 1, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	default:
+		return t
+	}
+}
+
+func (o *DateTimeOptions) inRange(t time.Time) bool {
+	if o.Min != nil && o.key(t).Before(o.key(*o.Min)) {
+		return false
+	}
+	if o.Max != nil && o.key(t).After(o.key(*o.Max)) {
+		return false
+	}
+	return true
+}
+
+// window describes the accepted range, e.g. "a date between 2026-10-01 and
+// 2026-10-31".
+func (o *DateTimeOptions) window() string {
+	what := map[string]string{ModeDate: "a date", ModeTime: "a time", ModeDateTime: "a date and time"}[o.Mode]
+	switch {
+	case o.Min != nil && o.Max != nil:
+		return fmt.Sprintf("%s between %s and %s", what, o.boundText(*o.Min), o.boundText(*o.Max))
+	case o.Min != nil:
+		return fmt.Sprintf("%s no earlier than %s", what, o.boundText(*o.Min))
+	case o.Max != nil:
+		return fmt.Sprintf("%s no later than %s", what, o.boundText(*o.Max))
+	}
+	return what
+}
+
+// format returns t in the RFC 3339 form for the mode.
+func (o *DateTimeOptions) format(t time.Time) string {
+	t = t.In(o.Location)
+	switch o.Mode {
+	case ModeDate:
+		return t.Format("2006-01-02")
+	case ModeTime:
+		return t.Format("15:04:05")
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+func (o *DateTimeOptions) boundText(t time.Time) string {
+	t = t.In(o.Location)
+	switch o.Mode {
+	case ModeDate:
+		return t.Format("2006-01-02")
+	case ModeTime:
+		return t.Format("15:04")
+	default:
+		return t.Format("2006-01-02 15:04 MST")
+	}
+}
+
+// describe spells t out for the user to check.
+func (o *DateTimeOptions) describe(t time.Time) string {
+	t = t.In(o.Location)
+	switch o.Mode {
+	case ModeDate:
+		return t.Format("Monday, 2 January 2006")
+	case ModeTime:
+		return t.Format("15:04:05")
+	default:
+		return t.Format("Monday, 2 January 2006 at 15:04 MST")
+	}
+}
+
+// zone names the location for the user; the local zone goes by its
+// abbreviation rather than "Local".
+func (o *DateTimeOptions) zone() string {
+	if o.Location == time.Local {
+		return time.Now().Format("MST")
+	}
+	return o.Location.String()
+}
+
+// inputValue returns t as the browser's native input for the mode expects.
+func (o *DateTimeOptions) inputValue(t time.Time) string {
+	t = t.In(o.Location)
+	switch o.Mode {
+	case ModeDate:
+		return t.Format("2006-01-02")
+	case ModeTime:
+		return t.Format("15:04")
+	default:
+		return t.Format("2006-01-02T15:04")
+	}
+}
+
+// runTTYDateTime reads a date or time and, unless it was typed in canonical
+// form, shows how it was read and asks the user to confirm it.
+func runTTYDateTime(tty io.Writer, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	opts := req.DateTime
+	fmt.Fprintf(tty, "(Enter %s", opts.examples())
+	if opts.Min != nil || opts.Max != nil {
+		fmt.Fprintf(tty, "; %s", opts.window())
+	}
+	fmt.Fprintf(tty, "; times in %s)\n", opts.zone())
+
+	label := map[string]string{ModeDate: "Date: ", ModeTime: "Time: ", ModeDateTime: "Date and time: "}[opts.Mode]
+	return readTTYLine(tty, scanner, label, func(response string) (string, error) {
+		valid, err := req.Validate(response)
+		if err != nil {
+			return "", err
+		}
+		var answer DateTimeAnswer
+		if err := json.Unmarshal([]byte(valid), &answer); err != nil {
+			return "", err
+		}
+		if answer.Raw == answer.Value {
+			return valid, nil
+		}
+
+		t, err := opts.Parse(answer.Value, time.Now())
+		if err != nil {
+			return "", err
+		}
+		ok, err := readTTYLine(tty, scanner, fmt.Sprintf("Read as %s. Correct? [Y/n] ", opts.describe(t)), confirmValidator(AnswerYes))
+		if err != nil {
+			return "", err
+		}
+		if ok != AnswerYes {
+			return "", fmt.Errorf("Please enter it again")
+		}
+		return valid, nil
+	})
+}
+
+// parseDateTimeOptions reads the mode, timezone and bounds of
+// user_datetime. Bounds are read like answers, so "today" works.
+func parseDateTimeOptions(args map[string]interface{}) (DateTimeOptions, error) {
+	opts := DateTimeOptions{Mode: ModeDateTime, Location: time.Local}
+
+	mode, present, err := optionalString(args, "mode")
+	if err != nil {
+		return opts, err
+	}
+	if present {
+		switch mode {
+		case ModeDate, ModeTime, ModeDateTime:
+			opts.Mode = mode
+		default:
+			return opts, fmt.Errorf("Invalid mode parameter: must be one of date, time, datetime")
+		}
+	}
+
+	zone, _, err := optionalString(args, "timezone")
+	if err != nil {
+		return opts, err
+	}
+	if zone != "" {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return opts, fmt.Errorf("Invalid timezone parameter: %v", err)
+		}
+		opts.Location = loc
+	}
+
+	now := time.Now()
+	for _, bound := range []struct {
+		name string
+		dest **time.Time
+	}{{"min", &opts.Min}, {"max", &opts.Max}} {
+		text, present, err := optionalString(args, bound.name)
+		if err != nil {
+			return opts, err
+		}
+		if !present {
+			continue
+		}
+		t, err := opts.Parse(text, now)
+		if err != nil {
+			return opts, fmt.Errorf("Invalid %s parameter: %v", bound.name, err)
+		}
+		*bound.dest = &t
+	}
+	if opts.Min != nil && opts.Max != nil && opts.key(*opts.Min).After(opts.key(*opts.Max)) {
+		return opts, fmt.Errorf("Invalid bounds: min %s is after max %s", opts.boundText(*opts.Min), opts.boundText(*opts.Max))
+	}
+	return opts, nil
+}
+
+func (s *MCPServer) handleUserDateTimeTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	opts, err := parseDateTimeOptions(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewDateTimePrompt(prompt, promptMethod(args), opts)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var value DateTimeAnswer
+	if err := json.Unmarshal([]byte(answer), &value); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode date: %v", err))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(value.Value),
+		},
+		"structuredContent": map[string]interface{}{
+			"value":    value.Value,
+			"raw":      value.Raw,
+			"mode":     opts.Mode,
+			"timezone": opts.zone(),
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"prompt-mcp/i18n"
+)
+
+// The fields of the page declining a prompt: declineField, set, declines
+// it, with declineReasonField as the reason.
+const (
+	declineField       = "decline"
+	declineReasonField = "reason"
+)
+
+// DeclinedError is the error providers return when the user declines to
+// answer: the Decline button on the web, Esc or Ctrl+D on the terminal. It
+// isn't a failure; the tool result says the prompt was declined, with the
+// reason the user gave, if any.
+type DeclinedError struct {
+	Reason string
+}
+
+func (e *DeclinedError) Error() string {
+	if e.Reason == "" {
+		return "Declined by the user"
+	}
+	return "Declined by the user: " + e.Reason
+}
+
+// declinedResult is the tool result for a declined prompt: not an error,
+// with structuredContent.declined set and the user's reason, if any.
+func declinedResult(err *DeclinedError) map[string]interface{} {
+	structured := map[string]interface{}{
+		"declined": true,
+	}
+	if err.Reason != "" {
+		structured["reason"] = err.Reason
+	}
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(err.Error()),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	}
+}
+
+// cutDecline reports whether a line read from the terminal declines the
+// prompt: one starting with Esc. What follows the Esc is the reason.
+func cutDecline(line string) (string, bool) {
+	reason, ok := strings.CutPrefix(line, string(rune(keyEscape)))
+	return strings.TrimSpace(reason), ok
+}
+
+// failedMessage is the message key saying why a prompt ended with err.
+func failedMessage(err error) string {
+	if _, ok := err.(*DeclinedError); ok {
+		return i18n.PageDeclined
+	}
+	return i18n.PageFailed
+}
+
+// handleDecline asks the user why they decline to answer, then ends the
+// prompt with their refusal and the reason they typed, if any.
+func (h *WebInputHandler) handleDecline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.accepting(w, r) {
+		return
+	}
+	// The decline button posts a plain form even from an upload prompt, so
+	// the token is checked here for every kind
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin submission refused", http.StatusForbidden)
+		return
+	}
+	if !h.validCSRF(r.PostFormValue(csrfField)) {
+		http.Error(w, "Invalid or missing form token; reload the page and try again", http.StatusForbidden)
+		return
+	}
+
+	// The button on the form asks for a reason first; declining with or
+	// without one ends the prompt
+	if r.PostFormValue(declineField) == "" {
+		h.renderDecline(w, r)
+		return
+	}
+
+	h.mu.Lock()
+	if h.state != webPending {
+		h.mu.Unlock()
+		h.accepting(w, r)
+		return
+	}
+	h.inflight.Add(1)
+	h.mu.Unlock()
+	defer h.inflight.Done()
+
+	reason := strings.TrimSpace(strings.ReplaceAll(r.PostFormValue(declineReasonField), "\r\n", "\n"))
+	if h.finish("", &DeclinedError{Reason: reason}) {
+		h.renderCompleted(w, r, http.StatusOK)
+	} else {
+		h.refuse(w, r)
+	}
+}
+
+// DeclinePageData is passed to the decline.html template, which asks why
+// the user declines the prompt. Label names the page and its button: the
+// prompt's decline label, or the default in the page's language. The form
+// posts the decline and reason fields.
+type DeclinePageData struct {
+	Lang  string
+	Label string
+
+	// Base prefixes the page's own links, for a prompt on the dashboard
+	Base string
+
+	// CSRF is the form token submissions must carry
+	CSRF string
+
+	// Theme is the operator's branding, nil for the built-in look
+	Theme *PageTheme
+}
+
+// renderDecline shows the page declining the prompt, with an optional
+// reason and a way back to the question.
+func (h *WebInputHandler) renderDecline(w http.ResponseWriter, r *http.Request) {
+	locale := h.locale(r)
+	decline := i18n.T(locale, i18n.Decline)
+	if h.req.DeclineLabel != "" {
+		decline = h.req.DeclineLabel
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, http.StatusOK, "decline.html", translate(locale), DeclinePageData{
+		Lang:  i18n.Normalize(locale),
+		Label: decline,
+		Base:  h.base,
+		CSRF:  h.csrf,
+		Theme: currentWebTheme().page(h.base),
+	})
+}
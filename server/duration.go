@@ -0,0 +1,167 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"prompt-mcp/units"
+)
+
+// TypeDuration is the user_input answer type for lengths of time.
+const TypeDuration = "duration"
+
+// durationUnit is a unit a duration answer can be typed or returned in.
+type durationUnit struct {
+	Symbol  string
+	Name    string
+	Seconds float64
+}
+
+// durationUnits lists the units of the web dropdown, default_unit and
+// result_unit, smallest first.
+var durationUnits = []durationUnit{
+	{"ms", "milliseconds", 1e-3},
+	{"s", "seconds", 1},
+	{"m", "minutes", 60},
+	{"h", "hours", 3600},
+	{"d", "days", 86400},
+}
+
+func findDurationUnit(symbol string) (durationUnit, bool) {
+	for _, unit := range durationUnits {
+		if unit.Symbol == symbol {
+			return unit, true
+		}
+	}
+	return durationUnit{}, false
+}
+
+func durationUnitSymbols() []string {
+	symbols := make([]string, len(durationUnits))
+	for i, unit := range durationUnits {
+		symbols[i] = unit.Symbol
+	}
+	return symbols
+}
+
+// DurationOptions describes a duration answer. Bounds are kept in
+// NumberOptions, in seconds.
+type DurationOptions struct {
+	// DefaultUnit is the unit of a bare number, e.g. "m" to read "90" as
+	// 90 minutes
+	DefaultUnit string
+
+	// ResultUnit is the unit structuredContent.value is given in
+	ResultUnit string
+}
+
+// parse reads s as a Go-style duration ("1h30m", "2 hours") or a bare
+// number of DefaultUnit, and returns it in seconds. Durations can't be
+// negative.
+func (d *DurationOptions) parse(s string) (float64, error) {
+	var n float64
+	if numberFormat.MatchString(s) {
+		bare, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsInf(bare, 0) {
+			return 0, fmt.Errorf("%q is out of range", s)
+		}
+		unit, _ := findDurationUnit(d.DefaultUnit)
+		n = bare * unit.Seconds
+	} else {
+		var err error
+		if n, err = units.Parse(units.Duration, s); err != nil {
+			return 0, err
+		}
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%q is negative; durations can't be", s)
+	}
+	return n, nil
+}
+
+// result converts seconds to ResultUnit.
+func (d *DurationOptions) result(seconds float64) (float64, string) {
+	unit, _ := findDurationUnit(d.ResultUnit)
+	return seconds / unit.Seconds, unit.Name
+}
+
+// window describes the accepted range, e.g. "between 30s and 24h".
+func (o *NumberOptions) window() string {
+	switch {
+	case o.Minimum != nil && o.Maximum != nil:
+		return fmt.Sprintf("between %s and %s", o.format(*o.Minimum), o.format(*o.Maximum))
+	case o.Minimum != nil:
+		return "of at least " + o.format(*o.Minimum)
+	}
+	return "of at most " + o.format(*o.Maximum)
+}
+
+// parseDurationOptions reads the arguments of a duration answer. minimum
+// and maximum may be durations ("30s") or numbers, read like a bare answer.
+func parseDurationOptions(args map[string]interface{}) (NumberOptions, error) {
+	d := &DurationOptions{DefaultUnit: "s", ResultUnit: "s"}
+	opts := NumberOptions{Units: units.Duration, Duration: d}
+
+	for _, arg := range []struct {
+		name  string
+		value *string
+	}{{"default_unit", &d.DefaultUnit}, {"result_unit", &d.ResultUnit}} {
+		symbol, present, err := optionalString(args, arg.name)
+		if err != nil {
+			return opts, err
+		}
+		if !present {
+			continue
+		}
+		if _, known := findDurationUnit(symbol); !known {
+			return opts, fmt.Errorf("Invalid %s parameter: must be one of %s", arg.name, strings.Join(durationUnitSymbols(), ", "))
+		}
+		*arg.value = symbol
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value **float64
+	}{{"minimum", &opts.Minimum}, {"maximum", &opts.Maximum}} {
+		var text string
+		switch v := args[bound.name].(type) {
+		case nil:
+			continue
+		case float64:
+			text = formatNumber(v)
+		case string:
+			text = strings.TrimSpace(v)
+		default:
+			return opts, fmt.Errorf("Invalid %s parameter: must be a duration or a number", bound.name)
+		}
+		n, err := d.parse(text)
+		if err != nil {
+			return opts, fmt.Errorf("Invalid %s parameter: %v", bound.name, err)
+		}
+		*bound.value = &n
+	}
+	if opts.Minimum != nil && opts.Maximum != nil && *opts.Minimum > *opts.Maximum {
+		return opts, fmt.Errorf("Invalid bounds: minimum %s is above maximum %s", opts.format(*opts.Minimum), opts.format(*opts.Maximum))
+	}
+	return opts, nil
+}
+
+// durationAmount splits an answer like "90m" into its number and unit.
+var durationAmount = regexp.MustCompile(`^\s*(\d+(?:\.\d*)?|\.\d+)\s*([a-z]*)\s*$`)
+
+// webDurationInput fills the number field and dropdown from value. An
+// answer the dropdown can't show, such as "1h30m", leaves the field empty.
+func webDurationInput(value, defaultUnit string) *webDuration {
+	input := &webDuration{Unit: defaultUnit, Units: durationUnits}
+	if m := durationAmount.FindStringSubmatch(value); m != nil {
+		if m[2] == "" {
+			input.Amount = m[1]
+		} else if _, known := findDurationUnit(m[2]); known {
+			input.Amount, input.Unit = m[1], m[2]
+		}
+	}
+	return input
+}
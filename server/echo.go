@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// echoDisabler is implemented by terminals that switch off echo themselves.
+// The returned function restores the previous state.
+type echoDisabler interface {
+	DisableEcho() (func(), error)
+}
+
+// disableEcho stops tty from echoing what the user types until the returned
+// function is called. The terminal is also restored if the process is
+// interrupted or terminated first.
+func disableEcho(tty io.ReadWriteCloser) (func(), error) {
+	if t, ok := tty.(echoDisabler); ok {
+		return t.DisableEcho()
+	}
+
+	f, ok := tty.(*os.File)
+	if !ok {
+		return nil, fmt.Errorf("cannot disable echo on this terminal")
+	}
+	return setTTYMode(f, "disable terminal echo", "-echo")
+}
+
+// discardTypeAhead drops what has been typed on tty but not yet read,
+// including a line not yet ended with Enter. Terminals other than a real
+// one are left alone.
+func discardTypeAhead(tty io.ReadWriteCloser) {
+	f, ok := tty.(*os.File)
+	if !ok {
+		return
+	}
+	// Without line editing the pending line can be read, and reads return
+	// at once when nothing is left
+	restore, err := setTTYMode(f, "discard typed input", "-icanon", "min", "0", "time", "0")
+	if err != nil {
+		return
+	}
+	defer restore()
+	f.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	buf := make([]byte, 1024)
+	for {
+		if n, err := f.Read(buf); n == 0 || err != nil {
+			return
+		}
+	}
+}
+
+// echoSwitch turns echo off partway through a prompt and makes sure it is
+// back on when the prompt ends, even if the prompt is abandoned while the
+// reader still holds the terminal.
+type echoSwitch struct {
+	mu      sync.Mutex
+	tty     io.ReadWriteCloser
+	restore func()
+	ended   bool
+}
+
+// off disables echo until on is called or the prompt ends.
+func (e *echoSwitch) off() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.ended {
+		return fmt.Errorf("prompt ended")
+	}
+	if e.restore != nil {
+		return nil
+	}
+	restore, err := disableEcho(e.tty)
+	if err != nil {
+		return err
+	}
+	e.restore = restore
+	return nil
+}
+
+// on restores echo if off disabled it.
+func (e *echoSwitch) on() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.restore != nil {
+		e.restore()
+		e.restore = nil
+	}
+}
+
+// end restores echo for good; later calls to off fail.
+func (e *echoSwitch) end() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.restore != nil {
+		e.restore()
+		e.restore = nil
+	}
+	e.ended = true
+}
+
+// setTTYMode applies stty settings to f until the returned function is
+// called, or the process is interrupted or terminated.
+func setTTYMode(f *os.File, what string, settings ...string) (func(), error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal state: %w", err)
+	}
+	if _, err := stty(f, settings...); err != nil {
+		return nil, fmt.Errorf("failed to %s: %w", what, err)
+	}
+
+	var once sync.Once
+	restore := func() {
+		once.Do(func() {
+			stty(f, strings.TrimSpace(saved))
+		})
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-sigs:
+			restore()
+			fmt.Fprintf(f, "\n")
+			// Deliver the signal again now that the terminal is usable
+			signal.Stop(sigs)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+		restore()
+	}, nil
+}
+
+// ttySize returns the rows and columns of tty, if it is a real terminal.
+func ttySize(tty interface{}) (rows, cols int, ok bool) {
+	f, isFile := tty.(*os.File)
+	if !isFile {
+		return 0, 0, false
+	}
+	out, err := stty(f, "size")
+	if err != nil {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(out, "%d %d", &rows, &cols); err != nil {
+		return 0, 0, false
+	}
+	return rows, cols, true
+}
+
+// stty runs stty against the terminal f.
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
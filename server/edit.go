@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// validExtension matches the file extensions user_edit accepts, so the temp
+// file name can't leave its directory.
+var validExtension = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// NewEditPrompt returns a prompt letting the user edit content. The terminal
+// opens it in the user's editor as a temp file named with extension, so the
+// editor can pick a syntax; the browser shows it in a text area. The answer is
+// the edited content, byte for byte, which may be empty.
+func NewEditPrompt(prompt, method, content, extension string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindEdit
+	req.Content = content
+	req.Extension = strings.TrimPrefix(extension, ".")
+	req.Multiline = true
+	req.AllowEmpty = true
+	req.Trim = TrimNone
+	return req
+}
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, split into
+// the program and its arguments, falling back to vi, or notepad on Windows.
+func editorCommand() ([]string, error) {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			if _, err := exec.LookPath(fields[0]); err != nil {
+				return nil, fmt.Errorf("editor %q from $%s not found: %w", fields[0], env, err)
+			}
+			return fields, nil
+		}
+	}
+
+	fallback := "vi"
+	if runtime.GOOS == "windows" {
+		fallback = "notepad"
+	}
+	if _, err := exec.LookPath(fallback); err != nil {
+		return nil, fmt.Errorf("no editor available: set $EDITOR (%s not found)", fallback)
+	}
+	return []string{fallback}, nil
+}
+
+// runTTYEdit writes the content to a temp file, runs the editor on it
+// attached to the terminal and returns the file as the editor left it. The
+// editor is killed when ctx is done, and the temp file is always removed.
+func runTTYEdit(ctx context.Context, tty io.ReadWriter, req *PromptRequest) (string, error) {
+	editor, err := editorCommand()
+	if err != nil {
+		return "", err
+	}
+
+	pattern := "prompt-mcp-*"
+	if req.Extension != "" {
+		pattern += "." + req.Extension
+	}
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, err = file.WriteString(req.Content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	writeTTYHeader(tty, req)
+	if req.Prompt != "" {
+		fmt.Fprintf(tty, "%s\n", req.Prompt)
+	}
+	fmt.Fprintf(tty, "Opening %s in %s; save and quit to send your changes\n", path, editor[0])
+
+	cmd := exec.CommandContext(ctx, editor[0], append(editor[1:], path)...)
+	cmd.Stdout, cmd.Stderr = tty, tty
+	// Only a real terminal is handed to the editor as input: copying from
+	// anything else would keep Wait blocked on a read after the editor exits
+	if f, ok := tty.(*os.File); ok {
+		cmd.Stdin = f
+	}
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("editor %s failed: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(data), nil
+}
+
+// editUnchanged reports whether edited is the original content, allowing for
+// the final newline editors add when saving.
+func editUnchanged(original, edited string) bool {
+	return edited == original || (!strings.HasSuffix(original, "\n") && edited == original+"\n")
+}
+
+func (s *MCPServer) handleUserEditTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	content, ok := args["content"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid content parameter")
+		return
+	}
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	extension, _, err := optionalString(args, "file_extension")
+	if err == nil && extension != "" && !validExtension.MatchString(strings.TrimPrefix(extension, ".")) {
+		err = fmt.Errorf("Invalid file_extension parameter: use letters, digits, '-', '_' and '.' only, such as \"md\" or \"tar.gz\"")
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please edit the following"
+	}
+
+	promptReq := NewEditPrompt(prompt, promptMethod(args), content, extension)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+
+	edited, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	unchanged := editUnchanged(content, edited)
+	if unchanged {
+		edited = content
+	}
+	result := s.answerResult(promptReq, edited)
+	addStructured(result, "unchanged", unchanged)
+	s.sendResponse(req.ID, result)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expected answer lengths, which decide between a one-line input and a
+// textarea in the browser.
+const (
+	ExpectedShort = "short"
+	ExpectedLong  = "long"
+)
+
+// looksLong guesses from the wording whether a prompt wants more than a
+// line: it asks to explain something, or quotes code.
+func looksLong(prompt string) bool {
+	if strings.Contains(prompt, "```") {
+		return true
+	}
+	words := strings.Fields(strings.ToLower(prompt))
+	if len(words) == 0 {
+		return false
+	}
+	last := strings.TrimRight(words[len(words)-1], ".:!?)")
+	return last == "explain"
+}
+
+// parseExpectedLength reads expected_length for a text prompt whose kind
+// and checks are already set, reporting whether the answer gets a
+// textarea. Without the argument, the prompt's wording decides.
+func parseExpectedLength(args map[string]interface{}, req *PromptRequest) (bool, error) {
+	length, present, err := optionalString(args, "expected_length")
+	if err != nil {
+		return false, err
+	}
+	fits := req.Kind == KindText && !req.Secret && len(req.Suggestions) == 0
+	switch {
+	case !present:
+		return fits && !req.Multiline && looksLong(req.Prompt), nil
+	case length == ExpectedShort:
+		return false, nil
+	case length != ExpectedLong:
+		return false, fmt.Errorf("Invalid expected_length parameter: must be 'short' or 'long'")
+	case req.Secret:
+		return false, fmt.Errorf("Invalid expected_length parameter: secrets are single-line")
+	case !fits:
+		return false, fmt.Errorf("Invalid expected_length parameter: only free text answers without suggestions can be long")
+	}
+	return true, nil
+}
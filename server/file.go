@@ -0,0 +1,219 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// FileOptions configures a file selection prompt.
+type FileOptions struct {
+	// StartDir is where relative paths are resolved and browsing starts.
+	// Empty means the server's working directory.
+	StartDir string
+
+	MustExist       bool
+	DirectoriesOnly bool
+
+	// Restrict rejects paths outside StartDir, following symlinks.
+	Restrict bool
+}
+
+// NewFilePrompt returns a prompt asking the user for a path. The answer is
+// the absolute, cleaned path.
+func NewFilePrompt(prompt, method string, opts FileOptions) (*PromptRequest, error) {
+	req := NewPromptRequest(prompt, method)
+	if err := req.MakePath(opts); err != nil {
+		return nil, fmt.Errorf("Invalid start_dir parameter: %v", err)
+	}
+	req.Trim = TrimBoth
+	return req, nil
+}
+
+// MakePath turns the prompt into one answered with a path, resolved and
+// checked against opts.
+func (r *PromptRequest) MakePath(opts FileOptions) error {
+	if opts.StartDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		opts.StartDir = wd
+	}
+	start, err := filepath.Abs(expandHome(opts.StartDir))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(start)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", opts.StartDir)
+	}
+	opts.StartDir = start
+
+	r.Kind = KindFile
+	r.File = &opts
+	r.Validate = opts.validate
+	return nil
+}
+
+// Resolve turns a typed path into an absolute, cleaned path, checking it
+// against the options.
+func (o *FileOptions) Resolve(path string) (string, error) {
+	path = expandHome(strings.TrimSpace(path))
+	if path == "" {
+		return "", fmt.Errorf("Please enter a path")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(o.StartDir, path)
+	}
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	exists := err == nil
+	if !exists && o.MustExist {
+		return "", fmt.Errorf("%s does not exist", path)
+	}
+	if exists && o.DirectoriesOnly && !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	if o.Restrict && !o.contains(path) {
+		return "", fmt.Errorf("%s is outside %s", path, o.StartDir)
+	}
+	return path, nil
+}
+
+func (o *FileOptions) validate(response string) (string, error) {
+	return o.Resolve(response)
+}
+
+// contains reports whether path lies within StartDir once symlinks in the
+// existing part of both are resolved.
+func (o *FileOptions) contains(path string) bool {
+	root := resolveSymlinks(o.StartDir)
+	rel, err := filepath.Rel(root, resolveSymlinks(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveSymlinks evaluates symlinks in the longest existing prefix of path,
+// so paths that don't exist yet can still be checked.
+func resolveSymlinks(path string) string {
+	var rest []string
+	for {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, rest...)...)
+		}
+		rest = append([]string{filepath.Base(path)}, rest...)
+		path = parent
+	}
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// fileEntry is one entry of a directory listing shown while browsing.
+type fileEntry struct {
+	Name  string
+	Path  string
+	IsDir bool
+}
+
+// listDir returns the entries of dir that can be browsed into or selected,
+// directories first.
+func (o *FileOptions) listDir(dir string) ([]fileEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []fileEntry
+	for _, entry := range entries {
+		isDir := entry.IsDir()
+		if !isDir && entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, entry.Name())); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if o.DirectoriesOnly && !isDir {
+			continue
+		}
+		list = append(list, fileEntry{Name: entry.Name(), Path: filepath.Join(dir, entry.Name()), IsDir: isDir})
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].IsDir && !list[j].IsDir
+	})
+	return list, nil
+}
+
+func (s *MCPServer) handleUserFileSelectTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	var opts FileOptions
+	startDir, _, err := optionalString(args, "start_dir")
+	if err == nil {
+		opts.StartDir = startDir
+		opts.MustExist, err = optionalBool(args, "must_exist", false)
+	}
+	if err == nil {
+		opts.DirectoriesOnly, err = optionalBool(args, "directories_only", false)
+	}
+	if err == nil {
+		opts.Restrict, err = optionalBool(args, "restrict_to_start_dir", false)
+	}
+	var maxAttempts int
+	if err == nil {
+		maxAttempts, err = optionalMaxAttempts(args)
+	}
+	var promptReq *PromptRequest
+	if err == nil {
+		promptReq, err = NewFilePrompt(prompt, promptMethod(args), opts)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	path, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	info, statErr := os.Stat(path)
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(path),
+		},
+		"structuredContent": map[string]interface{}{
+			"path":   path,
+			"exists": statErr == nil,
+			"isDir":  statErr == nil && info.IsDir(),
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Conditions under which user_confirm asks its follow-up question.
+const (
+	FollowUpOnYes = "on_yes"
+	FollowUpOnNo  = "on_no"
+)
+
+// Fields of the form asking a confirmation and its follow-up.
+const (
+	confirmedField = "confirmed"
+	followUpField  = "follow_up"
+)
+
+// FollowUp is a question asked right after a confirmation, in the same
+// session, when the answer matches Condition.
+type FollowUp struct {
+	Condition string
+	Question  FormField
+}
+
+// NewFollowUpConfirmPrompt returns a form prompt asking the yes/no question
+// and then, if the answer matches, the follow-up. def is the confirmation's
+// default, as for NewConfirmPrompt. The answer is a JSON object with
+// "confirmed" and, when it was asked, "follow_up".
+func NewFollowUpConfirmPrompt(prompt, method, def string, followUp FollowUp) *PromptRequest {
+	confirmed := FormField{Name: confirmedField, Label: "Answer", Type: FieldBoolean}
+	if def != "" {
+		confirmed.Default = def == AnswerYes
+	}
+	question := followUp.Question
+	question.Name = followUpField
+	question.When = &StepCondition{Field: confirmedField, Equals: followUp.Condition == FollowUpOnYes}
+	return NewFormPrompt(prompt, method, []FormField{confirmed, question})
+}
+
+// parseFollowUp reads the follow_up argument of user_confirm: a question
+// with the validation fields of user_input, and when to ask it.
+func parseFollowUp(args map[string]interface{}) (*FollowUp, error) {
+	value, exists := args["follow_up"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid follow_up parameter: must be an object")
+	}
+	if _, nested := obj["follow_up"]; nested {
+		return nil, fmt.Errorf("Invalid follow_up parameter: follow-up questions can't have follow-ups of their own")
+	}
+
+	condition, present, err := optionalString(obj, "condition")
+	if err == nil && !present {
+		condition = FollowUpOnYes
+	}
+	if err == nil && condition != FollowUpOnYes && condition != FollowUpOnNo {
+		err = fmt.Errorf("condition must be %q or %q", FollowUpOnYes, FollowUpOnNo)
+	}
+	var question FormField
+	if err == nil {
+		question, err = parseFollowUpQuestion(obj)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid follow_up parameter: %v", err)
+	}
+	return &FollowUp{Condition: condition, Question: question}, nil
+}
+
+// followUpChecks are the user_input checks a follow-up question takes, and
+// the type of question each applies to.
+var followUpChecks = map[string]string{
+	"pattern":            FieldText,
+	"validation_message": FieldText,
+	"allowed_values":     FieldText,
+	"min_length":         FieldText,
+	"max_length":         FieldText,
+	"minimum":            FieldNumber,
+	"maximum":            FieldNumber,
+}
+
+// parseFollowUpQuestion reads the question of a follow-up. Besides the
+// types of user_input_batch it takes integer, and the checks of user_input
+// that suit its type.
+func parseFollowUpQuestion(obj map[string]interface{}) (FormField, error) {
+	typ, _, err := optionalString(obj, "type")
+	if err != nil {
+		return FormField{}, err
+	}
+	spec := obj
+	if typ == TypeInteger {
+		spec = make(map[string]interface{}, len(obj))
+		for name, value := range obj {
+			spec[name] = value
+		}
+		spec["type"] = TypeNumber
+	}
+	question, err := parseBatchQuestion(followUpField, spec)
+	if err != nil {
+		return question, err
+	}
+
+	for name, applies := range followUpChecks {
+		if _, present := obj[name]; present && applies != question.Type {
+			return question, fmt.Errorf("%s doesn't apply to %s questions", name, question.Type)
+		}
+	}
+
+	switch question.Type {
+	case FieldText:
+		question.validate, err = followUpTextValidator(question.Label, obj)
+	case FieldNumber:
+		var opts NumberOptions
+		opts, err = parseNumberOptions(obj, typ)
+		if err == nil && opts.Units != "" {
+			err = fmt.Errorf("units aren't supported in follow-up questions")
+		}
+		question.check = func(value interface{}) error {
+			_, err := opts.ParseNumber(formatNumber(value.(float64)))
+			return err
+		}
+	}
+	if err == nil {
+		err = question.setDefault(obj["default"])
+	}
+	if err != nil || question.Default == nil {
+		return question, err
+	}
+
+	// The default must pass the question's own checks
+	value, err := question.Parse(question.defaultText())
+	if err != nil {
+		return question, fmt.Errorf("default: %v", err)
+	}
+	question.Default = value
+	return question, nil
+}
+
+// followUpTextValidator builds the pattern, allowed_values and length
+// checks of a text follow-up, as user_input does.
+func followUpTextValidator(prompt string, obj map[string]interface{}) (func(string) (string, error), error) {
+	req := NewPromptRequest(prompt, "")
+	var checks []func(string) (string, error)
+
+	pattern, hasPattern, err := optionalString(obj, "pattern")
+	if err != nil {
+		return nil, err
+	}
+	message, _, err := optionalString(obj, "validation_message")
+	if err != nil {
+		return nil, err
+	}
+	if hasPattern {
+		validate, err := NewPatternValidator(req, pattern, message)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, validate)
+	}
+
+	allowed, hasAllowed, err := optionalStringList(obj, "allowed_values")
+	if err == nil && hasAllowed && hasPattern {
+		err = fmt.Errorf("Invalid allowed_values parameter: can't be combined with a pattern")
+	}
+	if err == nil && hasAllowed {
+		var validate func(string) (string, error)
+		validate, err = NewAllowedValuesValidator(req, allowed)
+		checks = append(checks, validate)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := parseLengthLimits(obj)
+	if err != nil {
+		return nil, err
+	}
+	if limits != nil {
+		checks = append(checks, func(answer string) (string, error) {
+			return answer, limits.check(answer)
+		})
+	}
+
+	return func(answer string) (string, error) {
+		if strings.TrimSpace(answer) == "" {
+			return "", fmt.Errorf("Please enter an answer")
+		}
+		for _, check := range checks {
+			var err error
+			if answer, err = check(answer); err != nil {
+				return "", err
+			}
+		}
+		return answer, nil
+	}, nil
+}
+
+// handleFollowUpPrompt asks a confirmation with a follow-up and returns both
+// answers together.
+func (s *MCPServer) handleFollowUpPrompt(req MCPRequest, promptReq *PromptRequest, progressToken interface{}) {
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var answers map[string]interface{}
+	if err := json.Unmarshal([]byte(answer), &answers); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode answers: %v", err))
+		return
+	}
+	confirmed, _ := answers[confirmedField].(bool)
+	text := AnswerNo
+	if confirmed {
+		text = AnswerYes
+	}
+
+	structured := map[string]interface{}{
+		"answer":    text,
+		"confirmed": confirmed,
+	}
+	if value, asked := answers[followUpField]; asked {
+		structured["follow_up"] = value
+		text += "\n" + fmt.Sprint(value)
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
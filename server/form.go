@@ -0,0 +1,313 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Form field types.
+const (
+	FieldText    = "text"
+	FieldBoolean = "boolean"
+	FieldSelect  = "select"
+	FieldNumber  = "number"
+)
+
+// FormField is one question in a form prompt. Default holds a string for
+// text and select fields, a bool for boolean fields and a float64 for
+// number fields.
+type FormField struct {
+	Name    string
+	Label   string
+	Type    string
+	Default interface{}
+	Options []string
+
+	// Optional fields without a default may be left empty; they are then
+	// left out of the answer.
+	Optional bool
+
+	// When, if set, asks the field only when an earlier field's answer
+	// matches; otherwise it is skipped and left out of the answer.
+	When *StepCondition
+
+	// check, when set, further validates the typed value.
+	check func(value interface{}) error
+
+	// validate, when set, checks a text answer and returns its canonical
+	// form, as a text prompt's Validate does.
+	validate func(string) (string, error)
+}
+
+// NewFormPrompt returns a prompt asking every field in turn. Providers pass
+// the raw answers as a JSON object of strings keyed by field name; the
+// validated answer is a JSON object of typed values.
+func NewFormPrompt(prompt, method string, fields []FormField) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindForm
+	req.Fields = fields
+	req.Trim = TrimNone
+	req.Validate = formValidator(fields)
+	return req
+}
+
+// Parse checks a raw answer to the field and returns its typed value. An
+// empty answer gives the default, or nil for an optional field.
+func (f FormField) Parse(raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" && f.Default != nil {
+		return f.Default, nil
+	}
+	if raw == "" && f.Optional {
+		return nil, nil
+	}
+
+	value, err := f.parse(raw)
+	if err == nil && f.check != nil {
+		err = f.check(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (f FormField) parse(raw string) (interface{}, error) {
+	switch f.Type {
+	case FieldBoolean:
+		answer, err := confirmValidator("")(raw)
+		if err != nil {
+			return nil, err
+		}
+		return answer == AnswerYes, nil
+	case FieldSelect:
+		return choiceValidator(f.Options)(raw)
+	case FieldNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Please enter a number")
+		}
+		return n, nil
+	default:
+		if f.validate != nil {
+			return f.validate(raw)
+		}
+		return raw, nil
+	}
+}
+
+// label returns the field's label, falling back to its name.
+func (f FormField) label() string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return f.Name
+}
+
+// defaultText is the default as the user would type it.
+func (f FormField) defaultText() string {
+	switch def := f.Default.(type) {
+	case nil:
+		return ""
+	case bool:
+		if def {
+			return AnswerYes
+		}
+		return AnswerNo
+	case float64:
+		return strconv.FormatFloat(def, 'f', -1, 64)
+	default:
+		return fmt.Sprint(def)
+	}
+}
+
+// parseFormAnswers validates raw answers keyed by field name, returning the
+// typed values and the error for each field that failed.
+func parseFormAnswers(fields []FormField, raw map[string]string) (map[string]interface{}, map[string]string) {
+	values := make(map[string]interface{}, len(fields))
+	errs := make(map[string]string)
+	for _, field := range fields {
+		if !field.active(values) {
+			continue
+		}
+		value, err := field.Parse(raw[field.Name])
+		if err != nil {
+			errs[field.Name] = err.Error()
+			continue
+		}
+		if value != nil {
+			values[field.Name] = value
+		}
+	}
+	return values, errs
+}
+
+func formValidator(fields []FormField) func(string) (string, error) {
+	return func(response string) (string, error) {
+		var raw map[string]string
+		if err := json.Unmarshal([]byte(response), &raw); err != nil {
+			return "", fmt.Errorf("Invalid form response: %v", err)
+		}
+
+		values, errs := parseFormAnswers(fields, raw)
+		for _, field := range fields {
+			if msg, failed := errs[field.Name]; failed {
+				return "", fmt.Errorf("%s: %s", field.label(), msg)
+			}
+		}
+
+		data, err := json.Marshal(values)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// parseFormFields reads and checks the fields argument of user_form.
+func parseFormFields(args map[string]interface{}) ([]FormField, error) {
+	items, ok := args["fields"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("Invalid fields parameter: must be a non-empty array of fields")
+	}
+
+	fields := make([]FormField, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid fields parameter: field %d is not an object", i)
+		}
+
+		name, _, err := optionalString(obj, "name")
+		if err == nil && name == "" {
+			err = fmt.Errorf("Invalid fields parameter: field %d has no name", i)
+		}
+		if err == nil && seen[name] {
+			err = fmt.Errorf("Invalid fields parameter: duplicate field %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[name] = true
+
+		field, err := parseFormField(name, obj)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid field %q: %v", name, err)
+		}
+		fields[i] = field
+	}
+	return fields, nil
+}
+
+func parseFormField(name string, obj map[string]interface{}) (FormField, error) {
+	field := FormField{Name: name, Type: FieldText}
+
+	label, _, err := optionalString(obj, "label")
+	if err != nil {
+		return field, err
+	}
+	field.Label = label
+
+	fieldType, present, err := optionalString(obj, "type")
+	if err != nil {
+		return field, err
+	}
+	if present {
+		field.Type = fieldType
+	}
+
+	switch field.Type {
+	case FieldText, FieldBoolean, FieldNumber:
+	case FieldSelect:
+		options, _, err := optionalStringList(obj, "options")
+		if err == nil {
+			err = validateOptions(options)
+		}
+		if err != nil {
+			return field, err
+		}
+		field.Options = options
+	default:
+		return field, fmt.Errorf("type must be one of text, boolean, select, number")
+	}
+
+	err = field.setDefault(obj["default"])
+	return field, err
+}
+
+// setDefault checks that def, if not nil, is an answer of the field's type
+// and makes it the default.
+func (f *FormField) setDefault(def interface{}) error {
+	if def == nil {
+		return nil
+	}
+	switch f.Type {
+	case FieldBoolean:
+		_, ok := def.(bool)
+		if !ok {
+			return fmt.Errorf("default must be a boolean")
+		}
+	case FieldNumber:
+		_, ok := def.(float64)
+		if !ok {
+			return fmt.Errorf("default must be a number")
+		}
+	default:
+		str, ok := def.(string)
+		if !ok {
+			return fmt.Errorf("default must be a string")
+		}
+		if f.Type == FieldSelect && !containsString(f.Options, str) {
+			return fmt.Errorf("default must be one of the options")
+		}
+	}
+	f.Default = def
+	return nil
+}
+
+func (s *MCPServer) handleUserFormTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	fields, err := parseFormFields(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewFormPrompt(prompt, promptMethod(args), fields)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(answer), &values); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode form answers: %v", err))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer),
+		},
+		"structuredContent": values,
+		"isError":           false,
+	})
+}
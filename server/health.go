@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+)
+
+// protocolVersion is the MCP protocol version the server speaks.
+const protocolVersion = "2024-11-05"
+
+// Health is how the server is doing, as /healthz reports it. It says how
+// many prompts are waiting but never what they ask.
+type Health struct {
+	Status          string  `json:"status"`
+	UptimeSeconds   float64 `json:"uptime_seconds"`
+	Pending         int     `json:"pending"`
+	ProtocolVersion string  `json:"protocol_version"`
+	Ready           bool    `json:"ready"`
+}
+
+// Health returns how the server is doing: how long it has run, how many
+// prompts wait for an answer, and whether a client has initialized it.
+func (s *MCPServer) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := Health{Status: "ok", Pending: s.pending, ProtocolVersion: protocolVersion, Ready: s.initialized}
+	if !s.started.IsZero() {
+		h.UptimeSeconds = math.Round(time.Since(s.started).Seconds())
+	}
+	return h
+}
+
+// trackPending counts a prompt as waiting for an answer, or with a negative
+// delta as done.
+func (s *MCPServer) trackPending(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending += delta
+}
+
+// NewHealthHandler serves s's health for service managers and container
+// probes: /healthz always answers 200 with the Health as JSON while the
+// process serves requests, and /readyz answers 200 once a client has sent
+// initialize, 503 before. Neither needs a page token.
+func NewHealthHandler(s *MCPServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, r, http.StatusOK, s.Health())
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		health := s.Health()
+		status := http.StatusOK
+		if !health.Ready {
+			health.Status = "starting"
+			status = http.StatusServiceUnavailable
+		}
+		writeHealth(w, r, status, health)
+	})
+	return mux
+}
+
+func writeHealth(w http.ResponseWriter, r *http.Request, status int, health Health) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(health)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"html/template"
+	"strings"
+)
+
+// tokenClasses are the CSS classes of each kind of token in the browser.
+var tokenClasses = map[int]string{
+	tokenComment: "hl-c",
+	tokenString:  "hl-s",
+	tokenNumber:  "hl-n",
+	tokenKeyword: "hl-k",
+}
+
+// highlightHTML escapes code for a web page, wrapping the tokens
+// highlightCode colors on the terminal in spans. Code without a language is
+// only escaped.
+func highlightHTML(code, lang string) template.HTML {
+	if lang == "" {
+		return template.HTML(template.HTMLEscapeString(code))
+	}
+	var b strings.Builder
+	for i, line := range strings.Split(code, "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		last := 0
+		for _, token := range codeTokens(line, lang) {
+			b.WriteString(template.HTMLEscapeString(line[last:token[0]]))
+			b.WriteString(`<span class="` + tokenClasses[token[2]] + `">` + template.HTMLEscapeString(line[token[0]:token[1]]) + `</span>`)
+			last = token[1]
+		}
+		b.WriteString(template.HTMLEscapeString(line[last:]))
+	}
+	return template.HTML(b.String())
+}
+
+// slashCommentLanguages are the fence languages whose comments start with
+// //. With hashCommentLanguages they are the languages highlighted in the
+// browser; any other fence is shown plain.
+var slashCommentLanguages = map[string]bool{
+	"go": true, "golang": true, "c": true, "h": true, "cpp": true, "c++": true,
+	"java": true, "kotlin": true, "kt": true, "scala": true, "swift": true,
+	"js": true, "javascript": true, "jsx": true, "ts": true, "typescript": true, "tsx": true,
+	"rust": true, "rs": true, "cs": true, "csharp": true, "php": true,
+	"json": true, "jsonc": true, "proto": true, "dart": true,
+}
+
+// fenceLanguage is the language a fence tag highlights as, or "" for plain
+// text.
+func fenceLanguage(tag string) string {
+	tag = strings.ToLower(tag)
+	if slashCommentLanguages[tag] || hashCommentLanguages[tag] {
+		return tag
+	}
+	return ""
+}
+
+// promptHTML renders a prompt for the browser with its fenced code blocks
+// highlighted in scrolling <pre> blocks. A prompt without fences is only
+// escaped, as before.
+func promptHTML(text string) template.HTML {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	fenced := false
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			fenced = true
+			break
+		}
+	}
+	if !fenced {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	var paragraph []string
+	flush := func() {
+		if text := strings.Trim(strings.Join(paragraph, "\n"), "\n"); text != "" {
+			b.WriteString(`<div class="prompt-text">` + template.HTMLEscapeString(text) + `</div>`)
+		}
+		paragraph = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		m := mdFence.FindStringSubmatch(lines[i])
+		if m == nil {
+			paragraph = append(paragraph, lines[i])
+			continue
+		}
+		flush()
+		var code []string
+		for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+			code = append(code, lines[i])
+		}
+		lang := fenceLanguage(m[2])
+		b.WriteString(`<pre class="code"><code`)
+		if lang != "" {
+			b.WriteString(` class="language-` + template.HTMLEscapeString(lang) + `"`)
+		}
+		b.WriteString(`>` + string(highlightHTML(strings.Join(code, "\n"), lang)) + `</code></pre>`)
+	}
+	flush()
+	return template.HTML(b.String())
+}
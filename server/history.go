@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHistorySize is how many prompts the history keeps unless
+// configured otherwise.
+const defaultHistorySize = 100
+
+// HistoryEntry is a prompt the user was asked, as the history keeps it.
+// Client is the MCP client that asked, such as "Claude Desktop 0.9.2" or
+// "unknown client". Response is the answer as it may be shown to anyone:
+// "[redacted]" for sensitive and secret prompts, empty when the prompt
+// wasn't answered.
+type HistoryEntry struct {
+	ID       int64
+	Title    string
+	Prompt   string
+	Method   string
+	Client   string
+	Outcome  string
+	Response string
+	Asked    time.Time
+	Took     time.Duration
+}
+
+// History keeps the last prompts in memory, oldest dropped first, as an
+// audit trail of a session. A nil History keeps nothing.
+type History struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	start   int
+	count   int
+}
+
+// NewHistory returns a history keeping the last size prompts.
+func NewHistory(size int) *History {
+	return &History{entries: make([]HistoryEntry, max(size, 0))}
+}
+
+// Add records a prompt, dropping the oldest one when the history is full.
+func (h *History) Add(entry HistoryEntry) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	size := len(h.entries)
+	if size == 0 {
+		return
+	}
+	h.entries[(h.start+h.count)%size] = entry
+	if h.count < size {
+		h.count++
+	} else {
+		h.start = (h.start + 1) % size
+	}
+}
+
+// Entries returns the prompts kept, newest first.
+func (h *History) Entries() []HistoryEntry {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := make([]HistoryEntry, h.count)
+	for i := range entries {
+		entries[i] = h.entries[(h.start+h.count-1-i)%len(h.entries)]
+	}
+	return entries
+}
+
+// resize keeps the newest size prompts.
+func (h *History) resize(size int) {
+	entries := h.Entries()
+	if len(entries) > size {
+		entries = entries[:max(size, 0)]
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = make([]HistoryEntry, max(size, 0))
+	h.start, h.count = 0, len(entries)
+	for i, entry := range entries {
+		h.entries[len(entries)-1-i] = entry
+	}
+}
+
+// matches reports whether query appears in the entry's title, prompt,
+// method, client or shown response, ignoring case.
+func (e HistoryEntry) matches(query string) bool {
+	query = strings.ToLower(query)
+	for _, text := range []string{e.Title, e.Prompt, e.Method, e.Client, e.Response} {
+		if strings.Contains(strings.ToLower(text), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Image limits. Base64-encoded, the total stays far below maxMessageSize,
+// so a request carrying the most images allowed still fits the stdin
+// scanner's buffer.
+const (
+	maxImages          = 10
+	maxImageBytes      = 5 * 1024 * 1024
+	maxTotalImageBytes = 20 * 1024 * 1024
+)
+
+// imageExtensions maps the accepted image types to the extension used for
+// their temp files.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image is a picture shown with a prompt.
+type Image struct {
+	MimeType string
+	Data     []byte
+}
+
+// parseImages reads the images argument: an array of objects with base64
+// data and a mimeType, like MCP image content blocks. Each image must really
+// be of its declared type.
+func parseImages(args map[string]interface{}) ([]Image, error) {
+	value, exists := args["images"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid images parameter: must be an array of {data, mimeType} objects")
+	}
+	if len(items) > maxImages {
+		return nil, fmt.Errorf("Invalid images parameter: at most %d images are allowed", maxImages)
+	}
+
+	images := make([]Image, len(items))
+	total := 0
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid images parameter: image %d is not an object", i)
+		}
+		mimeType, _, err := optionalString(obj, "mimeType")
+		if err == nil && imageExtensions[mimeType] == "" {
+			err = fmt.Errorf("mimeType must be one of image/png, image/jpeg, image/gif, image/webp")
+		}
+		data, _, dataErr := optionalString(obj, "data")
+		if err == nil {
+			err = dataErr
+		}
+		// Check the size before decoding anything
+		if err == nil && len(data) > base64.StdEncoding.EncodedLen(maxImageBytes) {
+			err = fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid image %d: %v", i, err)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("Invalid image %d: data is not valid base64: %v", i, err)
+		case len(decoded) == 0:
+			return nil, fmt.Errorf("Invalid image %d: data is empty", i)
+		case len(decoded) > maxImageBytes:
+			return nil, fmt.Errorf("Invalid image %d: image is larger than %d bytes", i, maxImageBytes)
+		case http.DetectContentType(decoded) != mimeType:
+			return nil, fmt.Errorf("Invalid image %d: data is not %s", i, mimeType)
+		}
+
+		total += len(decoded)
+		if total > maxTotalImageBytes {
+			return nil, fmt.Errorf("Invalid images parameter: images total more than %d bytes", maxTotalImageBytes)
+		}
+		images[i] = Image{MimeType: mimeType, Data: decoded}
+	}
+	return images, nil
+}
+
+// writeImageFiles saves images to a new temp directory for a terminal user
+// to open, returning their paths and a function removing them.
+func writeImageFiles(images []Image) ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "prompt-mcp-images-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to save images: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	paths := make([]string, len(images))
+	for i, image := range images {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("image-%d%s", i+1, imageExtensions[image.MimeType]))
+		if err := os.WriteFile(paths[i], image.Data, 0o600); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to save images: %w", err)
+		}
+	}
+	return paths, cleanup, nil
+}
+
+// writeTTYImages tells the terminal user where the prompt's images were
+// saved. The returned function removes them.
+func writeTTYImages(tty io.Writer, images []Image) (func(), error) {
+	paths, cleanup, err := writeImageFiles(images)
+	if err != nil {
+		return nil, err
+	}
+	noun := "images"
+	if len(images) == 1 {
+		noun = "image"
+	}
+	fmt.Fprintf(tty, "(%d %s attached; open to view before answering:\n  %s)\n", len(images), noun, strings.Join(paths, "\n  "))
+	return cleanup, nil
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxButtonLabel is the longest submit_label or decline_label accepted, in
+// characters.
+const maxButtonLabel = 40
+
+// The actions a user_input result names in structuredContent.action,
+// whatever the prompt's buttons said. A prompt that timed out names
+// ActionTimedOut when its default_response was used; without one the
+// result is an error with structuredContent.timedOut and no action.
+const (
+	ActionSubmitted = "submitted"
+	ActionDeclined  = "declined"
+	ActionTimedOut  = "timed_out"
+)
+
+// parseButtonLabels reads submit_label and decline_label into req. Labels
+// are plain text on one line; surrounding space is dropped and an empty
+// label keeps the default.
+func parseButtonLabels(args map[string]interface{}, req *PromptRequest) error {
+	for _, arg := range []struct {
+		name  string
+		label *string
+	}{
+		{"submit_label", &req.SubmitLabel},
+		{"decline_label", &req.DeclineLabel},
+	} {
+		label, _, err := optionalString(args, arg.name)
+		if err != nil {
+			return err
+		}
+		label = strings.TrimSpace(label)
+		if utf8.RuneCountInString(label) > maxButtonLabel {
+			return fmt.Errorf("Invalid %s parameter: must be at most %d characters", arg.name, maxButtonLabel)
+		}
+		if strings.IndexFunc(label, unicode.IsControl) >= 0 {
+			return fmt.Errorf("Invalid %s parameter: must be plain text on one line", arg.name)
+		}
+		*arg.label = label
+	}
+	return nil
+}
+
+// labelled reports whether the prompt's buttons have labels of their own.
+func (r *PromptRequest) labelled() bool {
+	return r.SubmitLabel != "" || r.DeclineLabel != ""
+}
+
+// buttonHint is the terminal's hint for a labelled prompt, the answer's
+// label first as Enter gives it, e.g. "[Deploy/abort]", or "" without
+// labels.
+func (r *PromptRequest) buttonHint() string {
+	if !r.labelled() {
+		return ""
+	}
+	submit, decline := r.SubmitLabel, r.DeclineLabel
+	if submit == "" {
+		submit = "Submit"
+	}
+	if decline == "" {
+		decline = "decline"
+	}
+	first, size := utf8.DecodeRuneInString(decline)
+	return fmt.Sprintf("[%s/%s]", submit, string(unicode.ToLower(first))+decline[size:])
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// LengthLimits bounds how long an answer may be, counted in characters
+// (Unicode code points) after normalization. Zero means no bound.
+type LengthLimits struct {
+	Min int
+	Max int
+}
+
+// describe returns the limits as shown to the user, e.g. "at most 72
+// characters".
+func (l *LengthLimits) describe() string {
+	switch {
+	case l.Min > 0 && l.Max > 0:
+		return fmt.Sprintf("%d-%d characters", l.Min, l.Max)
+	case l.Max > 0:
+		return fmt.Sprintf("at most %d characters", l.Max)
+	default:
+		return fmt.Sprintf("at least %d characters", l.Min)
+	}
+}
+
+func (l *LengthLimits) check(answer string) error {
+	n := utf8.RuneCountInString(answer)
+	switch {
+	case l.Max > 0 && n > l.Max:
+		return fmt.Errorf("Response is %d characters long; it must be %s", n, l.describe())
+	case n < l.Min:
+		return fmt.Errorf("Response is %d characters long; it must be %s", n, l.describe())
+	}
+	return nil
+}
+
+// LimitLength wraps the prompt's validation so that answers outside limits
+// are asked again before any other check. Blank answers are left to
+// RequireAnswer.
+func (r *PromptRequest) LimitLength(limits LengthLimits) {
+	r.Length = &limits
+	validate := r.Validate
+	r.Validate = func(response string) (string, error) {
+		answer := normalizeAnswer(response, r.Trim, r.Dedent)
+		if strings.TrimSpace(answer) == "" && (r.Default != "" || r.AllowEmpty) {
+			return response, nil
+		}
+		if err := limits.check(answer); err != nil {
+			return "", err
+		}
+		if validate == nil {
+			return response, nil
+		}
+		return validate(response)
+	}
+}
+
+// parseLengthLimits reads min_length and max_length, returning nil when
+// neither is given.
+func parseLengthLimits(args map[string]interface{}) (*LengthLimits, error) {
+	var limits LengthLimits
+	for _, arg := range []struct {
+		name  string
+		value *int
+	}{{"min_length", &limits.Min}, {"max_length", &limits.Max}} {
+		n, err := optionalNumber(args, arg.name)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			continue
+		}
+		if *n < 1 || *n != float64(int(*n)) {
+			return nil, fmt.Errorf("Invalid %s parameter: must be a whole number of at least 1", arg.name)
+		}
+		*arg.value = int(*n)
+	}
+	if limits.Min == 0 && limits.Max == 0 {
+		return nil, nil
+	}
+	if limits.Max > 0 && limits.Min > limits.Max {
+		return nil, fmt.Errorf("Invalid min_length parameter: must not exceed max_length")
+	}
+	return &limits, nil
+}
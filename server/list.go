@@ -0,0 +1,279 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// maxListItems is the most items a list prompt collects.
+const maxListItems = 1000
+
+// How a list prompt treats an item entered twice.
+const (
+	DuplicatesAllow  = "allow"
+	DuplicatesDedupe = "dedupe"
+	DuplicatesReject = "reject"
+)
+
+// ListOptions describes a list of items: how many, what each must look
+// like, what happens to duplicates and what ends the list on the terminal.
+type ListOptions struct {
+	MinItems   int
+	MaxItems   int
+	Pattern    string
+	Message    string
+	Duplicates string
+
+	// Terminator is the line ending the list on the terminal; empty means
+	// a blank line
+	Terminator string
+
+	re *regexp.Regexp
+}
+
+// NewListPrompt returns a prompt collecting a list of items, in the order
+// they were entered. Providers answer with a JSON array of strings; the
+// answer is the array as checked, trimmed and, with DuplicatesDedupe,
+// deduplicated.
+func NewListPrompt(prompt, method string, opts ListOptions) (*PromptRequest, error) {
+	if opts.Pattern != "" {
+		re, err := regexp.Compile(`^(?:` + opts.Pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern parameter: %v", err)
+		}
+		opts.re = re
+	}
+	if opts.MaxItems == 0 {
+		opts.MaxItems = maxListItems
+	}
+	if opts.Duplicates == "" {
+		opts.Duplicates = DuplicatesAllow
+	}
+
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindList
+	req.List = &opts
+	req.Trim = TrimNone
+	req.Validate = opts.validate
+	return req, nil
+}
+
+// checkItem reports why item can't join items, already accepted.
+func (o *ListOptions) checkItem(item string, items []string) error {
+	if o.re != nil && !o.re.MatchString(item) {
+		if o.Message != "" {
+			return fmt.Errorf("%s", o.Message)
+		}
+		return fmt.Errorf("%q doesn't match the pattern %s", item, o.Pattern)
+	}
+	if o.Duplicates == DuplicatesReject && containsString(items, item) {
+		return fmt.Errorf("%q is already on the list", item)
+	}
+	return nil
+}
+
+// add appends item to items, unless it is a duplicate to drop.
+func (o *ListOptions) add(items []string, item string) []string {
+	if o.Duplicates == DuplicatesDedupe && containsString(items, item) {
+		return items
+	}
+	return append(items, item)
+}
+
+// bounds describes how many items are wanted, or "" when any number is.
+func (o *ListOptions) bounds() string {
+	switch {
+	case o.MinItems > 0 && o.MaxItems < maxListItems:
+		return fmt.Sprintf("%d to %d items", o.MinItems, o.MaxItems)
+	case o.MinItems > 0:
+		return fmt.Sprintf("at least %d items", o.MinItems)
+	case o.MaxItems < maxListItems:
+		return fmt.Sprintf("at most %d items", o.MaxItems)
+	}
+	return ""
+}
+
+func (o *ListOptions) validate(response string) (string, error) {
+	var raw []string
+	if err := json.Unmarshal([]byte(response), &raw); err != nil {
+		return "", fmt.Errorf("Response must be a JSON array of strings")
+	}
+
+	// Empty rows are left out rather than rejected
+	items := []string{}
+	for _, item := range raw {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if err := o.checkItem(item, items); err != nil {
+			return "", err
+		}
+		items = o.add(items, item)
+	}
+	switch {
+	case len(items) < o.MinItems:
+		return "", fmt.Errorf("Please enter at least %d items", o.MinItems)
+	case len(items) > o.MaxItems:
+		return "", fmt.Errorf("Please enter at most %d items", o.MaxItems)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runTTYList reads one item per line until the terminator, or until the
+// list is full. Items failing their checks are asked for again.
+func runTTYList(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	opts := req.List
+	end := "a blank line"
+	if opts.Terminator != "" {
+		end = fmt.Sprintf("a line reading %q", opts.Terminator)
+	}
+	hint := "One item per line; " + end + " finishes"
+	if bounds := opts.bounds(); bounds != "" {
+		hint += " (" + bounds + ")"
+	}
+	fmt.Fprintf(tty, "(%s)\n", hint)
+
+	items := []string{}
+	for len(items) < opts.MaxItems {
+		fmt.Fprintf(tty, "%d: ", len(items)+1)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("failed to read from terminal: %w", err)
+			}
+			return "", fmt.Errorf("terminal closed before the list was finished")
+		}
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == opts.Terminator {
+			if len(items) >= opts.MinItems {
+				break
+			}
+			writeTTYError(tty, fmt.Errorf("Please enter at least %d items", opts.MinItems))
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if err := opts.checkItem(line, items); err != nil {
+			writeTTYError(tty, err)
+			continue
+		}
+		items = opts.add(items, line)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	return req.Validate(string(data))
+}
+
+// parseListOptions reads the arguments of user_list.
+func parseListOptions(args map[string]interface{}) (ListOptions, error) {
+	var opts ListOptions
+	var err error
+
+	count := func(name string) (int, error) {
+		n, err := optionalNumber(args, name)
+		if err != nil || n == nil {
+			return 0, err
+		}
+		if *n != float64(int(*n)) || *n < 0 || *n > maxListItems {
+			return 0, fmt.Errorf("Invalid %s parameter: must be a whole number from 0 to %d", name, maxListItems)
+		}
+		return int(*n), nil
+	}
+	if opts.MinItems, err = count("min_items"); err != nil {
+		return opts, err
+	}
+	if opts.MaxItems, err = count("max_items"); err != nil {
+		return opts, err
+	}
+	if _, given := args["max_items"]; given && opts.MaxItems < 1 {
+		return opts, fmt.Errorf("Invalid max_items parameter: must be at least 1")
+	}
+	if opts.MaxItems > 0 && opts.MinItems > opts.MaxItems {
+		return opts, fmt.Errorf("Invalid min_items parameter: can't be more than max_items")
+	}
+
+	if opts.Pattern, _, err = optionalString(args, "pattern"); err != nil {
+		return opts, err
+	}
+	if opts.Message, _, err = optionalString(args, "validation_message"); err != nil {
+		return opts, err
+	}
+	if opts.Duplicates, _, err = optionalString(args, "duplicates"); err != nil {
+		return opts, err
+	}
+	switch opts.Duplicates {
+	case "", DuplicatesAllow, DuplicatesDedupe, DuplicatesReject:
+	default:
+		return opts, fmt.Errorf("Invalid duplicates parameter: must be 'allow', 'dedupe' or 'reject'")
+	}
+	if opts.Terminator, _, err = optionalString(args, "terminator"); err != nil {
+		return opts, err
+	}
+	if opts.Terminator != strings.TrimSpace(opts.Terminator) || strings.ContainsAny(opts.Terminator, "\r\n") {
+		return opts, fmt.Errorf("Invalid terminator parameter: must be a single line without surrounding whitespace")
+	}
+	return opts, nil
+}
+
+func (s *MCPServer) handleUserListTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	opts, err := parseListOptions(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq, err := NewListPrompt(prompt, promptMethod(args), opts)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var items []string
+	if err := json.Unmarshal([]byte(answer), &items); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode list: %v", err))
+		return
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer),
+		},
+		"structuredContent": map[string]interface{}{
+			"items": items,
+			"count": len(items),
+		},
+		"isError": false,
+	})
+}
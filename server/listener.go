@@ -0,0 +1,285 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"prompt-mcp/qr"
+)
+
+// defaultWebHost is the interface prompt pages are served on unless
+// configured otherwise.
+const defaultWebHost = "127.0.0.1"
+
+// WebListener is where prompt pages are served, and how their URLs are
+// written.
+type WebListener struct {
+	// Host is the interface to listen on
+	Host string
+
+	// MinPort and MaxPort bound the ports tried, first free one wins. Zero
+	// for both picks any free port.
+	MinPort int
+	MaxPort int
+
+	// ExternalURL, if set, replaces scheme, host and port in the URLs
+	// handed to the user, for pages reached through NAT or a proxy
+	ExternalURL string
+
+	// BasePath, such as "/prompt-mcp", is the path every page is served
+	// under, for a reverse proxy routing by path. It has a leading slash
+	// and no trailing one; "" serves from the root
+	BasePath string
+
+	// QR prints a QR code of each prompt's page on the controlling
+	// terminal, for answering from a phone
+	QR bool
+
+	// NoBrowser never opens a browser; the URL of each page is printed on
+	// the controlling terminal to open by hand
+	NoBrowser bool
+
+	// Auth, if set, is asked for by every page but the health probes
+	Auth *BasicAuth
+
+	// Tunnel, if set, is started for every page server, which is then
+	// reached at the tunnel's public URL
+	Tunnel *TunnelCommand
+}
+
+var (
+	webListenerMu sync.Mutex
+	webListener   = WebListener{Host: defaultWebHost}
+)
+
+// SetWebListener configures where the web method and dashboard serve pages
+// from now on. Pages already served keep their listener.
+func SetWebListener(l WebListener) {
+	webListenerMu.Lock()
+	defer webListenerMu.Unlock()
+	webListener = l
+}
+
+func currentWebListener() WebListener {
+	webListenerMu.Lock()
+	defer webListenerMu.Unlock()
+	return webListener
+}
+
+// ParsePortRange reads a port range such as "8400-8500", or a single port.
+func ParsePortRange(s string) (int, int, error) {
+	first, last, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	min, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q: use e.g. 8400-8500", s)
+	}
+	max := min
+	if isRange {
+		if max, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q: use e.g. 8400-8500", s)
+		}
+	}
+	if min < 1 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid port range %q: ports must run upwards from 1 to at most 65535", s)
+	}
+	return min, max, nil
+}
+
+// listen binds the configured host on port, or with port 0 on the first
+// free port of the range.
+func (l WebListener) listen(port int) (net.Listener, error) {
+	min, max := l.MinPort, l.MaxPort
+	if port != 0 {
+		min, max = port, port
+	}
+	if min == 0 {
+		return net.Listen("tcp", net.JoinHostPort(l.Host, "0"))
+	}
+
+	var lastErr error
+	for p := min; p <= max; p++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(l.Host, strconv.Itoa(p)))
+		if err == nil {
+			return listener, nil
+		}
+		lastErr = err
+	}
+	if min == max {
+		return nil, fmt.Errorf("port %d on %s is not available: %w", min, l.Host, lastErr)
+	}
+	return nil, fmt.Errorf("no free port between %d and %d on %s: %w", min, max, l.Host, lastErr)
+}
+
+// url is the base URL of pages served on port. Listening on every
+// interface, pages are opened through localhost.
+func (l WebListener) url(port int) string {
+	if l.ExternalURL != "" {
+		return strings.TrimSuffix(l.ExternalURL, "/")
+	}
+	host := l.Host
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// OpensBrowser reports whether pages are opened in a browser: not with
+// NoBrowser, nor on a machine without a display to show one on, such as
+// over SSH. macOS and Windows always have one.
+func (l WebListener) OpensBrowser() bool {
+	if l.NoBrowser {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// Private reports whether pages can only be reached from this machine.
+// Pages are never served over TLS, so only a loopback listener without a
+// tunnel qualifies.
+func (l WebListener) Private() bool {
+	if l.Tunnel != nil {
+		return false
+	}
+	if l.Host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(l.Host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// PhoneURL is page, a URL of this listener, as another device on the
+// network reaches it: with the external URL or through a tunnel as is,
+// otherwise with this machine's LAN address in place of an unspecified host.
+func (l WebListener) PhoneURL(page string) (string, error) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", err
+	}
+	if l.ExternalURL != "" || tunnelHost(u.Host) {
+		return page, nil
+	}
+	if l.Private() {
+		return "", fmt.Errorf("pages are only served on %s; use --web-host or --web-external-url to reach them from a phone", l.Host)
+	}
+	host := l.Host
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		lan := lanIP()
+		if lan == nil {
+			return "", fmt.Errorf("found no LAN address to reach pages on")
+		}
+		host = lan.String()
+	}
+	u.Host = net.JoinHostPort(host, u.Port())
+	return u.String(), nil
+}
+
+// lanIP returns this machine's address on the local network, preferring a
+// private IPv4 address, or nil if it has none.
+func lanIP() net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var global net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil || !ipnet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipnet.IP.IsPrivate() {
+			return ipnet.IP
+		}
+		if global == nil {
+			global = ipnet.IP
+		}
+	}
+	return global
+}
+
+// showQR prints a QR code of a prompt's page on the controlling terminal,
+// when configured and the page can be reached from a phone. The URL holds
+// the page's token, so it never goes to stderr.
+func showQR(page string) {
+	l := currentWebListener()
+	if !l.QR {
+		return
+	}
+	target, err := l.PhoneURL(page)
+	if err != nil {
+		return
+	}
+	code, err := qr.Encode(target)
+	if err != nil {
+		return
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+	fmt.Fprintf(tty, "\nScan to answer on your phone:\n%s%s\n\n", code.ANSI(4), target)
+}
+
+// mount serves handler under the listener's base path, with the base
+// stripped from the request, to those with the listener's credentials.
+// Paths outside it are not found.
+func (l WebListener) mount(handler http.Handler) http.Handler {
+	if l.BasePath == "" && l.Auth == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.Auth.Allows(r) {
+			challenge(w)
+			return
+		}
+		if l.BasePath == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, l.BasePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix(l.BasePath, handler).ServeHTTP(w, r)
+	})
+}
+
+// ParseBasePath reads the path pages are served under, such as
+// "/prompt-mcp" or "prompt-mcp/", into the form WebListener.BasePath takes.
+// Segments must be plain URL path characters; "." and ".." are refused.
+func ParseBasePath(s string) (string, error) {
+	trimmed := strings.Trim(strings.TrimSpace(s), "/")
+	if trimmed == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(trimmed, "/") {
+		if segment == "" || segment == "." || segment == ".." || url.PathEscape(segment) != segment {
+			return "", fmt.Errorf("invalid web base path %q: use a plain path such as /prompt-mcp", s)
+		}
+	}
+	return "/" + trimmed, nil
+}
+
+// validExternalURL checks a URL the user is sent to instead of the
+// listener's own.
+func validExternalURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("web external URL must be an http or https URL such as https://prompts.example.com (got %q)", s)
+	}
+	if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("web external URL must be just scheme and host; set a path with --web-base-path (got %q)", s)
+	}
+	return nil
+}
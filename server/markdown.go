@@ -0,0 +1,324 @@
+package server
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Prompt formats.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+)
+
+// defaultTTYWidth is used when the terminal's width can't be read.
+const defaultTTYWidth = 80
+
+// ANSI styles used for Markdown on the terminal.
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiNoBold    = "\x1b[22m"
+	ansiItalic    = "\x1b[3m"
+	ansiNoItalic  = "\x1b[23m"
+	ansiUnderline = "\x1b[4m"
+	ansiNoUnder   = "\x1b[24m"
+	ansiDim       = "\x1b[90m"
+	ansiCode      = "\x1b[36m"
+	ansiKeyword   = "\x1b[34m"
+	ansiString    = "\x1b[32m"
+	ansiNumber    = "\x1b[35m"
+	ansiNoColor   = "\x1b[39m"
+)
+
+var (
+	mdFence    = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+#-]*)")
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdQuote    = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumbered = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+
+	mdCodeSpan = regexp.MustCompile("`([^`]+)`")
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic   = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+	codeKeywords = `\b(func|return|if|else|elif|for|while|do|done|then|fi|case|esac|switch|break|continue|def|class|import|from|package|var|let|const|fn|struct|type|interface|true|false|nil|null|None|True|False|in|go|defer|select|try|except|catch|finally|throw|raise|new|public|private|static|void|async|await|with|as|echo|export|local)\b`
+	codeSlash    = regexp.MustCompile(`(//.*$)|("(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*')|\b(\d+(?:\.\d+)?)\b|` + codeKeywords)
+	codeHash     = regexp.MustCompile(`(#.*$)|("(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*')|\b(\d+(?:\.\d+)?)\b|` + codeKeywords)
+)
+
+// hashCommentLanguages are the fence languages whose comments start with #.
+var hashCommentLanguages = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "shell": true, "console": true,
+	"python": true, "py": true, "ruby": true, "rb": true, "perl": true,
+	"yaml": true, "yml": true, "toml": true, "ini": true, "conf": true,
+	"make": true, "makefile": true, "dockerfile": true, "r": true,
+}
+
+// RenderMarkdown formats Markdown for a terminal width columns wide. With
+// color, emphasis, code and headings are styled with ANSI escapes and code
+// blocks are highlighted; without it the markup is stripped to plain text.
+// Paragraphs, lists and quotes are wrapped; code blocks are indented but
+// never wrapped.
+func RenderMarkdown(text string, width int, color bool) string {
+	if width < 20 {
+		width = 20
+	}
+	r := mdRenderer{width: width, color: color}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			r.wrap(r.inline(strings.Join(paragraph, " ")), "", "")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			r.codeBlock(code, strings.ToLower(m[2]))
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+			r.blank()
+		case mdHeading.MatchString(line):
+			flush()
+			m := mdHeading.FindStringSubmatch(line)
+			r.heading(r.inline(m[2]), len(m[1]))
+		case mdRule.MatchString(line):
+			flush()
+			r.line(r.style(ansiDim, strings.Repeat("-", r.width)))
+		case mdQuote.MatchString(line):
+			flush()
+			bar := r.style(ansiDim, "| ")
+			r.wrap(r.inline(mdQuote.FindStringSubmatch(line)[1]), bar, bar)
+		case mdBullet.MatchString(line):
+			flush()
+			m := mdBullet.FindStringSubmatch(line)
+			bullet := "- "
+			if r.color {
+				bullet = "• "
+			}
+			r.wrap(r.inline(m[2]), m[1]+bullet, m[1]+"  ")
+		case mdNumbered.MatchString(line):
+			flush()
+			m := mdNumbered.FindStringSubmatch(line)
+			r.wrap(r.inline(m[3]), m[1]+m[2]+" ", m[1]+strings.Repeat(" ", len(m[2])+1))
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+
+	return strings.Trim(r.out.String(), "\n")
+}
+
+type mdRenderer struct {
+	width int
+	color bool
+	out   strings.Builder
+}
+
+func (r *mdRenderer) line(s string) {
+	r.out.WriteString(s)
+	r.out.WriteString("\n")
+}
+
+// blank separates blocks with at most one empty line.
+func (r *mdRenderer) blank() {
+	if r.out.Len() > 0 && !strings.HasSuffix(r.out.String(), "\n\n") {
+		r.out.WriteString("\n")
+	}
+}
+
+// style wraps s in an ANSI style when color is on.
+func (r *mdRenderer) style(code, s string) string {
+	if !r.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (r *mdRenderer) heading(text string, level int) {
+	r.blank()
+	if level == 1 {
+		r.line(r.style(ansiBold+ansiUnderline, text))
+	} else {
+		r.line(r.style(ansiBold, text))
+	}
+	r.blank()
+}
+
+// inline applies emphasis, code spans and links. Code spans are set aside
+// first so their contents are left alone.
+func (r *mdRenderer) inline(s string) string {
+	var spans []string
+	s = mdCodeSpan.ReplaceAllStringFunc(s, func(m string) string {
+		spans = append(spans, mdCodeSpan.FindStringSubmatch(m)[1])
+		return "\x00" + string(rune('0'+len(spans)-1)) + "\x00"
+	})
+
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLink.FindStringSubmatch(m)
+		url := parts[2]
+		if r.color {
+			url = ansiUnderline + url + ansiNoUnder
+		}
+		if parts[1] == parts[2] {
+			return url
+		}
+		return parts[1] + " (" + url + ")"
+	})
+	s = mdBold.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdBold.FindStringSubmatch(m)
+		if !r.color {
+			return parts[1] + parts[2]
+		}
+		return ansiBold + parts[1] + parts[2] + ansiNoBold
+	})
+	s = mdItalic.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdItalic.FindStringSubmatch(m)
+		if !r.color {
+			return parts[1] + parts[2]
+		}
+		return ansiItalic + parts[1] + parts[2] + ansiNoItalic
+	})
+
+	for i, span := range spans {
+		code := span
+		if r.color {
+			code = ansiCode + span + ansiNoColor
+		}
+		s = strings.Replace(s, "\x00"+string(rune('0'+i))+"\x00", code, 1)
+	}
+	return s
+}
+
+// wrap writes s as lines no wider than the terminal, starting with first and
+// continuing with rest.
+func (r *mdRenderer) wrap(s, first, rest string) {
+	prefix := first
+	current := ""
+	for _, word := range strings.Fields(s) {
+		if current != "" && visibleWidth(prefix+current+" "+word) > r.width {
+			r.line(prefix + current)
+			prefix, current = rest, ""
+		}
+		if current == "" {
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	if current != "" || prefix == first {
+		r.line(prefix + current)
+	}
+}
+
+func (r *mdRenderer) codeBlock(code []string, lang string) {
+	r.blank()
+	for _, line := range code {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if r.color {
+			line = highlightCode(line, lang)
+		}
+		r.line("    " + line)
+	}
+	r.blank()
+}
+
+// Kinds of token highlightCode colors.
+const (
+	tokenComment = iota + 1
+	tokenString
+	tokenNumber
+	tokenKeyword
+)
+
+// codeTokens finds the comments, strings, numbers and common keywords in a
+// line of code. It knows no grammar, only which comment marker lang uses.
+// Each match is [start, end, kind].
+func codeTokens(line, lang string) [][3]int {
+	re := codeSlash
+	if hashCommentLanguages[lang] {
+		re = codeHash
+	}
+	var tokens [][3]int
+	for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+		kind := tokenKeyword
+		for group := tokenComment; group < tokenKeyword; group++ {
+			if m[2*group] >= 0 {
+				kind = group
+				break
+			}
+		}
+		tokens = append(tokens, [3]int{m[0], m[1], kind})
+	}
+	return tokens
+}
+
+// tokenStyles are the ANSI colors of each kind of token.
+var tokenStyles = map[int]string{
+	tokenComment: ansiDim,
+	tokenString:  ansiString,
+	tokenNumber:  ansiNumber,
+	tokenKeyword: ansiKeyword,
+}
+
+// highlightCode colors a line of code for the terminal.
+func highlightCode(line, lang string) string {
+	var b strings.Builder
+	last := 0
+	for _, token := range codeTokens(line, lang) {
+		b.WriteString(line[last:token[0]])
+		b.WriteString(tokenStyles[token[2]] + line[token[0]:token[1]] + ansiNoColor)
+		last = token[1]
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// visibleWidth is the number of characters s takes on screen.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// ttyColor reports whether Markdown written to tty should be styled: only
+// on a real terminal, and never when NO_COLOR is set or TERM is dumb.
+func ttyColor(tty io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := tty.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ttyWidth returns the width of tty, or a default when it can't be read.
+func ttyWidth(tty io.Writer) int {
+	_, cols, ok := ttySize(tty)
+	if !ok || cols <= 0 {
+		return defaultTTYWidth
+	}
+	return cols
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoMethods is returned for prompts when the operator allows no input
+// method at all.
+var ErrNoMethods = errors.New("no input methods are allowed on this server; the operator must allow tty or web")
+
+// methodAllowed reports whether the operator lets agents use method. A nil
+// Methods list allows every method.
+func (c Config) methodAllowed(method string) bool {
+	return c.Methods == nil || containsString(c.Methods, method)
+}
+
+// resolveMethod returns the method a prompt asking for method may use. A
+// disallowed method falls back to the first allowed one, reported by
+// substituted. auto counts as allowed when what it resolves to is, both
+// when tty and web are.
+func (c Config) resolveMethod(method string) (used string, substituted bool, err error) {
+	if c.Methods == nil {
+		return method, false, nil
+	}
+	if len(c.Methods) == 0 {
+		return "", false, ErrNoMethods
+	}
+	switch method {
+	case MethodAuto:
+		if resolved := ResolveMethod(method); c.methodAllowed(resolved) {
+			return resolved, false, nil
+		}
+	case MethodBoth:
+		if c.methodAllowed(MethodTTY) && c.methodAllowed(MethodWeb) {
+			return method, false, nil
+		}
+	default:
+		if c.methodAllowed(method) {
+			return method, false, nil
+		}
+	}
+	return c.Methods[0], true, nil
+}
+
+// restrictMethodSchema returns schema with its method property offering
+// only the allowed methods, or without it when none are. The registered
+// schema is left untouched.
+func (c Config) restrictMethodSchema(schema map[string]interface{}) map[string]interface{} {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if c.Methods == nil || properties["method"] == nil {
+		return schema
+	}
+
+	restricted := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		restricted[key] = value
+	}
+	props := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		props[key] = value
+	}
+	restricted["properties"] = props
+
+	if len(c.Methods) == 0 {
+		delete(props, "method")
+		return restricted
+	}
+	enum := append([]string{}, c.Methods...)
+	if c.methodAllowed(MethodTTY) && c.methodAllowed(MethodWeb) {
+		enum = append(enum, MethodAuto, MethodBoth)
+	}
+	props["method"] = map[string]interface{}{
+		"type":        "string",
+		"description": fmt.Sprintf("Input method, one of: %s", strings.Join(enum, ", ")),
+		"enum":        enum,
+		"default":     c.Methods[0],
+	}
+	return restricted
+}
+
+// usePromptMethod applies the method allowlist to prompt, noting in the
+// result of the tool call req whenever the requested method was replaced.
+func (s *MCPServer) usePromptMethod(req MCPRequest, prompt *PromptRequest) error {
+	used, substituted, err := s.currentConfig().resolveMethod(prompt.Method)
+	if err != nil {
+		return err
+	}
+	if substituted {
+		s.addResultMeta(req.ID, "methodSubstitution", map[string]interface{}{
+			"requested": prompt.Method,
+			"used":      used,
+		})
+	}
+	prompt.Method = used
+	return nil
+}
+
+// addResultMeta sets key in the _meta of the result sent next for id.
+func (s *MCPServer) addResultMeta(id interface{}, key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resultMeta == nil {
+		s.resultMeta = make(map[interface{}]map[string]interface{})
+	}
+	if s.resultMeta[id] == nil {
+		s.resultMeta[id] = make(map[string]interface{})
+	}
+	s.resultMeta[id][key] = value
+}
+
+// takeResultMeta removes and returns the _meta entries noted for id.
+func (s *MCPServer) takeResultMeta(id interface{}) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	meta := s.resultMeta[id]
+	delete(s.resultMeta, id)
+	return meta
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Whitespace trimming modes applied to answers.
+const (
+	TrimBoth     = "both"
+	TrimTrailing = "trailing"
+	TrimNone     = "none"
+)
+
+// defaultTrim preserves each method's historical behavior: the terminal
+// trimmed answers, the web form returned them untouched.
+func defaultTrim(method string) string {
+	if method == "web" {
+		return TrimNone
+	}
+	return TrimBoth
+}
+
+// normalizeAnswer removes a common indent (when dedent is set) and then trims
+// whitespace according to trim.
+func normalizeAnswer(text string, trim string, dedent bool) string {
+	if dedent {
+		text = dedentText(text)
+	}
+
+	switch trim {
+	case TrimBoth:
+		return strings.TrimSpace(text)
+	case TrimTrailing:
+		return strings.TrimRightFunc(text, unicode.IsSpace)
+	default:
+		return text
+	}
+}
+
+// dedentText strips the longest run of leading spaces and tabs shared by every
+// non-blank line.
+func dedentText(text string) string {
+	lines := strings.Split(text, "\n")
+
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix = indent
+			first = false
+			continue
+		}
+		prefix = commonPrefix(prefix, indent)
+	}
+
+	if prefix == "" {
+		return text
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			lines[i] = line[len(prefix):]
+		} else {
+			// blank lines may carry less whitespace than the common indent
+			lines[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func commonPrefix(a, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// binaryAnswer reports whether an answer will be returned base64-encoded, in
+// which case it must not be normalized.
+func binaryAnswer(prompt *PromptRequest, response string) bool {
+	return prompt.Encoding == EncodingBase64 || (prompt.Encoding == "" && !utf8.ValidString(response))
+}
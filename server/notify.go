@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// notifyGracePeriod is how long a notification page is served if the
+// browser never loads it.
+const notifyGracePeriod = 30 * time.Second
+
+// notifyLingerTime keeps the server up briefly after the page was loaded, so
+// the browser can fetch anything else it asks for.
+const notifyLingerTime = 2 * time.Second
+
+// Notifier is implemented by providers that can show the user a message
+// without waiting for a reply. Notify must return without waiting for the
+// user.
+type Notifier interface {
+	Notify(ctx context.Context, req *PromptRequest) error
+}
+
+func (p ttyProvider) Notify(ctx context.Context, req *PromptRequest) error {
+	tty, err := p.open()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	if req.alert().Reminders {
+		fmt.Fprint(tty, "\a")
+	}
+	_, err = fmt.Fprintf(tty, "\n[notification] %s\n", req.Prompt)
+	return err
+}
+
+func (webProvider) Notify(ctx context.Context, req *PromptRequest) error {
+	served := make(chan struct{})
+	var once sync.Once
+
+	token, err := newWebToken()
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+token+"/", func(w http.ResponseWriter, r *http.Request) {
+		renderTemplate(w, http.StatusOK, "notify.html", nil, req)
+		once.Do(func() { close(served) })
+	})
+
+	l := currentWebListener()
+	server, url, err := startWebServer(l, mux, make(chan struct{}, 1))
+	if err != nil {
+		return err
+	}
+	showInBrowser(url+l.BasePath+"/"+token+"/", "notification", req.alert())
+
+	// Nothing waits for the user; the server goes away on its own
+	go func() {
+		select {
+		case <-served:
+			time.Sleep(notifyLingerTime)
+		case <-time.After(notifyGracePeriod):
+			fmt.Fprintf(os.Stderr, "Notification page was not opened on %s\n", url)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	return nil
+}
+
+func (s *MCPServer) handleNotifyUserTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	message, ok := args["message"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid message parameter")
+		return
+	}
+
+	priority, err := parsePriority(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	notification := NewPromptRequest(message, promptMethod(args))
+	notification.Priority = priority
+	s.applyAlert(notification)
+	if err := s.usePromptMethod(req, notification); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to notify the user: %v", err))
+		return
+	}
+	method := ResolveMethod(notification.Method)
+	notification.Method = method
+	notifier, ok := s.provider(method).(Notifier)
+	if !ok {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Method %s cannot show notifications", method))
+		return
+	}
+
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to notify the user: %v", err))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(fmt.Sprintf("Notification shown to the user (%s)", method)),
+		},
+		"structuredContent": map[string]interface{}{
+			"delivered": true,
+			"method":    method,
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"prompt-mcp/units"
+)
+
+// Answer types of the user_input tool.
+const (
+	TypeText    = "text"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypePath    = "path"
+)
+
+// numberFormat is the one accepted way of writing a number: '.' as the
+// decimal separator, no grouping, optional exponent.
+var numberFormat = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+// NumberOptions bounds a numeric prompt. Nil bounds are open. With Units
+// set (see package units), answers are quantities such as "5m" or "1.5GB",
+// and the bounds are in the canonical unit: seconds, bytes or a fraction.
+// Duration answers (TypeDuration) have Units set too.
+type NumberOptions struct {
+	Integer  bool
+	Minimum  *float64
+	Maximum  *float64
+	Units    string
+	Duration *DurationOptions
+}
+
+// ParseNumber parses s in the canonical number format, or as a quantity of
+// the options' units, and checks it against the options.
+func (o *NumberOptions) ParseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	n, err := o.parse(s)
+	if err != nil {
+		return 0, err
+	}
+
+	// Durations name the whole window: "between 30s and 24h"
+	if o.Duration != nil && (o.Minimum != nil && n < *o.Minimum || o.Maximum != nil && n > *o.Maximum) {
+		return 0, fmt.Errorf("Please enter a duration %s", o.window())
+	}
+
+	// Quantities are checked in their own terms: "at least 1m0s"
+	quantity := "a number of "
+	if o.Units != "" {
+		quantity = ""
+	}
+	if o.Integer && n != math.Trunc(n) {
+		return 0, fmt.Errorf("Please enter a whole number")
+	}
+	if o.Minimum != nil && n < *o.Minimum {
+		return 0, fmt.Errorf("Please enter %sat least %s", quantity, o.format(*o.Minimum))
+	}
+	if o.Maximum != nil && n > *o.Maximum {
+		return 0, fmt.Errorf("Please enter %sat most %s", quantity, o.format(*o.Maximum))
+	}
+	return n, nil
+}
+
+func (o *NumberOptions) parse(s string) (float64, error) {
+	if o.Duration != nil {
+		return o.Duration.parse(s)
+	}
+	if o.Units != "" {
+		return units.Parse(o.Units, s)
+	}
+	if !numberFormat.MatchString(s) {
+		if strings.Contains(s, ",") {
+			return 0, fmt.Errorf("%q is not a number: use '.' as the decimal separator and no thousands separators (e.g. 1234.5)", s)
+		}
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("%q is out of range", s)
+	}
+	return n, nil
+}
+
+// format writes a bound the way the user would type it.
+func (o *NumberOptions) format(n float64) string {
+	if o.Duration != nil {
+		return units.FormatDuration(n)
+	}
+	if o.Units != "" {
+		return units.Format(o.Units, n)
+	}
+	return formatNumber(n)
+}
+
+// Examples lists accepted ways of writing a quantity, e.g. "90s, 5m,
+// 1h30m", or "" for plain numbers.
+func (o *NumberOptions) Examples() string {
+	return strings.Join(units.Examples(o.Units), ", ")
+}
+
+// bounds describes the accepted range for prompts, or "" when unbounded.
+func (o *NumberOptions) bounds() string {
+	switch {
+	case o.Minimum != nil && o.Maximum != nil:
+		return fmt.Sprintf("%s-%s", o.format(*o.Minimum), o.format(*o.Maximum))
+	case o.Minimum != nil:
+		return fmt.Sprintf(">= %s", o.format(*o.Minimum))
+	case o.Maximum != nil:
+		return fmt.Sprintf("<= %s", o.format(*o.Maximum))
+	}
+	return ""
+}
+
+// MakeNumeric turns a text prompt into a numeric one. Answers are returned
+// in canonical form, e.g. "1.5" for "+1.50"; quantities are returned as the
+// user wrote them, and normalized by the caller.
+func (r *PromptRequest) MakeNumeric(opts NumberOptions) {
+	r.Kind = KindNumber
+	r.Number = &opts
+	r.Validate = func(response string) (string, error) {
+		if strings.TrimSpace(response) == "" && r.Default != "" {
+			return response, nil
+		}
+		n, err := opts.ParseNumber(response)
+		if err != nil {
+			return "", err
+		}
+		if opts.Units != "" {
+			return strings.TrimSpace(response), nil
+		}
+		return formatNumber(n), nil
+	}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// optionalNumber returns a numeric argument, or nil when it is absent.
+func optionalNumber(args map[string]interface{}, name string) (*float64, error) {
+	value, exists := args[name]
+	if !exists || value == nil {
+		return nil, nil
+	}
+	n, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s parameter: must be a number", name)
+	}
+	return &n, nil
+}
+
+// parseNumberOptions reads the numeric arguments of user_input for answer
+// type typ.
+func parseNumberOptions(args map[string]interface{}, typ string) (NumberOptions, error) {
+	opts := NumberOptions{Integer: typ == TypeInteger}
+
+	var err error
+	if opts.Minimum, err = optionalNumber(args, "minimum"); err != nil {
+		return opts, err
+	}
+	if opts.Maximum, err = optionalNumber(args, "maximum"); err != nil {
+		return opts, err
+	}
+	if opts.Minimum != nil && opts.Maximum != nil && *opts.Minimum > *opts.Maximum {
+		return opts, fmt.Errorf("Invalid bounds: minimum %s is above maximum %s", formatNumber(*opts.Minimum), formatNumber(*opts.Maximum))
+	}
+
+	kind, present, err := optionalString(args, "units")
+	switch {
+	case err != nil:
+		return opts, err
+	case present && !units.Valid(kind):
+		return opts, fmt.Errorf("Invalid units parameter: must be one of %s", strings.Join(units.Kinds(), ", "))
+	}
+	opts.Units = kind
+	return opts, nil
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Observer event types.
+const (
+	EventPromptCreated  = "prompt_created"
+	EventPromptResolved = "prompt_resolved"
+)
+
+// Outcomes reported in prompt_resolved events.
+const (
+	OutcomeAnswered = "answered"
+	OutcomeTimeout  = "timeout"
+	OutcomeError    = "error"
+	OutcomeDeclined = "declined"
+
+	// OutcomeCached is only kept in the history: the prompt was answered
+	// from the cache, so observers never saw it
+	OutcomeCached = "cached"
+)
+
+// observerBuffer is how many events may queue for an observer before it is
+// considered too slow and disconnected.
+const observerBuffer = 64
+
+// ObserverEvent is one line of the newline-delimited JSON stream sent to
+// observers. Answers are only included when the hub was created with
+// includeAnswers.
+type ObserverEvent struct {
+	Type     string    `json:"type"`
+	PromptID int64     `json:"prompt_id"`
+	Prompt   string    `json:"prompt,omitempty"`
+	Method   string    `json:"method,omitempty"`
+	Client   string    `json:"client,omitempty"`
+	Outcome  string    `json:"outcome,omitempty"`
+	Response string    `json:"response,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Replay marks prompt_created events re-sent to an observer that
+	// connected while the prompt was already pending.
+	Replay bool `json:"replay,omitempty"`
+}
+
+// ObserverHub fans prompt lifecycle events out to read-only observers.
+type ObserverHub struct {
+	includeAnswers bool
+
+	mu        sync.Mutex
+	observers map[*observer]struct{}
+	pending   map[int64]ObserverEvent
+	listener  net.Listener
+}
+
+type observer struct {
+	conn   net.Conn
+	events chan ObserverEvent
+	once   sync.Once
+}
+
+func NewObserverHub(includeAnswers bool) *ObserverHub {
+	return &ObserverHub{
+		includeAnswers: includeAnswers,
+		observers:      make(map[*observer]struct{}),
+		pending:        make(map[int64]ObserverEvent),
+	}
+}
+
+// ListenObservers creates a hub accepting observers on a unix socket at path,
+// replacing a stale socket file left by a previous run.
+func ListenObservers(path string, includeAnswers bool) (*ObserverHub, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	hub := NewObserverHub(includeAnswers)
+	go hub.Serve(listener)
+	return hub, nil
+}
+
+// Serve accepts observer connections until the listener is closed.
+func (h *ObserverHub) Serve(listener net.Listener) error {
+	h.mu.Lock()
+	h.listener = listener
+	h.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		h.Attach(conn)
+	}
+}
+
+// Attach starts streaming events to conn, beginning with a replay of every
+// pending prompt.
+func (h *ObserverHub) Attach(conn net.Conn) {
+	o := &observer{
+		conn:   conn,
+		events: make(chan ObserverEvent, observerBuffer),
+	}
+
+	h.mu.Lock()
+	pending := make([]ObserverEvent, 0, len(h.pending))
+	for _, ev := range h.pending {
+		ev.Replay = true
+		pending = append(pending, ev)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].PromptID < pending[j].PromptID })
+	for _, ev := range pending {
+		o.send(ev)
+	}
+	h.observers[o] = struct{}{}
+	h.mu.Unlock()
+
+	go h.write(o)
+
+	// Observers are read-only: anything they send is discarded, and EOF means
+	// they went away
+	go func() {
+		io.Copy(io.Discard, conn)
+		h.remove(o)
+	}()
+}
+
+// Publish sends an event to every observer without blocking. Observers whose
+// queue is full are disconnected.
+func (h *ObserverHub) Publish(ev ObserverEvent) {
+	if h == nil {
+		return
+	}
+	if !h.includeAnswers {
+		ev.Response = ""
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch ev.Type {
+	case EventPromptCreated:
+		h.pending[ev.PromptID] = ev
+	case EventPromptResolved:
+		delete(h.pending, ev.PromptID)
+	}
+
+	for o := range h.observers {
+		if !o.send(ev) {
+			delete(h.observers, o)
+			o.close()
+		}
+	}
+}
+
+// Close stops accepting observers and disconnects the connected ones.
+func (h *ObserverHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.listener != nil {
+		h.listener.Close()
+	}
+	for o := range h.observers {
+		delete(h.observers, o)
+		o.close()
+	}
+}
+
+func (h *ObserverHub) write(o *observer) {
+	encoder := json.NewEncoder(o.conn)
+	for ev := range o.events {
+		if err := encoder.Encode(ev); err != nil {
+			h.remove(o)
+			return
+		}
+	}
+	o.conn.Close()
+}
+
+func (h *ObserverHub) remove(o *observer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.observers[o]; ok {
+		delete(h.observers, o)
+	}
+	o.close()
+}
+
+func (o *observer) send(ev ObserverEvent) bool {
+	select {
+	case o.events <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *observer) close() {
+	o.once.Do(func() {
+		close(o.events)
+		// Unblock a writer stuck on a slow connection
+		o.conn.Close()
+	})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"prompt-mcp/units"
+)
+
+// formOverhead is room in a form body for the fields besides the answer,
+// such as the form token and the remember checkbox.
+const formOverhead = 64 * 1024
+
+// pasteLong is the length from which text pasted into a one-line answer
+// turns it into a textarea, even without a line break.
+const pasteLong = 500
+
+// webPaste is the size cap of a text answer, which the page counts pasted
+// and dropped text against.
+type webPaste struct {
+	MaxBytes int64
+	Limit    string
+	Long     int
+}
+
+// answerLimit is the largest answer the web form takes, in bytes.
+func (r *PromptRequest) answerLimit() int64 {
+	if r.MaxAnswerBytes <= 0 {
+		return defaultMaxAnswerBytes
+	}
+	return r.MaxAnswerBytes
+}
+
+// answerLimitText describes the cap, e.g. "1 MiB".
+func (r *PromptRequest) answerLimitText() string {
+	return units.Format(units.Bytes, float64(r.answerLimit()))
+}
+
+// pasteable reports whether the answer is free text that may be pasted or
+// dropped in at length.
+func (r *PromptRequest) pasteable() bool {
+	return r.Kind == KindText && !r.Secret && r.Phrase == nil && len(r.Options) == 0
+}
+
+// tooLarge is the error shown for an answer over the cap.
+func (h *WebInputHandler) tooLarge() string {
+	return fmt.Sprintf("The answer is larger than %s; shorten it and try again", h.req.answerLimitText())
+}
+
+// readForm parses a submission, refusing a body too large to hold an
+// answer within the cap. Percent-encoding may triple the answer's size on
+// the way, so the body is allowed that much.
+func (h *WebInputHandler) readForm(w http.ResponseWriter, r *http.Request) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 3*h.req.answerLimit()+formOverhead)
+	err := r.ParseForm()
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		h.renderForm(w, r, http.StatusRequestEntityTooLarge, h.tooLarge(), "")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// phraseConflicts are the user_input arguments that make no sense for a
+// phrase confirmation.
+var phraseConflicts = []string{"pattern", "type", "response_schema", "multiline", "secret", "confirm_secret", "default", "default_response", "allow_empty", "expected_length"}
+
+// ConfirmationPhrase is the text a user must type to approve a dangerous
+// action.
+type ConfirmationPhrase struct {
+	Phrase     string
+	IgnoreCase bool
+}
+
+func (p *ConfirmationPhrase) matches(response string) bool {
+	response = strings.TrimSpace(response)
+	if p.IgnoreCase {
+		return strings.EqualFold(response, p.Phrase)
+	}
+	return response == p.Phrase
+}
+
+// RequirePhrase turns the prompt into a confirmation that is approved only
+// by typing phrase. The answer is "yes" for the phrase and "no" for an empty
+// response; anything else is asked again. Since Ask's attempt limit is what
+// ends a run of wrong phrases, the prompt always gets one, even when the
+// configuration asks until the answer is valid.
+func (r *PromptRequest) RequirePhrase(phrase ConfirmationPhrase) {
+	r.Kind = KindConfirm
+	r.Phrase = &phrase
+	r.Default = ""
+	r.Trim = TrimNone
+	r.Validate = func(response string) (string, error) {
+		if strings.TrimSpace(response) == "" {
+			return AnswerNo, nil
+		}
+		if !phrase.matches(response) {
+			return "", fmt.Errorf("That does not match. Type %q exactly to confirm, or leave it empty to deny", phrase.Phrase)
+		}
+		return AnswerYes, nil
+	}
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = defaultMaxAttempts
+	}
+}
+
+// parseConfirmationPhrase reads confirmation_phrase and case_insensitive,
+// returning nil when no phrase was given.
+func parseConfirmationPhrase(args map[string]interface{}) (*ConfirmationPhrase, error) {
+	phrase, present, err := optionalString(args, "confirmation_phrase")
+	if err != nil || !present {
+		return nil, err
+	}
+	if phrase == "" || phrase != strings.TrimSpace(phrase) {
+		return nil, fmt.Errorf("Invalid confirmation_phrase parameter: must be non-empty without surrounding whitespace")
+	}
+	ignoreCase, err := optionalBool(args, "case_insensitive", false)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfirmationPhrase{Phrase: phrase, IgnoreCase: ignoreCase}, nil
+}
+
+// handlePhrasePrompt asks for a confirmation phrase and reports whether the
+// action was approved. Running out of attempts is a denial, not an error,
+// so the agent always gets a decision; so is a timeout when the prompt has
+// a TimeoutResponse. The prompt must have been made with RequirePhrase.
+func (s *MCPServer) handlePhrasePrompt(req MCPRequest, promptReq *PromptRequest, progressToken interface{}) {
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	exhausted := isAttemptsExhausted(err)
+	if exhausted {
+		answer, err = AnswerNo, nil
+	}
+	timedOut := false
+	if errors.Is(err, ErrTimeout) && promptReq.TimeoutResponse != nil {
+		answer, err, timedOut = *promptReq.TimeoutResponse, nil, true
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	structured := map[string]interface{}{
+		"answer":            answer,
+		"confirmed":         answer == AnswerYes,
+		"approved":          answer == AnswerYes,
+		"attemptsExhausted": exhausted,
+	}
+	text := answer
+	switch {
+	case exhausted:
+		text = fmt.Sprintf("no (the confirmation phrase was not entered within %d attempts)", promptReq.MaxAttempts)
+	case timedOut:
+		structured["timedOut"] = true
+		text = timedOutText(answer, promptReq.Timeout)
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Plan review decisions.
+const (
+	PlanApprove = "approve"
+	PlanDeny    = "deny"
+	PlanRevise  = "revise"
+)
+
+// PlanReview is the user's verdict on a plan. Instructions say what to
+// change and are only kept for PlanRevise.
+type PlanReview struct {
+	Decision     string `json:"decision"`
+	Instructions string `json:"instructions,omitempty"`
+}
+
+// NewPlanReviewPrompt returns a prompt showing plan for approval. Providers
+// answer with a PlanReview encoded as JSON.
+func NewPlanReviewPrompt(prompt, method, plan string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindPlanReview
+	req.Content = plan
+	req.Trim = TrimNone
+	req.Validate = validatePlanReview
+	return req
+}
+
+func validatePlanReview(response string) (string, error) {
+	var review PlanReview
+	if err := json.Unmarshal([]byte(response), &review); err != nil {
+		return "", fmt.Errorf("Invalid plan review response: %v", err)
+	}
+	review.Instructions = strings.TrimSpace(review.Instructions)
+
+	switch review.Decision {
+	case PlanApprove, PlanDeny:
+		review.Instructions = ""
+	case PlanRevise:
+		if review.Instructions == "" {
+			return "", fmt.Errorf("Please describe the changes you want")
+		}
+	default:
+		return "", fmt.Errorf("Please choose approve, deny or revise")
+	}
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runTTYPlanReview pages through the plan, then asks for a decision and,
+// for a revision, what to change.
+func runTTYPlanReview(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	if err := pageTTYContent(tty, scanner, req.Content); err != nil {
+		return "", err
+	}
+
+	return readTTYLine(tty, scanner, "[a]pprove, [d]eny, [r]evise: ", func(response string) (string, error) {
+		review := PlanReview{}
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "a", "approve":
+			review.Decision = PlanApprove
+		case "d", "deny":
+			review.Decision = PlanDeny
+		case "r", "revise":
+			instructions, err := readTTYLine(tty, scanner, "What should change? ", nil)
+			if err != nil {
+				return "", err
+			}
+			review = PlanReview{Decision: PlanRevise, Instructions: instructions}
+		default:
+			return "", fmt.Errorf("Please enter a, d or r")
+		}
+
+		data, err := json.Marshal(review)
+		if err != nil {
+			return "", err
+		}
+		return req.Validate(string(data))
+	})
+}
+
+func (s *MCPServer) handleUserPlanReviewTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	plan, ok := args["plan"].(string)
+	if !ok || strings.TrimSpace(plan) == "" {
+		s.sendError(req.ID, -32602, "Missing or invalid plan parameter")
+		return
+	}
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please review this plan"
+	}
+	timeout, err := optionalTimeout(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewPlanReviewPrompt(prompt, promptMethod(args), plan)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var review PlanReview
+	if err := json.Unmarshal([]byte(answer), &review); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode plan review: %v", err))
+		return
+	}
+
+	text := review.Decision
+	if review.Instructions != "" {
+		text += ": " + review.Instructions
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": map[string]interface{}{
+			"decision":     review.Decision,
+			"approved":     review.Decision == PlanApprove,
+			"instructions": review.Instructions,
+		},
+		"isError": false,
+	})
+}
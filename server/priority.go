@@ -0,0 +1,114 @@
+package server
+
+import "fmt"
+
+// Prompt priorities, set with the priority argument. Unlike urgency, which
+// styles a prompt by how much the answer matters, priority decides how hard
+// the server tries to get the user's attention.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// Alert is what the server does to get the user's attention for a prompt.
+type Alert struct {
+	// Bell rings the terminal bell as soon as the prompt is shown
+	Bell bool `json:"bell"`
+
+	// Reminders rings the bell for timeout warnings and notifications
+	Reminders bool `json:"reminders"`
+
+	// Focus opens the browser for web prompts, taking focus; without it
+	// the URL is only printed
+	Focus bool `json:"focus"`
+
+	// Emphasis bolds the terminal prompt and badges the page title and
+	// favicon
+	Emphasis bool `json:"emphasis"`
+}
+
+// AlertPolicy maps each priority to its alert.
+type AlertPolicy struct {
+	Low    Alert `json:"low"`
+	Normal Alert `json:"normal"`
+	High   Alert `json:"high"`
+}
+
+// DefaultAlertPolicy keeps low prompts silent and in the background,
+// normal prompts as they always were, and makes high prompts loud.
+func DefaultAlertPolicy() AlertPolicy {
+	return AlertPolicy{
+		Low:    Alert{},
+		Normal: Alert{Reminders: true, Focus: true},
+		High:   Alert{Bell: true, Reminders: true, Focus: true, Emphasis: true},
+	}
+}
+
+// For returns the alert for priority; empty or unknown means normal.
+func (p AlertPolicy) For(priority string) Alert {
+	switch priority {
+	case PriorityLow:
+		return p.Low
+	case PriorityHigh:
+		return p.High
+	default:
+		return p.Normal
+	}
+}
+
+// SetAlertPolicy replaces the default mapping of priorities to alerts.
+func (s *MCPServer) SetAlertPolicy(policy AlertPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = &policy
+}
+
+func (s *MCPServer) alertPolicy() AlertPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.alerts == nil {
+		return DefaultAlertPolicy()
+	}
+	return *s.alerts
+}
+
+// applyAlert settles how prompt gets the user's attention under the
+// server's policy.
+func (s *MCPServer) applyAlert(prompt *PromptRequest) {
+	alert := s.alertPolicy().For(prompt.Priority)
+	prompt.Alert = &alert
+}
+
+// alert returns the prompt's alert, falling back to the default policy for
+// prompts that never went through a server, such as those of the ask
+// command.
+func (r *PromptRequest) alert() Alert {
+	if r.Alert != nil {
+		return *r.Alert
+	}
+	return DefaultAlertPolicy().For(r.Priority)
+}
+
+// parsePriority reads the priority argument.
+func parsePriority(args map[string]interface{}) (string, error) {
+	priority, _, err := optionalString(args, "priority")
+	if err != nil {
+		return "", err
+	}
+	switch priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh:
+		return priority, nil
+	default:
+		return "", fmt.Errorf("Invalid priority parameter: must be 'low', 'normal' or 'high'")
+	}
+}
+
+func prioritySchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "How hard to get the user's attention: 'low' never rings the bell or opens a browser window (the URL is only printed), 'high' rings the bell and makes the prompt stand out",
+		"enum":        []string{PriorityLow, PriorityNormal, PriorityHigh},
+		"default":     PriorityNormal,
+	}
+}
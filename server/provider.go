@@ -0,0 +1,392 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrTimeout is returned when the user doesn't answer before the prompt's
+// timeout.
+var ErrTimeout = errors.New("timed out waiting for user input")
+
+// ErrAnsweredElsewhere is the cause to cancel a prompt's context with when
+// another method got the answer first, so a page still showing the prompt
+// can say so.
+var ErrAnsweredElsewhere = errors.New("answered elsewhere")
+
+// ErrWithdrawn is the cause a prompt's context is cancelled with when the
+// client cancels the tool call that asked it, so the page and terminal can
+// say the agent withdrew the question.
+var ErrWithdrawn = errors.New("withdrawn by the client")
+
+// TimeoutError is the error Ask returns when a prompt times out. It matches
+// ErrTimeout with errors.Is.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for user input", formatSeconds(e.Timeout))
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// formatSeconds writes d as a number of seconds, e.g. "30s" or "0.5s".
+func formatSeconds(d time.Duration) string {
+	return formatNumber(d.Seconds()) + "s"
+}
+
+// Input methods. MethodAuto resolves to tty when a controlling terminal is
+// available and web otherwise. MethodBoth asks on the terminal and a web
+// page at once, taking the first answer.
+const (
+	MethodTTY  = "tty"
+	MethodWeb  = "web"
+	MethodAuto = "auto"
+	MethodBoth = "both"
+)
+
+// Prompt kinds, which decide how providers render a prompt.
+const (
+	KindText     = ""
+	KindChoice   = "choice"
+	KindConfirm  = "confirm"
+	KindForm     = "form"
+	KindFile     = "file"
+	KindReview   = "review"
+	KindNumber   = "number"
+	KindEdit     = "edit"
+	KindRating   = "rating"
+	KindDateTime = "datetime"
+	KindAck      = "ack"
+	KindRank     = "rank"
+
+	KindPlanReview  = "plan_review"
+	KindCredentials = "credentials"
+	KindRead        = "read"
+	KindReaction    = "reaction"
+	KindList        = "list"
+	KindUpload      = "upload"
+)
+
+// Prompt urgencies, which providers use to tell routine questions from
+// dangerous ones.
+const (
+	UrgencyLow      = "low"
+	UrgencyNormal   = "normal"
+	UrgencyCritical = "critical"
+)
+
+// PromptRequest describes a single question put to the user.
+type PromptRequest struct {
+	ID      int64
+	Kind    string
+	Prompt  string
+	Options []string
+
+	// Title heads the prompt and Detail is background shown with it. Urgency
+	// is one of the Urgency constants, or empty for normal.
+	Title   string
+	Detail  string
+	Urgency string
+
+	// Priority is one of the Priority constants, or empty for normal.
+	// Alert is what it means under the server's policy; nil uses the
+	// default policy.
+	Priority string
+	Alert    *Alert
+
+	// Locale selects the language of the labels and buttons around the
+	// prompt, e.g. "de" or "ja_JP.UTF-8". Empty uses the server's locale.
+	// NegotiateLocale lets web pages use the browser's language instead,
+	// when it has a catalog.
+	Locale          string
+	NegotiateLocale bool
+
+	// Client is the MCP client that asked, shown with ID as "question N"
+	// of the session; nil is an unknown client
+	Client *ClientInfo
+
+	// Format is FormatMarkdown when Prompt and Detail are Markdown, which the
+	// terminal renders. Empty means plain text.
+	Format string
+
+	// Phrase, when set, makes a confirm prompt approvable only by typing
+	// the phrase.
+	Phrase *ConfirmationPhrase
+
+	// Partial, when set on a form prompt, receives each answer as the user
+	// gives it, so a timeout can return what was answered.
+	Partial *PartialAnswers
+
+	// Transforms normalize each answer, in order, before it is validated.
+	// Raw, when set, receives the accepted answer as the user gave it.
+	Transforms []string
+	Raw        *RawAnswer
+
+	// Service names what a credentials prompt signs in to.
+	Service string
+
+	// Images are shown with the prompt: inline in the browser, as temp
+	// files on the terminal.
+	Images []Image
+	// Context is the conversation leading up to the prompt, shown on
+	// request and never returned.
+	Context *Conversation
+	// Attachments are read-only text files shown as context before the
+	// question is answered.
+	Attachments []Attachment
+
+	// Reactions are the quick reactions a reaction prompt offers, in the
+	// order of Options.
+	Reactions []Reaction
+
+	// SingleKey answers a choice or confirm prompt on the terminal with one
+	// keypress, where the terminal can deliver single keys.
+	SingleKey bool
+
+	// MultiSelect lets a choice prompt pick several of its options.
+	MultiSelect bool
+
+	// AllowOther adds an "Other…" entry to a single choice prompt, answered
+	// with free text. Its answer is then a ChoiceAnswer.
+	AllowOther bool
+
+	// Default is the answer an empty response stands for: "yes" or "no" for
+	// confirm prompts, any text for text prompts.
+	Default string
+
+	// Pattern and AllowedValues are what the validators made by
+	// NewPatternValidator and NewAllowedValuesValidator check, kept so the
+	// web form can check them too before submitting.
+	Pattern       string
+	AllowedValues []string
+
+	Fields    []FormField
+	File      *FileOptions
+	Upload    *UploadOptions
+	Content   string
+	Extension string
+	Number    *NumberOptions
+	Length    *LengthLimits
+	Rating    *RatingOptions
+	Rank      *RankOptions
+	List      *ListOptions
+	DateTime  *DateTimeOptions
+	Method    string
+	Secret    bool
+	Multiline bool
+	Timeout   time.Duration
+	Delivery  string
+	Encoding  string
+	Trim      string
+	Dedent    bool
+
+	// TimeoutResponse, when set, is the answer used if the prompt times out.
+	// Providers show it; the tool handler substitutes it for ErrTimeout.
+	TimeoutResponse *string
+
+	// Asked is when the agent asked, which may be a while before a provider
+	// shows the question. Zero is when the provider does.
+	Asked time.Time
+
+	// AllowEmpty accepts an empty answer to a prompt without a default.
+	// Otherwise providers ask again, provided Validate was wrapped with
+	// RequireAnswer.
+	AllowEmpty bool
+
+	// MaxAttempts limits how many answers may fail Validate before Ask gives
+	// up with a *ValidationError. Zero or negative means no limit.
+	MaxAttempts int
+
+	// MaxAnswerBytes caps the text answers the web form accepts, such as a
+	// pasted log. Zero means the default.
+	MaxAnswerBytes int64
+
+	// Attempts, set by Ask under MaxAttempts, counts the invalid answers
+	// given so far.
+	Attempts *Attempts
+
+	// ConfirmSecret asks for a secret twice and only accepts matching
+	// entries.
+	ConfirmSecret bool
+
+	// LongAnswer gives a one-line text prompt a textarea in the browser,
+	// where the answer may still span lines. The terminal reads one line.
+	LongAnswer bool
+
+	// Placeholder is a hint shown in the empty answer field. Unlike
+	// Default, it is never the answer.
+	Placeholder string
+
+	// SubmitLabel and DeclineLabel replace the Submit and Decline buttons'
+	// text, and name them in the terminal's hint; empty keeps the defaults.
+	SubmitLabel  string
+	DeclineLabel string
+
+	// Suggestions are likely answers offered as shortcuts; any other text
+	// is still accepted.
+	Suggestions []string
+
+	// Remember, when set, lets the user ask for the answer to be reused for
+	// the same question. CacheBypass asks the user even when an answer is
+	// remembered.
+	Remember    *Remember
+	CacheBypass bool
+
+	// RequireConfirmation shows the user the answer as it will be returned
+	// and returns it only once they confirm it; otherwise they edit it.
+	RequireConfirmation bool
+
+	// Sensitive keeps the answer out of logs, traces, observer events and
+	// pages shown after submitting, while still returning it to the agent.
+	Sensitive bool
+
+	// Validate, when set, checks a raw response and returns the canonical
+	// answer. Providers show the error and ask again when it fails.
+	Validate func(response string) (string, error)
+}
+
+// NewPromptRequest returns a prompt for the given method with the same
+// defaults the user_input tool applies.
+func NewPromptRequest(prompt, method string) *PromptRequest {
+	method = ResolveMethod(method)
+
+	req := &PromptRequest{
+		Prompt: prompt,
+		Method: method,
+		Trim:   defaultTrim(method),
+	}
+	if method == MethodWeb || method == MethodBoth {
+		req.Timeout = defaultWebTimeout
+	}
+	return req
+}
+
+// ResolveMethod maps a requested input method onto one of the providers.
+// Unknown methods fall back to tty.
+func ResolveMethod(method string) string {
+	switch method {
+	case MethodWeb, MethodBoth:
+		return method
+	case MethodAuto:
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return MethodWeb
+		}
+		tty.Close()
+		return MethodTTY
+	default:
+		return MethodTTY
+	}
+}
+
+// InputProvider collects the user's response to a prompt. Implementations must
+// give up when ctx is done.
+type InputProvider interface {
+	GetInput(ctx context.Context, req *PromptRequest) (string, error)
+}
+
+// TimeoutWarner is implemented by providers that can get the user's attention
+// when a prompt is about to time out.
+type TimeoutWarner interface {
+	WarnTimeout(req *PromptRequest, remaining time.Duration)
+}
+
+type webProvider struct{}
+
+func (webProvider) GetInput(ctx context.Context, req *PromptRequest) (string, error) {
+	return getUserInputFromWeb(ctx, req)
+}
+
+func (webProvider) WarnTimeout(req *PromptRequest, remaining time.Duration) {
+	if req.alert().Reminders {
+		ringBell()
+	}
+}
+
+// DefaultProvider returns the built-in provider for an input method.
+func DefaultProvider(method string) InputProvider {
+	switch ResolveMethod(method) {
+	case MethodWeb:
+		return webProvider{}
+	case MethodBoth:
+		return NewRaceProvider(NewTTYProvider(nil), webProvider{})
+	default:
+		return NewTTYProvider(nil)
+	}
+}
+
+// Ask puts a prompt to the user through provider, enforcing the prompt's
+// timeout and normalizing the answer exactly as the user_input tool does. It
+// needs no MCP session, so the CLI can use it directly.
+func Ask(ctx context.Context, provider InputProvider, prompt *PromptRequest) (string, error) {
+	if prompt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, prompt.Timeout)
+		defer cancel()
+	}
+
+	if prompt.Validate != nil && prompt.MaxAttempts > 0 {
+		limited := *prompt
+		limited.Attempts = &Attempts{max: prompt.MaxAttempts, secret: prompt.Secret || prompt.Sensitive}
+		limited.Validate = limitAttempts(prompt.Validate, limited.Attempts)
+		prompt = &limited
+	}
+
+	response, err := provider.GetInput(ctx, prompt)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &TimeoutError{Timeout: prompt.Timeout}
+		}
+		return "", err
+	}
+
+	return returnedAnswer(prompt, response), nil
+}
+
+// returnedAnswer is response as Ask returns it for prompt.
+func returnedAnswer(prompt *PromptRequest, response string) string {
+	// Binary answers are returned byte-for-byte
+	if !binaryAnswer(prompt, response) {
+		response = normalizeAnswer(response, prompt.Trim, prompt.Dedent)
+	}
+
+	// An empty answer to a text prompt accepts its default
+	if (prompt.Kind == KindText || prompt.Kind == KindNumber) && prompt.Default != "" && strings.TrimSpace(response) == "" {
+		response = prompt.Default
+	}
+	return response
+}
+
+// SetInputProvider replaces the provider used for an input method.
+func (s *MCPServer) SetInputProvider(method string, p InputProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.providers == nil {
+		s.providers = make(map[string]InputProvider)
+	}
+	s.providers[method] = p
+}
+
+func (s *MCPServer) provider(method string) InputProvider {
+	s.mu.Lock()
+	p, ok := s.providers[method]
+	s.mu.Unlock()
+	if ok {
+		return p
+	}
+
+	// Both races the providers of tty and web, whichever they are
+	if method == MethodBoth {
+		return NewRaceProvider(s.provider(MethodTTY), s.provider(MethodWeb))
+	}
+	return DefaultProvider(method)
+}
@@ -0,0 +1,368 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pushTTL is how long, in seconds, a push service keeps a notification
+	// for a browser that is offline; prompts rarely outlive it
+	pushTTL = 15 * 60
+
+	// pushSubject is the VAPID contact push services may reach the sender
+	// at
+	pushSubject = "https://github.com/ArKade523/prompt-user-mcp"
+
+	// pushRecordSize is the record size of the aes128gcm encoding; a
+	// payload always fits one record
+	pushRecordSize = 4096
+)
+
+// pushEncoding is base64url without padding, as Web Push keys and VAPID
+// tokens are written.
+var pushEncoding = base64.RawURLEncoding
+
+// PushSubscription is a browser's Web Push subscription, as
+// PushSubscription.toJSON() gives it.
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// PushMessage is a notification as the dashboard's service worker shows
+// it. URL is the page it opens when clicked, Tag the notification it
+// replaces.
+type PushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// Pusher sends Web Push notifications to the browsers subscribed to it,
+// signed with its VAPID key and encrypted for each browser (RFC 8291 and
+// 8292), so the push services in between see neither the prompt nor its
+// page. Subscriptions are kept in memory; the dashboard renews them every
+// time it is opened.
+type Pusher struct {
+	// Client sends the notifications; nil uses http.DefaultClient
+	Client *http.Client
+
+	key *ecdsa.PrivateKey
+
+	mu   sync.Mutex
+	subs map[string]PushSubscription
+}
+
+// DefaultPushKeyFile is where the VAPID key is kept unless configured
+// otherwise: prompt-mcp/vapid.pem in the user's config directory.
+func DefaultPushKeyFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "prompt-mcp", "vapid.pem"), nil
+}
+
+// LoadPusher returns a pusher signing with the VAPID key in path. The
+// first time, when there is no such file, a key is made and written there,
+// readable by the user only; browsers subscribed with a key must be sent
+// to with it, so it is kept across restarts.
+func LoadPusher(path string) (*Pusher, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return newPushKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the push key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, fmt.Errorf("push key %s is not a PEM EC private key", path)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("push key %s: %w", path, err)
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("push key %s is not on P-256", path)
+	}
+	return &Pusher{key: key, subs: make(map[string]PushSubscription)}, nil
+}
+
+// newPushKey makes a VAPID key and writes it to path.
+func newPushKey(path string) (*Pusher, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to save the push key: %w", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to save the push key: %w", err)
+	}
+	return &Pusher{key: key, subs: make(map[string]PushSubscription)}, nil
+}
+
+// PublicKey is the VAPID public key browsers subscribe with, as an
+// uncompressed point in base64url.
+func (p *Pusher) PublicKey() string {
+	public, err := p.key.PublicKey.ECDH()
+	if err != nil {
+		return ""
+	}
+	return pushEncoding.EncodeToString(public.Bytes())
+}
+
+// Subscribe adds or renews a browser's subscription. Endpoints must be
+// https URLs and the keys those of a P-256 point and a 16-byte secret.
+func (p *Pusher) Subscribe(sub PushSubscription) error {
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("push endpoint must be an https URL")
+	}
+	if _, _, err := sub.keys(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subs[sub.Endpoint] = sub
+	return nil
+}
+
+// Unsubscribe drops the subscription with endpoint, if there is one.
+func (p *Pusher) Unsubscribe(endpoint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subs, endpoint)
+}
+
+// Subscriptions is how many browsers are subscribed. A nil Pusher has
+// none.
+func (p *Pusher) Subscriptions() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.subs)
+}
+
+// Send pushes msg to every subscribed browser and waits for the push
+// services to take it. Subscriptions the push service says are gone, as
+// when the user revoked the permission, are dropped; other failures are
+// reported on stderr and the subscription kept. A nil Pusher sends
+// nothing.
+func (p *Pusher) Send(ctx context.Context, msg PushMessage) {
+	if p == nil {
+		return
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	subs := make([]PushSubscription, 0, len(p.subs))
+	for _, sub := range p.subs {
+		subs = append(subs, sub)
+	}
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := p.send(ctx, sub, payload)
+			switch {
+			case status == http.StatusNotFound || status == http.StatusGone:
+				p.Unsubscribe(sub.Endpoint)
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "Web push failed: %v\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// send posts payload, encrypted, to sub's push service and returns its
+// status.
+func (p *Pusher) send(ctx context.Context, sub PushSubscription, payload []byte) (int, error) {
+	body, err := sub.encrypt(payload)
+	if err != nil {
+		return 0, err
+	}
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+	token, err := p.vapidToken(u.Scheme + "://" + u.Host)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(pushTTL))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", "vapid t="+token+", k="+p.PublicKey())
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("push service %s answered %s", u.Host, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// vapidToken is the ES256 JWT identifying the sender to the push service
+// at audience, valid for twelve hours.
+func (p *Pusher) vapidToken(audience string) (string, error) {
+	header := pushEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": pushSubject,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + pushEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + pushEncoding.EncodeToString(sig), nil
+}
+
+// keys decodes the subscription's public key and authentication secret,
+// with or without the base64 padding some browsers add.
+func (sub PushSubscription) keys() (*ecdh.PublicKey, []byte, error) {
+	public, err := pushEncoding.DecodeString(strings.TrimRight(sub.Keys.P256dh, "="))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid push key: %w", err)
+	}
+	key, err := ecdh.P256().NewPublicKey(public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid push key: %w", err)
+	}
+	secret, err := pushEncoding.DecodeString(strings.TrimRight(sub.Keys.Auth, "="))
+	if err != nil || len(secret) != 16 {
+		return nil, nil, fmt.Errorf("invalid push secret")
+	}
+	return key, secret, nil
+}
+
+// encrypt encodes payload for the subscription's browser as a single
+// aes128gcm record (RFC 8188), keyed as RFC 8291 lays out.
+func (sub PushSubscription) encrypt(payload []byte) ([]byte, error) {
+	browserKey, secret, err := sub.keys()
+	if err != nil {
+		return nil, err
+	}
+	local, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := local.ECDH(browserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	prk, err := hkdf.Extract(sha256.New, shared, secret)
+	if err != nil {
+		return nil, err
+	}
+	info := "WebPush: info\x00" + string(browserKey.Bytes()) + string(local.PublicKey().Bytes())
+	ikm, err := hkdf.Expand(sha256.New, prk, info, 32)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err = hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// The last record ends in a 2 delimiter; the tag takes another 16
+	if len(payload)+1+gcm.Overhead() > pushRecordSize {
+		return nil, fmt.Errorf("push message too long")
+	}
+
+	keyID := local.PublicKey().Bytes()
+	header := make([]byte, 0, 16+4+1+len(keyID))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	record := append(append([]byte{}, payload...), 2)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AnsweredElsewhereError is the cause a prompt's context is cancelled with
+// when the prompt was answered through another method, so the page or
+// terminal still showing it can say where. It matches ErrAnsweredElsewhere
+// with errors.Is.
+type AnsweredElsewhereError struct {
+	Method string
+}
+
+func (e *AnsweredElsewhereError) Error() string {
+	return "answered via " + e.Method
+}
+
+func (e *AnsweredElsewhereError) Is(target error) bool {
+	return target == ErrAnsweredElsewhere
+}
+
+// answeredVia returns the method that answered the prompt of ctx instead,
+// or "" when it wasn't answered elsewhere or didn't say how.
+func answeredVia(ctx context.Context) string {
+	var elsewhere *AnsweredElsewhereError
+	if errors.As(context.Cause(ctx), &elsewhere) {
+		return elsewhere.Method
+	}
+	return ""
+}
+
+// raceLeg is one of the methods a raceProvider asks through.
+type raceLeg struct {
+	method   string
+	provider InputProvider
+}
+
+// raceProvider asks through several providers at once; the first answer
+// wins.
+type raceProvider struct {
+	legs []raceLeg
+}
+
+// NewRaceProvider returns the provider of the both method: it puts the
+// prompt on the terminal through tty and on a web page through web at once,
+// and returns whichever answer arrives first. The other is then cancelled
+// with an *AnsweredElsewhereError, so the page says the prompt was answered
+// in the terminal, or the terminal read is abandoned with the terminal
+// restored. An answer the loser accepted in the meantime is discarded.
+//
+// A method that fails, such as the terminal when there is none, leaves the
+// prompt to the other; the prompt fails only when both do, with both
+// errors.
+func NewRaceProvider(tty, web InputProvider) InputProvider {
+	return raceProvider{legs: []raceLeg{{MethodTTY, tty}, {MethodWeb, web}}}
+}
+
+func (p raceProvider) GetInput(ctx context.Context, req *PromptRequest) (string, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	type legResult struct {
+		leg    int
+		answer string
+		err    error
+	}
+	results := make(chan legResult, len(p.legs))
+	for i, leg := range p.legs {
+		go func() {
+			answer, err := leg.provider.GetInput(ctx, req)
+			results <- legResult{i, answer, err}
+		}()
+	}
+
+	// Every leg is waited for, so the terminal is restored and the page
+	// closed before the answer is returned
+	var answer string
+	var declined error
+	won := false
+	errs := make([]error, len(p.legs))
+	for range p.legs {
+		result := <-results
+		var derr *DeclinedError
+		switch {
+		case won:
+			// Lost a near-simultaneous race; the answer is dropped
+		case result.err == nil || errors.As(result.err, &derr):
+			// Declining is an answer too
+			won = true
+			answer, declined = result.answer, result.err
+			cancel(&AnsweredElsewhereError{Method: p.legs[result.leg].method})
+		default:
+			errs[result.leg] = result.err
+		}
+	}
+	if won {
+		return answer, declined
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.Join(errs...)
+}
+
+// WarnTimeout warns through every method the prompt is shown on.
+func (p raceProvider) WarnTimeout(req *PromptRequest, remaining time.Duration) {
+	for _, leg := range p.legs {
+		if warner, ok := leg.provider.(TimeoutWarner); ok {
+			warner.WarnTimeout(req, remaining)
+		}
+	}
+}
+
+// Notify shows the notification through every method that can show one. It
+// fails only when none could.
+func (p raceProvider) Notify(ctx context.Context, req *PromptRequest) error {
+	err := errors.New("no method can show notifications")
+	for _, leg := range p.legs {
+		notifier, ok := leg.provider.(Notifier)
+		if !ok {
+			continue
+		}
+		if legErr := notifier.Notify(ctx, req); legErr == nil {
+			err = nil
+		} else if err != nil {
+			err = legErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// RankOptions describes a ranking: whether the user may leave options out,
+// and how many they may rank at most.
+type RankOptions struct {
+	AllowPartial bool
+	MaxRanked    int
+}
+
+// NewRankPrompt returns a prompt asking the user to order options by
+// preference. Providers answer with the 1-based option numbers in order,
+// comma-separated; the validated answer is the same list in canonical form,
+// e.g. "3,1,2".
+func NewRankPrompt(prompt, method string, options []string, opts RankOptions) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindRank
+	req.Options = options
+	if opts.MaxRanked == 0 {
+		opts.MaxRanked = len(options)
+	}
+	req.Rank = &opts
+	req.Validate = rankValidator(len(options), opts)
+	return req
+}
+
+// hint tells the terminal user how to answer.
+func (o *RankOptions) hint(count int) string {
+	if !o.AllowPartial {
+		return "List every number in order of preference, comma-separated, e.g. 2,1,3"
+	}
+	if o.MaxRanked < count {
+		return fmt.Sprintf("List up to %d numbers in order of preference, comma-separated", o.MaxRanked)
+	}
+	return "List the numbers you want to rank in order of preference, comma-separated"
+}
+
+func rankValidator(count int, opts RankOptions) func(string) (string, error) {
+	return func(response string) (string, error) {
+		order, err := parseRanking(response, count)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case !opts.AllowPartial && len(order) != count:
+			return "", fmt.Errorf("Please rank all %d options, each number exactly once", count)
+		case len(order) == 0:
+			return "", fmt.Errorf("Please rank at least one option")
+		case len(order) > opts.MaxRanked:
+			return "", fmt.Errorf("Please rank at most %d options", opts.MaxRanked)
+		}
+
+		numbers := make([]string, len(order))
+		for i, index := range order {
+			numbers[i] = strconv.Itoa(index + 1)
+		}
+		return strings.Join(numbers, ","), nil
+	}
+}
+
+// parseRanking reads a comma-separated list of 1-based option numbers into
+// 0-based indices, rejecting numbers out of range or given twice.
+func parseRanking(response string, count int) ([]int, error) {
+	order := []int{}
+	seen := make(map[int]bool, count)
+	for _, part := range strings.Split(response, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > count {
+			return nil, fmt.Errorf("%q is not an option number from 1 to %d", part, count)
+		}
+		if seen[n] {
+			return nil, fmt.Errorf("Option %d is listed twice; give each number once", n)
+		}
+		seen[n] = true
+		order = append(order, n-1)
+	}
+	return order, nil
+}
+
+// parseRankOptions reads the partial ranking arguments of user_rank.
+func parseRankOptions(args map[string]interface{}, count int) (RankOptions, error) {
+	var opts RankOptions
+	var err error
+	if opts.AllowPartial, err = optionalBool(args, "allow_partial", false); err != nil {
+		return opts, err
+	}
+
+	n, err := optionalNumber(args, "max_ranked")
+	if err != nil || n == nil {
+		return opts, err
+	}
+	if !opts.AllowPartial {
+		return opts, fmt.Errorf("Invalid max_ranked parameter: only allowed with allow_partial")
+	}
+	if *n != float64(int(*n)) || *n < 1 || int(*n) > count {
+		return opts, fmt.Errorf("Invalid max_ranked parameter: must be a whole number from 1 to %d (the number of options)", count)
+	}
+	opts.MaxRanked = int(*n)
+	return opts, nil
+}
+
+func (s *MCPServer) handleUserRankTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	options, _, err := optionalStringList(args, "options")
+	if err == nil {
+		err = validateOptions(options)
+	}
+	if err == nil && len(options) < 2 {
+		err = fmt.Errorf("Invalid options parameter: at least two options are needed to rank")
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	opts, err := parseRankOptions(args, len(options))
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewRankPrompt(prompt, promptMethod(args), options, opts)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	indices, err := parseRanking(answer, len(options))
+	if err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode ranking: %v", err))
+		return
+	}
+	ranking := make([]string, len(indices))
+	for i, index := range indices {
+		ranking[i] = options[index]
+	}
+
+	data, err := json.Marshal(ranking)
+	if err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to encode ranking: %v", err))
+		return
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(string(data)),
+		},
+		"structuredContent": map[string]interface{}{
+			"ranking":  ranking,
+			"indices":  indices,
+			"complete": len(ranking) == len(options),
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maxRatingSteps is the most points a rating scale may have.
+const maxRatingSteps = 101
+
+// ratingButtonSteps is the most points the browser shows as buttons; longer
+// scales get a slider.
+const ratingButtonSteps = 11
+
+// RatingOptions describes a rating scale from Min to Max, with optional
+// labels for its ends.
+type RatingOptions struct {
+	Min      int
+	Max      int
+	MinLabel string
+	MaxLabel string
+}
+
+// NewRatingPrompt returns a prompt asking for a whole number on the scale.
+// The answer is the rating in decimal.
+func NewRatingPrompt(prompt, method string, opts RatingOptions) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindRating
+	req.Rating = &opts
+	req.Trim = TrimNone
+	req.Validate = opts.validate
+	return req
+}
+
+func (o *RatingOptions) validate(response string) (string, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(response))
+	if err != nil || n < o.Min || n > o.Max {
+		return "", fmt.Errorf("Please enter a whole number from %d to %d", o.Min, o.Max)
+	}
+	return strconv.Itoa(n), nil
+}
+
+// Fraction is rating as a share of the top of the scale, so 4 of 5 is 0.8.
+func (o *RatingOptions) Fraction(rating int) float64 {
+	return float64(rating) / float64(o.Max)
+}
+
+// scale describes the scale and its labels for the terminal, e.g.
+// "1 = Not at all ... 5 = Completely".
+func (o *RatingOptions) scale() string {
+	end := func(n int, label string) string {
+		if label == "" {
+			return strconv.Itoa(n)
+		}
+		return fmt.Sprintf("%d = %s", n, label)
+	}
+	return end(o.Min, o.MinLabel) + " ... " + end(o.Max, o.MaxLabel)
+}
+
+// parseRatingOptions reads the scale arguments of user_rating. The scale
+// defaults to 1-5 and may not go below zero, so fractions stay within 0-1.
+func parseRatingOptions(args map[string]interface{}) (RatingOptions, error) {
+	opts := RatingOptions{Min: 1, Max: 5}
+	for _, bound := range []struct {
+		name string
+		dest *int
+	}{{"min", &opts.Min}, {"max", &opts.Max}} {
+		n, err := optionalNumber(args, bound.name)
+		if err != nil {
+			return opts, err
+		}
+		if n == nil {
+			continue
+		}
+		if *n != float64(int(*n)) {
+			return opts, fmt.Errorf("Invalid %s parameter: must be a whole number", bound.name)
+		}
+		*bound.dest = int(*n)
+	}
+	if opts.Min < 0 || opts.Min >= opts.Max || opts.Max-opts.Min+1 > maxRatingSteps {
+		return opts, fmt.Errorf("Invalid scale: need 0 <= min < max with at most %d points, got %d-%d", maxRatingSteps, opts.Min, opts.Max)
+	}
+
+	var err error
+	if opts.MinLabel, _, err = optionalString(args, "min_label"); err != nil {
+		return opts, err
+	}
+	if opts.MaxLabel, _, err = optionalString(args, "max_label"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func (s *MCPServer) handleUserRatingTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, ok := args["prompt"].(string)
+	if !ok {
+		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
+		return
+	}
+
+	opts, err := parseRatingOptions(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewRatingPrompt(prompt, promptMethod(args), opts)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	rating, err := strconv.Atoi(answer)
+	if err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode rating: %v", err))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(fmt.Sprintf("%d/%d", rating, opts.Max)),
+		},
+		"structuredContent": map[string]interface{}{
+			"rating":   rating,
+			"min":      opts.Min,
+			"max":      opts.Max,
+			"fraction": opts.Fraction(rating),
+		},
+		"isError": false,
+	})
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Reaction is one of the quick reactions offered by user_reaction. ID is
+// what the agent gets back; Emoji and Label are what the user sees.
+type Reaction struct {
+	ID    string
+	Emoji string
+	Label string
+}
+
+// DefaultReactions are offered when user_reaction is given none.
+var DefaultReactions = []Reaction{
+	{ID: "approve", Emoji: "👍", Label: "Approve"},
+	{ID: "reject", Emoji: "👎", Label: "Reject"},
+	{ID: "unsure", Emoji: "🤷", Label: "Unsure"},
+}
+
+// defaultReactionTimeout is how long user_reaction waits unless told
+// otherwise. A reaction takes one keypress, so it is much shorter than the
+// usual web timeout.
+const defaultReactionTimeout = time.Minute
+
+// reactionTimeoutFallback is the reaction used on timeout, when the set has
+// one with this ID.
+const reactionTimeoutFallback = "unsure"
+
+var reactionIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// display is how the reaction is shown in a list of options.
+func (r Reaction) display() string {
+	if r.Label == "" {
+		return r.Emoji
+	}
+	return r.Emoji + " " + r.Label
+}
+
+// NewReactionPrompt returns a prompt offering reactions, picked with one key
+// on the terminal and one click in the browser. Responses are a reaction's
+// number, ID, emoji or label; the answer is always its ID.
+func NewReactionPrompt(prompt, method string, reactions []Reaction) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindReaction
+	req.Reactions = reactions
+	req.Options = make([]string, len(reactions))
+	for i, r := range reactions {
+		req.Options[i] = r.display()
+	}
+	req.SingleKey = true
+	req.Trim = TrimNone
+	req.Validate = reactionValidator(reactions)
+	return req
+}
+
+func reactionValidator(reactions []Reaction) func(string) (string, error) {
+	return func(response string) (string, error) {
+		response = strings.TrimSpace(response)
+		if n, err := strconv.Atoi(response); err == nil && n >= 1 && n <= len(reactions) {
+			return reactions[n-1].ID, nil
+		}
+		for _, r := range reactions {
+			if strings.EqualFold(response, r.ID) || response == r.Emoji || (r.Label != "" && strings.EqualFold(response, r.Label)) {
+				return r.ID, nil
+			}
+		}
+		return "", fmt.Errorf("Invalid reaction %q: enter a number from 1 to %d", response, len(reactions))
+	}
+}
+
+// parseReactions reads the reactions argument: an array of {id, emoji,
+// label} objects. Without it the DefaultReactions are offered.
+func parseReactions(args map[string]interface{}) ([]Reaction, error) {
+	value, exists := args["reactions"]
+	if !exists || value == nil {
+		return DefaultReactions, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid reactions parameter: must be an array of {id, emoji, label} objects")
+	}
+	if len(items) < 2 || len(items) > maxSingleKeyOptions {
+		return nil, fmt.Errorf("Invalid reactions parameter: between 2 and %d reactions are needed", maxSingleKeyOptions)
+	}
+
+	reactions := make([]Reaction, len(items))
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid reactions parameter: reaction %d is not an object", i)
+		}
+		id, _, err := optionalString(obj, "id")
+		switch {
+		case err != nil:
+		case !reactionIDPattern.MatchString(id):
+			err = fmt.Errorf("id must be lowercase letters, digits, - or _, starting with a letter")
+		case seen[id]:
+			err = fmt.Errorf("id %q is used twice", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid reaction %d: %v", i, err)
+		}
+		seen[id] = true
+
+		emoji, _, err := optionalString(obj, "emoji")
+		if err == nil && (strings.TrimSpace(emoji) == "" || strings.ContainsAny(emoji, "\r\n")) {
+			err = fmt.Errorf("emoji must be a non-empty single line")
+		}
+		label, _, labelErr := optionalString(obj, "label")
+		if err == nil {
+			err = labelErr
+		}
+		if err == nil && strings.ContainsAny(label, "\r\n") {
+			err = fmt.Errorf("label must be a single line")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid reaction %q: %v", id, err)
+		}
+		reactions[i] = Reaction{ID: id, Emoji: strings.TrimSpace(emoji), Label: strings.TrimSpace(label)}
+	}
+	return reactions, nil
+}
+
+func (s *MCPServer) handleUserReactionTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "How did I do?"
+	}
+	reactions, err := parseReactions(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewReactionPrompt(prompt, promptMethod(args), reactions)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.Timeout = defaultReactionTimeout
+
+	timeout, err := optionalTimeout(args)
+	if err == nil {
+		err = parsePromptMeta(args, promptReq)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	// Unsure is the natural answer for no answer, when there is one. An
+	// empty timeout_reaction makes the timeout an error instead.
+	fallback, hasFallback, err := optionalString(args, "timeout_reaction")
+	if err == nil && !hasFallback && containsReaction(reactions, reactionTimeoutFallback) {
+		fallback = reactionTimeoutFallback
+	}
+	if err == nil && fallback != "" && !containsReaction(reactions, fallback) {
+		err = fmt.Errorf("Invalid timeout_reaction parameter: %q is not one of the reaction ids", fallback)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	if fallback != "" && promptReq.Timeout > 0 {
+		promptReq.TimeoutResponse = &fallback
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	timedOut := false
+	if errors.Is(err, ErrTimeout) && promptReq.TimeoutResponse != nil {
+		answer, err, timedOut = *promptReq.TimeoutResponse, nil, true
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	structured := map[string]interface{}{
+		"reaction": answer,
+	}
+	text := answer
+	if timedOut {
+		structured["timedOut"] = true
+		text = fmt.Sprintf("%s (no reaction within %s)", answer, formatSeconds(promptReq.Timeout))
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
+
+func containsReaction(reactions []Reaction, id string) bool {
+	for _, r := range reactions {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
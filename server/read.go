@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// maxReadBytes is the longest body user_read shows. Even JSON-escaped it
+// stays far below maxMessageSize.
+const maxReadBytes = 4 * 1024 * 1024
+
+// NewReadPrompt returns a prompt showing body for the user to read through
+// and acknowledge. The answer is "yes" when they confirm having read it and
+// "no" when they decline.
+func NewReadPrompt(prompt, method, body string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindRead
+	req.Content = body
+	req.Trim = TrimNone
+	req.Validate = confirmValidator("")
+	return req
+}
+
+// runTTYRead pages through the body, then asks whether it was read. With
+// keys, the terminal delivers single keypresses: space pages and y/n answer
+// without Enter. Otherwise each is a line.
+func runTTYRead(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest, keys bool) (string, error) {
+	next := func() (string, error) {
+		if !scanner.Scan() {
+			return "", fmt.Errorf("terminal closed while reading")
+		}
+		return scanner.Text(), nil
+	}
+	if keys {
+		next = func() (string, error) {
+			key, err := readTTYKey(tty)
+			fmt.Fprintf(tty, "\n")
+			return key, err
+		}
+	}
+
+	if err := pageTTY(tty, req.Content, "Space for more, q to finish", next); err != nil {
+		return "", err
+	}
+
+	label := "Have you read it? [y/n]: "
+	if !keys {
+		return readTTYLine(tty, scanner, label, req.Validate)
+	}
+	for {
+		fmt.Fprint(tty, label)
+		key, err := readTTYKey(tty)
+		if err != nil {
+			fmt.Fprintf(tty, "\n")
+			return "", err
+		}
+		fmt.Fprintf(tty, "%s\n", key)
+
+		answer, err := req.Validate(key)
+		if err == nil {
+			return answer, nil
+		}
+		if isAttemptsExhausted(err) {
+			fmt.Fprintf(tty, "Too many invalid attempts\n")
+			return "", err
+		}
+		writeTTYError(tty, err)
+	}
+}
+
+func (s *MCPServer) handleUserReadTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	body, ok := args["body"].(string)
+	if !ok || body == "" {
+		s.sendError(req.ID, -32602, "Missing or invalid body parameter")
+		return
+	}
+	if len(body) > maxReadBytes {
+		s.sendError(req.ID, -32602, fmt.Sprintf("Invalid body parameter: at most %d bytes are allowed", maxReadBytes))
+		return
+	}
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please read the following"
+	}
+
+	promptReq := NewReadPrompt(prompt, promptMethod(args), body)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+
+	timeout, err := optionalTimeout(args)
+	if err == nil {
+		err = parsePromptMeta(args, promptReq)
+	}
+	if err == nil {
+		promptReq.MaxAttempts, err = optionalMaxAttempts(args)
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	start := time.Now()
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	elapsed := time.Since(start)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	acknowledged := answer == AnswerYes
+	text := fmt.Sprintf("Acknowledged after %s", formatSeconds(elapsed.Round(time.Millisecond)))
+	if !acknowledged {
+		text = fmt.Sprintf("Not acknowledged after %s", formatSeconds(elapsed.Round(time.Millisecond)))
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": map[string]interface{}{
+			"acknowledged":   acknowledged,
+			"elapsedSeconds": elapsed.Seconds(),
+		},
+		"isError": false,
+	})
+}
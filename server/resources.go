@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const answerURIScheme = "prompt-history://"
+
+// maxStoredAnswers and maxStoredAnswerBytes bound the answers kept for
+// resources/read. Past either, the least recently used are forgotten, and
+// reading them fails like any unknown resource.
+const (
+	maxStoredAnswers     = 100
+	maxStoredAnswerBytes = 64 << 20
+)
+
+// storedAnswer is an answer kept server-side so the client can fetch it with
+// resources/read instead of receiving it inline.
+type storedAnswer struct {
+	PromptID int64
+	Prompt   string
+	Text     string
+	MimeType string
+	Created  time.Time
+}
+
+func answerURI(promptID int64) string {
+	return fmt.Sprintf("%s%d/answer", answerURIScheme, promptID)
+}
+
+func (s *MCPServer) storeAnswer(prompt *PromptRequest, text string) *storedAnswer {
+	answer := &storedAnswer{
+		PromptID: prompt.ID,
+		Prompt:   prompt.Prompt,
+		Text:     text,
+		MimeType: detectTextMimeType(text),
+		Created:  time.Now(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.answers == nil {
+		s.answers = make(map[int64]*storedAnswer)
+	}
+	if old, ok := s.answers[prompt.ID]; ok {
+		s.answerBytes -= len(old.Text)
+	}
+	s.answers[prompt.ID] = answer
+	s.answerBytes += len(text)
+	s.touchAnswerLocked(prompt.ID)
+	// The newest answer stays, however large
+	for len(s.answerOrder) > 1 && (len(s.answerOrder) > maxStoredAnswers || s.answerBytes > maxStoredAnswerBytes) {
+		oldest := s.answerOrder[0]
+		s.answerOrder = s.answerOrder[1:]
+		s.answerBytes -= len(s.answers[oldest].Text)
+		delete(s.answers, oldest)
+	}
+	return answer
+}
+
+// touchAnswerLocked marks the answer to prompt id as the most recently
+// used. s.mu must be held.
+func (s *MCPServer) touchAnswerLocked(id int64) {
+	for i, stored := range s.answerOrder {
+		if stored == id {
+			s.answerOrder = append(s.answerOrder[:i], s.answerOrder[i+1:]...)
+			break
+		}
+	}
+	s.answerOrder = append(s.answerOrder, id)
+}
+
+func (s *MCPServer) lookupAnswer(uri string) (*storedAnswer, bool) {
+	if !strings.HasPrefix(uri, answerURIScheme) || !strings.HasSuffix(uri, "/answer") {
+		return nil, false
+	}
+	idStr := strings.TrimSuffix(strings.TrimPrefix(uri, answerURIScheme), "/answer")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	answer, ok := s.answers[id]
+	if ok {
+		s.touchAnswerLocked(id)
+	}
+	return answer, ok
+}
+
+func detectTextMimeType(text string) string {
+	if json.Valid([]byte(text)) {
+		return "application/json"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+func (s *MCPServer) handleResourcesList(req MCPRequest) {
+	s.mu.Lock()
+	resources := make([]map[string]interface{}, 0, len(s.answers))
+	for _, answer := range s.answers {
+		resources = append(resources, map[string]interface{}{
+			"uri":         answerURI(answer.PromptID),
+			"name":        fmt.Sprintf("answer-%d", answer.PromptID),
+			"description": fmt.Sprintf("User's answer to: %s", answer.Prompt),
+			"mimeType":    answer.MimeType,
+			"size":        len(answer.Text),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i]["uri"].(string) < resources[j]["uri"].(string)
+	})
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"resources": resources,
+	})
+}
+
+func (s *MCPServer) handleResourcesRead(req MCPRequest) {
+	paramsBytes, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendError(req.ID, -32602, "Invalid params")
+		return
+	}
+
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(paramsBytes, &params); err != nil || params.URI == "" {
+		s.sendError(req.ID, -32602, "Missing or invalid uri parameter")
+		return
+	}
+
+	answer, ok := s.lookupAnswer(params.URI)
+	if !ok {
+		s.sendError(req.ID, -32002, fmt.Sprintf("Resource not found: %s", params.URI))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      params.URI,
+				"mimeType": answer.MimeType,
+				"text":     answer.Text,
+			},
+		},
+	})
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const answerPreviewBytes = 1024
+
+func textContent(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "text",
+		"text": text,
+	}
+}
+
+// addStructured sets key in a tool result's structuredContent, keeping what
+// is already there, such as the resource link details of a large answer.
+func addStructured(result map[string]interface{}, key string, value interface{}) {
+	structured, _ := result["structuredContent"].(map[string]interface{})
+	if structured == nil {
+		structured = make(map[string]interface{})
+		result["structuredContent"] = structured
+	}
+	structured[key] = value
+}
+
+// answerResult builds the tool result for a user's answer. Answers larger than
+// the inline limit are stored as a resource and returned as a preview plus a
+// resource_link the client can fetch with resources/read. Secrets are always
+// inlined so they are never kept by the server.
+func (s *MCPServer) answerResult(prompt *PromptRequest, response string) map[string]interface{} {
+	cfg := s.currentConfig()
+
+	if binaryAnswer(prompt, response) {
+		return base64Result(response)
+	}
+
+	if prompt.Delivery == DeliveryChunked && len(response) > cfg.chunkSize() {
+		return chunkedResult(response, cfg.chunkSize())
+	}
+
+	limit := cfg.inlineLimit()
+	if limit <= 0 || len(response) <= limit || prompt.Secret || prompt.Sensitive {
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent(response),
+			},
+			"isError": false,
+		}
+	}
+
+	answer := s.storeAnswer(prompt, response)
+	uri := answerURI(prompt.ID)
+	sum := sha256.Sum256([]byte(response))
+	hash := hex.EncodeToString(sum[:])
+
+	preview := fmt.Sprintf("%s\n\n[Answer truncated: %d bytes total, sha256 %s. Read the full answer from %s]",
+		truncateUTF8(response, answerPreviewBytes), len(response), hash, uri)
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(preview),
+			{
+				"type":     "resource_link",
+				"uri":      uri,
+				"name":     fmt.Sprintf("answer-%d", prompt.ID),
+				"mimeType": answer.MimeType,
+				"size":     len(response),
+			},
+		},
+		"structuredContent": map[string]interface{}{
+			"uri":    uri,
+			"size":   len(response),
+			"sha256": hash,
+		},
+		"isError": false,
+	}
+}
+
+// base64Result returns a binary answer base64-encoded in structuredContent,
+// since JSON-encoding it as a string would replace invalid UTF-8.
+func base64Result(response string) map[string]interface{} {
+	data := []byte(response)
+	mimeType := http.DetectContentType(data)
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(fmt.Sprintf("Binary answer: %d bytes of %s (sha256 %s). The base64-encoded payload is in structuredContent.data.",
+				len(data), mimeType, hash)),
+		},
+		"structuredContent": map[string]interface{}{
+			"encoding": EncodingBase64,
+			"data":     base64.StdEncoding.EncodeToString(data),
+			"mimeType": mimeType,
+			"size":     len(data),
+			"sha256":   hash,
+		},
+		"isError": false,
+	}
+}
+
+// chunkedResult splits an answer into sequential text blocks, each prefixed
+// with a "(part i/n)" marker.
+func chunkedResult(response string, size int) map[string]interface{} {
+	chunks := chunkText(response, size)
+
+	content := make([]map[string]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		content[i] = textContent(fmt.Sprintf("(part %d/%d)\n%s", i+1, len(chunks), chunk))
+	}
+
+	return map[string]interface{}{
+		"content": content,
+		"structuredContent": map[string]interface{}{
+			"delivery": DeliveryChunked,
+			"size":     len(response),
+			"chunks":   len(chunks),
+		},
+		"isError": false,
+	}
+}
+
+// chunkText splits s into pieces of at most size bytes. Pieces end after a
+// newline when one is available and never split a UTF-8 sequence.
+func chunkText(s string, size int) []string {
+	var chunks []string
+	for len(s) > size {
+		cut := strings.LastIndexByte(s[:size], '\n') + 1
+		if cut == 0 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				// size is smaller than a single rune
+				_, cut = utf8.DecodeRuneInString(s)
+			}
+		}
+		chunks = append(chunks, s[:cut])
+		s = s[cut:]
+	}
+	if len(s) > 0 {
+		chunks = append(chunks, s)
+	}
+	return chunks
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
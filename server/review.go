@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Review decisions.
+const (
+	DecisionApprove            = "approve"
+	DecisionReject             = "reject"
+	DecisionApproveWithComment = "approve_with_comment"
+)
+
+// defaultPageLines is the pager's page height when the terminal size is
+// unknown.
+const defaultPageLines = 24
+
+// Review is the user's verdict on a review prompt.
+type Review struct {
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// NewReviewPrompt returns a prompt showing content for review. Providers
+// answer with a Review encoded as JSON.
+func NewReviewPrompt(prompt, method, content string) *PromptRequest {
+	req := NewPromptRequest(prompt, method)
+	req.Kind = KindReview
+	req.Content = content
+	req.Trim = TrimNone
+	req.Validate = validateReview
+	return req
+}
+
+func validateReview(response string) (string, error) {
+	var review Review
+	if err := json.Unmarshal([]byte(response), &review); err != nil {
+		return "", fmt.Errorf("Invalid review response: %v", err)
+	}
+	review.Comment = strings.TrimSpace(review.Comment)
+
+	switch review.Decision {
+	case DecisionApprove, DecisionReject:
+	case DecisionApproveWithComment:
+		if review.Comment == "" {
+			return "", fmt.Errorf("Please enter a comment, or approve without one")
+		}
+	default:
+		return "", fmt.Errorf("Please choose approve, reject or approve with comment")
+	}
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// runTTYReview pages through the content, then asks for a decision.
+func runTTYReview(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	if err := pageTTYContent(tty, scanner, req.Content); err != nil {
+		return "", err
+	}
+
+	return readTTYLine(tty, scanner, "[a]pprove, [r]eject, approve with [c]omment: ", func(response string) (string, error) {
+		review := Review{}
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "a", "approve":
+			review.Decision = DecisionApprove
+		case "r", "reject":
+			review.Decision = DecisionReject
+		case "c", "comment":
+			comment, err := readTTYLine(tty, scanner, "Comment: ", nil)
+			if err != nil {
+				return "", err
+			}
+			review = Review{Decision: DecisionApproveWithComment, Comment: comment}
+		default:
+			return "", fmt.Errorf("Please enter a, r or c")
+		}
+
+		data, err := json.Marshal(review)
+		if err != nil {
+			return "", err
+		}
+		return req.Validate(string(data))
+	})
+}
+
+// pageTTYContent shows content a terminal page at a time, letting the user
+// skip the rest.
+func pageTTYContent(tty io.ReadWriter, scanner *bufio.Scanner, content string) error {
+	return pageTTY(tty, content, "Enter for more, q to skip to the decision", func() (string, error) {
+		if !scanner.Scan() {
+			return "", fmt.Errorf("terminal closed during review")
+		}
+		return scanner.Text(), nil
+	})
+}
+
+// pageTTY shows content a terminal page at a time. After each page but the
+// last it prints the hint and calls next for the user's key or line; q
+// stops paging.
+func pageTTY(tty io.ReadWriter, content, hint string, next func() (string, error)) error {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	page := ttyPageLines(tty) - 2
+
+	for start := 0; start < len(lines); start += page {
+		end := start + page
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for _, line := range lines[start:end] {
+			fmt.Fprintf(tty, "%s\n", line)
+		}
+		if end == len(lines) {
+			break
+		}
+
+		fmt.Fprintf(tty, "-- %d/%d lines -- %s: ", end, len(lines), hint)
+		key, err := next()
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(strings.TrimSpace(key), "q") {
+			break
+		}
+	}
+	return nil
+}
+
+// ttyPageLines returns the height of the terminal.
+func ttyPageLines(tty io.ReadWriter) int {
+	rows, _, ok := ttySize(tty)
+	if !ok || rows < 5 {
+		return defaultPageLines
+	}
+	return rows
+}
+
+func (s *MCPServer) handleUserReviewTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	content, ok := args["content"].(string)
+	if !ok || content == "" {
+		s.sendError(req.ID, -32602, "Missing or invalid content parameter")
+		return
+	}
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please review the following"
+	}
+
+	promptReq := NewReviewPrompt(prompt, promptMethod(args), content)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var review Review
+	if err := json.Unmarshal([]byte(answer), &review); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode review: %v", err))
+		return
+	}
+
+	text := review.Decision
+	if review.Comment != "" {
+		text += ": " + review.Comment
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": map[string]interface{}{
+			"decision": review.Decision,
+			"approved": review.Decision != DecisionReject,
+			"comment":  review.Comment,
+		},
+		"isError": false,
+	})
+}
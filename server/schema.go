@@ -0,0 +1,416 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// schemaKeywords are the JSON Schema keywords response_schema understands.
+// Annotations are accepted and ignored; any other keyword is rejected rather
+// than silently left unenforced.
+var schemaKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+
+	// Annotations
+	"$schema": true, "$id": true, "title": true, "description": true,
+	"default": true, "examples": true, "format": true,
+}
+
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// checkSchema reports schemas, or parts of them, that response_schema can't
+// enforce.
+func checkSchema(schema map[string]interface{}, path string) error {
+	for key := range schema {
+		if !schemaKeywords[key] {
+			return fmt.Errorf("%s: unsupported keyword %q", schemaPath(path), key)
+		}
+	}
+
+	types, err := typesOf(schema)
+	if err != nil {
+		return fmt.Errorf("%s: %v", schemaPath(path), err)
+	}
+	for _, typ := range types {
+		if !containsString(schemaTypes, typ) {
+			return fmt.Errorf("%s: unknown type %q", schemaPath(path), typ)
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+		if v, ok := schema[key]; ok {
+			if _, isNumber := v.(float64); !isNumber {
+				return fmt.Errorf("%s: %s must be a number", schemaPath(path), key)
+			}
+		}
+	}
+	for _, key := range []string{"minLength", "maxLength", "minItems", "maxItems"} {
+		if v, ok := schema[key]; ok {
+			if n, isNumber := v.(float64); !isNumber || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s: %s must be a non-negative integer", schemaPath(path), key)
+			}
+		}
+	}
+	if v, ok := schema["pattern"]; ok {
+		pattern, isString := v.(string)
+		if !isString {
+			return fmt.Errorf("%s: pattern must be a string", schemaPath(path))
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern: %v", schemaPath(path), err)
+		}
+	}
+	if v, ok := schema["enum"]; ok {
+		if values, isArray := v.([]interface{}); !isArray || len(values) == 0 {
+			return fmt.Errorf("%s: enum must be a non-empty array", schemaPath(path))
+		}
+	}
+
+	if v, ok := schema["required"]; ok {
+		names, isArray := v.([]interface{})
+		if !isArray {
+			return fmt.Errorf("%s: required must be an array of strings", schemaPath(path))
+		}
+		for _, name := range names {
+			if _, isString := name.(string); !isString {
+				return fmt.Errorf("%s: required must be an array of strings", schemaPath(path))
+			}
+		}
+	}
+	if v, ok := schema["properties"]; ok {
+		props, isObject := v.(map[string]interface{})
+		if !isObject {
+			return fmt.Errorf("%s: properties must be an object", schemaPath(path))
+		}
+		for name, prop := range props {
+			sub, isObject := prop.(map[string]interface{})
+			if !isObject {
+				return fmt.Errorf("%s: must be a schema object", schemaPath(path+"."+name))
+			}
+			if err := checkSchema(sub, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	if v, ok := schema["additionalProperties"]; ok {
+		switch sub := v.(type) {
+		case bool:
+		case map[string]interface{}:
+			if err := checkSchema(sub, path+".*"); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: additionalProperties must be a boolean or a schema", schemaPath(path))
+		}
+	}
+	if v, ok := schema["items"]; ok {
+		sub, isObject := v.(map[string]interface{})
+		if !isObject {
+			return fmt.Errorf("%s: items must be a schema object", schemaPath(path))
+		}
+		if err := checkSchema(sub, path+"[]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typesOf returns the types a schema allows; none means any.
+func typesOf(schema map[string]interface{}) ([]string, error) {
+	switch typ := schema["type"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{typ}, nil
+	case []interface{}:
+		types := make([]string, len(typ))
+		for i, t := range typ {
+			s, ok := t.(string)
+			if !ok {
+				return nil, fmt.Errorf("type must be a string or an array of strings")
+			}
+			types[i] = s
+		}
+		return types, nil
+	default:
+		return nil, fmt.Errorf("type must be a string or an array of strings")
+	}
+}
+
+// schemaPath names a location in a schema or an answer for error messages.
+func schemaPath(path string) string {
+	if path == "" {
+		return "response"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+// validateSchemaValue checks a decoded JSON value against a schema that
+// passed checkSchema.
+func validateSchemaValue(value interface{}, schema map[string]interface{}, path string) error {
+	types, _ := typesOf(schema)
+	if len(types) > 0 && !hasSchemaType(value, types) {
+		return fmt.Errorf("%s must be %s", schemaPath(path), strings.Join(types, " or "))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		return fmt.Errorf("%s must be one of %s", schemaPath(path), jsonList(enum))
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return fmt.Errorf("%s must be %s", schemaPath(path), jsonList([]interface{}{c}))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			return fmt.Errorf("%s must be at least %s", schemaPath(path), formatNumber(min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			return fmt.Errorf("%s must be at most %s", schemaPath(path), formatNumber(max))
+		}
+		if min, ok := schema["exclusiveMinimum"].(float64); ok && v <= min {
+			return fmt.Errorf("%s must be above %s", schemaPath(path), formatNumber(min))
+		}
+		if max, ok := schema["exclusiveMaximum"].(float64); ok && v >= max {
+			return fmt.Errorf("%s must be below %s", schemaPath(path), formatNumber(max))
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if min, ok := schema["minLength"].(float64); ok && length < int(min) {
+			return fmt.Errorf("%s must be at least %d characters", schemaPath(path), int(min))
+		}
+		if max, ok := schema["maxLength"].(float64); ok && length > int(max) {
+			return fmt.Errorf("%s must be at most %d characters", schemaPath(path), int(max))
+		}
+		// Unlike the pattern argument, schema patterns match anywhere
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			return fmt.Errorf("%s must match the pattern %s", schemaPath(path), pattern)
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && len(v) < int(min) {
+			return fmt.Errorf("%s must have at least %d items", schemaPath(path), int(min))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && len(v) > int(max) {
+			return fmt.Errorf("%s must have at most %d items", schemaPath(path), int(max))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchemaValue(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				return fmt.Errorf("%s is required", schemaPath(path+"."+name.(string)))
+			}
+		}
+
+		props, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := props[name].(map[string]interface{}); ok {
+				if err := validateSchemaValue(v[name], prop, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s is not allowed", schemaPath(path+"."+name))
+				}
+			case map[string]interface{}:
+				if err := validateSchemaValue(v[name], extra, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func hasSchemaType(value interface{}, types []string) bool {
+	for _, typ := range types {
+		switch v := value.(type) {
+		case nil:
+			if typ == "null" {
+				return true
+			}
+		case bool:
+			if typ == "boolean" {
+				return true
+			}
+		case float64:
+			if typ == "number" || (typ == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case string:
+			if typ == "string" {
+				return true
+			}
+		case []interface{}:
+			if typ == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if typ == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonList formats values as JSON for error messages.
+func jsonList(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		data, _ := json.Marshal(v)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// schemaFormFields maps an object schema whose properties are all strings,
+// numbers, integers or booleans onto form fields, so the user fills in
+// fields instead of typing JSON. Required properties come first, in the
+// order they are listed, then the rest by name. ok is false for any other
+// schema.
+func schemaFormFields(schema map[string]interface{}) (fields []FormField, ok bool) {
+	if schema["type"] != "object" {
+		return nil, false
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	if len(props) == 0 {
+		return nil, false
+	}
+
+	required, _ := schema["required"].([]interface{})
+	var names []string
+	for _, name := range required {
+		if _, exists := props[name.(string)]; !exists {
+			// A required property the form can't ask for
+			return nil, false
+		}
+		names = append(names, name.(string))
+	}
+	var rest []string
+	for name := range props {
+		if !containsString(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	for i, name := range append(names, rest...) {
+		prop := props[name].(map[string]interface{})
+		field, ok := schemaFormField(name, prop)
+		if !ok {
+			return nil, false
+		}
+		field.Optional = i >= len(names)
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+func schemaFormField(name string, prop map[string]interface{}) (FormField, bool) {
+	field := FormField{Name: name}
+	if title, ok := prop["title"].(string); ok {
+		field.Label = title
+	}
+
+	switch prop["type"] {
+	case "string":
+		field.Type = FieldText
+		if enum, ok := prop["enum"].([]interface{}); ok {
+			field.Type = FieldSelect
+			for _, v := range enum {
+				option, isString := v.(string)
+				if !isString {
+					return field, false
+				}
+				field.Options = append(field.Options, option)
+			}
+		}
+	case "number", "integer":
+		field.Type = FieldNumber
+	case "boolean":
+		field.Type = FieldBoolean
+	default:
+		return field, false
+	}
+
+	if def, ok := prop["default"]; ok && validateSchemaValue(def, prop, name) == nil {
+		field.Default = def
+	}
+	field.check = func(value interface{}) error {
+		return validateSchemaValue(value, prop, name)
+	}
+	return field, true
+}
+
+// schemaValidator accepts JSON text matching schema, unchanged.
+func schemaValidator(schema map[string]interface{}) func(string) (string, error) {
+	return func(response string) (string, error) {
+		var value interface{}
+		if err := json.Unmarshal([]byte(response), &value); err != nil {
+			return "", fmt.Errorf("Response is not valid JSON: %v", err)
+		}
+		if err := validateSchemaValue(value, schema, ""); err != nil {
+			return "", err
+		}
+		return response, nil
+	}
+}
+
+// MakeStructured turns a text prompt into one whose answer is JSON matching
+// schema, which must have passed checkSchema. Simple object schemas are
+// asked as a form unless the prompt is multi-line; otherwise the user types
+// the JSON.
+func (r *PromptRequest) MakeStructured(schema map[string]interface{}) {
+	validate := schemaValidator(schema)
+
+	fields, ok := schemaFormFields(schema)
+	if !ok || r.Multiline {
+		r.Validate = validate
+		return
+	}
+
+	r.Kind = KindForm
+	r.Fields = fields
+	r.Trim = TrimNone
+	form := formValidator(fields)
+	r.Validate = func(response string) (string, error) {
+		answer, err := form(response)
+		if err != nil {
+			return "", err
+		}
+		return validate(answer)
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// redacted replaces the answers of sensitive prompts wherever the server
+// writes them for anyone but the agent.
+const redacted = "[redacted]"
+
+// loggedResponse returns response as it may appear in logs, traces and
+// observer events.
+func (r *PromptRequest) loggedResponse(response string) string {
+	if r.Sensitive || r.Secret {
+		return redacted
+	}
+	if r.Kind == KindUpload {
+		// Files are summed up, not dumped
+		var file UploadedFile
+		if err := json.Unmarshal([]byte(response), &file); err == nil {
+			return fmt.Sprintf("[file %s, %d bytes of %s]", file.Name, len(file.Data), file.MimeType)
+		}
+	}
+	return response
+}
+
+// loggedPrompt returns the prompt's text as it may appear in logs. The
+// question can give away as much as the answer, so sensitive prompts keep
+// it out.
+func (r *PromptRequest) loggedPrompt() string {
+	if r.Sensitive {
+		return redacted
+	}
+	return r.Prompt
+}
+
+// markSensitive annotates every content item of a tool result as meant for
+// the assistant only, so clients can keep it out of what they show or store.
+func markSensitive(result map[string]interface{}) {
+	content, _ := result["content"].([]map[string]interface{})
+	for _, item := range content {
+		item["annotations"] = map[string]interface{}{
+			"audience": []string{"assistant"},
+		}
+	}
+}
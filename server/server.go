@@ -4,23 +4,69 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
 	"io"
-	"net"
-	"net/http"
 	"os"
-	"os/exec"
-	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"prompt-mcp/units"
 )
 
+// maxMessageSize is the largest JSON-RPC message read from stdin.
+const maxMessageSize = 64 * 1024 * 1024
+
+// pendingMessages is how many messages may be read ahead of the one being
+// handled.
+const pendingMessages = 256
+
 type MCPServer struct {
 	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
+
+	verbose bool
+
+	config    Config
+	providers map[string]InputProvider
+	promptSeq int64
+	answers   map[int64]*storedAnswer
+	observers *ObserverHub
+	history   *History
+	cache     *AnswerCache
+	client    *ClientInfo
+	clipboard ClipboardReader
+
+	// started is when the server was made or started reading, initialized
+	// whether a client sent initialize, and pending how many prompts wait
+	// for an answer; all under mu, for Health
+	started     time.Time
+	initialized bool
+	pending     int
+
+	// alerts maps prompt priorities to alerts; nil uses DefaultAlertPolicy
+	alerts *AlertPolicy
+
+	// answerOrder lists the prompt IDs of the stored answers, least
+	// recently used first, and answerBytes is their size; both under mu
+	answerOrder []int64
+	answerBytes int
+
+	// resultMeta holds _meta entries for results not sent yet, by request id
+	resultMeta map[interface{}]map[string]interface{}
+
+	// inflight cancels the prompts of calls still waiting for an answer, and
+	// withdrawn marks the calls the client cancelled, whose results are never
+	// sent; both by request id
+	inflight  map[interface{}]context.CancelCauseFunc
+	withdrawn map[interface{}]bool
+
+	mu      sync.Mutex
+	writeMu sync.Mutex
 }
 
 type MCPRequest struct {
@@ -37,9 +83,16 @@ type MCPResponse struct {
 	Error   *MCPError   `json:"error,omitempty"`
 }
 
+type MCPNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type MCPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 type UserInputRequest struct {
@@ -52,19 +105,13 @@ type UserInputResult struct {
 	Success  bool   `json:"success"`
 }
 
-type WebInputHandler struct {
-	prompt     string
-	response   chan string
-	serverDone chan struct{}
-	mu         sync.Mutex
-	server     *http.Server
-}
-
 func NewMCPServer() *MCPServer {
 	return &MCPServer{
-		stdin:  os.Stdin,
-		stdout: os.Stdout,
-		stderr: os.Stderr,
+		stdin:   os.Stdin,
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		config:  DefaultConfig(),
+		started: time.Now(),
 	}
 }
 
@@ -74,19 +121,68 @@ func (s *MCPServer) SetIO(stdin io.Reader, stdout io.Writer, stderr io.Writer) {
 	s.stderr = stderr
 }
 
+// SetVerbose makes the server trace prompts and their outcomes on stderr.
+func (s *MCPServer) SetVerbose(verbose bool) {
+	s.verbose = verbose
+}
+
+// logf writes a verbose trace line to stderr.
+func (s *MCPServer) logf(format string, args ...interface{}) {
+	if s.verbose && s.stderr != nil {
+		fmt.Fprintf(s.stderr, format+"\n", args...)
+	}
+}
+
 func (s *MCPServer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	if s.started.IsZero() {
+		s.started = time.Now()
+	}
+	s.mu.Unlock()
+
 	scanner := bufio.NewScanner(s.stdin)
+	// Tool arguments such as diffs under review can be far larger than the
+	// scanner's default 64KiB line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	// Messages are read in the background so a cancellation reaches the call
+	// it cancels while that call still waits for an answer
+	lines := make(chan string, pendingMessages)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || s.handleCancelled(line) {
+				continue
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
 
-	for scanner.Scan() {
+	for {
+		var line string
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
+		case next, ok := <-lines:
+			if !ok {
+				select {
+				case err := <-readErr:
+					return err
+				default:
+					return ctx.Err()
+				}
+			}
+			line = next
 		}
-
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
 
 		var req MCPRequest
@@ -106,25 +202,35 @@ func (s *MCPServer) Start(ctx context.Context) error {
 		case "tools/list":
 			s.handleToolsList(req)
 		case "tools/call":
-			s.handleToolCall(req, scanner)
+			s.handleToolCall(req)
+		case "resources/list":
+			s.handleResourcesList(req)
+		case "resources/read":
+			s.handleResourcesRead(req)
 		case "user_input":
-			s.handleUserInput(req, scanner)
+			// The legacy method follows the switch for the tool of the same name
+			if !s.currentConfig().toolEnabled("user_input") {
+				s.sendErrorData(req.ID, -32601, "Method not found", toolDisabledError("user_input"))
+				continue
+			}
+			s.handleUserInput(req)
 		default:
 			s.sendError(req.ID, -32601, "Method not found")
 		}
 	}
-
-	return scanner.Err()
 }
 
 func (s *MCPServer) handleInitialize(req MCPRequest) {
+	client := parseClientInfo(req.Params)
+	s.mu.Lock()
+	s.client = client
+	s.initialized = true
+	s.mu.Unlock()
+	s.logf("Client: %s", client)
+
 	result := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{
-				"listChanged": false,
-			},
-		},
+		"protocolVersion": protocolVersion,
+		"capabilities":    s.capabilities(),
 		"serverInfo": map[string]interface{}{
 			"name":    "prompt-mcp",
 			"version": "1.0.0",
@@ -136,42 +242,37 @@ func (s *MCPServer) handleInitialize(req MCPRequest) {
 
 func (s *MCPServer) handleCapabilities(req MCPRequest) {
 	result := map[string]interface{}{
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{
-				"listChanged": false,
-			},
-		},
+		"capabilities": s.capabilities(),
 	}
 
 	s.sendResponse(req.ID, result)
 }
 
-func (s *MCPServer) handleToolsList(req MCPRequest) {
-	tools := []map[string]interface{}{
-		{
-			"name":        "user_input",
-			"description": "Request input or approval from the user",
-			"inputSchema": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"prompt": map[string]interface{}{
-						"type":        "string",
-						"description": "The prompt to show to the user",
-					},
-					"timeout": map[string]interface{}{
-						"type":        "integer",
-						"description": "Optional timeout in seconds",
-					},
-					"method": map[string]interface{}{
-						"type":        "string",
-						"description": "Input method: 'tty' (terminal) or 'web' (browser)",
-						"enum":        []string{"tty", "web"},
-						"default":     "tty",
-					},
-				},
-				"required": []string{"prompt"},
-			},
+func (s *MCPServer) capabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"tools": map[string]interface{}{
+			// Only a server with a config file can change its tools, on reload
+			"listChanged": s.currentConfig().Path != "",
 		},
+		"resources": map[string]interface{}{
+			"listChanged": false,
+		},
+	}
+}
+
+func (s *MCPServer) handleToolsList(req MCPRequest) {
+	cfg := s.currentConfig()
+	enabled := cfg.enabledTools()
+
+	tools := make([]map[string]interface{}, len(enabled))
+	for i, tool := range enabled {
+		tools[i] = map[string]interface{}{
+			"name":        cfg.ToolPrefix + tool.Name,
+			"title":       tool.Title,
+			"description": tool.Description,
+			"inputSchema": cfg.restrictMethodSchema(tool.InputSchema),
+			"annotations": tool.annotations(),
+		}
 	}
 
 	result := map[string]interface{}{
@@ -181,7 +282,7 @@ func (s *MCPServer) handleToolsList(req MCPRequest) {
 	s.sendResponse(req.ID, result)
 }
 
-func (s *MCPServer) handleToolCall(req MCPRequest, scanner *bufio.Scanner) {
+func (s *MCPServer) handleToolCall(req MCPRequest) {
 	paramsBytes, err := json.Marshal(req.Params)
 	if err != nil {
 		s.sendError(req.ID, -32602, "Invalid params")
@@ -191,27 +292,52 @@ func (s *MCPServer) handleToolCall(req MCPRequest, scanner *bufio.Scanner) {
 	var toolCall struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
+		Meta      struct {
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
 	}
 	if err := json.Unmarshal(paramsBytes, &toolCall); err != nil {
 		s.sendError(req.ID, -32602, "Invalid params")
 		return
 	}
 
-	switch toolCall.Name {
-	case "user_input":
-		s.handleUserInputTool(req, toolCall.Arguments, scanner)
-	default:
+	cfg := s.currentConfig()
+	tool, ok := cfg.lookupCalledTool(toolCall.Name)
+	if !ok {
 		s.sendError(req.ID, -32601, "Unknown tool")
+		return
+	}
+	if !cfg.toolEnabled(tool.Name) {
+		s.sendErrorData(req.ID, -32601, "Tool disabled", toolDisabledError(toolCall.Name))
+		return
 	}
+
+	tool.handler(s, req, toolCall.Arguments, toolCall.Meta.ProgressToken)
 }
 
-func (s *MCPServer) handleUserInputTool(req MCPRequest, args map[string]interface{}, scanner *bufio.Scanner) {
+func (s *MCPServer) handleUserInputTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
 	prompt, ok := args["prompt"].(string)
 	if !ok {
 		s.sendError(req.ID, -32602, "Missing or invalid prompt parameter")
 		return
 	}
 
+	images, err := parseImages(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	attachments, err := parseAttachments(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	conv, err := parseContext(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
 	// Get input method, default to TTY
 	method := "tty"
 	if methodArg, exists := args["method"]; exists {
@@ -219,208 +345,691 @@ func (s *MCPServer) handleUserInputTool(req MCPRequest, args map[string]interfac
 			method = methodStr
 		}
 	}
+	// Images are best seen in the browser; only an explicit tty keeps the
+	// terminal, which lists them as temp files
+	if _, explicit := args["method"]; len(images) > 0 && (!explicit || method == MethodAuto) {
+		method = MethodWeb
+	}
 
-	var response string
-	var err error
+	promptReq := NewPromptRequest(prompt, method)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.Images = images
+	promptReq.Attachments = attachments
+	promptReq.Context = conv
 
-	switch method {
-	case "web":
-		response, err = s.getUserInputFromWeb(prompt)
-	case "tty":
-		fallthrough
-	default:
-		response, err = s.getUserInputFromTTY(prompt)
+	timeout, err := optionalTimeout(args)
+	if err == nil {
+		err = parsePromptMeta(args, promptReq)
+	}
+	if err == nil {
+		promptReq.MaxAttempts, err = optionalMaxAttempts(args)
+	}
+	if err == nil {
+		promptReq.CacheBypass, err = optionalBool(args, "cache_bypass", false)
+	}
+	if err == nil {
+		promptReq.RequireConfirmation, err = optionalBool(args, "require_confirmation", false)
+	}
+	if err == nil {
+		err = parseButtonLabels(args, promptReq)
 	}
-
 	if err != nil {
-		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to get user input: %v", err))
+		s.sendError(req.ID, -32602, err.Error())
 		return
 	}
-
-	result := map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": response,
-			},
-		},
-		"isError": false,
+	if timeout != nil {
+		promptReq.Timeout = *timeout
 	}
 
-	s.sendResponse(req.ID, result)
-}
+	cfg := s.currentConfig()
 
-func (s *MCPServer) getUserInputFromTTY(prompt string) (string, error) {
-	// Open the controlling terminal directly
-	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	phrase, err := parseConfirmationPhrase(args)
 	if err != nil {
-		return "", fmt.Errorf("failed to open /dev/tty: %w", err)
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if phrase != nil {
+		for _, name := range phraseConflicts {
+			if _, exists := args[name]; exists {
+				s.sendError(req.ID, -32602, fmt.Sprintf("Invalid %s parameter: can't be combined with confirmation_phrase", name))
+				return
+			}
+		}
+		if promptReq.MaxAttempts == 0 {
+			promptReq.MaxAttempts = cfg.maxAttempts()
+		}
+		promptReq.RequirePhrase(*phrase)
+		s.handlePhrasePrompt(req, promptReq, progressToken)
+		return
+	}
+
+	upload, err := parseUploadOptions(args, cfg.maxUploadBytes())
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if upload != nil {
+		for _, name := range uploadConflicts {
+			if _, exists := args[name]; exists {
+				s.sendError(req.ID, -32602, fmt.Sprintf("Invalid %s parameter: can't be combined with accept_file", name))
+				return
+			}
+		}
+		if err := promptReq.AcceptFile(*upload); err != nil {
+			s.sendError(req.ID, -32602, err.Error())
+			return
+		}
+		s.handleUploadPrompt(req, promptReq, progressToken)
+		return
 	}
-	defer tty.Close()
 
-	// Write prompt to the terminal
-	fmt.Fprintf(tty, "%s\n", prompt)
-	fmt.Fprintf(tty, "Response: ")
+	delivery := cfg.delivery()
+	if deliveryArg, exists := args["delivery"]; exists {
+		deliveryStr, ok := deliveryArg.(string)
+		if !ok || (deliveryStr != DeliveryInline && deliveryStr != DeliveryChunked) {
+			s.sendError(req.ID, -32602, "Invalid delivery parameter: must be 'inline' or 'chunked'")
+			return
+		}
+		delivery = deliveryStr
+	}
 
-	// Read response from the terminal
-	scanner := bufio.NewScanner(tty)
-	if scanner.Scan() {
-		return strings.TrimSpace(scanner.Text()), nil
+	// Without an explicit encoding, answers that aren't valid UTF-8 are
+	// returned base64-encoded
+	encoding := ""
+	if encodingArg, exists := args["encoding"]; exists {
+		encodingStr, ok := encodingArg.(string)
+		if !ok || (encodingStr != EncodingText && encodingStr != EncodingBase64) {
+			s.sendError(req.ID, -32602, "Invalid encoding parameter: must be 'text' or 'base64'")
+			return
+		}
+		encoding = encodingStr
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", fmt.Errorf("failed to read from terminal: %w", err)
+	def, _, err := optionalString(args, "default")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
 	}
+	promptReq.Default = def
 
-	return "", nil
-}
+	multiline, err := optionalBool(args, "multiline", false)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if multiline {
+		// Pasted text keeps its leading indentation and trailing newlines
+		promptReq.Multiline = true
+		promptReq.Trim = TrimNone
+	}
+
+	trim := promptReq.Trim
+	if trimArg, exists := args["trim"]; exists {
+		trimStr, ok := trimArg.(string)
+		if !ok || (trimStr != TrimBoth && trimStr != TrimTrailing && trimStr != TrimNone) {
+			s.sendError(req.ID, -32602, "Invalid trim parameter: must be 'both', 'trailing' or 'none'")
+			return
+		}
+		trim = trimStr
+	}
 
-func (s *MCPServer) getUserInputFromWeb(prompt string) (string, error) {
-	handler := &WebInputHandler{
-		prompt:     prompt,
-		response:   make(chan string, 1),
-		serverDone: make(chan struct{}, 1),
+	dedent := false
+	if dedentArg, exists := args["dedent"]; exists {
+		dedentBool, ok := dedentArg.(bool)
+		if !ok {
+			s.sendError(req.ID, -32602, "Invalid dedent parameter: must be a boolean")
+			return
+		}
+		dedent = dedentBool
 	}
 
-	// Find an available port
-	listener, err := net.Listen("tcp", ":0")
+	secret, err := optionalBool(args, "secret", false)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	confirmSecret, err := optionalBool(args, "confirm_secret", false)
 	if err != nil {
-		return "", fmt.Errorf("failed to find available port: %w", err)
+		s.sendError(req.ID, -32602, err.Error())
+		return
 	}
 
-	port := listener.Addr().(*net.TCPAddr).Port
-	listener.Close() // Close so we can use the port for HTTP server
+	if promptReq.Multiline && (secret || confirmSecret) {
+		s.sendError(req.ID, -32602, "Invalid multiline parameter: secrets are single-line")
+		return
+	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", handler.handleRoot)
-	mux.HandleFunc("/submit", handler.handleSubmit)
+	pattern, hasPattern, err := optionalString(args, "pattern")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	validationMessage, _, err := optionalString(args, "validation_message")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if hasPattern {
+		validate, err := NewPatternValidator(promptReq, pattern, validationMessage)
+		if err != nil {
+			s.sendError(req.ID, -32602, err.Error())
+			return
+		}
+		promptReq.Validate = validate
+	}
 
-	handler.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
-		Handler: mux,
+	allowedValues, hasAllowedValues, err := optionalStringList(args, "allowed_values")
+	if err == nil && hasAllowedValues {
+		switch {
+		case hasPattern:
+			err = fmt.Errorf("Invalid allowed_values parameter: can't be combined with a pattern")
+		case promptReq.Multiline:
+			err = fmt.Errorf("Invalid allowed_values parameter: can't be combined with multiline")
+		default:
+			var validate func(string) (string, error)
+			validate, err = NewAllowedValuesValidator(promptReq, allowedValues)
+			if err == nil && def != "" {
+				// The default is returned as written in allowed_values
+				canonical, defErr := validate(def)
+				if defErr != nil {
+					err = fmt.Errorf("Invalid default parameter: %v", defErr)
+				}
+				promptReq.Default = canonical
+			}
+			promptReq.Validate = validate
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
 	}
 
-	// Start server in background
-	go func() {
-		if err := handler.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			fmt.Fprintf(os.Stderr, "Web server error: %v\n", err)
+	answerType, _, err := optionalString(args, "type")
+	if err == nil && answerType != "" && answerType != TypeText && answerType != TypeNumber && answerType != TypeInteger && answerType != TypeDuration && answerType != TypePath {
+		err = fmt.Errorf("Invalid type parameter: must be 'text', 'number', 'integer', 'duration' or 'path'")
+	}
+	root, hasRoot, rootErr := optionalString(args, "root")
+	if err == nil && rootErr != nil {
+		err = rootErr
+	}
+	if err == nil && hasAllowedValues && answerType != "" && answerType != TypeText {
+		err = fmt.Errorf("Invalid allowed_values parameter: only text answers can be restricted to a list")
+	}
+	if _, hasUnits := args["units"]; err == nil && hasUnits && answerType != TypeNumber {
+		err = fmt.Errorf("Invalid units parameter: only answers of type number have units")
+	}
+	for _, name := range []string{"default_unit", "result_unit"} {
+		if _, present := args[name]; err == nil && present && answerType != TypeDuration {
+			err = fmt.Errorf("Invalid %s parameter: only answers of type duration have one", name)
 		}
-		handler.serverDone <- struct{}{}
-	}()
+	}
+	if err == nil && hasRoot && answerType != TypePath {
+		err = fmt.Errorf("Invalid root parameter: only path answers have a root")
+	}
+	if err == nil && answerType == TypePath {
+		switch {
+		case hasPattern:
+			err = fmt.Errorf("Invalid pattern parameter: path answers can't have a pattern")
+		case promptReq.Multiline:
+			err = fmt.Errorf("Invalid multiline parameter: path answers are single-line")
+		case secret || confirmSecret:
+			err = fmt.Errorf("Invalid secret parameter: path answers can't be secret")
+		case def != "":
+			err = fmt.Errorf("Invalid default parameter: path answers can't have a default")
+		default:
+			// Paths must exist, and with a root they must stay inside it
+			opts := FileOptions{StartDir: root, MustExist: true, Restrict: hasRoot}
+			if pathErr := promptReq.MakePath(opts); pathErr != nil {
+				err = fmt.Errorf("Invalid root parameter: %v", pathErr)
+			}
+		}
+	}
+	if err == nil && (answerType == TypeNumber || answerType == TypeInteger || answerType == TypeDuration) {
+		var opts NumberOptions
+		if answerType == TypeDuration {
+			opts, err = parseDurationOptions(args)
+		} else {
+			opts, err = parseNumberOptions(args, answerType)
+		}
+		if err == nil && hasPattern {
+			err = fmt.Errorf("Invalid pattern parameter: numeric answers can't have a pattern")
+		}
+		if err == nil && promptReq.Multiline {
+			err = fmt.Errorf("Invalid multiline parameter: numeric answers are single-line")
+		}
+		if err == nil && def != "" {
+			if _, defErr := opts.ParseNumber(def); defErr != nil {
+				err = fmt.Errorf("Invalid default parameter: %v", defErr)
+			}
+		}
+		if err == nil {
+			promptReq.MakeNumeric(opts)
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
+	var schemaObj map[string]interface{}
+	schema, hasSchema := args["response_schema"]
+	if hasSchema {
+		schemaObj, ok = schema.(map[string]interface{})
+		switch {
+		case !ok:
+			err = fmt.Errorf("Invalid response_schema parameter: must be a JSON Schema object")
+		case answerType == TypeNumber || answerType == TypeInteger || answerType == TypeDuration:
+			err = fmt.Errorf("Invalid response_schema parameter: can't be combined with a numeric type")
+		case answerType == TypePath:
+			err = fmt.Errorf("Invalid response_schema parameter: can't be combined with a path type")
+		case hasPattern:
+			err = fmt.Errorf("Invalid pattern parameter: use a pattern inside response_schema instead")
+		case hasAllowedValues:
+			err = fmt.Errorf("Invalid allowed_values parameter: use an enum inside response_schema instead")
+		case def != "":
+			err = fmt.Errorf("Invalid default parameter: use defaults inside response_schema instead")
+		case secret || confirmSecret:
+			err = fmt.Errorf("Invalid response_schema parameter: secrets can't be structured")
+		default:
+			if schemaErr := checkSchema(schemaObj, ""); schemaErr != nil {
+				err = fmt.Errorf("Invalid response_schema parameter: %v", schemaErr)
+			}
+		}
+		if err == nil {
+			promptReq.MakeStructured(schemaObj)
+			if promptReq.Kind == KindForm {
+				trim = TrimNone
+			}
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
 
-	url := fmt.Sprintf("http://localhost:%d", port)
+	limits, err := parseLengthLimits(args)
+	if err == nil && limits != nil {
+		switch {
+		case answerType != "" && answerType != TypeText:
+			err = fmt.Errorf("Invalid max_length parameter: only text answers have a length limit")
+		case hasSchema:
+			err = fmt.Errorf("Invalid max_length parameter: use minLength and maxLength inside response_schema instead")
+		case def != "":
+			if lengthErr := limits.check(def); lengthErr != nil {
+				err = fmt.Errorf("Invalid default parameter: %v", lengthErr)
+			}
+		}
+		if err == nil {
+			promptReq.LimitLength(*limits)
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
 
-	// Open browser
-	if err := openBrowser(url); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to open browser automatically. Please visit: %s\n", url)
-	} else {
-		fmt.Fprintf(os.Stderr, "Opening browser for input: %s\n", url)
+	allowEmpty, err := optionalBool(args, "allow_empty", false)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq.AllowEmpty = allowEmpty
+
+	placeholder, _, err := optionalString(args, "placeholder")
+	if err == nil && placeholder != "" {
+		switch {
+		case strings.ContainsAny(placeholder, "\r\n"):
+			err = fmt.Errorf("Invalid placeholder parameter: must be a single line")
+		case promptReq.Kind != KindText && promptReq.Kind != KindNumber:
+			err = fmt.Errorf("Invalid placeholder parameter: only text and number answers have a placeholder")
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq.Placeholder = placeholder
+
+	transforms, err := parseTransforms(args)
+	if err == nil && transforms != nil {
+		switch {
+		case promptReq.Kind != KindText || hasSchema:
+			err = fmt.Errorf("Invalid normalize parameter: only text answers can be normalized")
+		case secret || confirmSecret:
+			err = fmt.Errorf("Invalid normalize parameter: secrets are returned as typed")
+		default:
+			// Validation, suggestions and the default all see the
+			// normalized value
+			promptReq.Transform(transforms)
+			promptReq.Default = applyTransforms(promptReq.Default, transforms)
+		}
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
 	}
 
-	// Wait for response or timeout
-	select {
-	case response := <-handler.response:
-		handler.shutdown()
-		return response, nil
-	case <-time.After(5 * time.Minute): // 5 minute timeout
-		handler.shutdown()
-		return "", fmt.Errorf("timeout waiting for web input")
+	suggestions, err := parseSuggestions(args)
+	if err == nil && suggestions != nil {
+		switch {
+		case promptReq.Kind != KindText:
+			err = fmt.Errorf("Invalid suggestions parameter: only text answers have suggestions")
+		case promptReq.Multiline:
+			err = fmt.Errorf("Invalid suggestions parameter: can't be combined with multiline")
+		case secret || confirmSecret:
+			err = fmt.Errorf("Invalid suggestions parameter: secrets can't have suggestions")
+		case promptReq.Validate != nil:
+			// Every suggestion must be an answer the prompt accepts
+			for _, suggestion := range suggestions {
+				if _, validErr := promptReq.Validate(suggestion); validErr != nil {
+					err = fmt.Errorf("Invalid suggestions parameter: %q: %v", suggestion, validErr)
+					break
+				}
+			}
+		}
 	}
-}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	promptReq.Suggestions = suggestions
 
-func (h *WebInputHandler) handleRoot(w http.ResponseWriter, r *http.Request) {
-	tmpl := `<!DOCTYPE html>
-<html>
-<head>
-    <title>User Input Required</title>
-    <style>
-        body { font-family: Arial, sans-serif; max-width: 600px; margin: 50px auto; padding: 20px; }
-        .prompt { background: #f5f5f5; padding: 15px; border-left: 4px solid #007cba; margin: 20px 0; }
-        input[type="text"] { width: 100%; padding: 10px; font-size: 16px; border: 1px solid #ddd; }
-        button { background: #007cba; color: white; padding: 10px 20px; border: none; font-size: 16px; cursor: pointer; }
-        button:hover { background: #005a87; }
-    </style>
-</head>
-<body>
-    <h1>User Input Required</h1>
-    <div class="prompt">{{.Prompt}}</div>
-    <form action="/submit" method="post">
-        <input type="text" name="response" placeholder="Enter your response..." autofocus required>
-        <br><br>
-        <button type="submit">Submit</button>
-    </form>
-    <script>
-        document.querySelector('form').addEventListener('submit', function() {
-            document.querySelector('button').textContent = 'Submitting...';
-            document.querySelector('button').disabled = true;
-        });
-    </script>
-</body>
-</html>`
-
-	t, err := template.New("input").Parse(tmpl)
+	sensitive, err := optionalBool(args, "sensitive", false)
 	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
+		s.sendError(req.ID, -32602, err.Error())
 		return
 	}
+	promptReq.Sensitive = sensitive
 
-	data := struct{ Prompt string }{Prompt: h.prompt}
-	if err := t.Execute(w, data); err != nil {
-		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	promptReq.Secret = secret || confirmSecret
+	promptReq.ConfirmSecret = confirmSecret
+	promptReq.Delivery = delivery
+	promptReq.Encoding = encoding
+	promptReq.Trim = trim
+	promptReq.Dedent = dedent
+
+	long, err := parseExpectedLength(args, promptReq)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
 		return
 	}
-}
+	promptReq.LongAnswer = long
 
-func (h *WebInputHandler) handleSubmit(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if promptReq.Kind != KindForm {
+		promptReq.RequireAnswer()
+	}
+
+	timeoutResponse, hasTimeoutResponse, err := optionalString(args, "default_response")
+	if err == nil && hasTimeoutResponse {
+		// The fallback must be an answer the user could have given
+		validate := promptReq.Validate
+		if promptReq.Kind == KindForm {
+			validate = schemaValidator(schemaObj)
+		}
+		switch {
+		case promptReq.Timeout <= 0:
+			err = fmt.Errorf("Invalid default_response parameter: the prompt has no timeout")
+		case validate != nil:
+			valid, validErr := validate(timeoutResponse)
+			if validErr != nil {
+				err = fmt.Errorf("Invalid default_response parameter: %v", validErr)
+			}
+			timeoutResponse = valid
+		}
+		promptReq.TimeoutResponse = &timeoutResponse
+	}
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
 		return
 	}
 
-	response := r.FormValue("response")
-	if response == "" {
-		http.Error(w, "Response cannot be empty", http.StatusBadRequest)
+	if promptReq.Transforms != nil {
+		promptReq.Raw = &RawAnswer{}
+	}
+	response, err := s.collectInput(req, promptReq, progressToken)
+	timedOut := false
+	if errors.Is(err, ErrTimeout) && promptReq.TimeoutResponse != nil {
+		response, err, timedOut = *promptReq.TimeoutResponse, nil, true
+	}
+	var declined *DeclinedError
+	if errors.As(err, &declined) {
+		// The agent's parsing mustn't depend on the labels it chose, if any
+		result := declinedResult(declined)
+		addStructured(result, "action", ActionDeclined)
+		s.sendResponse(req.ID, result)
+		return
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
 		return
 	}
 
-	// Send response
-	select {
-	case h.response <- response:
-		fmt.Fprintf(w, "<html><body><h1>Thank you!</h1><p>Your response has been submitted. You can close this tab.</p></body></html>")
+	// Binary answers are returned byte-for-byte
+	normalization := map[string]interface{}{
+		"trim":   TrimNone,
+		"dedent": false,
+	}
+	if !binaryAnswer(promptReq, response) {
+		normalization["trim"] = promptReq.Trim
+		normalization["dedent"] = promptReq.Dedent
+	}
+	if promptReq.Transforms != nil {
+		normalization["transforms"] = promptReq.Transforms
+	}
+
+	meta := map[string]interface{}{
+		"normalization": normalization,
+	}
+	if promptReq.Default != "" {
+		meta["defaultUsed"] = response == promptReq.Default
+	}
+
+	result := s.answerResult(promptReq, response)
+	if hasSchema {
+		var value interface{}
+		if err := json.Unmarshal([]byte(response), &value); err == nil {
+			addStructured(result, "value", value)
+		}
+	}
+	if promptReq.Kind == KindNumber && promptReq.Number.Duration != nil {
+		// Durations come back as typed, and in the unit asked for
+		if n, err := promptReq.Number.ParseNumber(response); err == nil {
+			value, unit := promptReq.Number.Duration.result(n)
+			addStructured(result, "value", value)
+			addStructured(result, "unit", unit)
+			addStructured(result, "raw", response)
+		}
+	} else if promptReq.Kind == KindNumber && promptReq.Number.Units != "" {
+		// Quantities come back as typed, with their normalized value
+		if n, err := units.Parse(promptReq.Number.Units, response); err == nil {
+			addStructured(result, "value", n)
+			addStructured(result, "unit", units.Unit(promptReq.Number.Units))
+			addStructured(result, "raw", response)
+		}
+	} else if promptReq.Kind == KindNumber {
+		if n, err := strconv.ParseFloat(response, 64); err == nil {
+			addStructured(result, "value", n)
+		}
+	}
+	if timedOut {
+		addStructured(result, "timedOut", true)
+	} else if promptReq.Raw != nil {
+		addStructured(result, "raw", promptReq.Raw.Value())
+	}
+	if timedOut {
+		addStructured(result, "action", ActionTimedOut)
+	} else {
+		addStructured(result, "action", ActionSubmitted)
+	}
+	if len(promptReq.Suggestions) > 0 {
+		// Suggestions are offered, and picked, as written
+		picked := response
+		if promptReq.Raw != nil && !timedOut {
+			picked = promptReq.Raw.Value()
+		}
+		index := promptReq.suggestionIndex(picked)
+		addStructured(result, "suggested", index >= 0)
+		if index >= 0 {
+			addStructured(result, "suggestionIndex", index)
+		}
+	}
+	if promptReq.Sensitive {
+		markSensitive(result)
+	}
+	result["_meta"] = meta
+	s.sendResponse(req.ID, result)
+}
+
+// collectInput asks the provider for the prompt's method, warning the client
+// before the prompt times out.
+func (s *MCPServer) collectInput(req MCPRequest, prompt *PromptRequest, progressToken interface{}) (string, error) {
+	if err := s.usePromptMethod(req, prompt); err != nil {
+		return "", err
+	}
+	provider := s.provider(prompt.Method)
+	cfg := s.currentConfig()
+	cfg.applyPromptDefaults(prompt)
+	prompt.Client = s.ClientInfo()
+	if prompt.Asked.IsZero() {
+		prompt.Asked = time.Now()
+	}
+	s.applyAlert(prompt)
+
+	// The user may ask for the answer to be remembered, and reused for the
+	// same question without asking
+	var cache *AnswerCache
+	ttl := cfg.cacheTTL()
+	if ttl > 0 && cacheable(prompt) {
+		cache = s.AnswerCache()
+		if response, ok := cache.Lookup(prompt); ok && !prompt.CacheBypass {
+			s.answerFromCache(req, prompt, response)
+			return response, nil
+		}
+		prompt.Remember = &Remember{}
+	}
+
+	warning := s.scheduleTimeoutWarning(req, prompt, provider, progressToken)
+	defer warning.stop()
+
+	s.logf("Prompt %d: asking via %s: %q", prompt.ID, prompt.Method, prompt.loggedPrompt())
+
+	hub := s.observerHub()
+	hub.Publish(ObserverEvent{
+		Type:     EventPromptCreated,
+		PromptID: prompt.ID,
+		Prompt:   prompt.Prompt,
+		Method:   prompt.Method,
+		Client:   prompt.Client.String(),
+	})
+
+	asked := time.Now()
+	ctx, done := s.requestContext(req.ID)
+	defer done()
+	s.trackPending(1)
+	response, err := Ask(ctx, provider, prompt)
+	s.trackPending(-1)
+
+	resolved := ObserverEvent{
+		Type:     EventPromptResolved,
+		PromptID: prompt.ID,
+		Method:   prompt.Method,
+		Outcome:  OutcomeAnswered,
+	}
+	if !prompt.Secret {
+		resolved.Response = prompt.loggedResponse(response)
+	}
+	var declined *DeclinedError
+	switch {
+	case errors.Is(err, ErrTimeout):
+		resolved.Outcome = OutcomeTimeout
+		s.logf("Prompt %d: %v", prompt.ID, err)
+	case err != nil && errors.Is(context.Cause(ctx), ErrWithdrawn):
+		resolved.Outcome = OutcomeError
+		s.logf("Prompt %d: withdrawn by the client", prompt.ID)
+	case errors.As(err, &declined):
+		resolved.Outcome = OutcomeDeclined
+		s.logf("Prompt %d: declined", prompt.ID)
+	case err != nil:
+		resolved.Outcome = OutcomeError
+		if isAttemptsExhausted(err) && (prompt.Sensitive || prompt.Secret) {
+			// The reason quotes the last invalid answer
+			s.logf("Prompt %d: failed: %s", prompt.ID, redacted)
+		} else {
+			s.logf("Prompt %d: failed: %v", prompt.ID, err)
+		}
 	default:
-		http.Error(w, "Response already submitted", http.StatusBadRequest)
+		s.logf("Prompt %d: answered: %q", prompt.ID, prompt.loggedResponse(response))
+	}
+	hub.Publish(resolved)
+
+	entry := HistoryEntry{
+		ID:      prompt.ID,
+		Title:   prompt.Title,
+		Prompt:  prompt.Prompt,
+		Method:  prompt.Method,
+		Client:  prompt.Client.String(),
+		Outcome: resolved.Outcome,
+		Asked:   asked,
+		Took:    time.Since(asked),
+	}
+	if err == nil {
+		entry.Response = prompt.loggedResponse(response)
 	}
+	s.History().Add(entry)
+
+	if err == nil && prompt.Remember.Wanted() {
+		s.logf("Prompt %d: remembering the answer for %s", prompt.ID, formatSeconds(ttl))
+		cache.Store(prompt, response, ttl)
+	}
+	return response, err
 }
 
-func (h *WebInputHandler) shutdown() {
-	if h.server != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		h.server.Shutdown(ctx)
+// answerFromCache notes that prompt was answered with a remembered
+// response, in the result's _meta and the history, without asking.
+func (s *MCPServer) answerFromCache(req MCPRequest, prompt *PromptRequest, response string) {
+	s.logf("Prompt %d: answered from the cache: %q", prompt.ID, prompt.loggedResponse(response))
+	s.addResultMeta(req.ID, "cached", true)
+	s.History().Add(HistoryEntry{
+		ID:       prompt.ID,
+		Title:    prompt.Title,
+		Prompt:   prompt.Prompt,
+		Method:   prompt.Method,
+		Client:   prompt.Client.String(),
+		Outcome:  OutcomeCached,
+		Response: prompt.loggedResponse(response),
+		Asked:    time.Now(),
+	})
+}
+
+// History returns the prompts asked so far, for the dashboard's history
+// page. It is nil when the config keeps no history.
+func (s *MCPServer) History() *History {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history == nil {
+		size := s.config.historySize()
+		if size == 0 {
+			return nil
+		}
+		s.history = NewHistory(size)
 	}
+	return s.history
+}
+
+// SetObserverHub mirrors prompt lifecycle events to hub's observers.
+func (s *MCPServer) SetObserverHub(hub *ObserverHub) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = hub
 }
 
-func openBrowser(url string) error {
-	var cmd string
-	var args []string
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = "cmd"
-		args = []string{"/c", "start"}
-	case "darwin":
-		cmd = "open"
-	default: // "linux", "freebsd", "openbsd", "netbsd"
-		cmd = "xdg-open"
-	}
-	args = append(args, url)
-	return exec.Command(cmd, args...).Start()
+func (s *MCPServer) observerHub() *ObserverHub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.observers
 }
 
-func (s *MCPServer) handleUserInput(req MCPRequest, scanner *bufio.Scanner) {
+func (s *MCPServer) handleUserInput(req MCPRequest) {
 	paramsBytes, err := json.Marshal(req.Params)
 	if err != nil {
 		s.sendError(req.ID, -32602, "Invalid params")
@@ -433,8 +1042,23 @@ func (s *MCPServer) handleUserInput(req MCPRequest, scanner *bufio.Scanner) {
 		return
 	}
 
+	// The legacy method has no browser fallback
+	if !s.currentConfig().methodAllowed(MethodTTY) {
+		s.sendError(req.ID, -32603, "Failed to get user input: the tty method is not allowed on this server")
+		return
+	}
+
+	ctx, done := s.requestContext(req.ID)
+	defer done()
+	if userReq.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(userReq.Timeout)*time.Second)
+		defer cancel()
+	}
+
 	// Get user input from the controlling terminal, not from MCP stdin
-	response, err := s.getUserInputFromTTY(userReq.Prompt)
+	response, err := getUserInputFromTTY(ctx, userReq.Prompt)
+	response = strings.TrimSpace(response)
 	if err != nil {
 		result := UserInputResult{
 			Response: "",
@@ -453,26 +1077,68 @@ func (s *MCPServer) handleUserInput(req MCPRequest, scanner *bufio.Scanner) {
 }
 
 func (s *MCPServer) sendResponse(id interface{}, result interface{}) {
+	if s.takeWithdrawn(id) {
+		s.takeResultMeta(id)
+		return
+	}
+	if meta := s.takeResultMeta(id); meta != nil {
+		if m, ok := result.(map[string]interface{}); ok {
+			existing, _ := m["_meta"].(map[string]interface{})
+			if existing == nil {
+				existing = make(map[string]interface{})
+				m["_meta"] = existing
+			}
+			for key, value := range meta {
+				existing[key] = value
+			}
+		}
+	}
+
 	resp := MCPResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result:  result,
 	}
 
-	data, _ := json.Marshal(resp)
-	fmt.Fprintf(s.stdout, "%s\n", data)
+	s.writeMessage(resp)
 }
 
 func (s *MCPServer) sendError(id interface{}, code int, message string) {
+	s.sendErrorData(id, code, message, nil)
+}
+
+func (s *MCPServer) sendErrorData(id interface{}, code int, message string, data interface{}) {
+	s.takeResultMeta(id)
+	if s.takeWithdrawn(id) {
+		return
+	}
 	resp := MCPResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &MCPError{
 			Code:    code,
 			Message: message,
+			Data:    data,
 		},
 	}
 
-	data, _ := json.Marshal(resp)
+	s.writeMessage(resp)
+}
+
+func (s *MCPServer) sendNotification(method string, params interface{}) {
+	s.writeMessage(MCPNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// writeMessage serializes writes to stdout, since notifications may be sent
+// from timers while a tool call is in progress.
+func (s *MCPServer) writeMessage(msg interface{}) {
+	data, _ := json.Marshal(msg)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
 	fmt.Fprintf(s.stdout, "%s\n", data)
 }
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxSingleKeyOptions is the most options a single_key choice may have, so
+// that each is picked with one digit.
+const maxSingleKeyOptions = 9
+
+// readTTYSingleKey answers a choice, reaction or confirm prompt with one keypress on
+// a terminal in raw mode: a digit picks an option, y or n confirms, and
+// Enter takes a confirm prompt's default. Other keys beep and are ignored.
+// The selection is echoed once made.
+func readTTYSingleKey(tty io.ReadWriter, label string, req *PromptRequest) (string, error) {
+	fmt.Fprint(tty, label)
+	for {
+		key, err := readTTYKey(tty)
+		if err != nil {
+			fmt.Fprint(tty, "\n")
+			return "", err
+		}
+
+		response, ok := singleKeyResponse(req, key)
+		if !ok {
+			fmt.Fprint(tty, "\a")
+			continue
+		}
+		answer, err := req.Validate(response)
+		if err != nil {
+			fmt.Fprint(tty, "\a")
+			continue
+		}
+		if n, err := strconv.Atoi(response); err == nil {
+			fmt.Fprintf(tty, "%d) %s\n", n, req.Options[n-1])
+		} else {
+			fmt.Fprintf(tty, "%s\n", answer)
+		}
+		return answer, nil
+	}
+}
+
+// singleKeyResponse maps a keypress to the response it stands for.
+func singleKeyResponse(req *PromptRequest, key string) (string, bool) {
+	if req.Kind == KindChoice || req.Kind == KindReaction {
+		n, err := strconv.Atoi(key)
+		return key, err == nil && n >= 1 && n <= len(req.Options)
+	}
+	switch strings.ToLower(key) {
+	case "y", "n":
+		return key, true
+	case string(rune(keyReturn)), string(rune(keyNewline)):
+		return "", req.Default != ""
+	}
+	return "", false
+}
+
+// readTTYKey reads one keypress from a terminal in raw mode. Ctrl+C ends
+// the prompt and Ctrl+D declines it.
+func readTTYKey(tty io.Reader) (string, error) {
+	key := make([]byte, 1)
+	if _, err := tty.Read(key); err != nil {
+		if err == io.EOF {
+			return "", fmt.Errorf("terminal closed before a valid response was entered")
+		}
+		return "", fmt.Errorf("failed to read from terminal: %w", err)
+	}
+	switch key[0] {
+	case keyCtrlC:
+		return "", fmt.Errorf("input cancelled")
+	case keyCtrlD:
+		return "", &DeclinedError{}
+	}
+	return string(key), nil
+}
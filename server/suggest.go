@@ -0,0 +1,51 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseSuggestions reads the suggestions argument of user_input. An empty
+// list is the same as none.
+func parseSuggestions(args map[string]interface{}) ([]string, error) {
+	suggestions, _, err := optionalStringList(args, "suggestions")
+	if err != nil {
+		return nil, err
+	}
+	for i, suggestion := range suggestions {
+		if strings.TrimSpace(suggestion) == "" {
+			return nil, fmt.Errorf("Invalid suggestions parameter: element %d is blank", i)
+		}
+		for _, earlier := range suggestions[:i] {
+			if earlier == suggestion {
+				return nil, fmt.Errorf("Invalid suggestions parameter: %q is listed twice", suggestion)
+			}
+		}
+	}
+	if len(suggestions) == 0 {
+		return nil, nil
+	}
+	return suggestions, nil
+}
+
+// expandSuggestion turns a suggestion's number, typed on the terminal, into
+// the suggestion. Anything else is returned unchanged.
+func (r *PromptRequest) expandSuggestion(response string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(response))
+	if err != nil || n < 1 || n > len(r.Suggestions) {
+		return response
+	}
+	return r.Suggestions[n-1]
+}
+
+// suggestionIndex returns the position of answer among the prompt's
+// suggestions, or -1 when the user typed something else.
+func (r *PromptRequest) suggestionIndex(answer string) int {
+	for i, suggestion := range r.Suggestions {
+		if answer == normalizeAnswer(suggestion, r.Trim, r.Dedent) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Theme limits.
+const (
+	maxThemeTitle    = 100
+	maxThemeFooter   = 500
+	maxThemeLogoSize = 1024 * 1024
+)
+
+// themeLogoPath is where a logo file is served under /static/.
+const themeLogoPath = "theme-logo"
+
+// Built-in accent colours, which a theme's accent replaces.
+const (
+	defaultAccent      = "#007cba"
+	defaultAccentHover = "#005a87"
+)
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// WebTheme brands the web prompt pages, so users can tell them from a
+// lookalike. The zero value keeps the built-in look.
+type WebTheme struct {
+	// PageTitle names the pages in a header above the prompt and in the
+	// browser tab, such as "Acme Tools"
+	PageTitle string `json:"page_title"`
+
+	// Logo is an http(s) URL, or the path of a PNG, JPEG, GIF, WebP or SVG
+	// file read at startup and served with the pages
+	Logo string `json:"logo"`
+
+	// AccentColor replaces the blue of borders and buttons, as #rgb or
+	// #rrggbb
+	AccentColor string `json:"accent_color"`
+
+	// Footer is plain text shown under every page
+	Footer string `json:"footer"`
+}
+
+// Validate checks the theme without reading the logo file.
+func (t WebTheme) Validate() error {
+	if strings.ContainsAny(t.PageTitle, "\r\n") || utf8.RuneCountInString(t.PageTitle) > maxThemeTitle {
+		return fmt.Errorf("theme page_title must be a single line of at most %d characters", maxThemeTitle)
+	}
+	if utf8.RuneCountInString(t.Footer) > maxThemeFooter {
+		return fmt.Errorf("theme footer must be at most %d characters", maxThemeFooter)
+	}
+	if t.AccentColor != "" && !hexColor.MatchString(t.AccentColor) {
+		return fmt.Errorf("theme accent_color must be a hex colour such as #1a7f37 (got %q)", t.AccentColor)
+	}
+	if isThemeURL(t.Logo) {
+		if u, err := url.Parse(t.Logo); err != nil || u.Host == "" {
+			return fmt.Errorf("theme logo %q is not a valid URL", t.Logo)
+		}
+	}
+	return nil
+}
+
+func isThemeURL(logo string) bool {
+	return strings.HasPrefix(logo, "http://") || strings.HasPrefix(logo, "https://")
+}
+
+// PageTheme is a theme as the templates see it, part of PageData. Logo is
+// the URL to load the logo from, AccentHover a darker shade of Accent for
+// hovered buttons; both colours are #rrggbb.
+type PageTheme struct {
+	Title       string
+	Logo        string
+	Accent      string
+	AccentHover string
+	Footer      string
+}
+
+// loadedTheme is the theme in use, with its logo file read.
+type loadedTheme struct {
+	theme    WebTheme
+	logo     []byte
+	logoType string
+}
+
+var (
+	webThemeMu sync.Mutex
+	webTheme   loadedTheme
+)
+
+// SetWebTheme brands the web pages with theme. A logo file is read now, so
+// the pages never depend on it later; the theme in use is kept if it can't
+// be read or isn't an image.
+func SetWebTheme(theme WebTheme) error {
+	if err := theme.Validate(); err != nil {
+		return err
+	}
+	loaded := loadedTheme{theme: theme}
+	if theme.Logo != "" && !isThemeURL(theme.Logo) {
+		var err error
+		if loaded.logo, loaded.logoType, err = readThemeLogo(theme.Logo); err != nil {
+			return err
+		}
+	}
+
+	webThemeMu.Lock()
+	defer webThemeMu.Unlock()
+	webTheme = loaded
+	return nil
+}
+
+func currentWebTheme() loadedTheme {
+	webThemeMu.Lock()
+	defer webThemeMu.Unlock()
+	return webTheme
+}
+
+// readThemeLogo reads a logo file and works out its type from the content;
+// SVG, which can't be sniffed, also needs its extension.
+func readThemeLogo(path string) ([]byte, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("theme logo: %w", err)
+	}
+	if info.Size() > maxThemeLogoSize {
+		return nil, "", fmt.Errorf("theme logo %s is larger than %d bytes", path, maxThemeLogoSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("theme logo: %w", err)
+	}
+	mimeType := http.DetectContentType(data)
+	if _, ok := imageExtensions[mimeType]; ok {
+		return data, mimeType, nil
+	}
+	if strings.EqualFold(filepath.Ext(path), ".svg") && bytes.Contains(data, []byte("<svg")) {
+		return data, "image/svg+xml", nil
+	}
+	return nil, "", fmt.Errorf("theme logo %s must be a PNG, JPEG, GIF, WebP or SVG image", path)
+}
+
+// page returns the theme for the templates under base, or nil when there
+// is none, so the pages look exactly as without theming.
+func (t loadedTheme) page(base string) *PageTheme {
+	if t.theme == (WebTheme{}) {
+		return nil
+	}
+	page := &PageTheme{Title: t.theme.PageTitle, Logo: t.theme.Logo, Footer: t.theme.Footer}
+	if t.logo != nil {
+		page.Logo = base + "/static/" + themeLogoPath
+	}
+	if t.theme.AccentColor != "" {
+		page.Accent = expandHexColor(t.theme.AccentColor)
+		page.AccentHover = darken(page.Accent)
+	}
+	return page
+}
+
+// serveLogo writes the logo file, if the theme has one. An SVG may not run
+// scripts or load anything, even when opened on its own.
+func (t loadedTheme) serveLogo(w http.ResponseWriter, r *http.Request) {
+	if t.logo == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", t.logoType)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	w.Write(t.logo)
+}
+
+// expandHexColor writes #rgb as #rrggbb, lowercased.
+func expandHexColor(color string) string {
+	color = strings.ToLower(color)
+	if len(color) == 4 {
+		return string([]byte{'#', color[1], color[1], color[2], color[2], color[3], color[3]})
+	}
+	return color
+}
+
+// darken returns the hover shade of an #rrggbb colour, as the built-in
+// #005a87 is of #007cba.
+func darken(color string) string {
+	n, _ := strconv.ParseUint(color[1:], 16, 32)
+	r, g, b := n>>16, n>>8&0xff, n&0xff
+	shade := func(c uint64) uint64 { return c * 73 / 100 }
+	return fmt.Sprintf("#%02x%02x%02x", shade(r), shade(g), shade(b))
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// timeoutWarning fires at most once, and never after stop has returned.
+type timeoutWarning struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+func (w *timeoutWarning) stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	w.timer.Stop()
+}
+
+// scheduleTimeoutWarning arms the warning sent to the client (and the user,
+// through the provider) once the configured fraction of the prompt's timeout
+// has elapsed. It returns nil when the prompt has no timeout or warnings are
+// disabled.
+func (s *MCPServer) scheduleTimeoutWarning(req MCPRequest, prompt *PromptRequest, provider InputProvider, progressToken interface{}) *timeoutWarning {
+	fraction := s.currentConfig().warnFraction()
+	if prompt.Timeout <= 0 || fraction <= 0 || fraction >= 1 {
+		return nil
+	}
+
+	start := time.Now()
+	w := &timeoutWarning{}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = time.AfterFunc(time.Duration(float64(prompt.Timeout)*fraction), func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.stopped {
+			return
+		}
+
+		elapsed := time.Since(start)
+		remaining := prompt.Timeout - elapsed
+		seconds := int(math.Ceil(remaining.Seconds()))
+
+		s.sendNotification("notifications/message", map[string]interface{}{
+			"level":  "warning",
+			"logger": "prompt-mcp",
+			"data": map[string]interface{}{
+				"event":            "prompt_timeout_warning",
+				"promptId":         prompt.ID,
+				"requestId":        req.ID,
+				"prompt":           prompt.Prompt,
+				"secondsRemaining": seconds,
+			},
+		})
+
+		if progressToken != nil {
+			s.sendNotification("notifications/progress", map[string]interface{}{
+				"progressToken": progressToken,
+				"progress":      elapsed.Seconds(),
+				"total":         prompt.Timeout.Seconds(),
+				"message":       fmt.Sprintf("Prompt %d times out in %ds", prompt.ID, seconds),
+			})
+		}
+
+		if warner, ok := provider.(TimeoutWarner); ok {
+			warner.WarnTimeout(prompt, remaining)
+		}
+	})
+
+	return w
+}
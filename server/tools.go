@@ -0,0 +1,1244 @@
+package server
+
+import (
+	"strings"
+
+	"prompt-mcp/i18n"
+	"prompt-mcp/units"
+)
+
+type toolHandler func(s *MCPServer, req MCPRequest, args map[string]interface{}, progressToken interface{})
+
+// toolDefinition is a tool the server can expose through tools/list and
+// tools/call.
+type toolDefinition struct {
+	Name        string
+	Title       string
+	Description string
+	InputSchema map[string]interface{}
+	Annotations ToolAnnotations
+	handler     toolHandler
+}
+
+// ToolAnnotations are the MCP hints a client uses to decide whether a tool
+// call needs approval. The title is filled in from the tool's.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+// userAnnotations describe a tool that only talks to the user: it changes
+// nothing on the host and reaches nothing beyond the user, but asking again
+// may get a different answer.
+var userAnnotations = ToolAnnotations{ReadOnlyHint: true}
+
+// annotations returns the tool's annotations with its title.
+func (t toolDefinition) annotations() ToolAnnotations {
+	annotations := t.Annotations
+	annotations.Title = t.Title
+	return annotations
+}
+
+// builtinTools returns every tool the server knows about, in listing order.
+func builtinTools() []toolDefinition {
+	return []toolDefinition{
+		{
+			Name:        "user_input",
+			Title:       "Ask the User",
+			Description: "Request input or approval from the user. Pass response_schema (a JSON Schema object) to get a JSON answer validated against it, returned parsed in structuredContent.value; simple object schemas are shown to the user as form fields",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The prompt to show to the user",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short heading shown above the prompt (the page title in the browser)",
+					},
+					"detail": map[string]interface{}{
+						"type":        "string",
+						"description": "Background the user may need to answer, shown below the prompt (collapsible in the browser)",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Format of prompt and detail. 'markdown' is rendered on the terminal (styled when it supports color, plain text otherwise). In the browser, fenced code blocks in the prompt are highlighted whatever the format",
+						"enum":        []string{FormatText, FormatMarkdown},
+						"default":     FormatText,
+					},
+					"urgency": map[string]interface{}{
+						"type":        "string",
+						"description": "How much the answer matters. 'critical' prompts, e.g. before destructive actions, are styled to stand out",
+						"enum":        []string{UrgencyLow, UrgencyNormal, UrgencyCritical},
+						"default":     UrgencyNormal,
+					},
+					"locale":   localeSchema(),
+					"priority": prioritySchema(),
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait for an answer before giving up with an error result; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"max_attempts":         maxAttemptsSchema(),
+					"cache_bypass":         cacheBypassSchema(),
+					"require_confirmation": requireConfirmationSchema(),
+					"method":               methodSchema(),
+					"default": map[string]interface{}{
+						"type":        "string",
+						"description": "Answer used when the user submits an empty response. Pre-filled in the browser; _meta.defaultUsed reports whether it was kept",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Answer type. 'number' and 'integer' only accept numbers written with '.' as the decimal separator and no grouping (e.g. 1234.5), and return the value in structuredContent.value. 'duration' takes Go-style durations ('90s', '5m', '1h30m') or plain numbers of default_unit, and returns the value in result_unit with the text as typed. 'path' asks for an existing file or directory, with Tab completion in the terminal, and returns it absolute",
+						"enum":        []string{TypeText, TypeNumber, TypeInteger, TypeDuration, TypePath},
+						"default":     TypeText,
+					},
+					"root": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory path answers are resolved against (type path). When given, completion and answers are kept inside it",
+					},
+					"units": map[string]interface{}{
+						"type":        "string",
+						"description": "Accept a quantity in human formats (type number): 'duration' takes Go durations and phrases like '5 min' or '2 hours', 'bytes' takes sizes like '1.5GB' or '64KiB', 'percent' takes '20%'. structuredContent.value is normalized to seconds, bytes or a fraction (0.2 for 20%), and structuredContent.raw is the answer as typed. minimum and maximum are in the normalized unit",
+						"enum":        units.Kinds(),
+					},
+					"default_unit": map[string]interface{}{
+						"type":        "string",
+						"description": "Unit of a plain number typed as a duration (type duration)",
+						"enum":        durationUnitSymbols(),
+						"default":     "s",
+					},
+					"result_unit": map[string]interface{}{
+						"type":        "string",
+						"description": "Unit structuredContent.value is returned in (type duration)",
+						"enum":        durationUnitSymbols(),
+						"default":     "s",
+					},
+					"minimum": map[string]interface{}{
+						"type":        []string{"number", "string"},
+						"description": "Smallest accepted number (type number or integer), or duration such as '30s' (type duration)",
+					},
+					"maximum": map[string]interface{}{
+						"type":        []string{"number", "string"},
+						"description": "Largest accepted number (type number or integer), or duration such as '24h' (type duration)",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression (Go syntax) the whole answer must match. The user is asked again when it doesn't",
+					},
+					"validation_message": map[string]interface{}{
+						"type":        "string",
+						"description": "Message shown to the user when the answer doesn't match pattern",
+					},
+					"placeholder": map[string]interface{}{
+						"type":        "string",
+						"description": "Hint shown in the empty answer field, e.g. 'v1.2.3'. Never returned as the answer; use default for that",
+					},
+					"submit_label": map[string]interface{}{
+						"type":        "string",
+						"description": "Plain text on the submit button instead of 'Submit', e.g. 'Deploy', and first in the terminal's hint. structuredContent.action is 'submitted' or 'declined' whatever the labels say",
+						"maxLength":   maxButtonLabel,
+					},
+					"decline_label": map[string]interface{}{
+						"type":        "string",
+						"description": "Plain text on the decline button instead of 'Decline', e.g. 'Abort', and second in the terminal's hint",
+						"maxLength":   maxButtonLabel,
+					},
+					"normalize": map[string]interface{}{
+						"type":        "array",
+						"description": "Transforms applied, in the order listed, to a text answer before it is validated and returned: 'trim' strips surrounding whitespace, 'lower' / 'upper' change case, 'collapse_whitespace' turns each run of whitespace into one space, 'slug' lowercases and joins letters and digits with hyphens (e.g. for branch names). structuredContent.raw holds the answer as typed",
+						"items": map[string]interface{}{
+							"type": "string",
+							"enum": transformNames,
+						},
+						"minItems": 1,
+					},
+					"suggestions": map[string]interface{}{
+						"type":        "array",
+						"description": "Likely answers offered as one-click buttons in the browser and numbered shortcuts on the terminal. Any other text is still accepted; structuredContent.suggested tells which was used",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"sensitive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Keep the answer out of server logs, traces and observer events, and out of pages shown after submitting. The result still contains it, with content annotated for the assistant only",
+						"default":     false,
+					},
+					"allowed_values": map[string]interface{}{
+						"type":        "array",
+						"description": "The only accepted answers, compared ignoring case and surrounding whitespace. The user is asked again, with the list, when the answer isn't one of them; the value is returned as written here",
+						"items":       map[string]interface{}{"type": "string"},
+						"minItems":    1,
+					},
+					"min_length": map[string]interface{}{
+						"type":        "integer",
+						"description": "Fewest characters (Unicode code points) a text answer may have. Shorter answers are asked again",
+						"minimum":     1,
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most characters (Unicode code points) a text answer may have. Longer answers are asked again, never truncated; the browser shows a live counter",
+						"minimum":     1,
+					},
+					"default_response": map[string]interface{}{
+						"type":        "string",
+						"description": "Answer returned, as a successful result with structuredContent.timedOut true, when the timeout expires without an answer. Requires a timeout (web prompts have one by default) and must pass the prompt's validation",
+					},
+					"confirmation_phrase": confirmationPhraseSchema(),
+					"case_insensitive":    caseInsensitiveSchema(),
+					"images": map[string]interface{}{
+						"type":        "array",
+						"description": "Images for the user to look at, such as screenshots or charts, shown above the answer field (at most 10, 5MB each, 20MB in all). Without an explicit method the browser is used; with 'tty' they are saved to temp files whose paths are printed",
+						"maxItems":    maxImages,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"data": map[string]interface{}{
+									"type":        "string",
+									"description": "Base64-encoded image data",
+								},
+								"mimeType": map[string]interface{}{
+									"type": "string",
+									"enum": []string{"image/png", "image/jpeg", "image/gif", "image/webp"},
+								},
+							},
+							"required": []string{"data", "mimeType"},
+						},
+					},
+					"attachments": attachmentsSchema(),
+					"context":     contextSchema(),
+					"accept_file": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Ask the user for a file instead of text: a file input in the browser, a local path on the terminal. Files over the server's size cap (5MB by default) are refused. The result has the file's name, mimeType, size and sha256 in structuredContent. Can't be combined with the arguments that shape a text answer",
+						"default":     false,
+					},
+					"return_as": map[string]interface{}{
+						"type":        "string",
+						"description": "With accept_file, how the file comes back: 'base64' inline as an MCP content block (image, audio or an embedded resource blob, with its mimeType) or 'path', saved to a temp file whose path is returned in structuredContent.path",
+						"enum":        []string{ReturnBase64, ReturnPath},
+						"default":     ReturnBase64,
+					},
+					"allow_empty": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Accept an empty answer. Otherwise the user is asked again until they answer (an empty answer to a prompt with a default still accepts the default)",
+						"default":     false,
+					},
+					"response_schema": map[string]interface{}{
+						"type":        "object",
+						"description": "JSON Schema the answer must match. Supports type, enum, const, properties, required, additionalProperties, items, minItems, maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength and pattern; other keywords are rejected. Objects whose properties are all strings, numbers, integers or booleans are asked as form fields unless multiline is set; anything else is typed as JSON",
+					},
+					"delivery": map[string]interface{}{
+						"type":        "string",
+						"description": "How answers are returned: 'inline' (large answers become a resource link) or 'chunked' (split into numbered text blocks)",
+						"enum":        []string{DeliveryInline, DeliveryChunked},
+						"default":     DeliveryInline,
+					},
+					"encoding": map[string]interface{}{
+						"type":        "string",
+						"description": "Answer encoding: 'text' or 'base64' (binary-safe, payload in structuredContent.data). Defaults to base64 only when the answer is not valid UTF-8",
+						"enum":        []string{EncodingText, EncodingBase64},
+					},
+					"trim": map[string]interface{}{
+						"type":        "string",
+						"description": "Whitespace trimming applied to the answer. Defaults to 'both' for tty and 'none' for web",
+						"enum":        []string{TrimBoth, TrimTrailing, TrimNone},
+					},
+					"dedent": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Strip the indentation common to every line of a multi-line answer",
+						"default":     false,
+					},
+					"multiline": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Accept several lines (a textarea in the browser; on the terminal, finish with a line containing only '.' or Ctrl+D). Disables trimming unless trim is given",
+						"default":     false,
+					},
+					"expected_length": map[string]interface{}{
+						"type":        "string",
+						"description": "How long the answer is likely to be. 'long' gives the browser a resizable textarea (Ctrl+Enter submits) whose line breaks are kept; the terminal still reads one line, unlike multiline. 'short' keeps a one-line input. Without it, prompts ending in 'explain' or containing a code fence get the textarea",
+						"enum":        []string{ExpectedShort, ExpectedLong},
+					},
+					"secret": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Hide the answer while it is typed (no terminal echo, password field in the browser), e.g. for API keys",
+						"default":     false,
+					},
+					"confirm_secret": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Ask for the secret twice and only accept matching entries. Implies secret",
+						"default":     false,
+					},
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserInputTool,
+		},
+		{
+			Name:        "user_choice",
+			Title:       "Ask the User to Choose",
+			Description: "Ask the user to pick one option from a fixed list. Returns the exact option string chosen",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The question to show to the user",
+					},
+					"options": map[string]interface{}{
+						"type":        "array",
+						"description": "The options the user chooses between",
+						"items":       map[string]interface{}{"type": "string"},
+						"minItems":    1,
+					},
+					"multi_select": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Let the user pick several options. The answer is then a JSON array of the picked options in their original order",
+						"default":     false,
+					},
+					"min_selections": map[string]interface{}{
+						"type":        "integer",
+						"description": "Fewest options the user must pick with multi_select (default 1)",
+						"minimum":     0,
+					},
+					"max_selections": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most options the user may pick with multi_select (default all of them)",
+						"minimum":     1,
+					},
+					"allow_other": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Add an \"Other…\" entry letting the user type an answer of their own. structuredContent then has selected (the option, or \"other\"), text and custom, and a custom answer's text starts with \"Other: \". Not supported with multi_select",
+						"default":     false,
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression the text typed for Other must match in full (requires allow_other)",
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most characters the text typed for Other may have (requires allow_other)",
+						"minimum":     1,
+					},
+					"context": contextSchema(),
+					"single_key": map[string]interface{}{
+						"type":        "boolean",
+						"description": "On the terminal, pick an option with one digit key, without Enter. Needs at most 9 options and isn't supported with multi_select, allow_other or context. Falls back to typed answers where the terminal can't deliver single keys; the browser is unaffected",
+						"default":     false,
+					},
+					"max_attempts":         maxAttemptsSchema(),
+					"cache_bypass":         cacheBypassSchema(),
+					"require_confirmation": requireConfirmationSchema(),
+					"method":               methodSchema(),
+				},
+				"required": []string{"prompt", "options"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserChoiceTool,
+		},
+		{
+			Name:        "user_confirm",
+			Title:       "Ask the User to Confirm",
+			Description: "Ask the user a yes/no question. Returns \"yes\" or \"no\", with a confirmed boolean in structuredContent",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The question to show to the user",
+					},
+					"default": map[string]interface{}{
+						"type":        "string",
+						"description": "Answer used when the user just presses Enter on the terminal",
+						"enum":        []string{AnswerYes, AnswerNo},
+					},
+					"confirmation_phrase": confirmationPhraseSchema(),
+					"case_insensitive":    caseInsensitiveSchema(),
+					"attachments":         attachmentsSchema(),
+					"context":             contextSchema(),
+					"single_key": map[string]interface{}{
+						"type":        "boolean",
+						"description": "On the terminal, answer with the y or n key (Enter takes the default) without pressing Enter. Not supported with confirmation_phrase, follow_up, attachments or context. Falls back to typed answers where the terminal can't deliver single keys; the browser is unaffected",
+						"default":     false,
+					},
+					"follow_up": map[string]interface{}{
+						"type":        "object",
+						"description": "A question asked right after the confirmation, in the same session, when the answer matches condition. Both answers come back together: structuredContent.follow_up holds the follow-up's answer when it was asked. Takes the checks of user_input that suit its type; follow-ups can't be nested or combined with confirmation_phrase",
+						"properties": map[string]interface{}{
+							"condition": map[string]interface{}{
+								"type":        "string",
+								"description": "When to ask the follow-up",
+								"enum":        []string{FollowUpOnYes, FollowUpOnNo},
+								"default":     FollowUpOnYes,
+							},
+							"prompt": map[string]interface{}{
+								"type":        "string",
+								"description": "The follow-up question",
+							},
+							"type": map[string]interface{}{
+								"type": "string",
+								"enum": []string{"text", "number", "integer", "boolean", "choice"},
+							},
+							"options": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "Options of a choice question",
+							},
+							"default":            map[string]interface{}{"description": "Answer used when the follow-up is left empty"},
+							"pattern":            map[string]interface{}{"type": "string"},
+							"validation_message": map[string]interface{}{"type": "string"},
+							"allowed_values":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+							"min_length":         map[string]interface{}{"type": "integer", "minimum": 0},
+							"max_length":         map[string]interface{}{"type": "integer", "minimum": 1},
+							"minimum":            map[string]interface{}{"type": "number"},
+							"maximum":            map[string]interface{}{"type": "number"},
+						},
+						"required": []string{"prompt"},
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"on_timeout": map[string]interface{}{
+						"type":        "string",
+						"description": "What an unanswered prompt decides when the timeout expires: 'deny' answers no and 'approve' answers yes, with structuredContent.timedOut true; 'error' returns the usual timeout error. Use 'deny' for security-sensitive approvals so they fail closed. 'approve' can't be combined with confirmation_phrase, and neither with follow_up",
+						"enum":        []string{OnTimeoutError, OnTimeoutDeny, OnTimeoutApprove},
+						"default":     OnTimeoutError,
+					},
+					"max_attempts":         maxAttemptsSchema(),
+					"cache_bypass":         cacheBypassSchema(),
+					"require_confirmation": requireConfirmationSchema(),
+					"method":               methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserConfirmTool,
+		},
+		{
+			Name:        "user_ack",
+			Title:       "Wait for the User to Continue",
+			Description: "Show the user a message and wait until they acknowledge it: Enter on the terminal, a Continue button in the browser. Use it as a checkpoint, e.g. \"press Enter once the backup is done\". Returns acknowledged and elapsedSeconds (how long the user took) in structuredContent",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The message to show to the user",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short heading shown above the message",
+					},
+					"detail": map[string]interface{}{
+						"type":        "string",
+						"description": "Background shown below the message (collapsible in the browser)",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Format of prompt and detail",
+						"enum":        []string{FormatText, FormatMarkdown},
+						"default":     FormatText,
+					},
+					"urgency": map[string]interface{}{
+						"type":        "string",
+						"description": "How much the message matters",
+						"enum":        []string{UrgencyLow, UrgencyNormal, UrgencyCritical},
+						"default":     UrgencyNormal,
+					},
+					"locale":   localeSchema(),
+					"priority": prioritySchema(),
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"acknowledge_on_timeout": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Treat the timeout expiring as an acknowledgement (structuredContent.timedOut true) instead of an error result",
+						"default":     false,
+					},
+					"method": methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserAckTool,
+		},
+		{
+			Name:        "user_read",
+			Title:       "Ask the User to Read Something",
+			Description: "Show the user a long text, such as a license or an incident summary, and ask them to confirm they have read it. The terminal pages through it (Space for more, q to finish); the browser shows it in a scrollable box and only enables the confirm button once it has been scrolled to the end. Returns acknowledged and elapsedSeconds (how long the user took) in structuredContent",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "The text to read, up to 4MB",
+					},
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The message shown above the text (default \"Please read the following\")",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short heading shown above the message",
+					},
+					"urgency": map[string]interface{}{
+						"type":        "string",
+						"description": "How much the text matters",
+						"enum":        []string{UrgencyLow, UrgencyNormal, UrgencyCritical},
+						"default":     UrgencyNormal,
+					},
+					"locale":   localeSchema(),
+					"priority": prioritySchema(),
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever. Defaults to no timeout for tty and 300 for web, which may be too short for a long text",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"body"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserReadTool,
+		},
+		{
+			Name:        "user_form",
+			Title:       "Ask the User to Fill In a Form",
+			Description: "Ask the user several related questions in one form. Returns a JSON object keyed by field name",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the form",
+					},
+					"fields": map[string]interface{}{
+						"type":        "array",
+						"description": "The form fields, asked in order",
+						"minItems":    1,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name": map[string]interface{}{
+									"type":        "string",
+									"description": "Key of the field in the result",
+								},
+								"label": map[string]interface{}{
+									"type":        "string",
+									"description": "Text shown to the user; defaults to the name",
+								},
+								"type": map[string]interface{}{
+									"type":    "string",
+									"enum":    []string{FieldText, FieldBoolean, FieldSelect, FieldNumber},
+									"default": FieldText,
+								},
+								"default": map[string]interface{}{
+									"description": "Value used when the field is left empty: a string, boolean or number matching the type",
+								},
+								"options": map[string]interface{}{
+									"type":        "array",
+									"description": "Choices of a select field",
+									"items":       map[string]interface{}{"type": "string"},
+								},
+							},
+							"required": []string{"name"},
+						},
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt", "fields"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserFormTool,
+		},
+		{
+			Name:        "user_wizard",
+			Title:       "Walk the User Through Steps",
+			Description: "Ask the user a sequence of questions in one session, where later steps can depend on earlier answers (e.g. a confirmation phrase only for production). Steps whose condition isn't met are skipped. Returns a JSON object of step id to answer; on timeout, the answers given so far with timedOut",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the steps",
+					},
+					"steps": map[string]interface{}{
+						"type":        "array",
+						"description": "The steps, asked in order",
+						"minItems":    1,
+						"maxItems":    maxWizardSteps,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"id": map[string]interface{}{
+									"type":        "string",
+									"description": "Unique id the answer is returned under",
+								},
+								"prompt": map[string]interface{}{
+									"type":        "string",
+									"description": "The question",
+								},
+								"type": map[string]interface{}{
+									"type":        "string",
+									"description": "Answer type; 'phrase' steps pass only when the user types the phrase",
+									"enum":        []string{"text", "number", "boolean", "choice", stepPhrase},
+									"default":     "text",
+								},
+								"options": map[string]interface{}{
+									"type":        "array",
+									"items":       map[string]interface{}{"type": "string"},
+									"description": "The options of a choice step",
+								},
+								"phrase": map[string]interface{}{
+									"type":        "string",
+									"description": "What the user must type in a phrase step, such as the environment name",
+								},
+								"when": map[string]interface{}{
+									"type":        "object",
+									"description": "Only ask this step when an earlier step's answer equals a value",
+									"properties": map[string]interface{}{
+										"step": map[string]interface{}{
+											"type":        "string",
+											"description": "Id of an earlier step",
+										},
+										"equals": map[string]interface{}{
+											"description": "The answer that enables this step: a string, number or boolean matching the earlier step's type",
+										},
+									},
+									"required": []string{"step", "equals"},
+								},
+							},
+							"required": []string{"id", "prompt"},
+						},
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait for all steps; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"steps"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserWizardTool,
+		},
+		{
+			Name:        "user_file_select",
+			Title:       "Ask the User for a File",
+			Description: "Ask the user to pick a file or directory. Returns the absolute, cleaned path",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "What the path is for",
+					},
+					"start_dir": map[string]interface{}{
+						"type":        "string",
+						"description": "Directory relative paths are resolved against and browsing starts in. Defaults to the server's working directory",
+					},
+					"must_exist": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only accept paths that exist",
+						"default":     false,
+					},
+					"directories_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only accept directories",
+						"default":     false,
+					},
+					"restrict_to_start_dir": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Reject paths outside start_dir, following symlinks",
+						"default":     false,
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserFileSelectTool,
+		},
+		{
+			Name:        "notify_user",
+			Title:       "Notify the User",
+			Description: "Show the user a message without waiting for a reply. Returns as soon as the message is displayed",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "The message to show",
+					},
+					"priority": prioritySchema(),
+					"method":   methodSchema(),
+				},
+				"required": []string{"message"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleNotifyUserTool,
+		},
+		{
+			Name:        "user_review",
+			Title:       "Ask the User for a Review",
+			Description: "Show the user a diff or plan and ask them to approve it, reject it, or approve it with a comment",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The diff or plan to review, shown verbatim in a monospace block",
+					},
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the content",
+					},
+					"method": methodSchema(),
+				},
+				"required": []string{"content"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserReviewTool,
+		},
+		{
+			Name:        "user_plan_review",
+			Title:       "Ask the User to Review a Plan",
+			Description: "Show the user a plan and let them approve it, deny it, or ask for changes. structuredContent.decision is 'approve', 'deny' or 'revise'; for 'revise', structuredContent.instructions says what to change",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"plan": map[string]interface{}{
+						"type":        "string",
+						"description": "The plan to review, shown verbatim in a monospace block",
+					},
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the plan",
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"method": methodSchema(),
+				},
+				"required": []string{"plan"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserPlanReviewTool,
+		},
+		{
+			Name:        "user_edit",
+			Title:       "Let the User Edit Text",
+			Description: "Open content such as a commit message or config snippet in the user's editor ($VISUAL or $EDITOR) and return it as they saved it. structuredContent.unchanged is true when nothing was modified",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The text to edit; may be empty to let the user write from scratch",
+					},
+					"file_extension": map[string]interface{}{
+						"type":        "string",
+						"description": "Extension for the temp file, such as 'md' or 'yaml', so the editor picks the right syntax",
+					},
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown before the editor opens",
+					},
+					"method": methodSchema(),
+				},
+				"required": []string{"content"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserEditTool,
+		},
+		{
+			Name:        "user_rating",
+			Title:       "Ask the User for a Rating",
+			Description: "Ask the user for a rating on a numeric scale, such as how confident they are in a plan. Returns the rating and its fraction of the maximum (4 of 5 is 0.8)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The question to rate",
+					},
+					"min": map[string]interface{}{
+						"type":        "integer",
+						"description": "Bottom of the scale, at least 0",
+						"default":     1,
+					},
+					"max": map[string]interface{}{
+						"type":        "integer",
+						"description": "Top of the scale",
+						"default":     5,
+					},
+					"min_label": map[string]interface{}{
+						"type":        "string",
+						"description": "Label for the bottom of the scale, e.g. 'Not at all'",
+					},
+					"max_label": map[string]interface{}{
+						"type":        "string",
+						"description": "Label for the top of the scale, e.g. 'Completely'",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserRatingTool,
+		},
+		{
+			Name:        "user_reaction",
+			Title:       "Ask the User for a Quick Reaction",
+			Description: "Ask the user for a one-tap reaction to your output: 👍 approve, 👎 reject or 🤷 unsure by default. The terminal takes a single keypress and the browser shows large buttons. Lighter than user_choice, and it times out after 60 seconds by default with unsure as the answer. Returns the reaction's id, never the emoji, in structuredContent.reaction",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The question to react to (default \"How did I do?\")",
+					},
+					"reactions": map[string]interface{}{
+						"type":        "array",
+						"description": "Reactions to offer instead of approve, reject and unsure",
+						"minItems":    2,
+						"maxItems":    maxSingleKeyOptions,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"id": map[string]interface{}{
+									"type":        "string",
+									"description": "Identifier returned when the reaction is picked: lowercase letters, digits, - or _",
+								},
+								"emoji": map[string]interface{}{
+									"type":        "string",
+									"description": "Emoji shown for the reaction",
+								},
+								"label": map[string]interface{}{
+									"type":        "string",
+									"description": "Short caption shown with the emoji",
+								},
+							},
+							"required": []string{"id", "emoji"},
+						},
+					},
+					"timeout_reaction": map[string]interface{}{
+						"type":        "string",
+						"description": "Id of the reaction used when the timeout expires (structuredContent.timedOut true). Defaults to unsure when offered; an empty string makes the timeout an error",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short heading shown above the question",
+					},
+					"locale":   localeSchema(),
+					"priority": prioritySchema(),
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever",
+						"default":     60,
+					},
+					"method": methodSchema(),
+				},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserReactionTool,
+		},
+		{
+			Name:        "user_rank",
+			Title:       "Ask the User to Rank Options",
+			Description: "Ask the user to put options in order of preference. Returns the options as a JSON array, most preferred first, with their 0-based indices",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "What the options are ranked by",
+					},
+					"options": map[string]interface{}{
+						"type":        "array",
+						"description": "The options to rank",
+						"items":       map[string]interface{}{"type": "string"},
+						"minItems":    2,
+					},
+					"allow_partial": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Let the user rank only some of the options, e.g. their top 3. structuredContent.complete says whether every option was ranked",
+						"default":     false,
+					},
+					"max_ranked": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most options the user may rank with allow_partial (default all of them)",
+						"minimum":     1,
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt", "options"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserRankTool,
+		},
+		{
+			Name:        "user_list",
+			Title:       "Ask the User for a List",
+			Description: "Ask the user for a list of items, entered one per line on the terminal or row by row in the browser. Returns the items as a JSON array of strings, in the order they were entered",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "What the list is of",
+					},
+					"min_items": map[string]interface{}{
+						"type":        "integer",
+						"description": "Fewest items accepted",
+						"minimum":     0,
+						"default":     0,
+					},
+					"max_items": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most items accepted (default 1000)",
+						"minimum":     1,
+						"maximum":     maxListItems,
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression every item must match in full",
+					},
+					"validation_message": map[string]interface{}{
+						"type":        "string",
+						"description": "Message shown when an item doesn't match pattern",
+					},
+					"duplicates": map[string]interface{}{
+						"type":        "string",
+						"description": "What to do with an item entered twice: keep both, drop the repeat, or ask for another item",
+						"enum":        []string{DuplicatesAllow, DuplicatesDedupe, DuplicatesReject},
+						"default":     DuplicatesAllow,
+					},
+					"terminator": map[string]interface{}{
+						"type":        "string",
+						"description": "Line that ends the list on the terminal, e.g. 'done' (default: a blank line)",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserListTool,
+		},
+		{
+			Name:        "user_datetime",
+			Title:       "Ask the User for a Date or Time",
+			Description: "Ask the user for a date, a time of day or both. Returns the answer in RFC 3339 form (YYYY-MM-DD, HH:MM:SS or a full timestamp with offset) and the text the user entered",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "The question to ask",
+					},
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"description": "What to ask for",
+						"enum":        []string{ModeDate, ModeTime, ModeDateTime},
+						"default":     ModeDateTime,
+					},
+					"min": map[string]interface{}{
+						"type":        "string",
+						"description": "Earliest accepted answer, in the same forms the user may type, e.g. '2026-10-01', '09:00' or 'now'",
+					},
+					"max": map[string]interface{}{
+						"type":        "string",
+						"description": "Latest accepted answer",
+					},
+					"timezone": map[string]interface{}{
+						"type":        "string",
+						"description": "IANA time zone answers are read in, e.g. 'Europe/Berlin' (default: the server's local zone)",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"prompt"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserDateTimeTool,
+		},
+		{
+			Name:        "user_clipboard",
+			Title:       "Read the User's Clipboard",
+			Description: "Ask the user for permission to read their clipboard and, if they allow it, return its text (up to 1MB). A refusal is an error result that contains nothing from the clipboard",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the clipboard is needed, shown with the permission request",
+					},
+					"method": methodSchema(),
+				},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserClipboardTool,
+		},
+		{
+			Name:        "user_credentials",
+			Title:       "Ask the User to Sign In",
+			Description: "Ask the user for a username and password in one step; the password is never echoed. Returns them as structuredContent.username and structuredContent.password, marked for the assistant only and kept out of logs. Over the web method this is refused unless the prompt page is reachable only from this machine",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "What the user is signing in to, e.g. 'registry.example.com', shown with the prompt",
+					},
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the fields",
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserCredentialsTool,
+		},
+		{
+			Name:        "user_input_batch",
+			Title:       "Ask the User Several Questions",
+			Description: "Ask the user several independent questions in one terminal session or one browser page. Returns a JSON array of {id, response} in question order. On timeout, the answers given so far are returned with the ids still missing",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Text shown above the questions",
+					},
+					"questions": map[string]interface{}{
+						"type":        "array",
+						"description": "The questions, asked in order",
+						"minItems":    1,
+						"maxItems":    maxBatchQuestions,
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"id": map[string]interface{}{
+									"type":        "string",
+									"description": "Unique id the answer is returned under",
+								},
+								"prompt": map[string]interface{}{
+									"type":        "string",
+									"description": "The question",
+								},
+								"type": map[string]interface{}{
+									"type":        "string",
+									"description": "Answer type; responses are strings, numbers or booleans to match",
+									"enum":        []string{"text", "number", "boolean", "choice"},
+									"default":     "text",
+								},
+								"options": map[string]interface{}{
+									"type":        "array",
+									"items":       map[string]interface{}{"type": "string"},
+									"description": "The options of a choice question",
+								},
+							},
+							"required": []string{"id", "prompt"},
+						},
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait for all answers; 0 waits forever. Defaults to no timeout for tty and 300 for web",
+					},
+					"max_attempts": maxAttemptsSchema(),
+					"method":       methodSchema(),
+				},
+				"required": []string{"questions"},
+			},
+			Annotations: userAnnotations,
+			handler:     (*MCPServer).handleUserInputBatchTool,
+		},
+	}
+}
+
+func methodSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Input method: 'tty' (terminal), 'web' (browser), 'auto' (terminal when available, otherwise browser) or 'both' (terminal and browser at once, first answer wins)",
+		"enum":        []string{MethodTTY, MethodWeb, MethodAuto, MethodBoth},
+		"default":     MethodTTY,
+	}
+}
+
+func localeSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Language of the labels and buttons around the prompt, such as 'de' or 'ja'; the prompt text is shown as given. Unknown locales use English. Defaults to the server's locale",
+		"examples":    i18n.Languages(),
+	}
+}
+
+func cacheBypassSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Ask the user even if they asked to remember their answer to this exact question. Answers taken from memory are marked with _meta.cached",
+		"default":     false,
+	}
+}
+
+func requireConfirmationSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Show the user the exact answer before it is returned and return it only once they confirm it; otherwise they edit it. Use for approvals of destructive or irreversible actions",
+		"default":     false,
+	}
+}
+
+func maxAttemptsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "integer",
+		"description": "Invalid answers the user may give before the call returns an error result with the last invalid value and the reason (default 3). The user is told how many attempts remain",
+		"minimum":     1,
+		"maximum":     maxAttemptsLimit,
+	}
+}
+
+func confirmationPhraseSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "For dangerous actions: the user must type this phrase, such as a repository name, to approve. Returns structuredContent.approved; an empty answer or too many wrong phrases is a denial",
+	}
+}
+
+func attachmentsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Text files for the user to read before answering, such as a diff or config. The browser shows them as tabbed, highlighted panes above the question; the terminal lists them by name and pages through any the user picks. At most 20, 256KB each, 1MB in all; binary content is rejected",
+		"maxItems":    maxAttachments,
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "File name shown on the tab",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "The file's text",
+				},
+				"language": map[string]interface{}{
+					"type":        "string",
+					"description": "Language for highlighting, such as go, python or yaml. Guessed from the name's extension when omitted",
+				},
+			},
+			"required": []string{"name", "content"},
+		},
+	}
+}
+
+func contextSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "The recent exchange that led to the question, oldest first, so a user arriving cold knows what is being asked. Only displayed: collapsed under \"Show context\" in the browser, shown on the terminal when the user presses c. Never returned in the result. Beyond 64KB the oldest messages are dropped",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"role": map[string]interface{}{
+					"type":        "string",
+					"description": "Who said it, such as user or assistant",
+				},
+				"text": map[string]interface{}{
+					"type":        "string",
+					"description": "What was said",
+				},
+			},
+			"required": []string{"role", "text"},
+		},
+	}
+}
+
+func caseInsensitiveSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Match confirmation_phrase regardless of case",
+		"default":     false,
+	}
+}
+
+func lookupTool(name string) (toolDefinition, bool) {
+	for _, tool := range builtinTools() {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return toolDefinition{}, false
+}
+
+func toolNameList() string {
+	return toolNames(builtinTools())
+}
+
+func toolNames(tools []toolDefinition) string {
+	names := make([]string, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// lookupCalledTool finds the tool a tools/call names, which carries the
+// configured prefix.
+func (c Config) lookupCalledTool(name string) (toolDefinition, bool) {
+	if !strings.HasPrefix(name, c.ToolPrefix) {
+		return toolDefinition{}, false
+	}
+	return lookupTool(strings.TrimPrefix(name, c.ToolPrefix))
+}
+
+// enabledTools applies the tools config to the registered tools.
+func (c Config) enabledTools() []toolDefinition {
+	var tools []toolDefinition
+	for _, tool := range builtinTools() {
+		if c.toolEnabled(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (c Config) toolEnabled(name string) bool {
+	if len(c.Tools.Enable) > 0 && !containsString(c.Tools.Enable, name) {
+		return false
+	}
+	return !containsString(c.Tools.Disable, name)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// toolDisabledError is the error data returned for calls to tools the
+// operator has switched off.
+func toolDisabledError(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"tool":   name,
+		"reason": "administratively disabled",
+	}
+}
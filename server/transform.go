@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Answer transforms of the normalize argument.
+const (
+	TransformTrim     = "trim"
+	TransformLower    = "lower"
+	TransformUpper    = "upper"
+	TransformCollapse = "collapse_whitespace"
+	TransformSlug     = "slug"
+)
+
+var transformNames = []string{TransformTrim, TransformLower, TransformUpper, TransformCollapse, TransformSlug}
+
+// RawAnswer keeps the last answer a transformed prompt accepted, as the user
+// gave it.
+type RawAnswer struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (a *RawAnswer) set(value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.value = value
+}
+
+// Value returns the answer as given, before any transform.
+func (a *RawAnswer) Value() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.value
+}
+
+// Transform makes the prompt apply transforms, in order, to every answer
+// before it is validated, so validators and the caller only ever see the
+// normalized value. When Raw is set it records each accepted answer as
+// given.
+func (r *PromptRequest) Transform(transforms []string) {
+	r.Transforms = transforms
+	validate := r.Validate
+	r.Validate = func(response string) (string, error) {
+		transformed := applyTransforms(response, transforms)
+		if strings.TrimSpace(transformed) == "" && strings.TrimSpace(response) != "" && !r.AllowEmpty {
+			return "", fmt.Errorf("%q is empty once normalized; please use letters or digits", strings.TrimSpace(response))
+		}
+		if validate != nil {
+			var err error
+			if transformed, err = validate(transformed); err != nil {
+				return "", err
+			}
+		}
+		if r.Raw != nil {
+			r.Raw.set(response)
+		}
+		return transformed, nil
+	}
+}
+
+// applyTransforms runs text through each transform in turn.
+func applyTransforms(text string, transforms []string) string {
+	for _, transform := range transforms {
+		switch transform {
+		case TransformTrim:
+			text = strings.TrimSpace(text)
+		case TransformLower:
+			text = strings.ToLower(text)
+		case TransformUpper:
+			text = strings.ToUpper(text)
+		case TransformCollapse:
+			text = collapseWhitespace(text)
+		case TransformSlug:
+			text = slugify(text)
+		}
+	}
+	return text
+}
+
+// collapseWhitespace replaces every run of whitespace, newlines included,
+// with a single space.
+func collapseWhitespace(text string) string {
+	var b strings.Builder
+	inSpace := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			if !inSpace {
+				b.WriteByte(' ')
+			}
+			inSpace = true
+			continue
+		}
+		inSpace = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// slugify lowercases text and joins its runs of letters, digits and
+// combining marks with single hyphens. Non-ASCII letters are kept, so
+// "Über Äpfel!" becomes "über-äpfel".
+func slugify(text string) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || (unicode.Is(unicode.Mn, r) && b.Len() > 0 && !pending) {
+			if pending && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pending = false
+			b.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	return b.String()
+}
+
+// parseTransforms reads the normalize argument of user_input.
+func parseTransforms(args map[string]interface{}) ([]string, error) {
+	transforms, present, err := optionalStringList(args, "normalize")
+	if err != nil || !present {
+		return nil, err
+	}
+	if len(transforms) == 0 {
+		return nil, fmt.Errorf("Invalid normalize parameter: list at least one transform")
+	}
+	for _, transform := range transforms {
+		if !containsString(transformNames, transform) {
+			return nil, fmt.Errorf("Invalid normalize parameter: %q is not one of %s", transform, strings.Join(transformNames, ", "))
+		}
+	}
+	return transforms, nil
+}
@@ -0,0 +1,651 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"prompt-mcp/i18n"
+)
+
+// maxTTYLine is the longest line accepted from the terminal.
+const maxTTYLine = 4 * 1024 * 1024
+
+// multilineTerminator ends a multi-line answer on the terminal.
+const multilineTerminator = "."
+
+type ttyProvider struct {
+	open func() (io.ReadWriteCloser, error)
+}
+
+// NewTTYProvider returns a provider that prompts on the terminal returned by
+// open. A nil open uses the controlling terminal, /dev/tty.
+func NewTTYProvider(open func() (io.ReadWriteCloser, error)) InputProvider {
+	if open == nil {
+		open = openControllingTTY
+	}
+	return ttyProvider{open: open}
+}
+
+func openControllingTTY() (io.ReadWriteCloser, error) {
+	// Open the controlling terminal directly
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open /dev/tty: %w", err)
+	}
+	return tty, nil
+}
+
+func (p ttyProvider) GetInput(ctx context.Context, req *PromptRequest) (string, error) {
+	tty, err := p.open()
+	if err != nil {
+		if req.Kind == KindEdit {
+			return "", fmt.Errorf("no controlling terminal to run the editor on: %w", err)
+		}
+		return "", err
+	}
+	defer tty.Close()
+
+	// A withdrawn prompt's half-typed answer must not carry over into the
+	// next prompt on this terminal
+	withdrawn := false
+	defer func() {
+		if withdrawn {
+			discardTypeAhead(tty)
+		}
+	}()
+	asked := req
+	req = rememberOnTTY(req)
+
+	// The editor owns the terminal until it exits
+	if req.Kind == KindEdit {
+		return runTTYEdit(ctx, tty, req)
+	}
+
+	if req.Secret {
+		restore, err := disableEcho(tty)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	// Prompts that hide only some of what is typed switch echo off
+	// themselves; it is back on before the terminal is closed
+	echo := &echoSwitch{tty: tty}
+	defer echo.end()
+
+	// Paths get Tab completion, long reads page on Space and single-key
+	// prompts answer with one keypress, where the terminal can deliver
+	// single keys; elsewhere they are read as lines
+	editing := false
+	if req.Kind == KindFile || req.Kind == KindUpload || req.Kind == KindRead || req.SingleKey {
+		if restore, err := rawMode(tty); err == nil {
+			defer restore()
+			editing = true
+		}
+	}
+
+	type readResult struct {
+		line string
+		err  error
+	}
+	done := make(chan readResult, 1)
+
+	// Read response from the terminal, allowing long pasted lines
+	scanner := bufio.NewScanner(tty)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTTYLine)
+
+	// Talk to the terminal in the background so the read can be abandoned
+	// when ctx is done
+	deadline, _ := ctx.Deadline()
+	go func() {
+		for {
+			line, err := runTTYPrompt(tty, scanner, req, deadline, editing, echo)
+			if err == nil && req.RequireConfirmation {
+				var send bool
+				if send, err = confirmOnTTY(tty, scanner, req, line, editing); err == nil && !send {
+					continue
+				}
+			}
+			done <- readResult{line: line, err: err}
+			return
+		}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err == nil && asked.Remember != nil && asked.Validate == nil {
+			if line, remember := cutRemember(result.line); remember {
+				asked.Remember.Set()
+				result.line = line
+			}
+		}
+		return result.line, result.err
+	case <-ctx.Done():
+		// Move off the half-typed line; closing the handle unblocks the reader
+		fmt.Fprintf(tty, "\n")
+		switch {
+		case answeredVia(ctx) == MethodWeb:
+			fmt.Fprintf(tty, "(%s)\n", i18n.T(req.Locale, i18n.AnsweredInBrowser))
+		case errors.Is(context.Cause(ctx), ErrWithdrawn):
+			fmt.Fprintf(tty, "(%s)\n", i18n.T(req.Locale, i18n.Withdrawn))
+			withdrawn = true
+		}
+		return "", ctx.Err()
+	}
+}
+
+func (ttyProvider) WarnTimeout(req *PromptRequest, remaining time.Duration) {
+	if req.alert().Reminders {
+		ringBell()
+	}
+}
+
+// runTTYPrompt writes the prompt to the terminal and reads lines from
+// scanner until one passes the prompt's validation. deadline, if set, is
+// when the prompt times out. editing says the terminal is in raw mode for
+// the path line editor.
+func runTTYPrompt(tty io.ReadWriter, scanner *bufio.Scanner, req *PromptRequest, deadline time.Time, editing bool, echo *echoSwitch) (string, error) {
+	// Write prompt to the terminal
+	alert := req.alert()
+	if alert.Bell {
+		fmt.Fprint(tty, "\a")
+	}
+	writeTTYHeader(tty, req)
+	prompt, detail := req.Prompt, req.Detail
+	if req.Format == FormatMarkdown {
+		width, color := ttyWidth(tty), ttyColor(tty)
+		prompt = RenderMarkdown(prompt, width, color)
+		detail = RenderMarkdown(detail, width-2, color)
+	}
+	if alert.Emphasis && ttyColor(tty) {
+		prompt = ansiBold + prompt + ansiReset
+	}
+	fmt.Fprintf(tty, "%s\n", prompt)
+	if detail != "" {
+		fmt.Fprintf(tty, "\n%s\n\n", indent(detail, "  "))
+	}
+	if len(req.Images) > 0 {
+		cleanup, err := writeTTYImages(tty, req.Images)
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+	}
+	if !req.Asked.IsZero() {
+		fmt.Fprintf(tty, "(%s)\n", askedLine(req.Locale, req.Asked, time.Now()))
+	}
+	if !deadline.IsZero() {
+		fmt.Fprintf(tty, "(%s %s)\n", i18n.T(req.Locale, i18n.ExpiresAt), formatDeadline(deadline))
+	}
+	if req.TimeoutResponse != nil && req.Timeout > 0 {
+		fmt.Fprintf(tty, "(Without an answer within %s, %q will be used)\n", formatSeconds(req.Timeout), *req.TimeoutResponse)
+	}
+	if req.Remember != nil && !(req.SingleKey && editing) {
+		fmt.Fprintf(tty, "(%s)\n", i18n.T(req.Locale, i18n.RememberHint))
+	}
+
+	if req.Context != nil {
+		if err := runTTYContext(tty, scanner, req.Context); err != nil {
+			return "", err
+		}
+	}
+	if len(req.Attachments) > 0 {
+		if err := runTTYAttachments(tty, scanner, req.Attachments); err != nil {
+			return "", err
+		}
+	}
+
+	label := i18n.T(req.Locale, i18n.ResponseLabel) + ": "
+	switch req.Kind {
+	case KindChoice:
+		for i, option := range req.Options {
+			fmt.Fprintf(tty, "  %d) %s\n", i+1, option)
+		}
+		label = fmt.Sprintf("Choice [1-%d]: ", len(req.Options))
+		if req.MultiSelect {
+			label = "Choices (comma-separated numbers or options): "
+		}
+		if req.AllowOther {
+			fmt.Fprintf(tty, "  %d) %s\n", len(req.Options)+1, OtherLabel)
+			return runTTYOtherChoice(tty, scanner, fmt.Sprintf("Choice [1-%d]: ", len(req.Options)+1), req)
+		}
+	case KindConfirm:
+		label = confirmHint(req.Default) + " "
+		if req.Phrase != nil {
+			label = fmt.Sprintf("Type %q to confirm, or press Enter to deny: ", req.Phrase.Phrase)
+		}
+	case KindText:
+		if req.Length != nil {
+			fmt.Fprintf(tty, "(Answer in %s)\n", req.Length.describe())
+		}
+		label = i18n.T(req.Locale, i18n.ResponseLabel) + placeholderHint(tty, req)
+		if req.Default != "" {
+			label += fmt.Sprintf(" [%s]", req.Default)
+		}
+		if hint := req.buttonHint(); hint != "" {
+			label += " " + hint
+		}
+		label += ": "
+		if req.Placeholder != "" && (req.Secret || req.Multiline) {
+			fmt.Fprintf(tty, "%s\n", strings.TrimSpace(placeholderHint(tty, req)))
+		}
+		if len(req.Suggestions) > 0 {
+			return runTTYSuggestions(tty, scanner, label, req)
+		}
+	case KindNumber:
+		label = i18n.T(req.Locale, i18n.NumberLabel)
+		if req.Number.Duration != nil {
+			label = i18n.T(req.Locale, i18n.DurationLabel)
+		}
+		if examples := req.Number.Examples(); examples != "" {
+			label += " (e.g. " + examples + ")"
+		}
+		if d := req.Number.Duration; d != nil && d.DefaultUnit != "s" {
+			unit, _ := findDurationUnit(d.DefaultUnit)
+			label += " (plain numbers are " + unit.Name + ")"
+		}
+		if bounds := req.Number.bounds(); bounds != "" {
+			label += " (" + bounds + ")"
+		}
+		label += placeholderHint(tty, req)
+		if req.Default != "" {
+			label += fmt.Sprintf(" [%s]", req.Default)
+		}
+		if hint := req.buttonHint(); hint != "" {
+			label += " " + hint
+		}
+		label += ": "
+	case KindAck:
+		label = i18n.T(req.Locale, i18n.PressEnter) + " "
+	case KindRank:
+		for i, option := range req.Options {
+			fmt.Fprintf(tty, "  %d) %s\n", i+1, option)
+		}
+		fmt.Fprintf(tty, "(%s)\n", req.Rank.hint(len(req.Options)))
+		label = "Order: "
+	case KindRating:
+		fmt.Fprintf(tty, "(%s)\n", req.Rating.scale())
+		label = fmt.Sprintf("Rating [%d-%d]: ", req.Rating.Min, req.Rating.Max)
+	case KindReaction:
+		for i, option := range req.Options {
+			fmt.Fprintf(tty, "  %d) %s\n", i+1, option)
+		}
+		label = fmt.Sprintf("Reaction [1-%d]: ", len(req.Options))
+	case KindList:
+		return runTTYList(tty, scanner, req)
+	case KindDateTime:
+		return runTTYDateTime(tty, scanner, req)
+	case KindForm:
+		return runTTYForm(tty, scanner, req)
+	case KindReview:
+		return runTTYReview(tty, scanner, req)
+	case KindPlanReview:
+		return runTTYPlanReview(tty, scanner, req)
+	case KindCredentials:
+		return runTTYCredentials(tty, scanner, req, echo)
+	case KindRead:
+		return runTTYRead(tty, scanner, req, editing)
+	case KindFile:
+		fmt.Fprintf(tty, "(Relative paths start from %s)\n", req.File.StartDir)
+		label = i18n.T(req.Locale, i18n.PathLabel) + ": "
+		if editing {
+			fmt.Fprintf(tty, "(Tab completes)\n")
+			return readTTYPath(tty, label, req.File, req.Validate)
+		}
+	case KindUpload:
+		// There is nothing to upload from on a terminal; the file is read
+		// from its path instead
+		fmt.Fprintf(tty, "(Enter the path of a file to send, up to %s; relative paths start from %s)\n", req.Upload.limit(), req.File.StartDir)
+		label = "File: "
+		if editing {
+			fmt.Fprintf(tty, "(Tab completes)\n")
+			return readTTYPath(tty, label, req.File, req.Validate)
+		}
+	}
+
+	if req.SingleKey && editing {
+		return readTTYSingleKey(tty, label, req)
+	}
+	if req.Secret {
+		return runTTYSecret(tty, scanner, req)
+	}
+	if req.Multiline {
+		return runTTYMultiline(tty, scanner, req.Validate)
+	}
+
+	return readTTYLine(tty, scanner, label, req.Validate)
+}
+
+// placeholderHint returns the prompt's placeholder as shown after the
+// answer label, " (e.g. v1.2.3)", dimmed where the terminal supports it.
+func placeholderHint(tty io.Writer, req *PromptRequest) string {
+	if req.Placeholder == "" {
+		return ""
+	}
+	hint := fmt.Sprintf("(e.g. %s)", req.Placeholder)
+	if ttyColor(tty) {
+		hint = ansiDim + hint + ansiReset
+	}
+	return " " + hint
+}
+
+// runTTYSuggestions lists the prompt's suggestions as numbered shortcuts and
+// reads an answer, which may be a suggestion's number or any text.
+func runTTYSuggestions(tty io.Writer, scanner *bufio.Scanner, label string, req *PromptRequest) (string, error) {
+	for i, suggestion := range req.Suggestions {
+		fmt.Fprintf(tty, "  %d) %s\n", i+1, suggestion)
+	}
+	fmt.Fprintf(tty, "(Type a number to use a suggestion, or your own answer)\n")
+	return readTTYLine(tty, scanner, label, func(response string) (string, error) {
+		response = req.expandSuggestion(response)
+		if req.Validate == nil {
+			return response, nil
+		}
+		return req.Validate(response)
+	})
+}
+
+// runTTYOtherChoice reads a choice that may be Other, whose text is then
+// asked for on the next line.
+func runTTYOtherChoice(tty io.Writer, scanner *bufio.Scanner, label string, req *PromptRequest) (string, error) {
+	other := false
+	answer, err := readTTYLine(tty, scanner, label, func(response string) (string, error) {
+		if isOtherPick(req, response) {
+			other = true
+			return "", nil
+		}
+		return req.Validate(response)
+	})
+	if err != nil || !other {
+		return answer, err
+	}
+	return readTTYLine(tty, scanner, "Other: ", func(response string) (string, error) {
+		return req.Validate(OtherResponse(response))
+	})
+}
+
+// ttySeparatorWidth is the width of the rule printed above prompts with a
+// title or urgency.
+const ttySeparatorWidth = 60
+
+// writeTTYHeader sets a prompt with a title or urgency apart from whatever
+// the terminal showed before, tagging non-normal urgencies, and says which
+// client asked.
+func writeTTYHeader(tty io.Writer, req *PromptRequest) {
+	if req.Title != "" || req.Urgency != "" {
+		rule := "-"
+		if req.Urgency == UrgencyCritical {
+			rule = "="
+		}
+		fmt.Fprintf(tty, "\n%s\n", strings.Repeat(rule, ttySeparatorWidth))
+
+		var header []string
+		if req.Urgency != "" && req.Urgency != UrgencyNormal {
+			header = append(header, "["+strings.ToUpper(req.Urgency)+"]")
+		}
+		if req.Title != "" {
+			header = append(header, req.Title)
+		}
+		if len(header) > 0 {
+			fmt.Fprintf(tty, "%s\n", strings.Join(header, " "))
+		}
+	}
+	if requester := req.requester(req.Locale); requester != "" {
+		fmt.Fprintf(tty, "(%s)\n", requester)
+	}
+}
+
+// formatDeadline writes a deadline as a clock time, with the date when it
+// isn't today, followed by how far off it is.
+func formatDeadline(deadline time.Time) string {
+	now := time.Now()
+	layout := "15:04:05"
+	if deadline.YearDay() != now.YearDay() || deadline.Year() != now.Year() {
+		layout = "2006-01-02 15:04:05"
+	}
+	return fmt.Sprintf("%s, in %s", deadline.Format(layout), deadline.Sub(now).Round(time.Second))
+}
+
+// indent prefixes every non-empty line of text.
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runTTYForm asks each field of a form in turn, re-asking only the field
+// whose answer fails.
+func runTTYForm(tty io.Writer, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	raw := make(map[string]string, len(req.Fields))
+	values := make(map[string]interface{}, len(req.Fields))
+	for _, field := range req.Fields {
+		if !field.active(values) {
+			continue
+		}
+		label := field.label()
+		switch field.Type {
+		case FieldSelect:
+			fmt.Fprintf(tty, "%s:\n", label)
+			for i, option := range field.Options {
+				fmt.Fprintf(tty, "  %d) %s\n", i+1, option)
+			}
+			label = fmt.Sprintf("Choice [1-%d]", len(field.Options))
+		case FieldBoolean:
+			def := ""
+			if field.Default != nil {
+				def = field.defaultText()
+			}
+			label += " " + confirmHint(def)
+		}
+		if def := field.defaultText(); def != "" && field.Type != FieldBoolean {
+			label += fmt.Sprintf(" (default %s)", def)
+		} else if field.Optional && field.Default == nil {
+			label += " (optional)"
+		}
+
+		field := field
+		line, err := readTTYLine(tty, scanner, label+": ", func(response string) (string, error) {
+			if _, err := field.Parse(response); err != nil {
+				return "", req.Attempts.Fail(response, err)
+			}
+			return response, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		raw[field.Name] = line
+		value, _ := field.Parse(line)
+		if value != nil {
+			values[field.Name] = value
+		}
+		if req.Partial != nil {
+			req.Partial.Set(field.Name, value)
+		}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	return req.Validate(string(data))
+}
+
+// runTTYMultiline reads lines until one containing only the terminator or
+// the end of input (Ctrl+D), asking again while the text fails validate. A
+// line of ".." stands for a literal ".", and a first line starting with Esc
+// declines the prompt.
+func runTTYMultiline(tty io.Writer, scanner *bufio.Scanner, validate func(string) (string, error)) (string, error) {
+	fmt.Fprintf(tty, "(Finish with a line containing only %q, or Ctrl+D)\n", multilineTerminator)
+
+	for {
+		var lines []string
+		closed := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			if reason, ok := cutDecline(line); ok && len(lines) == 0 {
+				return "", &DeclinedError{Reason: reason}
+			}
+			if line == multilineTerminator {
+				closed = false
+				break
+			}
+			if line == multilineTerminator+multilineTerminator {
+				line = multilineTerminator
+			}
+			lines = append(lines, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return "", fmt.Errorf("failed to read from terminal: %w", err)
+		}
+
+		text := strings.Join(lines, "\n")
+		if validate == nil {
+			return text, nil
+		}
+		valid, err := validate(text)
+		if err == nil {
+			return valid, nil
+		}
+		if isAttemptsExhausted(err) {
+			fmt.Fprintf(tty, "Too many invalid attempts\n")
+			return "", err
+		}
+		if closed {
+			return "", fmt.Errorf("terminal closed before a valid response was entered: %v", err)
+		}
+		writeTTYError(tty, err)
+		fmt.Fprintf(tty, "(Enter the text again)\n")
+	}
+}
+
+// runTTYSecret reads a secret with echo already off, asking for it twice
+// when the prompt wants confirmation.
+func runTTYSecret(tty io.Writer, scanner *bufio.Scanner, req *PromptRequest) (string, error) {
+	for {
+		secret, err := readTTYLine(tty, scanner, i18n.T(req.Locale, i18n.SecretLabel)+": ", req.Validate)
+		// The Enter keypress isn't echoed either
+		fmt.Fprintf(tty, "\n")
+		if err != nil || !req.ConfirmSecret {
+			return secret, err
+		}
+
+		again, err := readTTYLine(tty, scanner, i18n.T(req.Locale, i18n.RepeatLabel)+": ", req.Validate)
+		fmt.Fprintf(tty, "\n")
+		if err != nil {
+			return "", err
+		}
+		if again == secret {
+			return secret, nil
+		}
+		fmt.Fprintf(tty, "%s\n", i18n.T(req.Locale, i18n.EntriesMismatch))
+	}
+}
+
+// readTTYLine shows label and reads lines until one passes validate. A nil
+// validate accepts the first line. A line starting with Esc declines the
+// prompt, as does the end of input when validate is set.
+func readTTYLine(tty io.Writer, scanner *bufio.Scanner, label string, validate func(string) (string, error)) (string, error) {
+	for {
+		fmt.Fprint(tty, label)
+
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("failed to read from terminal: %w", err)
+			}
+			if validate != nil {
+				// Ctrl+D before any valid answer declines the prompt
+				return "", &DeclinedError{}
+			}
+			return "", nil
+		}
+
+		line := scanner.Text()
+		if reason, ok := cutDecline(line); ok {
+			return "", &DeclinedError{Reason: reason}
+		}
+		if validate == nil {
+			return line, nil
+		}
+
+		valid, err := validate(line)
+		if err == nil {
+			return valid, nil
+		}
+		if isAttemptsExhausted(err) {
+			fmt.Fprintf(tty, "Too many invalid attempts\n")
+			return "", err
+		}
+		writeTTYError(tty, err)
+	}
+}
+
+// rememberSuffix ends a terminal answer the user wants remembered.
+const rememberSuffix = "!remember"
+
+// cutRemember removes rememberSuffix from the end of answer, reporting
+// whether it was there.
+func cutRemember(answer string) (string, bool) {
+	trimmed := strings.TrimRightFunc(answer, unicode.IsSpace)
+	if !strings.HasSuffix(trimmed, rememberSuffix) {
+		return answer, false
+	}
+	return strings.TrimRightFunc(strings.TrimSuffix(trimmed, rememberSuffix), unicode.IsSpace), true
+}
+
+// rememberOnTTY lets the user end a terminal answer to req with !remember.
+// A prompt with validation gets the suffix cut before the answer is
+// checked; without, GetInput cuts it from the answer.
+func rememberOnTTY(req *PromptRequest) *PromptRequest {
+	if req.Remember == nil || req.Validate == nil {
+		return req
+	}
+	validate := req.Validate
+	wrapped := *req
+	wrapped.Validate = func(response string) (string, error) {
+		response, remember := cutRemember(response)
+		valid, err := validate(response)
+		if err == nil && remember {
+			req.Remember.Set()
+		}
+		return valid, err
+	}
+	return &wrapped
+}
+
+// writeTTYError shows why an answer was rejected and, when the prompt has
+// an attempt limit, how many attempts remain.
+func writeTTYError(tty io.Writer, err error) {
+	var rerr *RetryError
+	if errors.As(err, &rerr) {
+		fmt.Fprintf(tty, "%v (%s)\n", err, rerr.Remaining())
+		return
+	}
+	fmt.Fprintf(tty, "%v\n", err)
+}
+
+func getUserInputFromTTY(ctx context.Context, prompt string) (string, error) {
+	return NewTTYProvider(nil).GetInput(ctx, &PromptRequest{Prompt: prompt})
+}
+
+// ringBell sounds the terminal bell on the controlling terminal, if there is one.
+func ringBell() {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+	tty.Write([]byte("\a"))
+}
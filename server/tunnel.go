@@ -0,0 +1,216 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tunnelStartTimeout is how long a tunnel command has to print its public
+// URL.
+const tunnelStartTimeout = 30 * time.Second
+
+// tunnelURL matches the public URL in the output of a custom tunnel
+// command unless configured otherwise: the first https URL.
+var tunnelURL = regexp.MustCompile(`https://[^\s"'<>|]+`)
+
+// tunnelPresets are the tunnel commands known by name, with the pattern
+// picking their public URL out of the links they print besides.
+var tunnelPresets = map[string]struct {
+	template string
+	pattern  string
+}{
+	"cloudflared":   {"cloudflared tunnel --no-autoupdate --url {url}", `https://[a-z0-9-]+\.trycloudflare\.com`},
+	"ngrok":         {"ngrok http {url} --log stdout --log-format logfmt", `https://[a-z0-9.-]+\.ngrok(-free)?\.(app|dev|io)`},
+	"localhost.run": {"ssh -T -o StrictHostKeyChecking=accept-new -o ServerAliveInterval=30 -o ExitOnForwardFailure=yes -R 80:{host}:{port} nokey@localhost.run", `https://[a-z0-9-]+\.lhr\.life`},
+}
+
+// TunnelCommand runs an outbound tunnel, such as cloudflared or an SSH
+// reverse tunnel, to make pages on this machine reachable at a public URL.
+type TunnelCommand struct {
+	// Template is the command line, split on whitespace without any
+	// quoting, in which {host}, {port} and {url} stand for the page
+	// server's local address
+	Template string
+
+	// Pattern matches the public URL in what the command prints
+	Pattern *regexp.Regexp
+}
+
+// ParseTunnel reads a tunnel setting: "cloudflared", "ngrok" or
+// "localhost.run", which run those tools, or a command template with
+// {host}, {port} or {url}. pattern, a regular expression, overrides how the
+// public URL is found in its output; by default a template's first https
+// URL is taken.
+func ParseTunnel(spec, pattern string) (*TunnelCommand, error) {
+	spec = strings.TrimSpace(spec)
+	t := &TunnelCommand{Template: spec, Pattern: tunnelURL}
+	if preset, ok := tunnelPresets[spec]; ok {
+		t.Template = preset.template
+		t.Pattern = regexp.MustCompile(preset.pattern)
+	} else if !strings.Contains(spec, "{port}") && !strings.Contains(spec, "{url}") {
+		return nil, fmt.Errorf("web tunnel must be cloudflared, ngrok, localhost.run or a command with {port} or {url} (got %q)", spec)
+	}
+	if pattern != "" {
+		var err error
+		if t.Pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid web tunnel URL pattern: %w", err)
+		}
+	}
+	return t, nil
+}
+
+// Tunnel is a running tunnel command.
+type Tunnel struct {
+	// URL is the public URL the tunnel serves the pages at, without a
+	// trailing slash
+	URL string
+
+	cmd    *exec.Cmd
+	exited chan struct{}
+	once   sync.Once
+}
+
+var (
+	tunnelsMu sync.Mutex
+	tunnels   = make(map[*Tunnel]bool)
+)
+
+// Start runs the tunnel to the page server on host and port, and returns
+// once the command printed its public URL. A command that exits, fails to
+// start or prints no URL in time is stopped and reported with the last
+// thing it said.
+func (c *TunnelCommand) Start(host string, port int) (*Tunnel, error) {
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	replacer := strings.NewReplacer("{host}", host, "{port}", strconv.Itoa(port), "{url}", "http://"+addr)
+	args := strings.Fields(replacer.Replace(c.Template))
+	if len(args) == 0 {
+		return nil, errors.New("empty tunnel command")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	output, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start tunnel: %w", err)
+	}
+	t := &Tunnel{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		writer.Close()
+		close(t.exited)
+	}()
+
+	// The output is read until the command exits, so it never blocks on
+	// a full pipe. urls gets the public URL, or is closed without one
+	urls := make(chan string, 1)
+	var last string
+	go func() {
+		scanner := bufio.NewScanner(output)
+		sent := false
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if m := c.Pattern.FindString(line); m != "" && !sent {
+				urls <- strings.TrimSuffix(m, "/")
+				sent = true
+			} else if line != "" && !sent {
+				last = line
+			}
+		}
+		io.Copy(io.Discard, output)
+		close(urls)
+	}()
+
+	select {
+	case u, ok := <-urls:
+		if !ok {
+			<-t.exited
+			return nil, fmt.Errorf("tunnel exited before giving a public URL: %s", last)
+		}
+		t.URL = u
+	case <-time.After(tunnelStartTimeout):
+		t.Close()
+		return nil, fmt.Errorf("tunnel gave no public URL within %s", tunnelStartTimeout)
+	}
+
+	tunnelsMu.Lock()
+	tunnels[t] = true
+	tunnelsMu.Unlock()
+	return t, nil
+}
+
+// Host is the host of the tunnel's public URL.
+func (t *Tunnel) Host() string {
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// Close stops the tunnel command and waits for it to exit.
+func (t *Tunnel) Close() error {
+	t.once.Do(func() {
+		tunnelsMu.Lock()
+		delete(tunnels, t)
+		tunnelsMu.Unlock()
+		t.cmd.Process.Kill()
+	})
+	<-t.exited
+	return nil
+}
+
+// CloseTunnels stops every tunnel still running, for the server to call on
+// its way out.
+func CloseTunnels() {
+	tunnelsMu.Lock()
+	open := make([]*Tunnel, 0, len(tunnels))
+	for t := range tunnels {
+		open = append(open, t)
+	}
+	tunnelsMu.Unlock()
+	for _, t := range open {
+		t.Close()
+	}
+}
+
+// tunnelHost reports whether host is the public host of a running tunnel.
+func tunnelHost(host string) bool {
+	tunnelsMu.Lock()
+	defer tunnelsMu.Unlock()
+	for t := range tunnels {
+		if strings.EqualFold(t.Host(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// startTunnel opens l's tunnel to the page server on port, if l has one.
+// A tunnel that fails to start is reported on stderr and nil returned, so
+// the local URL is used instead.
+func (l WebListener) startTunnel(port int) *Tunnel {
+	if l.Tunnel == nil {
+		return nil
+	}
+	t, err := l.Tunnel.Start(l.Host, port)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Web tunnel failed, using the local URL: %v\n", err)
+		return nil
+	}
+	return t
+}
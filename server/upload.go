@@ -0,0 +1,326 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"prompt-mcp/i18n"
+	"prompt-mcp/units"
+)
+
+// How an uploaded file is returned to the agent.
+const (
+	ReturnBase64 = "base64"
+	ReturnPath   = "path"
+)
+
+// uploadDrainLimit is how much of an oversized upload is read and thrown
+// away so the browser gets the error page instead of a reset connection.
+const uploadDrainLimit = 64 * 1024 * 1024
+
+// uploadConflicts are the user_input arguments that make no sense for a
+// file upload.
+var uploadConflicts = []string{"confirmation_phrase", "pattern", "allowed_values", "type", "response_schema", "multiline", "secret", "confirm_secret", "default", "default_response", "allow_empty", "placeholder", "suggestions", "normalize", "min_length", "max_length", "delivery", "encoding", "trim", "dedent", "expected_length", "require_confirmation"}
+
+// UploadOptions configures a file upload prompt.
+type UploadOptions struct {
+	// MaxBytes is the largest file accepted
+	MaxBytes int64
+
+	// ReturnAs is ReturnBase64 or ReturnPath
+	ReturnAs string
+}
+
+// limit describes the size cap, e.g. "5 MiB".
+func (o *UploadOptions) limit() string {
+	return units.Format(units.Bytes, float64(o.MaxBytes))
+}
+
+// UploadedFile is the answer to an upload prompt, JSON-encoded.
+type UploadedFile struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+// uploadTooLargeError is a file over the cap.
+type uploadTooLargeError struct {
+	limit string
+}
+
+func (e *uploadTooLargeError) Error() string {
+	return fmt.Sprintf("The file is larger than %s; choose a smaller one", e.limit)
+}
+
+func uploadTooLarge(o *UploadOptions) error {
+	return &uploadTooLargeError{limit: o.limit()}
+}
+
+// AcceptFile turns the prompt into one answered with a file: uploaded in
+// the browser, or read from a path typed on the terminal. The answer is an
+// UploadedFile.
+func (r *PromptRequest) AcceptFile(opts UploadOptions) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	r.Kind = KindUpload
+	r.Upload = &opts
+	r.File = &FileOptions{StartDir: wd, MustExist: true}
+	r.Trim = TrimBoth
+	r.Validate = r.readLocalFile
+	return nil
+}
+
+// readLocalFile reads the file at a path typed on the terminal.
+func (r *PromptRequest) readLocalFile(response string) (string, error) {
+	path, err := r.File.Resolve(response)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return "", err
+	case !info.Mode().IsRegular():
+		return "", fmt.Errorf("%s is not a regular file", path)
+	case info.Size() > r.Upload.MaxBytes:
+		return "", uploadTooLarge(r.Upload)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > r.Upload.MaxBytes {
+		return "", uploadTooLarge(r.Upload)
+	}
+	return encodeUpload(filepath.Base(path), "", data)
+}
+
+// encodeUpload makes the answer for a file, working out its type when the
+// browser didn't send a specific one.
+func encodeUpload(name, mimeType string, data []byte) (string, error) {
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	encoded, err := json.Marshal(UploadedFile{Name: name, MimeType: mimeType, Data: data})
+	return string(encoded), err
+}
+
+// parseUploadOptions reads accept_file and return_as, returning nil when no
+// file was asked for. maxBytes is the configured cap.
+func parseUploadOptions(args map[string]interface{}, maxBytes int64) (*UploadOptions, error) {
+	accept, err := optionalBool(args, "accept_file", false)
+	if err != nil {
+		return nil, err
+	}
+	returnAs, present, err := optionalString(args, "return_as")
+	if err != nil {
+		return nil, err
+	}
+	if !accept {
+		if present {
+			return nil, fmt.Errorf("Invalid return_as parameter: only file uploads are returned as base64 or a path")
+		}
+		return nil, nil
+	}
+	if !present {
+		returnAs = ReturnBase64
+	}
+	if returnAs != ReturnBase64 && returnAs != ReturnPath {
+		return nil, fmt.Errorf("Invalid return_as parameter: must be 'base64' or 'path'")
+	}
+	return &UploadOptions{MaxBytes: maxBytes, ReturnAs: returnAs}, nil
+}
+
+// handleUploadPrompt asks for a file and returns it inline or as a temp
+// file. The prompt must have been made with AcceptFile.
+func (s *MCPServer) handleUploadPrompt(req MCPRequest, promptReq *PromptRequest, progressToken interface{}) {
+	response, err := s.collectInput(req, promptReq, progressToken)
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+	var file UploadedFile
+	if err := json.Unmarshal([]byte(response), &file); err != nil {
+		s.sendInputError(req.ID, fmt.Errorf("failed to decode the uploaded file: %w", err))
+		return
+	}
+
+	sum := sha256.Sum256(file.Data)
+	hash := hex.EncodeToString(sum[:])
+	structured := map[string]interface{}{
+		"name":     file.Name,
+		"mimeType": file.MimeType,
+		"size":     len(file.Data),
+		"sha256":   hash,
+		"returnAs": promptReq.Upload.ReturnAs,
+	}
+	summary := fmt.Sprintf("Uploaded %s: %d bytes of %s (sha256 %s).", file.Name, len(file.Data), file.MimeType, hash)
+
+	var content []map[string]interface{}
+	if promptReq.Upload.ReturnAs == ReturnPath {
+		path, err := saveUpload(file)
+		if err != nil {
+			s.sendInputError(req.ID, err)
+			return
+		}
+		structured["path"] = path
+		content = []map[string]interface{}{
+			textContent(summary + " Saved to " + path),
+		}
+	} else {
+		content = []map[string]interface{}{
+			textContent(summary),
+			uploadContent(file),
+		}
+	}
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content":           content,
+		"structuredContent": structured,
+		"isError":           false,
+	})
+}
+
+// uploadContent is the MCP content block carrying a file: an image or
+// audio block where one fits, an embedded resource otherwise.
+func uploadContent(file UploadedFile) map[string]interface{} {
+	data := base64.StdEncoding.EncodeToString(file.Data)
+	for _, kind := range []string{"image", "audio"} {
+		if strings.HasPrefix(file.MimeType, kind+"/") {
+			return map[string]interface{}{
+				"type":     kind,
+				"data":     data,
+				"mimeType": file.MimeType,
+			}
+		}
+	}
+	return map[string]interface{}{
+		"type": "resource",
+		"resource": map[string]interface{}{
+			"uri":      "upload:///" + url.PathEscape(file.Name),
+			"mimeType": file.MimeType,
+			"blob":     data,
+		},
+	}
+}
+
+// saveUpload writes an uploaded file to a new temp directory, keeping its
+// name. The file is left for the agent to read.
+func saveUpload(file UploadedFile) (string, error) {
+	dir, err := os.MkdirTemp("", "prompt-mcp-upload-")
+	if err != nil {
+		return "", fmt.Errorf("failed to save the uploaded file: %w", err)
+	}
+	name := filepath.Base(filepath.Clean("/" + file.Name))
+	if name == "/" || name == "." {
+		name = "upload"
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, file.Data, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to save the uploaded file: %w", err)
+	}
+	return path, nil
+}
+
+var (
+	errNoUpload    = errors.New("Please choose a file to upload")
+	errUploadToken = errors.New("Invalid or missing form token; reload the page and try again")
+)
+
+// readUpload streams the file field of a multipart submission, stopping
+// at the size cap. The form token, which valid reports on, must come
+// before the file, as the page's form sends it.
+func readUpload(r *http.Request, opts *UploadOptions, valid func(string) bool) (string, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", errNoUpload
+	}
+	checked := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			if !checked {
+				return "", errUploadToken
+			}
+			return "", errNoUpload
+		}
+		if err != nil {
+			return "", fmt.Errorf("The upload was interrupted, try again")
+		}
+		if part.FormName() == csrfField && !checked {
+			token, _ := io.ReadAll(io.LimitReader(part, 256))
+			if !valid(string(token)) {
+				return "", errUploadToken
+			}
+			checked = true
+			continue
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			continue
+		}
+		if !checked {
+			return "", errUploadToken
+		}
+		data, err := io.ReadAll(io.LimitReader(part, opts.MaxBytes+1))
+		if err != nil {
+			return "", fmt.Errorf("The upload was interrupted, try again")
+		}
+		if int64(len(data)) > opts.MaxBytes {
+			return "", uploadTooLarge(opts)
+		}
+		return encodeUpload(filepath.Base(part.FileName()), part.Header.Get("Content-Type"), data)
+	}
+}
+
+// handleUpload takes a file submitted from the upload form. Problems with
+// the file, an oversized one included, are shown on the form again.
+func (h *WebInputHandler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	response, err := readUpload(r, h.req.Upload, h.validCSRF)
+	if errors.Is(err, errUploadToken) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *uploadTooLargeError
+		if errors.As(err, &tooLarge) {
+			// Read the rest so the browser finishes sending and shows the page
+			status = http.StatusRequestEntityTooLarge
+			io.Copy(io.Discard, io.LimitReader(r.Body, uploadDrainLimit))
+		}
+		h.renderForm(w, r, status, err.Error(), "")
+		return
+	}
+
+	h.mu.Lock()
+	if h.state != webPending {
+		h.mu.Unlock()
+		h.accepting(w, r)
+		return
+	}
+	h.inflight.Add(1)
+	h.mu.Unlock()
+	defer h.inflight.Done()
+
+	if h.finish(response, nil) {
+		h.renderThanks(w, r)
+	} else {
+		http.Error(w, i18n.T(h.locale(r), i18n.AlreadySubmitted), http.StatusBadRequest)
+	}
+}
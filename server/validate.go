@@ -0,0 +1,225 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ValidationError is returned when the user runs out of attempts to give a
+// valid answer.
+type ValidationError struct {
+	Attempts int
+	Value    string
+	Reason   string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed after %d attempts: %s", e.Attempts, e.Reason)
+}
+
+// RetryError is returned for an invalid answer the user may correct, with
+// how many attempts the prompt allows. Its message is the validation error.
+type RetryError struct {
+	Err         error
+	Attempt     int
+	MaxAttempts int
+}
+
+func (e *RetryError) Error() string { return e.Err.Error() }
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Remaining describes the attempts left, such as "2 attempts left".
+func (e *RetryError) Remaining() string {
+	left := e.MaxAttempts - e.Attempt + 1
+	if left == 1 {
+		return "1 attempt left"
+	}
+	return fmt.Sprintf("%d attempts left", left)
+}
+
+// Attempts counts the invalid answers given to one prompt. Ask sets it on
+// prompts with an attempt limit, so providers validating parts of an answer
+// themselves, like form fields, count against the same limit.
+type Attempts struct {
+	max    int
+	secret bool
+
+	mu       sync.Mutex
+	failures int
+}
+
+// Fail records that response failed with err. It returns a *RetryError
+// while attempts remain and a *ValidationError on the last one. A nil
+// Attempts returns err unchanged.
+func (a *Attempts) Fail(response string, err error) error {
+	if a == nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.failures++
+	if a.failures < a.max {
+		return &RetryError{Err: err, Attempt: a.failures + 1, MaxAttempts: a.max}
+	}
+	verr := &ValidationError{Attempts: a.failures, Reason: err.Error()}
+	if !a.secret {
+		verr.Value = response
+	}
+	return verr
+}
+
+// limitAttempts wraps validate so that its failures are counted by
+// attempts: the last returns a *ValidationError, which providers pass on
+// instead of asking again.
+func limitAttempts(validate func(string) (string, error), attempts *Attempts) func(string) (string, error) {
+	return func(response string) (string, error) {
+		valid, err := validate(response)
+		if err == nil {
+			return valid, nil
+		}
+		return "", attempts.Fail(response, err)
+	}
+}
+
+// isAttemptsExhausted reports whether err ends a prompt rather than asking
+// the user again.
+func isAttemptsExhausted(err error) bool {
+	var verr *ValidationError
+	return errors.As(err, &verr)
+}
+
+// NewPatternValidator returns a validator requiring the answer req would
+// return, after normalization, to match pattern in full. The pattern is
+// compiled once, here, and recorded on req. An empty answer is left to the
+// prompt's default, if it has one. message replaces the generic error shown
+// to the user.
+func NewPatternValidator(req *PromptRequest, pattern, message string) (func(string) (string, error), error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("Invalid pattern parameter: %v", err)
+	}
+	re := regexp.MustCompile(`^(?:` + pattern + `)$`)
+	req.Pattern = pattern
+
+	if message == "" {
+		message = fmt.Sprintf("Response must match the pattern %s", pattern)
+	}
+	return func(response string) (string, error) {
+		answer := normalizeAnswer(response, req.Trim, req.Dedent)
+		if strings.TrimSpace(answer) == "" && req.Default != "" {
+			return response, nil
+		}
+		if !re.MatchString(answer) {
+			return "", fmt.Errorf("%s", message)
+		}
+		return response, nil
+	}, nil
+}
+
+// NewAllowedValuesValidator returns a validator accepting only the answers in
+// values, compared after trimming and case folding. The answer becomes the
+// matching value as written in values, which are recorded on req. An empty
+// answer is left to the prompt's default, if it has one.
+func NewAllowedValuesValidator(req *PromptRequest, values []string) (func(string) (string, error), error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Invalid allowed_values parameter: must not be empty")
+	}
+	for i, value := range values {
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("Invalid allowed_values parameter: element %d is blank", i)
+		}
+		for _, earlier := range values[:i] {
+			if strings.EqualFold(strings.TrimSpace(earlier), strings.TrimSpace(value)) {
+				return nil, fmt.Errorf("Invalid allowed_values parameter: %q is listed twice", value)
+			}
+		}
+	}
+
+	req.AllowedValues = values
+	message := fmt.Sprintf("Response must be one of: %s", strings.Join(values, ", "))
+	return func(response string) (string, error) {
+		answer := strings.TrimSpace(normalizeAnswer(response, req.Trim, req.Dedent))
+		if answer == "" && req.Default != "" {
+			return response, nil
+		}
+		for _, value := range values {
+			if strings.EqualFold(strings.TrimSpace(value), answer) {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%s", message)
+	}, nil
+}
+
+// RequireAnswer wraps the prompt's validation so that an answer that is
+// blank after normalization is accepted when the prompt has a default or
+// allows empty answers, and asked again otherwise. Blank answers skip the
+// wrapped validation either way.
+func (r *PromptRequest) RequireAnswer() {
+	validate := r.Validate
+	r.Validate = func(response string) (string, error) {
+		if strings.TrimSpace(normalizeAnswer(response, r.Trim, r.Dedent)) != "" {
+			if validate == nil {
+				return response, nil
+			}
+			return validate(response)
+		}
+		if r.Default != "" || r.AllowEmpty {
+			return response, nil
+		}
+		return "", fmt.Errorf("Response cannot be empty")
+	}
+}
+
+// sendInputError reports a failure to collect an answer. A declined prompt
+// is a result marked declined; running out of attempts or time is a tool
+// error the agent can act on; anything else is an internal error.
+func (s *MCPServer) sendInputError(id interface{}, err error) {
+	// Declining is the user's answer, not a failure
+	var derr *DeclinedError
+	if errors.As(err, &derr) {
+		s.sendResponse(id, declinedResult(derr))
+		return
+	}
+
+	var terr *TimeoutError
+	if errors.As(err, &terr) {
+		s.sendResponse(id, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent(terr.Error()),
+			},
+			"structuredContent": map[string]interface{}{
+				"timedOut": true,
+				"timeout":  terr.Timeout.Seconds(),
+			},
+			"isError": true,
+		})
+		return
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		s.sendError(id, -32603, fmt.Sprintf("Failed to get user input: %v", err))
+		return
+	}
+
+	text := fmt.Sprintf("Validation failed after %d attempts: %s", verr.Attempts, verr.Reason)
+	if verr.Value != "" {
+		text += fmt.Sprintf(" (last value: %q)", verr.Value)
+	}
+	s.sendResponse(id, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(text),
+		},
+		"structuredContent": map[string]interface{}{
+			"attempts":  verr.Attempts,
+			"lastValue": verr.Value,
+			"reason":    verr.Reason,
+		},
+		"isError": true,
+	})
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"prompt-mcp/i18n"
+	"prompt-mcp/units"
+)
+
+// askedAgo says how long the question has been waiting, e.g. "Asked 2m 13s
+// ago". The page's script writes it the same way as it ticks.
+func askedAgo(locale string, elapsed time.Duration) string {
+	return fmt.Sprintf(i18n.T(locale, i18n.AskedAgo), units.FormatElapsed(elapsed.Seconds()))
+}
+
+// askedLine is when the question was asked, as the terminal prints it once
+// under the prompt: the clock time, and how long ago when the question
+// waited to be shown, e.g. behind another one.
+func askedLine(locale string, asked, now time.Time) string {
+	if now.Sub(asked) < time.Second {
+		return i18n.T(locale, i18n.DashboardAsked) + " " + asked.Format("15:04:05")
+	}
+	return askedAgo(locale, now.Sub(asked)) + " (" + asked.Format("15:04:05") + ")"
+}
@@ -0,0 +1,1276 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prompt-mcp/i18n"
+)
+
+// webState is where a web prompt stands. Pending prompts take answers;
+// closing ones have given up waiting but still take the submissions that
+// arrived in time. The others are final.
+type webState int
+
+const (
+	webPending webState = iota
+	webClosing
+	webAnswered
+	webFailed
+	webExpired
+)
+
+type WebInputHandler struct {
+	req        *PromptRequest
+	serverDone chan struct{}
+	mu         sync.Mutex
+	server     *http.Server
+	mux        *http.ServeMux
+
+	// state, under mu, with the answer or failure that ended the prompt
+	// and, once expired, why. changed is closed, and replaced, whenever
+	// state moves on
+	state   webState
+	answer  string
+	err     error
+	reason  string
+	changed chan struct{}
+
+	// base is the path the handler's pages are served under, "" at the
+	// root; back, if set, is the dashboard index the pages link back to
+	base string
+	back string
+
+	// token, if set, must lead every request path; once the prompt is
+	// answered the pages are gone
+	token string
+
+	// csrf is the form token every submission must carry back
+	csrf string
+
+	// deadline is when the prompt times out, if it has a timeout. Once the
+	// prompt is closing no submission is accepted; those that arrived
+	// before, counted in inflight, are still waited for
+	deadline time.Time
+	inflight sync.WaitGroup
+
+	// asked is when the question was asked, which the page counts from
+	asked time.Time
+
+	// staged, under mu, is the answer awaiting the user's confirmation
+	// when the prompt requires one
+	staged *stagedAnswer
+}
+
+// PageData is passed to the input.html template. It is the data contract
+// for custom templates (see LoadWebAssets): fields are added over time but
+// not renamed or removed. Which fields are set depends on the kind of
+// prompt; the rest keep their zero value, so templates test them with
+// {{if}} before use. Prompt, Title and Detail are plain text and escaped by
+// the template; Base prefixes the page's links, /static/ included, and
+// carries the page's token, and CSRF goes back in a hidden csrf field.
+type PageData struct {
+	Prompt  string
+	Options []string
+	Multi   bool
+	Other   bool
+	Confirm bool
+	Ack     bool
+	Default string
+
+	// Phrase, for a phrase confirmation, must be typed before the confirm
+	// button is enabled
+	Phrase *ConfirmationPhrase
+
+	// AllowEmpty drops the required attribute from the answer input
+	AllowEmpty bool
+
+	Title   string
+	Detail  string
+	Urgency string
+
+	// Lang is the language of the page chrome, for the lang attribute
+	Lang string
+
+	// Requester says which MCP client asked, and which question of the
+	// session this is; empty outside a session
+	Requester string
+
+	// Emphasis badges the title and favicon of high priority prompts
+	Emphasis bool
+
+	// Remember offers a checkbox asking for the answer to be reused for the
+	// same question
+	Remember bool
+
+	// Asked, in Unix milliseconds, is when the question was asked, and
+	// Waiting says how long ago as of rendering, e.g. "Asked 2m 13s ago";
+	// the page keeps it ticking. Deadline, in Unix milliseconds, drives the
+	// countdown, which turns to a warning as it nears, and zero hides it.
+	// AutoDecision, the message key of "Auto-denying in" or "Auto-approving
+	// in", heads it for a confirm prompt decided by its timeout
+	Asked           int64
+	Waiting         string
+	Deadline        int64
+	TimeoutResponse *string
+	AutoDecision    string
+	Secret          bool
+	Sensitive       bool
+	Suggestions     []string
+	Placeholder     string
+	Twice           bool
+	Multiline       bool
+	Fields          []webField
+	Browse          *webBrowse
+	Upload          *webUpload
+	Number          *NumberOptions
+	Images          []string
+	Attachments     []webAttachment
+	Context         *Conversation
+	ContextOmitted  string
+	Rating          *webRating
+	Rank            *webRank
+	List            *webList
+	Duration        *webDuration
+	DateTime        *webDateTime
+	Review          bool
+	PlanReview      bool
+	Credentials     bool
+	Read            bool
+	Reactions       []Reaction
+	Service         string
+	Content         string
+	Error           string
+	Value           string
+
+	// Length drives the remaining-characters counter of text answers
+	Length *LengthLimits
+
+	// Paste lets long text be pasted or dropped into a text answer, within
+	// the size cap it carries
+	Paste *webPaste
+
+	// Attempt tells a user retrying an answer which attempt this is
+	Attempt string
+
+	// SubmitLabel and DeclineLabel are the buttons' own text, empty for
+	// the defaults
+	SubmitLabel  string
+	DeclineLabel string
+
+	// Constraints mirrors the checks of a text answer on the form; Failed
+	// names the one a rejected answer failed, when the error doesn't say
+	Constraints *webConstraints
+	Failed      string
+
+	// Drafts makes the page post answers to /draft as they are filled in
+	Drafts bool
+
+	// Base prefixes the page's own links, for a prompt on the dashboard
+	Base string
+
+	// CSRF is the form token submissions must carry
+	CSRF string
+
+	// Theme is the operator's branding, nil for the built-in look
+	Theme *PageTheme
+}
+
+// webAttachment is an attachment's tab and its highlighted, read-only pane.
+type webAttachment struct {
+	Name     string
+	Language string
+	Size     string
+	Code     template.HTML
+}
+
+// webRating is the scale shown for a rating prompt: a button per point, or
+// a slider when there are too many.
+type webRating struct {
+	*RatingOptions
+	Steps  []int
+	Slider bool
+}
+
+// webRank is the sortable list shown for a ranking, in the order last
+// submitted. Partial rankings get a checkbox per option.
+type webRank struct {
+	Items   []webRankItem
+	Partial bool
+	Max     int
+	Limited bool
+}
+
+// webList is the rows of a list prompt, as last submitted, with the
+// bounds on their number.
+type webList struct {
+	Items  []string
+	Bounds string
+}
+
+// webDuration is the number field and unit dropdown of a duration
+// prompt, split from the value last submitted.
+type webDuration struct {
+	Amount string
+	Unit   string
+	Units  []durationUnit
+}
+
+type webRankItem struct {
+	Number int
+	Label  string
+	Picked bool
+}
+
+// webDateTime is the native input shown for a date or time prompt, with its
+// bounds in the input's own format.
+type webDateTime struct {
+	Type string
+	Min  string
+	Max  string
+	Zone string
+}
+
+// webUpload is the file input of an upload prompt, with its size cap.
+type webUpload struct {
+	MaxBytes int64
+	Limit    string
+}
+
+// webBrowse is the directory listing shown for a file prompt.
+type webBrowse struct {
+	Dir     string
+	Parent  string
+	DirOnly bool
+	Entries []fileEntry
+}
+
+// webField is a form field as rendered on the input page, with the value
+// last submitted for it and its validation error.
+type webField struct {
+	Name     string
+	Label    string
+	Type     string
+	Options  []string
+	Optional bool
+	Value    string
+	Error    string
+
+	// WhenField and WhenValue show the field only while the named field
+	// has that answer
+	WhenField string
+	WhenValue string
+}
+
+// NewWebInputHandler returns the HTTP handler serving the input page for req.
+func NewWebInputHandler(req *PromptRequest) *WebInputHandler {
+	h := &WebInputHandler{
+		req:        req,
+		serverDone: make(chan struct{}, 1),
+		changed:    make(chan struct{}),
+		csrf:       rand.Text(),
+		asked:      req.Asked,
+	}
+	if h.asked.IsZero() {
+		h.asked = time.Now()
+	}
+	if req.Timeout > 0 {
+		h.deadline = time.Now().Add(req.Timeout)
+	}
+
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("/", h.handleRoot)
+	h.mux.HandleFunc("/submit", h.handleSubmit)
+	h.mux.HandleFunc("/decline", h.handleDecline)
+	h.mux.HandleFunc("/image/", h.handleImage)
+	h.mux.HandleFunc("/draft", h.handleDraft)
+	h.mux.HandleFunc("/events", h.handleEvents)
+	h.mux.Handle("/static/", staticHandler())
+
+	return h
+}
+
+// Protect makes the handler answer only under a new random token, and
+// returns the path of its page: prefix, then the token. Requests without
+// the token, or after an answer was accepted, get 404.
+func (h *WebInputHandler) Protect(prefix string) (string, error) {
+	token, err := newWebToken()
+	if err != nil {
+		return "", err
+	}
+	h.token = token
+	h.base = prefix + "/" + token
+	return h.base + "/", nil
+}
+
+func (h *WebInputHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" {
+		h.mu.Lock()
+		spent := h.state == webAnswered
+		h.mu.Unlock()
+		if !tokenLeads(r.URL.Path, h.token) {
+			http.NotFound(w, r)
+			return
+		}
+		// Once answered, the page and its form only say so
+		if rest := strings.TrimPrefix(r.URL.Path, "/"+h.token); spent && rest != "/" && rest != "/submit" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/"+h.token {
+			http.Redirect(w, r, h.base+"/", http.StatusMovedPermanently)
+			return
+		}
+		http.StripPrefix("/"+h.token, h.mux).ServeHTTP(w, r)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// newWebToken returns a random, URL-safe token for a page's path.
+func newWebToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// tokenLeads reports whether path starts with the segment /token, comparing
+// the token in constant time.
+func tokenLeads(path, token string) bool {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return subtle.ConstantTimeCompare([]byte(segment), []byte(token)) == 1
+}
+
+// Wait blocks until the user submits a valid response or ctx is done. Once
+// ctx is done, later submissions are turned away, so a submission is either
+// returned here or told the prompt expired. One that arrived before then is
+// still waited for, however close to the deadline. Pages still open are
+// told how the prompt ended.
+func (h *WebInputHandler) Wait(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	for h.state == webPending {
+		changed := h.changed
+		h.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+		}
+		h.mu.Lock()
+		if ctx.Err() != nil && h.state == webPending {
+			h.setStateLocked(webClosing)
+		}
+	}
+	h.mu.Unlock()
+	h.inflight.Wait()
+
+	// A submission accepted before the prompt expired still counts
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch h.state {
+	case webAnswered:
+		return h.answer, nil
+	case webFailed:
+		return "", h.err
+	}
+	switch {
+	case answeredVia(ctx) == MethodTTY:
+		h.reason = i18n.PageAnsweredInTTY
+	case errors.Is(context.Cause(ctx), ErrAnsweredElsewhere):
+		h.reason = i18n.PageAnsweredElsewhere
+	case errors.Is(context.Cause(ctx), ErrWithdrawn):
+		h.reason = i18n.PageWithdrawn
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		h.reason = i18n.PageTimedOut
+	default:
+		h.reason = i18n.PageCancelled
+	}
+	h.setStateLocked(webExpired)
+	return "", ctx.Err()
+}
+
+// setStateLocked moves the prompt on to state, waking everything watching
+// it. h.mu must be held.
+func (h *WebInputHandler) setStateLocked(state webState) {
+	h.state = state
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// finish ends a pending or closing prompt with answer or err, and reports
+// whether it did; a prompt that already ended keeps its outcome.
+func (h *WebInputHandler) finish(answer string, err error) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != webPending && h.state != webClosing {
+		return false
+	}
+	h.answer, h.err = answer, err
+	if err != nil {
+		h.setStateLocked(webFailed)
+	} else {
+		h.setStateLocked(webAnswered)
+	}
+	return true
+}
+
+// webStateEvent tells a page how its prompt ended. State is "answered",
+// "failed", "expired" or "withdrawn".
+type webStateEvent struct {
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// handleEvents streams a "state" event to the page once the prompt stops
+// waiting for it, then ends.
+func (h *WebInputHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, ": watching\n\n")
+	flusher.Flush()
+
+	locale := h.locale(r)
+	for {
+		h.mu.Lock()
+		state, reason, changed, err := h.state, h.reason, h.changed, h.err
+		h.mu.Unlock()
+
+		var event webStateEvent
+		switch state {
+		case webAnswered:
+			event = webStateEvent{"answered", i18n.T(locale, i18n.Submitted)}
+		case webFailed:
+			event = webStateEvent{"failed", i18n.T(locale, failedMessage(err))}
+		case webExpired:
+			event = webStateEvent{"expired", i18n.T(locale, reason)}
+			if reason == i18n.PageWithdrawn {
+				event.State = "withdrawn"
+			}
+		default:
+			select {
+			case <-changed:
+				continue
+			case <-r.Context().Done():
+				return
+			}
+		}
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(w, "event: state\ndata: %s\n\n", data)
+		flusher.Flush()
+		return
+	}
+}
+
+func getUserInputFromWeb(ctx context.Context, req *PromptRequest) (string, error) {
+	l := currentWebListener()
+	handler := NewWebInputHandler(req)
+	path, err := handler.Protect(l.BasePath)
+	if err != nil {
+		return "", err
+	}
+
+	server, url, err := startWebServer(l, handler, handler.serverDone)
+	if err != nil {
+		return "", err
+	}
+	handler.server = server
+	showInBrowser(url+path, "input", req.alert())
+	showQR(url + path)
+
+	// Wait for response or timeout
+	response, err := handler.Wait(ctx)
+	handler.shutdown()
+	return response, err
+}
+
+// startWebServer serves handler on l in the background, under its base
+// path, signalling done when the server stops, and returns the URL the
+// page's path, base path included, is to be appended to: that of l's
+// tunnel, if it has one, which is closed with the server.
+func startWebServer(l WebListener, handler http.Handler, done chan<- struct{}) (*http.Server, string, error) {
+	listener, err := l.listen(0)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen for the web prompt: %w", err)
+	}
+
+	server := &http.Server{Handler: l.mount(handler)}
+
+	// Start server in background
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "Web server error: %v\n", err)
+		}
+		done <- struct{}{}
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	if tunnel := l.startTunnel(port); tunnel != nil {
+		server.RegisterOnShutdown(func() { tunnel.Close() })
+		return server, tunnel.URL, nil
+	}
+	return server, l.url(port), nil
+}
+
+// showInBrowser opens url, telling the user on stderr what it is for. An
+// alert without focus leaves the browser alone and only puts the URL on the
+// terminal. Without a browser to open, or when opening it fails, the URL is
+// shown on the terminal instead (showURL). The URL's path holds the page's
+// token, so stderr gets it only when there is no terminal and the user has
+// to open the page themselves.
+func showInBrowser(url, purpose string, alert Alert) {
+	if alert.Bell {
+		ringBell()
+	}
+	if !alert.Focus {
+		// Nothing asks for the user's attention, so without a terminal the
+		// page is left to the dashboard or the user's notifications
+		if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+			fmt.Fprint(tty, URLBlock(url, purpose))
+			tty.Close()
+		}
+		fmt.Fprintf(os.Stderr, "Waiting for %s on %s\n", purpose, urlOrigin(url))
+		return
+	}
+	if !currentWebListener().OpensBrowser() {
+		showURL(url, purpose)
+		return
+	}
+	if err := openBrowser(url); err != nil {
+		showURL(url, purpose)
+	} else {
+		fmt.Fprintf(os.Stderr, "Opening browser for %s on %s\n", purpose, urlOrigin(url))
+	}
+}
+
+// showURL prints url for the user to open by hand, as a block on the
+// controlling terminal: MCP clients often capture stderr, where it would
+// never be seen. Without a terminal it goes to stderr after all.
+func showURL(url, purpose string) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Open this URL in a browser for %s: %s\n", purpose, url)
+		return
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, URLBlock(url, purpose))
+	fmt.Fprintf(os.Stderr, "Waiting for %s on %s; the URL is on the terminal\n", purpose, urlOrigin(url))
+}
+
+// URLBlock is url set apart for copying: between rules, on a line of its
+// own with nothing around it but an indent, so a double click or a
+// terminal's link detection takes exactly the URL.
+func URLBlock(url, purpose string) string {
+	rule := strings.Repeat("─", 60)
+	return fmt.Sprintf("\n%s\nOpen this URL in a browser for %s:\n\n    %s\n\n%s\n", rule, purpose, url, rule)
+}
+
+// urlOrigin is the scheme and host of url, leaving out its path.
+func urlOrigin(url string) string {
+	scheme, rest, _ := strings.Cut(url, "://")
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host
+}
+
+// rankList orders the options as in the submitted value, if any, with the
+// options it left out after them in their original order.
+func (h *WebInputHandler) rankList(value string) *webRank {
+	opts := h.req.Rank
+	rank := &webRank{Partial: opts.AllowPartial, Max: opts.MaxRanked, Limited: opts.MaxRanked < len(h.req.Options)}
+	order, _ := parseRanking(value, len(h.req.Options))
+	picked := make(map[int]bool, len(order))
+	for _, index := range order {
+		picked[index] = true
+	}
+	for i := range h.req.Options {
+		if !picked[i] {
+			order = append(order, i)
+		}
+	}
+	for _, index := range order {
+		rank.Items = append(rank.Items, webRankItem{Number: index + 1, Label: h.req.Options[index], Picked: picked[index]})
+	}
+	return rank
+}
+
+// handleDraft records the valid answers of a form filled in so far, so
+// they can be returned if the prompt times out before it is submitted.
+func (h *WebInputHandler) handleDraft(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.req.Partial == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.checkCSRF(w, r) {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	values := make(map[string]interface{}, len(h.req.Fields))
+	for _, field := range h.req.Fields {
+		raw := r.FormValue("field." + field.Name)
+		var value interface{}
+		if strings.TrimSpace(raw) != "" && field.active(values) {
+			value, _ = field.Parse(raw)
+		}
+		if value != nil {
+			values[field.Name] = value
+		}
+		h.req.Partial.Set(field.Name, value)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleImage serves the prompt's images by index. nosniff keeps browsers
+// from treating them as anything but the validated image type.
+func (h *WebInputHandler) handleImage(w http.ResponseWriter, r *http.Request) {
+	i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/image/"))
+	if err != nil || i < 0 || i >= len(h.req.Images) {
+		http.NotFound(w, r)
+		return
+	}
+	image := h.req.Images[i]
+	w.Header().Set("Content-Type", image.MimeType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(image.Data)
+}
+
+func (h *WebInputHandler) handleRoot(w http.ResponseWriter, r *http.Request) {
+	// A page reloaded after the prompt ended says how it ended instead
+	h.mu.Lock()
+	state := h.state
+	h.mu.Unlock()
+	switch state {
+	case webAnswered, webFailed:
+		h.renderCompleted(w, r, http.StatusOK)
+		return
+	case webExpired:
+		h.renderExpired(w, r)
+		return
+	}
+
+	if h.req.Kind == KindForm {
+		h.renderFields(w, r, http.StatusOK, nil, nil, "")
+		return
+	}
+	if h.req.Kind == KindFile {
+		data := h.pageData("", "")
+		data.Browse = h.browse(r.URL.Query().Get("dir"))
+		h.renderPage(w, r, http.StatusOK, data)
+		return
+	}
+	// A text prompt's default and the content to edit are pre-filled so the
+	// user can just submit them
+	value := ""
+	if h.req.Kind == KindText || h.req.Kind == KindNumber {
+		value = h.req.Default
+	}
+	if h.req.Kind == KindEdit {
+		value = h.req.Content
+	}
+	h.renderForm(w, r, http.StatusOK, "", value)
+}
+
+// renderForm writes the input page, optionally with a validation error and
+// the value that failed it.
+func (h *WebInputHandler) renderForm(w http.ResponseWriter, r *http.Request, status int, errMsg, value string) {
+	h.renderPage(w, r, status, h.pageData(errMsg, value))
+}
+
+func (h *WebInputHandler) pageData(errMsg, value string) PageData {
+	data := PageData{
+		Prompt:      h.req.Prompt,
+		Options:     h.req.Options,
+		Multi:       h.req.MultiSelect,
+		Other:       h.req.AllowOther,
+		Confirm:     h.req.Kind == KindConfirm,
+		Ack:         h.req.Kind == KindAck,
+		Phrase:      h.req.Phrase,
+		Default:     h.req.Default,
+		AllowEmpty:  h.req.AllowEmpty,
+		Secret:      h.req.Secret,
+		Twice:       h.req.ConfirmSecret,
+		Sensitive:   h.req.Sensitive,
+		Suggestions: h.req.Suggestions,
+		Placeholder: h.req.Placeholder,
+		Multiline:   h.req.Multiline || h.req.LongAnswer,
+		Number:      h.req.Number,
+		Length:      h.req.Length,
+		Constraints: h.req.constraints(),
+		Review:      h.req.Kind == KindReview,
+		PlanReview:  h.req.Kind == KindPlanReview,
+		Credentials: h.req.Kind == KindCredentials,
+		Read:        h.req.Kind == KindRead,
+		Reactions:   h.req.Reactions,
+		Service:     h.req.Service,
+		Content:     h.req.Content,
+		Remember:    h.req.Remember != nil,
+		Error:       errMsg,
+		Value:       value,
+
+		SubmitLabel:  h.req.SubmitLabel,
+		DeclineLabel: h.req.DeclineLabel,
+	}
+	if h.req.Kind == KindFile {
+		data.Browse = h.browse("")
+	}
+	if h.req.Kind == KindUpload {
+		data.Upload = &webUpload{MaxBytes: h.req.Upload.MaxBytes, Limit: h.req.Upload.limit()}
+	}
+	if h.req.pasteable() {
+		data.Paste = &webPaste{MaxBytes: h.req.answerLimit(), Limit: h.req.answerLimitText(), Long: pasteLong}
+		// An answer pasted into a textarea is shown in one again
+		data.Multiline = data.Multiline || strings.Contains(value, "\n")
+	}
+	for i := range h.req.Images {
+		data.Images = append(data.Images, fmt.Sprintf("%s/image/%d", h.base, i))
+	}
+	if conv := h.req.Context; conv != nil {
+		data.Context = conv
+		if conv.Omitted > 0 {
+			data.ContextOmitted = conv.omittedText()
+		}
+	}
+	for _, a := range h.req.Attachments {
+		data.Attachments = append(data.Attachments, webAttachment{
+			Name:     a.Name,
+			Language: a.Language,
+			Size:     a.size(),
+			Code:     highlightHTML(a.Content, a.Language),
+		})
+	}
+	if h.req.Kind == KindRating {
+		rating := &webRating{RatingOptions: h.req.Rating}
+		if count := h.req.Rating.Max - h.req.Rating.Min + 1; count > ratingButtonSteps {
+			rating.Slider = true
+		} else {
+			for n := h.req.Rating.Min; n <= h.req.Rating.Max; n++ {
+				rating.Steps = append(rating.Steps, n)
+			}
+		}
+		data.Rating = rating
+	}
+	if h.req.Kind == KindRank {
+		data.Rank = h.rankList(value)
+	}
+	if h.req.Number != nil && h.req.Number.Duration != nil {
+		data.Duration = webDurationInput(value, h.req.Number.Duration.DefaultUnit)
+	}
+	if h.req.Kind == KindList {
+		list := &webList{Bounds: h.req.List.bounds()}
+		json.Unmarshal([]byte(value), &list.Items)
+		if len(list.Items) == 0 {
+			list.Items = []string{""}
+		}
+		data.List = list
+	}
+	if opts := h.req.DateTime; opts != nil {
+		input := &webDateTime{Type: opts.Mode, Zone: opts.zone()}
+		if opts.Mode == ModeDateTime {
+			input.Type = "datetime-local"
+		}
+		if opts.Min != nil {
+			input.Min = opts.inputValue(*opts.Min)
+		}
+		if opts.Max != nil {
+			input.Max = opts.inputValue(*opts.Max)
+		}
+		data.DateTime = input
+	}
+	return data
+}
+
+// browse lists dir for a file prompt. Directories the prompt may not leave
+// fall back to the start directory.
+func (h *WebInputHandler) browse(dir string) *webBrowse {
+	opts := h.req.File
+	if dir == "" || !filepath.IsAbs(dir) {
+		dir = opts.StartDir
+	}
+	dir = filepath.Clean(dir)
+	if opts.Restrict && !opts.contains(dir) {
+		dir = opts.StartDir
+	}
+
+	b := &webBrowse{Dir: dir, DirOnly: opts.DirectoriesOnly}
+	if parent := filepath.Dir(dir); parent != dir && (!opts.Restrict || opts.contains(parent)) {
+		b.Parent = parent
+	}
+	// An unreadable directory is shown empty; the typed path still works
+	b.Entries, _ = opts.listDir(dir)
+	return b
+}
+
+// renderFields writes the input page of a form prompt. Fields are filled
+// with the submitted raw values, or their defaults when nothing was
+// submitted yet.
+func (h *WebInputHandler) renderFields(w http.ResponseWriter, r *http.Request, status int, raw map[string]string, errs map[string]string, attempt string) {
+	fields := make([]webField, len(h.req.Fields))
+	for i, field := range h.req.Fields {
+		value, submitted := raw[field.Name]
+		if !submitted {
+			value = field.defaultText()
+		}
+		fields[i] = webField{
+			Name:     field.Name,
+			Label:    field.label(),
+			Type:     field.Type,
+			Options:  field.Options,
+			Optional: field.Optional,
+			Value:    value,
+			Error:    errs[field.Name],
+		}
+		if field.When != nil {
+			fields[i].WhenField = field.When.Field
+			fields[i].WhenValue = field.When.text()
+		}
+	}
+
+	h.renderPage(w, r, status, PageData{
+		Prompt:  h.req.Prompt,
+		Fields:  fields,
+		Drafts:  h.req.Partial != nil,
+		Attempt: attempt,
+	})
+}
+
+func (h *WebInputHandler) renderPage(w http.ResponseWriter, r *http.Request, status int, data PageData) {
+	data.CSRF = h.csrf
+	data.Base = h.base
+	data.Theme = currentWebTheme().page(h.base)
+	data.Title = h.req.Title
+	data.Detail = h.req.Detail
+	data.Urgency = h.req.Urgency
+	locale := h.locale(r)
+	data.Lang = i18n.Normalize(locale)
+	data.Requester = h.req.requester(locale)
+	data.Emphasis = h.req.alert().Emphasis
+	data.Asked = h.asked.UnixMilli()
+	data.Waiting = askedAgo(locale, time.Since(h.asked))
+	if !h.deadline.IsZero() {
+		data.Deadline = h.deadline.UnixMilli()
+		data.TimeoutResponse = h.req.TimeoutResponse
+		if h.req.Kind == KindConfirm && h.req.TimeoutResponse != nil {
+			data.AutoDecision = i18n.AutoDeny
+			if *h.req.TimeoutResponse == AnswerYes {
+				data.AutoDecision = i18n.AutoApprove
+			}
+		}
+	}
+
+	renderTemplate(w, status, "input.html", inputFuncs(locale), data)
+}
+
+// locale is the language of the page served for r: the browser's, if the
+// prompt's language may be negotiated, else the prompt's.
+func (h *WebInputHandler) locale(r *http.Request) string {
+	return pageLocale(r, h.req.Locale, h.req.NegotiateLocale)
+}
+
+// inputFuncs are the functions of the input page template in locale.
+func inputFuncs(locale string) template.FuncMap {
+	funcs := translate(locale)
+	funcs["inc"] = func(i int) int { return i + 1 }
+	// picked reports whether option i was among the submitted checkboxes
+	funcs["picked"] = func(value string, i int) bool {
+		return containsString(strings.Split(value, ","), strconv.Itoa(i+1))
+	}
+	return funcs
+}
+
+func (h *WebInputHandler) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Turn late submissions away before asking for anything else
+	if !h.accepting(w, r) {
+		return
+	}
+	if h.req.Kind == KindUpload {
+		if h.checkCSRF(w, r) {
+			h.handleUpload(w, r)
+		}
+		return
+	}
+	if !h.readForm(w, r) || !h.checkCSRF(w, r) {
+		return
+	}
+	if h.req.RequireConfirmation {
+		if nonce := r.PostFormValue(reviewConfirmField); nonce != "" {
+			h.confirmStaged(w, r, nonce)
+			return
+		}
+		if nonce := r.PostFormValue(reviewEditField); nonce != "" {
+			h.editStaged(w, r, nonce)
+			return
+		}
+	}
+
+	response := r.FormValue("response")
+
+	// The whole submission has arrived; if the prompt hasn't expired yet,
+	// it is answered before Wait gives up
+	h.mu.Lock()
+	if h.state != webPending {
+		h.mu.Unlock()
+		h.accepting(w, r)
+		return
+	}
+	h.inflight.Add(1)
+	h.mu.Unlock()
+	defer h.inflight.Done()
+	if h.req.MultiSelect {
+		// Each ticked checkbox submits its option number
+		response = strings.Join(r.Form["response"], ",")
+	}
+	if h.req.Number != nil && h.req.Number.Duration != nil && strings.TrimSpace(response) != "" {
+		// The number field and the unit dropdown make one answer, e.g. "90m"
+		response = strings.TrimSpace(response) + r.FormValue("unit")
+	}
+	if h.req.Phrase != nil && r.FormValue("deny") != "" {
+		response = ""
+	}
+	if pick := r.FormValue("pick"); pick != "" && h.req.Kind == KindFile {
+		response = pick
+	}
+	if suggestion := r.FormValue("suggestion"); suggestion != "" && len(h.req.Suggestions) > 0 {
+		response = suggestion
+	}
+	if h.req.Multiline || h.req.LongAnswer || h.req.pasteable() {
+		// Browsers submit textarea line breaks as CRLF, including those of
+		// a one-line answer the page turned into a textarea for a paste
+		response = strings.ReplaceAll(response, "\r\n", "\n")
+	}
+	if h.req.Kind == KindForm {
+		raw := make(map[string]string, len(h.req.Fields))
+		for _, field := range h.req.Fields {
+			raw[field.Name] = r.FormValue("field." + field.Name)
+		}
+
+		// Only the fields that failed are flagged; the rest keep their values
+		if _, errs := parseFormAnswers(h.req.Fields, raw); len(errs) > 0 {
+			var reasons []string
+			for _, field := range h.req.Fields {
+				if msg, failed := errs[field.Name]; failed {
+					reasons = append(reasons, field.Name+": "+msg)
+				}
+			}
+			data, _ := json.Marshal(raw)
+			err := h.req.Attempts.Fail(string(data), errors.New(strings.Join(reasons, "; ")))
+			if isAttemptsExhausted(err) {
+				h.giveUp(w, r, err)
+				return
+			}
+			h.renderFields(w, r, http.StatusBadRequest, raw, errs, attemptText(h.locale(r), err))
+			return
+		}
+
+		data, err := json.Marshal(raw)
+		if err != nil {
+			http.Error(w, "Failed to encode form", http.StatusInternalServerError)
+			return
+		}
+		response = string(data)
+	}
+
+	if int64(len(response)) > h.req.answerLimit() {
+		h.renderForm(w, r, http.StatusRequestEntityTooLarge, h.tooLarge(), "")
+		return
+	}
+
+	// What the form shows again if the response is rejected
+	shown := response
+	if h.req.Sensitive {
+		shown = ""
+	}
+	if h.req.AllowOther {
+		shown = ""
+		if response == "other" {
+			shown = r.FormValue("other")
+			response = OtherResponse(shown)
+		}
+	}
+	if h.req.Kind == KindCredentials {
+		creds := Credentials{Username: r.FormValue("username"), Password: r.FormValue("password")}
+		data, err := json.Marshal(creds)
+		if err != nil {
+			http.Error(w, "Failed to encode credentials", http.StatusInternalServerError)
+			return
+		}
+		response = string(data)
+		// Only the username is filled in again
+		shown = creds.Username
+	}
+	if h.req.Kind == KindPlanReview {
+		// Only the decision and instructions come back; the plan itself is
+		// never posted, however long it is
+		review := PlanReview{
+			Decision:     r.FormValue("decision"),
+			Instructions: strings.ReplaceAll(r.FormValue("instructions"), "\r\n", "\n"),
+		}
+		data, err := json.Marshal(review)
+		if err != nil {
+			http.Error(w, "Failed to encode plan review", http.StatusInternalServerError)
+			return
+		}
+		response = string(data)
+		shown = review.Instructions
+	}
+	if h.req.Kind == KindList {
+		items := r.Form["item"]
+		if items == nil {
+			items = []string{}
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			http.Error(w, "Failed to encode list", http.StatusInternalServerError)
+			return
+		}
+		response = string(data)
+		shown = response
+	}
+	if h.req.Kind == KindReview {
+		review := Review{
+			Decision: r.FormValue("decision"),
+			Comment:  strings.ReplaceAll(r.FormValue("comment"), "\r\n", "\n"),
+		}
+		// Approving with a comment typed in counts as approving with comment
+		if review.Decision == DecisionApprove && strings.TrimSpace(review.Comment) != "" {
+			review.Decision = DecisionApproveWithComment
+		}
+		data, err := json.Marshal(review)
+		if err != nil {
+			http.Error(w, "Failed to encode review", http.StatusInternalServerError)
+			return
+		}
+		response = string(data)
+		shown = review.Comment
+	}
+
+	if h.req.Secret && h.req.ConfirmSecret && response != r.FormValue("response_confirm") {
+		h.renderForm(w, r, http.StatusBadRequest, i18n.T(h.locale(r), i18n.EntriesMismatch), "")
+		return
+	}
+
+	if h.req.Validate != nil {
+		valid, err := h.req.Validate(response)
+		if isAttemptsExhausted(err) {
+			h.giveUp(w, r, err)
+			return
+		}
+		if err != nil {
+			data := h.pageData(err.Error(), shown)
+			data.Attempt = attemptText(h.locale(r), err)
+			if failed := h.req.failedConstraint(response); !strings.Contains(err.Error(), failed) {
+				data.Failed = failed
+			}
+			h.renderPage(w, r, http.StatusBadRequest, data)
+			return
+		}
+		response = valid
+	} else if strings.TrimSpace(response) == "" && h.req.Default == "" && !h.req.AllowEmpty {
+		http.Error(w, i18n.T(h.locale(r), i18n.ResponseEmpty), http.StatusBadRequest)
+		return
+	}
+
+	if h.req.RequireConfirmation {
+		h.stage(w, r, response, shown)
+		return
+	}
+	if h.finish(response, nil) {
+		// Wait returns the answer once this submission is done, so the
+		// choice is in by then
+		if r.FormValue("remember") != "" {
+			h.req.Remember.Set()
+		}
+		h.renderThanks(w, r)
+	} else {
+		h.refuse(w, r)
+	}
+}
+
+// accepting reports whether the prompt still takes submissions, and turns
+// the submission away if not.
+func (h *WebInputHandler) accepting(w http.ResponseWriter, r *http.Request) bool {
+	h.mu.Lock()
+	state := h.state
+	h.mu.Unlock()
+	if state == webPending {
+		return true
+	}
+	h.refuse(w, r)
+	return false
+}
+
+// refuse turns away a submission to a prompt that ended, with 410 and a
+// page saying how it ended.
+func (h *WebInputHandler) refuse(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	state := h.state
+	h.mu.Unlock()
+	if state == webAnswered || state == webFailed {
+		h.renderCompleted(w, r, http.StatusGone)
+	} else {
+		h.renderExpired(w, r)
+	}
+}
+
+// giveUp ends the prompt after the user ran out of attempts.
+func (h *WebInputHandler) giveUp(w http.ResponseWriter, r *http.Request, err error) {
+	if h.finish("", err) {
+		http.Error(w, i18n.T(h.locale(r), i18n.PageFailed), http.StatusBadRequest)
+	} else {
+		h.refuse(w, r)
+	}
+}
+
+// attemptText describes which attempt the user is on after err, in locale,
+// or nothing when the prompt has no attempt limit.
+func attemptText(locale string, err error) string {
+	var rerr *RetryError
+	if !errors.As(err, &rerr) {
+		return ""
+	}
+	return fmt.Sprintf(i18n.T(locale, i18n.AttemptCount), rerr.Attempt, rerr.MaxAttempts)
+}
+
+// MessagePageData is passed to the submit.html template, the page saying
+// how a prompt went: thanks for an answer, or why the prompt no longer
+// takes one. It loads nothing, as the prompt's server may be gone by the
+// time it would, so the theme's logo isn't shown.
+type MessagePageData struct {
+	Lang  string
+	Title string
+
+	// Message is announced by screen readers as a status
+	Message string
+
+	// Back leads to the dashboard's index, for a prompt listed there
+	Back string
+
+	// Close asks the browser to close the tab, as after an answer
+	Close bool
+
+	// Theme is the operator's branding, nil for the built-in look
+	Theme *PageTheme
+}
+
+// renderMessage writes the message page data describes in locale, with
+// the handler's way back and theme.
+func (h *WebInputHandler) renderMessage(w http.ResponseWriter, status int, locale string, data MessagePageData) {
+	data.Lang = i18n.Normalize(locale)
+	data.Back = h.back
+	data.Theme = currentWebTheme().page(h.base)
+	renderTemplate(w, status, "submit.html", translate(locale), data)
+}
+
+// renderThanks confirms a submission in the page's language.
+func (h *WebInputHandler) renderThanks(w http.ResponseWriter, r *http.Request) {
+	locale := h.locale(r)
+	h.renderMessage(w, http.StatusOK, locale, MessagePageData{
+		Title:   i18n.T(locale, i18n.ThankYou),
+		Message: i18n.T(locale, i18n.Submitted),
+		Close:   true,
+	})
+}
+
+// renderCompleted tells the user the prompt was already answered, declined,
+// or given up on after too many invalid attempts, with status: 200 for the
+// page reloaded, 410 for a submission. The answer isn't shown.
+func (h *WebInputHandler) renderCompleted(w http.ResponseWriter, r *http.Request, status int) {
+	h.mu.Lock()
+	state, err := h.state, h.err
+	h.mu.Unlock()
+
+	locale := h.locale(r)
+	title, msg := i18n.PageCompleted, i18n.Submitted
+	if state == webFailed {
+		title, msg = i18n.PageClosed, failedMessage(err)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.renderMessage(w, status, locale, MessagePageData{
+		Title:   i18n.T(locale, title),
+		Message: i18n.T(locale, msg),
+	})
+}
+
+// renderExpired tells the user their submission came too late.
+func (h *WebInputHandler) renderExpired(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	reason := h.reason
+	h.mu.Unlock()
+
+	locale := h.locale(r)
+	title, msg := i18n.LateTimedOut, i18n.T(locale, i18n.LateTimedOutText)
+	switch {
+	case reason == i18n.PageCancelled:
+		title, msg = i18n.LateCancelled, i18n.T(locale, i18n.LateCancelledText)
+	case reason == i18n.PageWithdrawn:
+		title, msg = i18n.LateWithdrawn, i18n.T(locale, i18n.LateWithdrawnText)
+	case reason == i18n.PageAnsweredInTTY:
+		title, msg = i18n.LateAnsweredInTTY, i18n.T(locale, i18n.LateAnsweredInTTYText)
+	case reason == i18n.PageAnsweredElsewhere:
+		title, msg = i18n.LateAnsweredElsewhere, i18n.T(locale, i18n.LateAnsweredElsewhereText)
+	case h.req.TimeoutResponse != nil:
+		msg = fmt.Sprintf(i18n.T(locale, i18n.LateDefaultUsedText), *h.req.TimeoutResponse)
+	}
+	h.renderMessage(w, http.StatusGone, locale, MessagePageData{
+		Title:   i18n.T(locale, title),
+		Message: msg + " " + i18n.T(locale, i18n.CloseTab),
+	})
+}
+
+func (h *WebInputHandler) shutdown() {
+	if h.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		h.server.Shutdown(ctx)
+	}
+}
+
+func openBrowser(url string) error {
+	var cmd string
+	var args []string
+
+	switch runtime.GOOS {
+	case "windows":
+		cmd = "cmd"
+		args = []string{"/c", "start"}
+	case "darwin":
+		cmd = "open"
+	default: // "linux", "freebsd", "openbsd", "netbsd"
+		cmd = "xdg-open"
+	}
+	args = append(args, url)
+	return exec.Command(cmd, args...).Start()
+}
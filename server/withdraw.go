@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// requestContext returns the context the prompts of the call with id wait
+// under, which is cancelled with ErrWithdrawn when the client cancels the
+// call, and a function to call once the prompt has ended. Prompts of a call
+// already withdrawn, such as the rest of a batch, get a cancelled context.
+func (s *MCPServer) requestContext(id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	if id == nil {
+		return ctx, func() { cancel(nil) }
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.withdrawn[id] {
+		cancel(ErrWithdrawn)
+		return ctx, func() {}
+	}
+	if s.inflight == nil {
+		s.inflight = make(map[interface{}]context.CancelCauseFunc)
+	}
+	s.inflight[id] = cancel
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.inflight, id)
+		s.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// handleCancelled withdraws the call named by line when it is a
+// notifications/cancelled message, reporting whether it was one. Calls that
+// are not waiting for an answer, because they are done or not started yet,
+// are left alone.
+func (s *MCPServer) handleCancelled(line string) bool {
+	if !strings.Contains(line, "notifications/cancelled") {
+		return false
+	}
+	var msg struct {
+		Method string `json:"method"`
+		Params struct {
+			RequestID interface{} `json:"requestId"`
+			Reason    string      `json:"reason"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.Method != "notifications/cancelled" {
+		return false
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.inflight[msg.Params.RequestID]
+	if ok {
+		if s.withdrawn == nil {
+			s.withdrawn = make(map[interface{}]bool)
+		}
+		s.withdrawn[msg.Params.RequestID] = true
+	}
+	s.mu.Unlock()
+
+	if ok {
+		if msg.Params.Reason != "" {
+			s.logf("Request %v: cancelled by the client: %s", msg.Params.RequestID, msg.Params.Reason)
+		} else {
+			s.logf("Request %v: cancelled by the client", msg.Params.RequestID)
+		}
+		cancel(ErrWithdrawn)
+	}
+	return true
+}
+
+// takeWithdrawn reports whether the client cancelled the call with id, so
+// its result must not be sent, and forgets it.
+func (s *MCPServer) takeWithdrawn(id interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.withdrawn[id] {
+		return false
+	}
+	delete(s.withdrawn, id)
+	return true
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maxWizardSteps is the most steps one user_wizard call may have.
+const maxWizardSteps = 50
+
+// stepPhrase is the wizard step type asking the user to type a phrase.
+const stepPhrase = "phrase"
+
+// StepCondition makes a form field depend on an earlier one: the field is
+// only asked when the earlier field's answer equals Equals.
+type StepCondition struct {
+	Field  string
+	Equals interface{}
+}
+
+// met reports whether the condition holds for the answers so far. An
+// earlier field that was skipped or not answered never meets it.
+func (c *StepCondition) met(values map[string]interface{}) bool {
+	value, ok := values[c.Field]
+	return ok && value == c.Equals
+}
+
+// text is the condition's value as the user would type it, which the
+// browser compares against the raw answer.
+func (c *StepCondition) text() string {
+	switch equals := c.Equals.(type) {
+	case bool:
+		if equals {
+			return AnswerYes
+		}
+		return AnswerNo
+	case float64:
+		return strconv.FormatFloat(equals, 'f', -1, 64)
+	default:
+		return fmt.Sprint(equals)
+	}
+}
+
+// active reports whether field is asked given the answers to the fields
+// before it.
+func (f FormField) active(values map[string]interface{}) bool {
+	return f.When == nil || f.When.met(values)
+}
+
+// NewWizardPrompt returns a form prompt walking steps in order, skipping
+// those whose condition isn't met, and keeping track of the answers given
+// so far.
+func NewWizardPrompt(prompt, method string, steps []FormField) *PromptRequest {
+	return NewBatchPrompt(prompt, method, steps)
+}
+
+// parseWizardSteps reads the steps argument of user_wizard.
+func parseWizardSteps(args map[string]interface{}) ([]FormField, error) {
+	items, ok := args["steps"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("Invalid steps parameter: must be a non-empty array of steps")
+	}
+	if len(items) > maxWizardSteps {
+		return nil, fmt.Errorf("Invalid steps parameter: at most %d steps are allowed", maxWizardSteps)
+	}
+
+	steps := make([]FormField, len(items))
+	earlier := make(map[string]FormField, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid steps parameter: step %d is not an object", i)
+		}
+
+		id, _, err := optionalString(obj, "id")
+		if err == nil && id == "" {
+			err = fmt.Errorf("Invalid steps parameter: step %d has no id", i)
+		}
+		if _, dup := earlier[id]; err == nil && dup {
+			err = fmt.Errorf("Invalid steps parameter: duplicate id %q", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		step, err := parseWizardStep(id, obj, earlier)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid step %q: %v", id, err)
+		}
+		steps[i] = step
+		earlier[id] = step
+	}
+	return steps, nil
+}
+
+func parseWizardStep(id string, obj map[string]interface{}, earlier map[string]FormField) (FormField, error) {
+	var step FormField
+	var err error
+	if typ, _ := obj["type"].(string); typ == stepPhrase {
+		step, err = parsePhraseStep(id, obj)
+	} else {
+		step, err = parseBatchQuestion(id, obj)
+	}
+	if err != nil {
+		return step, err
+	}
+
+	when, present := obj["when"]
+	if !present {
+		return step, nil
+	}
+	cond, ok := when.(map[string]interface{})
+	if !ok {
+		return step, fmt.Errorf("when must be an object with step and equals")
+	}
+	ref, _, err := optionalString(cond, "step")
+	if err != nil {
+		return step, err
+	}
+	prior, ok := earlier[ref]
+	if !ok {
+		return step, fmt.Errorf("when.step must name an earlier step (got %q)", ref)
+	}
+	equals, present := cond["equals"]
+	if !present {
+		return step, fmt.Errorf("when.equals is required")
+	}
+	if err := checkConditionValue(prior, equals); err != nil {
+		return step, fmt.Errorf("when.equals: %v", err)
+	}
+	step.When = &StepCondition{Field: ref, Equals: equals}
+	return step, nil
+}
+
+// parsePhraseStep reads a step the user completes by typing a phrase.
+func parsePhraseStep(id string, obj map[string]interface{}) (FormField, error) {
+	step := FormField{Name: id, Type: FieldText}
+
+	prompt, _, err := optionalString(obj, "prompt")
+	if err == nil && strings.TrimSpace(prompt) == "" {
+		err = fmt.Errorf("prompt is required")
+	}
+	if err != nil {
+		return step, err
+	}
+	phrase, _, err := optionalString(obj, "phrase")
+	if err == nil && phrase == "" {
+		err = fmt.Errorf("phrase is required for phrase steps")
+	}
+	if err != nil {
+		return step, err
+	}
+
+	step.Label = fmt.Sprintf("%s (type %q)", prompt, phrase)
+	step.check = func(value interface{}) error {
+		if value != phrase {
+			return fmt.Errorf("Type %q to continue", phrase)
+		}
+		return nil
+	}
+	return step, nil
+}
+
+// checkConditionValue reports whether equals is an answer step could give.
+func checkConditionValue(step FormField, equals interface{}) error {
+	switch step.Type {
+	case FieldBoolean:
+		if _, ok := equals.(bool); !ok {
+			return fmt.Errorf("must be a boolean to match step %q", step.Name)
+		}
+	case FieldNumber:
+		if _, ok := equals.(float64); !ok {
+			return fmt.Errorf("must be a number to match step %q", step.Name)
+		}
+	default:
+		str, ok := equals.(string)
+		if !ok {
+			return fmt.Errorf("must be a string to match step %q", step.Name)
+		}
+		if step.Type == FieldSelect && !containsString(step.Options, str) {
+			return fmt.Errorf("must be one of the options of step %q", step.Name)
+		}
+	}
+	return nil
+}
+
+// skippedSteps lists, in order, the steps whose condition wasn't met.
+func skippedSteps(steps []FormField, values map[string]interface{}) []string {
+	skipped := []string{}
+	for _, step := range steps {
+		if !step.active(values) {
+			skipped = append(skipped, step.Name)
+		}
+	}
+	return skipped
+}
+
+func (s *MCPServer) handleUserWizardTool(req MCPRequest, args map[string]interface{}, progressToken interface{}) {
+	prompt, _, err := optionalString(args, "prompt")
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	if prompt == "" {
+		prompt = "Please go through the following steps"
+	}
+
+	steps, err := parseWizardSteps(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	timeout, err := optionalTimeout(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+	maxAttempts, err := optionalMaxAttempts(args)
+	if err != nil {
+		s.sendError(req.ID, -32602, err.Error())
+		return
+	}
+
+	promptReq := NewWizardPrompt(prompt, promptMethod(args), steps)
+	promptReq.ID = atomic.AddInt64(&s.promptSeq, 1)
+	promptReq.MaxAttempts = maxAttempts
+	if timeout != nil {
+		promptReq.Timeout = *timeout
+	}
+
+	answer, err := s.collectInput(req, promptReq, progressToken)
+	var terr *TimeoutError
+	if errors.As(err, &terr) {
+		answers := promptReq.Partial.Values()
+		s.sendResponse(req.ID, map[string]interface{}{
+			"content": []map[string]interface{}{
+				textContent(fmt.Sprintf("%s with %d steps answered", terr.Error(), len(answers))),
+			},
+			"structuredContent": map[string]interface{}{
+				"answers":  answers,
+				"timedOut": true,
+				"timeout":  terr.Timeout.Seconds(),
+			},
+			"isError": true,
+		})
+		return
+	}
+	if err != nil {
+		s.sendInputError(req.ID, err)
+		return
+	}
+
+	var answers map[string]interface{}
+	if err := json.Unmarshal([]byte(answer), &answers); err != nil {
+		s.sendError(req.ID, -32603, fmt.Sprintf("Failed to decode answers: %v", err))
+		return
+	}
+
+	s.sendResponse(req.ID, map[string]interface{}{
+		"content": []map[string]interface{}{
+			textContent(answer),
+		},
+		"structuredContent": map[string]interface{}{
+			"answers": answers,
+			"skipped": skippedSteps(steps, answers),
+		},
+		"isError": false,
+	})
+}
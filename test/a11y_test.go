@@ -0,0 +1,209 @@
+package test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+var (
+	a11yScripts = regexp.MustCompile(`(?s)<(script|style)\b.*?</(script|style)>`)
+	a11yTag     = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)\b([^>]*)>`)
+	a11yAttr    = regexp.MustCompile(`([a-zA-Z:-]+)(?:="([^"]*)")?`)
+)
+
+// a11yElement is a start tag with its attributes and whether it sits
+// inside a label.
+type a11yElement struct {
+	name    string
+	attrs   map[string]string
+	inLabel bool
+}
+
+// scanTags lists the start tags of page, leaving out scripts and styles.
+func scanTags(page string) []a11yElement {
+	var elements []a11yElement
+	labels := 0
+	for _, m := range a11yTag.FindAllStringSubmatch(a11yScripts.ReplaceAllString(page, ""), -1) {
+		name := strings.ToLower(m[2])
+		if m[1] == "/" {
+			if name == "label" && labels > 0 {
+				labels--
+			}
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range a11yAttr.FindAllStringSubmatch(m[3], -1) {
+			attrs[strings.ToLower(a[1])] = a[2]
+		}
+		elements = append(elements, a11yElement{name, attrs, labels > 0})
+		if name == "label" {
+			labels++
+		}
+	}
+	return elements
+}
+
+// checkAccessible fails the test for a control without a label and for
+// aria references to ids that aren't on the page.
+func checkAccessible(t *testing.T, name, page string) {
+	t.Helper()
+	elements := scanTags(page)
+	ids, labelled := map[string]bool{}, map[string]bool{}
+	for _, e := range elements {
+		if id, ok := e.attrs["id"]; ok {
+			ids[id] = true
+		}
+		if e.name == "label" && e.attrs["for"] != "" {
+			labelled[e.attrs["for"]] = true
+		}
+	}
+	for _, e := range elements {
+		for _, attr := range []string{"aria-describedby", "aria-labelledby", "for", "list"} {
+			if e.name == "label" && attr == "for" && e.attrs[attr] == "" {
+				continue
+			}
+			for _, ref := range strings.Fields(e.attrs[attr]) {
+				if !ids[ref] {
+					t.Errorf("%s: <%s %s=%q> refers to a missing id", name, e.name, attr, ref)
+				}
+			}
+		}
+		switch e.name {
+		case "input", "select", "textarea":
+		default:
+			continue
+		}
+		switch e.attrs["type"] {
+		case "hidden", "submit", "button":
+			continue
+		}
+		_, named := e.attrs["aria-label"]
+		if _, ok := e.attrs["aria-labelledby"]; ok {
+			named = true
+		}
+		if !named && !e.inLabel && !labelled[e.attrs["id"]] {
+			t.Errorf("%s: <%s name=%q> has no label", name, e.name, e.attrs["name"])
+		}
+	}
+}
+
+func TestAccessiblePrompts(t *testing.T) {
+	secret := server.NewPromptRequest("Token?", "web")
+	secret.Secret = true
+	secret.ConfirmSecret = true
+	multiline := server.NewPromptRequest("Describe the bug", "web")
+	multiline.Multiline = true
+	integer := server.NewPromptRequest("Replicas?", "web")
+	integer.Number = &server.NumberOptions{Integer: true}
+	form := server.NewFormPrompt("Release", "web", releaseForm())
+	list, err := server.NewListPrompt("Which hosts?", "web", server.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	browse, err := server.NewFilePrompt("Pick a file", "web", server.FileOptions{StartDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompts := map[string]*server.PromptRequest{
+		"text":        server.NewPromptRequest("Name?", "web"),
+		"secret":      secret,
+		"multiline":   multiline,
+		"number":      integer,
+		"duration":    durationPrompt("web", nil, nil, server.DurationOptions{DefaultUnit: "m"}),
+		"datetime":    server.NewDateTimePrompt("When?", "web", server.DateTimeOptions{Mode: server.ModeDateTime, Location: time.UTC}),
+		"choice":      server.NewOtherChoicePrompt("Pick a fruit", "web", []string{"apple", "pear"}, nil),
+		"multi":       server.NewMultiChoicePrompt("Pick fruits", "web", []string{"apple", "pear"}, 0, 0),
+		"form":        form,
+		"credentials": server.NewCredentialsPrompt("Sign in", "web", "registry.example.com"),
+		"review":      server.NewReviewPrompt("Review", "web", "package main\n"),
+		"plan":        server.NewPlanReviewPrompt("Plan", "web", "1. Build\n2. Ship\n"),
+		"rating":      server.NewRatingPrompt("Rate", "web", server.RatingOptions{Min: 0, Max: 100}),
+		"stars":       server.NewRatingPrompt("Rate", "web", server.RatingOptions{Min: 1, Max: 5}),
+		"phrase":      server.NewConfirmPrompt("Delete?", "web", ""),
+		"rank":        server.NewRankPrompt("Which database?", "web", rankOptions, server.RankOptions{AllowPartial: true}),
+		"list":        list,
+		"read":        server.NewReadPrompt("Read the license", "web", "Clause 1\n"),
+		"upload":      uploadPrompt(t, 1024),
+		"browse":      browse,
+		"ack":         server.NewAckPrompt("Deployed", "web"),
+	}
+	prompts["phrase"].Phrase = &server.ConfirmationPhrase{Phrase: "delete prod"}
+	for name, req := range prompts {
+		page := pageGet(server.NewWebInputHandler(req), "/").Body.String()
+		checkAccessible(t, name, page)
+		if !regexp.MustCompile(`(?s)<main>.*class="prompt".*</main>`).MatchString(page) {
+			t.Errorf("%s: expected the question inside <main>, got:\n%s", name, page)
+		}
+		if !strings.Contains(page, `id="errors" role="alert" aria-live="assertive"`) {
+			t.Errorf("%s: expected a live error region, got:\n%s", name, page)
+		}
+	}
+}
+
+func TestAccessibleErrors(t *testing.T) {
+	req := server.NewPromptRequest("Ticket?", "web")
+	req.Validate, _ = server.NewPatternValidator(req, `[A-Z]+-\d+`, "Use a ticket key")
+	handler := server.NewWebInputHandler(req)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"ops 12"}}))
+	body := rec.Body.String()
+	checkAccessible(t, "rejected", body)
+	if !strings.Contains(body, `aria-describedby="question errors" aria-invalid="true"`) || !strings.Contains(body, "Use a ticket key") {
+		t.Errorf("Expected the answer marked invalid and tied to the error, got:\n%s", body)
+	}
+
+	form := server.NewFormPrompt("Release", "web", []server.FormField{{Name: "build", Type: server.FieldNumber}})
+	handler = server.NewWebInputHandler(form)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.build": {"soon"}}))
+	body = rec.Body.String()
+	checkAccessible(t, "form", body)
+	if !strings.Contains(body, `aria-invalid="true"`) || !strings.Contains(body, `aria-describedby="field.build.error"`) {
+		t.Errorf("Expected the field marked invalid and tied to its error, got:\n%s", body)
+	}
+}
+
+func TestAccessibleCountdown(t *testing.T) {
+	req := server.NewPromptRequest("Name?", "web")
+	req.Timeout = time.Minute
+	page := pageGet(server.NewWebInputHandler(req), "/").Body.String()
+	if !strings.Contains(page, `role="timer" aria-live="off"`) || !strings.Contains(page, `id="countdown-announce" aria-live="polite"`) {
+		t.Errorf("Expected a timer with a polite announcer, got:\n%s", page)
+	}
+}
+
+func TestAccessibleReview(t *testing.T) {
+	req := server.NewPromptRequest("Drop which table?", "web")
+	req.RequireConfirmation = true
+	handler := server.NewWebInputHandler(req)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"users"}}))
+	body := rec.Body.String()
+	checkAccessible(t, "review", body)
+	if !strings.Contains(body, `<main><h1 tabindex="-1" autofocus>`) {
+		t.Errorf("Expected the focus on the review heading, got:\n%s", body)
+	}
+}
+
+func TestAccessibleDecline(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Which database should be dropped?", "web"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/decline", url.Values{"response": {""}}))
+	checkAccessible(t, "decline", rec.Body.String())
+	if !strings.Contains(rec.Body.String(), `<label for="reason">`) {
+		t.Errorf("Expected a visible label on the reason, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/decline", url.Values{"decline": {"yes"}}))
+	if !strings.Contains(rec.Body.String(), `<p role="status">`) {
+		t.Errorf("Expected the outcome announced, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func ackCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_ack","arguments":{"prompt":"Hit enter when the DB backup is done"` + args + `}}}`
+}
+
+func TestUserAckTool(t *testing.T) {
+	provider := &fakeProvider{response: "", delay: 50 * time.Millisecond}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, ackCall("")).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false {
+		t.Fatalf("Expected success, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["acknowledged"] != true {
+		t.Errorf("Expected acknowledged true, got %v", structured)
+	}
+	if elapsed, _ := structured["elapsedSeconds"].(float64); elapsed < 0.05 {
+		t.Errorf("Expected the wait to be measured, got %v", structured["elapsedSeconds"])
+	}
+	if _, ok := structured["timedOut"]; ok {
+		t.Errorf("Expected no timedOut for an answered prompt, got %v", structured)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string); !strings.HasPrefix(text, "Acknowledged after ") {
+		t.Errorf("Unexpected text %q", text)
+	}
+	if provider.lastReq.Kind != server.KindAck {
+		t.Errorf("Expected an ack prompt, got kind %q", provider.lastReq.Kind)
+	}
+}
+
+func TestUserAckTTY(t *testing.T) {
+	term := newFakeTerminal("\n")
+	req := server.NewAckPrompt("Backup done?", "tty")
+
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatalf("Expected Enter to acknowledge, got %v", err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "Press Enter to continue") {
+		t.Errorf("Expected the Enter hint, got %q", out)
+	}
+}
+
+func TestUserAckWeb(t *testing.T) {
+	req := server.NewAckPrompt("Backup done?", "web")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, ">Continue</button>") {
+		t.Errorf("Expected a Continue button, got:\n%s", body)
+	}
+	// The only input is the hidden form token
+	if strings.Count(body, "<input") != 1 || strings.Contains(body, "<textarea") {
+		t.Errorf("Expected no answer field, got:\n%s", body)
+	}
+
+	// The button submits nothing, which must not count as an empty answer
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the acknowledgement to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if _, err := handler.Wait(context.Background()); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
+
+func TestUserAckTimeout(t *testing.T) {
+	tests := []struct {
+		args    string
+		isError bool
+	}{
+		{`,"timeout":0.05`, true},
+		{`,"timeout":0.05,"acknowledge_on_timeout":true`, false},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{delay: time.Minute})
+
+		messages := parseMessages(t, runServer(t, srv, ackCall(tt.args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if result["isError"] != tt.isError {
+			t.Errorf("%s: expected isError %v, got %v", tt.args, tt.isError, result)
+		}
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["timedOut"] != true {
+			t.Errorf("%s: expected timedOut, got %v", tt.args, structured)
+		}
+		if !tt.isError && structured["acknowledged"] != true {
+			t.Errorf("%s: expected the timeout to acknowledge, got %v", tt.args, structured)
+		}
+	}
+}
+
+func TestUserAckInvalidArguments(t *testing.T) {
+	for _, args := range []string{`,"timeout":-1`, `,"acknowledge_on_timeout":"yes"`, `,"urgency":"extreme"`} {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, ackCall(args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestAllowEmptyTTY(t *testing.T) {
+	tests := []struct {
+		allowEmpty string
+		input      string
+		answer     string
+	}{
+		{"false", "\n   \nmain\n", "main"},
+		{"true", "\n", ""},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Branch?","allow_empty":` + tt.allowEmpty + `}}}`
+
+		term := newFakeTerminal(tt.input)
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", ttyProvider(term))
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if result["isError"] != false {
+			t.Errorf("allow_empty %s: expected a successful result, got %v", tt.allowEmpty, result)
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.answer {
+			t.Errorf("allow_empty %s: expected %q, got %q", tt.allowEmpty, tt.answer, text)
+		}
+
+		asked := strings.Count(term.output.String(), "Response cannot be empty")
+		if tt.allowEmpty == "false" && asked != 2 {
+			t.Errorf("Expected both blank answers to be re-asked, got output:\n%s", term.output.String())
+		}
+		if tt.allowEmpty == "true" && asked != 0 {
+			t.Errorf("Expected the empty answer to be accepted, got output:\n%s", term.output.String())
+		}
+	}
+}
+
+func TestAllowEmptyMultilineTTY(t *testing.T) {
+	term := newFakeTerminal(".\nfirst\nsecond\n.\n")
+	req := server.NewPromptRequest("Notes?", "tty")
+	req.Multiline = true
+	req.Trim = server.TrimNone
+	req.RequireAnswer()
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "first\nsecond" {
+		t.Errorf("Expected the second block, got %q", answer)
+	}
+	if !strings.Contains(term.output.String(), "Response cannot be empty") {
+		t.Errorf("Expected the empty block to be re-asked, got:\n%s", term.output.String())
+	}
+}
+
+func TestAllowEmptyWeb(t *testing.T) {
+	for _, allowEmpty := range []bool{false, true} {
+		req := server.NewPromptRequest("Branch?", "web")
+		req.AllowEmpty = allowEmpty
+		req.RequireAnswer()
+		handler := server.NewWebInputHandler(req)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if required := strings.Contains(rec.Body.String(), " required>"); required == allowEmpty {
+			t.Errorf("allow_empty %v: expected required attribute %v", allowEmpty, !allowEmpty)
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {""}}))
+		if !allowEmpty {
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Response cannot be empty") {
+				t.Errorf("Expected an empty submission to be rejected, got %d:\n%s", rec.Code, rec.Body.String())
+			}
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected an empty submission to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+		answer, err := handler.Wait(context.Background())
+		if err != nil || answer != "" {
+			t.Errorf("Expected an empty answer, got %q (%v)", answer, err)
+		}
+	}
+}
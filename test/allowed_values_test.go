@@ -0,0 +1,99 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const environmentCall = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Environment?","allowed_values":["staging","Production"]%s}}}`
+
+func environmentResult(t *testing.T, srv *server.MCPServer, extra string) map[string]interface{} {
+	t.Helper()
+	input := strings.Replace(environmentCall, "%s", extra, 1)
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	return findResponse(t, messages, 1)
+}
+
+func TestAllowedValuesCanonicalCasing(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"prod", "  PRODUCTION "}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	result := environmentResult(t, srv, "")["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "Production" {
+		t.Errorf("Expected the value as listed, got %v", text)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected a mismatch to ask again, got %d attempts", provider.attempts)
+	}
+}
+
+func TestAllowedValuesGivesUpWithList(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"dev"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	result := environmentResult(t, srv, `,"max_attempts":1`)["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("Expected an isError result, got %v", result)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "Response must be one of: staging, Production") {
+		t.Errorf("Expected the allowed values in the error, got %q", text)
+	}
+	if provider.attempts != 1 {
+		t.Errorf("Expected max_attempts to stop after 1 attempt, got %d", provider.attempts)
+	}
+}
+
+func TestAllowedValuesDefault(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: ""})
+
+	result := environmentResult(t, srv, `,"default":"STAGING"`)["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "staging" {
+		t.Errorf("Expected the default in its listed casing, got %v", text)
+	}
+}
+
+func TestAllowedValuesTTY(t *testing.T) {
+	term := newFakeTerminal("qa\nStaging\n")
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	result := environmentResult(t, srv, "")["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "staging" {
+		t.Errorf("Expected staging, got %v", text)
+	}
+	if out := term.output.String(); !strings.Contains(out, "Response must be one of: staging, Production") {
+		t.Errorf("Expected the terminal to show the allowed values, got %q", out)
+	}
+}
+
+func TestAllowedValuesInvalidArguments(t *testing.T) {
+	tests := []string{
+		`,"default":"dev"`,
+		`,"pattern":"\\w+"`,
+		`,"multiline":true`,
+		`,"type":"number"`,
+		`,"response_schema":{"type":"string"}`,
+	}
+	for _, extra := range tests {
+		resp := environmentResult(t, &server.MCPServer{}, extra)
+		errorObj, ok := resp["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", extra, resp)
+		}
+	}
+
+	for _, list := range []string{`[]`, `["a","A"]`, `["a"," "]`, `"a"`} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Hi","allowed_values":` + list + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || !strings.Contains(errorObj["message"].(string), "Invalid allowed_values parameter") {
+			t.Errorf("%s: expected an allowed_values error, got %v", list, errorObj)
+		}
+	}
+}
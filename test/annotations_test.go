@@ -0,0 +1,68 @@
+package test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestToolsListAnnotations(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	tools := findResponse(t, messages, 1)["result"].(map[string]interface{})["tools"].([]interface{})
+	for _, tool := range tools {
+		tool := tool.(map[string]interface{})
+		title, _ := tool["title"].(string)
+		if title == "" {
+			t.Errorf("%v: expected a title", tool["name"])
+		}
+
+		annotations, ok := tool["annotations"].(map[string]interface{})
+		if !ok {
+			t.Errorf("%v: expected annotations, got %v", tool["name"], tool["annotations"])
+			continue
+		}
+		// The exact field names the MCP spec defines
+		want := map[string]interface{}{
+			"title":           title,
+			"readOnlyHint":    true,
+			"destructiveHint": false,
+			"idempotentHint":  false,
+			"openWorldHint":   false,
+		}
+		if !reflect.DeepEqual(annotations, want) {
+			t.Errorf("%v: expected annotations %v, got %v", tool["name"], want, annotations)
+		}
+	}
+}
+
+func TestToolAnnotationsRoundTrip(t *testing.T) {
+	annotations := server.ToolAnnotations{Title: "Ask the User", ReadOnlyHint: true, OpenWorldHint: true}
+
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"title":           "Ask the User",
+		"readOnlyHint":    true,
+		"destructiveHint": false,
+		"idempotentHint":  false,
+		"openWorldHint":   true,
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected %v, got %v", want, fields)
+	}
+
+	var decoded server.ToolAnnotations
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != annotations {
+		t.Errorf("Expected %+v back, got %+v (%v)", annotations, decoded, err)
+	}
+}
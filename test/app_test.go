@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// appManifest fetches and decodes the dashboard's web app manifest.
+func appManifest(t *testing.T, d *server.Dashboard) server.WebAppManifest {
+	t.Helper()
+	rec := dashboardGet(d, d.Path()+"manifest.webmanifest")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/manifest+json" {
+		t.Fatalf("Expected the manifest, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var manifest server.WebAppManifest
+	if err := json.Unmarshal(rec.Body.Bytes(), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestWebAppManifest(t *testing.T) {
+	d := newDashboard(t)
+	manifest := appManifest(t, d)
+	if manifest.StartURL != d.Path() || manifest.Scope != "/" || manifest.Display != "standalone" || manifest.Name != "prompt-mcp" || manifest.ThemeColor != "#007cba" {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	// Installability wants PNG icons of 192 and 512 pixels, which are
+	// served without the token
+	sizes := map[string]bool{}
+	for _, icon := range manifest.Icons {
+		rec := dashboardGet(d, icon.Src)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("%s: expected a PNG, got %d %q", icon.Src, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		config, err := png.DecodeConfig(rec.Body)
+		if err != nil {
+			t.Fatalf("%s: %v", icon.Src, err)
+		}
+		if fmt.Sprintf("%dx%d", config.Width, config.Height) != icon.Sizes {
+			t.Errorf("%s: declared %s, is %dx%d", icon.Src, icon.Sizes, config.Width, config.Height)
+		}
+		sizes[icon.Sizes+" "+icon.Purpose] = true
+	}
+	for _, want := range []string{"192x192 any", "512x512 any", "512x512 maskable"} {
+		if !sizes[want] {
+			t.Errorf("Expected a %s icon, got %+v", want, manifest.Icons)
+		}
+	}
+
+	// The manifest names the index, so it is under the token
+	if rec := dashboardGet(d, "/manifest.webmanifest"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no manifest without the token, got %d", rec.Code)
+	}
+
+	index := dashboardGet(d, d.Path()).Body.String()
+	for _, want := range []string{`<link rel="manifest" href="` + d.Path() + `manifest.webmanifest"`, `name="viewport"`, `name="theme-color" content="#007cba"`, `rel="apple-touch-icon"`, `register("/sw.js")`, `id="disconnected" role="alert" hidden`} {
+		if !strings.Contains(index, want) {
+			t.Errorf("Expected %s on the index, got:\n%s", want, index)
+		}
+	}
+}
+
+func TestWebAppTheme(t *testing.T) {
+	if err := server.SetWebTheme(server.WebTheme{PageTitle: "Acme Approvals", AccentColor: "#1a7"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.SetWebTheme(server.WebTheme{}) })
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", BasePath: "/prompt-mcp"})
+	d := newDashboard(t)
+
+	manifest := appManifest(t, d)
+	if manifest.Name != "Acme Approvals" || manifest.ThemeColor != "#11aa77" || manifest.Scope != "/prompt-mcp/" || manifest.ID != "/prompt-mcp/" {
+		t.Errorf("Expected the theme and base path in the manifest, got %+v", manifest)
+	}
+	if rec := dashboardGet(d, "/prompt-mcp/sw.js"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the service worker under the base path, got %d", rec.Code)
+	}
+}
+
+func TestWebAppShell(t *testing.T) {
+	d := newDashboard(t)
+	go d.GetInput(context.Background(), server.NewPromptRequest("Deploy which branch?", "web"))
+	waitListed(t, d, 1)
+
+	rec := dashboardGet(d, "/sw.js")
+	worker := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/javascript") || !strings.Contains(worker, "'offline'") {
+		t.Errorf("Expected the service worker keeping the offline page, got %d:\n%s", rec.Code, worker)
+	}
+
+	// The offline page is the whole shell: it says what is wrong and
+	// knows nothing about the prompts
+	rec = dashboardGet(d, "/offline")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<h1>Disconnected</h1>") || strings.Contains(body, "Deploy") || strings.Contains(body, "/p/") {
+		t.Errorf("Expected an offline page without prompts, got %d:\n%s", rec.Code, body)
+	}
+
+	// Pages of prompts without a dashboard stay as they were
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Deploy which branch?", "web"))
+	if page := pageGet(handler, "/").Body.String(); strings.Contains(page, "manifest") || strings.Contains(page, "serviceWorker") {
+		t.Errorf("Expected one-off pages not to be an app, got:\n%s", page)
+	}
+}
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestAskWithoutSession(t *testing.T) {
+	prompt := server.NewPromptRequest("Name?", "tty")
+
+	response, err := server.Ask(context.Background(), &fakeProvider{response: "  Alice \n"}, prompt)
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if response != "Alice" {
+		t.Errorf("Expected the tty default trim to apply, got %q", response)
+	}
+}
+
+func TestAskTimeout(t *testing.T) {
+	prompt := server.NewPromptRequest("Deploy?", "tty")
+	prompt.Timeout = 50 * time.Millisecond
+
+	_, err := server.Ask(context.Background(), &fakeProvider{response: "yes", delay: time.Second}, prompt)
+	if !errors.Is(err, server.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestAskDeclined(t *testing.T) {
+	// The ask command exits 2 with the reason when the user declines
+	term := newFakeTerminal("\x1b Ask the DBA\n")
+	_, err := server.Ask(context.Background(), ttyProvider(term), server.NewPromptRequest("Drop the table?", "tty"))
+	var declined *server.DeclinedError
+	if !errors.As(err, &declined) || declined.Reason != "Ask the DBA" || errors.Is(err, server.ErrTimeout) {
+		t.Errorf("Expected a decline with its reason, got %v", err)
+	}
+}
+
+func TestNewPromptRequestDefaults(t *testing.T) {
+	web := server.NewPromptRequest("Deploy?", "web")
+	if web.Method != "web" || web.Timeout != 5*time.Minute || web.Trim != server.TrimNone {
+		t.Errorf("Unexpected web defaults: %+v", web)
+	}
+
+	tty := server.NewPromptRequest("Deploy?", "bogus")
+	if tty.Method != "tty" || tty.Timeout != 0 || tty.Trim != server.TrimBoth {
+		t.Errorf("Expected unknown methods to fall back to tty defaults, got %+v", tty)
+	}
+
+	if auto := server.ResolveMethod("auto"); auto != "tty" && auto != "web" {
+		t.Errorf("Expected auto to resolve to tty or web, got %q", auto)
+	}
+}
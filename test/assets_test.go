@@ -0,0 +1,173 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// webAsset returns a static file of the web pages.
+func webAsset(t *testing.T, name string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(server.NewPromptRequest("Name?", "web")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/"+name, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected %s to be served, got %d", name, rec.Code)
+	}
+	return rec.Body.String()
+}
+
+var assetLink = regexp.MustCompile(`(?:href|src)="([^"]*/static/[^"]*)"`)
+
+func TestWebAssetsResolve(t *testing.T) {
+	req := server.NewPromptRequest("Deploy <b>now</b>?", "web")
+	handler := server.NewWebInputHandler(req)
+	page, err := handler.Protect("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, page, nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<div class="prompt">Deploy &lt;b&gt;now&lt;/b&gt;?</div>`) {
+		t.Errorf("Expected the prompt on the page, got:\n%s", body)
+	}
+
+	types := map[string]string{".css": "text/css", ".js": "text/javascript"}
+	links := assetLink.FindAllStringSubmatch(body, -1)
+	if len(links) != 2 {
+		t.Fatalf("Expected the stylesheet and script linked, got %v", links)
+	}
+	for _, link := range links {
+		if !strings.HasPrefix(link[1], page) {
+			t.Errorf("Expected %s under the page's token", link[1])
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, link[1], nil))
+		ext := link[1][strings.LastIndex(link[1], "."):]
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 || !strings.HasPrefix(rec.Header().Get("Content-Type"), types[ext]) || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("%s: expected a %s file, got %d %v", link[1], types[ext], rec.Code, rec.Header())
+		}
+	}
+
+	// Nothing but the files is served, and nothing without the token
+	for _, path := range []string{page + "static/", page + "static/missing.js", "/static/input.js"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestWebAssetsOffline(t *testing.T) {
+	for _, name := range []string{"input.css", "input.js"} {
+		if asset := webAsset(t, name); strings.Contains(asset, "http://") || strings.Contains(asset, "https://") || strings.Contains(asset, "@import") {
+			t.Errorf("Expected %s to load nothing from elsewhere", name)
+		}
+	}
+}
+
+// templateDir writes files, keyed by path, to a new custom template
+// directory and serves pages from it for the rest of the test.
+func templateDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { server.LoadWebAssets("") })
+	return dir
+}
+
+func TestWebTemplateDirOverrides(t *testing.T) {
+	dir := templateDir(t, map[string]string{
+		"templates/input.html": `<html><body><h1 class="brand">{{.Title}}</h1><p>{{.Prompt}}</p>` +
+			`<form method="POST" action="{{.Base}}/submit"><input type="hidden" name="csrf" value="{{.CSRF}}"><input name="response"></form></body></html>`,
+		"static/logo.svg": `<svg xmlns="http://www.w3.org/2000/svg"></svg>`,
+	})
+	if err := server.LoadWebAssets(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	req := server.NewPromptRequest("Deploy <b>now</b>?", "web")
+	req.Title = "Acme"
+	handler := server.NewWebInputHandler(req)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<h1 class="brand">Acme</h1><p>Deploy &lt;b&gt;now&lt;/b&gt;?</p>`) {
+		t.Errorf("Expected the custom input page, got:\n%s", body)
+	}
+
+	// The custom form still submits
+	csrf := regexp.MustCompile(`name="csrf" value="([^"]+)"`).FindStringSubmatch(rec.Body.String())
+	if csrf == nil {
+		t.Fatal("Expected the CSRF token on the custom page")
+	}
+	rec = httptest.NewRecorder()
+	post := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader("csrf="+csrf[1]+"&response=yes"))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(rec, post)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the answer accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// The page it leads to is still the built-in submit.html
+	if body := rec.Body.String(); !strings.Contains(body, "<h1>Thank you!</h1>") || !strings.Contains(body, `<p role="status">`) {
+		t.Errorf("Expected the built-in thank-you page, got:\n%s", body)
+	}
+
+	// Everything else comes from the built-in set
+	d := newDashboard(t)
+	if body := dashboardGet(d, d.Path()).Body.String(); !strings.Contains(body, "Nothing is waiting for an answer.") {
+		t.Errorf("Expected the built-in dashboard, got:\n%s", body)
+	}
+	if css := webAsset(t, "input.css"); !strings.Contains(css, ".prompt {") {
+		t.Errorf("Expected the built-in stylesheet, got:\n%s", css)
+	}
+	if logo := webAsset(t, "logo.svg"); !strings.Contains(logo, "<svg") {
+		t.Errorf("Expected the custom logo, got:\n%s", logo)
+	}
+}
+
+func TestWebTemplateDirErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{"syntax", map[string]string{"templates/input.html": "<html>\n<body>\n{{end}}\n</body>"}, "input.html:3:1: unexpected {{end}}"},
+		{"column", map[string]string{"templates/submit.html": "<html>\n  <h1>{{.Title}}</h1> <p>{{if}}</p>\n</html>"}, "submit.html:2:26: missing value for if"},
+		{"field", map[string]string{"templates/dashboard.html": "<html>\n  <p>{{.Logo}}</p>\n</html>"}, "dashboard.html:2:7: executing"},
+		{"function", map[string]string{"templates/notify.html": "<p>{{shout .Prompt}}</p>"}, `notify.html:1:4: function "shout" not defined`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := server.LoadWebAssets(templateDir(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("Expected an error containing %q, got %v", tt.expected, err)
+			}
+
+			// The pages in use are kept
+			if css := webAsset(t, "input.css"); !strings.Contains(css, ".prompt {") {
+				t.Errorf("Expected the built-in stylesheet kept")
+			}
+		})
+	}
+
+	if err := server.LoadWebAssets(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
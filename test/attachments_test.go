@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestAttachmentsPassedToPrompt(t *testing.T) {
+	for _, tool := range []string{"user_input", "user_confirm"} {
+		provider := &fakeProvider{response: "yes"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":{"prompt":"Apply this config?","method":"tty","attachments":[{"name":"app.yaml","content":"port: 8080\n"},{"name":"notes","content":"hi","language":"Markdown"}]}}}`
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		if result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{}); !ok || result["isError"] != false {
+			t.Fatalf("%s: expected a successful result, got %v", tool, findResponse(t, messages, 1))
+		}
+
+		attachments := provider.lastReq.Attachments
+		if len(attachments) != 2 {
+			t.Fatalf("%s: expected 2 attachments, got %v", tool, attachments)
+		}
+		if attachments[0].Language != "yaml" || attachments[1].Language != "markdown" {
+			t.Errorf("%s: expected the language to be guessed or lowercased, got %q and %q", tool, attachments[0].Language, attachments[1].Language)
+		}
+	}
+}
+
+func TestAttachmentsInvalid(t *testing.T) {
+	big := strings.Repeat("a", 256*1024+1)
+	quarter := strings.Repeat("a", 250*1024)
+	var many, tooMuch []string
+	for i := 0; i < 21; i++ {
+		many = append(many, `{"name":"f","content":""}`)
+	}
+	for i := 0; i < 5; i++ {
+		tooMuch = append(tooMuch, `{"name":"f","content":"`+quarter+`"}`)
+	}
+
+	for _, attachments := range []string{
+		`"notes.txt"`,
+		`["notes.txt"]`,
+		`[{"content":"x"}]`,
+		`[{"name":"  ","content":"x"}]`,
+		`[{"name":"a\nb","content":"x"}]`,
+		`[{"name":"a"}]`,
+		`[{"name":"a","content":5}]`,
+		`[{"name":"a","content":"x","language":1}]`,
+		`[{"name":"a.bin","content":"PK\u0003\u0004\u0000\u0000"}]`,
+		`[{"name":"a","content":"` + big + `"}]`,
+		`[` + strings.Join(tooMuch, ",") + `]`,
+		`[` + strings.Join(many, ",") + `]`,
+	} {
+		for _, tool := range []string{"user_input", "user_confirm"} {
+			input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":{"prompt":"Q","attachments":` + attachments + `}}}`
+			messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+			errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+			if !ok || errorObj["code"] != float64(-32602) {
+				if len(attachments) > 80 {
+					attachments = attachments[:80] + "..."
+				}
+				t.Errorf("%s %s: expected -32602, got %v", tool, attachments, errorObj)
+			}
+		}
+	}
+}
+
+func TestAttachmentsTTY(t *testing.T) {
+	term := newFakeTerminal("3\n2\n\nyes\n")
+	req := server.NewConfirmPrompt("Deploy?", "tty", "")
+	req.Attachments = []server.Attachment{
+		{Name: "plan.txt", Content: "step one\nstep two\n"},
+		{Name: "diff.patch", Content: "+added line\n"},
+	}
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "yes" {
+		t.Errorf("Expected yes, got %q", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{
+		"Attachments:\n  1) plan.txt (2 lines)\n  2) diff.patch (1 line)\n",
+		"Please enter a number from 1 to 2",
+		"--- diff.patch ---\n+added line\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "step one") {
+		t.Errorf("Expected only the picked attachment to be shown, got:\n%s", output)
+	}
+}
+
+func TestAttachmentsWeb(t *testing.T) {
+	req := server.NewPromptRequest("Merge this?", "web")
+	req.Attachments = []server.Attachment{
+		{Name: "main.go", Content: "// entry\nfunc main() { fmt.Println(\"<hi>\", 42) }\n", Language: "go"},
+		{Name: "<notes>", Content: "plain & simple"},
+	}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`role="tab" id="attachment-tab-0" aria-controls="attachment-0" aria-selected="true"`,
+		`aria-selected="false" title="1 line">&lt;notes&gt;</button>`,
+		`<code class="language-go"><span class="hl-c">// entry</span>`,
+		`<span class="hl-k">func</span> main()`,
+		`<span class="hl-s">&#34;&lt;hi&gt;&#34;</span>, <span class="hl-n">42</span>`,
+		`id="attachment-1" aria-labelledby="attachment-tab-1" hidden><code>plain &amp; simple</code>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, `class="attachments"`) > strings.Index(body, `class="prompt"`) {
+		t.Error("Expected the attachments above the question")
+	}
+}
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"prompt-mcp/server"
+)
+
+// authGet fetches path from h with the given credentials, or none when user
+// is empty.
+func authGet(h http.Handler, path, user, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func useWebAuth(t *testing.T, credentials string) {
+	t.Helper()
+	l, err := server.Config{WebAuth: credentials}.WebListener()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useWebListener(t, l)
+}
+
+func TestWebAuthDashboard(t *testing.T) {
+	useWebAuth(t, "ada:open sesame")
+	d := newDashboard(t)
+	d.SetHealth(server.NewHealthHandler(server.NewMCPServer()))
+
+	answers := make(chan string, 1)
+	go func() {
+		response, _ := d.GetInput(context.Background(), server.NewPromptRequest("Branch?", "web"))
+		answers <- response
+	}()
+	var page string
+	for page == "" {
+		if m := promptLinks.FindStringSubmatch(authGet(d, d.Path(), "ada", "open sesame").Body.String()); m != nil {
+			page = m[1]
+		}
+	}
+
+	for _, path := range []string{d.Path(), d.Path() + "events", d.Path() + "history", page, page + "events", page + "static/input.css"} {
+		for _, creds := range [][2]string{{"", ""}, {"ada", "open"}, {"bob", "open sesame"}, {"ada", "open sesame "}} {
+			rec := authGet(d, path, creds[0], creds[1])
+			if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != `Basic realm="prompt-mcp", charset="UTF-8"` {
+				t.Errorf("%s as %q: expected a challenge, got %d %q", path, creds, rec.Code, rec.Header().Get("WWW-Authenticate"))
+			}
+		}
+	}
+	for _, path := range []string{"/healthz", "/readyz"} {
+		if rec := authGet(d, path, "", ""); rec.Code == http.StatusUnauthorized || rec.Code == http.StatusNotFound {
+			t.Errorf("%s: expected the probe open, got %d", path, rec.Code)
+		}
+	}
+
+	// A submission needs the credentials as well as the form token
+	token := csrfInput.FindStringSubmatch(authGet(d, page, "ada", "open sesame").Body.String())[1]
+	post := func(user, password string) int {
+		req := httptest.NewRequest(http.MethodPost, page+"submit", strings.NewReader(url.Values{"response": {"main"}, "csrf": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(user, password)
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post("ada", "sesame"); code != http.StatusUnauthorized {
+		t.Errorf("Expected a submission without the password refused, got %d", code)
+	}
+	if code := post("ada", "open sesame"); code != http.StatusOK {
+		t.Errorf("Expected the submission accepted, got %d", code)
+	}
+	if answer := <-answers; answer != "main" {
+		t.Errorf("Expected main, got %q", answer)
+	}
+}
+
+func TestWebAuthHashed(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	useWebAuth(t, "ada:"+string(hash))
+	d := newDashboard(t)
+
+	// The hash itself is no password
+	for _, password := range []string{string(hash), "correct", "correct horse", "correct horse"} {
+		want := http.StatusUnauthorized
+		if password == "correct horse" {
+			want = http.StatusOK
+		}
+		if rec := authGet(d, d.Path(), "ada", password); rec.Code != want {
+			t.Errorf("%q: expected %d, got %d", password, want, rec.Code)
+		}
+	}
+	if rec := authGet(d, d.Path(), "ada", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong password refused after a right one, got %d", rec.Code)
+	}
+}
+
+func TestWebAuthConfig(t *testing.T) {
+	for _, creds := range []string{"ada", ":secret", "ada:", "ada:$2a$10$notahash"} {
+		if err := (server.Config{WebAuth: creds}).Validate(); err == nil {
+			t.Errorf("%q: expected invalid credentials", creds)
+		}
+	}
+	// Passwords may hold colons; user names can't
+	a, err := server.ParseBasicAuth("ada:pass:word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("ada", "pass:word")
+	if !a.Allows(req) {
+		t.Error("Expected a password with a colon to be accepted")
+	}
+	if !(*server.BasicAuth)(nil).Allows(httptest.NewRequest(http.MethodGet, "/", nil)) {
+		t.Error("Expected no credentials to let everyone through")
+	}
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func base64Call(t *testing.T, arguments string, response string) map[string]interface{} {
+	t.Helper()
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":` + arguments + `}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", &fakeProvider{response: response})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	return findResponse(t, messages, 1)["result"].(map[string]interface{})
+}
+
+func TestBinaryAnswerRoundTrip(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/pixel.png")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	// No encoding argument: the PNG is not valid UTF-8 so base64 is chosen
+	result := base64Call(t, `{"prompt":"Upload the image","method":"web"}`, string(fixture))
+
+	structured, ok := result["structuredContent"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected structured content, got %v", result)
+	}
+	if structured["encoding"] != "base64" {
+		t.Errorf("Expected encoding base64, got %v", structured["encoding"])
+	}
+	if structured["mimeType"] != "image/png" {
+		t.Errorf("Expected mime type image/png, got %v", structured["mimeType"])
+	}
+	if structured["size"] != float64(len(fixture)) {
+		t.Errorf("Expected size %d, got %v", len(fixture), structured["size"])
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(structured["data"].(string))
+	if err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if !bytes.Equal(decoded, fixture) {
+		t.Errorf("Decoded payload does not match the fixture")
+	}
+
+	sum := sha256.Sum256(fixture)
+	if structured["sha256"] != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected sha256 %x, got %v", sum, structured["sha256"])
+	}
+
+	content := result["content"].([]interface{})
+	text := content[0].(map[string]interface{})["text"].(string)
+	if bytes.Contains([]byte(text), fixture[:4]) {
+		t.Errorf("Expected the text block to describe the attachment, not contain it")
+	}
+}
+
+func TestExplicitBase64Encoding(t *testing.T) {
+	result := base64Call(t, `{"prompt":"Config?","method":"web","encoding":"base64"}`, "key=value\n")
+
+	structured := result["structuredContent"].(map[string]interface{})
+	decoded, _ := base64.StdEncoding.DecodeString(structured["data"].(string))
+	if string(decoded) != "key=value\n" {
+		t.Errorf("Expected key=value, got %q", decoded)
+	}
+	if structured["mimeType"] != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text/plain mime type, got %v", structured["mimeType"])
+	}
+}
+
+func TestTextAnswerNotEncoded(t *testing.T) {
+	result := base64Call(t, `{"prompt":"Name?","method":"web"}`, "héllo")
+
+	if structured := result["structuredContent"]; !reflect.DeepEqual(structured, map[string]interface{}{"action": "submitted"}) {
+		t.Errorf("Expected valid UTF-8 answers to stay text, got %v", structured)
+	}
+	content := result["content"].([]interface{})
+	if content[0].(map[string]interface{})["text"] != "héllo" {
+		t.Errorf("Expected héllo, got %v", content[0])
+	}
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// pageURLs matches the URLs a page links, loads or posts to.
+var pageURLs = regexp.MustCompile(`(?:href|src|action|data-base)="([^"]*)"|EventSource\("([^"]*)"\)`)
+
+// basePathLinks matches the links to prompt pages under /prompt-mcp.
+var basePathLinks = regexp.MustCompile(`href="(/prompt-mcp/p/\d+/[\w-]+/)"`)
+
+// unprefixedURLs returns the URLs in body that don't start with base.
+func unprefixedURLs(body, base string) []string {
+	var bad []string
+	for _, m := range pageURLs.FindAllStringSubmatch(body, -1) {
+		u := m[1] + m[2]
+		if !strings.HasPrefix(u, base+"/") && !strings.HasPrefix(u, "data:") {
+			bad = append(bad, u)
+		}
+	}
+	return bad
+}
+
+func TestWebBasePathConfig(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                "",
+		"/":               "",
+		"/prompt-mcp":     "/prompt-mcp",
+		"prompt-mcp/":     "/prompt-mcp",
+		" /tools/prompt/": "/tools/prompt",
+	} {
+		if got, err := server.ParseBasePath(in); err != nil || got != want {
+			t.Errorf("%q: expected %q, got %q (%v)", in, want, got, err)
+		}
+	}
+	for _, in := range []string{"/a//b", "/../etc", "/a/./b", "/prompt mcp", "/p?x=1", "/p#top"} {
+		if _, err := server.ParseBasePath(in); err == nil {
+			t.Errorf("%q: expected an invalid base path", in)
+		}
+		if err := (server.Config{WebBasePath: in}).Validate(); err == nil {
+			t.Errorf("%q: expected an invalid config", in)
+		}
+	}
+	if l, err := (server.Config{WebBasePath: "/prompt-mcp/"}).WebListener(); err != nil || l.BasePath != "/prompt-mcp" {
+		t.Errorf("Expected the base path on the listener, got %+v (%v)", l, err)
+	}
+}
+
+func TestWebBasePathDashboard(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", BasePath: "/prompt-mcp"})
+	d := newDashboard(t)
+	if !strings.HasPrefix(d.Path(), "/prompt-mcp/") {
+		t.Fatalf("Expected the index under the base path, got %s", d.Path())
+	}
+
+	answers := make(chan string, 1)
+	go func() {
+		req := server.NewConfirmPrompt("Deploy?", "web", "")
+		req.Images = []server.Image{{Data: []byte("GIF89a"), MimeType: "image/gif"}}
+		response, _ := d.GetInput(context.Background(), req)
+		answers <- response
+	}()
+
+	var index, page string
+	for index == "" {
+		body := dashboardGet(d, d.Path()).Body.String()
+		if basePathLinks.MatchString(body) {
+			index = body
+		}
+	}
+	if bad := unprefixedURLs(index, "/prompt-mcp"); len(bad) > 0 {
+		t.Errorf("Expected only URLs under the base path on the index, got %q in:\n%s", bad, index)
+	}
+	page = basePathLinks.FindStringSubmatch(index)[1]
+	body := dashboardGet(d, page).Body.String()
+	if !strings.Contains(body, `action="`+page+`submit"`) || !strings.Contains(body, page+"static/input.css") {
+		t.Errorf("Expected the form and stylesheet under the base path, got:\n%s", body)
+	}
+	if bad := unprefixedURLs(body, "/prompt-mcp"); len(bad) > 0 {
+		t.Errorf("Expected only URLs under the base path on the page, got %q in:\n%s", bad, body)
+	}
+	if rec := dashboardGet(d, page+"static/input.css"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the stylesheet under the base path, got %d", rec.Code)
+	}
+
+	unprefixed := strings.TrimPrefix(page, "/prompt-mcp")
+	for _, path := range []string{"/", strings.TrimPrefix(d.Path(), "/prompt-mcp"), unprefixed, unprefixed + "static/input.css", "/prompt-mcp/", "/prompt-mcpx" + unprefixed} {
+		if rec := dashboardGet(d, path); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, postForm(t, d, page+"submit", url.Values{"response": {"yes"}}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<a href="`+d.Path()+`" target="_top">`) {
+		t.Errorf("Expected the answer accepted with a way back under the base path, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer := <-answers; answer != "yes" {
+		t.Errorf("Expected yes, got %q", answer)
+	}
+}
+
+func TestWebBasePathServed(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", BasePath: "/prompt-mcp"})
+	d := newDashboard(t)
+	index, err := d.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	if !strings.HasPrefix(index, "http://127.0.0.1:") || !strings.HasSuffix(index, d.Path()) {
+		t.Fatalf("Expected the printed URL under the base path, got %s", index)
+	}
+
+	answers := make(chan string, 1)
+	go func() {
+		req := server.NewPromptRequest("Branch?", "web")
+		req.Alert = &server.Alert{}
+		response, _ := d.GetInput(context.Background(), req)
+		answers <- response
+	}()
+
+	get := func(u string) (int, string) {
+		resp, err := http.Get(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	var links []string
+	for links == nil {
+		_, body := get(index)
+		if m := basePathLinks.FindStringSubmatch(body); m != nil {
+			links = m[1:]
+		}
+	}
+	origin := strings.TrimSuffix(index, d.Path())
+	status, body := get(origin + links[0])
+	if status != http.StatusOK {
+		t.Fatalf("Expected the page, got %d", status)
+	}
+	if status, _ := get(origin + strings.TrimPrefix(links[0], "/prompt-mcp")); status != http.StatusNotFound {
+		t.Errorf("Expected 404 without the base path, got %d", status)
+	}
+
+	form := url.Values{"response": {"main"}, "csrf": {csrfInput.FindStringSubmatch(body)[1]}}
+	resp, err := http.PostForm(origin+links[0]+"submit", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the answer accepted, got %d", resp.StatusCode)
+	}
+	if answer := <-answers; answer != "main" {
+		t.Errorf("Expected main, got %q", answer)
+	}
+}
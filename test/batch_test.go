@@ -0,0 +1,158 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+var batchQuestions = []interface{}{
+	map[string]interface{}{"id": "env", "prompt": "Which environment?", "type": "choice", "options": []string{"staging", "prod"}},
+	map[string]interface{}{"id": "replicas", "prompt": "How many replicas?", "type": "number"},
+	map[string]interface{}{"id": "notify", "prompt": "Notify the team?", "type": "boolean"},
+	map[string]interface{}{"id": "note", "prompt": "Release note"},
+}
+
+func batchCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_input_batch",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserInputBatchAnswersInOrder(t *testing.T) {
+	provider := &fakeProvider{response: `{"note":"Fixes login","notify":"y","replicas":"3","env":"2"}`}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, batchCall(t, map[string]interface{}{"questions": batchQuestions})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	want := []interface{}{
+		map[string]interface{}{"id": "env", "response": "prod"},
+		map[string]interface{}{"id": "replicas", "response": float64(3)},
+		map[string]interface{}{"id": "notify", "response": true},
+		map[string]interface{}{"id": "note", "response": "Fixes login"},
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if !reflect.DeepEqual(structured["answers"], want) || len(structured["missing"].([]interface{})) != 0 {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+
+	var text []interface{}
+	if err := json.Unmarshal([]byte(result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)), &text); err != nil || !reflect.DeepEqual(text, want) {
+		t.Errorf("Expected the answers as a JSON array, got %v (%v)", text, err)
+	}
+	if provider.lastReq.Kind != server.KindForm || len(provider.lastReq.Fields) != 4 || provider.lastReq.Fields[0].Label != "Which environment?" {
+		t.Errorf("Expected one form with every question, got %+v", provider.lastReq)
+	}
+}
+
+func TestUserInputBatchInvalidQuestions(t *testing.T) {
+	tests := map[string]interface{}{
+		"missing":          nil,
+		"empty":            []interface{}{},
+		"not an object":    []interface{}{"Which?"},
+		"no id":            []interface{}{map[string]interface{}{"prompt": "Which?"}},
+		"no prompt":        []interface{}{map[string]interface{}{"id": "a"}},
+		"duplicate id":     []interface{}{map[string]interface{}{"id": "a", "prompt": "A"}, map[string]interface{}{"id": "a", "prompt": "B"}},
+		"unknown type":     []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "type": "date"}},
+		"choice no option": []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "type": "choice"}},
+		"text options":     []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "options": []string{"x"}}},
+	}
+
+	for name, questions := range tests {
+		args := map[string]interface{}{}
+		if questions != nil {
+			args["questions"] = questions
+		}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "{}"})
+
+		messages := parseMessages(t, runServer(t, srv, batchCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected an invalid params error, got %v", name, errObj)
+		}
+	}
+}
+
+func TestUserInputBatchPartialTimeoutTTY(t *testing.T) {
+	// Two questions are answered, then nobody types
+	term := &fakeTerminal{input: io.MultiReader(strings.NewReader("prod\n4\n"), blockingReader{})}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	messages := parseMessages(t, runServer(t, srv, batchCall(t, map[string]interface{}{"questions": batchQuestions, "timeout": 0.2})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != true {
+		t.Fatalf("Expected a timeout result, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	want := []interface{}{
+		map[string]interface{}{"id": "env", "response": "prod"},
+		map[string]interface{}{"id": "replicas", "response": float64(4)},
+	}
+	if !reflect.DeepEqual(structured["answers"], want) || !reflect.DeepEqual(structured["missing"], []interface{}{"notify", "note"}) || structured["timedOut"] != true {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "2 of 4 questions answered; missing: notify, note") {
+		t.Errorf("Unexpected text %q", text)
+	}
+}
+
+func TestUserInputBatchWebDrafts(t *testing.T) {
+	questions := []server.FormField{
+		{Name: "env", Label: "Which environment?", Type: server.FieldSelect, Options: []string{"staging", "prod"}},
+		{Name: "replicas", Label: "How many replicas?", Type: server.FieldNumber},
+	}
+	prompt := server.NewBatchPrompt("Deploy settings", "web", questions)
+	handler := server.NewWebInputHandler(prompt)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "data-drafts") || !strings.Contains(body, "Which environment?") || !strings.Contains(body, "How many replicas?") {
+		t.Errorf("Expected every question on one page with drafts enabled, got %s", body)
+	}
+
+	// Only valid answers are kept; a cleared answer is forgotten
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/draft", url.Values{"field.env": {"prod"}, "field.replicas": {"many"}}))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected the draft to be accepted, got %d", rec.Code)
+	}
+	if values := prompt.Partial.Values(); !reflect.DeepEqual(values, map[string]interface{}{"env": "prod"}) {
+		t.Errorf("Unexpected draft values %v", values)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), postForm(t, handler, "/draft", url.Values{"field.env": {""}, "field.replicas": {"2"}}))
+	if values := prompt.Partial.Values(); !reflect.DeepEqual(values, map[string]interface{}{"replicas": float64(2)}) {
+		t.Errorf("Unexpected draft values %v", values)
+	}
+
+	// Plain forms don't take drafts
+	plain := server.NewWebInputHandler(server.NewFormPrompt("Q", "web", questions))
+	rec = httptest.NewRecorder()
+	plain.ServeHTTP(rec, postForm(t, plain, "/draft", url.Values{"field.env": {"prod"}}))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected drafts to be refused for a plain form, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// drain returns the IDs of the events queued for sub, without waiting.
+func drain(sub *server.DashboardSubscriber) []int64 {
+	var ids []int64
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, ev.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestDashboardHubSubscribers(t *testing.T) {
+	hub := server.NewDashboardHub()
+	first, _ := hub.Subscribe(0)
+	second, _ := hub.Subscribe(0)
+	hub.Publish(server.DashboardEvent{Type: server.EventPromptCreated, Pending: 1})
+	hub.Publish(server.DashboardEvent{Type: server.EventPromptResolved})
+
+	for i, sub := range []*server.DashboardSubscriber{first, second} {
+		if ids := drain(sub); len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("Subscriber %d: expected events 1 and 2, got %v", i, ids)
+		}
+	}
+
+	// Gone subscribers get nothing more
+	hub.Unsubscribe(first)
+	hub.Publish(server.DashboardEvent{Type: server.EventPromptCreated})
+	if _, ok := <-first.Events(); ok {
+		t.Error("Expected the queue closed on unsubscribing")
+	}
+	if ids := drain(second); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("Expected event 3, got %v", ids)
+	}
+}
+
+func TestDashboardHubBlocked(t *testing.T) {
+	hub := server.NewDashboardHub()
+	blocked, _ := hub.Subscribe(0)
+	reading, _ := hub.Subscribe(0)
+
+	// One page never reads; publishing goes on regardless and the other
+	// keeps up
+	received := make(chan int64)
+	go func() {
+		for ev := range reading.Events() {
+			received <- ev.ID
+		}
+	}()
+	for i := int64(1); i <= 200; i++ {
+		hub.Publish(server.DashboardEvent{Type: server.EventPromptCreated})
+		select {
+		case id := <-received:
+			if id != i {
+				t.Fatalf("Expected event %d on the reading page, got %d", i, id)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected event %d on the reading page", i)
+		}
+	}
+
+	// The blocked page was dropped once its queue filled
+	ids := drain(blocked)
+	if _, ok := <-blocked.Events(); ok || len(ids) == 0 || len(ids) >= 200 {
+		t.Errorf("Expected the blocked page dropped after a full queue, got %d events", len(ids))
+	}
+
+	// Reconnecting from the last event it saw, it catches up on everything
+	// kept, even past its queue's size
+	last := ids[len(ids)-1]
+	sub, complete := hub.Subscribe(last)
+	if ids := drain(sub); !complete || len(ids) != int(200-last) || ids[0] != last+1 {
+		t.Errorf("Expected events %d to 200 replayed, got %v", last+1, ids)
+	}
+	hub.Unsubscribe(sub)
+
+	// Once they are no longer kept it is told to start afresh
+	hub.Unsubscribe(reading)
+	for i := 0; i < 200; i++ {
+		hub.Publish(server.DashboardEvent{Type: server.EventPromptCreated})
+	}
+	sub, complete = hub.Subscribe(last)
+	if complete {
+		t.Errorf("Expected events too far back to need a fresh start")
+	}
+	hub.Unsubscribe(sub)
+	sub, complete = hub.Subscribe(190)
+	if ids := drain(sub); !complete || len(ids) != 210 || ids[0] != 191 || ids[209] != 400 {
+		t.Errorf("Expected events 191 to 400 replayed, got %v", ids)
+	}
+}
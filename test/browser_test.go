@@ -0,0 +1,57 @@
+package test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestOpensBrowser(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("always has a display")
+	}
+	tests := []struct {
+		display, wayland string
+		noBrowser        bool
+		want             bool
+	}{
+		{"", "", false, false},
+		{":0", "", false, true},
+		{"", "wayland-0", false, true},
+		{":0", "wayland-0", true, false},
+	}
+	for _, tt := range tests {
+		t.Setenv("DISPLAY", tt.display)
+		t.Setenv("WAYLAND_DISPLAY", tt.wayland)
+		if got := (server.WebListener{NoBrowser: tt.noBrowser}).OpensBrowser(); got != tt.want {
+			t.Errorf("DISPLAY=%q WAYLAND_DISPLAY=%q no browser %v: expected %v, got %v", tt.display, tt.wayland, tt.noBrowser, tt.want, got)
+		}
+	}
+
+	if l, err := (server.Config{NoBrowser: true}).WebListener(); err != nil || !l.NoBrowser {
+		t.Errorf("Expected no_browser on the listener, got %+v (%v)", l, err)
+	}
+}
+
+func TestURLBlock(t *testing.T) {
+	url := "http://127.0.0.1:8400/prompt-mcp/abc_DEF-123/"
+	block := server.URLBlock(url, "input")
+	if !strings.Contains(block, "Open this URL in a browser for input:") {
+		t.Errorf("Expected the block to say what the URL is for, got:\n%s", block)
+	}
+	// The URL sits on a line of its own, to be copied whole
+	lines := strings.Split(block, "\n")
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == url {
+			found = true
+		} else if strings.Contains(line, url) {
+			t.Errorf("Expected nothing next to the URL, got %q", line)
+		}
+	}
+	if !found {
+		t.Errorf("Expected the URL on a line of its own, got:\n%s", block)
+	}
+}
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func cacheCall(tool, prompt, args string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":{"prompt":%q%s}}}`, tool, prompt, args)
+}
+
+// cachedAnswer runs call and returns the answer and whether it came from
+// the cache.
+func cachedAnswer(t *testing.T, srv *server.MCPServer, call string) (string, bool) {
+	t.Helper()
+	result := findResponse(t, parseMessages(t, runServer(t, srv, call).String()), 1)["result"].(map[string]interface{})
+	text, _ := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	meta, _ := result["_meta"].(map[string]interface{})
+	return text, meta["cached"] == true
+}
+
+func TestCacheRememberedOnTerminal(t *testing.T) {
+	term := newFakeTerminal("staging !remember\n")
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	if answer, cached := cachedAnswer(t, srv, cacheCall("user_input", "Use staging\n  credentials?", "")); answer != "staging" || cached {
+		t.Fatalf("Expected the typed answer without the suffix, got %q (cached %v)", answer, cached)
+	}
+	if out := term.output.String(); !strings.Contains(out, "(End the answer with !remember to reuse it for this question)") {
+		t.Errorf("Expected the terminal to explain !remember, got %q", out)
+	}
+
+	asked := &fakeProvider{response: "yes"}
+	srv.SetInputProvider("tty", asked)
+	if answer, cached := cachedAnswer(t, srv, cacheCall("user_input", "Use staging credentials?", "")); answer != "staging" || !cached {
+		t.Errorf("Expected the remembered answer, got %q (cached %v)", answer, cached)
+	}
+	if asked.lastReq != nil {
+		t.Error("Expected the user not to be asked again")
+	}
+	if entries := srv.History().Entries(); len(entries) != 2 || entries[0].Outcome != server.OutcomeCached || entries[0].Response != "staging" {
+		t.Errorf("Expected the cached answer in the history, got %+v", entries)
+	}
+
+	for _, call := range []string{
+		cacheCall("user_input", "Use staging credentials?", `,"cache_bypass":true`),
+		cacheCall("user_input", "Use staging credentials", ""),
+		cacheCall("user_confirm", "Use staging credentials?", ""),
+	} {
+		asked.lastReq = nil
+		if answer, cached := cachedAnswer(t, srv, call); cached || asked.lastReq == nil {
+			t.Errorf("%s: expected the user to be asked, got %q from the cache", call, answer)
+		}
+	}
+}
+
+func TestCacheChoiceOnTerminal(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(newFakeTerminal("2 !remember\n")))
+	call := cacheCall("user_choice", "Which region?", `,"options":["eu-west-1","us-east-1"]`)
+	if answer, _ := cachedAnswer(t, srv, call); answer != "us-east-1" {
+		t.Fatalf("Expected the second option, got %q", answer)
+	}
+
+	srv.SetInputProvider("tty", &fakeProvider{response: "eu-west-1"})
+	if answer, cached := cachedAnswer(t, srv, call); answer != "us-east-1" || !cached {
+		t.Errorf("Expected the remembered option, got %q (cached %v)", answer, cached)
+	}
+	// An option that is gone can't be the answer any more
+	if answer, cached := cachedAnswer(t, srv, cacheCall("user_choice", "Which region?", `,"options":["eu-west-1","ap-south-1"]`)); answer != "eu-west-1" || cached {
+		t.Errorf("Expected the user asked about the new options, got %q (cached %v)", answer, cached)
+	}
+}
+
+func TestCacheNeverOffered(t *testing.T) {
+	tests := []struct {
+		name   string
+		config server.Config
+		call   string
+	}{
+		{"sensitive", server.Config{}, cacheCall("user_input", "API key?", `,"sensitive":true`)},
+		{"phrase", server.Config{}, cacheCall("user_confirm", "Drop the database?", `,"confirmation_phrase":"drop"`)},
+		{"list", server.Config{}, cacheCall("user_list", "Hosts?", "")},
+		{"disabled", server.Config{CacheTTL: -1}, cacheCall("user_input", "Branch?", "")},
+	}
+	for _, tt := range tests {
+		provider := &fakeProvider{response: "drop"}
+		srv := &server.MCPServer{}
+		srv.SetConfig(tt.config)
+		srv.SetInputProvider("tty", provider)
+		runServer(t, srv, tt.call)
+		if provider.lastReq == nil || provider.lastReq.Remember != nil {
+			t.Errorf("%s: expected no offer to remember the answer, got %+v", tt.name, provider.lastReq)
+		}
+	}
+
+	provider := &fakeProvider{response: "main"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, cacheCall("user_input", "Branch?", ""))
+	if provider.lastReq.Remember == nil {
+		t.Error("Expected a plain prompt to offer remembering its answer")
+	}
+}
+
+func TestCacheWebCheckbox(t *testing.T) {
+	req := server.NewConfirmPrompt("Use staging credentials?", "web", "")
+	req.Remember = &server.Remember{}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `<input type="checkbox" name="remember" value="yes"> Remember this answer`) {
+		t.Fatalf("Expected the remember checkbox, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}, "remember": {"yes"}}))
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "yes" || !req.Remember.Wanted() {
+		t.Errorf("Expected yes to be remembered, got %q (%v), wanted %v", answer, err, req.Remember.Wanted())
+	}
+
+	plain := httptest.NewRecorder()
+	server.NewWebInputHandler(server.NewConfirmPrompt("Deploy?", "web", "")).ServeHTTP(plain, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(plain.Body.String(), `name="remember"`) {
+		t.Errorf("Expected no checkbox on a prompt that can't be remembered, got:\n%s", plain.Body.String())
+	}
+}
+
+func TestCacheExpiresAndClears(t *testing.T) {
+	cache := server.NewAnswerCache()
+	req := server.NewPromptRequest("Use  staging credentials?", "tty")
+	cache.Store(req, "yes", 30*time.Millisecond)
+	cache.Store(server.NewPromptRequest("Region?", "tty"), "eu", time.Hour)
+
+	if answer, ok := cache.Lookup(server.NewPromptRequest("Use staging\tcredentials? ", "tty")); !ok || answer != "yes" {
+		t.Errorf("Expected whitespace to be normalized, got %q (%v)", answer, ok)
+	}
+	if _, ok := cache.Lookup(server.NewPromptRequest("use staging credentials?", "tty")); ok {
+		t.Error("Expected a different question not to match")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Lookup(req); ok {
+		t.Error("Expected the answer to expire")
+	}
+	if n := cache.Len(); n != 1 {
+		t.Errorf("Expected one answer left, got %d", n)
+	}
+
+	d := newDashboard(t)
+	d.SetAnswerCache(cache)
+	if body := dashboardGet(d, d.Path()).Body.String(); !strings.Contains(body, `<form method="post" action="`+d.Path()+`forget"><button type="submit">Forget remembered answers (1)</button></form>`) {
+		t.Errorf("Expected the index to offer forgetting the answer, got:\n%s", body)
+	}
+	if rec := dashboardGet(d, d.Path()+"forget"); rec.Code != http.StatusMethodNotAllowed || cache.Len() != 1 {
+		t.Errorf("Expected a GET to forget nothing, got %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, d.Path()+"forget", nil))
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != d.Path() || cache.Len() != 0 {
+		t.Errorf("Expected the answers forgotten and a redirect to the index, got %d to %q with %d left", rec.Code, rec.Header().Get("Location"), cache.Len())
+	}
+	if body := dashboardGet(d, d.Path()).Body.String(); strings.Contains(body, "forget") {
+		t.Errorf("Expected nothing left to forget, got:\n%s", body)
+	}
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestUserChoiceTool(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["option A","option B","option C"],"method":"web"}}}`
+
+	provider := &fakeProvider{responses: []string{"option D", "7", "OPTION b"}}
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	content := result["content"].([]interface{})
+	if content[0].(map[string]interface{})["text"] != "option B" {
+		t.Errorf("Expected the exact option string, got %v", content[0])
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["index"] != float64(1) {
+		t.Errorf("Expected index 1, got %v", structured["index"])
+	}
+	if provider.attempts != 3 {
+		t.Errorf("Expected invalid selections to be re-asked, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserChoiceInvalidOptions(t *testing.T) {
+	tests := []string{
+		`{"prompt":"Which?"}`,
+		`{"prompt":"Which?","options":[]}`,
+		`{"prompt":"Which?","options":["a","a"]}`,
+		`{"prompt":"Which?","options":["a",3]}`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":` + args + `}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestUserChoiceListed(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	tools := findResponse(t, messages, 1)["result"].(map[string]interface{})["tools"].([]interface{})
+	for _, tool := range tools {
+		if tool.(map[string]interface{})["name"] == "user_choice" {
+			return
+		}
+	}
+	t.Error("Expected user_choice in tools/list")
+}
+
+func TestChoiceTTYMenu(t *testing.T) {
+	term := newFakeTerminal("banana\n3\n")
+	req := server.NewChoicePrompt("Pick a fruit", "tty", []string{"apple", "pear", "plum"})
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "plum" {
+		t.Errorf("Expected plum, got %q", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"Pick a fruit", "1) apple", "2) pear", "3) plum", `Invalid selection "banana"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected terminal output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestChoiceWebRadioButtons(t *testing.T) {
+	req := server.NewChoicePrompt("Pick a fruit", "web", []string{"apple", "pear"})
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `type="radio" name="response" value="1"`) || !strings.Contains(body, "pear") {
+		t.Errorf("Expected radio buttons for the options, got:\n%s", body)
+	}
+	if strings.Contains(body, `<input type="text"`) {
+		t.Error("Expected no free-text input for a choice prompt")
+	}
+
+	// An invalid selection re-renders the form with an error
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"9"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid selection") {
+		t.Errorf("Expected the form to be re-rendered with an error, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"2"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the valid selection to be accepted, got %d", rec.Code)
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != "pear" {
+		t.Errorf("Expected pear, got %q (%v)", answer, err)
+	}
+}
+
+// postForm builds a form-encoded POST request, with the form token of the
+// page h serves next to target.
+func postForm(t *testing.T, h http.Handler, target string, values url.Values) *http.Request {
+	t.Helper()
+	form := url.Values{"csrf": {formToken(h, target)}}
+	for key, value := range values {
+		form[key] = value
+	}
+	req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+var csrfInput = regexp.MustCompile(`name="csrf" value="([^"]*)"`)
+
+// formToken reads the form token off the page h serves next to target, or
+// returns "" when there is no page to read it from.
+func formToken(h http.Handler, target string) string {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, strings.TrimSuffix(target, path.Base(target)), nil))
+	if m := csrfInput.FindStringSubmatch(rec.Body.String()); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+func TestUserChoiceMultiSelect(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["lint","unit","e2e"],"multi_select":true,"min_selections":2,"method":"web"}}}`
+
+	provider := &fakeProvider{responses: []string{"2", "unit, bogus", "3,LINT,3"}}
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	content := result["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != `["lint","e2e"]` {
+		t.Errorf("Expected a JSON array in option order, got %v", text)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	indices := structured["indices"].([]interface{})
+	if len(indices) != 2 || indices[0] != float64(0) || indices[1] != float64(2) {
+		t.Errorf("Expected indices [0 2], got %v", indices)
+	}
+	if provider.attempts != 3 {
+		t.Errorf("Expected too few and unknown selections to be re-asked, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserChoiceInvalidSelectionBounds(t *testing.T) {
+	tests := []string{
+		`"min_selections":3,"max_selections":2`,
+		`"max_selections":4`,
+		`"max_selections":0`,
+		`"min_selections":-1`,
+		`"min_selections":1.5`,
+	}
+
+	for _, bounds := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["a","b","c"],"multi_select":true,` + bounds + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", bounds, errorObj)
+		}
+	}
+}
+
+func TestMultiChoiceTTY(t *testing.T) {
+	term := newFakeTerminal("1,2,3\nplum, 1\n")
+	req := server.NewMultiChoicePrompt("Pick fruit", "tty", []string{"apple", "pear", "plum"}, 1, 2)
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != `["apple","plum"]` {
+		t.Errorf("Expected apple and plum in option order, got %q", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"comma-separated", "between 1 and 2"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected terminal output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestMultiChoiceWebCheckboxes(t *testing.T) {
+	req := server.NewMultiChoicePrompt("Pick fruit", "web", []string{"apple", "pear", "plum"}, 0, 2)
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `type="checkbox" name="response" value="3"`) || strings.Contains(body, `type="radio"`) {
+		t.Errorf("Expected checkboxes for the options, got:\n%s", body)
+	}
+
+	// Too many ticks re-render the form with the ticks kept
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"1", "2", "3"}}))
+	body := rec.Body.String()
+	if rec.Code != http.StatusBadRequest || !strings.Contains(body, "at most 2") || !strings.Contains(body, `value="3" checked`) {
+		t.Errorf("Expected the form to be re-rendered with an error, got %d:\n%s", rec.Code, body)
+	}
+
+	// Ticking nothing is allowed with min_selections 0
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected an empty selection to be accepted, got %d", rec.Code)
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != "[]" {
+		t.Errorf("Expected an empty array, got %q (%v)", answer, err)
+	}
+}
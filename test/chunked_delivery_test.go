@@ -0,0 +1,114 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"prompt-mcp/server"
+)
+
+// chunkedCall runs a chunked user_input call answered with response and
+// returns the result.
+func chunkedCall(t *testing.T, chunkSize int, response string) map[string]interface{} {
+	t.Helper()
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste it","method":"web","delivery":"chunked"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{ChunkSize: chunkSize})
+	srv.SetInputProvider("web", &fakeProvider{response: response})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	return findResponse(t, messages, 1)["result"].(map[string]interface{})
+}
+
+// reassemble strips the part markers and joins the chunks back together.
+func reassemble(t *testing.T, result map[string]interface{}) (string, []string) {
+	t.Helper()
+
+	content := result["content"].([]interface{})
+	var whole strings.Builder
+	var chunks []string
+	for i, item := range content {
+		text := item.(map[string]interface{})["text"].(string)
+		marker := fmt.Sprintf("(part %d/%d)\n", i+1, len(content))
+		if !strings.HasPrefix(text, marker) {
+			t.Fatalf("Expected chunk %d to start with %q, got %q", i, marker, text)
+		}
+		chunk := strings.TrimPrefix(text, marker)
+		if !utf8.ValidString(chunk) {
+			t.Errorf("Chunk %d is not valid UTF-8: %q", i, chunk)
+		}
+		chunks = append(chunks, chunk)
+		whole.WriteString(chunk)
+	}
+	return whole.String(), chunks
+}
+
+func TestChunkedDeliverySplitsOnLines(t *testing.T) {
+	response := "first line\nsecond line\nthird line\n"
+	result := chunkedCall(t, 16, response)
+
+	whole, chunks := reassemble(t, result)
+	if whole != response {
+		t.Errorf("Expected chunks to reassemble to %q, got %q", response, whole)
+	}
+	for _, chunk := range chunks {
+		if !strings.HasSuffix(chunk, "\n") {
+			t.Errorf("Expected chunk to end at a line boundary, got %q", chunk)
+		}
+	}
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["size"] != float64(len(response)) {
+		t.Errorf("Expected size %d, got %v", len(response), structured["size"])
+	}
+	if structured["chunks"] != float64(len(chunks)) {
+		t.Errorf("Expected chunk count %d, got %v", len(chunks), structured["chunks"])
+	}
+}
+
+func TestChunkedDeliveryPreservesMultibyteRunes(t *testing.T) {
+	// No newlines, and every rune is 3 or 4 bytes so a 10 byte chunk size
+	// never lands on a rune boundary by accident.
+	response := strings.Repeat("日本語🎉", 20)
+	result := chunkedCall(t, 10, response)
+
+	whole, chunks := reassemble(t, result)
+	if whole != response {
+		t.Errorf("Expected chunks to reassemble to the original answer")
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > 10 {
+			t.Errorf("Expected chunks of at most 10 bytes, got %d", len(chunk))
+		}
+	}
+}
+
+func TestChunkedDeliveryAtThreshold(t *testing.T) {
+	response := strings.Repeat("x", 16)
+	result := chunkedCall(t, 16, response)
+
+	content := result["content"].([]interface{})
+	if len(content) != 1 || content[0].(map[string]interface{})["text"] != response {
+		t.Errorf("Expected an answer exactly at the chunk size to be returned unchunked, got %v", content)
+	}
+
+	result = chunkedCall(t, 16, response+"y")
+	if content := result["content"].([]interface{}); len(content) != 2 {
+		t.Errorf("Expected an answer one byte over the chunk size to be split in 2, got %d", len(content))
+	}
+}
+
+func TestInvalidDeliveryRejected(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste it","delivery":"carrier-pigeon"}}}`
+
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected error code -32602, got %v", errorObj["code"])
+	}
+}
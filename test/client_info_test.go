@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestClientInfoOnPrompts(t *testing.T) {
+	srv := &server.MCPServer{}
+	provider := &fakeProvider{response: "ok"}
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"Claude Desktop","version":"0.9.2"}}}
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"First?"}}}
+{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Second?"}}}`)
+
+	if info := srv.ClientInfo(); info == nil || info.Name != "Claude Desktop" || info.Version != "0.9.2" {
+		t.Fatalf("Expected the client stored on the session, got %+v", info)
+	}
+	req := provider.lastReq
+	if req.Client.String() != "Claude Desktop 0.9.2" || req.ID != 2 {
+		t.Errorf("Expected the second prompt from Claude Desktop 0.9.2, got %q, ID %d", req.Client, req.ID)
+	}
+	if entries := srv.History().Entries(); len(entries) != 2 || entries[0].Client != "Claude Desktop 0.9.2" {
+		t.Errorf("Expected the client in the history, got %+v", entries)
+	}
+}
+
+func TestClientInfoParsing(t *testing.T) {
+	tests := []struct {
+		params   string
+		expected string
+	}{
+		{`{"clientInfo":{"name":"Cursor"}}`, "Cursor"},
+		{`{"clientInfo":{"name":"evil\u001b[2J","version":" 1.0\n"}}`, "evil[2J 1.0"},
+		{`{"clientInfo":{"name":"` + strings.Repeat("x", 100) + `"}}`, strings.Repeat("x", 64) + "…"},
+		{`{"clientInfo":{"name":""}}`, "unknown client"},
+		{`{"clientInfo":{"name":42}}`, "unknown client"},
+		{`{}`, "unknown client"},
+	}
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		runServer(t, srv, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":`+tt.params+`}`)
+		if got := srv.ClientInfo().String(); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.params, tt.expected, got)
+		}
+	}
+}
+
+func TestClientInfoShown(t *testing.T) {
+	req := server.NewPromptRequest("Deploy?", "web")
+	req.ID = 4
+	req.Client = &server.ClientInfo{Name: "Claude Desktop", Version: "0.9.2"}
+
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(req).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<p class="requester">Requested by: Claude Desktop 0.9.2 · question 4</p>`) {
+		t.Errorf("Expected the client on the page, got:\n%s", body)
+	}
+
+	term := newFakeTerminal("yes\n")
+	req = server.NewPromptRequest("Deploy?", "tty")
+	req.ID = 4
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "(Requested by: unknown client · question 4)\nDeploy?") {
+		t.Errorf("Expected an unknown client above the prompt, got:\n%s", out)
+	}
+
+	// Prompts asked outside a session, like the ask command's, say nothing
+	rec = httptest.NewRecorder()
+	server.NewWebInputHandler(server.NewPromptRequest("Deploy?", "web")).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), "Requested by") {
+		t.Errorf("Expected no requester without a session, got:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const clipboardCall = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_clipboard","arguments":{"reason":"You copied the stack trace"}}}`
+
+// clipboardServer returns a server whose user answers the consent prompt
+// with answer and whose clipboard holds text.
+func clipboardServer(answer, text string, readErr error, reads *int) (*server.MCPServer, *fakeProvider) {
+	provider := &fakeProvider{response: answer}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	srv.SetClipboardReader(func(ctx context.Context) (string, error) {
+		*reads++
+		return text, readErr
+	})
+	return srv, provider
+}
+
+func TestUserClipboardConsented(t *testing.T) {
+	reads := 0
+	srv, provider := clipboardServer("y", "panic: runtime error", nil, &reads)
+
+	messages := parseMessages(t, runServer(t, srv, clipboardCall).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false || result["content"].([]interface{})[0].(map[string]interface{})["text"] != "panic: runtime error" {
+		t.Errorf("Expected the clipboard text, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["consented"] != true || structured["truncated"] != false || structured["size"] != float64(20) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if !strings.Contains(provider.lastReq.Prompt, "read your clipboard") || provider.lastReq.Detail != "You copied the stack trace" {
+		t.Errorf("Expected a consent prompt with the reason, got %+v", provider.lastReq)
+	}
+}
+
+func TestUserClipboardRefused(t *testing.T) {
+	for _, answer := range []string{"no", ""} {
+		reads := 0
+		srv, _ := clipboardServer(answer, "hunter2", nil, &reads)
+
+		out := runServer(t, srv, clipboardCall).String()
+		result := findResponse(t, parseMessages(t, out), 1)["result"].(map[string]interface{})
+
+		if result["isError"] != true || !strings.Contains(result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), "refused consent") {
+			t.Errorf("%q: expected a refusal error, got %v", answer, result)
+		}
+		if reads != 0 || strings.Contains(out, "hunter2") {
+			t.Errorf("%q: expected the clipboard to be left alone", answer)
+		}
+	}
+}
+
+func TestUserClipboardReadFailures(t *testing.T) {
+	tests := []struct {
+		text string
+		err  error
+		want string
+	}{
+		{"", errors.New("no clipboard command found"), "no clipboard command found"},
+		{"\xff\xfe", nil, "does not hold text"},
+	}
+
+	for _, tt := range tests {
+		reads := 0
+		srv, _ := clipboardServer("yes", tt.text, tt.err, &reads)
+
+		result := findResponse(t, parseMessages(t, runServer(t, srv, clipboardCall).String()), 1)["result"].(map[string]interface{})
+		if result["isError"] != true || !strings.Contains(result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), tt.want) {
+			t.Errorf("Expected %q, got %v", tt.want, result)
+		}
+	}
+}
+
+func TestUserClipboardTruncated(t *testing.T) {
+	reads := 0
+	big := strings.Repeat("é", 600*1024)
+	srv, _ := clipboardServer("yes", big, nil, &reads)
+
+	result := findResponse(t, parseMessages(t, runServer(t, srv, clipboardCall).String()), 1)["result"].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	structured := result["structuredContent"].(map[string]interface{})
+
+	if len(text) > 1024*1024 || !strings.HasPrefix(big, text) {
+		t.Errorf("Expected at most 1MiB of whole characters, got %d bytes", len(text))
+	}
+	if structured["truncated"] != true || structured["size"] != float64(len(big)) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+}
@@ -0,0 +1,51 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestCodeBlocksHighlighted(t *testing.T) {
+	prompt := "Apply this change?\n\n```go\nfunc main() { // entry\n\tfmt.Println(\"<hi>\")\n}\n```\nThen run:\n~~~sh\nmake test # all of it\n~~~"
+	page := renderInput(t, server.NewPromptRequest(prompt, "web"))
+
+	for _, want := range []string{
+		`<div class="prompt-text">Apply this change?</div>`,
+		`<pre class="code"><code class="language-go"><span class="hl-k">func</span> main() { <span class="hl-c">// entry</span>` + "\n\tfmt.Println(<span class=\"hl-s\">&#34;&lt;hi&gt;&#34;</span>)\n}</code></pre>",
+		`<div class="prompt-text">Then run:</div>`,
+		`<code class="language-sh">make test <span class="hl-c"># all of it</span></code>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %q on the page, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "```") || strings.Contains(page, "<hi>") {
+		t.Errorf("Expected the fences gone and the code escaped, got:\n%s", page)
+	}
+	if css := webAsset(t, "input.css"); !strings.Contains(css, "overflow-x: auto; white-space: pre;") {
+		t.Errorf("Expected code blocks to scroll sideways, got:\n%s", css)
+	}
+}
+
+func TestCodeBlocksPlainFallback(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		// Untagged and unknown languages are only escaped
+		{"Output:\n```\nif x < 1 { return }\n```", `<pre class="code"><code>if x &lt; 1 { return }</code></pre>`},
+		{"```brainfuck\n+[>+<-] // 42\n```", `<pre class="code"><code>+[&gt;+&lt;-] // 42</code></pre>`},
+		// An unterminated fence runs to the end
+		{"Log:\n```text\nline 1\nline 2", `<pre class="code"><code>line 1` + "\nline 2</code></pre>"},
+		// Without fences, the prompt is as before
+		{"Delete <b>all</b>?", `<div class="prompt">Delete &lt;b&gt;all&lt;/b&gt;?</div>`},
+	}
+	for _, tt := range tests {
+		page := renderInput(t, server.NewPromptRequest(tt.prompt, "web"))
+		if !strings.Contains(page, tt.want) {
+			t.Errorf("%q: expected %q, got:\n%s", tt.prompt, tt.want, page)
+		}
+	}
+}
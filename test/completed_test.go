@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestWebCompletedAfterAnswer(t *testing.T) {
+	req := server.NewPromptRequest("Deploy which branch?", "web")
+	handler := server.NewWebInputHandler(req)
+	submit := func(answer string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {answer}}))
+		return rec
+	}
+	if rec := submit("main"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answer to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "main" {
+		t.Fatalf("Expected main, got %q (%v)", answer, err)
+	}
+
+	// Reloading keeps saying so, without the prompt or the answer
+	for range 2 {
+		rec := pageGet(handler, "/")
+		body := rec.Body.String()
+		if rec.Code != http.StatusOK || !strings.Contains(body, "<h1>Already answered</h1>") || strings.Contains(body, "Deploy which branch?") || strings.Contains(body, "main") || strings.Contains(body, "<form") {
+			t.Errorf("Expected the page to say the prompt was answered, got %d:\n%s", rec.Code, body)
+		}
+	}
+
+	rec := submit("release")
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Already answered</h1>") {
+		t.Errorf("Expected a second answer to be gone, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != "main" {
+		t.Errorf("Expected the first answer to stand, got %q", answer)
+	}
+}
+
+func TestWebCompletedAfterTimeout(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Deploy which branch?", "web"))
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	handler.Wait(ctx)
+
+	rec := pageGet(handler, "/")
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Timed out</h1>") || strings.Contains(rec.Body.String(), "<form") {
+		t.Errorf("Expected the page to say the prompt timed out, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"main"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Timed out</h1>") {
+		t.Errorf("Expected a late answer to be gone, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWebCompletedAfterFailure(t *testing.T) {
+	req := server.NewPromptRequest("Port?", "web")
+	req.Locale = "de"
+	req.Validate = func(string) (string, error) { return "", &server.ValidationError{Attempts: 1, Reason: "not a port"} }
+	handler := server.NewWebInputHandler(req)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"x"}}))
+	if _, err := handler.Wait(context.Background()); err == nil {
+		t.Fatalf("Expected the prompt to fail, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = pageGet(handler, "/")
+	if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, `<html lang="de">`) || !strings.Contains(body, `<h1>Nicht mehr offen</h1>`) {
+		t.Errorf("Expected the page to say the prompt is closed, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+// pageGet fetches path from the handler of a page.
+func pageGet(h http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestUserConfirmTool(t *testing.T) {
+	tests := []struct {
+		args      string
+		responses []string
+		answer    string
+		confirmed bool
+		attempts  int32
+	}{
+		{`"default":"yes"`, []string{""}, "yes", true, 1},
+		{`"default":"no"`, []string{""}, "no", false, 1},
+		{`"default":"no"`, []string{"Y"}, "yes", true, 1},
+		{`"default":"yes"`, []string{"maybe", "NO"}, "no", false, 2},
+		{`"method":"web"`, []string{"", "yes"}, "yes", true, 2},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_confirm","arguments":{"prompt":"Deploy?",` + tt.args + `}}}`
+
+		provider := &fakeProvider{responses: tt.responses}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+		srv.SetInputProvider("web", provider)
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		content := result["content"].([]interface{})
+		if content[0].(map[string]interface{})["text"] != tt.answer {
+			t.Errorf("%s %q: expected %q, got %v", tt.args, tt.responses, tt.answer, content[0])
+		}
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["confirmed"] != tt.confirmed || structured["answer"] != tt.answer {
+			t.Errorf("%s %q: unexpected structuredContent %v", tt.args, tt.responses, structured)
+		}
+		if provider.attempts != tt.attempts {
+			t.Errorf("%s %q: expected %d attempts, got %d", tt.args, tt.responses, tt.attempts, provider.attempts)
+		}
+	}
+}
+
+func TestUserConfirmInvalidDefault(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_confirm","arguments":{"prompt":"Deploy?","default":"maybe"}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602 for an invalid default, got %v", errorObj)
+	}
+}
+
+func TestConfirmTTYHint(t *testing.T) {
+	tests := []struct {
+		def  string
+		hint string
+	}{
+		{"yes", "[Y/n]"},
+		{"no", "[y/N]"},
+		{"", "[y/n]"},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal("sure\n\ny\n")
+		req := server.NewConfirmPrompt("Deploy?", "tty", tt.def)
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil {
+			t.Fatalf("GetInput failed: %v", err)
+		}
+
+		output := term.output.String()
+		if !strings.Contains(output, "Deploy?\n"+tt.hint+" ") {
+			t.Errorf("Expected %s hint, got:\n%s", tt.hint, output)
+		}
+		if !strings.Contains(output, "Please answer yes or no") {
+			t.Errorf("Expected an unrecognized answer to re-prompt, got:\n%s", output)
+		}
+
+		// The empty line resolves to the default when there is one
+		want := tt.def
+		if want == "" {
+			want = "yes"
+		}
+		if answer != want {
+			t.Errorf("Default %q: expected %q, got %q", tt.def, want, answer)
+		}
+	}
+}
+
+func TestConfirmWebButtons(t *testing.T) {
+	req := server.NewConfirmPrompt("Deploy?", "web", "no")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `value="yes">Approve</button>`) || !strings.Contains(body, `value="no" class="deny" autofocus>Deny</button>`) {
+		t.Errorf("Expected Approve/Deny buttons, got:\n%s", body)
+	}
+	if strings.Contains(body, `<input type="text"`) {
+		t.Error("Expected no free-text input for a confirm prompt")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"no"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected Deny to be accepted, got %d", rec.Code)
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != "no" {
+		t.Errorf("Expected no, got %q (%v)", answer, err)
+	}
+}
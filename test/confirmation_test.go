@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+var reviewNonce = regexp.MustCompile(`name="(confirm|edit)" value="([^"]*)"`)
+
+// reviewNonces returns the nonces of a review page's Confirm and Edit
+// buttons.
+func reviewNonces(t *testing.T, body string) (confirm, edit string) {
+	t.Helper()
+	for _, m := range reviewNonce.FindAllStringSubmatch(body, -1) {
+		if m[1] == "confirm" {
+			confirm = m[2]
+		} else {
+			edit = m[2]
+		}
+	}
+	if confirm == "" || edit == "" {
+		t.Fatalf("Expected Confirm and Edit buttons, got:\n%s", body)
+	}
+	return confirm, edit
+}
+
+func TestRequireConfirmationWeb(t *testing.T) {
+	req := server.NewPromptRequest("Drop which table?", "web")
+	req.Trim = server.TrimBoth
+	req.RequireConfirmation = true
+	handler := server.NewWebInputHandler(req)
+	post := func(values url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", values))
+		return rec
+	}
+	// The page asks the question for as long as the prompt is open
+	answered := func() bool {
+		return !strings.Contains(pageGet(handler, "/").Body.String(), "Drop which table?")
+	}
+
+	// Submitting shows the answer as it will be returned, trimmed, and
+	// answers nothing yet
+	rec := post(url.Values{"response": {"  users  "}})
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "You are about to respond:") || !strings.Contains(body, "<pre>users</pre>") {
+		t.Fatalf("Expected the review page, got %d:\n%s", rec.Code, body)
+	}
+	if answered() {
+		t.Fatal("Expected the prompt to wait for the confirmation")
+	}
+
+	// Edit goes back to the form with the answer filled in
+	_, edit := reviewNonces(t, body)
+	rec = post(url.Values{"edit": {edit}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `value="  users  "`) {
+		t.Errorf("Expected the form with the answer, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec = post(url.Values{"edit": {edit}}); rec.Code != http.StatusConflict {
+		t.Errorf("Expected an edited answer to be gone, got %d", rec.Code)
+	}
+
+	// Only the latest review can be confirmed
+	first, _ := reviewNonces(t, post(url.Values{"response": {"sessions"}}).Body.String())
+	latest, _ := reviewNonces(t, post(url.Values{"response": {"audit_log"}}).Body.String())
+	if rec = post(url.Values{"confirm": {first}}); rec.Code != http.StatusConflict || answered() {
+		t.Errorf("Expected a replaced review to be refused, got %d", rec.Code)
+	}
+	if rec = post(url.Values{"confirm": {latest}}); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the confirmation accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "audit_log" {
+		t.Errorf("Expected audit_log, got %q (%v)", answer, err)
+	}
+
+	if rec = post(url.Values{"confirm": {latest}}); rec.Code != http.StatusGone {
+		t.Errorf("Expected a second confirmation to be gone, got %d", rec.Code)
+	}
+}
+
+func TestRequireConfirmationWebTimeout(t *testing.T) {
+	req := server.NewPromptRequest("Password?", "web")
+	req.Secret = true
+	req.RequireConfirmation = true
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"hunter2"}}))
+	if strings.Contains(rec.Body.String(), "hunter2") || !strings.Contains(rec.Body.String(), "••••••••") {
+		t.Errorf("Expected the secret masked, got:\n%s", rec.Body.String())
+	}
+	confirm, _ := reviewNonces(t, rec.Body.String())
+
+	// A review left too long is not confirmed after the prompt timed out
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	handler.Wait(ctx)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"confirm": {confirm}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Timed out</h1>") {
+		t.Errorf("Expected a late confirmation to be gone, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRequireConfirmationTTY(t *testing.T) {
+	term := newFakeTerminal("prod\nmaybe\nn\n  staging \ny\n")
+	req := server.NewPromptRequest("Deploy to?", "tty")
+	req.RequireConfirmation = true
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "  staging " {
+		t.Errorf("Expected the second answer, got %q", answer)
+	}
+	output := term.output.String()
+	for _, want := range []string{"You are about to respond:\n  prod\nSend this? [y/n] ", "Please answer yes or no", "You are about to respond:\n  staging\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in the output, got:\n%s", want, output)
+		}
+	}
+	if strings.Count(output, "Deploy to?") != 2 {
+		t.Errorf("Expected the prompt asked again after n, got:\n%s", output)
+	}
+}
+
+func TestRequireConfirmationArgument(t *testing.T) {
+	for _, tool := range []string{
+		`"name":"user_input","arguments":{"prompt":"Drop?","require_confirmation":true}`,
+		`"name":"user_confirm","arguments":{"prompt":"Drop?","require_confirmation":true}`,
+		`"name":"user_choice","arguments":{"prompt":"Drop?","options":["a","b"],"require_confirmation":true}`,
+	} {
+		provider := &fakeProvider{response: "1"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+		runServer(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{`+tool+`}}`)
+		if provider.lastReq == nil || !provider.lastReq.RequireConfirmation {
+			t.Errorf("%s: expected the prompt to require confirmation", tool)
+		}
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Drop?","require_confirmation":"yes"}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+	if findResponse(t, messages, 1)["error"] == nil {
+		t.Error("Expected a non-boolean require_confirmation to be refused")
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+var patternAttr = regexp.MustCompile(`pattern="([^"]*)"`)
+
+// formPattern returns the pattern attribute on a prompt's form, unescaped,
+// and whether there is one.
+func formPattern(t *testing.T, req *server.PromptRequest) (string, bool) {
+	t.Helper()
+	m := patternAttr.FindStringSubmatch(pageGet(server.NewWebInputHandler(req), "/").Body.String())
+	if m == nil {
+		return "", false
+	}
+	return html.UnescapeString(m[1]), true
+}
+
+func TestConstraintsPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		trim    string
+		want    string
+	}{
+		// Metacharacters, quotes and markup reach the browser unchanged
+		{`[A-Z]{2,4}-\d+(?:"x"|<y>&'z')?`, server.TrimNone, `[A-Z]{2,4}-\d+(?:"x"|<y>&'z')?`},
+		{`(?P<key>[a-z]+)=\w*`, server.TrimNone, `(?<key>[a-z]+)=\w*`},
+		// JavaScript has no flag groups, \z or \pL, and the browser can't
+		// trim before matching; those are left to the server
+		{`(?i)yes|no`, server.TrimNone, ""},
+		{`\pL+\z`, server.TrimNone, ""},
+		{`[a-z]+`, server.TrimBoth, ""},
+	}
+	for _, tt := range tests {
+		req := server.NewPromptRequest("Ticket?", "web")
+		req.Trim = tt.trim
+		validate, err := server.NewPatternValidator(req, tt.pattern, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Validate = validate
+		got, ok := formPattern(t, req)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: expected pattern %q, got %q", tt.pattern, tt.want, got)
+		}
+	}
+
+	// Secrets are checked in the browser too
+	req := server.NewPromptRequest("PIN?", "web")
+	req.Secret = true
+	req.Validate, _ = server.NewPatternValidator(req, `\d{4}`, "")
+	if got, _ := formPattern(t, req); got != `\d{4}` {
+		t.Errorf("Expected the pattern on the password input, got %q", got)
+	}
+}
+
+func TestConstraintsAttributes(t *testing.T) {
+	req := server.NewPromptRequest("Environment?", "web")
+	validate, err := server.NewAllowedValuesValidator(req, []string{"staging", `prod "eu"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Validate = validate
+	req.LimitLength(server.LengthLimits{Min: 2, Max: 12})
+	page := pageGet(server.NewWebInputHandler(req), "/").Body.String()
+	for _, want := range []string{`list="allowed-values"`, `<datalist id="allowed-values"><option value="staging"><option value="prod &#34;eu&#34;"></datalist>`, `minlength="2"`, `maxlength="12"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s on the form, got:\n%s", want, page)
+		}
+	}
+
+	minimum, maximum := 1.0, 64.0
+	number := server.NewPromptRequest("Replicas?", "web")
+	number.Number = &server.NumberOptions{Integer: true, Minimum: &minimum, Maximum: &maximum}
+	page = pageGet(server.NewWebInputHandler(number), "/").Body.String()
+	if !strings.Contains(page, `type="number"`) || !strings.Contains(page, `min="1"`) || !strings.Contains(page, `max="64"`) {
+		t.Errorf("Expected the bounds on the number input, got:\n%s", page)
+	}
+
+	// Without checks the form has no constraints
+	page = pageGet(server.NewWebInputHandler(server.NewPromptRequest("Name?", "web")), "/").Body.String()
+	if strings.Contains(page, "pattern=") || strings.Contains(page, "datalist") || strings.Contains(page, "maxlength") {
+		t.Errorf("Expected a plain input, got:\n%s", page)
+	}
+}
+
+func TestConstraintsFailed(t *testing.T) {
+	submit := func(message, response string) string {
+		req := server.NewPromptRequest("Ticket?", "web")
+		req.Validate, _ = server.NewPatternValidator(req, `[A-Z]+-\d+`, message)
+		handler := server.NewWebInputHandler(req)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {response}}))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected the answer rejected, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	// A custom message hides the pattern, so the page names it
+	body := submit("Use a ticket key", "ops 12")
+	if !strings.Contains(body, "Use a ticket key") || !strings.Contains(body, `Failed check: the pattern [A-Z]&#43;-\d&#43;`) {
+		t.Errorf("Expected the failed check named, got:\n%s", body)
+	}
+	// The generic message already says which check failed
+	if body = submit("", "ops 12"); strings.Contains(body, "Failed check") {
+		t.Errorf("Expected the failed check said once, got:\n%s", body)
+	}
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func contextCall(t *testing.T, tool string, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      tool,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+var optionContext = []interface{}{
+	map[string]interface{}{"role": "user", "text": "Which cache should we use?"},
+	map[string]interface{}{"role": "assistant", "text": "Option A is Redis, option B is <b>in-process</b>."},
+}
+
+func TestContextNotReturned(t *testing.T) {
+	for tool, args := range map[string]map[string]interface{}{
+		"user_input":   {"prompt": "Should I use option B?"},
+		"user_choice":  {"prompt": "Which option?", "options": []string{"A", "B"}},
+		"user_confirm": {"prompt": "Should I use option B?"},
+	} {
+		provider := &fakeProvider{response: "yes"}
+		if tool == "user_choice" {
+			provider.response = "2"
+		}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+
+		args["context"] = optionContext
+		out := runServer(t, srv, contextCall(t, tool, args)).String()
+		if _, ok := findResponse(t, parseMessages(t, out), 1)["result"]; !ok {
+			t.Fatalf("%s: expected a result, got:\n%s", tool, out)
+		}
+		if strings.Contains(out, "Redis") {
+			t.Errorf("%s: expected the context to stay out of the result, got:\n%s", tool, out)
+		}
+
+		conv := provider.lastReq.Context
+		if conv == nil || len(conv.Messages) != 2 || conv.Messages[1].Role != "assistant" || conv.Omitted != 0 {
+			t.Errorf("%s: expected both messages to reach the provider, got %+v", tool, conv)
+		}
+	}
+}
+
+func TestContextTruncatedOldestFirst(t *testing.T) {
+	entry := func(text string) map[string]interface{} {
+		return map[string]interface{}{"role": "assistant", "text": text}
+	}
+
+	var many []interface{}
+	for i := 0; i < 100; i++ {
+		many = append(many, entry(strings.Repeat(string(rune('a'+i%26)), 1000)))
+	}
+	many = append(many, entry("the question at hand"))
+
+	tests := []struct {
+		name     string
+		context  []interface{}
+		omitted  int
+		newest   string
+		messages int
+	}{
+		{"many", many, 36, "the question at hand", 65},
+		{"huge newest", []interface{}{entry("old"), entry(strings.Repeat("é", 40000) + "end")}, 1, "end", 1},
+	}
+	for _, tt := range tests {
+		provider := &fakeProvider{response: "ok"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+		runServer(t, srv, contextCall(t, "user_input", map[string]interface{}{"prompt": "Go on?", "context": tt.context}))
+
+		conv := provider.lastReq.Context
+		if conv == nil {
+			t.Fatalf("%s: expected context", tt.name)
+		}
+		size := 0
+		for _, m := range conv.Messages {
+			size += len(m.Role) + len(m.Text)
+		}
+		last := conv.Messages[len(conv.Messages)-1].Text
+		if conv.Omitted != tt.omitted || len(conv.Messages) != tt.messages || !strings.HasSuffix(last, tt.newest) || size > 64*1024+len("…") {
+			t.Errorf("%s: expected %d kept and %d omitted, got %d kept (%d bytes) and %d omitted", tt.name, tt.messages, tt.omitted, len(conv.Messages), size, conv.Omitted)
+		}
+	}
+}
+
+func TestContextTTY(t *testing.T) {
+	conv := &server.Conversation{Messages: []server.ContextMessage{
+		{Role: "user", Text: "Which cache should we use?"},
+		{Role: "assistant", Text: "Option A is Redis.\nOption B is in-process."},
+	}, Omitted: 3}
+
+	for input, shown := range map[string]bool{"x\nc\nB\n": true, "\nB\n": false} {
+		term := newFakeTerminal(input)
+		req := server.NewPromptRequest("Should I use option B?", "tty")
+		req.Context = conv
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil || answer != "B" {
+			t.Fatalf("%q: expected B, got %q (%v)", input, answer, err)
+		}
+		output := term.output.String()
+		if !strings.Contains(output, "(Context: 5 messages) Press c to show it, or Enter to answer: ") {
+			t.Errorf("%q: expected the context to be offered, got:\n%s", input, output)
+		}
+		want := "… 3 earlier messages omitted\n\n[user]\n  Which cache should we use?\n\n[assistant]\n  Option A is Redis.\n  Option B is in-process.\n"
+		if strings.Contains(output, want) != shown {
+			t.Errorf("%q: expected context shown %v, got:\n%s", input, shown, output)
+		}
+	}
+}
+
+func TestContextWeb(t *testing.T) {
+	req := server.NewPromptRequest("Should I use option B?", "web")
+	req.Context = &server.Conversation{Messages: []server.ContextMessage{
+		{Role: "assistant", Text: "Option B is <script>alert(1)</script>"},
+	}, Omitted: 1}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<details class="context"><summary>Show context</summary>`,
+		`<p class="context-omitted">… 1 earlier message omitted</p>`,
+		`<div class="context-role">assistant</div><div class="context-text">Option B is &lt;script&gt;alert(1)&lt;/script&gt;</div>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "<script>alert") {
+		t.Errorf("Expected the context to be escaped")
+	}
+}
+
+func TestContextInvalid(t *testing.T) {
+	for name, call := range map[string]struct {
+		tool string
+		args map[string]interface{}
+	}{
+		"not an array":       {"user_input", map[string]interface{}{"context": "earlier"}},
+		"not an object":      {"user_input", map[string]interface{}{"context": []interface{}{"hi"}}},
+		"missing role":       {"user_input", map[string]interface{}{"context": []interface{}{map[string]interface{}{"text": "hi"}}}},
+		"multiline role":     {"user_confirm", map[string]interface{}{"context": []interface{}{map[string]interface{}{"role": "a\nb", "text": "hi"}}}},
+		"long role":          {"user_confirm", map[string]interface{}{"context": []interface{}{map[string]interface{}{"role": strings.Repeat("r", 65), "text": "hi"}}}},
+		"missing text":       {"user_choice", map[string]interface{}{"options": []string{"a", "b"}, "context": []interface{}{map[string]interface{}{"role": "user"}}}},
+		"text not a string":  {"user_choice", map[string]interface{}{"options": []string{"a", "b"}, "context": []interface{}{map[string]interface{}{"role": "user", "text": 1}}}},
+		"choice single_key":  {"user_choice", map[string]interface{}{"options": []string{"a", "b"}, "single_key": true, "context": optionContext}},
+		"confirm single_key": {"user_confirm", map[string]interface{}{"single_key": true, "context": optionContext}},
+	} {
+		call.args["prompt"] = "P"
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, contextCall(t, call.tool, call.args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", name, errObj)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func credentialsCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_credentials","arguments":{"service":"registry.example.com"` + args + `}}}`
+}
+
+func TestUserCredentialsResult(t *testing.T) {
+	provider := &fakeProvider{responses: []string{
+		`{"username":"alice","password":""}`,
+		`{"username":"  alice ","password":" s3cret pass "}`,
+	}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	stdout, stderr := runVerbose(t, srv, credentialsCall(""))
+
+	result := findResponse(t, parseMessages(t, stdout), 1)["result"].(map[string]interface{})
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["username"] != "alice" || structured["password"] != " s3cret pass " {
+		t.Errorf("Expected a trimmed username and the password as typed, got %v", structured)
+	}
+	item := result["content"].([]interface{})[0].(map[string]interface{})
+	annotations, _ := item["annotations"].(map[string]interface{})
+	if audience, _ := annotations["audience"].([]interface{}); len(audience) != 1 || audience[0] != "assistant" {
+		t.Errorf("Expected the result to be marked sensitive, got %v", item)
+	}
+
+	if strings.Contains(stderr, "s3cret") {
+		t.Errorf("Password leaked to the log:\n%s", stderr)
+	}
+	if provider.lastReq.Service != "registry.example.com" || provider.lastReq.Prompt != "Please sign in to registry.example.com" {
+		t.Errorf("Expected the service in the prompt, got %q / %q", provider.lastReq.Service, provider.lastReq.Prompt)
+	}
+}
+
+func TestUserCredentialsRefusesPublicWeb(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "0.0.0.0"})
+	provider := &fakeProvider{response: `{"username":"alice","password":"pw"}`}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, credentialsCall(`,"method":"web"`)).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if result["isError"] != true || !strings.Contains(text, "Refusing") {
+		t.Errorf("Expected the web method to be refused, got %v", result)
+	}
+	if provider.lastReq != nil {
+		t.Error("Expected the user not to be asked")
+	}
+}
+
+func TestUserCredentialsInvalidArguments(t *testing.T) {
+	for _, args := range []string{
+		`,"service":"a\nb"`,
+		`,"service":5`,
+		`,"prompt":true`,
+		`,"timeout":-1`,
+	} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_credentials","arguments":{"prompt":"Sign in"` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestCredentialsTTY(t *testing.T) {
+	term := newFakeTerminal("\nalice\n\nhunter2\n")
+	req := server.NewCredentialsPrompt("Sign in", "tty", "registry.example.com")
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != `{"username":"alice","password":"hunter2"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+	if term.echoOff || term.restored != 1 {
+		t.Errorf("Expected echo to be switched off once and restored, got echoOff=%v restored=%d", term.echoOff, term.restored)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"Service: registry.example.com", "Please enter a username", "Password: \nPlease enter a password"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestCredentialsTTYRestoresEchoOnCancel(t *testing.T) {
+	term := &fakeTerminal{input: io.MultiReader(strings.NewReader("alice\n"), blockingReader{})}
+	req := server.NewCredentialsPrompt("Sign in", "tty", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := ttyProvider(term).GetInput(ctx, req); err == nil {
+		t.Fatal("Expected the abandoned prompt to fail")
+	}
+	if term.echoOff || term.restored != 1 {
+		t.Errorf("Expected echo to be restored after cancellation, got echoOff=%v restored=%d", term.echoOff, term.restored)
+	}
+}
+
+func TestCredentialsWeb(t *testing.T) {
+	req := server.NewCredentialsPrompt("Sign in", "web", "registry.example.com")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`Service: <strong>registry.example.com</strong>`,
+		`<input type="text" name="username" id="username" value="" autocomplete="username"`,
+		`<input type="password" name="password" id="password" autocomplete="current-password" required>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+
+	// A rejected submission keeps the username, never the password
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"username": {"alice"}, "password": {""}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `value="alice"`) {
+		t.Errorf("Expected the missing password to be rejected with the username kept, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"username": {"alice"}, "password": {"hunter2"}}))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "hunter2") {
+		t.Fatalf("Expected the credentials to be accepted without echoing the password, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != `{"username":"alice","password":"hunter2"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestCSRFFormToken(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	token := formToken(handler, "/submit")
+	if token == "" {
+		t.Fatal("Expected a form token on the page")
+	}
+	if other := formToken(server.NewWebInputHandler(server.NewPromptRequest("Name?", "web")), "/submit"); other == token {
+		t.Error("Expected each prompt to get its own form token")
+	}
+
+	for _, values := range []url.Values{
+		{"response": {"mallory"}},
+		{"response": {"mallory"}, "csrf": {""}},
+		{"response": {"mallory"}, "csrf": {token + "x"}},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/submit", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%v: expected 403, got %d", values, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"alice"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answer with the token to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "alice" {
+		t.Errorf("Expected alice, got %q (%v)", answer, err)
+	}
+}
+
+func TestCSRFOrigin(t *testing.T) {
+	tests := []struct {
+		header string
+		value  string
+		status int
+	}{
+		{"Origin", "http://evil.test", http.StatusForbidden},
+		{"Origin", "null", http.StatusForbidden},
+		{"Referer", "http://evil.test/page", http.StatusForbidden},
+		{"Origin", "http://example.com", http.StatusOK},
+		{"Referer", "http://example.com/", http.StatusOK},
+	}
+	for _, tt := range tests {
+		handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+		req := postForm(t, handler, "/submit", url.Values{"response": {"alice"}})
+		req.Header.Set(tt.header, tt.value)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected %d, got %d", tt.header, tt.value, tt.status, rec.Code)
+		}
+	}
+
+	// Pages reached through the external URL come from its origin
+	useWebListener(t, server.WebListener{ExternalURL: "https://prompts.example.org"})
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	req := postForm(t, handler, "/submit", url.Values{"response": {"alice"}})
+	req.Header.Set("Origin", "https://prompts.example.org")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the external URL's origin to be accepted, got %d", rec.Code)
+	}
+}
+
+func TestCSRFUpload(t *testing.T) {
+	handler := server.NewWebInputHandler(uploadPrompt(t, 1024))
+
+	// A file sent ahead of the token is refused before it is read
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "build.txt")
+	part.Write([]byte("ok\n"))
+	form.WriteField("csrf", formToken(handler, "/submit"))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/submit", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a file ahead of the token to be refused, got %d", rec.Code)
+	}
+
+	req = postFile(t, handler, "build.txt", []byte("ok\n"))
+	req.Header.Set("Origin", "http://evil.test")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin upload to be refused, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postFile(t, handler, "build.txt", []byte("ok\n")))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the upload with the token to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
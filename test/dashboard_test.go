@@ -0,0 +1,412 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// dashboardGet fetches path from the dashboard.
+func dashboardGet(d *server.Dashboard, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+// newDashboard returns a dashboard that isn't started.
+func newDashboard(t *testing.T) *server.Dashboard {
+	d, err := server.NewDashboard(0, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// promptLinks matches the links to prompt pages on the dashboard index.
+var promptLinks = regexp.MustCompile(`href="(/p/\d+/[\w-]+/)"`)
+
+// waitListed waits until the dashboard index links to n prompts, and
+// returns the index and the links.
+func waitListed(t *testing.T, d *server.Dashboard, n int) (string, []string) {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		body := dashboardGet(d, d.Path()).Body.String()
+		if matches := promptLinks.FindAllStringSubmatch(body, -1); len(matches) == n {
+			links := make([]string, n)
+			for i, m := range matches {
+				links[i] = m[1]
+			}
+			return body, links
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d prompts on the dashboard, got:\n%s", n, body)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDashboardPrompts(t *testing.T) {
+	d := newDashboard(t)
+	if body := dashboardGet(d, d.Path()).Body.String(); !strings.Contains(body, "Nothing is waiting for an answer.") {
+		t.Errorf("Expected an empty dashboard, got:\n%s", body)
+	}
+	if rec := dashboardGet(d, "/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for the index without its token, got %d", rec.Code)
+	}
+
+	type answer struct {
+		response string
+		err      error
+	}
+	answers := make(chan answer, 2)
+	ask := func(prompt, title string) {
+		req := server.NewConfirmPrompt(prompt, "web", "")
+		req.Title = title
+		response, err := d.GetInput(context.Background(), req)
+		answers <- answer{response, err}
+	}
+	go ask("Deploy to <production>?", "Deploy")
+	waitListed(t, d, 1)
+	go ask("Run the migrations?", "")
+	body, links := waitListed(t, d, 2)
+
+	for _, want := range []string{`<title>(2) Pending prompts</title>`, `<div class="entry-title">Deploy</div>`, `Deploy to &lt;production&gt;?`, `new EventSource("` + d.Path() + `events" + '?since=' +`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q on the dashboard, got:\n%s", want, body)
+		}
+	}
+	if !strings.HasPrefix(links[0], "/p/1/") || !strings.HasPrefix(links[1], "/p/2/") {
+		t.Errorf("Expected the oldest prompt first, got %q", links)
+	}
+
+	// Each prompt's page posts back under its own path, token included
+	second := links[1]
+	if rec := dashboardGet(d, strings.TrimSuffix(second, "/")); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != second {
+		t.Errorf("Expected a redirect to %s, got %d %q", second, rec.Code, rec.Header().Get("Location"))
+	}
+	if page := dashboardGet(d, second).Body.String(); !strings.Contains(page, `action="`+second+`submit"`) || !strings.Contains(page, "Run the migrations?") {
+		t.Errorf("Expected the second prompt's form, got:\n%s", page)
+	}
+	for _, path := range []string{"/p/2/", "/p/2/submit", "/p/2/wrong-token/", links[0][:len("/p/1/")] + second[len("/p/2/"):]} {
+		if rec := dashboardGet(d, path); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+
+	// A second tab holding the same form
+	straggler := postForm(t, d, second+"submit", url.Values{"response": {"yes"}})
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, postForm(t, d, second+"submit", url.Values{"response": {"no"}}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<a href="`+d.Path()+`" target="_top">Back to pending prompts</a>`) {
+		t.Fatalf("Expected the answer to be accepted with a way back, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if got := <-answers; got.err != nil || got.response != "no" {
+		t.Errorf("Expected no, got %q (%v)", got.response, got.err)
+	}
+
+	// Answered prompts leave the list, and their pages say they were
+	// answered instead of taking another
+	body = dashboardGet(d, d.Path()).Body.String()
+	if _, pending := waitListed(t, d, 1); pending[0] != links[0] {
+		t.Errorf("Expected only the first prompt to be left, got:\n%s", body)
+	}
+	if rec := dashboardGet(d, second); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Already answered") || strings.Contains(rec.Body.String(), "Run the migrations?") {
+		t.Errorf("Expected the answered page, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, straggler)
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected 410 for a late submission, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := dashboardGet(d, "/p/2/wrong-token/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a wrong token, got %d", rec.Code)
+	}
+	if rec := dashboardGet(d, "/p/x/"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a malformed path, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, postForm(t, d, links[0]+"submit", url.Values{"response": {"yes"}}))
+	if got := <-answers; got.err != nil || got.response != "yes" {
+		t.Errorf("Expected yes, got %q (%v)", got.response, got.err)
+	}
+}
+
+func TestDashboardResolvedPages(t *testing.T) {
+	d := newDashboard(t)
+
+	// A withdrawn prompt's page says so, and refuses what is sent after
+	ctx, cancel := context.WithCancelCause(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := d.GetInput(ctx, server.NewPromptRequest("Deploy?", "web"))
+		done <- err
+	}()
+	_, links := waitListed(t, d, 1)
+	straggler := postForm(t, d, links[0]+"submit", url.Values{"response": {"yes"}})
+	cancel(server.ErrWithdrawn)
+	if err := <-done; err == nil {
+		t.Fatal("Expected the prompt withdrawn")
+	}
+	if rec := dashboardGet(d, links[0]); rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Withdrawn</h1>") {
+		t.Errorf("Expected the withdrawn page, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, straggler)
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected 410 for a late submission, got %d", rec.Code)
+	}
+
+	// Pages are kept as long as the index lists the prompt as resolved
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		d.GetInput(ctx, server.NewPromptRequest("Deploy?", "web"))
+	}
+	if rec := dashboardGet(d, links[0]); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once the prompt is no longer listed, got %d", rec.Code)
+	}
+}
+
+func TestDashboardConcurrentPrompts(t *testing.T) {
+	d := newDashboard(t)
+
+	// Three calls queue up at once, one of them with a short timeout
+	type answer struct {
+		prompt   string
+		response string
+		err      error
+	}
+	answers := make(chan answer, 3)
+	ctxs := map[string]context.Context{"Lint?": context.Background(), "Test?": context.Background()}
+	expiring, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctxs["Deploy?"] = expiring
+	for prompt, ctx := range ctxs {
+		go func() {
+			response, err := d.GetInput(ctx, server.NewPromptRequest(prompt, "web"))
+			answers <- answer{prompt, response, err}
+		}()
+	}
+	body, links := waitListed(t, d, 3)
+
+	// Each is a card with the prompt's own page in it
+	pages := map[string]string{}
+	for _, link := range links {
+		if !strings.Contains(body, `<iframe class="entry-frame" src="`+link+`"`) {
+			t.Errorf("Expected a frame for %s, got:\n%s", link, body)
+		}
+		page := dashboardGet(d, link).Body.String()
+		for prompt := range ctxs {
+			if strings.Contains(page, prompt) {
+				pages[prompt] = link
+			}
+		}
+	}
+	if len(pages) != 3 {
+		t.Fatalf("Expected a page per prompt, got %v", pages)
+	}
+
+	// answered expects exactly the given prompt to be resolved next
+	answered := func(prompt string) answer {
+		t.Helper()
+		select {
+		case got := <-answers:
+			if got.prompt != prompt {
+				t.Fatalf("Expected %s to be resolved, got %+v", prompt, got)
+			}
+			return got
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected %s to be resolved", prompt)
+		}
+		return answer{}
+	}
+	submit := func(prompt, response string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, postForm(t, d, pages[prompt]+"submit", url.Values{"response": {response}}))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected the answer accepted, got %d", prompt, rec.Code)
+		}
+	}
+
+	// Answering the newest leaves the others waiting
+	submit("Test?", "go test ./...")
+	if got := answered("Test?"); got.err != nil || got.response != "go test ./..." {
+		t.Errorf("Expected the test answer, got %+v", got)
+	}
+	if body, _ := waitListed(t, d, 2); strings.Contains(body, pages["Test?"]) {
+		t.Errorf("Expected the answered prompt gone, got:\n%s", body)
+	}
+
+	// The timeout ends only its own prompt
+	if got := answered("Deploy?"); got.err == nil {
+		t.Errorf("Expected the deploy prompt to expire, got %+v", got)
+	}
+	waitListed(t, d, 1)
+	select {
+	case got := <-answers:
+		t.Fatalf("Expected the lint prompt still pending, got %+v", got)
+	default:
+	}
+
+	submit("Lint?", "make lint")
+	if got := answered("Lint?"); got.err != nil || got.response != "make lint" {
+		t.Errorf("Expected the lint answer, got %+v", got)
+	}
+	waitListed(t, d, 0)
+}
+
+// sseEvent is one event of a server-sent event stream.
+type sseEvent struct {
+	id, event string
+	data      server.DashboardEvent
+}
+
+// readEvents returns a function reading the next event off stream, failing
+// the test when it ends.
+func readEvents(t *testing.T, stream *bufio.Scanner) func() sseEvent {
+	return func() sseEvent {
+		t.Helper()
+		var ev sseEvent
+		for stream.Scan() {
+			line := stream.Text()
+			switch {
+			case line == "" && ev.event != "":
+				return ev
+			case strings.HasPrefix(line, "id: "):
+				ev.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev.data)
+			}
+		}
+		t.Fatalf("Event stream ended: %v", stream.Err())
+		return ev
+	}
+}
+
+// openEvents connects to the dashboard's event stream with lastID, if
+// given, as the browser reconnecting.
+func openEvents(t *testing.T, ts *httptest.Server, d *server.Dashboard, lastID string) (*http.Response, func() sseEvent) {
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+d.Path()+"events", nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+	return resp, readEvents(t, bufio.NewScanner(resp.Body))
+}
+
+func TestDashboardEvents(t *testing.T) {
+	d := newDashboard(t)
+	ts := httptest.NewServer(d)
+	defer ts.Close()
+
+	resp, next := openEvents(t, ts, d, "")
+	defer resp.Body.Close()
+
+	// The prompt appears, then expires
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		req := server.NewPromptRequest("Name?", "web")
+		req.Title = "Signup"
+		_, err := d.GetInput(ctx, req)
+		done <- err
+	}()
+	created := next()
+	if created.event != server.EventPromptCreated || created.id != "1" || created.data.Pending != 1 || created.data.Entry.Title != "Signup" || created.data.Entry.Deadline == 0 {
+		t.Errorf("Expected an event for the new prompt, got %+v", created)
+	}
+	cancel()
+	resolved := next()
+	if resolved.event != server.EventPromptResolved || resolved.id != "2" || resolved.data.Pending != 0 || resolved.data.Entry.Path != created.data.Entry.Path || resolved.data.Entry.Outcome != server.OutcomeError {
+		t.Errorf("Expected an event for the expired prompt, got %+v", resolved)
+	}
+	if err := <-done; err == nil {
+		t.Errorf("Expected the expired prompt to fail")
+	}
+
+	// It moves to the resolved list
+	body := dashboardGet(d, d.Path()).Body.String()
+	if !strings.Contains(body, `<h2 id="resolved-title">Resolved</h2>`) || !strings.Contains(body, `<div class="entry resolved outcome-error"><div class="entry-title">Signup</div>`) || strings.Contains(body, created.data.Entry.Path) {
+		t.Errorf("Expected the prompt among the resolved, got:\n%s", body)
+	}
+}
+
+func TestDashboardEventsCatchUp(t *testing.T) {
+	d := newDashboard(t)
+	ts := httptest.NewServer(d)
+	defer ts.Close()
+
+	// Two prompts come and go while the page is away
+	for _, prompt := range []string{"Lint?", "Test?"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			waitListed(t, d, 1)
+			cancel()
+		}()
+		d.GetInput(ctx, server.NewPromptRequest(prompt, "web"))
+	}
+
+	// Reconnecting after the first event replays the three others
+	resp, next := openEvents(t, ts, d, "1")
+	defer resp.Body.Close()
+	for _, want := range []string{"2", "3", "4"} {
+		if ev := next(); ev.id != want {
+			t.Fatalf("Expected event %s replayed, got %+v", want, ev)
+		}
+	}
+
+	// An ID the server never gave, as after a restart, has the page load
+	// the list again
+	resp, next = openEvents(t, ts, d, "99")
+	defer resp.Body.Close()
+	if ev := next(); ev.event != "reset" {
+		t.Errorf("Expected a reset, got %+v", ev)
+	}
+
+	// The index picks up where it rendered
+	if body := dashboardGet(d, d.Path()).Body.String(); !regexp.MustCompile(`'\?since=' \+\s*4\s*\)`).MatchString(body) {
+		t.Errorf("Expected the stream to start after event 4, got:\n%s", body)
+	}
+}
+
+func TestDashboardConfig(t *testing.T) {
+	tests := []struct {
+		cfg       server.Config
+		dashboard bool
+		valid     bool
+	}{
+		{server.Config{}, false, true},
+		{server.Config{WebPersistent: true}, true, true},
+		{server.Config{WebPort: 8080}, true, true},
+		{server.Config{WebPort: -1}, true, false},
+		{server.Config{WebPort: 70000}, true, false},
+	}
+	for _, tt := range tests {
+		if tt.cfg.Dashboard() != tt.dashboard {
+			t.Errorf("%+v: expected dashboard %v", tt.cfg, tt.dashboard)
+		}
+		if err := tt.cfg.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: expected valid %v, got %v", tt.cfg, tt.valid, err)
+		}
+	}
+}
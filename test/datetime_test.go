@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func dateTimeCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_datetime",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDateTimeParse(t *testing.T) {
+	zone := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, zone)
+
+	tests := []struct {
+		mode  string
+		input string
+		want  string
+	}{
+		{server.ModeDateTime, "2026-10-20T08:00:00Z", "2026-10-20T10:00:00+02:00"},
+		{server.ModeDateTime, "2026-10-20 15:04", "2026-10-20T15:04:00+02:00"},
+		{server.ModeDateTime, "2026-10-20T15:04", "2026-10-20T15:04:00+02:00"},
+		{server.ModeDateTime, "tomorrow 3pm", "2026-10-17T15:00:00+02:00"},
+		{server.ModeDateTime, "Tomorrow 3:30 PM", "2026-10-17T15:30:00+02:00"},
+		{server.ModeDateTime, "2026-12-01 9am", "2026-12-01T09:00:00+02:00"},
+		{server.ModeDateTime, "17:45", "2026-10-16T17:45:00+02:00"},
+		{server.ModeDateTime, "now", "2026-10-16T09:30:00+02:00"},
+		{server.ModeDate, "2026-10-20", "2026-10-20T00:00:00+02:00"},
+		{server.ModeDate, "yesterday", "2026-10-15T00:00:00+02:00"},
+		{server.ModeTime, "3pm", "2026-10-16T15:00:00+02:00"},
+		{server.ModeTime, "08:15:30", "2026-10-16T08:15:30+02:00"},
+	}
+	for _, tt := range tests {
+		opts := server.DateTimeOptions{Mode: tt.mode, Location: zone}
+		got, err := opts.Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.mode, tt.input, err)
+			continue
+		}
+		if got.Format(time.RFC3339) != tt.want {
+			t.Errorf("%s %q: expected %s, got %s", tt.mode, tt.input, tt.want, got.Format(time.RFC3339))
+		}
+	}
+
+	rejected := []struct {
+		mode  string
+		input string
+		err   string
+	}{
+		{server.ModeDateTime, "tomorrow", "Please include a time"},
+		{server.ModeDate, "3pm", "Please enter a date"},
+		{server.ModeTime, "today", "Please include a time"},
+		{server.ModeDateTime, "next week", "Could not read"},
+		{server.ModeDate, "20/10/2026", "Could not read"},
+		{server.ModeDateTime, "tomorrow teatime", "Could not read"},
+	}
+	for _, tt := range rejected {
+		opts := server.DateTimeOptions{Mode: tt.mode, Location: zone}
+		if _, err := opts.Parse(tt.input, now); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s %q: expected %q, got %v", tt.mode, tt.input, tt.err, err)
+		}
+	}
+}
+
+func TestUserDateTimeResult(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{responses: []string{"2026-09-30", "2026-10-20"}})
+
+	messages := parseMessages(t, runServer(t, srv, dateTimeCall(t, map[string]interface{}{
+		"prompt":   "When should the job run?",
+		"mode":     "date",
+		"min":      "2026-10-01",
+		"max":      "2026-10-31",
+		"timezone": "UTC",
+	})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["value"] != "2026-10-20" || structured["raw"] != "2026-10-20" || structured["mode"] != "date" || structured["timezone"] != "UTC" {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+}
+
+func TestUserDateTimeOutOfRange(t *testing.T) {
+	min := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)
+	prompt := server.NewDateTimePrompt("When?", "tty", server.DateTimeOptions{Mode: server.ModeDate, Location: time.UTC, Min: &min, Max: &max})
+
+	if _, err := prompt.Validate("2026-11-01"); err == nil || err.Error() != "Please enter a date between 2026-10-01 and 2026-10-31" {
+		t.Errorf("Expected the window in the error, got %v", err)
+	}
+	if _, err := prompt.Validate("2026-10-31"); err != nil {
+		t.Errorf("Expected the bounds to be inclusive, got %v", err)
+	}
+}
+
+func TestUserDateTimeInvalidArguments(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"mode": "week"},
+		{"timezone": "Mars/Olympus"},
+		{"min": "soon"},
+		{"mode": "date", "min": "2026-10-31", "max": "2026-10-01"},
+	}
+
+	for _, args := range tests {
+		args["prompt"] = "When?"
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "now"})
+
+		messages := parseMessages(t, runServer(t, srv, dateTimeCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected an invalid params error, got %v", args, errObj)
+		}
+	}
+}
+
+func TestUserDateTimeTTYConfirmsInterpretation(t *testing.T) {
+	// The first reading is rejected, the second accepted; canonical input
+	// needs no confirmation
+	term := newFakeTerminal("tomorrow 3pm\nn\ntomorrow 4pm\n\n")
+	prompt := server.NewDateTimePrompt("When?", "tty", server.DateTimeOptions{Mode: server.ModeDateTime, Location: time.UTC})
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value server.DateTimeAnswer
+	if err := json.Unmarshal([]byte(answer), &value); err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	if value.Value != tomorrow+"T16:00:00Z" || value.Raw != "tomorrow 4pm" {
+		t.Errorf("Unexpected answer %+v", value)
+	}
+
+	out := term.output.String()
+	if strings.Count(out, "Correct? [Y/n]") != 2 || !strings.Contains(out, "at 15:00 UTC") || !strings.Contains(out, "times in UTC") {
+		t.Errorf("Expected both readings to be confirmed, got %q", out)
+	}
+
+	term = newFakeTerminal("2026-10-20\n")
+	prompt = server.NewDateTimePrompt("When?", "tty", server.DateTimeOptions{Mode: server.ModeDate, Location: time.UTC})
+	if _, err := server.Ask(context.Background(), ttyProvider(term), prompt); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(term.output.String(), "Correct?") {
+		t.Errorf("Expected no confirmation for canonical input, got %q", term.output.String())
+	}
+}
+
+func TestUserDateTimeWeb(t *testing.T) {
+	min := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	handler := server.NewWebInputHandler(server.NewDateTimePrompt("When?", "web", server.DateTimeOptions{Mode: server.ModeDateTime, Location: time.UTC, Min: &min}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `type="datetime-local" name="response" value="" min="2026-10-16T09:00"`) || !strings.Contains(body, "Times are in UTC") {
+		t.Errorf("Expected a native datetime input with its bound, got %s", body)
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestDeclineWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Which database should be dropped?", "web"))
+	page := pageGet(handler, "/").Body.String()
+	if !strings.Contains(page, `formaction="/decline"`) || !strings.Contains(page, "Decline to answer") {
+		t.Fatalf("Expected a Decline button on the form, got:\n%s", page)
+	}
+
+	// Without the form token declining is refused like any submission
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/decline", strings.NewReader("decline=yes")))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a decline without the token refused, got %d", rec.Code)
+	}
+
+	// The button asks for a reason before anything is decided
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/decline", url.Values{"response": {""}}))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `name="reason"`) || !strings.Contains(body, `<a href="/">Back to the question</a>`) {
+		t.Fatalf("Expected the reason page, got %d:\n%s", rec.Code, body)
+	}
+	if !strings.Contains(pageGet(handler, "/").Body.String(), "Which database") {
+		t.Fatal("Expected the prompt still open")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/decline", url.Values{"decline": {"yes"}, "reason": {"  Ask the DBA\r\n"}}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "You declined to answer") {
+		t.Errorf("Expected the decline confirmed, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	_, err := handler.Wait(context.Background())
+	var declined *server.DeclinedError
+	if !errors.As(err, &declined) || declined.Reason != "Ask the DBA" {
+		t.Fatalf("Expected a decline with the reason, got %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"orders"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "You declined to answer") {
+		t.Errorf("Expected a late answer to be gone, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDeclineTTY(t *testing.T) {
+	tests := []struct {
+		input  string
+		reason string
+	}{
+		{"\x1b not my call\n", "not my call"},
+		{"\x1b\n", ""},
+		// Ctrl+D before an answer
+		{"", ""},
+	}
+	for _, tt := range tests {
+		req := server.NewPromptRequest("Which database should be dropped?", "tty")
+		req.RequireAnswer()
+		_, err := ttyProvider(newFakeTerminal(tt.input)).GetInput(context.Background(), req)
+		var declined *server.DeclinedError
+		if !errors.As(err, &declined) || declined.Reason != tt.reason {
+			t.Errorf("%q: expected a decline with reason %q, got %v", tt.input, tt.reason, err)
+		}
+	}
+}
+
+func TestDeclineRace(t *testing.T) {
+	// Declining in the terminal settles the prompt in the browser too
+	provider := server.NewRaceProvider(ttyProvider(newFakeTerminal("\x1b\n")), &fakeProvider{response: "orders", delay: time.Hour})
+	_, err := provider.GetInput(context.Background(), server.NewPromptRequest("Which database should be dropped?", "both"))
+	var declined *server.DeclinedError
+	if !errors.As(err, &declined) {
+		t.Errorf("Expected the decline to win, got %v", err)
+	}
+}
+
+func TestDeclineResult(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Which database should be dropped?"}}}`
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(newFakeTerminal("\x1b Ask the DBA\n")))
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	structured := result["structuredContent"].(map[string]interface{})
+	if result["isError"] != false || structured["declined"] != true || structured["reason"] != "Ask the DBA" {
+		t.Errorf("Expected a declined result with the reason, got %v", result)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "Declined by the user: Ask the DBA" {
+		t.Errorf("Unexpected text %q", text)
+	}
+	if entries := srv.History().Entries(); len(entries) != 1 || entries[0].Outcome != server.OutcomeDeclined {
+		t.Errorf("Expected the decline in the history, got %+v", entries)
+	}
+}
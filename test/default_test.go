@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestUserInputDefault(t *testing.T) {
+	tests := []struct {
+		response string
+		answer   string
+		used     bool
+	}{
+		{"", "main", true},
+		{"   ", "main", true},
+		{"develop", "develop", false},
+		{"main", "main", true},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Branch?","default":"main"}}}`
+
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: tt.response})
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+		if text != tt.answer {
+			t.Errorf("Response %q: expected %q, got %v", tt.response, tt.answer, text)
+		}
+		meta := result["_meta"].(map[string]interface{})
+		if meta["defaultUsed"] != tt.used {
+			t.Errorf("Response %q: expected defaultUsed %v, got %v", tt.response, tt.used, meta["defaultUsed"])
+		}
+	}
+}
+
+func TestUserInputWithoutDefaultOmitsDefaultUsed(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Branch?"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "main"})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	meta := findResponse(t, messages, 1)["result"].(map[string]interface{})["_meta"].(map[string]interface{})
+	if _, ok := meta["defaultUsed"]; ok {
+		t.Errorf("Expected no defaultUsed without a default, got %v", meta)
+	}
+}
+
+func TestDefaultTTYLabel(t *testing.T) {
+	term := newFakeTerminal("\n")
+	req := server.NewPromptRequest("Branch?", "tty")
+	req.Default = "main"
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), req)
+	if err != nil || answer != "main" {
+		t.Errorf("Expected main, got %q (%v)", answer, err)
+	}
+	if !strings.Contains(term.output.String(), "Response [main]: ") {
+		t.Errorf("Expected the default in brackets, got:\n%s", term.output.String())
+	}
+}
+
+func TestDefaultWebPrefilled(t *testing.T) {
+	req := server.NewPromptRequest("Branch?", "web")
+	req.Default = "main"
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `name="response" value="main"`) || strings.Contains(body, "autofocus required") {
+		t.Errorf("Expected the input to be pre-filled and optional, got:\n%s", body)
+	}
+
+	// Clearing the field still submits, and the default applies
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {""}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected an empty submission to be accepted, got %d", rec.Code)
+	}
+	answer, err := server.Ask(context.Background(), handlerProvider{handler}, req)
+	if err != nil || answer != "main" {
+		t.Errorf("Expected main, got %q (%v)", answer, err)
+	}
+}
+
+// handlerProvider waits for an answer submitted to a web handler.
+type handlerProvider struct {
+	handler *server.WebInputHandler
+}
+
+func (p handlerProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	return p.handler.Wait(ctx)
+}
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+	"prompt-mcp/units"
+)
+
+func durationPrompt(method string, min, max *float64, opts server.DurationOptions) *server.PromptRequest {
+	req := server.NewPromptRequest("How long?", method)
+	req.MakeNumeric(server.NumberOptions{Units: units.Duration, Minimum: min, Maximum: max, Duration: &opts})
+	return req
+}
+
+func TestDurationInput(t *testing.T) {
+	tests := []struct {
+		args      string
+		responses []string
+		raw       string
+		value     float64
+		unit      string
+	}{
+		{``, []string{"90s"}, "90s", 90, "seconds"},
+		{``, []string{"0"}, "0", 0, "seconds"},
+		{``, []string{"-5m", "5m"}, "5m", 300, "seconds"},
+		{`,"default_unit":"m"`, []string{"1.5"}, "1.5", 90, "seconds"},
+		{`,"result_unit":"m"`, []string{"1h30m"}, "1h30m", 90, "minutes"},
+		{`,"result_unit":"ms"`, []string{".5s"}, ".5s", 500, "milliseconds"},
+		{`,"result_unit":"h","default_unit":"d"`, []string{"0.25"}, "0.25", 6, "hours"},
+		{`,"minimum":"30s","maximum":"24h"`, []string{"10s", "25h", "1.5h"}, "1.5h", 5400, "seconds"},
+		{`,"minimum":2,"default_unit":"m"`, []string{"90s", "2"}, "2", 120, "seconds"},
+		{`,"default":"2 hours"`, []string{""}, "2 hours", 7200, "seconds"},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How long?","type":"duration"` + tt.args + `}}}`
+
+		provider := &fakeProvider{responses: tt.responses}
+		srv := &server.MCPServer{}
+		srv.SetConfig(server.Config{MaxAttempts: 5})
+		srv.SetInputProvider("tty", provider)
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: expected a result, got %v", tt.args, findResponse(t, messages, 1))
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.raw {
+			t.Errorf("%s %q: expected text %q, got %v", tt.args, tt.responses, tt.raw, text)
+		}
+		structured, _ := result["structuredContent"].(map[string]interface{})
+		if structured["value"] != tt.value || structured["unit"] != tt.unit || structured["raw"] != tt.raw {
+			t.Errorf("%s %q: unexpected structuredContent %v", tt.args, tt.responses, structured)
+		}
+		if int(provider.attempts) != len(tt.responses) {
+			t.Errorf("%s %q: expected %d attempts, got %d", tt.args, tt.responses, len(tt.responses), provider.attempts)
+		}
+	}
+}
+
+func TestDurationValidation(t *testing.T) {
+	min, max := 30.0, 86400.0
+	bounded := durationPrompt("tty", &min, &max, server.DurationOptions{DefaultUnit: "s", ResultUnit: "s"})
+	atLeast := durationPrompt("tty", &min, nil, server.DurationOptions{DefaultUnit: "m", ResultUnit: "s"})
+	atMost := durationPrompt("tty", nil, &max, server.DurationOptions{DefaultUnit: "s", ResultUnit: "s"})
+
+	tests := []struct {
+		req      *server.PromptRequest
+		response string
+		err      string
+	}{
+		{bounded, "0", "Please enter a duration between 30s and 24h"},
+		{bounded, "25h", "Please enter a duration between 30s and 24h"},
+		{bounded, "-1m", `"-1m" is negative; durations can't be`},
+		{bounded, "-30", `"-30" is negative; durations can't be`},
+		{bounded, "soon", "try e.g. 90s, 5m, 1h30m"},
+		{bounded, "30", ""},
+		{bounded, "24h", ""},
+		{bounded, "0.5m", ""},
+		{bounded, "1.5e3", ""},
+		{atLeast, "0.25", "Please enter a duration of at least 30s"},
+		{atLeast, "0.5", ""},
+		{atMost, "1.5d", "Please enter a duration of at most 24h"},
+		{atMost, "-0", ""},
+	}
+	for _, tt := range tests {
+		_, err := tt.req.Validate(tt.response)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%q: expected it to be accepted, got %v", tt.response, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%q: expected %q, got %v", tt.response, tt.err, err)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for seconds, want := range map[float64]string{0: "0s", 30: "30s", 90: "1m30s", 3600: "1h", 5400: "1h30m", 86400: "24h", 3630: "1h0m30s", 0.5: "500ms"} {
+		if got := units.FormatDuration(seconds); got != want {
+			t.Errorf("%v: expected %q, got %q", seconds, want, got)
+		}
+	}
+}
+
+func TestDurationTTY(t *testing.T) {
+	min := 60.0
+	term := newFakeTerminal("0.5\n2\n")
+	req := durationPrompt("tty", &min, nil, server.DurationOptions{DefaultUnit: "m", ResultUnit: "s"})
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != "2" {
+		t.Fatalf("Expected 2, got %q (%v)", answer, err)
+	}
+	output := term.output.String()
+	if !strings.Contains(output, "Duration (e.g. 90s, 5m, 1h30m, 2 hours, 1.5d) (plain numbers are minutes) (>= 1m): ") {
+		t.Errorf("Expected the formats, unit and bound in the label, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Please enter a duration of at least 1m") {
+		t.Errorf("Expected the window on re-prompt, got:\n%s", output)
+	}
+}
+
+func TestDurationWeb(t *testing.T) {
+	min := 30.0
+	req := durationPrompt("web", &min, nil, server.DurationOptions{DefaultUnit: "m", ResultUnit: "s"})
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`<input type="number" name="response" value="" step="any" min="0"`, `<select name="unit" aria-label="Unit">`, `<option value="m" selected>minutes</option>`, `<option value="ms">milliseconds</option>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q, got:\n%s", want, body)
+		}
+	}
+
+	// A rejected answer is shown again with its unit
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"10"}, "unit": {"s"}}))
+	body = rec.Body.String()
+	if rec.Code != http.StatusBadRequest || !strings.Contains(body, "Please enter a duration of at least 30s") || !strings.Contains(body, `value="10"`) || !strings.Contains(body, `<option value="s" selected>`) {
+		t.Errorf("Expected the window and the rejected answer, got %d:\n%s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"1.5"}, "unit": {"h"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the duration to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "1.5h" {
+		t.Errorf("Expected 1.5h, got %q (%v)", answer, err)
+	}
+}
+
+func TestDurationInvalidArguments(t *testing.T) {
+	tests := []string{
+		`"type":"duration","default_unit":"y"`,
+		`"type":"duration","result_unit":5`,
+		`"type":"number","default_unit":"m"`,
+		`"result_unit":"m"`,
+		`"type":"duration","units":"duration"`,
+		`"type":"duration","minimum":"soon"`,
+		`"type":"duration","minimum":"-5s"`,
+		`"type":"duration","maximum":true`,
+		`"type":"duration","minimum":"1h","maximum":"30m"`,
+		`"type":"duration","default":"forever"`,
+		`"type":"duration","pattern":"\\d+"`,
+		`"type":"number","minimum":"30s"`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How long?",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
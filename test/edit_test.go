@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// fakeEditor installs a shell script as $EDITOR. It records the path it was
+// given in a file, then runs script with the path as $1.
+func fakeEditor(t *testing.T, script string) (pathLog string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	pathLog = filepath.Join(dir, "path")
+	editor := filepath.Join(dir, "editor")
+	body := "#!/bin/sh\nprintf '%s' \"$1\" > '" + pathLog + "'\n" + script + "\n"
+	if err := os.WriteFile(editor, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+	return pathLog
+}
+
+func editCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_edit",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserEditReturnsEditedFile(t *testing.T) {
+	pathLog := fakeEditor(t, `printf 'fix: handle empty input\n' > "$1"`)
+
+	term := newFakeTerminal("")
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	messages := parseMessages(t, runServer(t, srv, editCall(t, map[string]interface{}{
+		"content":        "fix: stuff",
+		"file_extension": ".gitcommit",
+	})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if text != "fix: handle empty input\n" {
+		t.Errorf("Expected the edited file, got %q", text)
+	}
+	if unchanged := result["structuredContent"].(map[string]interface{})["unchanged"]; unchanged != false {
+		t.Errorf("Expected unchanged false, got %v", unchanged)
+	}
+
+	path, err := os.ReadFile(pathLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(path), ".gitcommit") {
+		t.Errorf("Expected the temp file to keep the extension, got %s", path)
+	}
+	if _, err := os.Stat(string(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the temp file to be removed, got %v", err)
+	}
+	if !strings.Contains(term.output.String(), "Please edit the following") {
+		t.Errorf("Expected the prompt on the terminal, got %q", term.output.String())
+	}
+}
+
+func TestUserEditUnchanged(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"quit without saving", "true"},
+		{"saved with a final newline", `printf 'key: value\n' > "$1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeEditor(t, tt.script)
+
+			srv := &server.MCPServer{}
+			srv.SetInputProvider("tty", ttyProvider(newFakeTerminal("")))
+
+			messages := parseMessages(t, runServer(t, srv, editCall(t, map[string]interface{}{"content": "key: value"})).String())
+			result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+			if text != "key: value" {
+				t.Errorf("Expected the original content, got %q", text)
+			}
+			if unchanged := result["structuredContent"].(map[string]interface{})["unchanged"]; unchanged != true {
+				t.Errorf("Expected unchanged true, got %v", unchanged)
+			}
+		})
+	}
+}
+
+func TestUserEditEditorFailure(t *testing.T) {
+	pathLog := fakeEditor(t, "exit 1")
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(newFakeTerminal("")))
+
+	messages := parseMessages(t, runServer(t, srv, editCall(t, map[string]interface{}{"content": "x"})).String())
+	response := findResponse(t, messages, 1)
+	errObj, ok := response["error"].(map[string]interface{})
+	if !ok || !strings.Contains(errObj["message"].(string), "failed") {
+		t.Fatalf("Expected an editor failure, got %v", response)
+	}
+
+	path, err := os.ReadFile(pathLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(string(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected the temp file to be removed after a failure, got %v", err)
+	}
+}
+
+func TestUserEditNoEditor(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "no-such-editor-for-prompt-mcp")
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(newFakeTerminal("")))
+
+	messages := parseMessages(t, runServer(t, srv, editCall(t, map[string]interface{}{"content": "x"})).String())
+	errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || !strings.Contains(errObj["message"].(string), "no-such-editor-for-prompt-mcp") {
+		t.Errorf("Expected an error naming the missing editor, got %v", errObj)
+	}
+}
+
+func TestUserEditNoTerminal(t *testing.T) {
+	fakeEditor(t, "true")
+
+	provider := server.NewTTYProvider(func() (io.ReadWriteCloser, error) {
+		return nil, errors.New("failed to open /dev/tty: no such device")
+	})
+	_, err := server.Ask(context.Background(), provider, server.NewEditPrompt("Edit", "tty", "x", ""))
+	if err == nil || !strings.Contains(err.Error(), "no controlling terminal") {
+		t.Errorf("Expected a missing terminal error, got %v", err)
+	}
+}
+
+func TestUserEditInvalidExtension(t *testing.T) {
+	for _, ext := range []string{"../x", "a/b", "md "} {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "x"})
+
+		messages := parseMessages(t, runServer(t, srv, editCall(t, map[string]interface{}{"content": "x", "file_extension": ext})).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%q: expected an invalid params error, got %v", ext, errObj)
+		}
+	}
+}
+
+func TestUserEditWebTextarea(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewEditPrompt("Edit the config", "web", "a: <1>\n", "yaml"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "<textarea") || !strings.Contains(body, "a: &lt;1&gt;") {
+		t.Errorf("Expected the content pre-filled in a textarea, got %s", body)
+	}
+	if strings.Contains(body, " required>") {
+		t.Error("Expected clearing the content to be allowed")
+	}
+}
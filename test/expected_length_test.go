@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func expectedLengthCall(prompt string, args map[string]interface{}) string {
+	args["prompt"] = prompt
+	args["method"] = "web"
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "user_input", "arguments": args},
+	})
+	return string(data)
+}
+
+func renderInput(t *testing.T, req *server.PromptRequest) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(req).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Body.String()
+}
+
+func TestExpectedLengthLayouts(t *testing.T) {
+	req := server.NewPromptRequest("Name?", "web")
+	page := renderInput(t, req)
+	if !strings.Contains(page, `<input type="text" name="response"`) || strings.Contains(page, "<textarea") {
+		t.Errorf("Expected a one-line input, got:\n%s", page)
+	}
+
+	req.LongAnswer = true
+	page = renderInput(t, req)
+	for _, want := range []string{`<textarea name="response" id="long-answer" rows="12"`, "Ctrl+Enter to submit"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %q on the page, got:\n%s", want, page)
+		}
+	}
+	if js := webAsset(t, "input.js"); !strings.Contains(js, "e.ctrlKey || e.metaKey") {
+		t.Errorf("Expected Ctrl+Enter to submit, got:\n%s", js)
+	}
+	if css := webAsset(t, "input.css"); !strings.Contains(css, "textarea { resize: vertical; }") {
+		t.Errorf("Expected the textarea to resize vertically, got:\n%s", css)
+	}
+	if strings.Contains(page, `<input type="text" name="response"`) {
+		t.Errorf("Expected the textarea instead of the input, got:\n%s", page)
+	}
+}
+
+func TestExpectedLengthArgument(t *testing.T) {
+	tests := []struct {
+		prompt string
+		args   map[string]interface{}
+		long   bool
+	}{
+		{"Name?", map[string]interface{}{}, false},
+		{"Name?", map[string]interface{}{"expected_length": "long"}, true},
+		{"Why did the deploy fail? Please explain.", map[string]interface{}{}, true},
+		{"Does this look right?\n```go\nx := 1\n```", map[string]interface{}{}, true},
+		{"Please explain", map[string]interface{}{"expected_length": "short"}, false},
+		{"Please explain", map[string]interface{}{"secret": true}, false},
+		{"Please explain", map[string]interface{}{"suggestions": []string{"a", "b"}}, false},
+		{"Please explain", map[string]interface{}{"type": "integer"}, false},
+		{"Explain the plan in one word", map[string]interface{}{}, false},
+	}
+
+	for _, tt := range tests {
+		provider := &fakeProvider{response: "7"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", provider)
+		resp := findResponse(t, parseMessages(t, runServer(t, srv, expectedLengthCall(tt.prompt, tt.args)).String()), 1)
+		if resp["error"] != nil {
+			t.Fatalf("%q %v: %v", tt.prompt, tt.args, resp["error"])
+		}
+		if provider.lastReq.LongAnswer != tt.long || provider.lastReq.Multiline {
+			t.Errorf("%q %v: expected a long answer %v, got %v (multiline %v)", tt.prompt, tt.args, tt.long, provider.lastReq.LongAnswer, provider.lastReq.Multiline)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"expected_length": "medium"},
+		{"expected_length": "long", "secret": true},
+		{"expected_length": "long", "type": "number"},
+		{"expected_length": "long", "suggestions": []string{"a"}},
+		{"expected_length": "long", "confirmation_phrase": "go"},
+	} {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", &fakeProvider{response: "x"})
+		if resp := findResponse(t, parseMessages(t, runServer(t, srv, expectedLengthCall("Why?", args)).String()), 1); resp["error"] == nil {
+			t.Errorf("%v: expected an invalid params error, got %v", args, resp)
+		}
+	}
+}
+
+func TestExpectedLengthKeepsNewlines(t *testing.T) {
+	req := server.NewPromptRequest("What changed?", "web")
+	req.LongAnswer = true
+	req.RequireAnswer()
+	handler := server.NewWebInputHandler(req)
+
+	// Whitespace alone is no answer
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {" \r\n\r\n "}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Response cannot be empty") {
+		t.Errorf("Expected a blank answer to be refused, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"  first\r\n\r\nsecond\r\n"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answer to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "  first\n\nsecond\n" {
+		t.Errorf("Expected the line breaks kept, got %q (%v)", answer, err)
+	}
+
+	// Without validation, the handler's own check trims too
+	handler = server.NewWebInputHandler(server.NewPromptRequest("What changed?", "web"))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"  \n"}}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a blank answer to be refused, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// fileTree creates root/docs/readme.md and root/src, plus a symlink
+// root/escape pointing outside root, returning root.
+func fileTree(t *testing.T) string {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	for _, dir := range []string{"docs", "src"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "readme.md"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(base, "outside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(base, "outside"), filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func fileSelectCall(args map[string]interface{}) string {
+	args["prompt"] = "Pick a path"
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "user_file_select", "arguments": args},
+	})
+	return string(data)
+}
+
+func TestFileSelectResolvesPaths(t *testing.T) {
+	root := fileTree(t)
+
+	tests := []struct {
+		args     map[string]interface{}
+		response string
+		path     string
+	}{
+		{map[string]interface{}{}, "docs/../docs/readme.md", filepath.Join(root, "docs", "readme.md")},
+		{map[string]interface{}{}, " src/ ", filepath.Join(root, "src")},
+		{map[string]interface{}{}, "new/file.txt", filepath.Join(root, "new", "file.txt")},
+		{map[string]interface{}{}, filepath.Join(root, "docs"), filepath.Join(root, "docs")},
+	}
+
+	for _, tt := range tests {
+		tt.args["start_dir"] = root
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: tt.response})
+
+		messages := parseMessages(t, runServer(t, srv, fileSelectCall(tt.args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.path {
+			t.Errorf("%q: expected %s, got %v", tt.response, tt.path, text)
+		}
+	}
+}
+
+func TestFileSelectRejections(t *testing.T) {
+	root := fileTree(t)
+
+	tests := []struct {
+		args     map[string]interface{}
+		response string
+		reason   string
+	}{
+		{map[string]interface{}{"must_exist": true}, "missing.txt", "does not exist"},
+		{map[string]interface{}{"directories_only": true}, "docs/readme.md", "is not a directory"},
+		{map[string]interface{}{"restrict_to_start_dir": true}, "../outside", "is outside"},
+		{map[string]interface{}{"restrict_to_start_dir": true}, "/etc", "is outside"},
+		{map[string]interface{}{"restrict_to_start_dir": true}, "escape/secret.txt", "is outside"},
+	}
+
+	for _, tt := range tests {
+		tt.args["start_dir"] = root
+		srv := &server.MCPServer{}
+		srv.SetConfig(server.Config{MaxAttempts: 1})
+		srv.SetInputProvider("tty", &fakeProvider{response: tt.response})
+
+		messages := parseMessages(t, runServer(t, srv, fileSelectCall(tt.args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+		if result["isError"] != true || !strings.Contains(text, tt.reason) {
+			t.Errorf("%q: expected rejection containing %q, got %q", tt.response, tt.reason, text)
+		}
+	}
+}
+
+func TestFileSelectInvalidStartDir(t *testing.T) {
+	input := fileSelectCall(map[string]interface{}{"start_dir": filepath.Join(t.TempDir(), "nope")})
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602 for a missing start_dir, got %v", errorObj)
+	}
+}
+
+func TestFileSelectTTY(t *testing.T) {
+	root := fileTree(t)
+	term := newFakeTerminal("docs/readme.md\ndocs\n")
+	req, err := server.NewFilePrompt("Pick a folder", "tty", server.FileOptions{StartDir: root, DirectoriesOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != filepath.Join(root, "docs") {
+		t.Fatalf("Expected the docs directory, got %q (%v)", answer, err)
+	}
+	output := term.output.String()
+	if !strings.Contains(output, "Relative paths start from "+root) || !strings.Contains(output, "is not a directory") {
+		t.Errorf("Unexpected terminal output:\n%s", output)
+	}
+}
+
+func TestFileSelectWebBrowser(t *testing.T) {
+	root := fileTree(t)
+	req, err := server.NewFilePrompt("Pick a file", "web", server.FileOptions{StartDir: root, Restrict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, ">docs/</a>") || !strings.Contains(body, ">src/</a>") || strings.Contains(body, `href="/?dir=`+url.QueryEscape(filepath.Dir(root))) {
+		t.Errorf("Expected a listing of the start directory without a way up, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?dir="+url.QueryEscape(filepath.Join(root, "docs")), nil))
+	if !strings.Contains(rec.Body.String(), "readme.md") {
+		t.Errorf("Expected to browse into docs, got:\n%s", rec.Body.String())
+	}
+
+	// Browsing outside the root shows the root instead
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?dir=/etc", nil))
+	if strings.Contains(rec.Body.String(), "passwd") || !strings.Contains(rec.Body.String(), ">docs/</a>") {
+		t.Errorf("Expected browsing to stay within the root, got:\n%s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"/etc/passwd"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "is outside") {
+		t.Errorf("Expected a path outside the root to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {""}, "pick": {filepath.Join(root, "docs", "readme.md")}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected a picked entry to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != filepath.Join(root, "docs", "readme.md") {
+		t.Errorf("Expected readme.md, got %q (%v)", answer, err)
+	}
+}
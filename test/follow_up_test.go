@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func confirmCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_confirm",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserConfirmFollowUp(t *testing.T) {
+	region := map[string]interface{}{"prompt": "Which region?", "allowed_values": []string{"eu-west-1", "us-east-1"}}
+	replicas := map[string]interface{}{"prompt": "How many replicas instead?", "condition": "on_no", "type": "integer", "minimum": 1}
+
+	tests := []struct {
+		name       string
+		def        string
+		followUp   map[string]interface{}
+		input      string
+		structured map[string]interface{}
+		output     string
+	}{
+		{"asked on yes", "", region, "yes\nmars\nEU-West-1\n",
+			map[string]interface{}{"answer": "yes", "confirmed": true, "follow_up": "eu-west-1"}, "Response must be one of: eu-west-1, us-east-1"},
+		{"skipped on no", "", region, "n\n",
+			map[string]interface{}{"answer": "no", "confirmed": false}, ""},
+		{"skipped by default", "no", region, "\n",
+			map[string]interface{}{"answer": "no", "confirmed": false}, "Answer [y/N]"},
+		{"asked on no", "", replicas, "no\n2.5\n0\n3\n",
+			map[string]interface{}{"answer": "no", "confirmed": false, "follow_up": float64(3)}, "at least 1"},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", ttyProvider(term))
+
+		args := map[string]interface{}{"prompt": "Deploy to production?", "method": "tty", "follow_up": tt.followUp}
+		if tt.def != "" {
+			args["default"] = tt.def
+		}
+		messages := parseMessages(t, runServer(t, srv, confirmCall(t, args)).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: expected a result, got %v", tt.name, findResponse(t, messages, 1))
+		}
+		if structured := result["structuredContent"]; !reflect.DeepEqual(structured, tt.structured) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.structured, structured)
+		}
+		if output := term.output.String(); !strings.Contains(output, tt.output) {
+			t.Errorf("%s: expected %q in the terminal output, got:\n%s", tt.name, tt.output, output)
+		}
+	}
+}
+
+func TestUserConfirmFollowUpWeb(t *testing.T) {
+	followUp := server.FollowUp{
+		Condition: server.FollowUpOnYes,
+		Question:  server.FormField{Label: "Which region?", Type: server.FieldText},
+	}
+	handler := server.NewWebInputHandler(server.NewFollowUpConfirmPrompt("Deploy to production?", "web", "", followUp))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `data-when="confirmed" data-equals="yes"`) {
+		t.Errorf("Expected the follow-up to appear only after yes, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.confirmed": {"yes"}, "field.follow_up": {"eu-west-1"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answers to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != `{"confirmed":true,"follow_up":"eu-west-1"}` {
+		t.Errorf("Unexpected answer %q (%v)", answer, err)
+	}
+}
+
+func TestUserConfirmFollowUpInvalid(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"not an object":       {"follow_up": "region"},
+		"no prompt":           {"follow_up": map[string]interface{}{"type": "text"}},
+		"nested":              {"follow_up": map[string]interface{}{"prompt": "A", "follow_up": map[string]interface{}{"prompt": "B"}}},
+		"bad condition":       {"follow_up": map[string]interface{}{"prompt": "A", "condition": "always"}},
+		"bad type":            {"follow_up": map[string]interface{}{"prompt": "A", "type": "date"}},
+		"pattern on number":   {"follow_up": map[string]interface{}{"prompt": "A", "type": "number", "pattern": "\\d+"}},
+		"minimum on text":     {"follow_up": map[string]interface{}{"prompt": "A", "minimum": 1}},
+		"bad pattern":         {"follow_up": map[string]interface{}{"prompt": "A", "pattern": "("}},
+		"units":               {"follow_up": map[string]interface{}{"prompt": "A", "type": "number", "units": "bytes"}},
+		"default not number":  {"follow_up": map[string]interface{}{"prompt": "A", "type": "number", "default": "x"}},
+		"default too small":   {"follow_up": map[string]interface{}{"prompt": "A", "type": "integer", "minimum": 1, "default": 0}},
+		"default not allowed": {"follow_up": map[string]interface{}{"prompt": "A", "allowed_values": []string{"x"}, "default": "y"}},
+		"with phrase":         {"follow_up": map[string]interface{}{"prompt": "A"}, "confirmation_phrase": "prod"},
+	}
+
+	for name, args := range tests {
+		args["prompt"] = "Deploy?"
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, confirmCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected an invalid params error, got %v", name, errObj)
+		}
+	}
+}
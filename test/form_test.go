@@ -0,0 +1,148 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const releaseFields = `[{"name":"version","label":"Release version"},` +
+	`{"name":"tag","label":"Tag it?","type":"boolean","default":true},` +
+	`{"name":"channel","type":"select","options":["stable","beta"]},` +
+	`{"name":"build","type":"number"}]`
+
+func releaseForm() []server.FormField {
+	return []server.FormField{
+		{Name: "version", Label: "Release version", Type: server.FieldText},
+		{Name: "tag", Label: "Tag it?", Type: server.FieldBoolean, Default: true},
+		{Name: "channel", Type: server.FieldSelect, Options: []string{"stable", "beta"}},
+		{Name: "build", Type: server.FieldNumber},
+	}
+}
+
+func TestUserFormTool(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_form","arguments":{"prompt":"Release","fields":` + releaseFields + `}}}`
+
+	provider := &fakeProvider{response: `{"version":"1.2.0","tag":"","channel":"2","build":"42"}`}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	want := map[string]interface{}{"version": "1.2.0", "tag": true, "channel": "beta", "build": float64(42)}
+
+	structured := result["structuredContent"].(map[string]interface{})
+	for name, value := range want {
+		if structured[name] != value {
+			t.Errorf("Expected %s = %v, got %v", name, value, structured[name])
+		}
+	}
+
+	var text map[string]interface{}
+	content := result["content"].([]interface{})
+	if err := json.Unmarshal([]byte(content[0].(map[string]interface{})["text"].(string)), &text); err != nil {
+		t.Fatalf("Expected the text content to be a JSON object: %v", err)
+	}
+	if len(text) != len(want) || text["channel"] != "beta" {
+		t.Errorf("Expected the text content to match, got %v", text)
+	}
+}
+
+func TestUserFormInvalidFields(t *testing.T) {
+	tests := []string{
+		`[]`,
+		`[{"label":"No name"}]`,
+		`[{"name":"a"},{"name":"a"}]`,
+		`[{"name":"a","type":"date"}]`,
+		`[{"name":"a","type":"select"}]`,
+		`[{"name":"a","type":"select","options":["x"],"default":"y"}]`,
+		`[{"name":"a","type":"number","default":"5"}]`,
+		`[{"name":"a","type":"boolean","default":"yes"}]`,
+	}
+
+	for _, fields := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_form","arguments":{"prompt":"Release","fields":` + fields + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", fields, errorObj)
+		}
+	}
+}
+
+func TestFormTTYReasksOnlyFailedField(t *testing.T) {
+	// "abc" fails the number field and is the only answer asked again
+	term := newFakeTerminal("1.2.0\n\nbeta\nabc\n7\n")
+	req := server.NewFormPrompt("Release", "tty", releaseForm())
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != `{"build":7,"channel":"beta","tag":true,"version":"1.2.0"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"Release version: ", "Tag it? [Y/n]: ", "  2) beta", "Please enter a number"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected terminal output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Count(output, "Release version: ") != 1 || strings.Count(output, "build: ") != 2 {
+		t.Errorf("Expected only the failed field to be re-asked, got:\n%s", output)
+	}
+}
+
+func TestFormWebFieldErrors(t *testing.T) {
+	req := server.NewFormPrompt("Release", "web", releaseForm())
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`name="field.version"`, `name="field.tag" value="yes" checked`, `<option value="beta">`, `type="number"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the form to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{
+		"field.version": {"1.2.0"},
+		"field.tag":     {"no"},
+		"field.channel": {"beta"},
+		"field.build":   {"soon"},
+	}))
+	body = rec.Body.String()
+	if rec.Code != http.StatusBadRequest || strings.Count(body, `<div class="error">`) != 1 || !strings.Contains(body, "Please enter a number") {
+		t.Errorf("Expected only the build field to be flagged, got %d:\n%s", rec.Code, body)
+	}
+	if !strings.Contains(body, `value="1.2.0"`) || !strings.Contains(body, `value="no" checked`) || !strings.Contains(body, `value="beta" selected`) {
+		t.Errorf("Expected valid fields to keep their values, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{
+		"field.version": {"1.2.0"},
+		"field.tag":     {"no"},
+		"field.channel": {"beta"},
+		"field.build":   {"7"},
+	}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the corrected form to be accepted, got %d", rec.Code)
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != `{"build":7,"channel":"beta","tag":false,"version":"1.2.0"}` {
+		t.Errorf("Unexpected answer %s (%v)", answer, err)
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// heldProvider answers once release is closed.
+type heldProvider struct {
+	release chan struct{}
+}
+
+func (p heldProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	<-p.release
+	return "yes", nil
+}
+
+// probe fetches path from the health handler and decodes its body.
+func probe(t *testing.T, h http.Handler, path string) (int, server.Health, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var health server.Health
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatalf("%s: expected JSON, got %q", path, rec.Body.String())
+	}
+	return rec.Code, health, rec.Body.String()
+}
+
+func TestHealthReadiness(t *testing.T) {
+	srv := server.NewMCPServer()
+	h := server.NewHealthHandler(srv)
+
+	if code, health, _ := probe(t, h, "/readyz"); code != http.StatusServiceUnavailable || health.Status != "starting" || health.Ready {
+		t.Errorf("Expected 503 before initialize, got %d %+v", code, health)
+	}
+	code, health, _ := probe(t, h, "/healthz")
+	if code != http.StatusOK || health.Status != "ok" || health.ProtocolVersion != "2024-11-05" || health.Pending != 0 || health.UptimeSeconds < 0 {
+		t.Errorf("Expected a healthy server before initialize, got %d %+v", code, health)
+	}
+
+	runServer(t, srv, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"probe","version":"1"}}}`)
+	if code, health, _ := probe(t, h, "/readyz"); code != http.StatusOK || health.Status != "ok" || !health.Ready {
+		t.Errorf("Expected ready after initialize, got %d %+v", code, health)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/healthz", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", method, rec.Code)
+		}
+	}
+	if rec := dashboardGet(newDashboard(t), "/healthz"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no probes on a dashboard without health, got %d", rec.Code)
+	}
+}
+
+func TestHealthPendingPrompts(t *testing.T) {
+	provider := heldProvider{release: make(chan struct{})}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	h := server.NewHealthHandler(srv)
+
+	srv.SetIO(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy the secret project?"}}}`+"\n"), io.Discard, io.Discard)
+	done := make(chan struct{})
+	go func() {
+		srv.Start(context.Background())
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.Health().Pending != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the prompt to be pending")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	_, health, body := probe(t, h, "/healthz")
+	if health.Pending != 1 || strings.Contains(body, "secret") {
+		t.Errorf("Expected one pending prompt and nothing of what it asks, got %s", body)
+	}
+
+	close(provider.release)
+	<-done
+	if pending := srv.Health().Pending; pending != 0 {
+		t.Errorf("Expected no pending prompt once answered, got %d", pending)
+	}
+}
+
+func TestHealthDashboard(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", BasePath: "/prompt-mcp"})
+	d := newDashboard(t)
+	d.SetHealth(server.NewHealthHandler(server.NewMCPServer()))
+
+	if code, health, _ := probe(t, d, "/prompt-mcp/healthz"); code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("Expected the probe without a token, got %d %+v", code, health)
+	}
+	if code, _, _ := probe(t, d, "/prompt-mcp/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready without a client, got %d", code)
+	}
+	if rec := dashboardGet(d, "/healthz"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 outside the base path, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to write from the server's
+// notification goroutines while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// runServer feeds input to srv, waits for the server to finish processing it,
+// and returns the buffer its messages are written to.
+func runServer(t *testing.T, srv *server.MCPServer, input string) *syncBuffer {
+	t.Helper()
+
+	stdout := &syncBuffer{}
+	var stderr bytes.Buffer
+	srv.SetIO(strings.NewReader(input), stdout, &stderr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		srv.Start(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not finish processing input")
+	}
+
+	return stdout
+}
+
+// parseMessages decodes every newline-delimited JSON-RPC message in out.
+func parseMessages(t *testing.T, out string) []map[string]interface{} {
+	t.Helper()
+
+	var messages []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to parse message %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// findResponse returns the response with the given id.
+func findResponse(t *testing.T, messages []map[string]interface{}, id float64) map[string]interface{} {
+	t.Helper()
+
+	for _, msg := range messages {
+		if msg["id"] == id {
+			return msg
+		}
+	}
+	t.Fatalf("No response with id %v in %v", id, messages)
+	return nil
+}
+
+// findNotifications returns every notification with the given method.
+func findNotifications(messages []map[string]interface{}, method string) []map[string]interface{} {
+	var found []map[string]interface{}
+	for _, msg := range messages {
+		if msg["method"] == method {
+			found = append(found, msg)
+		}
+	}
+	return found
+}
+
+// fakeProvider answers prompts after a delay instead of asking a real user.
+// With responses set, it behaves like a user retrying after each validation
+// error: every response is tried in turn until one passes.
+type fakeProvider struct {
+	response  string
+	responses []string
+	delay     time.Duration
+	warnings  int32
+	attempts  int32
+	lastReq   *server.PromptRequest
+	notified  []string
+}
+
+func (p *fakeProvider) Notify(ctx context.Context, req *server.PromptRequest) error {
+	p.notified = append(p.notified, req.Prompt)
+	return nil
+}
+
+func (p *fakeProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	p.lastReq = req
+
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	responses := p.responses
+	if responses == nil {
+		responses = []string{p.response}
+	}
+
+	var lastErr error
+	for _, response := range responses {
+		atomic.AddInt32(&p.attempts, 1)
+		if req.Validate == nil {
+			return response, nil
+		}
+		valid, err := req.Validate(response)
+		if err == nil {
+			return valid, nil
+		}
+		var verr *server.ValidationError
+		if errors.As(err, &verr) {
+			return "", err
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// fakeTerminal stands in for /dev/tty: reads come from the scripted input
+// and everything written is captured.
+type fakeTerminal struct {
+	input  io.Reader
+	output syncBuffer
+
+	// echoOff is set while echo is disabled; restored counts restores
+	echoOff  bool
+	restored int
+
+	// closed counts closes of the terminal handle
+	closed int32
+}
+
+func newFakeTerminal(input string) *fakeTerminal {
+	return &fakeTerminal{input: strings.NewReader(input)}
+}
+
+func (f *fakeTerminal) Read(p []byte) (int, error)  { return f.input.Read(p) }
+func (f *fakeTerminal) Write(p []byte) (int, error) { return f.output.Write(p) }
+func (f *fakeTerminal) Close() error                { atomic.AddInt32(&f.closed, 1); return nil }
+
+func (f *fakeTerminal) DisableEcho() (func(), error) {
+	f.echoOff = true
+	return func() {
+		f.echoOff = false
+		f.restored++
+	}, nil
+}
+
+// ttyProvider returns a provider prompting on term.
+func ttyProvider(term *fakeTerminal) server.InputProvider {
+	return server.NewTTYProvider(func() (io.ReadWriteCloser, error) {
+		return term, nil
+	})
+}
+
+func (p *fakeProvider) WarnTimeout(req *server.PromptRequest, remaining time.Duration) {
+	atomic.AddInt32(&p.warnings, 1)
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestHistoryKeepsNewest(t *testing.T) {
+	h := server.NewHistory(2)
+	for _, prompt := range []string{"first", "second", "third"} {
+		h.Add(server.HistoryEntry{Prompt: prompt})
+	}
+	entries := h.Entries()
+	if len(entries) != 2 || entries[0].Prompt != "third" || entries[1].Prompt != "second" {
+		t.Errorf("Expected the two newest prompts, newest first, got %+v", entries)
+	}
+
+	// A nil history, as with history_size < 0, keeps nothing
+	var none *server.History
+	none.Add(server.HistoryEntry{Prompt: "lost"})
+	if entries := none.Entries(); len(entries) != 0 {
+		t.Errorf("Expected nothing kept, got %+v", entries)
+	}
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{HistorySize: -1})
+	if srv.History() != nil {
+		t.Error("Expected no history with a negative size")
+	}
+}
+
+func TestDashboardHistory(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "sk-live-1234"})
+	runServer(t, srv, sensitiveCall(`,"sensitive":true`))
+	srv.SetInputProvider("tty", &fakeProvider{response: "yes", delay: 20 * time.Millisecond})
+	runServer(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy <b>now</b>?","title":"Release"}}}`)
+
+	entries := srv.History().Entries()
+	if len(entries) != 2 || entries[0].Prompt != "Deploy <b>now</b>?" || entries[0].Method != "tty" || entries[0].Outcome != server.OutcomeAnswered || entries[0].Took < 20*time.Millisecond {
+		t.Fatalf("Expected both prompts recorded, newest first, got %+v", entries)
+	}
+
+	d := newDashboard(t)
+	if rec := dashboardGet(d, d.Path()+"history"); rec.Code != 404 {
+		t.Errorf("Expected no history page without a history, got %d", rec.Code)
+	}
+	d.SetHistory(srv.History())
+	if body := dashboardGet(d, d.Path()).Body.String(); !strings.Contains(body, `href="`+d.Path()+`history"`) {
+		t.Errorf("Expected the index to link to the history, got:\n%s", body)
+	}
+
+	body := dashboardGet(d, d.Path()+"history").Body.String()
+	if strings.Contains(body, "sk-live-1234") || !strings.Contains(body, `<div class="entry-response">[redacted]</div>`) {
+		t.Errorf("Expected the sensitive answer redacted, got:\n%s", body)
+	}
+	release := strings.Index(body, "Deploy &lt;b&gt;now&lt;/b&gt;?")
+	if release < 0 || release > strings.Index(body, "API key for staging?") {
+		t.Errorf("Expected the prompts escaped, newest first, got:\n%s", body)
+	}
+	if !strings.Contains(body, `<div class="entry-response">yes</div>`) || !strings.Contains(body, "· tty · unknown client · Answered in 0s") {
+		t.Errorf("Expected the answer, method and time taken, got:\n%s", body)
+	}
+
+	// The filter matches titles too, ignoring case
+	body = dashboardGet(d, d.Path()+"history?q="+url.QueryEscape("RELEASE")).Body.String()
+	if strings.Contains(body, "API key") || !strings.Contains(body, "Deploy") || !strings.Contains(body, `value="RELEASE"`) {
+		t.Errorf("Expected only the matching prompt, got:\n%s", body)
+	}
+	body = dashboardGet(d, d.Path()+"history?q=nothing").Body.String()
+	if !strings.Contains(body, "No prompts match the filter.") {
+		t.Errorf("Expected no matches, got:\n%s", body)
+	}
+
+	// Like the index, the page needs the dashboard's token
+	for _, path := range []string{"/history", "/wrong-token/history"} {
+		if rec := dashboardGet(d, path); rec.Code != 404 {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// pngBytes returns a small valid PNG, padded with a trailing chunk of
+// zeros to size bytes when size is larger.
+func pngBytes(t *testing.T, size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if size > buf.Len() {
+		buf.Write(make([]byte, size-buf.Len()))
+	}
+	return buf.Bytes()
+}
+
+func imageCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_input",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func pngArg(data []byte) map[string]interface{} {
+	return map[string]interface{}{"mimeType": "image/png", "data": base64.StdEncoding.EncodeToString(data)}
+}
+
+func TestUserInputImagesPreferWeb(t *testing.T) {
+	for _, method := range []string{"", "auto", "web"} {
+		web := &fakeProvider{response: "looks fine"}
+		tty := &fakeProvider{response: "from the terminal"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", web)
+		srv.SetInputProvider("tty", tty)
+
+		args := map[string]interface{}{"prompt": "Does the chart look right?", "images": []interface{}{pngArg(pngBytes(t, 0))}}
+		if method != "" {
+			args["method"] = method
+		}
+		messages := parseMessages(t, runServer(t, srv, imageCall(t, args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "looks fine" {
+			t.Errorf("method %q: expected the browser to be used, got %v", method, text)
+		}
+		if web.lastReq == nil || len(web.lastReq.Images) != 1 || web.lastReq.Images[0].MimeType != "image/png" {
+			t.Errorf("method %q: expected the image to reach the provider", method)
+		}
+	}
+}
+
+func TestUserInputImagesOnTTY(t *testing.T) {
+	term := newFakeTerminal("ok\n")
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	args := map[string]interface{}{
+		"prompt": "Which is better?",
+		"method": "tty",
+		"images": []interface{}{pngArg(pngBytes(t, 0)), pngArg(pngBytes(t, 100))},
+	}
+	messages := parseMessages(t, runServer(t, srv, imageCall(t, args)).String())
+	findResponse(t, messages, 1)
+
+	out := term.output.String()
+	if !strings.Contains(out, "(2 images attached") {
+		t.Fatalf("Expected the images to be listed, got %q", out)
+	}
+	paths := regexp.MustCompile(`\S+image-\d\.png`).FindAllString(out, -1)
+	if len(paths) != 2 {
+		t.Fatalf("Expected two image paths, got %q", out)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed after the prompt, got %v", path, err)
+		}
+	}
+}
+
+func TestUserInputInvalidImages(t *testing.T) {
+	png := pngBytes(t, 0)
+	many := make([]interface{}, 11)
+	for i := range many {
+		many[i] = pngArg(png)
+	}
+	total := make([]interface{}, 5)
+	for i := range total {
+		total[i] = pngArg(pngBytes(t, 4500*1024))
+	}
+
+	tests := map[string]interface{}{
+		"not an array":    "image.png",
+		"unknown type":    []interface{}{map[string]interface{}{"mimeType": "image/svg+xml", "data": base64.StdEncoding.EncodeToString([]byte("<svg/>"))}},
+		"not base64":      []interface{}{map[string]interface{}{"mimeType": "image/png", "data": "%%%"}},
+		"wrong type":      []interface{}{map[string]interface{}{"mimeType": "image/jpeg", "data": base64.StdEncoding.EncodeToString(png)}},
+		"not an image":    []interface{}{map[string]interface{}{"mimeType": "image/png", "data": base64.StdEncoding.EncodeToString([]byte("<html>"))}},
+		"empty":           []interface{}{map[string]interface{}{"mimeType": "image/png", "data": ""}},
+		"too many":        many,
+		"too large":       []interface{}{pngArg(pngBytes(t, 5*1024*1024+1))},
+		"too large total": total,
+	}
+
+	for name, images := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", &fakeProvider{response: "x"})
+
+		messages := parseMessages(t, runServer(t, srv, imageCall(t, map[string]interface{}{"prompt": "Look", "images": images})).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected an invalid params error, got %v", name, errObj)
+		}
+	}
+}
+
+func TestWebPromptImages(t *testing.T) {
+	data := pngBytes(t, 0)
+	prompt := server.NewPromptRequest("Look at this", "web")
+	prompt.Images = []server.Image{{MimeType: "image/png", Data: data}}
+	handler := server.NewWebInputHandler(prompt)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<img src="/image/0" alt="Image 1">`) || strings.Index(body, "<img") > strings.Index(body, "<form") {
+		t.Errorf("Expected the image above the form, got %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/image/0", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || rec.Header().Get("X-Content-Type-Options") != "nosniff" || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Errorf("Unexpected image response %d %v", rec.Code, rec.Header())
+	}
+
+	for _, path := range []string{"/image/1", "/image/-1", "/image/x"} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), postForm(t, handler, "/submit", map[string][]string{"response": {"ok"}}))
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "ok" {
+		t.Errorf("Expected ok, got %q (%v)", answer, err)
+	}
+}
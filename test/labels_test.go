@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestLabelsWeb(t *testing.T) {
+	req := server.NewPromptRequest("Ship it?", "web")
+	req.SubmitLabel = `Deploy "v2" <now>`
+	req.DeclineLabel = `<b>Abort</b> & 'roll back'`
+	handler := server.NewWebInputHandler(req)
+
+	page := pageGet(handler, "/").Body.String()
+	for _, want := range []string{
+		`<button type="submit">Deploy &#34;v2&#34; &lt;now&gt;</button>`,
+		`class="decline">&lt;b&gt;Abort&lt;/b&gt; &amp; &#39;roll back&#39;</button>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s on the page, got:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<now>") || strings.Contains(page, "<b>Abort") {
+		t.Errorf("Expected the labels escaped, got:\n%s", page)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/decline", url.Values{"response": {""}}))
+	if body := rec.Body.String(); !strings.Contains(body, "<h1>&lt;b&gt;Abort&lt;/b&gt; &amp; &#39;roll back&#39;</h1>") {
+		t.Errorf("Expected the decline page named after the label, got:\n%s", body)
+	}
+
+	// Without labels the buttons keep their defaults
+	page = pageGet(server.NewWebInputHandler(server.NewPromptRequest("Ship it?", "web")), "/").Body.String()
+	if !strings.Contains(page, `<button type="submit">Submit</button>`) || !strings.Contains(page, `class="decline">Decline to answer</button>`) {
+		t.Errorf("Expected the default labels, got:\n%s", page)
+	}
+}
+
+func TestLabelsTTY(t *testing.T) {
+	term := newFakeTerminal("yes\n")
+	req := server.NewPromptRequest("Ship it?", "tty")
+	req.SubmitLabel, req.DeclineLabel = "Deploy", "Abort"
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "Response [Deploy/abort]: ") {
+		t.Errorf("Expected the labels in the hint, got %q", out)
+	}
+}
+
+func TestLabelsResult(t *testing.T) {
+	call := func(terminal, labels string) map[string]interface{} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Ship it?"` + labels + `}}}`
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", ttyProvider(newFakeTerminal(terminal)))
+		return findResponse(t, parseMessages(t, runServer(t, srv, input).String()), 1)
+	}
+
+	// The result names the action, never the label
+	structured := func(resp map[string]interface{}) map[string]interface{} {
+		m, _ := resp["result"].(map[string]interface{})["structuredContent"].(map[string]interface{})
+		return m
+	}
+	labels := `,"submit_label":"Deploy","decline_label":"Abort"`
+	if got := structured(call("yes\n", labels)); got["action"] != server.ActionSubmitted {
+		t.Errorf("Expected action submitted, got %v", got)
+	}
+	if got := structured(call("\x1b\n", labels)); got["action"] != server.ActionDeclined || got["declined"] != true {
+		t.Errorf("Expected action declined, got %v", got)
+	}
+	// Unlabelled prompts name it the same way
+	for _, labels := range []string{"", `,"submit_label":""`} {
+		if got := structured(call("yes\n", labels)); got["action"] != server.ActionSubmitted {
+			t.Errorf("%s: expected action submitted, got %v", labels, got)
+		}
+		if got := structured(call("\x1b\n", labels)); got["action"] != server.ActionDeclined || got["declined"] != true {
+			t.Errorf("%s: expected action declined, got %v", labels, got)
+		}
+	}
+
+	for _, labels := range []string{`,"submit_label":"` + strings.Repeat("x", 41) + `"`, `,"decline_label":"Abort\nnow"`} {
+		if resp := call("yes\n", labels); resp["error"] == nil {
+			t.Errorf("%s: expected the label refused, got %v", labels, resp)
+		}
+	}
+}
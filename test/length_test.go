@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const lengthCall = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Summary?"%s}}}`
+
+func lengthResponse(t *testing.T, srv *server.MCPServer, extra string) map[string]interface{} {
+	t.Helper()
+	input := strings.Replace(lengthCall, "%s", extra, 1)
+	return findResponse(t, parseMessages(t, runServer(t, srv, input).String()), 1)
+}
+
+func TestMaxLengthCountsCodePoints(t *testing.T) {
+	// 6 characters but 12 bytes, then 7 characters
+	provider := &fakeProvider{responses: []string{"日本語テキスト", "日本語テキス"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	result := lengthResponse(t, srv, `,"max_length":6`)["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "日本語テキス" {
+		t.Errorf("Expected the 6-character answer, got %v", text)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected the over-long answer to be asked again, got %d attempts", provider.attempts)
+	}
+}
+
+func TestLengthLimitsGiveUp(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"héllo"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	result := lengthResponse(t, srv, `,"min_length":10,"max_length":20,"max_attempts":1`)["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("Expected an isError result, got %v", result)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "Response is 5 characters long; it must be 10-20 characters") {
+		t.Errorf("Expected the length in the error, got %q", text)
+	}
+}
+
+func TestLengthLimitsTTY(t *testing.T) {
+	term := newFakeTerminal("this is too long\nshort\n")
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	result := lengthResponse(t, srv, `,"max_length":8`)["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "short" {
+		t.Errorf("Expected the short answer, got %v", text)
+	}
+	out := term.output.String()
+	if !strings.Contains(out, "(Answer in at most 8 characters)") {
+		t.Errorf("Expected the limit in the prompt, got %q", out)
+	}
+	if !strings.Contains(out, "Response is 16 characters long") {
+		t.Errorf("Expected the over-long answer to be refused, got %q", out)
+	}
+}
+
+func TestLengthLimitsWeb(t *testing.T) {
+	req := server.NewPromptRequest("Summary?", "web")
+	req.LimitLength(server.LengthLimits{Max: 5})
+	req.RequireAnswer()
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-max-length="5"`) || !strings.Contains(body, `id="length-counter"`) {
+		t.Errorf("Expected the live counter, got:\n%s", body)
+	}
+	if !strings.Contains(body, `maxlength="5"`) {
+		t.Error("Expected the browser to stop typing at the limit")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"ñññññ!"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "it must be at most 5 characters") {
+		t.Errorf("Expected an over-long answer to be rejected, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"ñññññ"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected a 5-character answer to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "ñññññ" {
+		t.Errorf("Expected ñññññ, got %q (%v)", answer, err)
+	}
+}
+
+func TestLengthLimitsWithDefault(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: ""})
+
+	result := lengthResponse(t, srv, `,"max_length":10,"default":"ok"`)["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "ok" {
+		t.Errorf("Expected the default, got %v", text)
+	}
+}
+
+func TestLengthLimitsInvalidArguments(t *testing.T) {
+	tests := []string{
+		`,"max_length":0`,
+		`,"min_length":2.5`,
+		`,"max_length":"10"`,
+		`,"min_length":5,"max_length":4`,
+		`,"max_length":3,"default":"toolong"`,
+		`,"max_length":3,"type":"integer"`,
+		`,"max_length":3,"response_schema":{"type":"string"}`,
+	}
+	for _, extra := range tests {
+		resp := lengthResponse(t, &server.MCPServer{}, extra)
+		errorObj, ok := resp["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", extra, resp)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func listCall(t *testing.T, args map[string]interface{}) string {
+	args["prompt"] = "Which hosts should be drained?"
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_list",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserListResult(t *testing.T) {
+	provider := &fakeProvider{responses: []string{`["web1","db 1"]`, `[" web1 ", "", "web2", "web1"]`}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	args := map[string]interface{}{"pattern": `[a-z]+\d`, "duplicates": "dedupe"}
+	messages := parseMessages(t, runServer(t, srv, listCall(t, args)).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if !reflect.DeepEqual(structured["items"], []interface{}{"web1", "web2"}) || structured["count"] != float64(2) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != `["web1","web2"]` {
+		t.Errorf("Unexpected text %v", text)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected the item not matching the pattern to be refused, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserListTTY(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   server.ListOptions
+		answer string
+		output []string
+	}{
+		{"blank line", "web2\nweb1\nweb2\n\n", server.ListOptions{},
+			`["web2","web1","web2"]`, []string{"(One item per line; a blank line finishes)\n1: 2: 3: 4: "}},
+		{"terminator", "web1\n\nweb2\ndone\n", server.ListOptions{Terminator: "done"},
+			`["web1","web2"]`, []string{`a line reading "done" finishes`}},
+		{"min items", "\nweb1\n\nweb2\n\n", server.ListOptions{MinItems: 2},
+			`["web1","web2"]`, []string{"(at least 2 items)", "Please enter at least 2 items"}},
+		{"max items", "web1\nweb2\n", server.ListOptions{MaxItems: 2},
+			`["web1","web2"]`, []string{"(at most 2 items)"}},
+		{"pattern", "db 1\nweb1\n\n", server.ListOptions{Pattern: `[a-z]+\d`, Message: "Host names only"},
+			`["web1"]`, []string{"Host names only"}},
+		{"reject", "web1\nweb1\nweb2\n\n", server.ListOptions{Duplicates: server.DuplicatesReject},
+			`["web1","web2"]`, []string{`"web1" is already on the list`}},
+		{"dedupe", "web1\nweb1\nweb2\n\n", server.ListOptions{Duplicates: server.DuplicatesDedupe},
+			`["web1","web2"]`, nil},
+	}
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		req, err := server.NewListPrompt("Which hosts?", "tty", tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil || answer != tt.answer {
+			t.Errorf("%s: expected %s, got %q (%v)", tt.name, tt.answer, answer, err)
+		}
+		for _, want := range tt.output {
+			if !strings.Contains(term.output.String(), want) {
+				t.Errorf("%s: expected %q, got %q", tt.name, want, term.output.String())
+			}
+		}
+	}
+}
+
+func TestUserListWeb(t *testing.T) {
+	req, err := server.NewListPrompt("Which hosts?", "web", server.ListOptions{MinItems: 2, Duplicates: server.DuplicatesReject})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`One item per row (at least 2 items).`, `id="list-items"`, `<input type="text" name="item" value="" aria-label="Item">`, `class="list-remove"`, `id="list-add"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the list, got:\n%s", want, body)
+		}
+	}
+
+	// A rejected list is shown again as submitted
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"item": {"web1", "web1"}}))
+	body = rec.Body.String()
+	if rec.Code != http.StatusBadRequest || strings.Count(body, `name="item" value="web1"`) != 2 || !strings.Contains(body, "already on the list") {
+		t.Errorf("Expected the rejected rows to be kept, got %d:\n%s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"item": {"web2", "", "web1"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the list to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != `["web2","web1"]` {
+		t.Errorf(`Expected ["web2","web1"], got %q (%v)`, answer, err)
+	}
+}
+
+func TestUserListInvalidArguments(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"min_items": -1},
+		{"min_items": 1.5},
+		{"max_items": 0},
+		{"max_items": 1001},
+		{"min_items": 3, "max_items": 2},
+		{"pattern": "("},
+		{"duplicates": "merge"},
+		{"terminator": " done"},
+		{"terminator": "a\nb"},
+	}
+	for _, args := range tests {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, listCall(t, args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
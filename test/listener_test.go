@@ -0,0 +1,123 @@
+package test
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// useWebListener serves web pages from l until the test ends.
+func useWebListener(t *testing.T, l server.WebListener) {
+	server.SetWebListener(l)
+	t.Cleanup(func() {
+		def, _ := server.Config{}.WebListener()
+		server.SetWebListener(def)
+	})
+}
+
+// freePort returns a port that was free a moment ago.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func startDashboard(t *testing.T) (string, error) {
+	d := newDashboard(t)
+	url, err := d.Start()
+	if err == nil {
+		t.Cleanup(func() { d.Close() })
+	}
+	return url, err
+}
+
+func TestWebListenerSinglePort(t *testing.T) {
+	port := freePort(t)
+	cfg := server.Config{WebHost: "127.0.0.1", WebPortRange: strconv.Itoa(port)}
+	l, err := cfg.WebListener()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useWebListener(t, l)
+
+	url, err := startDashboard(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://127.0.0.1:" + strconv.Itoa(port) + "/"; !strings.HasPrefix(url, want) {
+		t.Errorf("Expected the configured host and port %s, got %s", want, url)
+	}
+
+	// The only port is taken now
+	if _, err := startDashboard(t); err == nil || !strings.Contains(err.Error(), "port "+strconv.Itoa(port)+" on 127.0.0.1 is not available") {
+		t.Errorf("Expected the port to be unavailable, got %v", err)
+	}
+}
+
+func TestWebListenerRangeExhausted(t *testing.T) {
+	port := freePort(t)
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", MinPort: port, MaxPort: port + 1})
+
+	var urls []string
+	for range 2 {
+		url, err := startDashboard(t)
+		if err != nil {
+			t.Skipf("Port range %d-%d is not free: %v", port, port+1, err)
+		}
+		urls = append(urls, url)
+	}
+	for i, url := range urls {
+		if want := "http://127.0.0.1:" + strconv.Itoa(port+i) + "/"; !strings.HasPrefix(url, want) {
+			t.Errorf("Expected the ports to be taken in order, got %s for %s", url, want)
+		}
+	}
+
+	want := "no free port between " + strconv.Itoa(port) + " and " + strconv.Itoa(port+1) + " on 127.0.0.1"
+	if _, err := startDashboard(t); err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+}
+
+func TestWebListenerExternalURL(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", ExternalURL: "https://prompts.example.com/"})
+
+	url, err := startDashboard(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, "https://prompts.example.com/") || strings.Contains(url, "127.0.0.1") {
+		t.Errorf("Expected the external URL, got %s", url)
+	}
+}
+
+func TestWebListenerConfig(t *testing.T) {
+	l, err := server.Config{}.WebListener()
+	if err != nil || l.Host != "127.0.0.1" || l.MinPort != 0 || l.MaxPort != 0 {
+		t.Errorf("Expected loopback and any port by default, got %+v (%v)", l, err)
+	}
+	l, err = server.Config{WebPortRange: " 8400 - 8500 "}.WebListener()
+	if err != nil || l.MinPort != 8400 || l.MaxPort != 8500 {
+		t.Errorf("Expected 8400-8500, got %+v (%v)", l, err)
+	}
+
+	for _, cfg := range []server.Config{
+		{WebPortRange: "8500-8400"},
+		{WebPortRange: "0-10"},
+		{WebPortRange: "8400-70000"},
+		{WebPortRange: "8400-"},
+		{WebPortRange: "high"},
+		{WebExternalURL: "prompts.example.com"},
+		{WebExternalURL: "ftp://prompts.example.com"},
+		{WebExternalURL: "https://example.com/prompts"},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v: expected an invalid config", cfg)
+		}
+	}
+}
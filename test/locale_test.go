@@ -0,0 +1,298 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/i18n"
+	"prompt-mcp/server"
+)
+
+func localeCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?"` + args + `}}}`
+}
+
+func renderLocale(t *testing.T, req *server.PromptRequest) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(req).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Body.String()
+}
+
+func TestLocaleWebJapanese(t *testing.T) {
+	req := server.NewPromptRequest("デプロイしますか?", "web")
+	req.Locale = "ja_JP.UTF-8"
+	body := renderLocale(t, req)
+
+	for _, want := range []string{
+		`<html lang="ja">`,
+		"<title>入力が必要です</title>",
+		"<h1>入力が必要です</h1>",
+		`placeholder="回答を入力してください..."`,
+		">送信</button>",
+		"デプロイしますか?",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the page, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "User Input Required") || strings.Contains(body, ">Submit<") {
+		t.Errorf("Expected no English chrome, got:\n%s", body)
+	}
+}
+
+func TestLocaleWebThanks(t *testing.T) {
+	req := server.NewPromptRequest("Deploy?", "web")
+	req.Locale = "ja"
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	if !strings.Contains(rec.Body.String(), "ありがとうございました！") {
+		t.Errorf("Expected a Japanese thank-you page, got:\n%s", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, "charset=utf-8") {
+		t.Errorf("Expected a UTF-8 page, got %q", ct)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "yes" {
+		t.Errorf("Expected yes, got %q (%v)", answer, err)
+	}
+}
+
+func TestLocaleWebExpired(t *testing.T) {
+	req := server.NewPromptRequest("Deploy?", "web")
+	req.Locale = "de"
+	handler := server.NewWebInputHandler(req)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(server.ErrWithdrawn)
+	handler.Wait(ctx)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	body := rec.Body.String()
+	for _, want := range []string{`<html lang="de">`, "<h1>Zurückgezogen</h1>", "Sie können diesen Tab schließen."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the page, got:\n%s", want, body)
+		}
+	}
+	if rec.Code != http.StatusGone || strings.Contains(body, "withdrew") {
+		t.Errorf("Expected a German 410 page, got %d:\n%s", rec.Code, body)
+	}
+}
+
+func TestLocaleWebErrors(t *testing.T) {
+	submit := func(req *server.PromptRequest, form url.Values) string {
+		handler := server.NewWebInputHandler(req)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", form))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	secret := server.NewPromptRequest("Passwort?", "web")
+	secret.Locale, secret.Secret, secret.ConfirmSecret = "de", true, true
+	if body := submit(secret, url.Values{"response": {"hunter2"}, "response_confirm": {"hunter3"}}); !strings.Contains(body, "Die Eingaben stimmen nicht überein") {
+		t.Errorf("Expected the mismatch in German, got:\n%s", body)
+	}
+
+	empty := server.NewPromptRequest("Name?", "web")
+	empty.Locale = "fr"
+	if body := submit(empty, url.Values{"response": {" "}}); !strings.Contains(body, "La réponse ne peut pas être vide") {
+		t.Errorf("Expected the empty answer refused in French, got:\n%s", body)
+	}
+
+	limited := server.NewChoicePrompt("Fruta", "web", []string{"manzana", "pera"})
+	limited.Locale, limited.MaxAttempts = "es", 3
+	provider := providerFunc(func(ctx context.Context, req *server.PromptRequest) (string, error) {
+		if body := submit(req, url.Values{"response": {"9"}}); !strings.Contains(body, "Intento 2 de 3") {
+			t.Errorf("Expected the attempt count in Spanish, got:\n%s", body)
+		}
+		return "", server.ErrTimeout
+	})
+	server.Ask(context.Background(), provider, limited)
+}
+
+func TestLocaleFallsBackToEnglish(t *testing.T) {
+	for _, locale := range []string{"", "xx", "C", "pt_BR.UTF-8"} {
+		req := server.NewPromptRequest("Deploy?", "web")
+		req.Locale = locale
+		body := renderLocale(t, req)
+		if !strings.Contains(body, "<h1>User Input Required</h1>") || !strings.Contains(body, `<html lang="en">`) {
+			t.Errorf("%q: expected the English page, got:\n%s", locale, body)
+		}
+	}
+
+	if got := i18n.T("de-AT", i18n.Submit); got != "Absenden" {
+		t.Errorf("Expected the region to be ignored, got %q", got)
+	}
+	if got := i18n.T("fr", "no_such_key"); got != "no_such_key" {
+		t.Errorf("Expected an unknown key to be returned as is, got %q", got)
+	}
+}
+
+func TestLocaleTTY(t *testing.T) {
+	term := newFakeTerminal("\n")
+	req := server.NewAckPrompt("Backup done?", "tty")
+	req.Locale = "fr"
+
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "Appuyez sur Entrée pour continuer...") {
+		t.Errorf("Expected the French Enter hint, got %q", out)
+	}
+
+	term = newFakeTerminal("ja\n")
+	req = server.NewPromptRequest("Name?", "tty")
+	req.Locale = "de"
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "Antwort: ") {
+		t.Errorf("Expected the German label, got %q", out)
+	}
+}
+
+func TestLocaleArgumentAndServerDefault(t *testing.T) {
+	tests := []struct {
+		args   string
+		config string
+		want   string
+	}{
+		{`,"locale":"ja"`, "", "ja"},
+		{"", "de", "de"},
+		{`,"locale":"es"`, "de", "es"},
+	}
+
+	for _, tt := range tests {
+		provider := &fakeProvider{response: "yes"}
+		srv := &server.MCPServer{}
+		srv.SetConfig(server.Config{Locale: tt.config})
+		srv.SetInputProvider("tty", provider)
+
+		runServer(t, srv, localeCall(tt.args))
+		if provider.lastReq.Locale != tt.want {
+			t.Errorf("%s with config %q: expected locale %q, got %q", tt.args, tt.config, tt.want, provider.lastReq.Locale)
+		}
+	}
+}
+
+func TestLocaleInvalid(t *testing.T) {
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, localeCall(`,"locale":7`)).String())
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602, got %v", errorObj)
+	}
+
+	if err := (server.Config{Locale: "klingon"}).Validate(); err == nil {
+		t.Error("Expected an unknown config locale to be rejected")
+	}
+	if err := (server.Config{Locale: "ja_JP.UTF-8"}).Validate(); err != nil {
+		t.Errorf("Expected a POSIX locale to be accepted, got %v", err)
+	}
+}
+
+func TestLocaleNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"de", "de"},
+		{"de-CH", "de"},
+		{"FR-ca,en;q=0.5", "fr"},
+		{"en;q=0.4, ja;q=0.9, es", "es"},
+		{"es;q=0.5, ja;q=0.5", "es"},
+		{"pt-BR, pt;q=0.9, fr;q=0.8", "fr"},
+		{"de;q=0, fr;q=0.1", "fr"},
+		{"ja;q=1.5, de;q=abc, es;level=1, fr;q=0.2", "fr"},
+		{"*;q=0.5", ""},
+		{"pt-BR", ""},
+		{"", ""},
+		{" , ;q=1", ""},
+	}
+
+	for _, tt := range tests {
+		if got := i18n.Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleWebNegotiated(t *testing.T) {
+	render := func(config server.Config, args, acceptLanguage string) string {
+		t.Helper()
+		page := &pageProvider{accept: acceptLanguage}
+		srv := &server.MCPServer{}
+		srv.SetConfig(config)
+		srv.SetInputProvider("tty", page)
+		runServer(t, srv, localeCall(args))
+		return page.body
+	}
+
+	tests := []struct {
+		name   string
+		config server.Config
+		args   string
+		accept string
+		want   string
+	}{
+		{"browser language", server.Config{Locale: "en"}, "", "fr-FR,en;q=0.8", "fr"},
+		{"no catalog falls back to the server locale", server.Config{Locale: "de"}, "", "pt-BR", "de"},
+		{"no header", server.Config{Locale: "ja"}, "", "", "ja"},
+		{"pinned", server.Config{Locale: "de", PinLocale: true}, "", "fr", "de"},
+		{"prompt locale wins", server.Config{Locale: "en"}, `,"locale":"es"`, "fr", "es"},
+	}
+	for _, tt := range tests {
+		body := render(tt.config, tt.args, tt.accept)
+		if !strings.Contains(body, `<html lang="`+tt.want+`">`) {
+			t.Errorf("%s: expected a page in %q, got:\n%s", tt.name, tt.want, body)
+		}
+	}
+}
+
+func TestLocaleDashboardNegotiated(t *testing.T) {
+	get := func(d *server.Dashboard) string {
+		req := httptest.NewRequest(http.MethodGet, d.Path(), nil)
+		req.Header.Set("Accept-Language", "ja, en;q=0.5")
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	d, err := server.NewDashboard(0, "de")
+	if err != nil {
+		t.Fatalf("NewDashboard: %v", err)
+	}
+	if body := get(d); !strings.Contains(body, `<html lang="ja">`) {
+		t.Errorf("Expected the index in Japanese, got:\n%s", body)
+	}
+	d.PinLocale()
+	if body := get(d); !strings.Contains(body, `<html lang="de">`) {
+		t.Errorf("Expected the pinned index in German, got:\n%s", body)
+	}
+}
+
+// pageProvider renders the web page of a prompt for a browser sending an
+// Accept-Language header, and answers it.
+type pageProvider struct {
+	accept string
+	body   string
+}
+
+func (p *pageProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if p.accept != "" {
+		r.Header.Set("Accept-Language", p.accept)
+	}
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(req).ServeHTTP(rec, r)
+	p.body = rec.Body.String()
+	return "yes", nil
+}
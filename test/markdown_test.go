@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const markdownPrompt = "# Deploy plan\n\n" +
+	"This will **force-push** `main` to _origin_, see [the runbook](https://example.com/runbook).\n\n" +
+	"- first\n- second\n\n" +
+	"```go\nfunc main() { // start\n\tfmt.Println(\"hi\", 42)\n}\n```"
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	got := server.RenderMarkdown(markdownPrompt, 100, false)
+	want := "Deploy plan\n\n" +
+		"This will force-push main to origin, see the runbook (https://example.com/runbook).\n\n" +
+		"- first\n- second\n\n" +
+		"    func main() { // start\n        fmt.Println(\"hi\", 42)\n    }"
+	if got != want {
+		t.Errorf("Unexpected plain rendering:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMarkdownColor(t *testing.T) {
+	got := server.RenderMarkdown(markdownPrompt, 80, true)
+	for _, want := range []string{
+		"\x1b[1m\x1b[4mDeploy plan",
+		"\x1b[1mforce-push\x1b[22m",
+		"\x1b[36mmain\x1b[39m",
+		"\x1b[3morigin\x1b[23m",
+		"• first",
+		"    \x1b[34mfunc\x1b[39m main() { \x1b[90m// start\x1b[39m",
+		"\x1b[32m\"hi\"\x1b[39m, \x1b[35m42\x1b[39m",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the colored rendering, got:\n%q", want, got)
+		}
+	}
+}
+
+func TestRenderMarkdownWraps(t *testing.T) {
+	text := "- " + strings.Repeat("word ", 30) + "\n\n**" + strings.Repeat("bold ", 30) + "**"
+	for _, color := range []bool{false, true} {
+		for _, line := range strings.Split(server.RenderMarkdown(text, 40, color), "\n") {
+			plain := strings.NewReplacer("\x1b[1m", "", "\x1b[22m", "").Replace(line)
+			if len([]rune(plain)) > 40 {
+				t.Errorf("Expected lines of at most 40 columns, got %q", plain)
+			}
+			if strings.HasPrefix(plain, "word") {
+				t.Errorf("Expected list continuation lines to be indented, got %q", plain)
+			}
+		}
+	}
+}
+
+func TestRenderMarkdownLeavesCodeAlone(t *testing.T) {
+	got := server.RenderMarkdown("Set `**not bold**` and keep snake_case_names", 80, false)
+	if got != "Set **not bold** and keep snake_case_names" {
+		t.Errorf("Expected code spans and identifiers untouched, got %q", got)
+	}
+}
+
+func TestMarkdownPromptTTY(t *testing.T) {
+	term := newFakeTerminal("ok\n")
+	req := server.NewPromptRequest("Ready to **ship** `v1.2`?", "tty")
+	req.Format = server.FormatMarkdown
+
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	// The fake terminal is not a real one, so the markup is stripped
+	if output := term.output.String(); !strings.HasPrefix(output, "Ready to ship v1.2?\n") || strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected plain rendered Markdown, got %q", output)
+	}
+}
+
+func TestMarkdownFormatArgument(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		ok     bool
+	}{{`"markdown"`, true}, {`"text"`, true}, {`"html"`, false}, {`1`, false}} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q","format":` + tt.format + `}}}`
+
+		provider := &fakeProvider{response: "a"}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+		response := findResponse(t, parseMessages(t, runServer(t, srv, input).String()), 1)
+
+		if _, failed := response["error"]; failed == tt.ok {
+			t.Errorf("format %s: unexpected response %v", tt.format, response)
+		}
+	}
+}
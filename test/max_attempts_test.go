@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestMaxAttemptsArgument(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Version?","pattern":"\\d+","max_attempts":2}}}`
+
+	provider := &fakeProvider{responses: []string{"one", "two", "3"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != true {
+		t.Fatalf("Expected an isError result, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["attempts"] != float64(2) || structured["lastValue"] != "two" || !strings.Contains(structured["reason"].(string), "pattern") {
+		t.Errorf("Expected the last invalid value and reason, got %v", structured)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected the user to be asked twice, got %d", provider.attempts)
+	}
+}
+
+func TestMaxAttemptsOverridesConfig(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_rating","arguments":{"prompt":"How was it?","max_attempts":4}}}`
+
+	provider := &fakeProvider{responses: []string{"0", "9", "six", "4"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false {
+		t.Errorf("Expected the fourth attempt to be accepted, got %v", result)
+	}
+}
+
+func TestMaxAttemptsPerCall(t *testing.T) {
+	// Each call gets its own count: two failures apiece stay under three
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["a","b"],"max_attempts":3}}}
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["a","b"],"max_attempts":3}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{responses: []string{"c", "d", "a"}})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	for _, id := range []float64{1, 2} {
+		result := findResponse(t, messages, id)["result"].(map[string]interface{})
+		if result["isError"] != false {
+			t.Errorf("Call %v: expected success, got %v", id, result)
+		}
+	}
+}
+
+func TestInvalidMaxAttempts(t *testing.T) {
+	tools := []string{
+		`"user_input","arguments":{"prompt":"Q"`,
+		`"user_choice","arguments":{"prompt":"Q","options":["a"]`,
+		`"user_confirm","arguments":{"prompt":"Q"`,
+		`"user_form","arguments":{"prompt":"Q","fields":[{"name":"a"}]`,
+		`"user_file_select","arguments":{"prompt":"Q"`,
+		`"user_rating","arguments":{"prompt":"Q"`,
+		`"user_datetime","arguments":{"prompt":"Q"`,
+		`"user_input_batch","arguments":{"questions":[{"id":"a","prompt":"A"}]`,
+	}
+
+	for _, tool := range tools {
+		for _, value := range []string{"0", "1.5", `"3"`, "101"} {
+			input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":` + tool + `,"max_attempts":` + value + `}}}`
+			messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+			errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+			if !ok || errorObj["code"] != float64(-32602) {
+				t.Errorf("%s with max_attempts %s: expected -32602, got %v", tool, value, errorObj)
+			}
+		}
+	}
+}
+
+func TestMaxAttemptsTTYShowsRemaining(t *testing.T) {
+	term := newFakeTerminal("x\n9\n4\n")
+	req := server.NewRatingPrompt("How many stars?", "tty", server.RatingOptions{Min: 1, Max: 5})
+	req.MaxAttempts = 3
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), req)
+	if err != nil || answer != "4" {
+		t.Fatalf("Expected 4, got %q (%v)", answer, err)
+	}
+	output := term.output.String()
+	if !strings.Contains(output, "(2 attempts left)") || !strings.Contains(output, "(1 attempt left)") {
+		t.Errorf("Expected the remaining attempts to be shown, got:\n%s", output)
+	}
+}
+
+func TestMaxAttemptsTTYFormFields(t *testing.T) {
+	term := newFakeTerminal("many\nlots\n3\n")
+	req := server.NewFormPrompt("Deploy", "tty", []server.FormField{{Name: "replicas", Label: "Replicas", Type: server.FieldNumber}})
+	req.MaxAttempts = 2
+
+	_, err := server.Ask(context.Background(), ttyProvider(term), req)
+	var verr *server.ValidationError
+	if !errors.As(err, &verr) || verr.Attempts != 2 {
+		t.Errorf("Expected invalid fields to use up the attempts, got %v", err)
+	}
+}
+
+func TestMaxAttemptsWebShowsAttempt(t *testing.T) {
+	req := server.NewChoicePrompt("Pick a fruit", "web", []string{"apple", "pear"})
+	req.MaxAttempts = 3
+
+	provider := providerFunc(func(ctx context.Context, limited *server.PromptRequest) (string, error) {
+		handler := server.NewWebInputHandler(limited)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if strings.Contains(rec.Body.String(), "Attempt ") {
+			t.Errorf("Expected no attempt count before a mistake, got:\n%s", rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"9"}}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Attempt 2 of 3") {
+			t.Errorf("Expected the attempt to be shown, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"2"}}))
+		return handler.Wait(ctx)
+	})
+
+	answer, err := server.Ask(context.Background(), provider, req)
+	if err != nil || answer != "pear" {
+		t.Errorf("Expected pear, got %q (%v)", answer, err)
+	}
+}
+
+func TestMaxAttemptsWebFormFields(t *testing.T) {
+	req := server.NewFormPrompt("Deploy", "web", []server.FormField{{Name: "replicas", Label: "Replicas", Type: server.FieldNumber}})
+	req.MaxAttempts = 2
+
+	provider := providerFunc(func(ctx context.Context, limited *server.PromptRequest) (string, error) {
+		handler := server.NewWebInputHandler(limited)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.replicas": {"many"}}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Attempt 2 of 2") {
+			t.Errorf("Expected the attempt to be shown, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.replicas": {"lots"}}))
+		if !strings.Contains(rec.Body.String(), "Too many invalid attempts") {
+			t.Errorf("Expected the last attempt to end the prompt, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+		return handler.Wait(ctx)
+	})
+
+	_, err := server.Ask(context.Background(), provider, req)
+	var verr *server.ValidationError
+	if !errors.As(err, &verr) || !strings.Contains(verr.Reason, "replicas") {
+		t.Errorf("Expected a ValidationError naming the field, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func restrictedServer(methods []string) (*server.MCPServer, *fakeProvider, *fakeProvider) {
+	tty, web := &fakeProvider{response: "from tty"}, &fakeProvider{response: "from web"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", tty)
+	srv.SetInputProvider("web", web)
+	cfg := server.DefaultConfig()
+	cfg.Methods = methods
+	srv.SetConfig(cfg)
+	return srv, tty, web
+}
+
+func TestDisallowedMethodFallsBack(t *testing.T) {
+	tests := []struct {
+		methods   []string
+		requested string
+		want      string
+	}{
+		{[]string{"tty"}, `"web"`, "from tty"},
+		{[]string{"web"}, `"tty"`, "from web"},
+		{[]string{"web", "tty"}, `"tty"`, "from tty"},
+		{[]string{"tty"}, `"both"`, "from tty"},
+		{nil, `"web"`, "from web"},
+	}
+
+	for _, tc := range tests {
+		srv, _, _ := restrictedServer(tc.methods)
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Hi","method":` + tc.requested + `}}}`
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tc.want {
+			t.Errorf("%v with %s: expected %q, got %v", tc.methods, tc.requested, tc.want, text)
+		}
+		substitution, substituted := result["_meta"].(map[string]interface{})["methodSubstitution"].(map[string]interface{})
+		if wantSubstituted := !strings.HasSuffix(tc.want, strings.Trim(tc.requested, `"`)); substituted != wantSubstituted {
+			t.Errorf("%v with %s: expected substitution %v, got %v", tc.methods, tc.requested, wantSubstituted, substitution)
+		}
+		if substituted && (substitution["requested"] != strings.Trim(tc.requested, `"`) || "from "+substitution["used"].(string) != tc.want) {
+			t.Errorf("%v with %s: unexpected substitution %v", tc.methods, tc.requested, substitution)
+		}
+	}
+}
+
+func TestDisallowedMethodFallsBackForOtherTools(t *testing.T) {
+	srv, _, web := restrictedServer([]string{"web"})
+	web.response = "2"
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which?","options":["a","b"],"method":"tty"}}}`
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if web.lastReq == nil || web.lastReq.Method != "web" {
+		t.Fatalf("Expected the browser to be used instead, got %+v", web.lastReq)
+	}
+	if _, ok := result["_meta"].(map[string]interface{})["methodSubstitution"]; !ok {
+		t.Errorf("Expected the substitution to be noted, got %v", result)
+	}
+}
+
+func TestNoMethodsAllowed(t *testing.T) {
+	srv, tty, web := restrictedServer([]string{})
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Hi"}}}
+{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"notify_user","arguments":{"message":"Hi"}}}
+{"jsonrpc":"2.0","id":3,"method":"user_input","params":{"prompt":"Hi"}}`
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	for _, id := range []float64{1, 2, 3} {
+		errorObj, ok := findResponse(t, messages, id)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32603) || !strings.Contains(errorObj["message"].(string), "allowed") {
+			t.Errorf("Request %v: expected a -32603 saying no method is allowed, got %v", id, errorObj)
+		}
+	}
+	if tty.lastReq != nil || web.lastReq != nil {
+		t.Error("Expected no provider to be asked")
+	}
+}
+
+func TestToolsListOffersAllowedMethods(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+
+	methodSchema := func(methods []string) map[string]interface{} {
+		srv, _, _ := restrictedServer(methods)
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		for _, tool := range findResponse(t, messages, 1)["result"].(map[string]interface{})["tools"].([]interface{}) {
+			tool := tool.(map[string]interface{})
+			if tool["name"] == "user_input" {
+				schema, _ := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})["method"].(map[string]interface{})
+				return schema
+			}
+		}
+		t.Fatal("user_input not listed")
+		return nil
+	}
+
+	if schema := methodSchema([]string{"web"}); schema == nil || len(schema["enum"].([]interface{})) != 1 || schema["enum"].([]interface{})[0] != "web" || schema["default"] != "web" {
+		t.Errorf("Expected only web to be offered, got %v", schema)
+	}
+	if schema := methodSchema([]string{"web", "tty"}); schema == nil || len(schema["enum"].([]interface{})) != 4 || schema["default"] != "web" {
+		t.Errorf("Expected web, tty and auto to be offered, got %v", schema)
+	}
+	if schema := methodSchema([]string{}); schema != nil {
+		t.Errorf("Expected no method to be offered, got %v", schema)
+	}
+	if schema := methodSchema(nil); len(schema["enum"].([]interface{})) != 4 || schema["default"] != "tty" {
+		t.Errorf("Expected the registered schema without restriction, got %v", schema)
+	}
+
+	// Restricting one server leaves the registered schemas alone
+	if schema := methodSchema(nil); len(schema["enum"].([]interface{})) != 4 {
+		t.Errorf("Expected the registered schema to be untouched, got %v", schema)
+	}
+}
+
+func TestMethodsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"methods":["web"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := server.LoadConfig(path)
+	if err != nil || len(cfg.Methods) != 1 || cfg.Methods[0] != "web" {
+		t.Fatalf("Expected methods [web], got %v (%v)", cfg.Methods, err)
+	}
+	if server.DefaultConfig().Methods != nil {
+		t.Error("Expected every method to be allowed by default")
+	}
+
+	bad := server.DefaultConfig()
+	bad.Methods = []string{"auto"}
+	if err := bad.Validate(); err == nil {
+		t.Error("Expected auto to be refused in the allowlist")
+	}
+
+	// Changing the methods changes the tool schemas
+	var stdout, stderr bytes.Buffer
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.DefaultConfig())
+	srv.SetIO(strings.NewReader(""), &stdout, &stderr)
+	if err := srv.ReloadConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "notifications/tools/list_changed") {
+		t.Errorf("Expected a tool list change notification, got %q", stdout.String())
+	}
+}
+
+func TestPreparePrompt(t *testing.T) {
+	// The ask command applies the config the server would
+	cfg := server.DefaultConfig()
+	cfg.Methods = []string{"web"}
+	cfg.Locale = "de"
+	cfg.MaxAnswerBytes = 4096
+	prompt := server.NewPromptRequest("Deploy?", "tty")
+	substituted, err := cfg.PreparePrompt(prompt)
+	if err != nil || !substituted || prompt.Method != "web" {
+		t.Errorf("Expected tty swapped for web, got %q (substituted %v, %v)", prompt.Method, substituted, err)
+	}
+	if prompt.Locale != "de" || !prompt.NegotiateLocale || prompt.MaxAnswerBytes != 4096 {
+		t.Errorf("Expected the config's locale and cap, got %+v", prompt)
+	}
+
+	cfg.Methods = []string{}
+	if _, err := cfg.PreparePrompt(server.NewPromptRequest("Deploy?", "tty")); err != server.ErrNoMethods {
+		t.Errorf("Expected ErrNoMethods, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestMultilineTTY(t *testing.T) {
+	tests := []struct {
+		input  string
+		answer string
+	}{
+		// The terminator only counts on a line of its own
+		{"fix: parse a.b.c\n\n  - keep . in paths\n.\nignored\n", "fix: parse a.b.c\n\n  - keep . in paths"},
+		{"first\n..\nlast\n.\n", "first\n.\nlast"},
+		// End of input (Ctrl+D) finishes the answer too
+		{"no terminator\nat all", "no terminator\nat all"},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		req := server.NewPromptRequest("Commit message?", "tty")
+		req.Multiline = true
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil {
+			t.Fatalf("GetInput failed: %v", err)
+		}
+		if answer != tt.answer {
+			t.Errorf("Expected %q, got %q", tt.answer, answer)
+		}
+	}
+}
+
+func TestMultilineTTYLongLine(t *testing.T) {
+	line := strings.Repeat("x", 200*1024)
+	term := newFakeTerminal(line + "\n.\n")
+	req := server.NewPromptRequest("Paste", "tty")
+	req.Multiline = true
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != line {
+		t.Errorf("Expected a %d byte line, got %d bytes (%v)", len(line), len(answer), err)
+	}
+}
+
+func TestMultilineToolPreservesNewlines(t *testing.T) {
+	answer := "  indented: yes\nlist:\n  - a\n  - b\n" + strings.Repeat("line with \"quotes\" and\ttabs\n", 200)
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"YAML?","multiline":true}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: answer})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if text != answer {
+		t.Errorf("Expected the answer to be returned exactly, got %q", text)
+	}
+}
+
+func TestMultilineRejectsSecret(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Key?","multiline":true,"secret":true}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602, got %v", errorObj)
+	}
+}
+
+func TestMultilineWebTextarea(t *testing.T) {
+	req := server.NewPromptRequest("Commit message?", "web")
+	req.Multiline = true
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<textarea name="response"`) || strings.Contains(body, `<input type="text"`) {
+		t.Errorf("Expected a textarea instead of a text input, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"subject\r\n\r\nbody . line\r\n"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answer to be accepted, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != "subject\n\nbody . line\n" {
+		t.Errorf("Expected CRLF line breaks to be normalized, got %q", answer)
+	}
+}
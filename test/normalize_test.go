@@ -0,0 +1,143 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func normalizeCall(t *testing.T, args map[string]interface{}) string {
+	args["prompt"] = "Branch name?"
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_input",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestNormalizeTransforms(t *testing.T) {
+	tests := []struct {
+		normalize []interface{}
+		response  string
+		want      string
+	}{
+		{[]interface{}{"trim"}, "  Feature  ", "Feature"},
+		{[]interface{}{"lower"}, "MixedCase", "mixedcase"},
+		{[]interface{}{"upper"}, "abc-1", "ABC-1"},
+		{[]interface{}{"collapse_whitespace"}, "a  b\t\nc", "a b c"},
+		{[]interface{}{"collapse_whitespace", "trim"}, "  a   b  ", "a b"},
+		{[]interface{}{"trim", "lower", "upper"}, " Mixed ", "MIXED"},
+		{[]interface{}{"slug"}, "  Fix: the Login bug!! ", "fix-the-login-bug"},
+		{[]interface{}{"slug", "upper"}, "fix login", "FIX-LOGIN"},
+	}
+
+	for _, tt := range tests {
+		provider := &fakeProvider{response: tt.response}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", provider)
+
+		input := normalizeCall(t, map[string]interface{}{"normalize": tt.normalize, "method": "web"})
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.want {
+			t.Errorf("%v %q: expected %q, got %v", tt.normalize, tt.response, tt.want, text)
+		}
+		structured, _ := result["structuredContent"].(map[string]interface{})
+		if structured["raw"] != tt.response {
+			t.Errorf("%v %q: expected the raw answer in structuredContent, got %v", tt.normalize, tt.response, structured)
+		}
+		meta := result["_meta"].(map[string]interface{})["normalization"].(map[string]interface{})
+		if len(meta["transforms"].([]interface{})) != len(tt.normalize) {
+			t.Errorf("%v: expected the transforms in _meta, got %v", tt.normalize, meta)
+		}
+	}
+}
+
+func TestNormalizeSlugUnicode(t *testing.T) {
+	tests := []struct {
+		response string
+		want     string
+	}{
+		{"Über Äpfel 🍎 #42", "über-äpfel-42"},
+		{"ÉCOLE d'été", "école-d-été"},
+		{"Café Menu", "café-menu"},
+		{"日本語 テスト", "日本語-テスト"},
+		{"ΣΊΣΥΦΟΣ—Μύθος", "σίσυφοσ-μύθος"},
+		{"--already-a-slug--", "already-a-slug"},
+		{"١٢٣ abc", "١٢٣-abc"},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", &fakeProvider{response: tt.response})
+
+		input := normalizeCall(t, map[string]interface{}{"normalize": []interface{}{"slug"}, "method": "web"})
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.want {
+			t.Errorf("%q: expected %q, got %v", tt.response, tt.want, text)
+		}
+	}
+}
+
+func TestNormalizeBeforeValidation(t *testing.T) {
+	tests := []struct {
+		args      map[string]interface{}
+		responses []string
+		want      string
+	}{
+		// The pattern only accepts slugs, which the transform produces
+		{map[string]interface{}{"normalize": []interface{}{"slug"}, "pattern": "[a-z0-9-]+"}, []string{"New Feature"}, "new-feature"},
+		// Punctuation alone slugs to nothing and is asked again
+		{map[string]interface{}{"normalize": []interface{}{"slug"}}, []string{"!!!", "ok"}, "ok"},
+		{map[string]interface{}{"normalize": []interface{}{"lower"}, "allowed_values": []interface{}{"yes", "no"}}, []string{"YES"}, "yes"},
+		{map[string]interface{}{"normalize": []interface{}{"trim"}, "max_length": 3}, []string{"  abcd ", " abc "}, "abc"},
+		// The default is normalized too
+		{map[string]interface{}{"normalize": []interface{}{"slug"}, "default": "Main Line"}, []string{""}, "main-line"},
+	}
+
+	for _, tt := range tests {
+		provider := &fakeProvider{responses: tt.responses}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", provider)
+
+		tt.args["method"] = "web"
+		messages := parseMessages(t, runServer(t, srv, normalizeCall(t, tt.args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.want {
+			t.Errorf("%v %q: expected %q, got %v", tt.args, tt.responses, tt.want, text)
+		}
+	}
+}
+
+func TestNormalizeInvalidArguments(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"normalize": "slug"},
+		{"normalize": []interface{}{}},
+		{"normalize": []interface{}{"kebab"}},
+		{"normalize": []interface{}{"lower"}, "type": "number"},
+		{"normalize": []interface{}{"lower"}, "secret": true},
+		{"normalize": []interface{}{"lower"}, "response_schema": map[string]interface{}{"type": "object"}},
+		{"normalize": []interface{}{"slug"}, "pattern": "[a-z]+", "suggestions": []interface{}{"a b"}},
+	}
+
+	for _, args := range tests {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, normalizeCall(t, args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestNotifyUserReturnsImmediately(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify_user","arguments":{"message":"Deploy finished","method":"web"}}}`
+
+	// A prompt would block for the provider's delay; a notification must not
+	provider := &fakeProvider{delay: time.Hour}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false {
+		t.Fatalf("Expected success, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["delivered"] != true || structured["method"] != "web" {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if len(provider.notified) != 1 || provider.notified[0] != "Deploy finished" {
+		t.Errorf("Expected the message to be shown once, got %v", provider.notified)
+	}
+	if provider.attempts != 0 {
+		t.Errorf("Expected no input to be requested, got %d attempts", provider.attempts)
+	}
+}
+
+func TestNotifyUserRequiresMessage(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify_user","arguments":{"prompt":"Deploy finished"}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602, got %v", errorObj)
+	}
+}
+
+func TestNotifyUserUnsupportedProvider(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify_user","arguments":{"message":"Hi"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", providerFunc(func(ctx context.Context, req *server.PromptRequest) (string, error) {
+		return "", nil
+	}))
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || !strings.Contains(errorObj["message"].(string), "cannot show notifications") {
+		t.Errorf("Expected an error for a provider without notifications, got %v", errorObj)
+	}
+}
+
+func TestNotifyTTY(t *testing.T) {
+	term := newFakeTerminal("")
+	notifier := ttyProvider(term).(server.Notifier)
+
+	if err := notifier.Notify(context.Background(), server.NewPromptRequest("Migration starting", "tty")); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if output := term.output.String(); !strings.Contains(output, "[notification] Migration starting\n") {
+		t.Errorf("Expected the message on the terminal, got %q", output)
+	}
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestNumberInput(t *testing.T) {
+	tests := []struct {
+		args      string
+		responses []string
+		text      string
+		value     float64
+	}{
+		{`"type":"number"`, []string{"+1.50"}, "1.5", 1.5},
+		{`"type":"number"`, []string{"1,5", "abc", "1.5e3"}, "1500", 1500},
+		{`"type":"integer","minimum":1,"maximum":10`, []string{"2.5", "11", "7"}, "7", 7},
+		{`"type":"number","minimum":0,"default":"3"`, []string{""}, "3", 3},
+		{`"type":"number","maximum":-1`, []string{"0", "-1"}, "-1", -1},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How many?",` + tt.args + `}}}`
+
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{responses: tt.responses})
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.text {
+			t.Errorf("%s %q: expected text %q, got %v", tt.args, tt.responses, tt.text, text)
+		}
+		structured, _ := result["structuredContent"].(map[string]interface{})
+		if structured["value"] != tt.value {
+			t.Errorf("%s %q: expected value %v, got %v", tt.args, tt.responses, tt.value, structured)
+		}
+	}
+}
+
+func TestNumberInvalidArguments(t *testing.T) {
+	tests := []string{
+		`"type":"date"`,
+		`"type":"number","minimum":"1"`,
+		`"type":"number","minimum":5,"maximum":1`,
+		`"type":"integer","default":"1.5"`,
+		`"type":"number","default":"1,5"`,
+		`"type":"number","pattern":"\\d+"`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How many?",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestNumberRejectsComma(t *testing.T) {
+	opts := server.NumberOptions{}
+	if _, err := opts.ParseNumber("1,5"); err == nil || !strings.Contains(err.Error(), "decimal separator") {
+		t.Errorf("Expected a decimal separator hint, got %v", err)
+	}
+	for _, s := range []string{"1 000", "NaN", "Inf", "0x10", "1_000", "1e999"} {
+		if _, err := opts.ParseNumber(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
+
+func numericPrompt(method string) *server.PromptRequest {
+	min, max := 1.0, 10.0
+	req := server.NewPromptRequest("How many?", method)
+	req.MakeNumeric(server.NumberOptions{Integer: true, Minimum: &min, Maximum: &max})
+	return req
+}
+
+func TestNumberTTY(t *testing.T) {
+	term := newFakeTerminal("lots\n12\n4\n")
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), numericPrompt("tty"))
+	if err != nil || answer != "4" {
+		t.Fatalf("Expected 4, got %q (%v)", answer, err)
+	}
+	output := term.output.String()
+	for _, want := range []string{"Number (1-10): ", `"lots" is not a number`, "at most 10"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestNumberWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(numericPrompt("web"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<input type="number" name="response" value="" step="1" min="1" max="10"`) {
+		t.Errorf("Expected a bounded number input, got:\n%s", body)
+	}
+
+	// The browser's min/max can be bypassed, so the server checks too
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"0"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "at least 1") {
+		t.Errorf("Expected an out-of-range number to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"10"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 10 to be accepted, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != "10" {
+		t.Errorf("Expected 10, got %q", answer)
+	}
+}
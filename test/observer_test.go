@@ -0,0 +1,144 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// readEvent decodes the next observer event, failing the test after a second.
+func readEvent(t *testing.T, conn net.Conn, reader *bufio.Reader) server.ObserverEvent {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+
+	var ev server.ObserverEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		t.Fatalf("Failed to parse event %q: %v", line, err)
+	}
+	return ev
+}
+
+func TestObserverStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "obs.sock")
+	hub, err := server.ListenObservers(path, false)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer hub.Close()
+
+	early, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer early.Close()
+	earlyReader := bufio.NewReader(early)
+
+	// Writes from observers are ignored
+	early.Write([]byte("please answer for me\n"))
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?","method":"web"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetObserverHub(hub)
+	srv.SetInputProvider("web", &fakeProvider{response: "secret answer", delay: 300 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		runServer(t, srv, input)
+		close(done)
+	}()
+
+	created := readEvent(t, early, earlyReader)
+	if created.Type != server.EventPromptCreated || created.Prompt != "Deploy?" || created.Method != "web" {
+		t.Errorf("Expected prompt_created for Deploy?, got %+v", created)
+	}
+
+	// A late joiner gets the pending prompt replayed
+	late, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer late.Close()
+	lateReader := bufio.NewReader(late)
+
+	replayed := readEvent(t, late, lateReader)
+	if replayed.Type != server.EventPromptCreated || !replayed.Replay || replayed.PromptID != created.PromptID {
+		t.Errorf("Expected a replayed prompt_created, got %+v", replayed)
+	}
+
+	<-done
+
+	for _, c := range []struct {
+		conn   net.Conn
+		reader *bufio.Reader
+	}{{early, earlyReader}, {late, lateReader}} {
+		resolved := readEvent(t, c.conn, c.reader)
+		if resolved.Type != server.EventPromptResolved || resolved.Outcome != server.OutcomeAnswered {
+			t.Errorf("Expected prompt_resolved answered, got %+v", resolved)
+		}
+		if resolved.Response != "" {
+			t.Errorf("Expected answers to be excluded by default, got %q", resolved.Response)
+		}
+	}
+}
+
+func TestObserverIncludeAnswers(t *testing.T) {
+	hub := server.NewObserverHub(true)
+	client, conn := net.Pipe()
+	defer client.Close()
+	hub.Attach(conn)
+
+	go hub.Publish(server.ObserverEvent{Type: server.EventPromptResolved, PromptID: 1, Response: "yes"})
+
+	ev := readEvent(t, client, bufio.NewReader(client))
+	if ev.Response != "yes" {
+		t.Errorf("Expected the answer to be included, got %+v", ev)
+	}
+}
+
+func TestSlowObserverDisconnected(t *testing.T) {
+	hub := server.NewObserverHub(false)
+
+	// net.Pipe is unbuffered, so an observer that never reads blocks every write
+	slow, conn := net.Pipe()
+	defer slow.Close()
+	hub.Attach(conn)
+
+	published := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			hub.Publish(server.ObserverEvent{Type: server.EventPromptCreated, PromptID: int64(i)})
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		t.Fatal("A slow observer blocked publishing")
+	}
+
+	// The connection was closed rather than left to catch up
+	slow.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(slow)
+	count := 0
+	for {
+		if _, err := reader.ReadBytes('\n'); err != nil {
+			break
+		}
+		count++
+	}
+	if count >= 1000 {
+		t.Errorf("Expected the slow observer to be cut off, but it received all %d events", count)
+	}
+}
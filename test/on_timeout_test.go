@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestUserConfirmOnTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]interface{}
+		structured map[string]interface{}
+		text       string
+	}{
+		{"deny", map[string]interface{}{"on_timeout": "deny"},
+			map[string]interface{}{"answer": "no", "confirmed": false, "timedOut": true}, "no (denied automatically: no answer within"},
+		{"approve", map[string]interface{}{"on_timeout": "approve"},
+			map[string]interface{}{"answer": "yes", "confirmed": true, "timedOut": true}, "yes (approved automatically: no answer within"},
+		{"phrase", map[string]interface{}{"on_timeout": "deny", "confirmation_phrase": "prod"},
+			map[string]interface{}{"answer": "no", "confirmed": false, "approved": false, "attemptsExhausted": false, "timedOut": true}, "no (denied automatically"},
+	}
+
+	for _, tt := range tests {
+		// The provider never answers, so only the timeout ends the prompt
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "yes", delay: time.Hour})
+
+		tt.args["prompt"] = "Deploy to production?"
+		tt.args["timeout"] = 0.01
+		messages := parseMessages(t, runServer(t, srv, confirmCall(t, tt.args)).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: expected a result, got %v", tt.name, findResponse(t, messages, 1))
+		}
+		if structured := result["structuredContent"]; !reflect.DeepEqual(structured, tt.structured) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.structured, structured)
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string); !strings.HasPrefix(text, tt.text) {
+			t.Errorf("%s: unexpected text %q", tt.name, text)
+		}
+	}
+}
+
+func TestUserConfirmOnTimeoutError(t *testing.T) {
+	for _, onTimeout := range []string{"", "error"} {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "yes", delay: time.Hour})
+
+		args := map[string]interface{}{"prompt": "Deploy?", "timeout": 0.01}
+		if onTimeout != "" {
+			args["on_timeout"] = onTimeout
+		}
+		messages := parseMessages(t, runServer(t, srv, confirmCall(t, args)).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok || result["isError"] != true {
+			t.Errorf("%q: expected the usual timeout error, got %v", onTimeout, findResponse(t, messages, 1))
+		}
+	}
+}
+
+func TestUserConfirmOnTimeoutInvalid(t *testing.T) {
+	for name, args := range map[string]map[string]interface{}{
+		"bad value":      {"on_timeout": "ignore", "timeout": 5},
+		"not a string":   {"on_timeout": true, "timeout": 5},
+		"no timeout":     {"on_timeout": "deny", "method": "tty"},
+		"waits forever":  {"on_timeout": "deny", "timeout": 0},
+		"bad timeout":    {"timeout": -1},
+		"approve phrase": {"on_timeout": "approve", "timeout": 5, "confirmation_phrase": "prod"},
+		"with follow_up": {"on_timeout": "deny", "timeout": 5, "follow_up": map[string]interface{}{"prompt": "Why?"}},
+	} {
+		args["prompt"] = "Deploy?"
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, confirmCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", name, errObj)
+		}
+	}
+}
+
+func TestWebCountdownAutoDecision(t *testing.T) {
+	for answer, want := range map[string]string{"no": "Auto-denying in", "yes": "Auto-approving in"} {
+		req := server.NewConfirmPrompt("Deploy?", "web", "")
+		decision := answer
+		req.TimeoutResponse = &decision
+		handler := server.NewWebInputHandler(req)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		body := rec.Body.String()
+		if !strings.Contains(body, want+` <span id="remaining"></span></div>`) {
+			t.Errorf("%s: expected %q in the countdown, got:\n%s", answer, want, body)
+		}
+		if strings.Contains(body, "will be used") {
+			t.Errorf("%s: expected the decision to replace the default notice", answer)
+		}
+	}
+}
+
+func TestWebSubmissionInFlightAtDeadline(t *testing.T) {
+	// The deadline is a context the test cancels, and the answer is held
+	// mid-validation until the prompt has expired, so the race always
+	// plays out the same way
+	req := server.NewConfirmPrompt("Deploy?", "web", "")
+	arrived, release := make(chan struct{}), make(chan struct{})
+	validate := req.Validate
+	req.Validate = func(response string) (string, error) {
+		if response == "yes" {
+			close(arrived)
+			<-release
+		}
+		return validate(response)
+	}
+	handler := server.NewWebInputHandler(req)
+
+	code := make(chan int, 1)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+		code <- rec.Code
+	}()
+	<-arrived
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	go func() {
+		// Invalid answers are rejected until the prompt expires, then
+		// turned away
+		for {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"maybe"}}))
+			if rec.Code == http.StatusGone {
+				break
+			}
+		}
+		close(release)
+	}()
+
+	answer, err := handler.Wait(ctx)
+	if err != nil || answer != "yes" {
+		t.Errorf("Expected the submission that arrived in time to count, got %q (%v)", answer, err)
+	}
+	if submitted := <-code; submitted != http.StatusOK {
+		t.Errorf("Expected the submission to be accepted, got %d", submitted)
+	}
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestUserChoiceOtherAnswer(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which region?","options":["us-east","eu-west"],"allow_other":true,"pattern":"[a-z]+-[a-z]+","max_length":12}}}`
+
+	provider := &fakeProvider{responses: []string{
+		server.OtherResponse("  "),
+		server.OtherResponse("Tokyo"),
+		server.OtherResponse("ap-northeast-1"),
+		server.OtherResponse(" ap-south "),
+	}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	cfg := server.DefaultConfig()
+	cfg.MaxAttempts = 5
+	srv.SetConfig(cfg)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "Other: ap-south" {
+		t.Errorf("Expected the custom answer to be marked, got %v", text)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["selected"] != "other" || structured["text"] != "ap-south" || structured["custom"] != true || structured["index"] != float64(-1) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if provider.attempts != 4 {
+		t.Errorf("Expected empty, unmatched and overlong answers to be re-asked, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserChoiceOtherCannedOption(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":{"prompt":"Which region?","options":["us-east","eu-west"],"allow_other":true}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "2"})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "eu-west" {
+		t.Errorf("Expected the option, got %v", text)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["selected"] != "eu-west" || structured["custom"] != false || structured["index"] != float64(1) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+}
+
+func TestUserChoiceOtherInvalidArguments(t *testing.T) {
+	tests := []string{
+		`{"prompt":"Which?","options":["a","b"],"allow_other":true,"multi_select":true}`,
+		`{"prompt":"Which?","options":["a","b"],"pattern":"x"}`,
+		`{"prompt":"Which?","options":["a","b"],"allow_other":true,"pattern":"("}`,
+		`{"prompt":"Which?","options":["a","b"],"allow_other":true,"max_length":0}`,
+		`{"prompt":"Which?","options":["a","b"],"allow_other":"yes"}`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_choice","arguments":` + args + `}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestOtherChoiceTTY(t *testing.T) {
+	term := newFakeTerminal("3\n\nMango\n")
+	req := server.NewOtherChoicePrompt("Pick a fruit", "tty", []string{"apple", "pear"}, nil)
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != `{"other":"Mango"}` {
+		t.Errorf("Expected the typed answer, got %q", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"1) apple", "3) Other…", "Choice [1-3]: ", "Other: ", "Please type your answer for Other"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected terminal output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// Options are still picked by number or name
+	answer, err = ttyProvider(newFakeTerminal("pear\n")).GetInput(context.Background(), server.NewOtherChoicePrompt("Pick a fruit", "tty", []string{"apple", "pear"}, nil))
+	if err != nil || answer != `{"option":"pear"}` {
+		t.Errorf("Expected pear, got %q (%v)", answer, err)
+	}
+}
+
+func TestOtherChoiceWeb(t *testing.T) {
+	req := server.NewOtherChoicePrompt("Pick a fruit", "web", []string{"apple", "pear"}, nil)
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `value="other"`) || !strings.Contains(body, `name="other"`) {
+		t.Errorf("Expected an Other radio and text input, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"other"}, "other": {"Mango"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the custom answer to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != `{"other":"Mango"}` {
+		t.Errorf("Expected the typed answer, got %q (%v)", answer, err)
+	}
+}
+
+func TestOtherChoiceWebKeepsRejectedText(t *testing.T) {
+	req := server.NewOtherChoicePrompt("Pick a fruit", "web", []string{"apple", "pear"}, func(text string) error {
+		if len(text) > 3 {
+			return errors.New("too long")
+		}
+		return nil
+	})
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"other"}, "other": {"Mango"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `value="Mango"`) {
+		t.Errorf("Expected the form to keep the rejected text, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestPasteWeb(t *testing.T) {
+	req := server.NewPromptRequest("Paste the stack trace", "web")
+	req.MaxAnswerBytes = 64 * 1024
+	handler := server.NewWebInputHandler(req)
+
+	page := pageGet(handler, "/").Body.String()
+	for _, want := range []string{`data-paste-max="65536"`, `data-paste-limit="64 KiB"`, `id="paste-counter"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected %s on the page, got:\n%s", want, page)
+		}
+	}
+
+	// A pasted trace comes back as it was typed, tabs, trailing spaces and
+	// all; only the browser's CRLF line breaks are undone
+	trace := "panic: runtime error\r\n\tgoroutine 1 [running]:\r\n\tmain.main()  \r\n"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {trace}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the trace accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != strings.ReplaceAll(trace, "\r\n", "\n") {
+		t.Errorf("Expected the trace verbatim, got %q (%v)", answer, err)
+	}
+}
+
+func TestPasteWebCap(t *testing.T) {
+	req := server.NewPromptRequest("Paste the log", "web")
+	req.MaxAnswerBytes = 1024
+	handler := server.NewWebInputHandler(req)
+	answered := func() bool {
+		return !strings.Contains(pageGet(handler, "/").Body.String(), "Paste the log")
+	}
+
+	// Over the cap, whether the body is cut off on the way or decodes to
+	// too much, the form says so and the prompt stays open
+	for _, response := range []string{strings.Repeat("x", 100*1024), strings.Repeat("line\n", 300)} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {response}}))
+		if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "The answer is larger than 1 KiB") {
+			t.Errorf("Expected the answer refused as too large, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+		if answered() {
+			t.Fatal("Expected the prompt still open")
+		}
+	}
+}
+
+func TestPasteWebTextarea(t *testing.T) {
+	req := server.NewPromptRequest("Paste the log", "web")
+	req.Validate = func(response string) (string, error) {
+		return "", errors.New("Not a log")
+	}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"first\r\nsecond"}}))
+	body := rec.Body.String()
+	if rec.Code != http.StatusBadRequest || !strings.Contains(body, "<textarea") || !strings.Contains(body, "first\nsecond</textarea>") {
+		t.Errorf("Expected the pasted answer back in a textarea, got %d:\n%s", rec.Code, body)
+	}
+
+	// Secrets are never pasted into
+	secret := server.NewPromptRequest("Token?", "web")
+	secret.Secret = true
+	if page := pageGet(server.NewWebInputHandler(secret), "/").Body.String(); strings.Contains(page, "data-paste-max") {
+		t.Errorf("Expected no paste target on a secret, got:\n%s", page)
+	}
+}
+
+func TestPasteCapConfig(t *testing.T) {
+	provider := &fakeProvider{response: "ok"}
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{MaxAnswerBytes: 4096})
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste the log"}}}`)
+	if provider.lastReq == nil || provider.lastReq.MaxAnswerBytes != 4096 {
+		t.Errorf("Expected the configured cap on the prompt, got %+v", provider.lastReq)
+	}
+	if err := (server.Config{MaxAnswerBytes: -1}).Validate(); err == nil {
+		t.Error("Expected a negative cap to be refused")
+	}
+}
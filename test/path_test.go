@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// rawTerminal is a fake terminal that can switch to key-at-a-time input,
+// which enables the path line editor.
+type rawTerminal struct {
+	*fakeTerminal
+	raw bool
+}
+
+func (r *rawTerminal) RawMode() (func(), error) {
+	r.raw = true
+	return func() { r.raw = false }, nil
+}
+
+func rawTTYProvider(term *rawTerminal) server.InputProvider {
+	return server.NewTTYProvider(func() (io.ReadWriteCloser, error) {
+		return term, nil
+	})
+}
+
+func pathInputCall(args map[string]interface{}) string {
+	args["prompt"] = "Which file?"
+	args["type"] = "path"
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "user_input", "arguments": args},
+	})
+	return string(data)
+}
+
+func TestCompletePath(t *testing.T) {
+	root := fileTree(t)
+	sep := string(filepath.Separator)
+	if err := os.WriteFile(filepath.Join(root, ".env"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		restrict bool
+		input    string
+		want     string
+		matches  []string
+	}{
+		{false, "d", "docs" + sep, nil},
+		{false, "docs" + sep + "r", filepath.Join("docs", "readme.md"), nil},
+		{false, "", "", []string{"docs" + sep, "escape" + sep, "src" + sep}},
+		{false, ".", ".env", nil},
+		{false, "x", "x", nil},
+		{true, "", "", []string{"docs" + sep, "src" + sep}},
+		{true, "e", "e", nil},
+		{true, ".." + sep, ".." + sep, nil},
+	}
+
+	for _, tt := range tests {
+		opts := &server.FileOptions{StartDir: root, Restrict: tt.restrict}
+		got, matches := opts.Complete(tt.input)
+		if got != tt.want || !reflect.DeepEqual(matches, tt.matches) {
+			t.Errorf("Complete(%q) restrict=%v = %q, %v; want %q, %v", tt.input, tt.restrict, got, matches, tt.want, tt.matches)
+		}
+	}
+}
+
+func TestTTYPathTabCompletion(t *testing.T) {
+	root := fileTree(t)
+
+	term := &rawTerminal{fakeTerminal: newFakeTerminal("do\tre\t\n")}
+	req, err := server.NewFilePrompt("Which file?", "tty", server.FileOptions{StartDir: root, MustExist: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := rawTTYProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if want := filepath.Join(root, "docs", "readme.md"); answer != want {
+		t.Errorf("expected %s, got %q", want, answer)
+	}
+	if term.raw {
+		t.Error("raw mode was not restored")
+	}
+	if out := term.output.String(); !strings.Contains(out, "(Tab completes)") {
+		t.Errorf("expected the completion hint, got %q", out)
+	}
+}
+
+func TestTTYPathListsMatches(t *testing.T) {
+	root := fileTree(t)
+
+	term := &rawTerminal{fakeTerminal: newFakeTerminal("\tsrc\n")}
+	req, err := server.NewFilePrompt("Which file?", "tty", server.FileOptions{StartDir: root, Restrict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := rawTTYProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if want := filepath.Join(root, "src"); answer != want {
+		t.Errorf("expected %s, got %q", want, answer)
+	}
+
+	sep := string(filepath.Separator)
+	out := term.output.String()
+	if !strings.Contains(out, "docs"+sep+"  src"+sep) {
+		t.Errorf("expected the matches to be listed, got %q", out)
+	}
+	if strings.Contains(out, "escape") {
+		t.Errorf("completion offered an entry outside the root: %q", out)
+	}
+}
+
+func TestTTYPathEditing(t *testing.T) {
+	root := fileTree(t)
+
+	// Backspace, Ctrl-W and an arrow key before the final answer
+	term := &rawTerminal{fakeTerminal: newFakeTerminal("srx\x7fc/foo\x17\x1b[Ddocs\n")}
+	req, err := server.NewFilePrompt("Which file?", "tty", server.FileOptions{StartDir: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := rawTTYProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if want := filepath.Join(root, "src", "docs"); answer != want {
+		t.Errorf("expected %s, got %q", want, answer)
+	}
+}
+
+func TestTTYPathRetriesOutsideRoot(t *testing.T) {
+	root := fileTree(t)
+
+	term := &rawTerminal{fakeTerminal: newFakeTerminal("../outside\ndocs\n")}
+	req, err := server.NewFilePrompt("Which file?", "tty", server.FileOptions{StartDir: root, Restrict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer, err := rawTTYProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput: %v", err)
+	}
+	if want := filepath.Join(root, "docs"); answer != want {
+		t.Errorf("expected %s, got %q", want, answer)
+	}
+	if out := term.output.String(); !strings.Contains(out, "is outside") {
+		t.Errorf("expected the outside path to be rejected, got %q", out)
+	}
+}
+
+func TestUserInputPathType(t *testing.T) {
+	root := fileTree(t)
+
+	tests := []struct {
+		args     map[string]interface{}
+		response string
+		path     string
+		reason   string
+	}{
+		{map[string]interface{}{"root": root}, "docs/readme.md", filepath.Join(root, "docs", "readme.md"), ""},
+		{map[string]interface{}{"root": root}, "missing.txt", "", "does not exist"},
+		{map[string]interface{}{"root": root}, "../outside", "", "is outside"},
+		{map[string]interface{}{}, root, root, ""},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetConfig(server.Config{MaxAttempts: 1})
+		srv.SetInputProvider("tty", &fakeProvider{response: tt.response})
+
+		messages := parseMessages(t, runServer(t, srv, pathInputCall(tt.args)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+
+		if tt.reason != "" {
+			if result["isError"] != true || !strings.Contains(text, tt.reason) {
+				t.Errorf("%q: expected rejection %q, got %v", tt.response, tt.reason, result)
+			}
+			continue
+		}
+		if text != tt.path {
+			t.Errorf("%q: expected %s, got %v", tt.response, tt.path, text)
+		}
+	}
+}
+
+func TestUserInputPathInvalidArguments(t *testing.T) {
+	root := fileTree(t)
+
+	tests := []struct {
+		args    map[string]interface{}
+		message string
+	}{
+		{map[string]interface{}{"root": filepath.Join(root, "docs", "readme.md")}, "Invalid root parameter"},
+		{map[string]interface{}{"pattern": "^a"}, "Invalid pattern parameter"},
+		{map[string]interface{}{"multiline": true}, "Invalid multiline parameter"},
+		{map[string]interface{}{"secret": true}, "Invalid secret parameter"},
+		{map[string]interface{}{"default": "docs"}, "Invalid default parameter"},
+		{map[string]interface{}{"response_schema": map[string]interface{}{"type": "string"}}, "Invalid response_schema parameter"},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "docs"})
+
+		messages := parseMessages(t, runServer(t, srv, pathInputCall(tt.args)).String())
+		resp := findResponse(t, messages, 1)
+		errObj, ok := resp["error"].(map[string]interface{})
+		if !ok || !strings.Contains(errObj["message"].(string), tt.message) {
+			t.Errorf("%v: expected %q error, got %v", tt.args, tt.message, resp)
+		}
+	}
+
+	// root only makes sense for paths
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "x"})
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "user_input", "arguments": map[string]interface{}{"prompt": "Hi", "root": root}},
+	})
+	resp := findResponse(t, parseMessages(t, runServer(t, srv, string(data)).String()), 1)
+	if _, ok := resp["error"]; !ok {
+		t.Errorf("expected root without type path to be rejected, got %v", resp)
+	}
+}
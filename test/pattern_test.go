@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const semverCall = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Version?","pattern":"v?\\d+\\.\\d+\\.\\d+","validation_message":"Enter a semver like 1.2.3"}}}`
+
+func TestPatternReprompts(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"1.2", " 1.2.3 "}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, semverCall).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false {
+		t.Fatalf("Expected success, got %v", result)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "1.2.3" {
+		t.Errorf("Expected the trimmed answer, got %v", text)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", provider.attempts)
+	}
+}
+
+func TestPatternGivesUp(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"one", "two", "three", "1.2.3"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, semverCall).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != true {
+		t.Fatalf("Expected an isError result, got %v", result)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "Validation failed after 3 attempts") || !strings.Contains(text, "Enter a semver like 1.2.3") || !strings.Contains(text, `"three"`) {
+		t.Errorf("Expected the failure to explain itself, got %q", text)
+	}
+	if provider.attempts != 3 {
+		t.Errorf("Expected the user to be asked 3 times, got %d", provider.attempts)
+	}
+}
+
+func TestPatternMaxAttemptsConfig(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"one", "two", "three", "1.2.3"}}
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{MaxAttempts: -1})
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, semverCall).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "1.2.3" {
+		t.Errorf("Expected a negative limit to keep asking, got %v", result)
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Version?","pattern":"(unclosed"}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) || !strings.Contains(errorObj["message"].(string), "Invalid pattern parameter") {
+		t.Errorf("Expected -32602 for a bad pattern, got %v", errorObj)
+	}
+}
+
+func TestPatternTTY(t *testing.T) {
+	term := newFakeTerminal("main branch\nfeature/x\n")
+	req := server.NewPromptRequest("Branch?", "tty")
+	req.Validate = mustPatternValidator(t, req, `[\w./-]+`, "No spaces please")
+	req.MaxAttempts = 3
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), req)
+	if err != nil || answer != "feature/x" {
+		t.Fatalf("Expected feature/x, got %q (%v)", answer, err)
+	}
+	if !strings.Contains(term.output.String(), "No spaces please") {
+		t.Errorf("Expected the validation message, got:\n%s", term.output.String())
+	}
+
+	term = newFakeTerminal("a b\nc d\n")
+	req.MaxAttempts = 2
+	_, err = server.Ask(context.Background(), ttyProvider(term), req)
+	var verr *server.ValidationError
+	if !errors.As(err, &verr) || verr.Attempts != 2 || verr.Value != "c d" {
+		t.Errorf("Expected a ValidationError after 2 attempts, got %v", err)
+	}
+}
+
+func TestPatternWeb(t *testing.T) {
+	req := server.NewPromptRequest("Branch?", "web")
+	req.Validate = mustPatternValidator(t, req, `[\w./-]+`, "No spaces please")
+	req.MaxAttempts = 2
+
+	var handler *server.WebInputHandler
+	provider := providerFunc(func(ctx context.Context, limited *server.PromptRequest) (string, error) {
+		handler = server.NewWebInputHandler(limited)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"a b"}}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "No spaces please") || !strings.Contains(rec.Body.String(), `value="a b"`) {
+			t.Errorf("Expected the form to be re-rendered with the error, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"c d"}}))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Too many invalid attempts") {
+			t.Errorf("Expected the last attempt to end the prompt, got %d:\n%s", rec.Code, rec.Body.String())
+		}
+		return handler.Wait(ctx)
+	})
+
+	_, err := server.Ask(context.Background(), provider, req)
+	var verr *server.ValidationError
+	if !errors.As(err, &verr) || verr.Attempts != 2 {
+		t.Errorf("Expected a ValidationError, got %v", err)
+	}
+}
+
+// providerFunc adapts a function to server.InputProvider.
+type providerFunc func(ctx context.Context, req *server.PromptRequest) (string, error)
+
+func (f providerFunc) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	return f(ctx, req)
+}
+
+func mustPatternValidator(t *testing.T, req *server.PromptRequest, pattern, message string) func(string) (string, error) {
+	validate, err := server.NewPatternValidator(req, pattern, message)
+	if err != nil {
+		t.Fatalf("NewPatternValidator failed: %v", err)
+	}
+	return validate
+}
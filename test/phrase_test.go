@@ -0,0 +1,172 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func phraseCall(t *testing.T, tool string, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      tool,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestConfirmationPhrase(t *testing.T) {
+	tests := []struct {
+		name       string
+		tool       string
+		ignoreCase bool
+		responses  []string
+		approved   bool
+		exhausted  bool
+	}{
+		{"exact", "user_confirm", false, []string{"acme/prod"}, true, false},
+		{"retry", "user_input", false, []string{"acme/pro", "acme/prod"}, true, false},
+		{"wrong case", "user_confirm", false, []string{"ACME/PROD", "Acme/Prod", "yes"}, false, true},
+		{"case insensitive", "user_confirm", true, []string{"ACME/PROD"}, true, false},
+		{"empty denies", "user_input", false, []string{""}, false, false},
+		{"yes is not enough", "user_input", false, []string{"yes", "y", "yes"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{responses: tt.responses}
+			srv := &server.MCPServer{}
+			srv.SetInputProvider("tty", provider)
+
+			args := map[string]interface{}{"prompt": "Delete the repository?", "confirmation_phrase": "acme/prod"}
+			if tt.ignoreCase {
+				args["case_insensitive"] = true
+			}
+			messages := parseMessages(t, runServer(t, srv, phraseCall(t, tt.tool, args)).String())
+			result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+			if result["isError"] != false {
+				t.Fatalf("Expected a decision, not an error: %v", result)
+			}
+			structured := result["structuredContent"].(map[string]interface{})
+			if structured["approved"] != tt.approved || structured["confirmed"] != tt.approved || structured["attemptsExhausted"] != tt.exhausted {
+				t.Errorf("Unexpected structuredContent %v", structured)
+			}
+			if provider.lastReq.Phrase == nil || provider.lastReq.Phrase.Phrase != "acme/prod" {
+				t.Errorf("Expected the phrase to reach the provider, got %+v", provider.lastReq.Phrase)
+			}
+		})
+	}
+}
+
+func TestConfirmationPhraseUnlimitedAttemptsStillEnds(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{MaxAttempts: -1})
+	srv.SetInputProvider("tty", &fakeProvider{responses: []string{"a", "b", "c", "acme/prod"}})
+
+	messages := parseMessages(t, runServer(t, srv, phraseCall(t, "user_confirm", map[string]interface{}{"prompt": "Delete?", "confirmation_phrase": "acme/prod"})).String())
+	structured := findResponse(t, messages, 1)["result"].(map[string]interface{})["structuredContent"].(map[string]interface{})
+	if structured["approved"] != false || structured["attemptsExhausted"] != true {
+		t.Errorf("Expected a denial after the default number of attempts, got %v", structured)
+	}
+}
+
+func TestConfirmationPhraseInvalidArguments(t *testing.T) {
+	tests := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"user_confirm", map[string]interface{}{"confirmation_phrase": ""}},
+		{"user_confirm", map[string]interface{}{"confirmation_phrase": " prod "}},
+		{"user_confirm", map[string]interface{}{"confirmation_phrase": "prod", "default": "yes"}},
+		{"user_confirm", map[string]interface{}{"confirmation_phrase": "prod", "case_insensitive": "yes"}},
+		{"user_input", map[string]interface{}{"confirmation_phrase": "prod", "pattern": "prod"}},
+		{"user_input", map[string]interface{}{"confirmation_phrase": "prod", "secret": true}},
+		{"user_input", map[string]interface{}{"confirmation_phrase": 7}},
+	}
+
+	for _, tt := range tests {
+		tt.args["prompt"] = "Delete?"
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "prod"})
+
+		messages := parseMessages(t, runServer(t, srv, phraseCall(t, tt.tool, tt.args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s %v: expected an invalid params error, got %v", tt.tool, tt.args, errObj)
+		}
+	}
+}
+
+func TestConfirmationPhraseTTY(t *testing.T) {
+	term := newFakeTerminal("acme\nacme/prod\n")
+	prompt := server.NewConfirmPrompt("Delete?", "tty", "")
+	prompt.RequirePhrase(server.ConfirmationPhrase{Phrase: "acme/prod"})
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), prompt)
+	if err != nil || answer != server.AnswerYes {
+		t.Fatalf("Expected yes, got %q, %v", answer, err)
+	}
+	out := term.output.String()
+	if !strings.Contains(out, `Type "acme/prod" to confirm, or press Enter to deny: `) || !strings.Contains(out, "That does not match") {
+		t.Errorf("Unexpected terminal output %q", out)
+	}
+}
+
+func TestConfirmationPhraseWeb(t *testing.T) {
+	newHandler := func() *server.WebInputHandler {
+		prompt := server.NewConfirmPrompt("Delete?", "web", "")
+		prompt.RequirePhrase(server.ConfirmationPhrase{Phrase: "acme/prod"})
+		return server.NewWebInputHandler(prompt)
+	}
+
+	handler := newHandler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-phrase="acme/prod"`) || !strings.Contains(body, `id="phrase-confirm" class="deny" disabled`) {
+		t.Errorf("Expected a phrase input and a disabled confirm button, got %s", body)
+	}
+	if strings.Contains(body, `value="yes"`+">Approve") {
+		t.Error("Expected no plain Approve button")
+	}
+
+	// The server checks the phrase whatever the page allowed
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "does not match") {
+		t.Errorf("Expected a wrong phrase to be rejected, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"acme/prod"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the phrase to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != server.AnswerYes {
+		t.Errorf("Expected yes, got %q (%v)", answer, err)
+	}
+
+	// Cancel denies even with the phrase typed in
+	handler = newHandler()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"acme/prod"}, "deny": {"yes"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the denial to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != server.AnswerNo {
+		t.Errorf("Expected no, got %q (%v)", answer, err)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func placeholderCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Version?"` + args + `}}}`
+}
+
+func TestPlaceholderTTY(t *testing.T) {
+	tests := []struct {
+		setup func(*server.PromptRequest)
+		label string
+	}{
+		{func(r *server.PromptRequest) {}, "Response (e.g. v1.2.3): "},
+		{func(r *server.PromptRequest) { r.Default = "v1.0.0" }, "Response (e.g. v1.2.3) [v1.0.0]: "},
+		{func(r *server.PromptRequest) { r.MakeNumeric(server.NumberOptions{}) }, "Number (e.g. v1.2.3): "},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal("1\n")
+		req := server.NewPromptRequest("Version?", "tty")
+		req.Placeholder = "v1.2.3"
+		tt.setup(req)
+
+		if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+			t.Fatalf("GetInput: %v", err)
+		}
+		if out := term.output.String(); !strings.Contains(out, tt.label) {
+			t.Errorf("Expected label %q, got %q", tt.label, out)
+		}
+	}
+}
+
+func TestPlaceholderIsNeverTheAnswer(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"", "v2.0.0"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, placeholderCall(`,"placeholder":"v1.2.3"`)).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "v2.0.0" {
+		t.Errorf("Expected the typed answer, got %v", text)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected an empty answer to be asked again, got %d attempts", provider.attempts)
+	}
+	if provider.lastReq.Placeholder != "v1.2.3" {
+		t.Errorf("Expected the placeholder on the prompt, got %q", provider.lastReq.Placeholder)
+	}
+}
+
+func TestPlaceholderWebEscaping(t *testing.T) {
+	for _, multiline := range []bool{false, true} {
+		req := server.NewPromptRequest("Version?", "web")
+		req.Placeholder = `"><script>alert(1)</script> & 'v1'`
+		req.Multiline = multiline
+		handler := server.NewWebInputHandler(req)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		body := rec.Body.String()
+
+		if strings.Contains(body, "<script>alert(1)") {
+			t.Errorf("multiline %v: placeholder was not escaped:\n%s", multiline, body)
+		}
+		if !strings.Contains(body, `placeholder="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt; &amp; &#39;v1&#39;"`) {
+			t.Errorf("multiline %v: expected the escaped placeholder attribute, got:\n%s", multiline, body)
+		}
+		if strings.Contains(body, "Enter your response...") {
+			t.Errorf("multiline %v: expected the placeholder to replace the generic one", multiline)
+		}
+	}
+
+	// Without one the generic placeholder stays
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Version?", "web"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `placeholder="Enter your response..."`) {
+		t.Error("Expected the generic placeholder")
+	}
+}
+
+func TestPlaceholderInvalidArguments(t *testing.T) {
+	tests := []string{
+		`,"placeholder":42`,
+		`,"placeholder":"two\nlines"`,
+		`,"placeholder":"/tmp","type":"path"`,
+		`,"placeholder":"x","response_schema":{"type":"object","properties":{"a":{"type":"string"}}}`,
+	}
+	for _, args := range tests {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, placeholderCall(args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
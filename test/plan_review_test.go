@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func planReviewCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_plan_review",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserPlanReviewDecisions(t *testing.T) {
+	tests := []struct {
+		responses    []string
+		decision     string
+		approved     bool
+		instructions string
+		text         string
+	}{
+		{[]string{`{"decision":"approve","instructions":"ignored"}`}, "approve", true, "", "approve"},
+		{[]string{`{"decision":"deny"}`}, "deny", false, "", "deny"},
+		{[]string{`{"decision":"revise","instructions":" "}`, `{"decision":"revise","instructions":"split step 2"}`}, "revise", false, "split step 2", "revise: split step 2"},
+		{[]string{`{"decision":"maybe"}`, `{"decision":"deny"}`}, "deny", false, "", "deny"},
+	}
+
+	for _, tt := range tests {
+		provider := &fakeProvider{responses: tt.responses}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", provider)
+
+		input := planReviewCall(t, map[string]interface{}{"plan": "1. Do it\n", "method": "web"})
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["decision"] != tt.decision || structured["approved"] != tt.approved || structured["instructions"] != tt.instructions {
+			t.Errorf("%v: unexpected structuredContent %v", tt.responses, structured)
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.text {
+			t.Errorf("%v: expected text %q, got %v", tt.responses, tt.text, text)
+		}
+		if int(atomic.LoadInt32(&provider.attempts)) != len(tt.responses) {
+			t.Errorf("%v: expected %d attempts, got %d", tt.responses, len(tt.responses), provider.attempts)
+		}
+	}
+}
+
+func TestUserPlanReviewInvalidArguments(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{},
+		{"plan": "  \n"},
+		{"plan": 42},
+		{"plan": "1. Do it", "prompt": 7},
+		{"plan": "1. Do it", "timeout": -1},
+	} {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, planReviewCall(t, args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestPlanReviewTTY(t *testing.T) {
+	tests := []struct {
+		input  string
+		answer string
+	}{
+		{"a\n", `{"decision":"approve"}`},
+		{"d\n", `{"decision":"deny"}`},
+		{"x\nr\nuse a queue\n", `{"decision":"revise","instructions":"use a queue"}`},
+		{"r\n\nr\ncache it\n", `{"decision":"revise","instructions":"cache it"}`},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		req := server.NewPlanReviewPrompt("Proceed?", "tty", "1. Read\n2. Write\n")
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%q: GetInput failed: %v", tt.input, err)
+		}
+		if answer != tt.answer {
+			t.Errorf("%q: unexpected answer %s", tt.input, answer)
+		}
+		if output := term.output.String(); !strings.Contains(output, "2. Write") || !strings.Contains(output, "[a]pprove, [d]eny, [r]evise: ") {
+			t.Errorf("%q: expected the plan and the decision prompt, got:\n%s", tt.input, output)
+		}
+	}
+}
+
+func TestPlanReviewWeb(t *testing.T) {
+	req := server.NewPlanReviewPrompt("Proceed?", "web", "1. Read <config>\n")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<pre class="review">1. Read &lt;config&gt;`,
+		`<textarea name="instructions" id="instructions"`,
+		`value="approve" class="plan-decision">Approve</button>`,
+		`value="revise" class="plan-decision">Revise</button>`,
+		`value="deny" class="plan-decision deny">Deny</button>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"decision": {"revise"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Please describe the changes you want") {
+		t.Errorf("Expected a revision without instructions to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"decision": {"revise"}, "instructions": {"skip step 1\r\nthen retry"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the review to be accepted, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != `{"decision":"revise","instructions":"skip step 1\nthen retry"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+}
+
+func TestPlanReviewWebLargePlan(t *testing.T) {
+	// Several megabytes of plan; only the decision is posted back
+	plan := strings.Repeat("- step with <angle> & \"quotes\"\n", 200000)
+	handler := server.NewWebInputHandler(server.NewPlanReviewPrompt("Proceed?", "web", plan))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(rec.Body.String(), strings.Repeat("- step with &lt;angle&gt; &amp; &#34;quotes&#34;\n", 1000)) {
+		t.Error("Expected the whole plan to be rendered, escaped")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"decision": {"approve"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the review to be accepted, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != `{"decision":"approve"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func priorityCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?"` + args + `}}}`
+}
+
+func TestPriorityBellOnlyForHigh(t *testing.T) {
+	for _, priority := range []string{"", server.PriorityLow, server.PriorityNormal, server.PriorityHigh} {
+		term := newFakeTerminal("yes\n")
+		req := server.NewPromptRequest("Deploy?", "tty")
+		req.Priority = priority
+
+		if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+			t.Fatalf("%q: GetInput: %v", priority, err)
+		}
+		rang := strings.Contains(term.output.String(), "\a")
+		if rang != (priority == server.PriorityHigh) {
+			t.Errorf("%q: expected the bell only for high priority, bell written: %v", priority, rang)
+		}
+	}
+}
+
+func TestPriorityNotifications(t *testing.T) {
+	for _, priority := range []string{server.PriorityLow, server.PriorityNormal} {
+		term := newFakeTerminal("")
+		req := server.NewPromptRequest("Migration starting", "tty")
+		req.Priority = priority
+
+		if err := ttyProvider(term).(server.Notifier).Notify(context.Background(), req); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+		rang := strings.Contains(term.output.String(), "\a")
+		if rang != (priority == server.PriorityNormal) {
+			t.Errorf("%q: unexpected bell %v for a notification", priority, rang)
+		}
+	}
+}
+
+func TestPriorityPolicy(t *testing.T) {
+	provider := &fakeProvider{response: "yes"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, priorityCall(`,"priority":"low"`))
+
+	if alert := provider.lastReq.Alert; alert == nil || *alert != (server.Alert{}) {
+		t.Errorf("Expected low priority to stay quiet, got %+v", alert)
+	}
+
+	// Operators can change what each priority does
+	policy := server.DefaultAlertPolicy()
+	policy.Normal.Bell = true
+	srv = &server.MCPServer{}
+	srv.SetAlertPolicy(policy)
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, priorityCall(""))
+
+	if alert := provider.lastReq.Alert; alert == nil || !alert.Bell {
+		t.Errorf("Expected the policy to ring for normal prompts, got %+v", alert)
+	}
+}
+
+func TestPriorityWebEmphasis(t *testing.T) {
+	for _, priority := range []string{server.PriorityNormal, server.PriorityHigh} {
+		req := server.NewPromptRequest("Deploy?", "web")
+		req.Priority = priority
+		rec := httptest.NewRecorder()
+		server.NewWebInputHandler(req).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		body := rec.Body.String()
+
+		high := priority == server.PriorityHigh
+		if strings.Contains(body, "<title>(!) User Input Required</title>") != high {
+			t.Errorf("%q: unexpected title badge:\n%s", priority, body)
+		}
+		if strings.Contains(body, `rel="icon"`) != high {
+			t.Errorf("%q: unexpected favicon badge:\n%s", priority, body)
+		}
+	}
+}
+
+func TestPriorityInvalid(t *testing.T) {
+	calls := []string{
+		priorityCall(`,"priority":"urgent"`),
+		priorityCall(`,"priority":1`),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"notify_user","arguments":{"message":"Done","priority":"urgent"}}}`,
+	}
+	for _, call := range calls {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, call).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", call, errorObj)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestPromptMetaPassedToProvider(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Force-push?","title":"Rewrite history","detail":"main has 3 commits not on your branch","urgency":"critical"}}}`
+
+	provider := &fakeProvider{response: "no"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	findResponse(t, messages, 1)
+
+	req := provider.lastReq
+	if req.Title != "Rewrite history" || req.Detail != "main has 3 commits not on your branch" || req.Urgency != server.UrgencyCritical {
+		t.Errorf("Expected the metadata on the prompt request, got title=%q detail=%q urgency=%q", req.Title, req.Detail, req.Urgency)
+	}
+}
+
+func TestPromptMetaInvalid(t *testing.T) {
+	for _, args := range []string{`"urgency":"high"`, `"title":1`, `"detail":false`} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestPromptMetaTTY(t *testing.T) {
+	term := newFakeTerminal("no\n")
+	req := server.NewPromptRequest("Force-push?", "tty")
+	req.Title = "Rewrite history"
+	req.Detail = "main has 3 commits\nnot on your branch"
+	req.Urgency = server.UrgencyCritical
+
+	if _, err := ttyProvider(term).GetInput(context.Background(), req); err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+
+	output := term.output.String()
+	want := strings.Repeat("=", 60) + "\n[CRITICAL] Rewrite history\nForce-push?\n\n  main has 3 commits\n  not on your branch\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("Expected a separated, tagged header and indented detail, got:\n%s", output)
+	}
+}
+
+func TestPromptMetaTTYPlain(t *testing.T) {
+	term := newFakeTerminal("x\n")
+	if _, err := ttyProvider(term).GetInput(context.Background(), server.NewPromptRequest("Q?", "tty")); err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if output := term.output.String(); !strings.HasPrefix(output, "Q?\n") {
+		t.Errorf("Expected no header without metadata, got:\n%s", output)
+	}
+}
+
+func TestPromptMetaWeb(t *testing.T) {
+	req := server.NewPromptRequest("Force-push?", "web")
+	req.Title = "Rewrite history"
+	req.Detail = "main has 3 commits not on your branch"
+	req.Urgency = server.UrgencyCritical
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"<title>Rewrite history</title>",
+		"<h1>Rewrite history</h1>",
+		`<body class="urgency-critical">`,
+		"<details class=\"detail\"><summary>Details</summary><pre>main has 3 commits not on your branch</pre></details>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the page to contain %q, got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// fakeBrowser is a push subscriber: the keys a browser subscribes with and
+// what its push service received.
+type fakeBrowser struct {
+	key      *ecdh.PrivateKey
+	secret   []byte
+	service  *httptest.Server
+	status   int
+	messages chan server.PushMessage
+}
+
+// newFakeBrowser starts a push service answering status, decrypting and
+// checking every message it is sent for pusher.
+func newFakeBrowser(t *testing.T, pusher *server.Pusher, status int) *fakeBrowser {
+	t.Helper()
+	b := &fakeBrowser{secret: make([]byte, 16), status: status, messages: make(chan server.PushMessage, 10)}
+	var err error
+	if b.key, err = ecdh.P256().GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	rand.Read(b.secret)
+	b.service = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := checkVAPID(r, pusher.PublicKey(), "https://"+r.Host); err != nil {
+			t.Errorf("VAPID: %v", err)
+		}
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+			t.Errorf("Expected an aes128gcm message with a TTL, got %v", r.Header)
+		}
+		var msg server.PushMessage
+		if err := json.Unmarshal(b.decrypt(t, body), &msg); err != nil {
+			t.Errorf("Expected a JSON message: %v", err)
+		}
+		b.messages <- msg
+		w.WriteHeader(b.status)
+	}))
+	t.Cleanup(b.service.Close)
+	pusher.Client = b.service.Client()
+	return b
+}
+
+func (b *fakeBrowser) subscription() server.PushSubscription {
+	var sub server.PushSubscription
+	sub.Endpoint = b.service.URL + "/send/abc"
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes())
+	sub.Keys.Auth = base64.URLEncoding.EncodeToString(b.secret)
+	return sub
+}
+
+// decrypt reads an aes128gcm message as a browser does (RFC 8291).
+func (b *fakeBrowser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 86 || body[20] != 65 {
+		t.Fatalf("Expected a header with a 65-byte key id, got %d bytes", len(body))
+	}
+	salt, recordSize, keyID := body[:16], binary.BigEndian.Uint32(body[16:20]), body[21:86]
+	if recordSize < 18 {
+		t.Fatalf("Unexpected record size %d", recordSize)
+	}
+	sender, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, _ := b.key.ECDH(sender)
+	prk, _ := hkdf.Extract(sha256.New, shared, b.secret)
+	ikm, _ := hkdf.Expand(sha256.New, prk, "WebPush: info\x00"+string(b.key.PublicKey().Bytes())+string(keyID), 32)
+	prk, _ = hkdf.Extract(sha256.New, ikm, salt)
+	cek, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, body[86:], nil)
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if len(plain) == 0 || plain[len(plain)-1] != 2 {
+		t.Fatalf("Expected the last record's delimiter, got %q", plain)
+	}
+	return plain[:len(plain)-1]
+}
+
+// checkVAPID verifies the request's VAPID token against key.
+func checkVAPID(r *http.Request, key, audience string) error {
+	var token, k string
+	for _, part := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "vapid "), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "t":
+			token = value
+		case "k":
+			k = value
+		}
+	}
+	if k != key {
+		return fmt.Errorf("expected key %s, got %s", key, k)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected a JWT, got %q", token)
+	}
+	point, _ := base64.RawURLEncoding.DecodeString(k)
+	x, y := elliptic.Unmarshal(elliptic.P256(), point)
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if x == nil || len(sig) != 64 || !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		return fmt.Errorf("bad signature")
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var c struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	if err := json.Unmarshal(claims, &c); err != nil || c.Aud != audience || c.Exp == 0 || c.Sub == "" {
+		return fmt.Errorf("unexpected claims %s", claims)
+	}
+	return nil
+}
+
+func newPusher(t *testing.T) *server.Pusher {
+	t.Helper()
+	p, err := server.LoadPusher(filepath.Join(t.TempDir(), "keys", "vapid.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPushKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt-mcp", "vapid.pem")
+	first, err := server.LoadPusher(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the key saved for the user only, got %v (%v)", info, err)
+	}
+	if key, _ := base64.RawURLEncoding.DecodeString(first.PublicKey()); len(key) != 65 || key[0] != 4 {
+		t.Errorf("Expected an uncompressed P-256 point, got %q", first.PublicKey())
+	}
+	again, err := server.LoadPusher(path)
+	if err != nil || again.PublicKey() != first.PublicKey() {
+		t.Errorf("Expected the saved key on the next run, got %q (%v)", again.PublicKey(), err)
+	}
+
+	os.WriteFile(path, []byte("not a key"), 0o600)
+	if _, err := server.LoadPusher(path); err == nil {
+		t.Error("Expected a broken key file to be refused")
+	}
+	if path, err := (server.Config{WebPushKeyFile: "/etc/vapid.pem"}).PushKeyFile(); err != nil || path != "/etc/vapid.pem" {
+		t.Errorf("Expected the configured key file, got %q (%v)", path, err)
+	}
+	if !(server.Config{WebPush: true}).Dashboard() {
+		t.Error("Expected web_push to turn the dashboard on")
+	}
+}
+
+func TestPushSend(t *testing.T) {
+	pusher := newPusher(t)
+	browser := newFakeBrowser(t, pusher, http.StatusCreated)
+	if err := pusher.Subscribe(browser.subscription()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := server.PushMessage{Title: "Agent needs input: Deploy", Body: "Which branch?", URL: "/p/1/abc/", Tag: "/p/1/abc/"}
+	pusher.Send(context.Background(), want)
+	if got := <-browser.messages; got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if pusher.Subscriptions() != 1 {
+		t.Error("Expected the subscription kept")
+	}
+
+	// A failing push service keeps the subscription; a gone one drops it
+	browser.status = http.StatusInternalServerError
+	pusher.Send(context.Background(), want)
+	<-browser.messages
+	if pusher.Subscriptions() != 1 {
+		t.Error("Expected the subscription kept after a failure")
+	}
+	browser.status = http.StatusGone
+	pusher.Send(context.Background(), want)
+	<-browser.messages
+	if pusher.Subscriptions() != 0 {
+		t.Error("Expected an expired subscription dropped")
+	}
+
+	bad := browser.subscription()
+	bad.Endpoint = "http://push.example.test/send"
+	if pusher.Subscribe(bad) == nil {
+		t.Error("Expected a plain http endpoint refused")
+	}
+	bad = browser.subscription()
+	bad.Keys.Auth = "c2hvcnQ"
+	if pusher.Subscribe(bad) == nil {
+		t.Error("Expected a short secret refused")
+	}
+	(*server.Pusher)(nil).Send(context.Background(), want)
+}
+
+func TestPushDashboard(t *testing.T) {
+	d := newDashboard(t)
+	if rec := dashboardGet(d, d.Path()+"push"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no subscriptions without push, got %d", rec.Code)
+	}
+	if strings.Contains(dashboardGet(d, d.Path()).Body.String(), `id="notify"`) {
+		t.Error("Expected no notification button without push")
+	}
+
+	pusher := newPusher(t)
+	browser := newFakeBrowser(t, pusher, http.StatusCreated)
+	d.SetPush(pusher)
+	index := dashboardGet(d, d.Path()).Body.String()
+	if !strings.Contains(index, `id="notify"`) || !strings.Contains(index, pusher.PublicKey()) {
+		t.Errorf("Expected the notification button and key on the index, got:\n%s", index)
+	}
+	if rec := dashboardGet(d, "/sw.js"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "showNotification") {
+		t.Errorf("Expected the service worker to show notifications, got %d", rec.Code)
+	}
+
+	subscribe := func(method, origin string, sub interface{}) int {
+		body, _ := json.Marshal(sub)
+		req := httptest.NewRequest(method, d.Path()+"push", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		d.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := subscribe(http.MethodPost, "http://evil.test", browser.subscription()); code != http.StatusForbidden {
+		t.Errorf("Expected a cross-origin subscription refused, got %d", code)
+	}
+	if code := subscribe(http.MethodPost, "", map[string]string{"endpoint": "https://push.example.test"}); code != http.StatusBadRequest {
+		t.Errorf("Expected a subscription without keys refused, got %d", code)
+	}
+	if code := subscribe(http.MethodPost, "http://example.com", browser.subscription()); code != http.StatusNoContent || pusher.Subscriptions() != 1 {
+		t.Fatalf("Expected the subscription accepted, got %d", code)
+	}
+
+	// A new prompt is pushed, linking to its page
+	go d.GetInput(context.Background(), server.NewPromptRequest("Deploy which branch?", "web"))
+	msg := <-browser.messages
+	_, links := waitListed(t, d, 1)
+	if msg.Title != "Agent needs input" || msg.Body != "Deploy which branch?" || msg.URL != links[0] {
+		t.Errorf("Expected the prompt pushed with a link to %s, got %+v", links[0], msg)
+	}
+
+	if code := subscribe(http.MethodDelete, "", map[string]string{"endpoint": browser.subscription().Endpoint}); code != http.StatusNoContent || pusher.Subscriptions() != 0 {
+		t.Errorf("Expected the subscription dropped, got %d", code)
+	}
+}
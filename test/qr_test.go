@@ -0,0 +1,314 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"prompt-mcp/qr"
+	"prompt-mcp/server"
+)
+
+// qrBlocks lists, per version, the level M error correction blocks and
+// codewords per block.
+var qrBlocks = map[int][2]int{1: {1, 10}, 2: {1, 16}, 3: {1, 26}, 4: {2, 18}, 5: {2, 24}, 6: {4, 16}, 7: {4, 18}, 8: {4, 22}, 9: {5, 22}, 10: {5, 26}}
+
+var qrAlignment = map[int][]int{2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34}, 7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50}}
+
+// qrReserved reports whether a module of a version's symbol is part of a
+// function pattern rather than data.
+func qrReserved(version, x, y int) bool {
+	size := 17 + 4*version
+	inFinder := func(fx, fy int) bool { return x >= fx && x < fx+8 && y >= fy && y < fy+8 }
+	switch {
+	case inFinder(0, 0), inFinder(size-8, 0), inFinder(0, size-8):
+		return true
+	case x == 6 || y == 6:
+		return true
+	case y == 8 && (x <= 8 || x >= size-8), x == 8 && (y <= 8 || y >= size-8):
+		return true
+	case version >= 7 && (x < 6 && y >= size-11 && y < size-8 || y < 6 && x >= size-11 && x < size-8):
+		return true
+	}
+	align := qrAlignment[version]
+	for i, ay := range align {
+		for j, ax := range align {
+			last := len(align) - 1
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			if x >= ax-2 && x <= ax+2 && y >= ay-2 && y <= ay+2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func qrGFMul(x, y int) int {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// decodeQR reads a level M byte mode symbol back, checking its format
+// information and error correction on the way.
+func decodeQR(t *testing.T, c *qr.Code) string {
+	t.Helper()
+	size := c.Size
+	bit := func(x, y int) int {
+		if c.Black(x, y) {
+			return 1
+		}
+		return 0
+	}
+
+	// Format information, both copies, must be a valid BCH code word
+	format, second := 0, 0
+	for i, pos := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		format |= bit(pos[0], pos[1]) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(8, size-15+i) << i
+	}
+	if format != second {
+		t.Fatalf("Format copies differ: %015b and %015b", format, second)
+	}
+	unmasked := format ^ 0x5412
+	rem := unmasked
+	for i := 14; i >= 10; i-- {
+		if rem>>i&1 == 1 {
+			rem ^= 0x537 << (i - 10)
+		}
+	}
+	if rem != 0 || unmasked>>13 != 0 {
+		t.Fatalf("Expected level M format information, got %015b", format)
+	}
+	if bit(8, size-8) != 1 {
+		t.Error("Expected the dark module")
+	}
+	mask := unmasked >> 10 & 7
+	masks := []func(x, y int) bool{
+		func(x, y int) bool { return (x+y)%2 == 0 },
+		func(x, y int) bool { return y%2 == 0 },
+		func(x, y int) bool { return x%3 == 0 },
+		func(x, y int) bool { return (x+y)%3 == 0 },
+		func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+		func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+		func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+		func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+	}
+
+	// Codewords in placement order
+	var raw []int
+	acc, n := 0, 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if qrReserved(c.Version, x, y) {
+					continue
+				}
+				b := bit(x, y)
+				if masks[mask](x, y) {
+					b ^= 1
+				}
+				acc, n = acc<<1|b, n+1
+				if n == 8 {
+					raw = append(raw, acc)
+					acc, n = 0, 0
+				}
+			}
+		}
+	}
+
+	// De-interleave, and check every block's syndromes are zero
+	blocks, ecc := qrBlocks[c.Version][0], qrBlocks[c.Version][1]
+	total := len(raw)
+	short := blocks - total%blocks
+	shortData := total/blocks - ecc
+	parts := make([][]int, blocks)
+	k := 0
+	for i := 0; i <= shortData; i++ {
+		for b := range parts {
+			if i < shortData || b >= short {
+				parts[b] = append(parts[b], raw[k])
+				k++
+			}
+		}
+	}
+	var data []int
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	for i := 0; i < ecc; i++ {
+		for b := range parts {
+			parts[b] = append(parts[b], raw[k])
+			k++
+		}
+	}
+	for b, part := range parts {
+		root := 1
+		for i := 0; i < ecc; i++ {
+			s := 0
+			for _, cw := range part {
+				s = qrGFMul(s, root) ^ cw
+			}
+			if s != 0 {
+				t.Fatalf("Block %d: syndrome %d is %d", b, i, s)
+			}
+			root = qrGFMul(root, 2)
+		}
+	}
+
+	// Byte mode segment
+	pos := 0
+	read := func(bits int) int {
+		v := 0
+		for i := 0; i < bits; i++ {
+			v = v<<1 | data[pos/8]>>(7-pos%8)&1
+			pos++
+		}
+		return v
+	}
+	if mode := read(4); mode != 0b0100 {
+		t.Fatalf("Expected byte mode, got %04b", mode)
+	}
+	countBits := 8
+	if c.Version >= 10 {
+		countBits = 16
+	}
+	text := make([]byte, read(countBits))
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
+
+func TestQREncode(t *testing.T) {
+	tests := []struct {
+		length  int
+		version int
+	}{
+		{1, 1}, {14, 1}, {15, 2}, {26, 2}, {27, 3}, {62, 4}, {84, 5}, {106, 6}, {122, 7}, {152, 8}, {180, 9}, {181, 10}, {213, 10},
+	}
+	for _, tt := range tests {
+		text := strings.Repeat("http://192.168.1.20:8400/", 10)[:tt.length]
+		c, err := qr.Encode(text)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tt.length, err)
+		}
+		if c.Version != tt.version || c.Size != 17+4*tt.version {
+			t.Errorf("%d bytes: expected version %d, got %d (size %d)", tt.length, tt.version, c.Version, c.Size)
+		}
+		if got := decodeQR(t, c); got != text {
+			t.Errorf("%d bytes: decoded %q", tt.length, got)
+		}
+	}
+
+	if _, err := qr.Encode(strings.Repeat("x", qr.MaxLength+1)); err == nil {
+		t.Error("Expected text over the capacity to be refused")
+	}
+}
+
+func TestQRFinders(t *testing.T) {
+	c, err := qr.Encode("http://192.168.1.20:8400/p/1/token/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"#######.", "#.....#.", "#.###.#.", "#.###.#.", "#.###.#.", "#.....#.", "#######.", "........"}
+	for _, corner := range [][2]int{{0, 0}, {c.Size - 8, 0}, {0, c.Size - 8}} {
+		for dy := 0; dy < 8; dy++ {
+			var row strings.Builder
+			for dx := 0; dx < 8; dx++ {
+				// Mirror the other corners so the separator is always last
+				x, y := dx, dy
+				if corner[0] != 0 {
+					x = 7 - dx
+				}
+				if corner[1] != 0 {
+					y = 7 - dy
+				}
+				if c.Black(corner[0]+x, corner[1]+y) {
+					row.WriteByte('#')
+				} else {
+					row.WriteByte('.')
+				}
+			}
+			if row.String() != want[dy] {
+				t.Errorf("Finder at %v, row %d: expected %s, got %s", corner, dy, want[dy], row.String())
+			}
+		}
+	}
+}
+
+func TestQRANSI(t *testing.T) {
+	c, err := qr.Encode("hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(c.ANSI(2), "\n"), "\n")
+	if len(lines) != (c.Size+4+1)/2 {
+		t.Fatalf("Expected two rows of modules per line, got %d lines", len(lines))
+	}
+	if got := strings.Count(lines[0], "▀"); got != c.Size+4 {
+		t.Errorf("Expected %d columns, got %d", c.Size+4, got)
+	}
+	// The quiet zone is light; the top-left finder starts on the next line
+	if !strings.HasPrefix(lines[0], "\x1b[37;47m▀") || !strings.Contains(lines[1], "\x1b[30;40m▀") {
+		t.Errorf("Expected a light quiet zone and a dark finder, got %q / %q", lines[0], lines[1])
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], "\x1b[37;49m▀\x1b[0m") {
+		t.Errorf("Expected the unused bottom half left alone, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestQRPhoneURL(t *testing.T) {
+	page := "http://localhost:8400/abc/"
+	tests := []struct {
+		listener server.WebListener
+		want     string
+		err      bool
+	}{
+		{server.WebListener{Host: "127.0.0.1"}, "", true},
+		{server.WebListener{Host: "localhost"}, "", true},
+		{server.WebListener{Host: "192.168.1.20"}, "http://192.168.1.20:8400/abc/", false},
+		{server.WebListener{Host: "127.0.0.1", ExternalURL: "https://prompts.example.com"}, page, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.listener.PhoneURL(page)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%+v: expected %q (error %v), got %q (%v)", tt.listener, tt.want, tt.err, got, err)
+		}
+	}
+
+	// Every interface means the machine's LAN address, if it has one
+	got, err := server.WebListener{Host: "0.0.0.0"}.PhoneURL(page)
+	if err != nil {
+		t.Skipf("No LAN address: %v", err)
+	}
+	if strings.Contains(got, "localhost") || strings.Contains(got, "0.0.0.0") || !strings.HasSuffix(got, ":8400/abc/") {
+		t.Errorf("Expected a LAN address, got %s", got)
+	}
+}
+
+func TestQRConfig(t *testing.T) {
+	l, err := server.Config{WebQR: true, WebHost: "0.0.0.0"}.WebListener()
+	if err != nil || !l.QR || l.Private() {
+		t.Errorf("Expected a public listener printing QR codes, got %+v (%v)", l, err)
+	}
+	if l, _ := (server.Config{}).WebListener(); l.QR || !l.Private() {
+		t.Errorf("Expected no QR codes and a private listener by default, got %+v", l)
+	}
+}
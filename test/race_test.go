@@ -0,0 +1,138 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// racePage serves each prompt from a web page of its own, handing
+// the page to the test before waiting for an answer.
+type racePage struct {
+	pages chan *server.WebInputHandler
+}
+
+func (p *racePage) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	handler := server.NewWebInputHandler(req)
+	p.pages <- handler
+	return handler.Wait(ctx)
+}
+
+// lateProvider accepts an answer just as the prompt is cancelled, like a
+// page submitted a moment after the terminal answered.
+type lateProvider struct{}
+
+func (lateProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	<-ctx.Done()
+	return "late", nil
+}
+
+type failingProvider struct{ err error }
+
+func (p failingProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	return "", p.err
+}
+
+func TestRaceTerminalWins(t *testing.T) {
+	web := &racePage{pages: make(chan *server.WebInputHandler, 1)}
+	race := server.NewRaceProvider(ttyProvider(newFakeTerminal("from tty\n")), web)
+
+	answer, err := race.GetInput(context.Background(), server.NewPromptRequest("Deploy?", "both"))
+	if err != nil || answer != "from tty" {
+		t.Fatalf("Expected the terminal's answer, got %q (%v)", answer, err)
+	}
+
+	page := <-web.pages
+	ts := httptest.NewServer(page)
+	defer ts.Close()
+	if state := <-watchPage(t, ts); state.State != "expired" || state.Message != "Answered in the terminal. You can close this tab." {
+		t.Errorf("Expected the page to say it was answered in the terminal, got %+v", state)
+	}
+	rec := httptest.NewRecorder()
+	page.ServeHTTP(rec, postForm(t, page, "/submit", url.Values{"response": {"from web"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Answered in the terminal</h1>") {
+		t.Errorf("Expected a later web answer to be turned away, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRaceWebWins(t *testing.T) {
+	// The terminal never answers; the test ends its reads
+	input, typing := io.Pipe()
+	defer typing.Close()
+	term := &fakeTerminal{input: input}
+
+	req := server.NewPromptRequest("Password?", "both")
+	req.Secret = true
+	race := server.NewRaceProvider(ttyProvider(term), &fakeProvider{response: "hunter2", delay: 20 * time.Millisecond})
+
+	answer, err := race.GetInput(context.Background(), req)
+	if err != nil || answer != "hunter2" {
+		t.Fatalf("Expected the web answer, got %q (%v)", answer, err)
+	}
+	if out := term.output.String(); !strings.Contains(out, "(Answered in the browser)") {
+		t.Errorf("Expected the terminal to say where the prompt was answered, got %q", out)
+	}
+	if term.echoOff || term.restored != 1 || term.closed != 1 {
+		t.Errorf("Expected the terminal restored and closed, got echoOff=%v restored=%d closed=%d", term.echoOff, term.restored, term.closed)
+	}
+}
+
+func TestRaceSecondAnswerDiscarded(t *testing.T) {
+	race := server.NewRaceProvider(&fakeProvider{response: "first"}, lateProvider{})
+	for i := 0; i < 20; i++ {
+		answer, err := race.GetInput(context.Background(), server.NewPromptRequest("Deploy?", "both"))
+		if err != nil || answer != "first" {
+			t.Fatalf("Expected the first answer to win, got %q (%v)", answer, err)
+		}
+	}
+}
+
+func TestRaceFailures(t *testing.T) {
+	noTTY := failingProvider{errors.New("failed to open /dev/tty")}
+
+	race := server.NewRaceProvider(noTTY, &fakeProvider{response: "from web", delay: 10 * time.Millisecond})
+	if answer, err := race.GetInput(context.Background(), server.NewPromptRequest("Deploy?", "both")); err != nil || answer != "from web" {
+		t.Errorf("Expected the web to answer without a terminal, got %q (%v)", answer, err)
+	}
+
+	gaveUp := errors.New("too many invalid attempts")
+	race = server.NewRaceProvider(noTTY, failingProvider{gaveUp})
+	if _, err := race.GetInput(context.Background(), server.NewPromptRequest("Deploy?", "both")); !errors.Is(err, gaveUp) || !errors.Is(err, noTTY.err) {
+		t.Errorf("Expected the prompt to fail when both methods do, got %v", err)
+	}
+
+	req := server.NewPromptRequest("Deploy?", "both")
+	req.Timeout = 20 * time.Millisecond
+	race = server.NewRaceProvider(&fakeProvider{delay: time.Hour}, &fakeProvider{delay: time.Hour})
+	if _, err := server.Ask(context.Background(), race, req); !errors.Is(err, server.ErrTimeout) {
+		t.Errorf("Expected the prompt to time out, got %v", err)
+	}
+}
+
+func TestRaceMethod(t *testing.T) {
+	tty, web := &fakeProvider{response: "from tty", delay: time.Hour}, &fakeProvider{response: "from web"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", tty)
+	srv.SetInputProvider("web", web)
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Hi","method":"both"}}}`
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "from web" {
+		t.Errorf("Expected the web answer, got %v", text)
+	}
+	if tty.lastReq == nil || tty.lastReq != web.lastReq || web.lastReq.Method != "both" {
+		t.Errorf("Expected the prompt asked on both methods, got %+v and %+v", tty.lastReq, web.lastReq)
+	}
+	if web.lastReq.Timeout != 5*time.Minute {
+		t.Errorf("Expected the web page's default timeout, got %v", web.lastReq.Timeout)
+	}
+}
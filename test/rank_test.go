@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+var rankOptions = []string{"Postgres", "MySQL", "SQLite"}
+
+func rankCall(t *testing.T, args map[string]interface{}) string {
+	args["prompt"] = "Which database do you prefer?"
+	if _, ok := args["options"]; !ok {
+		args["options"] = rankOptions
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_rank",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserRankResult(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"3,1", "3,1,1", "3,1,4", " 3, 1 ,2 "}}
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{MaxAttempts: 5})
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, rankCall(t, map[string]interface{}{})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	structured := result["structuredContent"].(map[string]interface{})
+	want := []interface{}{"SQLite", "Postgres", "MySQL"}
+	if !reflect.DeepEqual(structured["ranking"], want) || !reflect.DeepEqual(structured["indices"], []interface{}{float64(2), float64(0), float64(1)}) || structured["complete"] != true {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != `["SQLite","Postgres","MySQL"]` {
+		t.Errorf("Unexpected text %v", text)
+	}
+	if provider.attempts != 4 {
+		t.Errorf("Expected incomplete, repeated and out-of-range orders to be asked again, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserRankPartial(t *testing.T) {
+	provider := &fakeProvider{responses: []string{"2,3,1", "2,3"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, rankCall(t, map[string]interface{}{"allow_partial": true, "max_ranked": 2})).String())
+	structured := findResponse(t, messages, 1)["result"].(map[string]interface{})["structuredContent"].(map[string]interface{})
+
+	if !reflect.DeepEqual(structured["ranking"], []interface{}{"MySQL", "SQLite"}) || structured["complete"] != false {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected more than max_ranked to be refused, got %d attempts", provider.attempts)
+	}
+}
+
+func TestUserRankTTY(t *testing.T) {
+	term := newFakeTerminal("2,2,1\n2,3,1\n")
+	req := server.NewRankPrompt("Which database?", "tty", rankOptions, server.RankOptions{})
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != "2,3,1" {
+		t.Fatalf("Expected 2,3,1, got %q (%v)", answer, err)
+	}
+	out := term.output.String()
+	if !strings.Contains(out, "  1) Postgres\n  2) MySQL\n  3) SQLite\n") || !strings.Contains(out, "comma-separated") {
+		t.Errorf("Expected the numbered options and a hint, got %q", out)
+	}
+	if !strings.Contains(out, "Option 2 is listed twice") {
+		t.Errorf("Expected the repeated number to be explained, got %q", out)
+	}
+}
+
+func TestUserRankWeb(t *testing.T) {
+	req := server.NewRankPrompt("Which database?", "web", rankOptions, server.RankOptions{AllowPartial: true, MaxRanked: 2})
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`id="rank-list" data-max="2"`, `draggable="true" data-number="1"`, `type="hidden" name="response" id="rank-order"`, `class="rank-pick"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the sortable list, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `<script src="http`) {
+		t.Error("Expected no external scripts")
+	}
+
+	// A rejected order is shown again as submitted
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"3,2,1"}}))
+	body = rec.Body.String()
+	if rec.Code != http.StatusBadRequest || strings.Index(body, `data-number="3"`) > strings.Index(body, `data-number="1"`) {
+		t.Errorf("Expected the rejected order to be kept, got %d:\n%s", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"3,1"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the ranking to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "3,1" {
+		t.Errorf("Expected 3,1, got %q (%v)", answer, err)
+	}
+}
+
+func TestUserRankInvalidArguments(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"options": []string{"only"}},
+		{"options": []string{"a", "a"}},
+		{"options": "a,b"},
+		{"max_ranked": 2},
+		{"allow_partial": true, "max_ranked": 4},
+		{"allow_partial": true, "max_ranked": 1.5},
+		{"allow_partial": "yes"},
+	}
+	for _, args := range tests {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, rankCall(t, args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
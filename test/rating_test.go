@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func ratingCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_rating",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserRatingResult(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{responses: []string{"6", "four", " 4 "}})
+
+	messages := parseMessages(t, runServer(t, srv, ratingCall(t, map[string]interface{}{"prompt": "How confident are you?"})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["rating"] != float64(4) || structured["fraction"] != 0.8 || structured["min"] != float64(1) || structured["max"] != float64(5) {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "4/5" {
+		t.Errorf("Expected text 4/5, got %v", text)
+	}
+}
+
+func TestUserRatingInvalidScale(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"min": 5, "max": 5},
+		{"min": -1, "max": 5},
+		{"min": 1, "max": 2.5},
+		{"min": 0, "max": 500},
+		{"max": "ten"},
+		{"min_label": 3},
+	}
+
+	for _, args := range tests {
+		args["prompt"] = "Rate"
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "1"})
+
+		messages := parseMessages(t, runServer(t, srv, ratingCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%v: expected an invalid params error, got %v", args, errObj)
+		}
+	}
+}
+
+func TestUserRatingTTY(t *testing.T) {
+	term := newFakeTerminal("0\n10\n")
+	prompt := server.NewRatingPrompt("How was it?", "tty", server.RatingOptions{Min: 1, Max: 10, MinLabel: "Awful", MaxLabel: "Great"})
+
+	answer, err := server.Ask(context.Background(), ttyProvider(term), prompt)
+	if err != nil || answer != "10" {
+		t.Fatalf("Expected 10, got %q, %v", answer, err)
+	}
+
+	out := term.output.String()
+	for _, want := range []string{"(1 = Awful ... 10 = Great)", "Rating [1-10]: ", "from 1 to 10"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q on the terminal, got %q", want, out)
+		}
+	}
+}
+
+func TestUserRatingWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewRatingPrompt("Rate", "web", server.RatingOptions{Min: 1, Max: 5, MinLabel: "Low", MaxLabel: "High"}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if strings.Count(body, `name="response" value="`) != 5 || !strings.Contains(body, "<span>Low</span><span>High</span>") {
+		t.Errorf("Expected five buttons and the labels, got %s", body)
+	}
+
+	slider := server.NewWebInputHandler(server.NewRatingPrompt("Rate", "web", server.RatingOptions{Min: 0, Max: 100}))
+	rec = httptest.NewRecorder()
+	slider.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `type="range" name="response" min="0" max="100"`) {
+		t.Errorf("Expected a slider for a long scale, got %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	slider.ServeHTTP(rec, postForm(t, slider, "/submit", url.Values{"response": {"101"}}))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an out-of-range rating to be rejected, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func reactionCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_reaction",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserReaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]interface{}
+		provider   *fakeProvider
+		structured map[string]interface{}
+		text       string
+	}{
+		{"emoji", map[string]interface{}{}, &fakeProvider{response: "👎"},
+			map[string]interface{}{"reaction": "reject"}, "reject"},
+		{"number", map[string]interface{}{"prompt": "Good summary?"}, &fakeProvider{response: "1"},
+			map[string]interface{}{"reaction": "approve"}, "approve"},
+		{"timeout", map[string]interface{}{"timeout": 0.05}, &fakeProvider{response: "1", delay: time.Second},
+			map[string]interface{}{"reaction": "unsure", "timedOut": true}, "unsure (no reaction within"},
+		{"custom", map[string]interface{}{"reactions": []interface{}{
+			map[string]interface{}{"id": "ship", "emoji": "🚀"},
+			map[string]interface{}{"id": "hold", "emoji": "✋", "label": "Hold"},
+		}}, &fakeProvider{response: "hold"}, map[string]interface{}{"reaction": "hold"}, "hold"},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", tt.provider)
+
+		messages := parseMessages(t, runServer(t, srv, reactionCall(t, tt.args)).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: expected a result, got %v", tt.name, findResponse(t, messages, 1))
+		}
+		if structured := result["structuredContent"]; !reflect.DeepEqual(structured, tt.structured) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.structured, structured)
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string); !strings.HasPrefix(text, tt.text) {
+			t.Errorf("%s: unexpected text %q", tt.name, text)
+		}
+	}
+}
+
+func TestUserReactionDefaults(t *testing.T) {
+	provider := &fakeProvider{response: "2"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+	runServer(t, srv, reactionCall(t, map[string]interface{}{}))
+
+	req := provider.lastReq
+	if req.Prompt != "How did I do?" || req.Timeout != time.Minute || !req.SingleKey {
+		t.Errorf("Expected a one-minute single-key prompt, got %q, %v, %v", req.Prompt, req.Timeout, req.SingleKey)
+	}
+	if req.TimeoutResponse == nil || *req.TimeoutResponse != "unsure" {
+		t.Errorf("Expected unsure on timeout, got %v", req.TimeoutResponse)
+	}
+	if want := []string{"👍 Approve", "👎 Reject", "🤷 Unsure"}; !reflect.DeepEqual(req.Options, want) {
+		t.Errorf("Expected %v, got %v", want, req.Options)
+	}
+}
+
+func TestUserReactionTimeoutError(t *testing.T) {
+	// Without unsure among the reactions there is nothing to fall back to
+	args := map[string]interface{}{"timeout": 0.05, "reactions": []interface{}{
+		map[string]interface{}{"id": "yes", "emoji": "✅"},
+		map[string]interface{}{"id": "no", "emoji": "❌"},
+	}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "1", delay: time.Second})
+
+	messages := parseMessages(t, runServer(t, srv, reactionCall(t, args)).String())
+	result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	if !ok || result["isError"] != true {
+		t.Errorf("Expected an error result, got %v", findResponse(t, messages, 1))
+	}
+}
+
+func TestReactionTTYKey(t *testing.T) {
+	term := &keyTerminal{fakeTerminal: newFakeTerminal("x2")}
+	answer, err := keyProvider(term).GetInput(context.Background(), server.NewReactionPrompt("Good summary?", "tty", server.DefaultReactions))
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "reject" {
+		t.Errorf("Expected reject, got %q", answer)
+	}
+	output := term.output.String()
+	for _, want := range []string{"  1) 👍 Approve\n", "Reaction [1-3]: \a2) 👎 Reject\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestReactionWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewReactionPrompt("Good summary?", "web", server.DefaultReactions))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `<button type="submit" name="response" value="approve" class="reaction"><span class="reaction-emoji" aria-hidden="true">👍</span><span class="reaction-label">Approve</span></button>`) {
+		t.Errorf("Expected a button per reaction, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"unsure"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the reaction to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "unsure" {
+		t.Errorf("Unexpected answer %q (%v)", answer, err)
+	}
+}
+
+func TestUserReactionInvalidArguments(t *testing.T) {
+	pair := func(a, b map[string]interface{}) []interface{} { return []interface{}{a, b} }
+	ok := map[string]interface{}{"id": "ok", "emoji": "👌"}
+	for name, args := range map[string]map[string]interface{}{
+		"prompt bool":         {"prompt": true},
+		"not an array":        {"reactions": "👍"},
+		"one reaction":        {"reactions": []interface{}{ok}},
+		"ten reactions":       {"reactions": []interface{}{ok, ok, ok, ok, ok, ok, ok, ok, ok, ok}},
+		"not an object":       {"reactions": []interface{}{ok, "👎"}},
+		"duplicate id":        {"reactions": pair(ok, ok)},
+		"bad id":              {"reactions": pair(ok, map[string]interface{}{"id": "Not OK", "emoji": "👎"})},
+		"missing emoji":       {"reactions": pair(ok, map[string]interface{}{"id": "no"})},
+		"multiline label":     {"reactions": pair(ok, map[string]interface{}{"id": "no", "emoji": "👎", "label": "a\nb"})},
+		"unknown fallback":    {"timeout_reaction": "maybe"},
+		"fallback not in set": {"reactions": pair(ok, map[string]interface{}{"id": "no", "emoji": "👎"}), "timeout_reaction": "unsure"},
+		"bad timeout":         {"timeout": -1},
+	} {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, reactionCall(t, args)).String())
+		errObj, isErr := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !isErr || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", name, errObj)
+		}
+	}
+}
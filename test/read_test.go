@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func readCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_read",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// numberedLines returns n lines reading "line 1" to "line n".
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestUserReadLargeBody(t *testing.T) {
+	// Several hundred KB, escaped onto a single line of stdin
+	body := strings.Repeat("The licensee shall \"read\" every clause.\n\t", 15000)
+
+	for _, tt := range []struct {
+		response     string
+		acknowledged bool
+		text         string
+	}{
+		{"yes", true, "Acknowledged after"},
+		{"no", false, "Not acknowledged after"},
+	} {
+		provider := &fakeProvider{response: tt.response}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+
+		messages := parseMessages(t, runServer(t, srv, readCall(t, map[string]interface{}{"body": body})).String())
+		result, ok := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected a result, got %v", findResponse(t, messages, 1))
+		}
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["acknowledged"] != tt.acknowledged {
+			t.Errorf("%s: expected acknowledged %v, got %v", tt.response, tt.acknowledged, structured)
+		}
+		if _, ok := structured["elapsedSeconds"].(float64); !ok {
+			t.Errorf("%s: expected elapsedSeconds, got %v", tt.response, structured)
+		}
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string); !strings.HasPrefix(text, tt.text) {
+			t.Errorf("%s: unexpected text %q", tt.response, text)
+		}
+
+		if provider.lastReq.Content != body || provider.lastReq.Kind != server.KindRead || provider.lastReq.Prompt != "Please read the following" {
+			t.Errorf("%s: expected the body to arrive intact with the default prompt", tt.response)
+		}
+	}
+}
+
+func TestUserReadInvalidArguments(t *testing.T) {
+	for name, args := range map[string]map[string]interface{}{
+		"missing body":  {},
+		"empty body":    {"body": ""},
+		"body number":   {"body": 5},
+		"body too long": {"body": strings.Repeat("a", 4*1024*1024+1)},
+		"prompt bool":   {"body": "x", "prompt": true},
+		"bad timeout":   {"body": "x", "timeout": -1},
+	} {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, readCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", name, errObj)
+		}
+	}
+}
+
+func TestReadTTYLines(t *testing.T) {
+	term := newFakeTerminal("\nq\nmaybe\ny\n")
+	req := server.NewReadPrompt("Read the license", "tty", numberedLines(60))
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "yes" {
+		t.Errorf("Expected yes, got %q", answer)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{
+		"line 22\n-- 22/60 lines -- Space for more, q to finish: ",
+		"line 44\n-- 44/60 lines -- Space for more, q to finish: ",
+		"Have you read it? [y/n]: ",
+		"Please answer yes or no",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "line 45\n") {
+		t.Errorf("Expected q to stop paging, got:\n%s", output)
+	}
+}
+
+// keyTerminal is a fake terminal that can deliver single keys.
+type keyTerminal struct {
+	*fakeTerminal
+	raw, restored int
+}
+
+func (k *keyTerminal) RawMode() (func(), error) {
+	k.raw++
+	return func() { k.restored++ }, nil
+}
+
+func TestReadTTYKeys(t *testing.T) {
+	term := &keyTerminal{fakeTerminal: newFakeTerminal("  xn")}
+	provider := server.NewTTYProvider(func() (io.ReadWriteCloser, error) { return term, nil })
+	req := server.NewReadPrompt("Read the incident summary", "tty", numberedLines(50))
+
+	answer, err := provider.GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "no" {
+		t.Errorf("Expected no, got %q", answer)
+	}
+	if term.raw != 1 || term.restored != 1 {
+		t.Errorf("Expected raw mode once and restored, got %d/%d", term.raw, term.restored)
+	}
+
+	output := term.output.String()
+	for _, want := range []string{"line 50\n", "Have you read it? [y/n]: x\nPlease answer yes or no", "Have you read it? [y/n]: n\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestReadWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewReadPrompt("Read the license", "web", "Clause 1 <b>bold</b>\nClause 2\n"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<div class="read" id="read-body" tabindex="0">Clause 1 &lt;b&gt;bold&lt;/b&gt;`,
+		`<button type="submit" name="response" value="yes" id="read-confirm" disabled>I have read this</button>`,
+		`Scroll to the end to confirm`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the acknowledgement to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "yes" {
+		t.Errorf("Unexpected answer %q (%v)", answer, err)
+	}
+}
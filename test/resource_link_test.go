@@ -0,0 +1,129 @@
+package test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestOversizedAnswerReturnedAsResourceLink(t *testing.T) {
+	answer := strings.Repeat("log line\n", 500)
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste the log","method":"web"}}}
+{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"prompt-history://1/answer"}}
+{"jsonrpc":"2.0","id":3,"method":"resources/list","params":{}}`
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{InlineLimit: 1024})
+	srv.SetInputProvider("web", &fakeProvider{response: answer})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	content := result["content"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("Expected preview and resource link, got %d content items", len(content))
+	}
+
+	preview := content[0].(map[string]interface{})
+	if preview["type"] != "text" || len(preview["text"].(string)) >= len(answer) {
+		t.Errorf("Expected a short text preview, got %v", preview)
+	}
+
+	link := content[1].(map[string]interface{})
+	if link["type"] != "resource_link" {
+		t.Errorf("Expected resource_link, got %v", link["type"])
+	}
+	if link["uri"] != "prompt-history://1/answer" {
+		t.Errorf("Expected uri prompt-history://1/answer, got %v", link["uri"])
+	}
+	if link["mimeType"] != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text/plain mime type, got %v", link["mimeType"])
+	}
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["size"] != float64(len(answer)) {
+		t.Errorf("Expected size %d, got %v", len(answer), structured["size"])
+	}
+	if hash, _ := structured["sha256"].(string); len(hash) != 64 {
+		t.Errorf("Expected a sha256 hex digest, got %v", structured["sha256"])
+	}
+
+	read := findResponse(t, messages, 2)["result"].(map[string]interface{})
+	contents := read["contents"].([]interface{})
+	if len(contents) != 1 || contents[0].(map[string]interface{})["text"] != answer {
+		t.Errorf("Expected resources/read to return the full answer")
+	}
+
+	list := findResponse(t, messages, 3)["result"].(map[string]interface{})
+	if resources := list["resources"].([]interface{}); len(resources) != 1 {
+		t.Errorf("Expected 1 listed resource, got %d", len(resources))
+	}
+}
+
+func TestSmallAnswerStaysInline(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Name?","method":"web"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{InlineLimit: 1024})
+	srv.SetInputProvider("web", &fakeProvider{response: "Alice"})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	content := result["content"].([]interface{})
+	if len(content) != 1 || content[0].(map[string]interface{})["text"] != "Alice" {
+		t.Errorf("Expected the answer inline, got %v", content)
+	}
+	if structured := result["structuredContent"]; !reflect.DeepEqual(structured, map[string]interface{}{"action": "submitted"}) {
+		t.Errorf("Expected no link details for inline answers, got %v", structured)
+	}
+}
+
+func TestReadUnknownResource(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"prompt-history://42/answer"}}`
+
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if errorObj["code"] != float64(-32002) {
+		t.Errorf("Expected error code -32002, got %v", errorObj["code"])
+	}
+}
+
+func TestStoredAnswersEvicted(t *testing.T) {
+	call := func(id int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste the log","method":"web"}}}`, id)
+	}
+	read := func(id, prompt int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"resources/read","params":{"uri":"prompt-history://%d/answer"}}`, id, prompt)
+	}
+
+	// 101 answers are stored; reading the first one halfway keeps it, so
+	// the second is the one forgotten
+	var input []string
+	for i := 1; i <= 101; i++ {
+		input = append(input, call(i))
+		if i == 50 {
+			input = append(input, read(1001, 1))
+		}
+	}
+	input = append(input, read(1002, 1), read(1003, 2), read(1004, 3))
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{InlineLimit: 1024})
+	srv.SetInputProvider("web", &fakeProvider{response: strings.Repeat("log line\n", 500)})
+	messages := parseMessages(t, runServer(t, srv, strings.Join(input, "\n")).String())
+
+	for _, id := range []float64{1002, 1004} {
+		if resp := findResponse(t, messages, id); resp["error"] != nil {
+			t.Errorf("%v: expected the answer still kept, got %v", id, resp["error"])
+		}
+	}
+	errorObj, _ := findResponse(t, messages, 1003)["error"].(map[string]interface{})
+	if errorObj["code"] != float64(-32002) || errorObj["message"] != "Resource not found: prompt-history://2/answer" {
+		t.Errorf("Expected the evicted answer not found, got %v", errorObj)
+	}
+}
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func reviewCall(t *testing.T, content string) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_review",
+			"arguments": map[string]interface{}{"content": content, "method": "web"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserReviewDecisions(t *testing.T) {
+	tests := []struct {
+		responses []string
+		decision  string
+		approved  bool
+		comment   string
+	}{
+		{[]string{`{"decision":"approve"}`}, "approve", true, ""},
+		{[]string{`{"decision":"reject"}`}, "reject", false, ""},
+		{[]string{`{"decision":"approve_with_comment","comment":"  "}`, `{"decision":"approve_with_comment","comment":"rename foo"}`}, "approve_with_comment", true, "rename foo"},
+		{[]string{`{"decision":"maybe"}`, `{"decision":"reject"}`}, "reject", false, ""},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("web", &fakeProvider{responses: tt.responses})
+
+		messages := parseMessages(t, runServer(t, srv, reviewCall(t, "diff --git a/x b/x\n")).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["decision"] != tt.decision || structured["approved"] != tt.approved || structured["comment"] != tt.comment {
+			t.Errorf("%v: unexpected structuredContent %v", tt.responses, structured)
+		}
+	}
+}
+
+func TestUserReviewLargeDiff(t *testing.T) {
+	var diff strings.Builder
+	for i := 0; i < 30000; i++ {
+		fmt.Fprintf(&diff, "+line %d with <html> & \"quotes\"\n", i)
+	}
+
+	provider := &fakeProvider{response: `{"decision":"approve"}`}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	// The request is a single line far longer than bufio.Scanner's default limit
+	messages := parseMessages(t, runServer(t, srv, reviewCall(t, diff.String())).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	if result["structuredContent"].(map[string]interface{})["decision"] != "approve" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if provider.lastReq.Content != diff.String() {
+		t.Error("Expected the content to reach the provider intact")
+	}
+
+	handler := server.NewWebInputHandler(server.NewReviewPrompt("Apply?", "web", diff.String()))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<pre class="review">`) || !strings.Contains(body, "&#43;line 29999 with &lt;html&gt; &amp; &#34;quotes&#34;") {
+		t.Error("Expected the whole diff to be rendered, escaped, in a pre block")
+	}
+}
+
+func TestUserReviewMissingContent(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_review","arguments":{"prompt":"Apply?"}}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if !ok || errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected -32602, got %v", errorObj)
+	}
+}
+
+func TestReviewTTYPager(t *testing.T) {
+	var content strings.Builder
+	for i := 1; i <= 50; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+
+	// Page once, skip the rest, fumble the decision, then comment
+	term := newFakeTerminal("\nq\nx\nc\nlooks good\n")
+	req := server.NewReviewPrompt("Apply?", "tty", content.String())
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != `{"decision":"approve_with_comment","comment":"looks good"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+
+	output := term.output.String()
+	if !strings.Contains(output, "line 22\n-- 22/50 lines --") || !strings.Contains(output, "line 44\n-- 44/50 lines --") {
+		t.Errorf("Expected the content to be paged, got:\n%s", output)
+	}
+	if strings.Contains(output, "line 45\n") {
+		t.Errorf("Expected q to skip the remaining pages, got:\n%s", output)
+	}
+	if !strings.Contains(output, "Please enter a, r or c") {
+		t.Errorf("Expected an invalid decision to re-prompt, got:\n%s", output)
+	}
+}
+
+func TestReviewWeb(t *testing.T) {
+	req := server.NewReviewPrompt("Apply?", "web", "+added\n")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{`value="approve">Approve</button>`, `value="approve_with_comment">Approve with comment</button>`, `value="reject" class="deny">Reject</button>`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s, got:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"decision": {"approve_with_comment"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Please enter a comment") {
+		t.Errorf("Expected a missing comment to be rejected, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"decision": {"approve"}, "comment": {"ship it\r\nthen tag"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the review to be accepted, got %d", rec.Code)
+	}
+	if answer, _ := handler.Wait(context.Background()); answer != `{"decision":"approve_with_comment","comment":"ship it\nthen tag"}` {
+		t.Errorf("Unexpected answer %s", answer)
+	}
+}
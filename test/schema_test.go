@@ -0,0 +1,172 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+const deploySchema = `{"type":"object","properties":{` +
+	`"env":{"type":"string","enum":["staging","production"]},` +
+	`"replicas":{"type":"integer","minimum":1},` +
+	`"note":{"type":"string","maxLength":20}},` +
+	`"required":["env","replicas"]}`
+
+func TestResponseSchemaRawJSON(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Ports?","response_schema":{"type":"array","items":{"type":"integer"},"minItems":1}}}}`
+
+	provider := &fakeProvider{responses: []string{"80, 443", `[80,"https"]`, ` [80, 443] `}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	content := result["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "[80, 443]" {
+		t.Errorf("Expected the raw answer as text, got %q", text)
+	}
+	value, ok := result["structuredContent"].(map[string]interface{})["value"].([]interface{})
+	if !ok || len(value) != 2 || value[1] != float64(443) {
+		t.Errorf("Expected the parsed answer in structuredContent.value, got %v", result["structuredContent"])
+	}
+	if provider.attempts != 3 {
+		t.Errorf("Expected invalid JSON and schema mismatches to be re-asked, got %d attempts", provider.attempts)
+	}
+}
+
+func TestResponseSchemaForm(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy","response_schema":` + deploySchema + `}}}`
+
+	provider := &fakeProvider{responses: []string{
+		`{"env":"1","replicas":"0","note":""}`,
+		`{"env":"production","replicas":"3","note":""}`,
+	}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	value := result["structuredContent"].(map[string]interface{})["value"].(map[string]interface{})
+	if value["env"] != "production" || value["replicas"] != float64(3) {
+		t.Errorf("Expected typed form values, got %v", value)
+	}
+	if _, present := value["note"]; present {
+		t.Errorf("Expected the empty optional field to be left out, got %v", value)
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected the out-of-range field to be re-asked, got %d attempts", provider.attempts)
+	}
+	if provider.lastReq.Kind != server.KindForm || provider.lastReq.Fields[0].Name != "env" || !provider.lastReq.Fields[2].Optional {
+		t.Errorf("Expected required fields first and the rest optional, got %+v", provider.lastReq.Fields)
+	}
+}
+
+func TestResponseSchemaWebFields(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"replicas": map[string]interface{}{"type": "integer", "minimum": float64(1)},
+			"canary":   map[string]interface{}{"type": "boolean"},
+		},
+		"required": []interface{}{"replicas"},
+	}
+	req := server.NewPromptRequest("Deploy", "web")
+	req.MakeStructured(schema)
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `name="field.replicas"`) || !strings.Contains(body, "canary (optional)") {
+		t.Errorf("Expected form fields for the schema properties, got:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.replicas": {"1.5"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "replicas must be integer") {
+		t.Errorf("Expected the field to be flagged, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.replicas": {"2"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the valid form to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != `{"replicas":2}` {
+		t.Errorf("Expected {\"replicas\":2}, got %q (%v)", answer, err)
+	}
+}
+
+func TestResponseSchemaMultilineTypesJSON(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy","multiline":true,"response_schema":` + deploySchema + `}}}`
+
+	provider := &fakeProvider{responses: []string{`{"env":"staging"}`, "{\n  \"env\": \"staging\",\n  \"replicas\": 2\n}\n"}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	value := result["structuredContent"].(map[string]interface{})["value"].(map[string]interface{})
+	if value["replicas"] != float64(2) {
+		t.Errorf("Expected the typed JSON to be parsed, got %v", value)
+	}
+	if provider.lastReq.Kind == server.KindForm {
+		t.Error("Expected multiline to opt out of the form")
+	}
+	if provider.attempts != 2 {
+		t.Errorf("Expected the missing required property to be re-asked, got %d attempts", provider.attempts)
+	}
+}
+
+func TestResponseSchemaInvalid(t *testing.T) {
+	tests := []string{
+		`"response_schema":"object"`,
+		`"response_schema":{"anyOf":[{"type":"string"}]}`,
+		`"response_schema":{"type":"thing"}`,
+		`"response_schema":{"type":"string","pattern":"("}`,
+		`"response_schema":{"type":"object","properties":{"a":{"minLength":-1}}}`,
+		`"response_schema":{"type":"string"},"pattern":"a+"`,
+		`"response_schema":{"type":"string"},"default":"x"`,
+		`"response_schema":{"type":"string"},"type":"number"`,
+		`"response_schema":{"type":"string"},"secret":true`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestResponseSchemaAdvertised(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	tools := findResponse(t, messages, 1)["result"].(map[string]interface{})["tools"].([]interface{})
+	for _, tool := range tools {
+		def := tool.(map[string]interface{})
+		if def["name"] != "user_input" {
+			continue
+		}
+		props := def["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		if _, ok := props["response_schema"]; !ok || !strings.Contains(def["description"].(string), "response_schema") {
+			t.Errorf("Expected user_input to advertise response_schema, got %v", def)
+		}
+		return
+	}
+	t.Error("Expected user_input in tools/list")
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestSecretTTYDisablesEcho(t *testing.T) {
+	term := newFakeTerminal("hunter2\n")
+	req := server.NewPromptRequest("API key?", "tty")
+	req.Secret = true
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "hunter2" {
+		t.Errorf("Expected hunter2, got %q", answer)
+	}
+	if term.echoOff || term.restored != 1 {
+		t.Errorf("Expected echo to be restored once, got echoOff=%v restored=%d", term.echoOff, term.restored)
+	}
+	if strings.Contains(term.output.String(), "hunter2") {
+		t.Errorf("Expected the secret not to be written to the terminal, got:\n%s", term.output.String())
+	}
+}
+
+func TestSecretTTYRestoresEchoOnCancel(t *testing.T) {
+	term := &fakeTerminal{input: blockingReader{}}
+	req := server.NewPromptRequest("API key?", "tty")
+	req.Secret = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ttyProvider(term).GetInput(ctx, req); err == nil {
+		t.Fatal("Expected the cancelled prompt to fail")
+	}
+	if term.echoOff || term.restored != 1 {
+		t.Errorf("Expected echo to be restored after cancellation, got echoOff=%v restored=%d", term.echoOff, term.restored)
+	}
+}
+
+func TestConfirmSecretTTY(t *testing.T) {
+	term := newFakeTerminal("hunter2\nhunter3\nhunter2\nhunter2\n")
+	req := server.NewPromptRequest("Password?", "tty")
+	req.Secret = true
+	req.ConfirmSecret = true
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != "hunter2" {
+		t.Fatalf("Expected hunter2, got %q (%v)", answer, err)
+	}
+	output := term.output.String()
+	if strings.Count(output, "Repeat to confirm: ") != 2 || !strings.Contains(output, "Entries did not match") {
+		t.Errorf("Expected a mismatch to ask again, got:\n%s", output)
+	}
+}
+
+func TestSecretWebPasswordField(t *testing.T) {
+	req := server.NewPromptRequest("Password?", "web")
+	req.Secret = true
+	req.ConfirmSecret = true
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<input type="password" name="response"`) || !strings.Contains(body, `name="response_confirm"`) {
+		t.Errorf("Expected password fields, got:\n%s", body)
+	}
+	if strings.Contains(body, `<input type="text"`) {
+		t.Error("Expected no plain text input for a secret")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"hunter2"}, "response_confirm": {"hunter3"}}))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Entries did not match") {
+		t.Errorf("Expected mismatched entries to be rejected, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "hunter") {
+		t.Error("Expected the rejected secret not to be sent back to the browser")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"hunter2"}, "response_confirm": {"hunter2"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected matching entries to be accepted, got %d", rec.Code)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "hunter2" {
+		t.Errorf("Expected hunter2, got %q (%v)", answer, err)
+	}
+}
+
+func TestSecretNeverStoredOrObserved(t *testing.T) {
+	secret := strings.Repeat("s", 4096)
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Key?","method":"web","secret":true}}}
+{"jsonrpc":"2.0","id":2,"method":"resources/list","params":{}}`
+
+	hub := server.NewObserverHub(true)
+	client, conn := net.Pipe()
+	defer client.Close()
+	hub.Attach(conn)
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{InlineLimit: 1024})
+	srv.SetObserverHub(hub)
+	srv.SetInputProvider("web", &fakeProvider{response: secret})
+
+	events := make(chan server.ObserverEvent, 2)
+	go func() {
+		reader := bufio.NewReader(client)
+		for i := 0; i < 2; i++ {
+			events <- readEvent(t, client, reader)
+		}
+	}()
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+
+	content := findResponse(t, messages, 1)["result"].(map[string]interface{})["content"].([]interface{})
+	if len(content) != 1 || content[0].(map[string]interface{})["text"] != secret {
+		t.Errorf("Expected the secret to be returned inline, got %d content items", len(content))
+	}
+	list := findResponse(t, messages, 2)["result"].(map[string]interface{})
+	if resources := list["resources"].([]interface{}); len(resources) != 0 {
+		t.Errorf("Expected the secret not to be stored, got %v", resources)
+	}
+
+	<-events
+	if resolved := <-events; resolved.Response != "" {
+		t.Errorf("Expected observers not to see the secret, got %q", resolved.Response)
+	}
+}
+
+// blockingReader never returns, like a terminal nobody types into.
+type blockingReader struct{}
+
+func (blockingReader) Read(p []byte) (int, error) {
+	select {}
+}
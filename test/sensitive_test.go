@@ -0,0 +1,157 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// runVerbose runs srv in verbose mode and returns its stdout and stderr.
+func runVerbose(t *testing.T, srv *server.MCPServer, input string) (string, string) {
+	t.Helper()
+
+	stdout := &syncBuffer{}
+	stderr := &syncBuffer{}
+	srv.SetIO(strings.NewReader(input), stdout, stderr)
+	srv.SetVerbose(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Start(ctx)
+	return stdout.String(), stderr.String()
+}
+
+func sensitiveCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"API key for staging?"` + args + `}}}`
+}
+
+func TestSensitiveRedactedInVerboseLog(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "sk-live-1234"})
+
+	stdout, stderr := runVerbose(t, srv, sensitiveCall(`,"sensitive":true`))
+
+	if strings.Contains(stderr, "sk-live-1234") {
+		t.Errorf("Sensitive answer leaked to stderr:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "answered: \"[redacted]\"") {
+		t.Errorf("Expected the answer to be logged as redacted, got:\n%s", stderr)
+	}
+	if strings.Contains(stderr, "API key for staging?") || !strings.Contains(stderr, "asking via tty: \"[redacted]\"") {
+		t.Errorf("Expected the prompt to be logged as redacted, got:\n%s", stderr)
+	}
+
+	// The agent still gets the value, marked for the assistant only
+	result := findResponse(t, parseMessages(t, stdout), 1)["result"].(map[string]interface{})
+	item := result["content"].([]interface{})[0].(map[string]interface{})
+	if item["text"] != "sk-live-1234" {
+		t.Errorf("Expected the answer in the result, got %v", item["text"])
+	}
+	annotations, _ := item["annotations"].(map[string]interface{})
+	audience, _ := annotations["audience"].([]interface{})
+	if len(audience) != 1 || audience[0] != "assistant" {
+		t.Errorf("Expected audience [assistant], got %v", item["annotations"])
+	}
+}
+
+func TestVerboseLogsOrdinaryAnswers(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "main"})
+
+	stdout, stderr := runVerbose(t, srv, sensitiveCall(""))
+
+	if !strings.Contains(stderr, "answered: \"main\"") || !strings.Contains(stderr, "asking via tty: \"API key for staging?\"") {
+		t.Errorf("Expected the prompt and answer in the verbose log, got:\n%s", stderr)
+	}
+	item := findResponse(t, parseMessages(t, stdout), 1)["result"].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if _, ok := item["annotations"]; ok {
+		t.Errorf("Expected no annotations without sensitive, got %v", item)
+	}
+
+	// Quiet unless verbose
+	var quiet bytes.Buffer
+	srv = &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "main"})
+	srv.SetIO(strings.NewReader(sensitiveCall("")), &syncBuffer{}, &quiet)
+	srv.Start(context.Background())
+	if quiet.Len() != 0 {
+		t.Errorf("Expected nothing on stderr without verbose, got %q", quiet.String())
+	}
+}
+
+func TestSensitiveRedactedOnFailure(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "hunter2"})
+
+	stdout, stderr := runVerbose(t, srv, sensitiveCall(`,"sensitive":true,"pattern":"\\d+","max_attempts":1`))
+
+	if strings.Contains(stderr, "hunter2") || !strings.Contains(stderr, "failed: [redacted]") {
+		t.Errorf("Expected a redacted failure, got:\n%s", stderr)
+	}
+	result := findResponse(t, parseMessages(t, stdout), 1)["result"].(map[string]interface{})
+	if strings.Contains(result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), "hunter2") {
+		t.Errorf("Expected the invalid value to be left out like a secret, got %v", result)
+	}
+}
+
+func TestSensitiveRedactedForObservers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "obs.sock")
+	hub, err := server.ListenObservers(path, true)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer hub.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	srv := &server.MCPServer{}
+	srv.SetObserverHub(hub)
+	srv.SetInputProvider("tty", &fakeProvider{response: "sk-live-1234", delay: 100 * time.Millisecond})
+	runServer(t, srv, sensitiveCall(`,"sensitive":true`))
+
+	readEvent(t, conn, reader)
+	resolved := readEvent(t, conn, reader)
+	if resolved.Type != server.EventPromptResolved || resolved.Response != "[redacted]" {
+		t.Errorf("Expected a redacted answer for observers, got %+v", resolved)
+	}
+}
+
+func TestSensitiveWebDoesNotEchoValue(t *testing.T) {
+	req := server.NewPromptRequest("Token?", "web")
+	req.Sensitive = true
+	req.Validate = func(response string) (string, error) {
+		if !strings.HasPrefix(response, "tok-") {
+			return "", errors.New("Tokens start with tok-")
+		}
+		return response, nil
+	}
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"wrong-value"}}))
+	if rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), "wrong-value") {
+		t.Errorf("Expected the rejected value not to be shown again, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"tok-secret"}}))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "tok-secret") {
+		t.Errorf("Expected a thank-you page without the value, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
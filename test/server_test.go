@@ -204,8 +204,8 @@ func TestMCPServerToolsList(t *testing.T) {
 		t.Fatal("Expected tools to be an array")
 	}
 
-	if len(tools) != 1 {
-		t.Fatalf("Expected 1 tool, got %d", len(tools))
+	if len(tools) != 20 {
+		t.Fatalf("Expected 20 tools, got %d", len(tools))
 	}
 
 	tool, ok := tools[0].(map[string]interface{})
@@ -217,7 +217,7 @@ func TestMCPServerToolsList(t *testing.T) {
 		t.Errorf("Expected tool name 'user_input', got %v", tool["name"])
 	}
 
-	if tool["description"] != "Request input or approval from the user" {
+	if desc, _ := tool["description"].(string); !strings.HasPrefix(desc, "Request input or approval from the user") {
 		t.Errorf("Expected tool description, got %v", tool["description"])
 	}
 }
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func keyProvider(term *keyTerminal) server.InputProvider {
+	return server.NewTTYProvider(func() (io.ReadWriteCloser, error) { return term, nil })
+}
+
+func TestSingleKeyChoice(t *testing.T) {
+	term := &keyTerminal{fakeTerminal: newFakeTerminal("x02")}
+	req := server.NewChoicePrompt("Where to?", "tty", []string{"dev", "staging", "prod"})
+	req.SingleKey = true
+
+	answer, err := keyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetInput failed: %v", err)
+	}
+	if answer != "staging" {
+		t.Errorf("Expected staging, got %q", answer)
+	}
+	if output := term.output.String(); !strings.Contains(output, "Choice [1-3]: \a\a2) staging\n") {
+		t.Errorf("Expected invalid keys to beep and the selection to be echoed, got:\n%q", output)
+	}
+	if term.raw != 1 || term.restored != 1 {
+		t.Errorf("Expected raw mode once and restored, got %d/%d", term.raw, term.restored)
+	}
+}
+
+func TestSingleKeyConfirm(t *testing.T) {
+	tests := []struct {
+		def, input, answer string
+	}{
+		{"", "\rY", "yes"},
+		{"no", "\r", "no"},
+		{"yes", "n", "no"},
+	}
+	for _, tt := range tests {
+		term := &keyTerminal{fakeTerminal: newFakeTerminal(tt.input)}
+		req := server.NewConfirmPrompt("Deploy?", "tty", tt.def)
+		req.SingleKey = true
+
+		answer, err := keyProvider(term).GetInput(context.Background(), req)
+		if err != nil || answer != tt.answer {
+			t.Errorf("%q with default %q: expected %s, got %q (%v)", tt.input, tt.def, tt.answer, answer, err)
+		}
+		if output := term.output.String(); !strings.HasSuffix(output, tt.answer+"\n") {
+			t.Errorf("%q: expected the answer to be echoed, got %q", tt.input, output)
+		}
+	}
+}
+
+func TestSingleKeyCtrlC(t *testing.T) {
+	term := &keyTerminal{fakeTerminal: newFakeTerminal("x\x03y")}
+	req := server.NewConfirmPrompt("Deploy?", "tty", "")
+	req.SingleKey = true
+
+	_, err := keyProvider(term).GetInput(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected Ctrl+C to cancel, got %v", err)
+	}
+	if term.restored != 1 {
+		t.Errorf("Expected the terminal to be restored, got %d", term.restored)
+	}
+}
+
+func TestSingleKeyFallsBackToLines(t *testing.T) {
+	// A terminal that can't deliver single keys reads lines as usual
+	term := newFakeTerminal("9\n3\n")
+	req := server.NewChoicePrompt("Where to?", "tty", []string{"dev", "staging", "prod"})
+	req.SingleKey = true
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != "prod" {
+		t.Errorf("Expected prod, got %q (%v)", answer, err)
+	}
+}
+
+func TestSingleKeyPipe(t *testing.T) {
+	if _, err := exec.LookPath("stty"); err != nil {
+		t.Skip("stty not available")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	go func() {
+		w.WriteString("2\n")
+		w.Close()
+	}()
+
+	// stty fails on a pipe, so the answer is read as a line
+	req := server.NewChoicePrompt("Where to?", "tty", []string{"dev", "staging"})
+	req.SingleKey = true
+	answer, err := server.NewTTYProvider(func() (io.ReadWriteCloser, error) { return r, nil }).GetInput(context.Background(), req)
+	if err != nil || answer != "staging" {
+		t.Errorf("Expected staging, got %q (%v)", answer, err)
+	}
+}
+
+func TestSingleKeyRealTerminalRestored(t *testing.T) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no terminal available")
+	}
+	defer tty.Close()
+	before := sttyState(t, tty)
+
+	// Abandoning the prompt must leave the terminal as it was
+	req := server.NewConfirmPrompt("Test prompt, please ignore", "tty", "")
+	req.SingleKey = true
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	server.NewTTYProvider(nil).GetInput(ctx, req)
+
+	if after := sttyState(t, tty); after != before {
+		t.Errorf("Terminal state changed from %s to %s", before, after)
+	}
+}
+
+func sttyState(t *testing.T, tty *os.File) string {
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		t.Skipf("stty failed: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestSingleKeyInvalidArguments(t *testing.T) {
+	ten := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	tests := map[string]string{
+		"not a bool":       `{"name":"user_choice","arguments":{"prompt":"P","options":["a","b"],"single_key":"yes"}}`,
+		"multi_select":     `{"name":"user_choice","arguments":{"prompt":"P","options":["a","b"],"single_key":true,"multi_select":true}}`,
+		"allow_other":      `{"name":"user_choice","arguments":{"prompt":"P","options":["a","b"],"single_key":true,"allow_other":true}}`,
+		"ten options":      `{"name":"user_choice","arguments":{"prompt":"P","options":["` + strings.Join(ten, `","`) + `"],"single_key":true}}`,
+		"phrase":           `{"name":"user_confirm","arguments":{"prompt":"P","single_key":true,"confirmation_phrase":"x"}}`,
+		"follow_up":        `{"name":"user_confirm","arguments":{"prompt":"P","single_key":true,"follow_up":{"prompt":"Q"}}}`,
+		"attachments":      `{"name":"user_confirm","arguments":{"prompt":"P","single_key":true,"attachments":[{"name":"a","content":"b"}]}}`,
+		"confirm not bool": `{"name":"user_confirm","arguments":{"prompt":"P","single_key":1}}`,
+	}
+	for name, params := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":` + params + `}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", name, errObj)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func suggestionsCall(args string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Branch name?"` + args + `}}}`
+}
+
+func TestSuggestionsResult(t *testing.T) {
+	tests := []struct {
+		response  string
+		text      string
+		suggested bool
+		index     interface{}
+	}{
+		{"main", "main", true, float64(0)},
+		{" develop ", "develop", true, float64(1)},
+		{"feature/login", "feature/login", false, nil},
+	}
+
+	for _, tt := range tests {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: tt.response})
+
+		messages := parseMessages(t, runServer(t, srv, suggestionsCall(`,"suggestions":["main","develop"]`)).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.text {
+			t.Errorf("%q: expected %q, got %v", tt.response, tt.text, text)
+		}
+		structured := result["structuredContent"].(map[string]interface{})
+		if structured["suggested"] != tt.suggested || structured["suggestionIndex"] != tt.index {
+			t.Errorf("%q: unexpected structuredContent %v", tt.response, structured)
+		}
+	}
+}
+
+func TestSuggestionsEmptyList(t *testing.T) {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "main"})
+
+	messages := parseMessages(t, runServer(t, srv, suggestionsCall(`,"suggestions":[]`)).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	if structured := result["structuredContent"]; !reflect.DeepEqual(structured, map[string]interface{}{"action": "submitted"}) {
+		t.Errorf("Expected an empty list to mean no suggestions, got %v", result)
+	}
+}
+
+func TestSuggestionsTTY(t *testing.T) {
+	tests := []struct {
+		input  string
+		answer string
+	}{
+		{"2\n", "develop"},
+		{"hotfix\n", "hotfix"},
+		{"3\n", "3"},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		req := server.NewPromptRequest("Branch name?", "tty")
+		req.Suggestions = []string{"main", "develop"}
+
+		answer, err := ttyProvider(term).GetInput(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%q: GetInput: %v", tt.input, err)
+		}
+		if answer != tt.answer {
+			t.Errorf("%q: expected %q, got %q", tt.input, tt.answer, answer)
+		}
+		out := term.output.String()
+		if !strings.Contains(out, "  1) main\n  2) develop\n") || !strings.Contains(out, "or your own answer") {
+			t.Errorf("Expected numbered suggestions, got %q", out)
+		}
+	}
+}
+
+func TestSuggestionsWeb(t *testing.T) {
+	long := strings.Repeat("a very long suggestion ", 40)
+	req := server.NewPromptRequest("Branch name?", "web")
+	req.Suggestions = []string{"main", long}
+	req.RequireAnswer()
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `name="suggestion" value="main"`) || !strings.Contains(body, "formnovalidate") {
+		t.Errorf("Expected one-click suggestion buttons, got:\n%s", body)
+	}
+	if strings.Index(body, `class="suggestions"`) > strings.Index(body, `<input type="text" name="response"`) {
+		t.Error("Expected the suggestions above the text input")
+	}
+	if !strings.Contains(webAsset(t, "input.css"), "overflow-wrap: anywhere") {
+		t.Error("Expected long suggestions to wrap")
+	}
+
+	// Clicking a suggestion submits it even with the text input empty
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {""}, "suggestion": {"main"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the suggestion to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "main" {
+		t.Errorf("Expected main, got %q (%v)", answer, err)
+	}
+}
+
+func TestSuggestionsWebWithoutSuggestions(t *testing.T) {
+	req := server.NewPromptRequest("Branch name?", "web")
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), `class="suggestions"`) {
+		t.Error("Expected no suggestion row without suggestions")
+	}
+}
+
+func TestSuggestionsInvalidArguments(t *testing.T) {
+	tests := []string{
+		`,"suggestions":"main"`,
+		`,"suggestions":["main",""]`,
+		`,"suggestions":["main","main"]`,
+		`,"suggestions":["main"],"multiline":true`,
+		`,"suggestions":["main"],"secret":true`,
+		`,"suggestions":["1"],"type":"number"`,
+		`,"suggestions":["main","x"],"pattern":"[a-z]{2,}"`,
+	}
+	for _, args := range tests {
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, suggestionsCall(args)).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// themedPage renders a prompt's page under theme and returns it with the
+// handler serving it.
+func themedPage(t *testing.T, theme server.WebTheme) (string, *server.WebInputHandler) {
+	t.Helper()
+	if err := server.SetWebTheme(theme); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.SetWebTheme(server.WebTheme{}) })
+
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Deploy?", "web"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Body.String(), handler
+}
+
+func TestWebTheme(t *testing.T) {
+	plain, _ := themedPage(t, server.WebTheme{})
+	for _, unwanted := range []string{"<style>", `class="brand"`, "brand-footer", "theme-logo"} {
+		if strings.Contains(plain, unwanted) {
+			t.Errorf("Expected no %s without a theme, got:\n%s", unwanted, plain)
+		}
+	}
+	if !strings.Contains(plain, "<title>User Input Required</title>") {
+		t.Errorf("Expected the built-in title, got:\n%s", plain)
+	}
+	if css := webAsset(t, "input.css"); !strings.Contains(css, ":root { --accent: #007cba; --accent-hover: #005a87; }") || !strings.Contains(css, "button { background: var(--accent);") {
+		t.Errorf("Expected the built-in colours as custom properties, got:\n%s", css)
+	}
+
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(logo, []byte("\x89PNG\r\n\x1a\nlogo"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	themed, handler := themedPage(t, server.WebTheme{
+		PageTitle:   "Acme <Tools>",
+		Logo:        logo,
+		AccentColor: "#1A7F37",
+		Footer:      "Questions? Ask #platform",
+	})
+	for _, want := range []string{
+		"<title>User Input Required · Acme &lt;Tools&gt;</title>",
+		"<style>:root { --accent: #1a7f37; --accent-hover: #125c28; }</style>",
+		`<header class="brand"><img src="/static/theme-logo" alt="Acme &lt;Tools&gt;"><span>Acme &lt;Tools&gt;</span></header>`,
+		`<footer class="brand-footer">Questions? Ask #platform</footer>`,
+	} {
+		if !strings.Contains(themed, want) {
+			t.Errorf("Expected %q on the themed page, got:\n%s", want, themed)
+		}
+	}
+
+	// The pages that follow are branded too
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	for _, want := range []string{
+		"<title>Thank you! · Acme &lt;Tools&gt;</title>",
+		"<style>:root { --accent: #1a7f37; }</style>",
+		`<header class="brand">Acme &lt;Tools&gt;</header>`,
+		`<footer class="brand-footer">Questions? Ask #platform</footer>`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %q on the themed thank-you page, got:\n%s", want, rec.Body.String())
+		}
+	}
+
+	// The logo was read at startup and is served with the page
+	os.Remove(logo)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/theme-logo", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" || rec.Body.String() != "\x89PNG\r\n\x1a\nlogo" {
+		t.Errorf("Expected the logo, got %d %v", rec.Code, rec.Header())
+	}
+
+	// A logo URL is linked as is
+	linked, _ := themedPage(t, server.WebTheme{Logo: "https://intra.example.com/logo.svg"})
+	if !strings.Contains(linked, `<img src="https://intra.example.com/logo.svg" alt="">`) {
+		t.Errorf("Expected the logo URL, got:\n%s", linked)
+	}
+}
+
+func TestWebThemeInvalid(t *testing.T) {
+	notImage := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(notImage, []byte("<script>alert(1)</script>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		theme    server.WebTheme
+		expected string
+	}{
+		{server.WebTheme{AccentColor: "red"}, "hex colour"},
+		{server.WebTheme{AccentColor: "#12345"}, "hex colour"},
+		{server.WebTheme{AccentColor: "#fff; background: url(x)"}, "hex colour"},
+		{server.WebTheme{PageTitle: "Acme\nTools"}, "single line"},
+		{server.WebTheme{Footer: strings.Repeat("x", 501)}, "at most 500"},
+		{server.WebTheme{Logo: "https://"}, "not a valid URL"},
+		{server.WebTheme{Logo: filepath.Join(t.TempDir(), "missing.png")}, "no such file"},
+		{server.WebTheme{Logo: notImage}, "must be a PNG"},
+	}
+	for _, tt := range tests {
+		err := server.SetWebTheme(tt.theme)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%+v: expected an error containing %q, got %v", tt.theme, tt.expected, err)
+		}
+	}
+	if err := (server.Config{Theme: server.WebTheme{AccentColor: "blue"}}).Validate(); err == nil {
+		t.Error("Expected the config to reject a bad theme")
+	}
+}
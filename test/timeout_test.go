@@ -0,0 +1,257 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestUserInputTimeoutTTY(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Anyone there?","timeout":0.05}}}`
+
+	term := &fakeTerminal{input: blockingReader{}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	start := time.Now()
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the prompt to give up after the timeout, took %s", elapsed)
+	}
+
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Errorf("Expected an error result, got %v", result)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if text != "timed out after 0.05s waiting for user input" {
+		t.Errorf("Unexpected timeout message %q", text)
+	}
+	if structured := result["structuredContent"].(map[string]interface{}); structured["timedOut"] != true || structured["timeout"] != 0.05 {
+		t.Errorf("Expected timedOut and timeout in structuredContent, got %v", structured)
+	}
+	if atomic.LoadInt32(&term.closed) != 1 {
+		t.Errorf("Expected the terminal to be closed once, got %d", term.closed)
+	}
+}
+
+func TestUserInputTimeoutOverridesWebDefault(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q","method":"web","timeout":0.2}}}`
+
+	provider := &fakeProvider{response: "late", delay: time.Minute}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if provider.lastReq.Timeout != 200*time.Millisecond {
+		t.Errorf("Expected a 0.2s timeout, got %s", provider.lastReq.Timeout)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if result["isError"] != true || !strings.Contains(text, "after 0.2s") {
+		t.Errorf("Expected a timeout error result, got %v", result)
+	}
+}
+
+func TestUserInputZeroTimeoutWaits(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q","method":"web","timeout":0}}}`
+
+	provider := &fakeProvider{response: "answer"}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	findResponse(t, messages, 1)
+
+	if provider.lastReq.Timeout != 0 {
+		t.Errorf("Expected timeout 0 to disable the web default, got %s", provider.lastReq.Timeout)
+	}
+}
+
+func TestUserInputInvalidTimeout(t *testing.T) {
+	for _, timeout := range []string{`-1`, `"30"`, `true`} {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q","timeout":` + timeout + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for timeout %s, got %v", timeout, errorObj)
+		}
+	}
+}
+
+func TestDefaultResponseOnTimeout(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Run migrations?","timeout":0.05,"default_response":"skip"}}}`
+
+	term := &fakeTerminal{input: blockingReader{}}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != false {
+		t.Errorf("Expected a successful result, got %v", result)
+	}
+	if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != "skip" {
+		t.Errorf("Expected the default response, got %q", text)
+	}
+	if structured, _ := result["structuredContent"].(map[string]interface{}); structured["timedOut"] != true || structured["action"] != server.ActionTimedOut {
+		t.Errorf("Expected structuredContent.timedOut and action timed_out, got %v", result["structuredContent"])
+	}
+	if !strings.Contains(term.output.String(), `(Without an answer within 0.05s, "skip" will be used)`) {
+		t.Errorf("Expected the terminal to announce the default, got:\n%s", term.output.String())
+	}
+}
+
+func TestDefaultResponseCanonicalized(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Replicas?","method":"web","type":"number","timeout":0.05,"default_response":"+2.50"}}}`
+
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("web", &fakeProvider{response: "3", delay: time.Minute})
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["value"] != 2.5 || structured["timedOut"] != true {
+		t.Errorf("Expected the canonical default with timedOut, got %v", structured)
+	}
+}
+
+func TestDefaultResponseInvalid(t *testing.T) {
+	tests := []string{
+		`"default_response":"skip"`,
+		`"default_response":"skip","timeout":10,"pattern":"\\d+"`,
+		`"default_response":"","timeout":10`,
+		`"default_response":5,"timeout":10`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Q",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("Expected -32602 for %s, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestWebCountdownShowsDefault(t *testing.T) {
+	req := server.NewPromptRequest("Run migrations?", "web")
+	skip := "skip"
+	req.TimeoutResponse = &skip
+	handler := server.NewWebInputHandler(req)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="countdown" data-deadline="`) || !strings.Contains(body, "<strong>skip</strong> will be used") {
+		t.Errorf("Expected a countdown naming the default, got:\n%s", body)
+	}
+}
+
+func TestWebSubmissionAfterTimeout(t *testing.T) {
+	req := server.NewPromptRequest("Q", "web")
+	skip := "skip"
+	req.TimeoutResponse = &skip
+	handler := server.NewWebInputHandler(req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if _, err := handler.Wait(ctx); err == nil {
+		t.Fatal("Expected Wait to fail once ctx is done")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"half-typ"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "&#34;skip&#34; was used instead") {
+		t.Errorf("Expected a late submission to be turned away, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWebSubmissionRacingTimeout(t *testing.T) {
+	// Whichever wins, the submitter and the waiter must agree on it
+	for i := 0; i < 50; i++ {
+		req := server.NewPromptRequest("Q", "web")
+		handler := server.NewWebInputHandler(req)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Millisecond)
+		code := make(chan int, 1)
+		go func() {
+			time.Sleep(time.Duration(i%5) * time.Millisecond)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"answer"}}))
+			code <- rec.Code
+		}()
+
+		answer, err := handler.Wait(ctx)
+		cancel()
+		submitted := <-code
+
+		switch {
+		case err == nil && (answer != "answer" || submitted != http.StatusOK):
+			t.Fatalf("Run %d: answer %q returned but the submission got %d", i, answer, submitted)
+		case err != nil && submitted != http.StatusGone:
+			t.Fatalf("Run %d: timed out but the submission got %d", i, submitted)
+		}
+	}
+}
+
+func TestWebCountdownDeadline(t *testing.T) {
+	req := server.NewPromptRequest("Run migrations?", "web")
+	req.Timeout = time.Minute
+	before := time.Now()
+	handler := server.NewWebInputHandler(req)
+
+	// The page is loaded later, but counts down to the same moment
+	time.Sleep(200 * time.Millisecond)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	page := rec.Body.String()
+
+	m := regexp.MustCompile(`data-deadline="(\d+)"`).FindStringSubmatch(page)
+	if m == nil {
+		t.Fatalf("Expected a countdown, got:\n%s", page)
+	}
+	deadline, _ := strconv.ParseInt(m[1], 10, 64)
+	if min := before.Add(time.Minute).UnixMilli(); deadline < min || deadline > min+100 {
+		t.Errorf("Expected the deadline set when the prompt was made, %d, got %d", min, deadline)
+	}
+	if !strings.Contains(page, `This request expires in <span id="remaining"></span>`) {
+		t.Errorf("Expected the countdown on the page, got:\n%s", page)
+	}
+	if css := webAsset(t, "input.css"); !strings.Contains(css, "form.expired { opacity: 0.5;") {
+		t.Errorf("Expected an expired form to be greyed out, got:\n%s", css)
+	}
+	if js := webAsset(t, "input.js"); !strings.Contains(js, "e.stopImmediatePropagation();") {
+		t.Errorf("Expected an expired form not to submit, got:\n%s", js)
+	}
+}
+
+func TestTTYPrintsDeadline(t *testing.T) {
+	req := server.NewPromptRequest("Anyone there?", "tty")
+	req.Timeout = 5 * time.Minute
+	term := newFakeTerminal("yes\n")
+	if _, err := server.Ask(context.Background(), ttyProvider(term), req); err != nil {
+		t.Fatal(err)
+	}
+	out := term.output.String()
+	if !regexp.MustCompile(`\(This request expires at \d\d:\d\d:\d\d, in 5m0s\)\n`).MatchString(out) {
+		t.Errorf("Expected the deadline on the terminal, got:\n%s", out)
+	}
+	if strings.Count(out, "expires at") != 1 {
+		t.Errorf("Expected the deadline once, got:\n%s", out)
+	}
+}
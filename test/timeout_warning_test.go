@@ -0,0 +1,96 @@
+package test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestTimeoutWarningSentOnce(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?","method":"web"},"_meta":{"progressToken":"tok-1"}}}`
+
+	provider := &fakeProvider{response: "yes", delay: 300 * time.Millisecond}
+
+	srv := &server.MCPServer{}
+	// 0.0002 of the 5 minute web timeout is 60ms
+	srv.SetConfig(server.Config{WarnFraction: 0.0002})
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+
+	warnings := findNotifications(messages, "notifications/message")
+	if len(warnings) != 1 {
+		t.Fatalf("Expected exactly 1 warning, got %d: %v", len(warnings), messages)
+	}
+
+	params := warnings[0]["params"].(map[string]interface{})
+	if params["level"] != "warning" {
+		t.Errorf("Expected level warning, got %v", params["level"])
+	}
+	data := params["data"].(map[string]interface{})
+	if data["prompt"] != "Deploy?" {
+		t.Errorf("Expected warning to identify the prompt, got %v", data["prompt"])
+	}
+	if data["requestId"] != float64(1) {
+		t.Errorf("Expected requestId 1, got %v", data["requestId"])
+	}
+	if remaining, ok := data["secondsRemaining"].(float64); !ok || remaining < 290 || remaining > 300 {
+		t.Errorf("Expected roughly 300 seconds remaining, got %v", data["secondsRemaining"])
+	}
+
+	progress := findNotifications(messages, "notifications/progress")
+	if len(progress) != 1 {
+		t.Fatalf("Expected 1 progress notification, got %d", len(progress))
+	}
+	if progress[0]["params"].(map[string]interface{})["progressToken"] != "tok-1" {
+		t.Errorf("Expected progress token tok-1, got %v", progress[0]["params"])
+	}
+
+	if atomic.LoadInt32(&provider.warnings) != 1 {
+		t.Errorf("Expected the provider to be warned once, got %d", provider.warnings)
+	}
+
+	response := findResponse(t, messages, 1)
+	if response["result"] == nil {
+		t.Errorf("Expected a result after the warning, got %v", response)
+	}
+}
+
+func TestTimeoutWarningSuppressedWhenResolved(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?","method":"web"}}}`
+
+	provider := &fakeProvider{response: "yes", delay: 10 * time.Millisecond}
+
+	srv := &server.MCPServer{}
+	// 0.001 of the 5 minute web timeout is 300ms
+	srv.SetConfig(server.Config{WarnFraction: 0.001})
+	srv.SetInputProvider("web", provider)
+
+	stdout := runServer(t, srv, input)
+	time.Sleep(400 * time.Millisecond)
+
+	messages := parseMessages(t, stdout.String())
+	if warnings := findNotifications(messages, "notifications/message"); len(warnings) != 0 {
+		t.Errorf("Expected no warning for a resolved prompt, got %v", warnings)
+	}
+	if atomic.LoadInt32(&provider.warnings) != 0 {
+		t.Errorf("Expected the provider not to be warned, got %d", provider.warnings)
+	}
+}
+
+func TestTimeoutWarningDisabled(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?","method":"web"}}}`
+
+	provider := &fakeProvider{response: "yes", delay: 100 * time.Millisecond}
+
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{WarnFraction: -1})
+	srv.SetInputProvider("web", provider)
+
+	messages := parseMessages(t, runServer(t, srv, input).String())
+	if warnings := findNotifications(messages, "notifications/message"); len(warnings) != 0 {
+		t.Errorf("Expected no warning when disabled, got %v", warnings)
+	}
+}
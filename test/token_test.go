@@ -0,0 +1,135 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestWebToken(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	page, err := handler.Protect("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := strings.Trim(page, "/")
+	if len(token) < 20 || strings.Contains(token, "/") {
+		t.Fatalf("Expected a random path segment, got %q", page)
+	}
+	other := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	if otherPage, _ := other.Protect(""); otherPage == page {
+		t.Errorf("Expected every prompt to get its own token, got %q twice", page)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	submit := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, path, url.Values{"response": {"Ada"}}))
+		return rec
+	}
+
+	// Without the exact token there is nothing to see or answer
+	wrong := "/" + token[:len(token)-1] + "x/"
+	for _, path := range []string{"/", "/submit", wrong, "/" + token + "x/", "/" + strings.ToUpper(token) + "/"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", path, rec.Code)
+		}
+	}
+	for _, path := range []string{"/submit", wrong + "submit"} {
+		if rec := submit(path); rec.Code != http.StatusNotFound {
+			t.Errorf("POST %s: expected 404, got %d", path, rec.Code)
+		}
+	}
+
+	if rec := get("/" + token); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != page {
+		t.Errorf("Expected a redirect to the page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec := get(page)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `action="`+page+`submit"`) {
+		t.Fatalf("Expected the form to post under the token, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	// The tokened URL takes exactly one answer
+	if rec := submit(page + "submit"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the answer to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "Ada" {
+		t.Errorf("Expected Ada, got %q (%v)", answer, err)
+	}
+	if rec := submit(page + "submit"); rec.Code != http.StatusGone {
+		t.Errorf("Expected 410 for a second answer, got %d", rec.Code)
+	}
+	if rec := get(page); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<h1>Already answered</h1>") || strings.Contains(rec.Body.String(), "Ada") {
+		t.Errorf("Expected the page to say it was answered once answered, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	for _, path := range []string{page + "events", page + "static/input.css", wrong} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404 once answered, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestWebTokenNotLogged(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	d := newDashboard(t)
+	index, err := d.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A watching index page means the prompts aren't brought up in a browser
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, index+"events", nil)
+	events, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+	if _, err := bufio.NewReader(events.Body).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	low := server.NewPromptRequest("Deploy?", "web")
+	low.Priority = server.PriorityLow
+	go d.GetInput(ctx, low)
+	waitListed(t, d, 1)
+	go d.GetInput(ctx, server.NewPromptRequest("Migrate?", "web"))
+	_, links := waitListed(t, d, 2)
+
+	os.Stderr = stderr
+	w.Close()
+	out, _ := io.ReadAll(r)
+	tokens := []string{strings.Trim(strings.TrimPrefix(d.Path(), "/"), "/")}
+	for _, link := range links {
+		tokens = append(tokens, strings.Split(link, "/")[3])
+	}
+	for _, token := range tokens {
+		if strings.Contains(string(out), token) {
+			t.Errorf("Expected no token on stderr, got %q", out)
+		}
+	}
+	if origin := strings.TrimSuffix(index, d.Path()); strings.Count(string(out), "Waiting for input on "+origin+"\n") != 2 {
+		t.Errorf("Expected stderr to name only where the prompts are served, got %q", out)
+	}
+}
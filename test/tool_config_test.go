@@ -0,0 +1,130 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func disabledServer() *server.MCPServer {
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{Tools: server.ToolsConfig{Disable: []string{"user_input"}}})
+	return srv
+}
+
+func TestDisabledToolHiddenFromList(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+
+	messages := parseMessages(t, runServer(t, disabledServer(), input).String())
+
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+	for _, tool := range result["tools"].([]interface{}) {
+		if tool.(map[string]interface{})["name"] == "user_input" {
+			t.Errorf("Expected disabled tools to be hidden, got %v", tool)
+		}
+	}
+}
+
+func TestDisabledToolCallRejected(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Hi"}}}
+{"jsonrpc":"2.0","id":2,"method":"user_input","params":{"prompt":"Hi"}}`
+
+	messages := parseMessages(t, runServer(t, disabledServer(), input).String())
+
+	for _, id := range []float64{1, 2} {
+		errorObj, ok := findResponse(t, messages, id)["error"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected an error for request %v", id)
+		}
+		if errorObj["code"] != float64(-32601) {
+			t.Errorf("Expected error code -32601, got %v", errorObj["code"])
+		}
+		data, ok := errorObj["data"].(map[string]interface{})
+		if !ok || data["reason"] != "administratively disabled" {
+			t.Errorf("Expected error data saying the tool is administratively disabled, got %v", errorObj["data"])
+		}
+	}
+}
+
+func TestUnknownToolStillUnknown(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope","arguments":{}}}`
+
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if errorObj["message"] != "Unknown tool" || errorObj["data"] != nil {
+		t.Errorf("Expected a plain Unknown tool error, got %v", errorObj)
+	}
+}
+
+func TestDisablingEveryToolIsInvalid(t *testing.T) {
+	cfg := server.DefaultConfig()
+	cfg.Tools.Disable = []string{"user_input", "user_choice", "user_confirm", "user_ack", "user_read", "user_form", "user_wizard", "user_file_select", "notify_user", "user_review", "user_plan_review", "user_edit", "user_rating", "user_reaction", "user_rank", "user_list", "user_datetime", "user_clipboard", "user_credentials", "user_input_batch"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error when every tool is disabled")
+	}
+
+	cfg = server.DefaultConfig()
+	cfg.Tools.Enable = []string{"no_such_tool"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "no_such_tool") {
+		t.Errorf("Expected an unknown tool error, got %v", err)
+	}
+}
+
+func TestLoadConfigToolsSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tools":{"enable":["user_input"]},"chunk_size":100}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := server.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.Tools.Enable) != 1 || cfg.Tools.Enable[0] != "user_input" {
+		t.Errorf("Expected tools.enable [user_input], got %v", cfg.Tools.Enable)
+	}
+	if cfg.ChunkSize != 100 {
+		t.Errorf("Expected chunk_size 100, got %d", cfg.ChunkSize)
+	}
+	if cfg.InlineLimit != server.DefaultConfig().InlineLimit {
+		t.Errorf("Expected missing keys to keep defaults, got inline limit %d", cfg.InlineLimit)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+}
+
+func TestReloadNotifiesToolListChange(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	srv := disabledServer()
+	srv.SetIO(strings.NewReader(""), &stdout, &stderr)
+
+	if err := srv.ReloadConfig(server.DefaultConfig()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	messages := parseMessages(t, stdout.String())
+	if len(findNotifications(messages, "notifications/tools/list_changed")) != 1 {
+		t.Errorf("Expected a list_changed notification, got %v", messages)
+	}
+
+	// Reloading the same tool set is silent
+	stdout.Reset()
+	if err := srv.ReloadConfig(server.DefaultConfig()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no notification when tools are unchanged, got %q", stdout.String())
+	}
+
+	// An invalid config is refused and the previous one kept
+	bad := server.DefaultConfig()
+	bad.Tools.Disable = []string{"user_input", "user_choice", "user_confirm", "user_ack", "user_read", "user_form", "user_wizard", "user_file_select", "notify_user", "user_review", "user_plan_review", "user_edit", "user_rating", "user_reaction", "user_rank", "user_list", "user_datetime", "user_clipboard", "user_credentials", "user_input_batch"}
+	if err := srv.ReloadConfig(bad); err == nil {
+		t.Error("Expected reload to refuse a config disabling every tool")
+	}
+}
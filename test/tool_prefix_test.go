@@ -0,0 +1,97 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func prefixedServer(prefix string) *server.MCPServer {
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "hello"})
+	cfg := server.DefaultConfig()
+	cfg.ToolPrefix = prefix
+	srv.SetConfig(cfg)
+	return srv
+}
+
+func listedToolNames(t *testing.T, srv *server.MCPServer) []string {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`
+	messages := parseMessages(t, runServer(t, srv, input).String())
+
+	var names []string
+	for _, tool := range findResponse(t, messages, 1)["result"].(map[string]interface{})["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func callTool(t *testing.T, srv *server.MCPServer, name string) map[string]interface{} {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + name + `","arguments":{"prompt":"Hi"}}}`
+	return findResponse(t, parseMessages(t, runServer(t, srv, input).String()), 1)
+}
+
+func TestToolPrefixEmpty(t *testing.T) {
+	srv := prefixedServer("")
+
+	if names := listedToolNames(t, srv); names[0] != "user_input" {
+		t.Errorf("Expected plain tool names, got %v", names)
+	}
+	if _, ok := callTool(t, srv, "user_input")["result"]; !ok {
+		t.Error("Expected the plain name to be callable")
+	}
+}
+
+func TestToolPrefixWithUnderscores(t *testing.T) {
+	srv := prefixedServer("prompt_user_")
+
+	for _, name := range listedToolNames(t, srv) {
+		if !strings.HasPrefix(name, "prompt_user_") {
+			t.Errorf("Expected every tool to be prefixed, got %q", name)
+		}
+	}
+	result, ok := callTool(t, srv, "prompt_user_user_input")["result"].(map[string]interface{})
+	if !ok || result["content"].([]interface{})[0].(map[string]interface{})["text"] != "hello" {
+		t.Errorf("Expected the prefixed name to be callable, got %v", result)
+	}
+}
+
+func TestToolPrefixRejectsUnprefixedName(t *testing.T) {
+	srv := prefixedServer("prompt_")
+
+	for _, name := range []string{"user_input", "prompt_", "prompt_prompt_user_input", "prompt_nope"} {
+		errorObj, ok := callTool(t, srv, name)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32601) || errorObj["message"] != "Unknown tool" {
+			t.Errorf("Expected %q to be an unknown tool, got %v", name, errorObj)
+		}
+	}
+}
+
+func TestToolPrefixWithDisabledTool(t *testing.T) {
+	srv := prefixedServer("prompt_")
+	cfg := server.DefaultConfig()
+	cfg.ToolPrefix = "prompt_"
+	cfg.Tools.Disable = []string{"user_input"}
+	srv.SetConfig(cfg)
+
+	for _, name := range listedToolNames(t, srv) {
+		if name == "prompt_user_input" {
+			t.Error("Expected the disabled tool to be hidden")
+		}
+	}
+	errorObj, ok := callTool(t, srv, "prompt_user_input")["error"].(map[string]interface{})
+	if !ok || errorObj["message"] != "Tool disabled" || errorObj["data"].(map[string]interface{})["tool"] != "prompt_user_input" {
+		t.Errorf("Expected the disabled tool to be refused by its prefixed name, got %v", errorObj)
+	}
+}
+
+func TestInvalidToolPrefix(t *testing.T) {
+	for _, prefix := range []string{"my prefix", "prompt/", "ü_"} {
+		cfg := server.DefaultConfig()
+		cfg.ToolPrefix = prefix
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Expected prefix %q to be rejected", prefix)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+// fakeTunnel writes a tunnel command that prints script's output and
+// returns a template running it with the page server's URL.
+func fakeTunnel(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tunnel.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path + " {url}"
+}
+
+// liveTunnel is a fake tunnel that gives a public URL and keeps running.
+func liveTunnel(t *testing.T) *server.TunnelCommand {
+	t.Helper()
+	c, err := server.ParseTunnel(fakeTunnel(t, `echo "starting"; echo "ready at https://fake-abc.example.test/ for $1"; exec sleep 60`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestParseTunnel(t *testing.T) {
+	for _, name := range []string{"cloudflared", "ngrok", "localhost.run"} {
+		c, err := server.ParseTunnel(name, "")
+		if err != nil || !strings.Contains(c.Template, "{") {
+			t.Errorf("%s: expected a preset, got %+v (%v)", name, c, err)
+		}
+	}
+	c, err := server.ParseTunnel("cloudflared", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := "INF |  https://api.cloudflare.com/x  https://wild-cat-1.trycloudflare.com  |"
+	if got := c.Pattern.FindString(line); got != "https://wild-cat-1.trycloudflare.com" {
+		t.Errorf("Expected the quick tunnel's URL, got %q", got)
+	}
+
+	for _, spec := range []string{"", "serveo", "my-tunnel --to localhost"} {
+		if _, err := server.ParseTunnel(spec, ""); err == nil {
+			t.Errorf("%q: expected an invalid tunnel", spec)
+		}
+	}
+	if _, err := server.ParseTunnel("bore local {port}", "("); err == nil {
+		t.Error("Expected an invalid URL pattern")
+	}
+	if err := (server.Config{WebTunnel: "ngrok", WebExternalURL: "https://prompts.example.org"}).Validate(); err == nil {
+		t.Error("Expected a tunnel and an external URL to conflict")
+	}
+	l, err := server.Config{WebTunnel: "ngrok"}.WebListener()
+	if err != nil || l.Tunnel == nil || l.Private() {
+		t.Errorf("Expected a public listener with a tunnel, got %+v (%v)", l, err)
+	}
+}
+
+func TestTunnelStartAndClose(t *testing.T) {
+	tunnel, err := liveTunnel(t).Start("0.0.0.0", 8400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tunnel.URL != "https://fake-abc.example.test" || tunnel.Host() != "fake-abc.example.test" {
+		t.Errorf("Expected the public URL without a slash, got %q", tunnel.URL)
+	}
+
+	// The page is reached through the tunnel, so its origin is accepted
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	req := postForm(t, handler, "/submit", url.Values{"response": {"alice"}})
+	req.Header.Set("Origin", tunnel.URL)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the tunnel's origin to be accepted, got %d", rec.Code)
+	}
+
+	server.CloseTunnels()
+	if tunnel.Close() != nil {
+		t.Error("Expected closing twice to be harmless")
+	}
+	handler = server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	req = postForm(t, handler, "/submit", url.Values{"response": {"alice"}})
+	req.Header.Set("Origin", tunnel.URL)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a closed tunnel's origin to be refused, got %d", rec.Code)
+	}
+}
+
+func TestTunnelFailure(t *testing.T) {
+	c, err := server.ParseTunnel(fakeTunnel(t, `echo "account required"; exit 1`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Start("127.0.0.1", 8400); err == nil || !strings.Contains(err.Error(), "account required") {
+		t.Errorf("Expected the tunnel's last words in the error, got %v", err)
+	}
+
+	// A page server falls back to the local URL
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", Tunnel: c})
+	url, err := startDashboard(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(url, "http://127.0.0.1:") {
+		t.Errorf("Expected the local URL, got %s", url)
+	}
+}
+
+func TestTunnelDashboard(t *testing.T) {
+	useWebListener(t, server.WebListener{Host: "127.0.0.1", Tunnel: liveTunnel(t)})
+	d := newDashboard(t)
+	url, err := d.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://fake-abc.example.test"+d.Path() {
+		t.Errorf("Expected the dashboard at the public URL, got %s", url)
+	}
+	l := server.WebListener{Host: "127.0.0.1", Tunnel: liveTunnel(t)}
+	if phone, err := l.PhoneURL(url); err != nil || phone != url {
+		t.Errorf("Expected phones to use the public URL, got %q (%v)", phone, err)
+	}
+	d.Close()
+	if phone, _ := l.PhoneURL(url); phone == url {
+		t.Error("Expected the dashboard's tunnel to close with it")
+	}
+}
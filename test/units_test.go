@@ -0,0 +1,224 @@
+package test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+	"prompt-mcp/units"
+)
+
+func TestUnitsParse(t *testing.T) {
+	tests := []struct {
+		kind  string
+		input string
+		want  float64
+	}{
+		{units.Duration, "300", 300},
+		{units.Duration, "5m", 300},
+		{units.Duration, "5 min", 300},
+		{units.Duration, "5 Minutes", 300},
+		{units.Duration, "1h30m", 5400},
+		{units.Duration, "1 hour, 30 minutes", 5400},
+		{units.Duration, "1 hour and 30 minutes", 5400},
+		{units.Duration, "1.5h", 5400},
+		{units.Duration, "250ms", 0.25},
+		{units.Duration, "2d", 172800},
+		{units.Duration, "1 week", 604800},
+		{units.Duration, "-90s", -90},
+		{units.Duration, " 10 sec ", 10},
+		{units.Bytes, "512", 512},
+		{units.Bytes, "1.5GB", 1.5e9},
+		{units.Bytes, "1.5 gb", 1.5e9},
+		{units.Bytes, "64KiB", 65536},
+		{units.Bytes, "2 MB", 2e6},
+		{units.Bytes, "1k", 1000},
+		{units.Bytes, "3 bytes", 3},
+		{units.Bytes, "0.1KiB", 102},
+		{units.Percent, "20%", 0.2},
+		{units.Percent, "20", 0.2},
+		{units.Percent, "12.5 %", 0.125},
+		{units.Percent, "150 percent", 1.5},
+		{units.Percent, "-5%", -0.05},
+	}
+
+	for _, tt := range tests {
+		got, err := units.Parse(tt.kind, tt.input)
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %v", tt.kind, tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %q: expected %v, got %v", tt.kind, tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestUnitsParseInvalid(t *testing.T) {
+	tests := []struct {
+		kind  string
+		input string
+	}{
+		{units.Duration, ""},
+		{units.Duration, "soon"},
+		{units.Duration, "5 fortnights"},
+		{units.Duration, "5m later"},
+		{units.Duration, "1,5h"},
+		{units.Bytes, "1.5 GBs"},
+		{units.Bytes, "1GB 2MB"},
+		{units.Bytes, "-1KB"},
+		{units.Bytes, "lots"},
+		{units.Percent, "20%%"},
+		{units.Percent, "a fifth"},
+		{"weight", "5kg"},
+	}
+
+	for _, tt := range tests {
+		if got, err := units.Parse(tt.kind, tt.input); err == nil {
+			t.Errorf("%s %q: expected an error, got %v", tt.kind, tt.input, got)
+		}
+	}
+
+	_, err := units.Parse(units.Duration, "soon")
+	if err == nil || !strings.Contains(err.Error(), "1h30m") {
+		t.Errorf("Expected the error to show examples, got %v", err)
+	}
+}
+
+func TestUnitsFormat(t *testing.T) {
+	tests := []struct {
+		kind string
+		n    float64
+		want string
+	}{
+		{units.Duration, 300, "5m0s"},
+		{units.Duration, 0.25, "250ms"},
+		{units.Bytes, 1 << 30, "1 GiB"},
+		{units.Bytes, 1.5e9, "1.5 GB"},
+		{units.Bytes, 65536, "64 KiB"},
+		{units.Bytes, 1000, "1 kB"},
+		{units.Bytes, 1001, "1001 B"},
+		{units.Percent, 0.07, "7%"},
+		{units.Percent, 0.125, "12.5%"},
+	}
+
+	for _, tt := range tests {
+		if got := units.Format(tt.kind, tt.n); got != tt.want {
+			t.Errorf("%s %v: expected %q, got %q", tt.kind, tt.n, tt.want, got)
+		}
+	}
+}
+
+func TestUnitsFormatElapsed(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "0s"},
+		{0.9, "0s"},
+		{-3, "0s"},
+		{59.5, "59s"},
+		{133, "2m 13s"},
+		{3600, "1h 0m"},
+		{3905, "1h 5m"},
+		{90061, "1d 1h"},
+	}
+	for _, tt := range tests {
+		if got := units.FormatElapsed(tt.seconds); got != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.seconds, tt.want, got)
+		}
+	}
+}
+
+func TestUnitsInput(t *testing.T) {
+	tests := []struct {
+		args      string
+		responses []string
+		raw       string
+		value     float64
+		unit      string
+	}{
+		{`"units":"duration"`, []string{"5 min"}, "5 min", 300, "seconds"},
+		{`"units":"duration","minimum":60`, []string{"soon", "30s", "2m"}, "2m", 120, "seconds"},
+		{`"units":"bytes","maximum":2e9`, []string{"3GB", "1.5GB"}, "1.5GB", 1.5e9, "bytes"},
+		{`"units":"percent"`, []string{" 20% "}, "20%", 0.2, "fraction"},
+		{`"units":"duration","default":"1h"`, []string{""}, "1h", 3600, "seconds"},
+	}
+
+	for _, tt := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How long?","type":"number",` + tt.args + `}}}`
+
+		provider := &fakeProvider{responses: tt.responses}
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", provider)
+
+		messages := parseMessages(t, runServer(t, srv, input).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+		if text := result["content"].([]interface{})[0].(map[string]interface{})["text"]; text != tt.raw {
+			t.Errorf("%s %q: expected text %q, got %v", tt.args, tt.responses, tt.raw, text)
+		}
+		structured, _ := result["structuredContent"].(map[string]interface{})
+		if structured["value"] != tt.value || structured["unit"] != tt.unit || structured["raw"] != tt.raw {
+			t.Errorf("%s %q: unexpected structuredContent %v", tt.args, tt.responses, structured)
+		}
+	}
+}
+
+func TestUnitsBoundsMessage(t *testing.T) {
+	min := 60.0
+	req := server.NewPromptRequest("How long?", "tty")
+	req.MakeNumeric(server.NumberOptions{Units: units.Duration, Minimum: &min})
+
+	if _, err := req.Validate("30s"); err == nil || err.Error() != "Please enter at least 1m0s" {
+		t.Errorf("Expected the bound in duration terms, got %v", err)
+	}
+	if _, err := req.Validate("a while"); err == nil || !strings.Contains(err.Error(), "90s, 5m, 1h30m") {
+		t.Errorf("Expected examples for an invalid duration, got %v", err)
+	}
+}
+
+func TestUnitsInvalidArguments(t *testing.T) {
+	tests := []string{
+		`"units":"duration"`,
+		`"type":"integer","units":"duration"`,
+		`"type":"text","units":"bytes"`,
+		`"type":"number","units":"weight"`,
+		`"type":"number","units":5`,
+		`"type":"number","units":"bytes","default":"lots"`,
+	}
+
+	for _, args := range tests {
+		input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"How much?",` + args + `}}}`
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+		errorObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errorObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected -32602, got %v", args, errorObj)
+		}
+	}
+}
+
+func TestUnitsTTYAndWeb(t *testing.T) {
+	term := newFakeTerminal("later\n1.5h\n")
+	req := server.NewPromptRequest("TTL?", "tty")
+	req.MakeNumeric(server.NumberOptions{Units: units.Duration})
+
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil || answer != "1.5h" {
+		t.Fatalf("Expected 1.5h, got %q, %v", answer, err)
+	}
+	if output := term.output.String(); !strings.Contains(output, "(e.g. 90s, 5m, 1h30m, 2 hours, 1.5d)") {
+		t.Errorf("Expected the accepted formats in the prompt, got:\n%s", output)
+	}
+
+	req = server.NewPromptRequest("Size?", "web")
+	req.MakeNumeric(server.NumberOptions{Units: units.Bytes})
+	rec := httptest.NewRecorder()
+	server.NewWebInputHandler(req).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `<input type="text" name="response"`) || !strings.Contains(body, `placeholder="e.g. 512, 64KiB, 1.5GB, 2 MB"`) {
+		t.Errorf("Expected a text field with examples, got:\n%s", body)
+	}
+}
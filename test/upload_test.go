@@ -0,0 +1,225 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func uploadCall(args map[string]interface{}) string {
+	args["prompt"] = "Send the log"
+	args["accept_file"] = true
+	data, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "user_input", "arguments": args},
+	})
+	return string(data)
+}
+
+// postFile submits a file from the upload form h serves.
+func postFile(t *testing.T, h http.Handler, name string, data []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("csrf", formToken(h, "/submit"))
+	if name != "" {
+		part, err := form.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/submit", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func uploadPrompt(t *testing.T, maxBytes int64) *server.PromptRequest {
+	req := server.NewPromptRequest("Send the log", "web")
+	if err := req.AcceptFile(server.UploadOptions{MaxBytes: maxBytes, ReturnAs: server.ReturnBase64}); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestUploadWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(uploadPrompt(t, 1024))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	for _, want := range []string{`enctype="multipart/form-data"`, `<input type="file" name="file" id="upload" data-max="1024"`, "Files up to 1 KiB"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %q on the page, got:\n%s", want, rec.Body.String())
+		}
+	}
+
+	// No file chosen
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postFile(t, handler, "", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Please choose a file to upload") {
+		t.Errorf("Expected a missing file to be reported, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postFile(t, handler, "build.txt", []byte("ok\n")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the file to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	answer, err := handler.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file server.UploadedFile
+	if err := json.Unmarshal([]byte(answer), &file); err != nil || file.Name != "build.txt" || string(file.Data) != "ok\n" || !strings.HasPrefix(file.MimeType, "text/plain") {
+		t.Errorf("Expected build.txt, got %+v (%v)", file, err)
+	}
+}
+
+func TestUploadWebTooLarge(t *testing.T) {
+	handler := server.NewWebInputHandler(uploadPrompt(t, 1024))
+
+	// The page comes back with the error, and the prompt still waits
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postFile(t, handler, "big.bin", bytes.Repeat([]byte("x"), 1025)))
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), `<div class="error">The file is larger than 1 KiB; choose a smaller one</div>`) || !strings.Contains(rec.Body.String(), `type="file"`) {
+		t.Errorf("Expected the form with an error, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postFile(t, handler, "small.bin", bytes.Repeat([]byte("x"), 1024)))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected a file at the cap to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUploadTTY(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), bytes.Repeat([]byte("x"), 2048), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := server.NewPromptRequest("Send the notes", "tty")
+	req.AcceptFile(server.UploadOptions{MaxBytes: 1024, ReturnAs: server.ReturnPath})
+	term := newFakeTerminal(dir + "\n" + filepath.Join(dir, "big.txt") + "\n" + path + "\n")
+	answer, err := ttyProvider(term).GetInput(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := term.output.String()
+	for _, want := range []string{"up to 1 KiB", "File: ", "is not a regular file", "The file is larger than 1 KiB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q on the terminal, got:\n%s", want, out)
+		}
+	}
+	var file server.UploadedFile
+	if err := json.Unmarshal([]byte(answer), &file); err != nil || file.Name != "notes.txt" || string(file.Data) != "hello" {
+		t.Errorf("Expected notes.txt, got %+v (%v)", file, err)
+	}
+}
+
+func TestUploadResult(t *testing.T) {
+	dir := t.TempDir()
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	if err := os.WriteFile(filepath.Join(dir, "shot.png"), png, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data.bin"), []byte{0, 1, 2}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Images come back as image content
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: filepath.Join(dir, "shot.png")})
+	result := findResponse(t, parseMessages(t, runServer(t, srv, uploadCall(map[string]interface{}{})).String()), 1)["result"].(map[string]interface{})
+	content := result["content"].([]interface{})
+	image := content[1].(map[string]interface{})
+	if image["type"] != "image" || image["mimeType"] != "image/png" || image["data"] != base64.StdEncoding.EncodeToString(png) {
+		t.Errorf("Expected an image block, got %v", image)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["name"] != "shot.png" || structured["size"] != float64(len(png)) || structured["returnAs"] != "base64" {
+		t.Errorf("Expected the file's details, got %v", structured)
+	}
+
+	// Anything else is an embedded resource
+	srv = &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: filepath.Join(dir, "data.bin")})
+	result = findResponse(t, parseMessages(t, runServer(t, srv, uploadCall(map[string]interface{}{})).String()), 1)["result"].(map[string]interface{})
+	resource := result["content"].([]interface{})[1].(map[string]interface{})
+	if inner, _ := resource["resource"].(map[string]interface{}); resource["type"] != "resource" || inner["blob"] != "AAEC" || inner["mimeType"] != "application/octet-stream" {
+		t.Errorf("Expected an embedded resource, got %v", resource)
+	}
+
+	// Or the file is saved and its path returned
+	srv = &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: filepath.Join(dir, "data.bin")})
+	result = findResponse(t, parseMessages(t, runServer(t, srv, uploadCall(map[string]interface{}{"return_as": "path"})).String()), 1)["result"].(map[string]interface{})
+	path, _ := result["structuredContent"].(map[string]interface{})["path"].(string)
+	defer os.RemoveAll(filepath.Dir(path))
+	if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, []byte{0, 1, 2}) || filepath.Base(path) != "data.bin" || filepath.Dir(path) == dir {
+		t.Errorf("Expected a temp copy of data.bin, got %s (%v)", path, err)
+	}
+	if len(result["content"].([]interface{})) != 1 {
+		t.Errorf("Expected only the path to be returned, got %v", result["content"])
+	}
+}
+
+func TestUploadCap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.bin")
+	if err := os.WriteFile(path, make([]byte, 2048), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := &server.MCPServer{}
+	srv.SetConfig(server.Config{MaxUploadBytes: 1024})
+	srv.SetInputProvider("tty", &fakeProvider{response: path})
+
+	resp := findResponse(t, parseMessages(t, runServer(t, srv, uploadCall(map[string]interface{}{})).String()), 1)
+	if data, _ := json.Marshal(resp); !strings.Contains(string(data), "larger than 1 KiB") {
+		t.Errorf("Expected the configured cap to apply, got %s", data)
+	}
+	if err := (server.Config{MaxUploadBytes: -1}).Validate(); err == nil {
+		t.Error("Expected a negative cap to be refused")
+	}
+}
+
+func TestUploadArguments(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{"return_as": "url"},
+		{"multiline": true},
+		{"confirmation_phrase": "delete"},
+		{"accept_file": "yes"},
+	} {
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", &fakeProvider{response: "x"})
+		resp := findResponse(t, parseMessages(t, runServer(t, srv, uploadCall(args)).String()), 1)
+		if resp["error"] == nil {
+			t.Errorf("%v: expected an invalid params error, got %v", args, resp)
+		}
+	}
+
+	// return_as needs a file to return
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", &fakeProvider{response: "x"})
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Name?","return_as":"path"}}}`
+	if resp := findResponse(t, parseMessages(t, runServer(t, srv, input).String()), 1); resp["error"] == nil {
+		t.Errorf("Expected return_as without accept_file to be refused, got %v", resp)
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+func TestWaitingWeb(t *testing.T) {
+	req := server.NewPromptRequest("Deploy?", "web")
+	req.Asked = time.Now().Add(-133 * time.Second)
+	req.Timeout = time.Minute
+	page := pageGet(server.NewWebInputHandler(req), "/").Body.String()
+	want := `<div class="waiting" id="waiting" data-asked="` + unixMillis(req.Asked) + `" data-format="Asked %s ago">Asked 2m 13s ago</div>`
+	if !strings.Contains(page, want) {
+		t.Errorf("Expected %s on the page, got:\n%s", want, page)
+	}
+	if !strings.Contains(page, `<div class="deadline-banner" id="deadline-banner" role="alert" hidden>`) {
+		t.Errorf("Expected a hidden deadline banner, got:\n%s", page)
+	}
+
+	// Without a timeout there is nothing to warn about, but the wait is
+	// still shown, in the page's language
+	req = server.NewPromptRequest("Deploy?", "web")
+	req.Timeout = 0
+	req.Locale = "de"
+	page = pageGet(server.NewWebInputHandler(req), "/").Body.String()
+	if !strings.Contains(page, `>Gefragt vor 0s</div>`) || strings.Contains(page, "deadline-banner") {
+		t.Errorf("Expected the wait without a banner, got:\n%s", page)
+	}
+
+	js := webAsset(t, "input.js")
+	for _, want := range []string{"used >= 0.75", "used >= 0.9"} {
+		if !strings.Contains(js, want) {
+			t.Errorf("Expected the escalation at %s, got:\n%s", want, js)
+		}
+	}
+}
+
+func TestWaitingTTY(t *testing.T) {
+	req := server.NewPromptRequest("Anyone there?", "tty")
+	req.Asked = time.Now().Add(-133 * time.Second)
+	term := newFakeTerminal("yes\n")
+	if _, err := server.Ask(context.Background(), ttyProvider(term), req); err != nil {
+		t.Fatal(err)
+	}
+	out := term.output.String()
+	if !regexp.MustCompile(`\(Asked 2m 13s ago \(\d\d:\d\d:\d\d\)\)\n`).MatchString(out) || strings.Count(out, "Asked") != 1 {
+		t.Errorf("Expected the ask time once, got:\n%s", out)
+	}
+
+	// A question shown as it is asked just gives the time
+	req.Asked = time.Now()
+	term = newFakeTerminal("yes\n")
+	server.Ask(context.Background(), ttyProvider(term), req)
+	if out := term.output.String(); !regexp.MustCompile(`\(Asked at \d\d:\d\d:\d\d\)\n`).MatchString(out) {
+		t.Errorf("Expected the ask time, got:\n%s", out)
+	}
+}
+
+// unixMillis writes t in Unix milliseconds, as pages carry times.
+func unixMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+type pageState struct {
+	State   string `json:"state"`
+	Message string `json:"message"`
+}
+
+// watchPage opens the page's event stream, returning a channel with the
+// state event once it arrives.
+func watchPage(t *testing.T, ts *httptest.Server) <-chan pageState {
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+
+	states := make(chan pageState, 1)
+	go func() {
+		defer resp.Body.Close()
+		lines := bufio.NewScanner(resp.Body)
+		event := ""
+		for lines.Scan() {
+			line := lines.Text()
+			if name, ok := strings.CutPrefix(line, "event: "); ok {
+				event = name
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok && event == "state" {
+				var state pageState
+				json.Unmarshal([]byte(data), &state)
+				states <- state
+				return
+			}
+		}
+		close(states)
+	}()
+	return states
+}
+
+func TestWebEventsAnswered(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `<div class="state-notice" id="state-notice" role="status" hidden></div>`) {
+		t.Errorf("Expected the state notice on the page, got:\n%s", rec.Body.String())
+	}
+	if js := webAsset(t, "input.js"); !strings.Contains(js, `new EventSource(base + '/events')`) {
+		t.Errorf("Expected the page to watch its events, got:\n%s", js)
+	}
+
+	// Another tab answering acknowledges to every page
+	states := watchPage(t, ts)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"Ada"}}))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "window.close()") {
+		t.Errorf("Expected the answer to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if state := <-states; state.State != "answered" || state.Message != "Your response has been submitted. You can close this tab." {
+		t.Errorf("Expected an acknowledgement, got %+v", state)
+	}
+	if answer, err := handler.Wait(context.Background()); err != nil || answer != "Ada" {
+		t.Errorf("Expected Ada, got %q (%v)", answer, err)
+	}
+
+	// A page opened later learns it at once
+	if state := <-watchPage(t, ts); state.State != "answered" {
+		t.Errorf("Expected the answer to be reported, got %+v", state)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"Bob"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Already answered</h1>") {
+		t.Errorf("Expected a second answer to be refused, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWebEventsExpired(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     func() (context.Context, context.CancelFunc)
+		message string
+		late    string
+	}{
+		{"timeout", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 0)
+		}, "Timed out. You can close this tab.", "<h1>Timed out</h1>"},
+		{"cancelled", func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}, "Cancelled by the agent. You can close this tab.", "<h1>Cancelled</h1>"},
+		{"elsewhere", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			return ctx, func() { cancel(server.ErrAnsweredElsewhere) }
+		}, "Answered elsewhere. You can close this tab.", "<h1>Answered elsewhere</h1>"},
+		{"terminal", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			return ctx, func() { cancel(&server.AnsweredElsewhereError{Method: "tty"}) }
+		}, "Answered in the terminal. You can close this tab.", "<h1>Answered in the terminal</h1>"},
+	}
+
+	for _, tt := range tests {
+		handler := server.NewWebInputHandler(server.NewPromptRequest("Name?", "web"))
+		ts := httptest.NewServer(handler)
+		states := watchPage(t, ts)
+
+		ctx, cancel := tt.ctx()
+		cancel()
+		if _, err := handler.Wait(ctx); err == nil {
+			t.Errorf("%s: expected Wait to fail", tt.name)
+		}
+		if state := <-states; state.State != "expired" || state.Message != tt.message {
+			t.Errorf("%s: expected %q, got %+v", tt.name, tt.message, state)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"Ada"}}))
+		if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), tt.late) {
+			t.Errorf("%s: expected a late submission to be turned away, got %d:\n%s", tt.name, rec.Code, rec.Body.String())
+		}
+		ts.Close()
+	}
+}
+
+func TestWebEventsFailed(t *testing.T) {
+	req := server.NewPromptRequest("Code?", "web")
+	req.Validate = func(response string) (string, error) {
+		return "", &server.ValidationError{Attempts: 1, Reason: "not a code"}
+	}
+	handler := server.NewWebInputHandler(req)
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	states := watchPage(t, ts)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"x"}}))
+	if state := <-states; state.State != "failed" || state.Message != "Too many invalid attempts. You can close this tab." {
+		t.Errorf("Expected the failure to be reported, got %+v (%d)", state, rec.Code)
+	}
+	if _, err := handler.Wait(context.Background()); err == nil || !strings.Contains(err.Error(), "not a code") {
+		t.Errorf("Expected the validation error, got %v", err)
+	}
+}
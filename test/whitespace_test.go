@@ -0,0 +1,68 @@
+package test
+
+import (
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+func TestAnswerWhitespaceHandling(t *testing.T) {
+	const indented = "\n    func main() {\n        run()\n    }\n  "
+
+	tests := []struct {
+		name       string
+		method     string
+		extra      string
+		response   string
+		want       string
+		wantTrim   string
+		wantDedent bool
+	}{
+		{"tty default trims both", "tty", ``, "  hello  \n", "hello", "both", false},
+		{"web default keeps everything", "web", ``, "  hello  \n", "  hello  \n", "none", false},
+		{"explicit both", "web", `,"trim":"both"`, "  hello  \n", "hello", "both", false},
+		{"trailing keeps indentation", "tty", `,"trim":"trailing"`, "    indented code \t\n", "    indented code", "trailing", false},
+		{"none on tty", "tty", `,"trim":"none"`, "  hello  ", "  hello  ", "none", false},
+		{"dedent with none", "web", `,"dedent":true`, indented, "\nfunc main() {\n    run()\n}\n", "none", true},
+		{"dedent with both", "web", `,"dedent":true,"trim":"both"`, indented, "func main() {\n    run()\n}", "both", true},
+		{"dedent with trailing", "tty", `,"dedent":true,"trim":"trailing"`, indented, "\nfunc main() {\n    run()\n}", "trailing", true},
+		{"dedent with tabs", "web", `,"dedent":true`, "\tone\n\t\ttwo", "one\n\ttwo", "none", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Paste code","method":"` + tt.method + `"` + tt.extra + `}}}`
+
+			srv := &server.MCPServer{}
+			srv.SetInputProvider(tt.method, &fakeProvider{response: tt.response})
+
+			messages := parseMessages(t, runServer(t, srv, input).String())
+			result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+			content := result["content"].([]interface{})
+			if got := content[0].(map[string]interface{})["text"]; got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+
+			meta := result["_meta"].(map[string]interface{})
+			normalization := meta["normalization"].(map[string]interface{})
+			if normalization["trim"] != tt.wantTrim {
+				t.Errorf("Expected trim %q recorded, got %v", tt.wantTrim, normalization["trim"])
+			}
+			if normalization["dedent"] != tt.wantDedent {
+				t.Errorf("Expected dedent %v recorded, got %v", tt.wantDedent, normalization["dedent"])
+			}
+		})
+	}
+}
+
+func TestInvalidTrimRejected(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Name?","trim":"left"}}}`
+
+	messages := parseMessages(t, runServer(t, &server.MCPServer{}, input).String())
+
+	errorObj := findResponse(t, messages, 1)["error"].(map[string]interface{})
+	if errorObj["code"] != float64(-32602) {
+		t.Errorf("Expected error code -32602, got %v", errorObj["code"])
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"prompt-mcp/server"
+)
+
+// waitingProvider waits for an answer that never comes, saying on asked
+// when it starts.
+type waitingProvider struct {
+	asked chan *server.PromptRequest
+}
+
+func (p waitingProvider) GetInput(ctx context.Context, req *server.PromptRequest) (string, error) {
+	p.asked <- req
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestWithdrawWeb(t *testing.T) {
+	handler := server.NewWebInputHandler(server.NewPromptRequest("Deploy?", "web"))
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	states := watchPage(t, ts)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(server.ErrWithdrawn)
+	if _, err := handler.Wait(ctx); err == nil {
+		t.Fatal("Expected the wait to end without an answer")
+	}
+	if state := <-states; state.State != "withdrawn" || state.Message != "The agent withdrew this request. You can close this tab." {
+		t.Errorf("Expected the page told the request was withdrawn, got %+v", state)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"response": {"yes"}}))
+	if rec.Code != http.StatusGone || !strings.Contains(rec.Body.String(), "<h1>Withdrawn</h1>") {
+		t.Errorf("Expected a late answer turned away, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWithdrawTTY(t *testing.T) {
+	// The terminal never answers; the test ends its reads
+	input, typing := io.Pipe()
+	defer typing.Close()
+	term := &fakeTerminal{input: input}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(20*time.Millisecond, func() { cancel(server.ErrWithdrawn) })
+	if _, err := ttyProvider(term).GetInput(ctx, server.NewPromptRequest("Deploy?", "tty")); err == nil {
+		t.Fatal("Expected the prompt abandoned")
+	}
+	if out := term.output.String(); !strings.Contains(out, "(The agent withdrew this request)\n") {
+		t.Errorf("Expected the terminal to say the request was withdrawn, got %q", out)
+	}
+}
+
+func TestWithdrawCall(t *testing.T) {
+	provider := waitingProvider{asked: make(chan *server.PromptRequest, 1)}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", provider)
+
+	stdin, client := io.Pipe()
+	stdout, out := io.Pipe()
+	srv.SetIO(stdin, out, io.Discard)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go srv.Start(ctx)
+	defer client.Close()
+
+	send := func(message string) {
+		if _, err := io.WriteString(client, message+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"user_input","arguments":{"prompt":"Deploy?"}}}`)
+	select {
+	case <-provider.asked:
+	case <-ctx.Done():
+		t.Fatal("Expected the prompt asked")
+	}
+
+	// The cancelled call gets no result, and the next call's is its own
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"user pressed Esc"}}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	var msg struct {
+		ID interface{} `json:"id"`
+	}
+	if err := json.NewDecoder(bufio.NewReader(stdout)).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != 2.0 {
+		t.Errorf("Expected only the second call answered, got a message for %v", msg.ID)
+	}
+}
@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prompt-mcp/server"
+)
+
+var wizardSteps = []interface{}{
+	map[string]interface{}{"id": "env", "prompt": "Which environment?", "type": "choice", "options": []string{"staging", "production"}},
+	map[string]interface{}{"id": "confirm", "prompt": "Confirm the production deploy", "type": "phrase", "phrase": "production",
+		"when": map[string]interface{}{"step": "env", "equals": "production"}},
+	map[string]interface{}{"id": "rollout", "prompt": "Rollout percentage?", "type": "number"},
+}
+
+func wizardCall(t *testing.T, args map[string]interface{}) string {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      "user_wizard",
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestUserWizardBranches(t *testing.T) {
+	tests := []struct {
+		input   string
+		answers map[string]interface{}
+		skipped []interface{}
+	}{
+		{"staging\n25\n", map[string]interface{}{"env": "staging", "rollout": float64(25)}, []interface{}{"confirm"}},
+		{"2\nprod\nproduction\n10\n", map[string]interface{}{"env": "production", "confirm": "production", "rollout": float64(10)}, []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		term := newFakeTerminal(tt.input)
+		srv := &server.MCPServer{}
+		srv.SetInputProvider("tty", ttyProvider(term))
+
+		messages := parseMessages(t, runServer(t, srv, wizardCall(t, map[string]interface{}{"steps": wizardSteps})).String())
+		result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+		structured, _ := result["structuredContent"].(map[string]interface{})
+
+		if !reflect.DeepEqual(structured["answers"], tt.answers) || !reflect.DeepEqual(structured["skipped"], tt.skipped) {
+			t.Errorf("%q: unexpected structuredContent %v", tt.input, result)
+		}
+		out := term.output.String()
+		if asked := strings.Contains(out, `type "production"`); asked != (len(tt.skipped) == 0) {
+			t.Errorf("%q: phrase step asked %v, output %q", tt.input, asked, out)
+		}
+	}
+}
+
+func TestUserWizardPartialTimeout(t *testing.T) {
+	term := &fakeTerminal{input: io.MultiReader(strings.NewReader("staging\n"), blockingReader{})}
+	srv := &server.MCPServer{}
+	srv.SetInputProvider("tty", ttyProvider(term))
+
+	messages := parseMessages(t, runServer(t, srv, wizardCall(t, map[string]interface{}{"steps": wizardSteps, "timeout": 0.2})).String())
+	result := findResponse(t, messages, 1)["result"].(map[string]interface{})
+
+	if result["isError"] != true {
+		t.Fatalf("Expected a timeout result, got %v", result)
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if !reflect.DeepEqual(structured["answers"], map[string]interface{}{"env": "staging"}) || structured["timedOut"] != true {
+		t.Errorf("Unexpected structuredContent %v", structured)
+	}
+}
+
+func TestUserWizardWeb(t *testing.T) {
+	steps := []server.FormField{
+		{Name: "env", Label: "Which environment?", Type: server.FieldSelect, Options: []string{"staging", "production"}},
+		{Name: "notify", Label: "Notify?", Type: server.FieldBoolean},
+		{Name: "channel", Label: "Channel", Type: server.FieldText, When: &server.StepCondition{Field: "notify", Equals: true}},
+	}
+	prompt := server.NewWizardPrompt("Deploy", "web", steps)
+	handler := server.NewWebInputHandler(prompt)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `data-when="notify" data-equals="yes"`) {
+		t.Errorf("Expected the conditional step to be disclosed progressively, got:\n%s", body)
+	}
+
+	// A draft for a step whose condition no longer holds is dropped
+	handler.ServeHTTP(httptest.NewRecorder(), postForm(t, handler, "/draft", url.Values{"field.env": {"staging"}, "field.notify": {"no"}, "field.channel": {"#ops"}}))
+	if values := prompt.Partial.Values(); !reflect.DeepEqual(values, map[string]interface{}{"env": "staging", "notify": false}) {
+		t.Errorf("Unexpected draft values %v", values)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, postForm(t, handler, "/submit", url.Values{"field.env": {"production"}, "field.notify": {"yes"}, "field.channel": {"#deploys"}}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the wizard to be accepted, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	answer, err := handler.Wait(context.Background())
+	if err != nil || answer != `{"channel":"#deploys","env":"production","notify":true}` {
+		t.Errorf("Unexpected answer %q (%v)", answer, err)
+	}
+}
+
+func TestUserWizardInvalidSteps(t *testing.T) {
+	tests := map[string]interface{}{
+		"missing":         nil,
+		"empty":           []interface{}{},
+		"no prompt":       []interface{}{map[string]interface{}{"id": "a"}},
+		"duplicate id":    []interface{}{map[string]interface{}{"id": "a", "prompt": "A"}, map[string]interface{}{"id": "a", "prompt": "B"}},
+		"phrase missing":  []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "type": "phrase"}},
+		"later step":      []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "when": map[string]interface{}{"step": "b", "equals": "x"}}, map[string]interface{}{"id": "b", "prompt": "B"}},
+		"no equals":       []interface{}{map[string]interface{}{"id": "a", "prompt": "A"}, map[string]interface{}{"id": "b", "prompt": "B", "when": map[string]interface{}{"step": "a"}}},
+		"wrong type":      []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "type": "boolean"}, map[string]interface{}{"id": "b", "prompt": "B", "when": map[string]interface{}{"step": "a", "equals": "yes"}}},
+		"not an option":   []interface{}{map[string]interface{}{"id": "a", "prompt": "A", "type": "choice", "options": []string{"x"}}, map[string]interface{}{"id": "b", "prompt": "B", "when": map[string]interface{}{"step": "a", "equals": "y"}}},
+		"when not object": []interface{}{map[string]interface{}{"id": "a", "prompt": "A"}, map[string]interface{}{"id": "b", "prompt": "B", "when": "a"}},
+	}
+
+	for name, steps := range tests {
+		args := map[string]interface{}{}
+		if steps != nil {
+			args["steps"] = steps
+		}
+		messages := parseMessages(t, runServer(t, &server.MCPServer{}, wizardCall(t, args)).String())
+		errObj, ok := findResponse(t, messages, 1)["error"].(map[string]interface{})
+		if !ok || errObj["code"] != float64(-32602) {
+			t.Errorf("%s: expected an invalid params error, got %v", name, errObj)
+		}
+	}
+}
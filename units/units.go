@@ -0,0 +1,262 @@
+// Package units parses quantities the way people type them, such as "5 min",
+// "1.5GB" or "20%", and normalizes them to a canonical unit: seconds for
+// durations, bytes for sizes and a fraction for percentages.
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of quantity.
+const (
+	Duration = "duration"
+	Bytes    = "bytes"
+	Percent  = "percent"
+)
+
+// Kinds lists every supported kind.
+func Kinds() []string {
+	return []string{Duration, Bytes, Percent}
+}
+
+// Valid reports whether kind is supported.
+func Valid(kind string) bool {
+	switch kind {
+	case Duration, Bytes, Percent:
+		return true
+	}
+	return false
+}
+
+// Unit names the canonical unit Parse returns for kind.
+func Unit(kind string) string {
+	switch kind {
+	case Duration:
+		return "seconds"
+	case Bytes:
+		return "bytes"
+	case Percent:
+		return "fraction"
+	}
+	return ""
+}
+
+// Examples returns a few accepted ways of writing a quantity of kind.
+func Examples(kind string) []string {
+	switch kind {
+	case Duration:
+		return []string{"90s", "5m", "1h30m", "2 hours", "1.5d"}
+	case Bytes:
+		return []string{"512", "64KiB", "1.5GB", "2 MB"}
+	case Percent:
+		return []string{"20%", "12.5%", "20"}
+	}
+	return nil
+}
+
+// quantity is a number optionally followed by a unit; the number is written
+// with '.' as the decimal separator and no grouping.
+var quantity = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*([a-zµμ%]*)`)
+
+// durationUnits maps duration unit spellings to seconds.
+var durationUnits = map[string]float64{
+	"ns": 1e-9, "nanosecond": 1e-9, "nanoseconds": 1e-9,
+	"us": 1e-6, "µs": 1e-6, "μs": 1e-6, "microsecond": 1e-6, "microseconds": 1e-6,
+	"ms": 1e-3, "msec": 1e-3, "msecs": 1e-3, "millisecond": 1e-3, "milliseconds": 1e-3,
+	"s": 1, "sec": 1, "secs": 1, "second": 1, "seconds": 1,
+	"m": 60, "min": 60, "mins": 60, "minute": 60, "minutes": 60,
+	"h": 3600, "hr": 3600, "hrs": 3600, "hour": 3600, "hours": 3600,
+	"d": 86400, "day": 86400, "days": 86400,
+	"w": 604800, "wk": 604800, "wks": 604800, "week": 604800, "weeks": 604800,
+}
+
+// byteUnits maps size unit spellings to bytes. Bare prefixes and SI units
+// are powers of 1000; the "i" units are powers of 1024.
+var byteUnits = map[string]float64{
+	"b": 1, "byte": 1, "bytes": 1,
+	"k": 1e3, "kb": 1e3, "kilobyte": 1e3, "kilobytes": 1e3,
+	"m": 1e6, "mb": 1e6, "megabyte": 1e6, "megabytes": 1e6,
+	"g": 1e9, "gb": 1e9, "gigabyte": 1e9, "gigabytes": 1e9,
+	"t": 1e12, "tb": 1e12, "terabyte": 1e12, "terabytes": 1e12,
+	"p": 1e15, "pb": 1e15, "petabyte": 1e15, "petabytes": 1e15,
+	"ki": 1 << 10, "kib": 1 << 10,
+	"mi": 1 << 20, "mib": 1 << 20,
+	"gi": 1 << 30, "gib": 1 << 30,
+	"ti": 1 << 40, "tib": 1 << 40,
+	"pi": 1 << 50, "pib": 1 << 50,
+}
+
+// percentUnits maps percentage spellings to their fraction; a bare number
+// is a percentage too.
+var percentUnits = map[string]float64{
+	"": 0.01, "%": 0.01, "pct": 0.01, "percent": 0.01,
+}
+
+// Parse reads s as a quantity of kind and returns it in the canonical unit.
+// A bare number is taken in the kind's natural unit: seconds, bytes or
+// percent. Sizes are rounded to whole bytes and can't be negative.
+func Parse(kind, s string) (float64, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	sign := 1.0
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		if text[0] == '-' {
+			sign = -1
+		}
+		text = strings.TrimSpace(text[1:])
+	}
+
+	var n float64
+	var ok bool
+	switch kind {
+	case Duration:
+		n, ok = parseDuration(text)
+	case Bytes:
+		n, ok = parseSingle(text, byteUnits, 1)
+		n = math.Round(n)
+		if ok && sign < 0 && n != 0 {
+			return 0, fmt.Errorf("%q is negative; sizes can't be", strings.TrimSpace(s))
+		}
+	case Percent:
+		n, ok = parseSingle(text, percentUnits, 0.01)
+	default:
+		return 0, fmt.Errorf("unknown kind %q", kind)
+	}
+	if !ok {
+		return 0, fmt.Errorf("%q is not a valid %s; try e.g. %s", strings.TrimSpace(s), noun(kind), strings.Join(Examples(kind), ", "))
+	}
+	if math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, fmt.Errorf("%q is out of range", strings.TrimSpace(s))
+	}
+	return sign * n, nil
+}
+
+// parseDuration reads one or more number-unit pairs, as in "1h30m" or
+// "1 hour 30 minutes", or a bare number of seconds.
+func parseDuration(text string) (float64, bool) {
+	if text == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseFloat(text, 64); err == nil && quantity.MatchString(text) {
+		return n, true
+	}
+
+	total := 0.0
+	for text != "" {
+		m := quantity.FindStringSubmatch(text)
+		if m == nil {
+			return 0, false
+		}
+		scale, known := durationUnits[m[2]]
+		if !known {
+			return 0, false
+		}
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		total += n * scale
+		text = strings.TrimSpace(strings.TrimPrefix(text[len(m[0]):], ","))
+		text = strings.TrimSpace(strings.TrimPrefix(text, "and "))
+	}
+	return total, true
+}
+
+// parseSingle reads a number followed by an optional unit from table; a
+// bare number is scaled by bare.
+func parseSingle(text string, table map[string]float64, bare float64) (float64, bool) {
+	m := quantity.FindStringSubmatch(text)
+	if m == nil || len(m[0]) != len(text) {
+		return 0, false
+	}
+	scale := bare
+	if m[2] != "" {
+		var known bool
+		if scale, known = table[m[2]]; !known {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * scale, true
+}
+
+// Format writes n, in the canonical unit of kind, the way a person would
+// read it, e.g. "5m0s", "1 GiB" or "20%".
+func Format(kind string, n float64) string {
+	switch kind {
+	case Duration:
+		if math.Abs(n) < math.MaxInt64/1e9 {
+			return time.Duration(n * float64(time.Second)).String()
+		}
+	case Bytes:
+		return formatBytes(n)
+	case Percent:
+		return strconv.FormatFloat(math.Round(n*100*1e9)/1e9, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// FormatDuration writes seconds as a Go duration without the zero parts a
+// person wouldn't write: "24h" rather than "24h0m0s", "1h30m", "30s".
+func FormatDuration(seconds float64) string {
+	text := Format(Duration, seconds)
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// FormatElapsed writes how long something has been going on in its two
+// largest units, the way a person glancing at a clock reads it: "45s",
+// "2m 13s", "1h 5m", "3d 4h". Fractions of a second are dropped and
+// negative spans count as none.
+func FormatElapsed(seconds float64) string {
+	s := int64(math.Max(0, math.Floor(seconds)))
+	switch {
+	case s < 60:
+		return fmt.Sprintf("%ds", s)
+	case s < 3600:
+		return fmt.Sprintf("%dm %ds", s/60, s%60)
+	case s < 86400:
+		return fmt.Sprintf("%dh %dm", s/3600, s%3600/60)
+	}
+	return fmt.Sprintf("%dd %dh", s/86400, s%86400/3600)
+}
+
+// formatBytes uses the largest binary unit n is a whole multiple of, or
+// the largest decimal unit that needs at most three decimals.
+func formatBytes(n float64) string {
+	binary := []string{"PiB", "TiB", "GiB", "MiB", "KiB"}
+	decimal := []string{"PB", "TB", "GB", "MB", "kB"}
+	for i := range binary {
+		power := float64(len(binary) - i)
+		if size := math.Pow(1024, power); n >= size && math.Mod(n, size) == 0 {
+			return strconv.FormatFloat(n/size, 'f', -1, 64) + " " + binary[i]
+		}
+		if v := n / math.Pow(1000, power); v >= 1 && v*1000 == math.Round(v*1000) {
+			return strconv.FormatFloat(v, 'f', -1, 64) + " " + decimal[i]
+		}
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64) + " B"
+}
+
+// noun is how error messages refer to a quantity of kind.
+func noun(kind string) string {
+	switch kind {
+	case Bytes:
+		return "size"
+	case Percent:
+		return "percentage"
+	}
+	return kind
+}